@@ -9,39 +9,42 @@ import (
 
 	"github.com/evandrarf/dinacom-be/database"
 	"github.com/evandrarf/dinacom-be/internal/config"
+	"github.com/evandrarf/dinacom-be/internal/pkg/readiness"
 	"github.com/evandrarf/dinacom-be/internal/pkg/validate"
+	"github.com/gofiber/fiber/v2"
 )
 
 func main() {
 	viperConfig := config.NewViper()
 
 	log := config.NewLogger(viperConfig)
-	db := database.New(viperConfig)
-	validator := validate.NewValidator()
-	api := config.NewAPI(viperConfig, log)
-
-	// Run migrations
-	if err := database.Migrate(db); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	if err := config.LoadDifficulties(viperConfig); err != nil {
+		log.Fatalf("Failed to load difficulties: %v", err)
 	}
-	log.Info("Migrations completed successfully")
-
-	// Run seeders
-	if err := database.SeedQuestionBank(db); err != nil {
-		log.Fatalf("Failed to seed question bank: %v", err)
+	if err := config.ValidateAPIConfig(viperConfig); err != nil {
+		log.Fatalf("%v", err)
 	}
-	log.Info("Seeders completed successfully")
+	db, err := database.New(viperConfig)
+	if err != nil {
+		log.Fatalf("Failed to connect database: %v", err)
+	}
+	validator := validate.NewValidator()
+	api := config.NewAPI(viperConfig, log)
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 
 	defer stop()
 
+	// /readyz reports 503 until migrations and seeding below have finished
+	ready := readiness.New()
+
 	config.Bootstrap(&config.BootstrapConfig{
 		Config:    viperConfig,
 		Log:       log,
 		Api:       api,
 		Validator: validator,
 		DB:        db,
+		Readiness: ready,
 	})
 
 	listenAddr := ":8080"
@@ -52,6 +55,44 @@ func main() {
 		}
 	}()
 
+	// Prefork children run this same main; migrations, seeding and pruning happen once, in the primary process
+	if fiber.IsChild() {
+		log.Info("Prefork child process, startup jobs are left to the primary process")
+	} else {
+		// Auto migration is on by default; production should disable it and run `make migrate`
+		if database.AutoMigrateEnabled(viperConfig) {
+			// Run migrations
+			if err := database.Migrate(db); err != nil {
+				log.Fatalf("Failed to run migrations: %v", err)
+			}
+			log.Info("Migrations completed successfully")
+
+			// Run seeders
+			if err := database.Seed(db, viperConfig); err != nil {
+				log.Fatalf("Failed to seed question bank: %v", err)
+			}
+			log.Info("Seeders completed successfully")
+		} else {
+			if err := database.CheckTables(db); err != nil {
+				log.Fatalf("Auto migration disabled: %v", err)
+			}
+			log.Info("Auto migration disabled, schema check passed")
+		}
+
+		// Prune stale cached questions so they get regenerated
+		if viperConfig.GetBool("questions.prune_stale_on_startup") {
+			pruned, err := database.PruneStaleQuestions(db, viperConfig.GetInt("questions.max_age_days"))
+			if err != nil {
+				log.Errorf("Failed to prune stale questions: %v", err)
+			} else {
+				log.Infof("Pruned %d stale cached questions", pruned)
+			}
+		}
+	}
+
+	ready.SetReady()
+	log.Info("Startup completed, ready to serve traffic")
+
 	<-ctx.Done()
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/evandrarf/dinacom-be/database"
+	"github.com/evandrarf/dinacom-be/internal/config"
+)
+
+func main() {
+	viperConfig := config.NewViper()
+
+	log := config.NewLogger(viperConfig)
+	if err := config.ValidateMigrateConfig(viperConfig); err != nil {
+		log.Fatalf("%v", err)
+	}
+	db, err := database.New(viperConfig)
+	if err != nil {
+		log.Fatalf("Failed to connect database: %v", err)
+	}
+
+	// Seed templates may use configured difficulties
+	if err := config.LoadDifficulties(viperConfig); err != nil {
+		log.Fatalf("Failed to load difficulties: %v", err)
+	}
+
+	// Run migrations
+	if err := database.Migrate(db); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+	log.Info("Migrations completed successfully")
+
+	// Run seeders
+	if err := database.Seed(db, viperConfig); err != nil {
+		log.Fatalf("Failed to seed question bank: %v", err)
+	}
+	log.Info("Seeders completed successfully")
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/evandrarf/dinacom-be/internal/entity"
+	"gorm.io/gorm"
+)
+
+// backfill fills a column that was added after rows already existed. Every backfill is idempotent,
+// so Migrate runs all of them each time after the schema migration.
+type backfill struct {
+	name string
+	run  func(db *gorm.DB) (int64, error)
+}
+
+var backfills = []backfill{
+	{name: "chat_messages.kind", run: backfillFeedbackKind},
+}
+
+func runBackfills(db *gorm.DB) error {
+	for _, b := range backfills {
+		rows, err := b.run(db)
+		if err != nil {
+			return fmt.Errorf("backfill %s: %w", b.name, err)
+		}
+		if rows > 0 {
+			fmt.Printf("[MIGRATE] backfill %s updated %d rows\n", b.name, rows)
+		}
+	}
+	return nil
+}
+
+// legacyFeedbackHeader starts the report feedback posted by the default chat.feedback_template before
+// chat messages had a kind
+const legacyFeedbackHeader = "%Hasil Analisis Ujian Kamu%"
+
+// backfillFeedbackKind marks the report feedback of sessions from before chat_messages.kind existed, so
+// they are not sent a second one: the earliest assistant message with the feedback header per session,
+// for sessions that have no feedback message yet
+func backfillFeedbackKind(db *gorm.DB) (int64, error) {
+	firstFeedback := db.Model(&entity.ChatMessage{}).
+		Select("MIN(id)").
+		Where("role = ? AND kind = ? AND message LIKE ?", "assistant", entity.ChatMessageKindChat, legacyFeedbackHeader).
+		Group("session_id")
+	withFeedback := db.Model(&entity.ChatMessage{}).
+		Select("session_id").
+		Where("kind = ?", entity.ChatMessageKindFeedback)
+
+	result := db.Model(&entity.ChatMessage{}).
+		Where("id IN (?) AND session_id NOT IN (?)", firstFeedback, withFeedback).
+		UpdateColumn("kind", entity.ChatMessageKindFeedback)
+	return result.RowsAffected, result.Error
+}
@@ -2,13 +2,26 @@ package database
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
-func New(config *viper.Viper) *gorm.DB {
+// Defaults for the initial connection: retries after the first attempt and the first backoff (doubled per retry)
+const (
+	defaultConnectRetries = 5
+	defaultConnectBackoff = 2 * time.Second
+)
+
+// openDB opens a connection; a variable so a transient failure can be simulated
+var openDB = func(dsn string) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{})
+}
+
+func New(config *viper.Viper) (*gorm.DB, error) {
 	username := config.GetString("database.username")
 	password := config.GetString("database.password")
 	host := config.GetString("database.host")
@@ -34,11 +47,63 @@ func New(config *viper.Viper) *gorm.DB {
 		timezone,
 	)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// Server-side cap on any single statement so a runaway query cannot block a request indefinitely
+	if timeoutMs := config.GetInt("database.statement_timeout_ms"); timeoutMs > 0 {
+		dsn += fmt.Sprintf(" statement_timeout=%d", timeoutMs)
+	}
 
+	retries, backoff := retryConfig(config)
+	db, err := connectWithRetry(dsn, retries, backoff)
 	if err != nil {
-		panic(fmt.Errorf("failed to connect database: %w", err))
+		return nil, err
+	}
+
+	// Optional read replicas: queries go to replicas, writes stay on the primary
+	if replicaDSNs := config.GetStringSlice("database.replicas"); len(replicaDSNs) > 0 {
+		replicas := make([]gorm.Dialector, 0, len(replicaDSNs))
+		for _, replicaDSN := range replicaDSNs {
+			replicas = append(replicas, postgres.Open(replicaDSN))
+		}
+
+		if err := db.Use(dbresolver.Register(dbresolver.Config{
+			Replicas: replicas,
+			Policy:   dbresolver.RandomPolicy{},
+		})); err != nil {
+			return nil, fmt.Errorf("failed to register read replicas: %w", err)
+		}
 	}
 
-	return db
+	return db, nil
+}
+
+// retryConfig reads database.connect_retries and database.connect_backoff, shared by connecting and seeding
+func retryConfig(config *viper.Viper) (int, time.Duration) {
+	retries := defaultConnectRetries
+	if config.IsSet("database.connect_retries") {
+		retries = config.GetInt("database.connect_retries")
+	}
+	backoff := defaultConnectBackoff
+	if config.IsSet("database.connect_backoff") {
+		backoff = config.GetDuration("database.connect_backoff")
+	}
+	return retries, backoff
+}
+
+// connectWithRetry retries transient connection failures (e.g. database still starting) with exponential backoff
+func connectWithRetry(dsn string, retries int, backoff time.Duration) (*gorm.DB, error) {
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			fmt.Printf("[DATABASE] Connection attempt %d/%d failed: %v, retrying in %s\n", attempt, retries+1, lastErr, backoff)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		db, err := openDB(dsn)
+		if err == nil {
+			return db, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("failed to connect database after %d attempts: %w", retries+1, lastErr)
 }
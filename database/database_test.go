@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestConnectWithRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		retries      int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "first attempt succeeds", failures: 0, retries: 3, wantAttempts: 1},
+		{name: "transient failures recover", failures: 2, retries: 3, wantAttempts: 3},
+		{name: "gives up after retries", failures: 10, retries: 2, wantAttempts: 3, wantErr: true},
+		{name: "no retries", failures: 1, retries: 0, wantAttempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			original := openDB
+			t.Cleanup(func() { openDB = original })
+			openDB = func(string) (*gorm.DB, error) {
+				attempts++
+				if attempts <= tt.failures {
+					return nil, errors.New("connection refused")
+				}
+				return &gorm.DB{}, nil
+			}
+
+			db, err := connectWithRetry("dsn", tt.retries, time.Millisecond)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("connectWithRetry error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && db == nil {
+				t.Error("connectWithRetry returned no db")
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryConfig(t *testing.T) {
+	config := viper.New()
+	if retries, backoff := retryConfig(config); retries != defaultConnectRetries || backoff != defaultConnectBackoff {
+		t.Errorf("defaults = %d/%s, want %d/%s", retries, backoff, defaultConnectRetries, defaultConnectBackoff)
+	}
+
+	config.Set("database.connect_retries", 0)
+	config.Set("database.connect_backoff", "250ms")
+	if retries, backoff := retryConfig(config); retries != 0 || backoff != 250*time.Millisecond {
+		t.Errorf("configured = %d/%s, want 0/250ms", retries, backoff)
+	}
+}
+
+func TestStatementTimeoutDSN(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeoutMs int
+		want      string
+	}{
+		{name: "configured", timeoutMs: 250, want: " statement_timeout=250"},
+		{name: "disabled", timeoutMs: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dsn string
+			original := openDB
+			t.Cleanup(func() { openDB = original })
+			openDB = func(d string) (*gorm.DB, error) {
+				dsn = d
+				return &gorm.DB{}, nil
+			}
+
+			config := viper.New()
+			config.Set("database.statement_timeout_ms", tt.timeoutMs)
+			if _, err := New(config); err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if got := strings.Contains(dsn, "statement_timeout"); got != (tt.want != "") || (tt.want != "" && !strings.HasSuffix(dsn, tt.want)) {
+				t.Errorf("dsn = %q, want statement_timeout %q", dsn, tt.want)
+			}
+		})
+	}
+}
+
+// TestStatementTimeoutCancelsSlowQuery needs a Postgres server; set TEST_DATABASE_DSN to run it
+func TestStatementTimeoutCancelsSlowQuery(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn+" statement_timeout=100"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	start := time.Now()
+	err = db.Exec("SELECT pg_sleep(5)").Error
+	if err == nil || !strings.Contains(err.Error(), "statement timeout") {
+		t.Errorf("slow query err = %v, want a statement timeout", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := db.WithContext(ctx).Exec("SELECT pg_sleep(5)").Error; err == nil {
+		t.Error("slow query outlived its context deadline")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("slow queries took %s, want them cancelled", elapsed)
+	}
+}
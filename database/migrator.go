@@ -1,17 +1,50 @@
 package database
 
 import (
+	"fmt"
+
 	"github.com/evandrarf/dinacom-be/internal/entity"
+	"github.com/spf13/viper"
 	"gorm.io/gorm"
 )
 
-func Migrate(db *gorm.DB) error {
-	err := db.AutoMigrate(
+func models() []interface{} {
+	return []interface{}{
 		&entity.QuestionBankTemplate{},
 		&entity.GeneratedQuestion{},
 		&entity.UserAnswer{},
 		&entity.SessionAnalysisCache{},
 		&entity.ChatMessage{},
-	)
-	return err
+		&entity.Session{},
+		&entity.LLMUsage{},
+		&entity.QuestionServeLog{},
+		&entity.UserPreferences{},
+	}
+}
+
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(models()...); err != nil {
+		return err
+	}
+	return runBackfills(db)
+}
+
+// AutoMigrateEnabled - database.auto_migrate, on unless explicitly disabled
+func AutoMigrateEnabled(config *viper.Viper) bool {
+	return !config.IsSet("database.auto_migrate") || config.GetBool("database.auto_migrate")
+}
+
+// CheckTables - Ensure every required table exists (used when auto migration is disabled)
+func CheckTables(db *gorm.DB) error {
+	migrator := db.Migrator()
+	for _, model := range models() {
+		if !migrator.HasTable(model) {
+			stmt := &gorm.Statement{DB: db}
+			if err := stmt.Parse(model); err != nil {
+				return err
+			}
+			return fmt.Errorf("required table %s is missing, run `make migrate`", stmt.Schema.Table)
+		}
+	}
+	return nil
 }
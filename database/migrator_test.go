@@ -0,0 +1,45 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestAutoMigrateEnabled(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  bool
+	}{
+		{name: "unset defaults on", want: true},
+		{name: "enabled", value: true, want: true},
+		{name: "disabled", value: false},
+		{name: "disabled from env string", value: "false"},
+	}
+	for _, tt := range tests {
+		config := viper.New()
+		if tt.value != nil {
+			config.Set("database.auto_migrate", tt.value)
+		}
+		if got := AutoMigrateEnabled(config); got != tt.want {
+			t.Errorf("%s: AutoMigrateEnabled = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// With auto migration off, a schema without the tables stops the API; a dry run reports no tables
+func TestCheckTablesMissing(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+
+	err = CheckTables(db)
+	if err == nil || !strings.Contains(err.Error(), "required table question_bank_templates is missing") {
+		t.Errorf("CheckTables error = %v, want the first missing table", err)
+	}
+}
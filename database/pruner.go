@@ -0,0 +1,24 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/entity"
+	"gorm.io/gorm"
+)
+
+// PruneStaleQuestions - Soft delete cached generated questions older than maxAgeDays so they get regenerated
+func PruneStaleQuestions(db *gorm.DB, maxAgeDays int) (int64, error) {
+	if maxAgeDays <= 0 {
+		return 0, nil
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -maxAgeDays)
+	result := db.Where("created_at < ?", cutoff).Delete(&entity.GeneratedQuestion{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune stale questions: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	oldEntity "github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+)
+
+// LoadQuestionBankFile - Parse templates from a JSON array or CSV file.
+// CSV columns: id,difficulty,target_letter_pair,target_letter,correct_word,distractors (distractors separated by "|")
+func LoadQuestionBankFile(path string) ([]oldEntity.QuestionTemplate, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open seed file: %w", err)
+	}
+	defer f.Close()
+
+	var templates []oldEntity.QuestionTemplate
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.NewDecoder(f).Decode(&templates); err != nil {
+			return nil, fmt.Errorf("failed to parse seed json: %w", err)
+		}
+	case ".csv":
+		templates, err = parseQuestionBankCSV(f)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported seed file type: %s (use .json or .csv)", path)
+	}
+
+	for i, tpl := range templates {
+		// JSON and CSV files alike may write "Easy" or "EASY"; store the canonical form
+		templates[i].Difficulty = oldEntity.NormalizeDifficulty(string(tpl.Difficulty))
+		if err := validateTemplate(templates[i]); err != nil {
+			return nil, fmt.Errorf("invalid seed record %d: %w", i+1, err)
+		}
+	}
+
+	return templates, nil
+}
+
+func parseQuestionBankCSV(r io.Reader) ([]oldEntity.QuestionTemplate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse seed csv: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	templates := make([]oldEntity.QuestionTemplate, 0, len(records)-1)
+	for i, record := range records[1:] { // skip header
+		if len(record) != 6 {
+			return nil, fmt.Errorf("seed csv line %d: expected 6 columns, got %d", i+2, len(record))
+		}
+
+		var distractors []string
+		for _, d := range strings.Split(record[5], "|") {
+			if d = strings.TrimSpace(d); d != "" {
+				distractors = append(distractors, d)
+			}
+		}
+
+		templates = append(templates, oldEntity.QuestionTemplate{
+			ID:               strings.TrimSpace(record[0]),
+			Difficulty:       oldEntity.Difficulty(record[1]),
+			TargetLetterPair: strings.ToLower(strings.TrimSpace(record[2])),
+			TargetLetter:     strings.TrimSpace(record[3]),
+			CorrectWord:      strings.TrimSpace(record[4]),
+			Distractors:      distractors,
+		})
+	}
+
+	return templates, nil
+}
+
+func validateTemplate(tpl oldEntity.QuestionTemplate) error {
+	if tpl.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if _, err := oldEntity.ParseDifficulty(string(tpl.Difficulty)); err != nil {
+		return fmt.Errorf("template %s: %w", tpl.ID, err)
+	}
+
+	validPair := false
+	for _, lp := range oldEntity.LetterPairs {
+		if lp == tpl.TargetLetterPair {
+			validPair = true
+			break
+		}
+	}
+	if !validPair {
+		return fmt.Errorf("template %s: invalid letter pair %q", tpl.ID, tpl.TargetLetterPair)
+	}
+
+	if strings.TrimSpace(tpl.CorrectWord) == "" {
+		return fmt.Errorf("template %s: correct word is required", tpl.ID)
+	}
+	if len(tpl.Distractors) == 0 {
+		return fmt.Errorf("template %s: distractors are required", tpl.ID)
+	}
+
+	return nil
+}
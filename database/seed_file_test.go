@@ -0,0 +1,90 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	oldEntity "github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+)
+
+func TestLoadQuestionBankFile(t *testing.T) {
+	want := []oldEntity.QuestionTemplate{
+		{ID: "f-bd-1", Difficulty: oldEntity.DifficultyEasy, TargetLetterPair: "b-d", TargetLetter: "b", CorrectWord: "bola", Distractors: []string{"dola", "bela", "pola"}},
+		{ID: "f-pq-1", Difficulty: oldEntity.DifficultyMedium, TargetLetterPair: "p-q", TargetLetter: "p", CorrectWord: "pintu", Distractors: []string{"qintu", "bintu"}},
+	}
+	for _, file := range []string{"question_bank.json", "question_bank.csv"} {
+		t.Run(file, func(t *testing.T) {
+			got, err := LoadQuestionBankFile(filepath.Join("testdata", file))
+			if err != nil {
+				t.Fatalf("LoadQuestionBankFile: %v", err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("loaded %d templates, want %d", len(got), len(want))
+			}
+			for i := range want {
+				g, w := got[i], want[i]
+				if g.ID != w.ID || g.Difficulty != w.Difficulty || g.TargetLetterPair != w.TargetLetterPair ||
+					g.TargetLetter != w.TargetLetter || g.CorrectWord != w.CorrectWord || !slices.Equal(g.Distractors, w.Distractors) {
+					t.Errorf("template %d = %+v, want %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
+
+// JSON and CSV seed files may spell difficulty in any case; it is stored canonical
+func TestLoadQuestionBankFileNormalizesDifficulty(t *testing.T) {
+	const header = "id,difficulty,target_letter_pair,target_letter,correct_word,distractors\n"
+	files := map[string]string{
+		"bank.csv":  header + "x-1, EASY ,b-d,b,bola,dola\nx-2,Hard,p-q,p,pintu,qintu\n",
+		"bank.json": `[{"id":"x-1","difficulty":" EASY ","targetLetterPair":"b-d","correctWord":"bola","distractors":["dola"]},{"id":"x-2","difficulty":"Hard","targetLetterPair":"p-q","correctWord":"pintu","distractors":["qintu"]}]`,
+	}
+	for file, content := range files {
+		t.Run(file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), file)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadQuestionBankFile(path)
+			if err != nil {
+				t.Fatalf("LoadQuestionBankFile: %v", err)
+			}
+			if len(got) != 2 || got[0].Difficulty != oldEntity.DifficultyEasy || got[1].Difficulty != oldEntity.DifficultyHard {
+				t.Errorf("templates = %+v, want easy and hard", got)
+			}
+		})
+	}
+}
+
+func TestLoadQuestionBankFileRejectsBadRecords(t *testing.T) {
+	const header = "id,difficulty,target_letter_pair,target_letter,correct_word,distractors\n"
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{name: "no distractors", file: "bank.csv", content: header + "x-1,easy,b-d,b,bola,\n", wantErr: "distractors are required"},
+		{name: "unknown difficulty", file: "bank.csv", content: header + "x-1,extreme,b-d,b,bola,dola\n", wantErr: "invalid difficulty"},
+		{name: "unknown pair", file: "bank.json", content: `[{"id":"x-1","difficulty":"easy","targetLetterPair":"x-y","correctWord":"bola","distractors":["dola"]}]`, wantErr: "invalid letter pair"},
+		{name: "missing correct word", file: "bank.json", content: `[{"id":"x-1","difficulty":"easy","targetLetterPair":"b-d","distractors":["dola"]}]`, wantErr: "correct word is required"},
+		{name: "wrong column count", file: "bank.csv", content: "id,difficulty,target_letter_pair\nx-1,easy,b-d\n", wantErr: "expected 6 columns"},
+		{name: "ragged row", file: "bank.csv", content: header + "x-1,easy,b-d\n", wantErr: "wrong number of fields"},
+		{name: "unsupported extension", file: "bank.yaml", content: "- id: x-1\n", wantErr: "unsupported seed file type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadQuestionBankFile(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadQuestionBankFile error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
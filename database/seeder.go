@@ -2,10 +2,14 @@ package database
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	oldEntity "github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
 	"github.com/evandrarf/dinacom-be/internal/entity"
+	"github.com/evandrarf/dinacom-be/internal/pkg/mapper"
+	"github.com/spf13/viper"
 	"gorm.io/gorm"
 )
 
@@ -16,39 +20,160 @@ var QuestionBankData = []oldEntity.QuestionTemplate{
 
 }
 
-// SeedQuestionBank - Migrate data dari QuestionBankData ke database
-func SeedQuestionBank(db *gorm.DB) error {
+// seedDataError marks seeding failures caused by the seed data itself; retrying them cannot help
+type seedDataError struct {
+	err error
+}
+
+func (e *seedDataError) Error() string { return e.err.Error() }
+func (e *seedDataError) Unwrap() error { return e.err }
+
+// Seed runs SeedQuestionBank unless seed.enabled is false, retrying transient database failures with
+// database.connect_retries / database.connect_backoff. Each attempt runs in a single transaction,
+// so a failed attempt leaves nothing behind.
+func Seed(db *gorm.DB, config *viper.Viper) error {
+	if config.IsSet("seed.enabled") && !config.GetBool("seed.enabled") {
+		fmt.Println("Seeding disabled (seed.enabled=false), skipping...")
+		return nil
+	}
+
+	retries, backoff := retryConfig(config)
+	return seedWithRetry(retries, backoff, func() error {
+		return SeedQuestionBank(db, config.GetString("seed.file"), config.GetBool("seed.verify"))
+	})
+}
+
+func seedWithRetry(retries int, backoff time.Duration, seed func() error) error {
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			fmt.Printf("[SEED] Attempt %d/%d failed: %v, retrying in %s\n", attempt, retries+1, lastErr, backoff)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		err := seed()
+		if err == nil {
+			return nil
+		}
+		var dataErr *seedDataError
+		if errors.As(err, &dataErr) {
+			return err
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("failed to seed after %d attempts: %w", retries+1, lastErr)
+}
+
+// SeedQuestionBank - Migrate data dari seedFile (JSON/CSV) atau QuestionBankData ke database.
+// With verify every seeded template is read back and its distractors must round-trip, otherwise nothing is seeded.
+func SeedQuestionBank(db *gorm.DB, seedFile string, verify bool) error {
 	// Check if already seeded
 	var count int64
-	db.Model(&entity.QuestionBankTemplate{}).Count(&count)
+	if err := db.Model(&entity.QuestionBankTemplate{}).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count question bank templates: %w", err)
+	}
 	if count > 0 {
 		fmt.Println("Question bank already seeded, skipping...")
 		return nil
 	}
 
+	data := QuestionBankData
+	if seedFile != "" {
+		fileData, err := LoadQuestionBankFile(seedFile)
+		if err != nil {
+			return &seedDataError{err}
+		}
+		data = fileData
+		fmt.Printf("Loaded %d templates from %s\n", len(data), seedFile)
+	}
+
 	fmt.Println("Seeding question bank templates...")
 
-	for _, tpl := range QuestionBankData {
-		// Convert distractors to JSON string
-		distractorsJSON, err := json.Marshal(tpl.Distractors)
-		if err != nil {
-			return fmt.Errorf("failed to marshal distractors for %s: %w", tpl.ID, err)
+	// Seed in one transaction so a failed template or verification leaves the bank empty instead of half seeded
+	err := db.Transaction(func(tx *gorm.DB) error {
+		expected := make(map[string][]string, len(data))
+		for _, tpl := range data {
+			// Distractors must never collide with the correct word, otherwise two options are "correct"
+			distractors := mapper.FilterDistractors(tpl.CorrectWord, tpl.Distractors)
+			if len(distractors) == 0 {
+				return &seedDataError{fmt.Errorf("template %s has no distractors distinct from correct word %s", tpl.ID, tpl.CorrectWord)}
+			}
+			if len(distractors) != len(tpl.Distractors) {
+				fmt.Printf("Warning: template %s had distractors colliding with %s, repaired to %v\n", tpl.ID, tpl.CorrectWord, distractors)
+			}
+
+			// Convert distractors to JSON string
+			distractorsJSON, err := json.Marshal(distractors)
+			if err != nil {
+				return &seedDataError{fmt.Errorf("failed to marshal distractors for %s: %w", tpl.ID, err)}
+			}
+
+			difficulty, err := oldEntity.ParseDifficulty(string(tpl.Difficulty))
+			if err != nil {
+				return &seedDataError{fmt.Errorf("template %s: %w", tpl.ID, err)}
+			}
+
+			template := entity.QuestionBankTemplate{
+				TemplateID:       tpl.ID,
+				Difficulty:       string(difficulty),
+				TargetLetterPair: tpl.TargetLetterPair,
+				TargetLetter:     tpl.TargetLetter,
+				CorrectWord:      tpl.CorrectWord,
+				Distractors:      string(distractorsJSON),
+			}
+			expected[tpl.ID] = distractors
+
+			if err := tx.Create(&template).Error; err != nil {
+				return fmt.Errorf("failed to seed template %s: %w", tpl.ID, err)
+			}
+		}
+
+		if verify {
+			return verifySeededDistractors(tx, expected)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-		template := entity.QuestionBankTemplate{
-			TemplateID:       tpl.ID,
-			Difficulty:       string(tpl.Difficulty),
-			TargetLetterPair: tpl.TargetLetterPair,
-			TargetLetter:     tpl.TargetLetter,
-			CorrectWord:      tpl.CorrectWord,
-			Distractors:      string(distractorsJSON),
+	fmt.Printf("Successfully seeded %d question bank templates\n", len(data))
+	return nil
+}
+
+// verifySeededDistractors reads the seeded templates back and checks that the stored distractors JSON
+// unmarshals to exactly the slice that was written, so encoding bugs surface at seed time instead of in
+// mapper.ConvertToQuestionTemplate while serving questions
+func verifySeededDistractors(db *gorm.DB, expected map[string][]string) error {
+	var templates []entity.QuestionBankTemplate
+	if err := db.Find(&templates).Error; err != nil {
+		return fmt.Errorf("failed to read back seeded templates: %w", err)
+	}
+	if len(templates) != len(expected) {
+		return &seedDataError{fmt.Errorf("seed verification: expected %d templates, found %d", len(expected), len(templates))}
+	}
+
+	for _, tpl := range templates {
+		want, ok := expected[tpl.TemplateID]
+		if !ok {
+			return &seedDataError{fmt.Errorf("seed verification: unexpected template %s", tpl.TemplateID)}
 		}
 
-		if err := db.Create(&template).Error; err != nil {
-			return fmt.Errorf("failed to seed template %s: %w", tpl.ID, err)
+		var got []string
+		if err := json.Unmarshal([]byte(tpl.Distractors), &got); err != nil {
+			return &seedDataError{fmt.Errorf("seed verification: template %s distractors do not unmarshal: %w", tpl.TemplateID, err)}
+		}
+		if len(got) != len(want) {
+			return &seedDataError{fmt.Errorf("seed verification: template %s distractors %v, want %v", tpl.TemplateID, got, want)}
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				return &seedDataError{fmt.Errorf("seed verification: template %s distractors %v, want %v", tpl.TemplateID, got, want)}
+			}
 		}
 	}
 
-	fmt.Printf("Successfully seeded %d question bank templates\n", len(QuestionBankData))
+	fmt.Printf("Verified distractors of %d seeded templates\n", len(templates))
 	return nil
 }
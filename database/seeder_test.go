@@ -0,0 +1,246 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	oldEntity "github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/evandrarf/dinacom-be/internal/entity"
+	"github.com/spf13/viper"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// readBackDB answers every query with the given templates, standing in for the rows a seed just wrote
+func readBackDB(t *testing.T, templates []entity.QuestionBankTemplate) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	err = db.Callback().Query().Replace("gorm:query", func(tx *gorm.DB) {
+		*tx.Statement.Dest.(*[]entity.QuestionBankTemplate) = templates
+	})
+	if err != nil {
+		t.Fatalf("replace query callback: %v", err)
+	}
+	return db
+}
+
+// Verification passes only when every stored distractors column unmarshals back to the slice that was seeded
+func TestVerifySeededDistractors(t *testing.T) {
+	expected := map[string][]string{"e-bd-1": {"dola", "pola"}, "e-mn-1": {"nama", "mana"}}
+	tests := []struct {
+		name    string
+		stored  []entity.QuestionBankTemplate
+		wantErr string
+	}{
+		{name: "round trips", stored: []entity.QuestionBankTemplate{
+			{TemplateID: "e-bd-1", Distractors: `["dola","pola"]`},
+			{TemplateID: "e-mn-1", Distractors: `["nama","mana"]`},
+		}},
+		{name: "not json", stored: []entity.QuestionBankTemplate{
+			{TemplateID: "e-bd-1", Distractors: `dola,pola`},
+			{TemplateID: "e-mn-1", Distractors: `["nama","mana"]`},
+		}, wantErr: "template e-bd-1 distractors do not unmarshal"},
+		{name: "reordered", stored: []entity.QuestionBankTemplate{
+			{TemplateID: "e-bd-1", Distractors: `["dola","pola"]`},
+			{TemplateID: "e-mn-1", Distractors: `["mana","nama"]`},
+		}, wantErr: "template e-mn-1 distractors"},
+		{name: "truncated", stored: []entity.QuestionBankTemplate{
+			{TemplateID: "e-bd-1", Distractors: `["dola"]`},
+			{TemplateID: "e-mn-1", Distractors: `["nama","mana"]`},
+		}, wantErr: "template e-bd-1 distractors"},
+		{name: "missing template", stored: []entity.QuestionBankTemplate{
+			{TemplateID: "e-bd-1", Distractors: `["dola","pola"]`},
+		}, wantErr: "expected 2 templates, found 1"},
+		{name: "unexpected template", stored: []entity.QuestionBankTemplate{
+			{TemplateID: "e-bd-1", Distractors: `["dola","pola"]`},
+			{TemplateID: "h-pq-9", Distractors: `["nama","mana"]`},
+		}, wantErr: "unexpected template h-pq-9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySeededDistractors(readBackDB(t, tt.stored), expected)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("verifySeededDistractors: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// seedRecorder stands in for the database during a seed: counts fail while countFailures lasts, the insert of
+// failTemplate fails, and transactions only record how they ended
+type seedRecorder struct {
+	countFailures int
+	failTemplate  string
+
+	counts    int
+	created   []string // template ids inserted by the current attempt
+	commits   int
+	rollbacks int
+}
+
+func seedTestDB(t *testing.T, rec *seedRecorder) *gorm.DB {
+	t.Helper()
+	conn := sql.OpenDB(seedConnector{rec})
+	t.Cleanup(func() { conn.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open seed db: %v", err)
+	}
+	err = errors.Join(
+		db.Callback().Query().Replace("gorm:query", func(tx *gorm.DB) {
+			rec.counts++
+			if rec.counts <= rec.countFailures {
+				_ = tx.AddError(errors.New("connection refused"))
+			}
+		}),
+		db.Callback().Create().Replace("gorm:create", func(tx *gorm.DB) {
+			id := tx.Statement.Dest.(*entity.QuestionBankTemplate).TemplateID
+			if id == rec.failTemplate {
+				_ = tx.AddError(errors.New("duplicate key"))
+				return
+			}
+			rec.created = append(rec.created, id)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("replace callbacks: %v", err)
+	}
+	return db
+}
+
+type seedConnector struct{ rec *seedRecorder }
+
+func (c seedConnector) Connect(context.Context) (driver.Conn, error) { return seedConn(c), nil }
+func (c seedConnector) Driver() driver.Driver                        { return nil }
+
+type seedConn struct{ rec *seedRecorder }
+
+func (c seedConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("seedTestDB runs no statements")
+}
+func (c seedConn) Close() error              { return nil }
+func (c seedConn) Begin() (driver.Tx, error) { return seedTx(c), nil }
+
+type seedTx struct{ rec *seedRecorder }
+
+func (t seedTx) Commit() error {
+	t.rec.commits++
+	return nil
+}
+
+func (t seedTx) Rollback() error {
+	t.rec.rollbacks++
+	t.rec.created = nil
+	return nil
+}
+
+func withQuestionBank(t *testing.T, data []oldEntity.QuestionTemplate) {
+	t.Helper()
+	original := QuestionBankData
+	QuestionBankData = data
+	t.Cleanup(func() { QuestionBankData = original })
+}
+
+var seedBank = []oldEntity.QuestionTemplate{
+	{ID: "e-bd-1", Difficulty: "easy", TargetLetterPair: "b-d", CorrectWord: "bola", Distractors: []string{"dola", "pola"}},
+	{ID: "e-bd-2", Difficulty: "easy", TargetLetterPair: "b-d", CorrectWord: "dadu", Distractors: []string{"babu"}},
+	{ID: "e-mn-1", Difficulty: "easy", TargetLetterPair: "m-n", CorrectWord: "nama", Distractors: []string{"mana"}},
+}
+
+// seed.enabled=false skips seeding without touching the database
+func TestSeedDisabled(t *testing.T) {
+	withQuestionBank(t, seedBank)
+	rec := &seedRecorder{}
+	config := viper.New()
+	config.Set("seed.enabled", false)
+
+	if err := Seed(seedTestDB(t, rec), config); err != nil {
+		t.Fatalf("Seed: %v", err)
+	}
+	if rec.counts != 0 || rec.commits != 0 || len(rec.created) != 0 {
+		t.Errorf("disabled seed touched the database: %+v", rec)
+	}
+}
+
+// A database that is not ready yet is retried with the connect-retry config until it answers
+func TestSeedRetriesTransientFailure(t *testing.T) {
+	withQuestionBank(t, seedBank)
+	tests := []struct {
+		name          string
+		countFailures int
+		retries       int
+		wantErr       bool
+	}{
+		{name: "first attempt succeeds", retries: 2},
+		{name: "transient failure recovers", countFailures: 2, retries: 2},
+		{name: "gives up after retries", countFailures: 3, retries: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &seedRecorder{countFailures: tt.countFailures}
+			config := viper.New()
+			config.Set("database.connect_retries", tt.retries)
+			config.Set("database.connect_backoff", time.Millisecond)
+
+			err := Seed(seedTestDB(t, rec), config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Seed error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if rec.counts != min(tt.countFailures+1, tt.retries+1) {
+				t.Errorf("attempts = %d, want %d", rec.counts, min(tt.countFailures+1, tt.retries+1))
+			}
+			if !tt.wantErr && (rec.commits != 1 || len(rec.created) != len(seedBank)) {
+				t.Errorf("seeded %v with %d commits, want all %d templates in one commit", rec.created, rec.commits, len(seedBank))
+			}
+		})
+	}
+}
+
+// A failure partway through rolls back the templates already inserted; bad seed data is not retried
+func TestSeedRollsBackPartialFailure(t *testing.T) {
+	tests := []struct {
+		name         string
+		bank         []oldEntity.QuestionTemplate
+		failTemplate string
+		wantAttempts int
+	}{
+		{name: "insert fails", bank: seedBank, failTemplate: "e-mn-1", wantAttempts: 3},
+		{name: "bad template", bank: append(slices.Clone(seedBank), oldEntity.QuestionTemplate{
+			ID: "e-xx-1", Difficulty: "easy", CorrectWord: "bola", Distractors: []string{"bola"},
+		}), wantAttempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withQuestionBank(t, tt.bank)
+			rec := &seedRecorder{failTemplate: tt.failTemplate}
+			config := viper.New()
+			config.Set("database.connect_retries", 2)
+			config.Set("database.connect_backoff", time.Millisecond)
+
+			if err := Seed(seedTestDB(t, rec), config); err == nil {
+				t.Fatal("Seed succeeded")
+			}
+			if rec.counts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", rec.counts, tt.wantAttempts)
+			}
+			if rec.commits != 0 || rec.rollbacks != tt.wantAttempts || len(rec.created) != 0 {
+				t.Errorf("commits = %d, rollbacks = %d, left behind %v; want every attempt rolled back", rec.commits, rec.rollbacks, rec.created)
+			}
+		})
+	}
+}
@@ -13,6 +13,11 @@ func NewAPI(config *viper.Viper, log *logrus.Logger) *fiber.App {
 		ErrorHandler: ErrorHandler(log),
 		Prefork:      config.GetBool("api.prefork"),
 	})
+	if api.Config().Prefork && !fiber.IsChild() {
+		// Each prefork child is a separate process with its own copy of in-memory state
+		log.Warn("api.prefork is enabled: metrics, the LLM circuit breaker, report locks, the template cache and queued question writes are per process, " +
+			"and scheduled jobs (session expiry) and startup migrations run in the primary process only")
+	}
 	return api
 }
 
@@ -1,13 +1,21 @@
 package config
 
 import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
 	"github.com/evandrarf/dinacom-be/internal/delivery/http/handler"
 	"github.com/evandrarf/dinacom-be/internal/delivery/http/middleware"
 	"github.com/evandrarf/dinacom-be/internal/delivery/http/repository"
 	"github.com/evandrarf/dinacom-be/internal/delivery/http/route"
 	"github.com/evandrarf/dinacom-be/internal/delivery/http/usecase"
 	"github.com/evandrarf/dinacom-be/internal/pkg/llm"
+	"github.com/evandrarf/dinacom-be/internal/pkg/metrics"
+	"github.com/evandrarf/dinacom-be/internal/pkg/readiness"
 	"github.com/evandrarf/dinacom-be/internal/pkg/validate"
+	"github.com/evandrarf/dinacom-be/internal/pkg/webhook"
 	"github.com/gofiber/fiber/v2"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -20,6 +28,7 @@ type BootstrapConfig struct {
 	DB        *gorm.DB
 	Log       *logrus.Logger
 	Validator *validate.Validator
+	Readiness *readiness.Flag
 }
 
 func Bootstrap(config *BootstrapConfig) {
@@ -29,32 +38,98 @@ func Bootstrap(config *BootstrapConfig) {
 		Config: config.Config,
 	})
 
+	// Difficulties come from config (see LoadDifficulties), so the rule can't be a static oneof
+	config.Validator.RegisterRule("difficulty", func(value string) bool {
+		_, err := entity.ParseDifficulty(value)
+		return err == nil
+	}, "{0} must be one of "+strings.Join(entity.DifficultyNames(), ", "))
+
 	apiKey := ""
 	model := ""
 	baseURL := ""
 	promptTemplate := ""
+	webhookURL := ""
+	accuracyAlertURL := ""
+	webhookSecret := ""
+	webhookMaxRetries := 0
+	webhookTimeout := 0
+	breakerThreshold := 5
+	breakerCooldown := 30
+	templateCache := true
+	templateCacheTTL := 300
 	if config.Config != nil {
 		apiKey = config.Config.GetString("llm.gemini.api_key")
 		model = config.Config.GetString("llm.gemini.model")
 		baseURL = config.Config.GetString("llm.gemini.base_url")
 		promptTemplate = config.Config.GetString("llm.gemini.prompt_template")
+		webhookURL = config.Config.GetString("webhooks.session_complete_url")
+		accuracyAlertURL = config.Config.GetString("webhooks.accuracy_alert_url")
+		webhookSecret = config.Config.GetString("webhooks.secret")
+		webhookMaxRetries = config.Config.GetInt("webhooks.max_retries")
+		webhookTimeout = config.Config.GetInt("webhooks.timeout_seconds")
+		if config.Config.IsSet("llm.circuit_breaker.failure_threshold") {
+			breakerThreshold = config.Config.GetInt("llm.circuit_breaker.failure_threshold")
+		}
+		if config.Config.IsSet("llm.circuit_breaker.cooldown_seconds") {
+			breakerCooldown = config.Config.GetInt("llm.circuit_breaker.cooldown_seconds")
+		}
+		if config.Config.IsSet("questions.template_cache.enabled") {
+			templateCache = config.Config.GetBool("questions.template_cache.enabled")
+		}
+		if config.Config.IsSet("questions.template_cache.ttl_seconds") {
+			templateCacheTTL = config.Config.GetInt("questions.template_cache.ttl_seconds")
+		}
 	}
 
 	gemini := llm.NewGeminiClient(apiKey, model, baseURL)
+	if config.Config != nil && config.Config.IsSet("llm.gemini.json_mode") {
+		gemini.JSONMode = config.Config.GetBool("llm.gemini.json_mode")
+	}
+	gemini.SetBreaker(llm.NewBreaker(breakerThreshold, time.Duration(breakerCooldown)*time.Second))
+	sessionWebhook := webhook.NewClient(webhookURL, webhookSecret, webhookMaxRetries, time.Duration(webhookTimeout)*time.Second)
+	accuracyAlert := webhook.NewClient(accuracyAlertURL, webhookSecret, webhookMaxRetries, time.Duration(webhookTimeout)*time.Second)
 	dyslexiaQuestionRepo := repository.NewDyslexiaQuestionRepository(config.DB)
+	if templateCache {
+		dyslexiaQuestionRepo = repository.NewTemplateCachingRepository(dyslexiaQuestionRepo, time.Duration(templateCacheTTL)*time.Second)
+	}
+	metricsRegistry := metrics.NewRegistry()
 	dyslexiaQuestionUsecase := usecase.NewDyslexiaQuestionUsecase(usecase.DyslexiaQuestionConfig{
 		DB:             config.DB,
 		Gemini:         gemini,
 		PromptTemplate: promptTemplate,
 		Repository:     dyslexiaQuestionRepo,
 		Config:         config.Config,
+		Webhook:        sessionWebhook,
+		AccuracyAlert:  accuracyAlert,
+		Metrics:        metricsRegistry,
+		Log:            config.Log,
+		Difficulties:   entity.DifficultyLevels(),
+		PreforkChild:   fiber.IsChild(),
+	})
+	// Queued question writes are flushed once the server has stopped taking requests
+	config.Api.Hooks().OnShutdown(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return dyslexiaQuestionUsecase.Shutdown(ctx)
 	})
 	dyslexiaQuestionHandler := handler.NewDyslexiaQuestionHandler(config.Validator, config.Log, dyslexiaQuestionUsecase)
 
+	// API docs are served unless api.docs.enabled is explicitly false
+	var docsHandler handler.DocsHandler
+	if config.Config == nil || !config.Config.IsSet("api.docs.enabled") || config.Config.GetBool("api.docs.enabled") {
+		appName := ""
+		if config.Config != nil {
+			appName = config.Config.GetString("app.name")
+		}
+		docsHandler = handler.NewDocsHandler(appName)
+	}
+
 	route.Setup(&route.RouteConfig{
 		Api:                     config.Api,
 		Middleware:              mid,
 		DyslexiaQuestionHandler: dyslexiaQuestionHandler,
+		HealthHandler:           handler.NewHealthHandler(config.Readiness, gemini.Breaker(), metricsRegistry),
+		DocsHandler:             docsHandler,
 	})
 
 }
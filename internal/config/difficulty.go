@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/spf13/viper"
+)
+
+// LoadDifficulties registers dyslexia.difficulties as the valid difficulty levels; the built-in
+// easy/medium/hard stay in place when the key is not set. Call it before seeding or serving requests.
+func LoadDifficulties(config *viper.Viper) error {
+	if config == nil || !config.IsSet("dyslexia.difficulties") {
+		return nil
+	}
+
+	levels, err := DifficultyLevelsFromConfig(config)
+	if err != nil {
+		return err
+	}
+	return entity.SetDifficultyLevels(levels)
+}
+
+// DifficultyLevelsFromConfig parses dyslexia.difficulties, or returns the built-in levels when it is not set
+func DifficultyLevelsFromConfig(config *viper.Viper) ([]entity.DifficultyLevel, error) {
+	if config == nil || !config.IsSet("dyslexia.difficulties") {
+		return entity.DefaultDifficultyLevels, nil
+	}
+
+	var levels []entity.DifficultyLevel
+	if err := config.UnmarshalKey("dyslexia.difficulties", &levels); err != nil {
+		return nil, fmt.Errorf("invalid dyslexia.difficulties: %w", err)
+	}
+	levels, err := entity.NormalizeDifficultyLevels(levels)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dyslexia.difficulties: %w", err)
+	}
+	return levels, nil
+}
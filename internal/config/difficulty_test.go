@@ -0,0 +1,75 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/spf13/viper"
+)
+
+func TestDifficultyLevelsFromConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		levels    any
+		wantNames []entity.Difficulty
+		wantErr   string
+	}{
+		{name: "unset keeps the built-in levels", wantNames: []entity.Difficulty{"easy", "medium", "hard"}},
+		{name: "custom levels", levels: []map[string]any{{"name": "Beginner", "min_length": 3, "max_length": 4}, {"name": "easy"}},
+			wantNames: []entity.Difficulty{"beginner", "easy"}},
+		{name: "duplicate", levels: []map[string]any{{"name": "easy"}, {"name": "easy"}}, wantErr: "invalid dyslexia.difficulties: duplicate difficulty"},
+		{name: "not a list", levels: "easy", wantErr: "invalid dyslexia.difficulties"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := viper.New()
+			if tt.levels != nil {
+				config.Set("dyslexia.difficulties", tt.levels)
+			}
+
+			levels, err := DifficultyLevelsFromConfig(config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DifficultyLevelsFromConfig: %v", err)
+			}
+			if len(levels) != len(tt.wantNames) {
+				t.Fatalf("levels = %+v, want %v", levels, tt.wantNames)
+			}
+			for i, level := range levels {
+				if level.Name != tt.wantNames[i] {
+					t.Errorf("level %d = %q, want %q", i, level.Name, tt.wantNames[i])
+				}
+			}
+		})
+	}
+}
+
+// Loading a custom set registers it for request validation; leaving the key unset keeps the built-in levels
+func TestLoadDifficulties(t *testing.T) {
+	t.Cleanup(func() { _ = entity.SetDifficultyLevels(entity.DefaultDifficultyLevels) })
+
+	if err := LoadDifficulties(viper.New()); err != nil {
+		t.Fatalf("LoadDifficulties without the key: %v", err)
+	}
+	if _, err := entity.ParseDifficulty("hard"); err != nil {
+		t.Errorf("built-in level lost: %v", err)
+	}
+
+	config := viper.New()
+	config.Set("dyslexia.difficulties", []map[string]any{{"name": "beginner", "min_length": 3, "max_length": 4}})
+	if err := LoadDifficulties(config); err != nil {
+		t.Fatalf("LoadDifficulties: %v", err)
+	}
+	if _, err := entity.ParseDifficulty("beginner"); err != nil {
+		t.Errorf("beginner not registered: %v", err)
+	}
+	if _, err := entity.ParseDifficulty("hard"); err == nil {
+		t.Error("hard still valid after configuring only beginner")
+	}
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/usecase"
+	"github.com/spf13/viper"
+)
+
+// configKey is a key checked at startup. Required keys must be set to a non-empty value; check, when
+// present, validates a value that is set (optional keys are only checked when they are set).
+type configKey struct {
+	key      string
+	required bool
+	check    func(config *viper.Viper, key string) error
+}
+
+// databaseKeys are needed by every binary that opens the database (api and migrate)
+var databaseKeys = []configKey{
+	{key: "database.host", required: true},
+	{key: "database.port", required: true, check: portCheck},
+	{key: "database.username", required: true},
+	{key: "database.dbname", required: true},
+	{key: "database.sslmode", check: oneOfCheck("disable", "allow", "prefer", "require", "verify-ca", "verify-full")},
+}
+
+// apiKeys are needed by the API server on top of databaseKeys. Defaults read by the usecase are checked
+// here too, so a typo shows up in the same startup error instead of being replaced by the built-in default.
+var apiKeys = []configKey{
+	{key: "llm.gemini.api_key", required: true},
+	{key: "llm.gemini.base_url", check: urlCheck},
+	{key: "dyslexia.default_difficulty", check: difficultyCheck},
+	{key: "dyslexia.default_patterns", check: patternsCheck},
+	{key: "questions.generation_strategy", check: parseCheck(usecase.ParseGenerationStrategy)},
+	{key: "questions.options.case", check: parseCheck(usecase.ParseOptionCase)},
+}
+
+// ValidateAPIConfig checks the keys the API server cannot start without, see validateKeys. Difficulty names are
+// checked against the config's own dyslexia.difficulties.
+func ValidateAPIConfig(config *viper.Viper) error {
+	return validateKeys(config, append(append([]configKey{}, databaseKeys...), apiKeys...))
+}
+
+// ValidateMigrateConfig checks the keys the migrate command cannot run without, see validateKeys
+func ValidateMigrateConfig(config *viper.Viper) error {
+	return validateKeys(config, databaseKeys)
+}
+
+// validateKeys reports every missing or malformed key in one error, so a bad config fails at startup
+// with the full list instead of a connection error later on
+func validateKeys(config *viper.Viper, keys []configKey) error {
+	if config == nil {
+		return fmt.Errorf("invalid configuration: no config loaded")
+	}
+
+	var problems []string
+	for _, k := range keys {
+		if !isSetValue(config, k.key) {
+			if k.required {
+				problems = append(problems, k.key+" is required")
+			}
+			continue
+		}
+		if k.check != nil {
+			if err := k.check(config, k.key); err != nil {
+				problems = append(problems, fmt.Sprintf("%s %v", k.key, err))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// isSetValue reports whether key holds a non-empty value; lists count when they have an element
+func isSetValue(config *viper.Viper, key string) bool {
+	if !config.IsSet(key) {
+		return false
+	}
+	return strings.TrimSpace(config.GetString(key)) != "" || len(config.GetStringSlice(key)) > 0
+}
+
+func portCheck(config *viper.Viper, key string) error {
+	raw := strings.TrimSpace(config.GetString(key))
+	var port int
+	if _, err := fmt.Sscan(raw, &port); err != nil || fmt.Sprint(port) != raw || port < 1 || port > 65535 {
+		return fmt.Errorf("must be a port number between 1 and 65535 (got %q)", raw)
+	}
+	return nil
+}
+
+func oneOfCheck(values ...string) func(config *viper.Viper, key string) error {
+	return func(config *viper.Viper, key string) error {
+		value := config.GetString(key)
+		for _, v := range values {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s (got %q)", strings.Join(values, ", "), value)
+	}
+}
+
+// difficultyCheck matches the value against the levels of this config's dyslexia.difficulties
+func difficultyCheck(config *viper.Viper, key string) error {
+	levels, err := DifficultyLevelsFromConfig(config)
+	if err != nil {
+		return fmt.Errorf("cannot be checked: %v", err)
+	}
+	names := make([]string, len(levels))
+	for i, level := range levels {
+		names[i] = string(level.Name)
+	}
+	if !slices.Contains(names, string(entity.NormalizeDifficulty(config.GetString(key)))) {
+		return fmt.Errorf("is not a configured difficulty (got %q, use %s)", config.GetString(key), strings.Join(names, ", "))
+	}
+	return nil
+}
+
+func patternsCheck(config *viper.Viper, key string) error {
+	var rejected []string
+	for _, pattern := range config.GetStringSlice(key) {
+		if !slices.Contains(entity.LetterPairs, strings.ToLower(strings.TrimSpace(pattern))) {
+			rejected = append(rejected, pattern)
+		}
+	}
+	if len(rejected) > 0 {
+		return fmt.Errorf("has unknown letter pairs %s (allowed: %s)", strings.Join(rejected, ", "), strings.Join(entity.LetterPairs, ", "))
+	}
+	return nil
+}
+
+// parseCheck adapts a parse function of the value's package, so the accepted values live in one place
+func parseCheck(parse func(string) (string, error)) func(config *viper.Viper, key string) error {
+	return func(config *viper.Viper, key string) error {
+		if _, err := parse(config.GetString(key)); err != nil {
+			return fmt.Errorf("is invalid: %v", err)
+		}
+		return nil
+	}
+}
+
+func urlCheck(config *viper.Viper, key string) error {
+	value := config.GetString(key)
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an http(s) URL (got %q)", value)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestValidateAPIConfigUsecaseDefaults(t *testing.T) {
+	base := map[string]interface{}{
+		"database.host":      "localhost",
+		"database.port":      "5432",
+		"database.username":  "postgres",
+		"database.dbname":    "dinacom",
+		"llm.gemini.api_key": "key",
+	}
+	tests := []struct {
+		name    string
+		set     map[string]interface{}
+		wantErr []string
+	}{
+		{name: "built-in defaults"},
+		{name: "valid overrides", set: map[string]interface{}{
+			"dyslexia.default_difficulty":   "Medium",
+			"dyslexia.default_patterns":     []string{"b-d", "P-Q"},
+			"questions.generation_strategy": "cache_first",
+			"questions.options.case":        "natural",
+		}},
+		{name: "default from custom levels", set: map[string]interface{}{
+			"dyslexia.difficulties":       []map[string]interface{}{{"name": "medium"}, {"name": "hard"}},
+			"dyslexia.default_difficulty": "hard",
+		}},
+		{name: "default missing from custom levels", set: map[string]interface{}{
+			"dyslexia.difficulties":       []map[string]interface{}{{"name": "medium"}, {"name": "hard"}},
+			"dyslexia.default_difficulty": "easy",
+		}, wantErr: []string{"dyslexia.default_difficulty", "use medium, hard"}},
+		{name: "every bad default reported at once", set: map[string]interface{}{
+			"dyslexia.default_difficulty":   "extreme",
+			"dyslexia.default_patterns":     []string{"b-d", "x-y"},
+			"questions.generation_strategy": "llm_only",
+			"questions.options.case":        "title",
+		}, wantErr: []string{
+			"dyslexia.default_difficulty", "dyslexia.default_patterns", "x-y",
+			"questions.generation_strategy", "questions.options.case",
+		}},
+	}
+	for _, tt := range tests {
+		config := viper.New()
+		for k, v := range base {
+			config.Set(k, v)
+		}
+		for k, v := range tt.set {
+			config.Set(k, v)
+		}
+
+		err := ValidateAPIConfig(config)
+		if len(tt.wantErr) == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Fatalf("%s: expected an error", tt.name)
+		}
+		for _, want := range tt.wantErr {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: error %q does not mention %q", tt.name, err, want)
+			}
+		}
+	}
+}
+
+func TestValidateKeysMissingAndMalformed(t *testing.T) {
+	keys := []configKey{
+		{key: "database.host", required: true},
+		{key: "database.port", required: true, check: portCheck},
+		{key: "database.sslmode", check: oneOfCheck("disable", "require")},
+		{key: "llm.gemini.base_url", check: urlCheck},
+	}
+	tests := []struct {
+		name      string
+		set       map[string]interface{}
+		wantErr   []string
+		unwantErr []string
+	}{
+		{name: "required keys set, optional keys absent", set: map[string]interface{}{
+			"database.host": "localhost", "database.port": 5432,
+		}},
+		{name: "every missing required key reported", wantErr: []string{"database.host is required", "database.port is required"},
+			unwantErr: []string{"database.sslmode", "llm.gemini.base_url"}},
+		{name: "blank value counts as missing", set: map[string]interface{}{
+			"database.host": "  ", "database.port": "5432",
+		}, wantErr: []string{"database.host is required"}, unwantErr: []string{"database.port"}},
+		{name: "empty list counts as missing", set: map[string]interface{}{
+			"database.host": []string{}, "database.port": "5432",
+		}, wantErr: []string{"database.host is required"}},
+		{name: "missing and malformed reported together", set: map[string]interface{}{
+			"database.port": "54x", "database.sslmode": "verify", "llm.gemini.base_url": "ftp://example.com",
+		}, wantErr: []string{"database.host is required", `database.port must be a port number between 1 and 65535 (got "54x")`,
+			`database.sslmode must be one of disable, require (got "verify")`, "llm.gemini.base_url must be an http(s) URL"}},
+		{name: "port out of range", set: map[string]interface{}{
+			"database.host": "localhost", "database.port": "70000",
+		}, wantErr: []string{"database.port must be a port number"}},
+	}
+	for _, tt := range tests {
+		config := viper.New()
+		for k, v := range tt.set {
+			config.Set(k, v)
+		}
+
+		err := validateKeys(config, keys)
+		if len(tt.wantErr) == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Fatalf("%s: expected an error", tt.name)
+		}
+		for _, want := range tt.wantErr {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: error %q does not mention %q", tt.name, err, want)
+			}
+		}
+		for _, unwanted := range tt.unwantErr {
+			if strings.Contains(err.Error(), unwanted) {
+				t.Errorf("%s: error %q mentions %q", tt.name, err, unwanted)
+			}
+		}
+	}
+}
+
+func TestValidateConfigWithoutConfig(t *testing.T) {
+	if err := ValidateAPIConfig(nil); err == nil || !strings.Contains(err.Error(), "no config loaded") {
+		t.Errorf("nil config: got %v", err)
+	}
+}
+
+func TestValidateMigrateConfigIgnoresAPIKeys(t *testing.T) {
+	config := viper.New()
+	config.Set("database.host", "localhost")
+	config.Set("database.port", "5432")
+	config.Set("database.username", "postgres")
+	config.Set("database.dbname", "dinacom")
+
+	if err := ValidateMigrateConfig(config); err != nil {
+		t.Errorf("migrate config: unexpected error %v", err)
+	}
+	err := ValidateAPIConfig(config)
+	if err == nil || !strings.Contains(err.Error(), "llm.gemini.api_key is required") {
+		t.Errorf("api config without api key: got %v", err)
+	}
+}
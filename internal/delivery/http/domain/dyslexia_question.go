@@ -3,14 +3,61 @@ package domain
 var (
 	DYSLEXIA_QUESTION_GENERATE_SUCCESS      = "Berhasil generate pertanyaan"
 	DYSLEXIA_QUESTION_GENERATE_FAILED       = "Gagal generate pertanyaan"
+	DYSLEXIA_QUESTION_GET_TEMPLATES_SUCCESS = "Berhasil mendapatkan template soal"
+	DYSLEXIA_QUESTION_GET_TEMPLATES_FAILED  = "Gagal mendapatkan template soal"
+	DYSLEXIA_QUESTION_CREATE_SUCCESS        = "Berhasil menambahkan soal"
+	DYSLEXIA_QUESTION_CREATE_FAILED         = "Gagal menambahkan soal"
 	DYSLEXIA_QUESTION_SUBMIT_ANSWER_SUCCESS = "Berhasil submit jawaban"
 	DYSLEXIA_QUESTION_SUBMIT_ANSWER_FAILED  = "Gagal submit jawaban"
 	DYSLEXIA_QUESTION_GET_SESSION_SUCCESS   = "Berhasil mendapatkan data session"
 	DYSLEXIA_QUESTION_GET_SESSION_FAILED    = "Gagal mendapatkan data session"
 	DYSLEXIA_QUESTION_GET_REPORT_SUCCESS    = "Berhasil generate report"
 	DYSLEXIA_QUESTION_GET_REPORT_FAILED     = "Gagal generate report"
+	DYSLEXIA_SESSION_START_SUCCESS          = "Berhasil memulai session"
+	DYSLEXIA_SESSION_START_FAILED           = "Gagal memulai session"
+	DYSLEXIA_SESSION_RESUME_SUCCESS         = "Berhasil melanjutkan session"
+	DYSLEXIA_SESSION_RESUME_FAILED          = "Gagal melanjutkan session"
+	DYSLEXIA_SESSION_PROGRESS_SUCCESS       = "Berhasil mendapatkan progress session"
+	DYSLEXIA_SESSION_PROGRESS_FAILED        = "Gagal mendapatkan progress session"
+	DYSLEXIA_SESSION_DRILL_SUCCESS          = "Berhasil mendapatkan soal latihan"
+	DYSLEXIA_SESSION_DRILL_FAILED           = "Gagal mendapatkan soal latihan"
+	DYSLEXIA_ANALYTICS_COHORT_SUCCESS       = "Berhasil mendapatkan analitik kelas"
+	DYSLEXIA_ANALYTICS_COHORT_FAILED        = "Gagal mendapatkan analitik kelas"
+	DYSLEXIA_ANALYTICS_PAIRS_SUCCESS        = "Berhasil mendapatkan analitik pasangan huruf"
+	DYSLEXIA_ANALYTICS_PAIRS_FAILED         = "Gagal mendapatkan analitik pasangan huruf"
+	DYSLEXIA_QUESTION_REGENERATE_SUCCESS    = "Berhasil meregenerasi opsi soal"
+	DYSLEXIA_QUESTION_REGENERATE_FAILED     = "Gagal meregenerasi opsi soal"
+	DYSLEXIA_QUESTION_BULK_GENERATE_SUCCESS = "Berhasil membuat soal secara massal"
+	DYSLEXIA_QUESTION_BULK_GENERATE_FAILED  = "Gagal membuat soal secara massal"
+	DYSLEXIA_ANSWER_REGRADE_SUCCESS         = "Berhasil menilai ulang jawaban"
+	DYSLEXIA_ANSWER_REGRADE_FAILED          = "Gagal menilai ulang jawaban"
+	DYSLEXIA_SESSION_IMPORT_SUCCESS         = "Berhasil mengimpor jawaban"
+	DYSLEXIA_SESSION_IMPORT_FAILED          = "Gagal mengimpor jawaban"
+	DYSLEXIA_SERVE_LOG_SUCCESS              = "Berhasil mendapatkan log soal"
+	DYSLEXIA_SERVE_LOG_FAILED               = "Gagal mendapatkan log soal"
+	DYSLEXIA_QUESTION_PROMPT_SUCCESS        = "Berhasil mendapatkan prompt soal"
+	DYSLEXIA_QUESTION_PROMPT_FAILED         = "Gagal mendapatkan prompt soal"
+	DYSLEXIA_QUESTION_DISTRIBUTION_SUCCESS  = "Berhasil mendapatkan distribusi jawaban soal"
+	DYSLEXIA_QUESTION_DISTRIBUTION_FAILED   = "Gagal mendapatkan distribusi jawaban soal"
+	DYSLEXIA_QUESTION_LIST_SUCCESS          = "Berhasil mendapatkan daftar soal"
+	DYSLEXIA_QUESTION_LIST_FAILED           = "Gagal mendapatkan daftar soal"
+	DYSLEXIA_REPORT_COMPARE_SUCCESS         = "Berhasil membandingkan session"
+	DYSLEXIA_REPORT_COMPARE_FAILED          = "Gagal membandingkan session"
+	DYSLEXIA_REPORT_BATCH_SUCCESS           = "Berhasil mendapatkan report session"
+	DYSLEXIA_REPORT_BATCH_FAILED            = "Gagal mendapatkan report session"
+	DYSLEXIA_USER_TRENDS_SUCCESS            = "Berhasil mendapatkan trend user"
+	DYSLEXIA_USER_TRENDS_FAILED             = "Gagal mendapatkan trend user"
+	DYSLEXIA_USER_DELETE_SUCCESS            = "Berhasil menghapus data user"
+	DYSLEXIA_USER_DELETE_FAILED             = "Gagal menghapus data user"
+	DYSLEXIA_USER_RESTORE_SUCCESS           = "Berhasil memulihkan data user"
+	DYSLEXIA_USER_RESTORE_FAILED            = "Gagal memulihkan data user"
+	DYSLEXIA_USER_PREFERENCES_GET_SUCCESS   = "Berhasil mendapatkan preferensi user"
+	DYSLEXIA_USER_PREFERENCES_GET_FAILED    = "Gagal mendapatkan preferensi user"
+	DYSLEXIA_USER_PREFERENCES_SAVE_SUCCESS  = "Berhasil menyimpan preferensi user"
+	DYSLEXIA_USER_PREFERENCES_SAVE_FAILED   = "Gagal menyimpan preferensi user"
 	DYSLEXIA_CHATBOT_SEND_SUCCESS           = "Berhasil mengirim pesan ke chatbot"
 	DYSLEXIA_CHATBOT_SEND_FAILED            = "Gagal mengirim pesan ke chatbot"
 	DYSLEXIA_CHATBOT_HISTORY_SUCCESS        = "Berhasil mendapatkan riwayat chat"
 	DYSLEXIA_CHATBOT_HISTORY_FAILED         = "Gagal mendapatkan riwayat chat"
+	DYSLEXIA_CHATBOT_TRANSCRIPT_FAILED      = "Gagal mengunduh transkrip chat"
 )
@@ -1,5 +1,12 @@
 package entity
 
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
 type Difficulty string
 
 const (
@@ -8,6 +15,123 @@ const (
 	DifficultyHard   Difficulty = "hard"
 )
 
+// NormalizeDifficulty canonicalizes d to the lowercase enum form ("Easy", " HARD " -> easy, hard) without validating it
+func NormalizeDifficulty(d string) Difficulty {
+	return Difficulty(strings.ToLower(strings.TrimSpace(d)))
+}
+
+// ParseDifficulty normalizes d and rejects anything that is not a registered difficulty level
+func ParseDifficulty(d string) (Difficulty, error) {
+	difficulty := NormalizeDifficulty(d)
+	if _, ok := LookupDifficulty(difficulty); !ok {
+		return "", fmt.Errorf("invalid difficulty %q (use %s)", d, strings.Join(DifficultyNames(), ", "))
+	}
+	return difficulty, nil
+}
+
+// DifficultyLevel describes a selectable difficulty: the word length the AI must respect
+// and the guidance line the generation prompt gives for it
+type DifficultyLevel struct {
+	Name      Difficulty `mapstructure:"name" json:"name"`
+	Guidance  string     `mapstructure:"guidance" json:"guidance"`
+	MinLength int        `mapstructure:"min_length" json:"min_length"`
+	MaxLength int        `mapstructure:"max_length" json:"max_length"` // 0 = no upper bound
+}
+
+// DefaultDifficultyLevels is used unless dyslexia.difficulties configures another set
+var DefaultDifficultyLevels = []DifficultyLevel{
+	{Name: DifficultyEasy, MinLength: 4, MaxLength: 5, Guidance: "Short words (4-5 letters) with ONE confusing letter pair (e.g., bola vs dola, pagi vs qagi)"},
+	{Name: DifficultyMedium, MinLength: 5, MaxLength: 6, Guidance: "Medium words (5-6 letters) with confusing letters in multiple positions (e.g., bunga vs dunga, panas vs qanas)"},
+	{Name: DifficultyHard, MinLength: 6, MaxLength: 0, Guidance: "Longer words (6+ letters) with multiple confusing letter patterns (e.g., beruang vs deruang, membaca vs memdaca)"},
+}
+
+var (
+	difficultyMu     sync.RWMutex
+	difficultyLevels = DefaultDifficultyLevels
+)
+
+// SetDifficultyLevels replaces the registered difficulties; call it once at startup before serving requests
+func SetDifficultyLevels(levels []DifficultyLevel) error {
+	normalized, err := NormalizeDifficultyLevels(levels)
+	if err != nil {
+		return err
+	}
+
+	difficultyMu.Lock()
+	difficultyLevels = normalized
+	difficultyMu.Unlock()
+	return nil
+}
+
+// NormalizeDifficultyLevels validates levels, lowercasing names and filling built-in defaults, without registering them
+func NormalizeDifficultyLevels(levels []DifficultyLevel) ([]DifficultyLevel, error) {
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("at least one difficulty level is required")
+	}
+
+	normalized := make([]DifficultyLevel, 0, len(levels))
+	seen := make(map[Difficulty]bool, len(levels))
+	for _, level := range levels {
+		level.Name = NormalizeDifficulty(string(level.Name))
+		if level.Name == "" || strings.ContainsAny(string(level.Name), " ,") {
+			return nil, fmt.Errorf("invalid difficulty name %q", level.Name)
+		}
+		if seen[level.Name] {
+			return nil, fmt.Errorf("duplicate difficulty %q", level.Name)
+		}
+		// Built-in names keep their guidance and word length unless the config overrides them
+		for _, builtin := range DefaultDifficultyLevels {
+			if builtin.Name != level.Name {
+				continue
+			}
+			if level.Guidance == "" {
+				level.Guidance = builtin.Guidance
+			}
+			if level.MinLength == 0 && level.MaxLength == 0 {
+				level.MinLength, level.MaxLength = builtin.MinLength, builtin.MaxLength
+			}
+		}
+		if level.MinLength < 0 || level.MaxLength < 0 || (level.MaxLength > 0 && level.MaxLength < level.MinLength) {
+			return nil, fmt.Errorf("difficulty %q has invalid word length %d-%d", level.Name, level.MinLength, level.MaxLength)
+		}
+		seen[level.Name] = true
+		normalized = append(normalized, level)
+	}
+	return normalized, nil
+}
+
+// DifficultyLevels returns the registered difficulties in their configured order
+func DifficultyLevels() []DifficultyLevel {
+	difficultyMu.RLock()
+	defer difficultyMu.RUnlock()
+	return append([]DifficultyLevel(nil), difficultyLevels...)
+}
+
+// LookupDifficulty returns the registered level named d
+func LookupDifficulty(d Difficulty) (DifficultyLevel, bool) {
+	difficultyMu.RLock()
+	defer difficultyMu.RUnlock()
+	for _, level := range difficultyLevels {
+		if level.Name == d {
+			return level, true
+		}
+	}
+	return DifficultyLevel{}, false
+}
+
+// DifficultyNames lists the registered difficulty names
+func DifficultyNames() []string {
+	levels := DifficultyLevels()
+	names := make([]string, len(levels))
+	for i, level := range levels {
+		names[i] = string(level.Name)
+	}
+	return names
+}
+
+// LetterPairs - Common confusing letter pairs for dyslexia practice
+var LetterPairs = []string{"b-d", "p-q", "m-w", "n-u", "m-n"}
+
 type Phase string
 
 const (
@@ -22,7 +146,7 @@ type QuestionTemplate struct {
 	Difficulty       Difficulty `json:"difficulty"`
 	TargetLetterPair string     `json:"targetLetterPair"`
 	TargetLetter     string     `json:"targetLetter"`
-	CorrectWord      string     `json:"correctWord"`
+	CorrectWord      string     `json:"correctWord,omitempty"`
 	Distractors      []string   `json:"distractors"`
 }
 
@@ -34,36 +158,159 @@ type GeneratedQuestion struct {
 	TargetLetter     string     `json:"targetLetter"`
 	Options          []string   `json:"options"`
 	Answer           string     `json:"answer,omitempty"`
+	CorrectIndex     *int       `json:"correct_index,omitempty"` // posisi answer di options (0-based), hanya jika answer disertakan
+}
+
+// Alasan soal yang dikembalikan lebih sedikit dari yang diminta
+const (
+	GenerateShortfallCacheExhausted = "cache_exhausted" // cache tidak punya cukup soal yang belum dipakai di session
+	GenerateShortfallDuplicates     = "duplicates"      // soal baru terus sama dengan yang sudah ada
+	GenerateShortfallQuotaExceeded  = "quota_exceeded"  // kuota AI harian user habis, sisa diisi fallback
+	GenerateShortfallAIFailed       = "ai_failed"       // panggilan AI gagal, sisa diisi fallback
+)
+
+// Meta generate soal, hanya dikirim jika soal kurang dari yang diminta atau ditambah dari fallback
+type GenerateMeta struct {
+	Requested int    `json:"requested"`
+	Returned  int    `json:"returned"`
+	Reason    string `json:"reason"`
+	ToppedUp  int    `json:"topped_up,omitempty"` // soal fallback yang ditambahkan (questions.cache_topup_fallback)
+}
+
+// Opsi Generate. Difficulty dan Patterns kosong = preferensi user session (lalu default config);
+// UseAI dan Shuffle diisi eksplisit oleh pemanggil (default handler: true)
+type GenerateOptions struct {
+	Difficulty    Difficulty
+	Count         int
+	IncludeAnswer bool
+	Patterns      []string
+	UseAI         bool
+	SessionID     string // soal yang sudah dipakai di session tidak diulang
+	Shuffle       bool   // false = urutan opsi dan cache stabil
+	Model         string // override LLM opsional, harus ada di llm.allowed_models
+}
+
+// Request untuk generate soal via JSON body
+type GenerateQuestionRequest struct {
+	Difficulty    Difficulty `json:"difficulty" validate:"omitempty,difficulty"`
+	Count         int        `json:"count" validate:"omitempty,min=1,max=10"`
+	Patterns      []string   `json:"patterns" validate:"omitempty,dive,required"`
+	IncludeAnswer bool       `json:"include_answer"`
+	UseAI         *bool      `json:"use_ai"`  // default true
+	Shuffle       *bool      `json:"shuffle"` // default true; false = options unshuffled on every path, cached results in a stable order
+	SessionID     string     `json:"session_id"`
+	TemplateID    string     `json:"template_id"` // serve this bank template instead of random generation
+	Model         string     `json:"model"`       // optional LLM override, must be in llm.allowed_models
+}
+
+// Normalize canonicalizes difficulty before validation so "Easy" and "EASY" are accepted
+func (r *GenerateQuestionRequest) Normalize() {
+	r.Difficulty = NormalizeDifficulty(string(r.Difficulty))
+}
+
+// Query params untuk GET /questions/generate
+type GenerateQuestionQuery struct {
+	Difficulty    Difficulty `query:"difficulty" json:"difficulty" validate:"omitempty,difficulty"`
+	Count         int        `query:"count" json:"count" validate:"omitempty,min=1,max=10"`
+	IncludeAnswer bool       `query:"includeAnswer" json:"includeAnswer"`
+	Patterns      []string   `query:"pattern" json:"pattern" validate:"omitempty,dive,required"` // pattern=b-d,p-q atau pattern=b-d&pattern=p-q
+	UseAI         *bool      `query:"use_ai" json:"use_ai"`                                      // default true
+	Shuffle       *bool      `query:"shuffle" json:"shuffle"`                                    // default true
+	SessionID     string     `query:"session_id" json:"session_id"`
+	TemplateID    string     `query:"template_id" json:"template_id"`
+	Model         string     `query:"model" json:"model"` // optional LLM override, must be in llm.allowed_models
+}
+
+// Normalize lowercases difficulty and splits comma separated patterns
+func (q *GenerateQuestionQuery) Normalize() {
+	q.Difficulty = NormalizeDifficulty(string(q.Difficulty))
+	q.SessionID = strings.TrimSpace(q.SessionID)
+	q.TemplateID = strings.TrimSpace(q.TemplateID)
+	q.Model = strings.TrimSpace(q.Model)
+
+	patterns := []string{}
+	for _, value := range q.Patterns {
+		for _, p := range strings.Split(value, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				patterns = append(patterns, p)
+			}
+		}
+	}
+	q.Patterns = patterns
+}
+
+// Request untuk insert soal manual (admin)
+type CreateQuestionRequest struct {
+	QuestionText     string     `json:"question_text"`
+	Options          []string   `json:"options" validate:"required,min=2,dive,required"`
+	CorrectAnswer    string     `json:"correct_answer" validate:"required"`
+	Difficulty       Difficulty `json:"difficulty" validate:"required,difficulty"`
+	TargetLetterPair string     `json:"target_letter_pair" validate:"required"`
+	TargetLetter     string     `json:"target_letter"`
+}
+
+// Normalize canonicalizes difficulty before validation, like GenerateQuestionRequest
+func (r *CreateQuestionRequest) Normalize() {
+	r.Difficulty = NormalizeDifficulty(string(r.Difficulty))
 }
 
 // Request untuk submit jawaban
 type SubmitAnswerRequest struct {
 	UserID     string `json:"user_id" validate:"required"`
-	SessionID  string `json:"session_id" validate:"required"`
+	SessionID  string `json:"session_id" validate:"required,session_id"`
 	QuestionID string `json:"question_id" validate:"required"`
-	Answer     string `json:"answer" validate:"required"`
+	Answer     string `json:"answer" validate:"required_without_all=AnswerIndex Skipped,excluded_with=AnswerIndex"`
+	// Alternatif untuk answer: index opsi yang dipilih (0-based) pada options soal
+	AnswerIndex *int `json:"answer_index" validate:"omitempty,min=0"`
+	Skipped     bool `json:"skipped"` // "tidak tahu": jawaban kosong, butuh answers.allow_skip
+
+	// Penilaian ad hoc (answers.allow_adhoc): soal buatan integrasi luar yang question_id-nya tidak ada di DB
+	CorrectAnswer    string     `json:"correct_answer" validate:"omitempty,max=100"`
+	Options          []string   `json:"options" validate:"omitempty,dive,required,max=100"` // opsional, harus memuat correct_answer
+	TargetLetterPair string     `json:"target_letter_pair" validate:"omitempty,max=10"`
+	Difficulty       Difficulty `json:"difficulty" validate:"omitempty,difficulty"` // default dyslexia.default_difficulty
+}
+
+func (r *SubmitAnswerRequest) Normalize() {
+	r.CorrectAnswer = strings.TrimSpace(r.CorrectAnswer)
+	r.TargetLetterPair = strings.ToLower(strings.TrimSpace(r.TargetLetterPair))
+	r.Difficulty = NormalizeDifficulty(string(r.Difficulty))
 }
 
 // Response untuk submit jawaban
 type SubmitAnswerResponse struct {
-	IsCorrect     bool   `json:"is_correct"`
-	UserAnswer    string `json:"user_answer"`
-	CorrectAnswer string `json:"correct_answer"`
-	QuestionID    string `json:"question_id"`
-	SessionID     string `json:"session_id"`
+	IsCorrect     bool    `json:"is_correct"`
+	Skipped       bool    `json:"skipped"`
+	PartialCredit float64 `json:"partial_credit"`
+	AttemptNumber int     `json:"attempt_number"`
+	UserAnswer    string  `json:"user_answer"`
+	CorrectAnswer string  `json:"correct_answer"`
+	QuestionID    string  `json:"question_id"`
+	SessionID     string  `json:"session_id"`
+	Corrected     bool    `json:"corrected,omitempty"` // menimpa jawaban sebelumnya (answers.correction_window_seconds)
+
+	// Konteks soal, supaya client tidak perlu fetch ulang
+	QuestionText     string   `json:"question_text"`
+	Options          []string `json:"options"`
+	TargetLetterPair string   `json:"target_letter_pair,omitempty"`
+	Hint             string   `json:"hint,omitempty"`     // hanya untuk jawaban salah
+	Feedback         string   `json:"feedback,omitempty"` // pesan penyemangat, lihat answers.feedback
 }
 
 // User answer log untuk session
 type UserAnswerLog struct {
-	ID               uint   `json:"id"`
-	QuestionID       string `json:"question_id"`
-	QuestionText     string `json:"question_text"`
-	UserAnswer       string `json:"user_answer"`
-	CorrectAnswer    string `json:"correct_answer"`
-	IsCorrect        bool   `json:"is_correct"`
-	Difficulty       string `json:"difficulty"`
-	TargetLetterPair string `json:"target_letter_pair,omitempty"`
-	AnsweredAt       string `json:"answered_at"`
+	ID               uint    `json:"id"`
+	QuestionID       string  `json:"question_id"`
+	QuestionText     string  `json:"question_text"`
+	UserAnswer       string  `json:"user_answer"`
+	CorrectAnswer    string  `json:"correct_answer"`
+	IsCorrect        bool    `json:"is_correct"`
+	Skipped          bool    `json:"skipped"`
+	PartialCredit    float64 `json:"partial_credit"`
+	AttemptNumber    int     `json:"attempt_number"`
+	Difficulty       string  `json:"difficulty"`
+	TargetLetterPair string  `json:"target_letter_pair,omitempty"`
+	AnsweredAt       string  `json:"answered_at"`
 }
 
 // Error pattern analysis
@@ -76,21 +323,364 @@ type ErrorPattern struct {
 
 // Session report response
 type SessionReport struct {
-	SessionID       string         `json:"session_id"`
-	TotalQuestions  int            `json:"total_questions"`
-	CorrectAnswers  int            `json:"correct_answers"`
-	WrongAnswers    int            `json:"wrong_answers"`
-	AccuracyRate    string         `json:"accuracy_rate"`
-	OverallValue    string         `json:"overall_value"`
+	SessionID        string         `json:"session_id"`
+	TotalQuestions   int            `json:"total_questions"`
+	CorrectAnswers   int            `json:"correct_answers"`
+	WrongAnswers     int            `json:"wrong_answers"`
+	SkippedAnswers   int            `json:"skipped_answers"` // dilewati ("tidak tahu"), tidak dihitung sebagai salah huruf
+	SessionScore     float64        `json:"session_score"`   // jumlah partial credit
+	AccuracyRate     string         `json:"accuracy_rate"`
+	OverallValue     string         `json:"overall_value"`
+	ErrorPatterns    []ErrorPattern `json:"error_patterns"`
+	DifficultyStats  map[string]int `json:"difficulty_stats"`
+	AIAnalysys       string         `json:"ai_analysis"`
+	Recommendations  string         `json:"recommendations"`
+	InsufficientData bool           `json:"insufficient_data"` // true jika jawaban terlalu sedikit untuk analisis AI
+}
+
+// Request untuk POST /report/batch
+type BatchReportRequest struct {
+	SessionIDs []string `json:"session_ids" validate:"required,min=1,dive,session_id"`
+}
+
+const (
+	BatchReportStatusReady        = "ready"         // report diambil dari cache
+	BatchReportStatusNotGenerated = "not_generated" // belum ada report, panggil /report/sessions/:session_id
+	BatchReportStatusStale        = "stale"         // jawaban dinilai ulang, report lama; panggil /report/sessions/:session_id
+)
+
+// Satu item hasil POST /report/batch
+type BatchReportItem struct {
+	SessionID string         `json:"session_id"`
+	Status    string         `json:"status"`
+	Report    *SessionReport `json:"report,omitempty"`
+}
+
+// Perubahan error rate satu pasangan huruf antara dua session (persen)
+type LetterPairDelta struct {
+	LetterPair     string   `json:"letter_pair"`
+	ErrorRateA     *float64 `json:"error_rate_a"`     // null jika tidak dilatih di session A
+	ErrorRateB     *float64 `json:"error_rate_b"`     // null jika tidak dilatih di session B
+	ErrorRateDelta *float64 `json:"error_rate_delta"` // B - A, negatif berarti membaik
+}
+
+// Perbandingan dua session (B dibanding A)
+type SessionComparison struct {
+	SessionA         string            `json:"session_a"`
+	SessionB         string            `json:"session_b"`
+	AccuracyA        float64           `json:"accuracy_a"`
+	AccuracyB        float64           `json:"accuracy_b"`
+	AccuracyDelta    float64           `json:"accuracy_delta"` // B - A dalam poin persen
+	LetterPairDeltas []LetterPairDelta `json:"letter_pair_deltas"`
+	Verdict          string            `json:"verdict"` // improved, declined, similar
+}
+
+// Hasil regenerasi opsi soal secara massal (admin)
+type RegenerateResult struct {
+	Matched   int      `json:"matched"`
+	Updated   int      `json:"updated"`
+	Failed    int      `json:"failed"`
+	Skipped   int      `json:"skipped"` // tidak diproses karena request dibatalkan
+	FailedIDs []string `json:"failed_ids"`
+}
+
+// Request generate soal massal untuk mengisi cache (admin). Tanpa targets, semua difficulty x letter pair
+// masing-masing dibuat sebanyak count_per_target.
+type BulkGenerateRequest struct {
+	Targets        []BulkGenerateTarget `json:"targets" validate:"omitempty,max=100,dive"`
+	CountPerTarget int                  `json:"count_per_target" validate:"omitempty,min=1,max=100"`
+}
+
+// Normalize canonicalizes the difficulty of every target before validation
+func (r *BulkGenerateRequest) Normalize() {
+	for i := range r.Targets {
+		r.Targets[i].Difficulty = NormalizeDifficulty(string(r.Targets[i].Difficulty))
+	}
+}
+
+// Jumlah soal yang dibuat untuk satu kombinasi difficulty dan letter pair
+type BulkGenerateTarget struct {
+	Difficulty       Difficulty `json:"difficulty" validate:"required,difficulty"`
+	TargetLetterPair string     `json:"target_letter_pair" validate:"required"` // contoh: "b-d"
+	Count            int        `json:"count" validate:"required,min=1,max=100"`
+}
+
+// Ringkasan generate massal; skipped = tidak dicoba karena batas panggilan AI atau request dibatalkan
+type BulkGenerateResult struct {
+	Requested int                        `json:"requested"`
+	Created   int                        `json:"created"`
+	Failed    int                        `json:"failed"`
+	Skipped   int                        `json:"skipped"`
+	Targets   []BulkGenerateTargetResult `json:"targets"`
+}
+
+type BulkGenerateTargetResult struct {
+	Difficulty       Difficulty `json:"difficulty"`
+	TargetLetterPair string     `json:"target_letter_pair"`
+	Requested        int        `json:"requested"`
+	Created          int        `json:"created"`
+	Failed           int        `json:"failed"`
+	Skipped          int        `json:"skipped"`
+}
+
+// Payload webhook user.accuracy_drop: akurasi session jauh di bawah rata-rata session sebelumnya
+type AccuracyDropAlert struct {
+	UserID             string  `json:"user_id"`
+	SessionID          string  `json:"session_id"`
+	SessionAccuracy    float64 `json:"session_accuracy"`    // persen
+	HistoricalAccuracy float64 `json:"historical_accuracy"` // rata-rata persen session sebelumnya
+	Delta              float64 `json:"delta"`               // session - historis (negatif = turun)
+	Threshold          float64 `json:"threshold"`
+	HistorySessions    int     `json:"history_sessions"`
+}
+
+// Hasil penilaian ulang jawaban terhadap jawaban benar soal saat ini (admin)
+type RegradeResult struct {
+	Checked             int      `json:"checked"`              // jawaban yang dinilai ulang (dilewati tidak dihitung)
+	Updated             int      `json:"updated"`              // jawaban yang nilainya berubah
+	Flipped             int      `json:"flipped"`              // is_correct berubah
+	FlippedToCorrect    int      `json:"flipped_to_correct"`   // salah -> benar
+	FlippedToWrong      int      `json:"flipped_to_wrong"`     // benar -> salah
+	MissingQuestions    int      `json:"missing_questions"`    // soal sudah tidak ada, jawaban tidak diubah
+	InvalidatedSessions []string `json:"invalidated_sessions"` // analisis tersimpan dibuat ulang pada report berikutnya
+}
+
+// Catatan soal yang pernah dikirim dalam session
+type ServeLogItem struct {
+	QuestionID string `json:"question_id"`
+	UserID     string `json:"user_id"`
+	SessionID  string `json:"session_id"`
+	ServedAt   string `json:"served_at"`
+}
+
+// Prompt LLM yang menghasilkan sebuah soal (audit)
+type QuestionPrompt struct {
+	QuestionID  string `json:"question_id"`
+	GeneratedBy string `json:"generated_by"`
+	Prompt      string `json:"prompt"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// Filter daftar soal admin; kosong = semua
+type QuestionListFilter struct {
+	GeneratedBy string // prefix, mis. "api.openai.com/" atau "api.openai.com/gpt-4o-mini"
+	Difficulty  string
+	LetterPair  string
+}
+
+// Soal tersimpan beserta model yang menghasilkannya
+type AdminQuestionItem struct {
+	QuestionID       string   `json:"question_id"`
+	Difficulty       string   `json:"difficulty"`
+	TargetLetterPair string   `json:"target_letter_pair"`
+	CorrectAnswer    string   `json:"correct_answer"`
+	Options          []string `json:"options"`
+	GeneratedBy      string   `json:"generated_by"` // provider/model, template, manual
+	UsageCount       int      `json:"usage_count"`
+	CreatedAt        string   `json:"created_at"`
+}
+
+// Berapa kali sebuah opsi dipilih untuk satu soal
+type OptionCount struct {
+	Option    string `json:"option"`
+	Count     int    `json:"count"`
+	IsCorrect bool   `json:"is_correct"`
+	InOptions bool   `json:"in_options"` // false untuk jawaban yang tidak ada di opsi soal
+}
+
+// Distribusi jawaban satu soal, untuk menemukan distractor yang terlalu menjebak
+type AnswerDistribution struct {
+	QuestionID     string        `json:"question_id"`
+	CorrectAnswer  string        `json:"correct_answer"`
+	TotalAnswers   int           `json:"total_answers"`
+	WrongAnswers   int           `json:"wrong_answers"`
+	SkippedAnswers int           `json:"skipped_answers"`
+	ErrorRate      string        `json:"error_rate"` // salah / (total - dilewati)
+	Options        []OptionCount `json:"options"`    // paling sering dipilih dulu
+}
+
+// Jawaban yang sudah dinilai secara offline (kiosk)
+type ImportedAnswer struct {
+	QuestionID string    `json:"question_id" validate:"required"`
+	UserAnswer string    `json:"user_answer" validate:"required"`
+	IsCorrect  bool      `json:"is_correct"`
+	AnsweredAt time.Time `json:"answered_at" validate:"required"`
+}
+
+// Request untuk import jawaban offline
+type ImportAnswersRequest struct {
+	SessionID string           `json:"-" params:"session_id" validate:"required,session_id"`
+	UserID    string           `json:"user_id" validate:"required"`
+	Answers   []ImportedAnswer `json:"answers" validate:"required,min=1,max=500,dive"`
+}
+
+// Jawaban yang penilaian client berbeda dengan soal yang tersimpan
+type ImportConflict struct {
+	QuestionID      string `json:"question_id"`
+	ClientIsCorrect bool   `json:"client_is_correct"`
+	ServerIsCorrect bool   `json:"server_is_correct"` // nilai ini yang disimpan
+}
+
+// Hasil import jawaban offline
+type ImportAnswersResult struct {
+	SessionID  string           `json:"session_id"`
+	Imported   int              `json:"imported"`
+	Skipped    int              `json:"skipped"`    // sudah pernah dijawab
+	Unverified int              `json:"unverified"` // soal tidak ada di server, penilaian client dipakai
+	Conflicts  []ImportConflict `json:"conflicts"`
+}
+
+// Parameter path session_id untuk endpoint tanpa body
+type SessionPathParams struct {
+	SessionID string `json:"-" params:"session_id" validate:"required,session_id"`
+}
+
+// Request untuk memulai session
+type StartSessionRequest struct {
+	UserID      string     `json:"user_id" validate:"required"`
+	SessionID   string     `json:"session_id" validate:"required,session_id"`
+	TargetCount int        `json:"target_count" validate:"required,min=1"`
+	Difficulty  Difficulty `json:"difficulty" validate:"omitempty,difficulty"`
+}
+
+// Normalize canonicalizes the optional difficulty before validation
+func (r *StartSessionRequest) Normalize() {
+	r.Difficulty = NormalizeDifficulty(string(r.Difficulty))
+}
+
+// Parameter path user_id untuk endpoint tanpa body
+type UserPathParams struct {
+	UserID string `json:"-" params:"user_id" validate:"required,user_id"`
+}
+
+// Request untuk menyimpan preferensi user (menggantikan seluruh preferensi sebelumnya)
+type UserPreferencesRequest struct {
+	UserID              string     `json:"-" params:"user_id" validate:"required,user_id"`
+	PreferredDifficulty Difficulty `json:"preferred_difficulty" validate:"omitempty,difficulty"`
+	LetterPairs         []string   `json:"letter_pairs" validate:"omitempty,dive,required"` // contoh: ["b-d","p-q"]
+	Language            string     `json:"language" validate:"omitempty,oneof=id en"`
+	Voice               string     `json:"voice" validate:"omitempty,max=50"`
+}
+
+func (r *UserPreferencesRequest) Normalize() {
+	r.PreferredDifficulty = NormalizeDifficulty(string(r.PreferredDifficulty))
+	r.Language = strings.ToLower(strings.TrimSpace(r.Language))
+	r.Voice = strings.TrimSpace(r.Voice)
+}
+
+// Preferensi user; difficulty dan letter_pairs jadi default generate jika request tidak mengisinya
+type UserPreferences struct {
+	UserID              string   `json:"user_id"`
+	PreferredDifficulty string   `json:"preferred_difficulty"`
+	LetterPairs         []string `json:"letter_pairs"`
+	Language            string   `json:"language"`
+	Voice               string   `json:"voice"`
+	UpdatedAt           string   `json:"updated_at,omitempty"` // kosong = belum pernah disimpan
+}
+
+// Session info response
+type SessionInfo struct {
+	SessionID   string `json:"session_id"`
+	UserID      string `json:"user_id"`
+	TargetCount int    `json:"target_count"`
+	Difficulty  string `json:"difficulty"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// Progress session terhadap target_count; answered_count menghitung soal unik (re-attempt dihitung sekali)
+type SessionProgress struct {
+	SessionID         string  `json:"session_id"`
+	AnsweredCount     int     `json:"answered_count"`
+	TargetCount       int     `json:"target_count"`
+	RemainingCount    int     `json:"remaining_count"`
+	CompletionPercent float64 `json:"completion_percent"` // 0-100
+	Completed         bool    `json:"completed"`
+}
+
+// Response untuk resume session
+type SessionResumeResponse struct {
+	SessionID      string              `json:"session_id"`
+	AnsweredCount  int                 `json:"answered_count"`
+	TargetCount    int                 `json:"target_count"`
+	RemainingCount int                 `json:"remaining_count"`
+	Questions      []GeneratedQuestion `json:"questions"`
+}
+
+// Query params untuk GET /sessions/:session_id/drill
+type DrillQuery struct {
+	Pair       string     `query:"pair" json:"pair" validate:"required"`
+	Difficulty Difficulty `query:"difficulty" json:"difficulty" validate:"omitempty,difficulty"`
+	Target     float64    `query:"target" json:"target" validate:"omitempty,gt=0,lte=100"` // akurasi minimal (persen)
+	Window     int        `query:"window" json:"window" validate:"omitempty,min=1,max=50"` // jumlah jawaban terakhir yang dihitung
+	UseAI      *bool      `query:"use_ai" json:"use_ai"`                                   // default true
+}
+
+func (q *DrillQuery) Normalize() {
+	q.Pair = strings.ToLower(strings.TrimSpace(q.Pair))
+	q.Difficulty = NormalizeDifficulty(string(q.Difficulty))
+}
+
+// Status latihan satu pasangan huruf: soal berikutnya, atau mastered
+type DrillResponse struct {
+	SessionID       string             `json:"session_id"`
+	LetterPair      string             `json:"letter_pair"`
+	Mastered        bool               `json:"mastered"`
+	Target          float64            `json:"target"`
+	Window          int                `json:"window"`
+	WindowAnswers   int                `json:"window_answers"` // jawaban pasangan ini yang masuk window (<= window)
+	RollingAccuracy string             `json:"rolling_accuracy"`
+	Question        *GeneratedQuestion `json:"question,omitempty"` // kosong jika sudah mastered
+}
+
+// Cohort analytics response (aggregated across users)
+type CohortAnalytics struct {
+	UserIDs         []string       `json:"user_ids"`
+	ActiveUsers     int            `json:"active_users"`
+	TotalAnswers    int            `json:"total_answers"`
+	AverageAccuracy string         `json:"average_accuracy"`
 	ErrorPatterns   []ErrorPattern `json:"error_patterns"`
 	DifficultyStats map[string]int `json:"difficulty_stats"`
-	AIAnalysys      string         `json:"ai_analysis"`
-	Recommendations string         `json:"recommendations"`
+	From            string         `json:"from,omitempty"`
+	To              string         `json:"to,omitempty"`
+}
+
+// Tingkat kesalahan global per pasangan huruf (semua user)
+type PairAnalytics struct {
+	Difficulty   Difficulty     `json:"difficulty,omitempty"`
+	TotalAnswers int            `json:"total_answers"`
+	Pairs        []ErrorPattern `json:"pairs"` // tersulit dulu; pasangan tanpa data di akhir dengan total_count 0
+}
+
+// Jumlah data user yang dihapus / dipulihkan
+type UserDataResult struct {
+	UserID         string `json:"user_id"`
+	Answers        int64  `json:"answers"`
+	Sessions       int64  `json:"sessions"`
+	AnalysisCaches int64  `json:"analysis_caches"`
+	ChatMessages   int64  `json:"chat_messages"`
+	ServeLogs      int64  `json:"serve_logs"`
+}
+
+// Satu titik trend: akurasi satu pasangan huruf dalam satu session
+type TrendPoint struct {
+	SessionID    string `json:"session_id"`
+	StartedAt    string `json:"started_at"`
+	TotalCount   int    `json:"total_count"`
+	CorrectCount int    `json:"correct_count"`
+	AccuracyRate string `json:"accuracy_rate"`
+}
+
+// Trend akurasi per pasangan huruf lintas session (kronologis)
+type LetterPairTrend struct {
+	LetterPair string       `json:"letter_pair"`
+	Points     []TrendPoint `json:"points"`
 }
 
 // Chat request
 type ChatRequest struct {
-	Message string `json:"message" validate:"required"`
+	SessionID string `json:"-" params:"session_id" validate:"required,session_id"`
+	Message   string `json:"message" validate:"required,notblank,max=2000"`
+	Model     string `json:"model"` // optional LLM override, must be in llm.allowed_models
 }
 
 // Chat response
@@ -105,3 +695,20 @@ type ChatHistoryItem struct {
 	Message   string `json:"message"`
 	CreatedAt string `json:"created_at"`
 }
+
+const (
+	TranscriptFormatMarkdown = "md"
+	TranscriptFormatText     = "txt"
+)
+
+// Query untuk unduh transkrip chat
+type ChatTranscriptQuery struct {
+	Format string `query:"format" json:"format" validate:"omitempty,oneof=md txt"` // default md
+}
+
+// Transkrip chat yang siap diunduh sebagai file
+type ChatTranscript struct {
+	Filename    string
+	ContentType string
+	Body        []byte
+}
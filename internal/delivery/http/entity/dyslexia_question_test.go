@@ -0,0 +1,120 @@
+package entity
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseDifficulty(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    Difficulty
+		wantErr string
+	}{
+		{name: "canonical", in: "easy", want: DifficultyEasy},
+		{name: "mixed case and spaces", in: " HaRd ", want: DifficultyHard},
+		{name: "upper case", in: "MEDIUM", want: DifficultyMedium},
+		{name: "unknown", in: "extreme", wantErr: `invalid difficulty "extreme" (use easy, medium, hard)`},
+		{name: "empty", in: "", wantErr: `invalid difficulty ""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDifficulty(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDifficulty(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseDifficulty(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+// Every request boundary canonicalizes difficulty before validation
+func TestRequestNormalize(t *testing.T) {
+	generate := GenerateQuestionRequest{Difficulty: " Easy "}
+	generate.Normalize()
+	create := CreateQuestionRequest{Difficulty: "MEDIUM"}
+	create.Normalize()
+	start := StartSessionRequest{Difficulty: "Hard"}
+	start.Normalize()
+
+	got := []Difficulty{generate.Difficulty, create.Difficulty, start.Difficulty}
+	want := []Difficulty{DifficultyEasy, DifficultyMedium, DifficultyHard}
+	if !slices.Equal(got, want) {
+		t.Errorf("normalized difficulties = %q, want %q", got, want)
+	}
+}
+
+func TestGenerateQuestionQueryNormalize(t *testing.T) {
+	q := GenerateQuestionQuery{
+		Difficulty: "EASY",
+		Patterns:   []string{"b-d, p-q", " ", "m-w,,"},
+		SessionID:  " sess-1 ",
+		TemplateID: " tpl-1",
+		Model:      "gemini ",
+	}
+	q.Normalize()
+	if q.Difficulty != DifficultyEasy || q.SessionID != "sess-1" || q.TemplateID != "tpl-1" || q.Model != "gemini" {
+		t.Errorf("normalized query = %+v", q)
+	}
+	if want := []string{"b-d", "p-q", "m-w"}; !slices.Equal(q.Patterns, want) {
+		t.Errorf("patterns = %q, want %q", q.Patterns, want)
+	}
+}
+
+// Registered levels replace the built-in three: names are normalized, built-in names keep their defaults,
+// and ParseDifficulty follows the registered set
+func TestSetDifficultyLevels(t *testing.T) {
+	t.Cleanup(func() { _ = SetDifficultyLevels(DefaultDifficultyLevels) })
+
+	err := SetDifficultyLevels([]DifficultyLevel{
+		{Name: " Beginner ", MinLength: 3, MaxLength: 4, Guidance: "Very short words"},
+		{Name: "easy"},
+		{Name: "challenge", MinLength: 8},
+	})
+	if err != nil {
+		t.Fatalf("SetDifficultyLevels: %v", err)
+	}
+	if got := DifficultyNames(); !slices.Equal(got, []string{"beginner", "easy", "challenge"}) {
+		t.Errorf("DifficultyNames = %v, want the configured order", got)
+	}
+	if easy, _ := LookupDifficulty(DifficultyEasy); easy.MinLength != 4 || easy.MaxLength != 5 || easy.Guidance == "" {
+		t.Errorf("easy = %+v, want the built-in word length and guidance", easy)
+	}
+	if got, err := ParseDifficulty("CHALLENGE"); err != nil || got != "challenge" {
+		t.Errorf("ParseDifficulty(CHALLENGE) = %q, %v; want challenge", got, err)
+	}
+	if _, err := ParseDifficulty("hard"); err == nil || !strings.Contains(err.Error(), "use beginner, easy, challenge") {
+		t.Errorf("ParseDifficulty(hard) error = %v, want it rejected with the registered names", err)
+	}
+}
+
+func TestNormalizeDifficultyLevelsRejectsBadLevels(t *testing.T) {
+	tests := []struct {
+		name    string
+		levels  []DifficultyLevel
+		wantErr string
+	}{
+		{name: "none", wantErr: "at least one difficulty level"},
+		{name: "blank name", levels: []DifficultyLevel{{Name: " "}}, wantErr: "invalid difficulty name"},
+		{name: "name with a comma", levels: []DifficultyLevel{{Name: "easy,hard"}}, wantErr: "invalid difficulty name"},
+		{name: "duplicate", levels: []DifficultyLevel{{Name: "easy"}, {Name: "EASY"}}, wantErr: "duplicate difficulty"},
+		{name: "max below min", levels: []DifficultyLevel{{Name: "beginner", MinLength: 5, MaxLength: 3}}, wantErr: "invalid word length"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NormalizeDifficultyLevels(tt.levels); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+			if got := DifficultyNames(); !slices.Equal(got, []string{"easy", "medium", "hard"}) {
+				t.Errorf("registered levels changed to %v", got)
+			}
+		})
+	}
+}
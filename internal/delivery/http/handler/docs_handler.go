@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/evandrarf/dinacom-be/internal/pkg/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	DocsHandler interface {
+		OpenAPI(ctx *fiber.Ctx) error
+		SwaggerUI(ctx *fiber.Ctx) error
+	}
+
+	docsHandler struct {
+		title string
+		once  sync.Once
+		spec  map[string]any
+	}
+)
+
+func NewDocsHandler(title string) DocsHandler {
+	if title == "" {
+		title = "dinacom-be"
+	}
+	return &docsHandler{title: title}
+}
+
+// GET /openapi.json
+func (h *docsHandler) OpenAPI(ctx *fiber.Ctx) error {
+	h.once.Do(func() {
+		h.spec = buildOpenAPISpec(h.title).Document()
+	})
+	return ctx.JSON(h.spec)
+}
+
+// GET /docs
+func (h *docsHandler) SwaggerUI(ctx *fiber.Ctx) error {
+	ctx.Type("html")
+	return ctx.SendString(swaggerUIPage)
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>API Docs</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>`
+
+// buildOpenAPISpec lists every route registered in route.SetupDyslexiaQuestionRoute; keep both in sync
+// generateMetaNote documents the non-paginated meta of the generate endpoints
+const generateMetaNote = "When fewer questions than requested are available, or fallback questions topped up the cache, " +
+	"the response carries meta {requested, returned, reason (cache_exhausted, duplicates, quota_exceeded, ai_failed), topped_up}."
+
+func buildOpenAPISpec(title string) *openapi.Spec {
+	spec := openapi.NewSpec(title, "1.0.0")
+
+	sessionPath := openapi.Param{Name: "session_id", In: "path", Type: "string"}
+	userPath := openapi.Param{Name: "user_id", In: "path", Type: "string"}
+	difficulty := openapi.Param{Name: "difficulty", In: "query", Type: "string", Description: strings.Join(entity.DifficultyNames(), ", ")}
+	includeAnswer := openapi.Param{Name: "includeAnswer", In: "query", Type: "boolean", Description: "only honoured when dyslexia.allow_include_answer is enabled"}
+	useAI := openapi.Param{Name: "use_ai", In: "query", Type: "boolean", Description: "default true"}
+	from := openapi.Param{Name: "from", In: "query", Type: "string", Description: "YYYY-MM-DD or RFC3339"}
+	to := openapi.Param{Name: "to", In: "query", Type: "string", Description: "YYYY-MM-DD or RFC3339"}
+	page := openapi.Param{Name: "page", In: "query", Type: "integer"}
+
+	// Questions
+	spec.Add("GET", "/questions/generate", openapi.Operation{
+		Summary: "Generate questions", Tag: "questions",
+		Params: []openapi.Param{
+			difficulty,
+			{Name: "count", In: "query", Type: "integer", Description: "1-10"},
+			includeAnswer,
+			{Name: "pattern", In: "query", Type: "string", Description: "comma separated letter pairs, e.g. b-d,p-q"},
+			useAI,
+			{Name: "session_id", In: "query", Type: "string"},
+			{Name: "template_id", In: "query", Type: "string", Description: "serve a specific bank template"},
+			{Name: "shuffle", In: "query", Type: "boolean", Description: "default true; false keeps options unshuffled (correct answer first for new questions) and returns cached questions in a stable order"},
+			{Name: "model", In: "query", Type: "string", Description: "LLM override, must be listed in llm.allowed_models"},
+		},
+		Response:    []entity.GeneratedQuestion{},
+		Description: generateMetaNote,
+	})
+	spec.Add("POST", "/questions/generate", openapi.Operation{
+		Summary: "Generate questions (JSON body)", Tag: "questions",
+		Body: entity.GenerateQuestionRequest{}, Response: []entity.GeneratedQuestion{},
+		Description: generateMetaNote,
+	})
+	spec.Add("GET", "/questions/templates", openapi.Operation{
+		Summary: "List question bank templates", Tag: "questions",
+		Params:   []openapi.Param{difficulty, includeAnswer, page, {Name: "limit", In: "query", Type: "integer"}},
+		Response: []entity.QuestionTemplate{}, Paginated: true,
+	})
+	spec.Add("GET", "/questions/fallback", openapi.Operation{
+		Summary: "Preview a deterministic fallback question", Tag: "questions",
+		Params: []openapi.Param{
+			difficulty,
+			{Name: "pattern", In: "query", Type: "string", Required: true},
+			{Name: "seed", In: "query", Type: "integer"},
+		},
+		Response: entity.GeneratedQuestion{},
+	})
+	spec.Add("POST", "/questions/answer", openapi.Operation{
+		Summary: "Submit an answer", Tag: "questions",
+		Body: entity.SubmitAnswerRequest{}, Response: entity.SubmitAnswerResponse{},
+		Description: "With answers.allow_adhoc, a question_id that is not in the DB is graded against the inline correct_answer (optionally options, target_letter_pair, difficulty) and stored as an ad hoc question.",
+	})
+	spec.Add("GET", "/questions/sessions/:session_id", openapi.Operation{
+		Summary: "List answers of a session", Tag: "questions",
+		Description: "Sessions started with a target_count report their progress in meta.",
+		Params:      []openapi.Param{sessionPath}, Response: []entity.UserAnswerLog{},
+	})
+
+	// Sessions
+	spec.Add("POST", "/sessions", openapi.Operation{
+		Summary: "Start a session", Tag: "sessions",
+		Body: entity.StartSessionRequest{}, Response: entity.SessionInfo{},
+	})
+	spec.Add("GET", "/sessions/:session_id/resume", openapi.Operation{
+		Summary: "Resume a session", Tag: "sessions",
+		Params: []openapi.Param{sessionPath, useAI}, Response: entity.SessionResumeResponse{},
+	})
+	spec.Add("GET", "/sessions/:session_id/progress", openapi.Operation{
+		Summary: "Answered questions against the session's target_count", Tag: "sessions",
+		Params: []openapi.Param{sessionPath}, Response: entity.SessionProgress{},
+	})
+	spec.Add("GET", "/sessions/:session_id/drill", openapi.Operation{
+		Summary: "Next question of a letter-pair drill, or mastered", Tag: "sessions",
+		Params: []openapi.Param{
+			sessionPath,
+			{Name: "pair", In: "query", Type: "string", Required: true},
+			difficulty,
+			{Name: "target", In: "query", Type: "number", Description: "accuracy percent, default sessions.drill.target_accuracy"},
+			{Name: "window", In: "query", Type: "integer", Description: "last N answers to the pair, default sessions.drill.window"},
+			useAI,
+		},
+		Response: entity.DrillResponse{},
+	})
+
+	spec.Add("POST", "/sessions/:session_id/import", openapi.Operation{
+		Summary: "Import answers graded offline", Tag: "sessions",
+		Params: []openapi.Param{sessionPath}, Body: entity.ImportAnswersRequest{}, Response: entity.ImportAnswersResult{},
+	})
+
+	// Reports
+	spec.Add("GET", "/report/sessions/:session_id", openapi.Operation{
+		Summary: "Session report with AI analysis", Tag: "report",
+		Params: []openapi.Param{sessionPath}, Response: entity.SessionReport{},
+	})
+	spec.Add("GET", "/report/compare", openapi.Operation{
+		Summary: "Compare two sessions", Tag: "report",
+		Params: []openapi.Param{
+			{Name: "a", In: "query", Type: "string", Required: true},
+			{Name: "b", In: "query", Type: "string", Required: true},
+			{Name: "same_user", In: "query", Type: "boolean"},
+		},
+		Response: entity.SessionComparison{},
+	})
+	spec.Add("POST", "/report/batch", openapi.Operation{
+		Summary: "Cached reports for many sessions", Tag: "report",
+		Body: entity.BatchReportRequest{}, Response: []entity.BatchReportItem{},
+		Description: "Never calls the LLM; sessions without a cached report have status not_generated. Capped by report.batch_max_sessions.",
+	})
+
+	// Analytics and users
+	spec.Add("GET", "/analytics/cohort", openapi.Operation{
+		Summary: "Cohort analytics", Tag: "analytics",
+		Params: []openapi.Param{
+			{Name: "user_ids", In: "query", Type: "string", Required: true, Description: "comma separated"},
+			from, to,
+		},
+		Response: entity.CohortAnalytics{},
+	})
+	spec.Add("GET", "/analytics/pairs", openapi.Operation{
+		Summary: "Global error rate per letter pair", Tag: "analytics",
+		Params:      []openapi.Param{difficulty},
+		Response:    entity.PairAnalytics{},
+		Description: "Across all users, hardest pair first; known pairs without answers are listed last with total_count 0.",
+	})
+	spec.Add("GET", "/users/:user_id/trends", openapi.Operation{
+		Summary: "Per-letter-pair accuracy trend", Tag: "users",
+		Params:   []openapi.Param{userPath, {Name: "pair", In: "query", Type: "string"}, from, to},
+		Response: []entity.LetterPairTrend{},
+	})
+	spec.Add("GET", "/users/:user_id/preferences", openapi.Operation{
+		Summary: "User preferences", Tag: "users",
+		Params: []openapi.Param{userPath}, Response: entity.UserPreferences{},
+	})
+	spec.Add("PUT", "/users/:user_id/preferences", openapi.Operation{
+		Summary: "Save user preferences", Tag: "users",
+		Params: []openapi.Param{userPath}, Body: entity.UserPreferencesRequest{}, Response: entity.UserPreferences{},
+		Description: "Replaces all preferences. preferred_difficulty and letter_pairs become the defaults of /questions/generate when a request with a session_id of this user omits difficulty or pattern.",
+	})
+	spec.Add("DELETE", "/users/:user_id", openapi.Operation{
+		Summary: "Erase a user's data", Tag: "users", AdminOnly: true,
+		Params: []openapi.Param{userPath}, Response: entity.UserDataResult{},
+	})
+	spec.Add("POST", "/users/:user_id/restore", openapi.Operation{
+		Summary: "Restore erased user data within the grace period", Tag: "users", AdminOnly: true,
+		Params: []openapi.Param{userPath}, Response: entity.UserDataResult{},
+	})
+
+	// Admin
+	spec.Add("POST", "/admin/questions", openapi.Operation{
+		Summary: "Insert a hand-authored question", Tag: "admin", AdminOnly: true,
+		Body: entity.CreateQuestionRequest{}, Response: entity.GeneratedQuestion{},
+	})
+	spec.Add("POST", "/admin/questions/regenerate", openapi.Operation{
+		Summary: "Regenerate options of cached questions", Tag: "admin", AdminOnly: true,
+		Params:   []openapi.Param{{Name: "pattern", In: "query", Type: "string", Required: true}, difficulty},
+		Response: entity.RegenerateResult{},
+	})
+	spec.Add("POST", "/admin/questions/bulk-generate", openapi.Operation{
+		Summary: "Generate and cache AI questions per difficulty and letter pair", Tag: "admin", AdminOnly: true,
+		Description: "Without targets every difficulty and letter pair gets count_per_target questions. " +
+			"Runs synchronously: at most questions.bulk_generate.max_llm_calls questions are attempted within " +
+			"questions.bulk_generate.timeout_seconds (default 300); the rest are reported as skipped.",
+		Body: entity.BulkGenerateRequest{}, Response: entity.BulkGenerateResult{},
+	})
+	spec.Add("GET", "/admin/questions", openapi.Operation{
+		Summary: "List cached questions with the model that generated them", Tag: "admin", AdminOnly: true,
+		Params: []openapi.Param{
+			{Name: "generated_by", In: "query", Type: "string", Description: "prefix of provider/model, e.g. api.openai.com/gpt-4o-mini, or template/manual; ad hoc grading records are listed only with generated_by=adhoc"},
+			difficulty,
+			{Name: "pair", In: "query", Type: "string", Description: "letter pair, e.g. b-d"},
+			page,
+			{Name: "per_page", In: "query", Type: "integer", Description: "default 50, max 100"},
+		},
+		Response: []entity.AdminQuestionItem{}, Paginated: true,
+	})
+	spec.Add("GET", "/admin/questions/:question_id/prompt", openapi.Operation{
+		Summary: "Prompt that generated a question", Tag: "admin", AdminOnly: true,
+		Params:      []openapi.Param{{Name: "question_id", In: "path", Type: "string"}},
+		Response:    entity.QuestionPrompt{},
+		Description: "Only available for questions generated while llm.store_prompts was enabled.",
+	})
+	spec.Add("GET", "/admin/questions/:question_id/distribution", openapi.Operation{
+		Summary: "How often each option of a question was chosen", Tag: "admin", AdminOnly: true,
+		Params:   []openapi.Param{{Name: "question_id", In: "path", Type: "string"}},
+		Response: entity.AnswerDistribution{},
+	})
+	spec.Add("POST", "/admin/questions/:question_id/regrade", openapi.Operation{
+		Summary: "Regrade every answer to a question against its current correct answer", Tag: "admin", AdminOnly: true,
+		Params:      []openapi.Param{{Name: "question_id", In: "path", Type: "string"}},
+		Response:    entity.RegradeResult{},
+		Description: "Cached analyses of sessions whose answers changed are invalidated and regenerated by the next report.",
+	})
+	spec.Add("POST", "/admin/sessions/:session_id/regrade", openapi.Operation{
+		Summary: "Regrade the answers of a session against the current correct answers", Tag: "admin", AdminOnly: true,
+		Params: []openapi.Param{sessionPath}, Response: entity.RegradeResult{},
+	})
+	spec.Add("GET", "/admin/sessions/:session_id/served", openapi.Operation{
+		Summary: "Questions served to a session", Tag: "admin", AdminOnly: true,
+		Params: []openapi.Param{sessionPath}, Response: []entity.ServeLogItem{},
+	})
+
+	// Chatbot
+	spec.Add("POST", "/chatbot/sessions/:session_id", openapi.Operation{
+		Summary: "Chat about a session report", Tag: "chatbot",
+		Params: []openapi.Param{sessionPath}, Body: entity.ChatRequest{}, Response: entity.ChatResponse{},
+	})
+	spec.Add("GET", "/chatbot/sessions/:session_id/history", openapi.Operation{
+		Summary: "Chat history", Tag: "chatbot",
+		Params:   []openapi.Param{sessionPath, page, {Name: "per_page", In: "query", Type: "integer"}},
+		Response: []entity.ChatHistoryItem{}, Paginated: true,
+	})
+	spec.Add("GET", "/chatbot/sessions/:session_id/transcript", openapi.Operation{
+		Summary: "Download the chat transcript", Tag: "chatbot",
+		Params:      []openapi.Param{sessionPath, {Name: "format", In: "query", Type: "string", Description: "md (default) or txt"}},
+		Description: "Returns the whole conversation, oldest first, as a text/markdown or text/plain attachment instead of the JSON envelope.",
+	})
+
+	return spec
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestOpenAPI(t *testing.T) {
+	app := fiber.New()
+	app.Get("/openapi.json", NewDocsHandler("dinacom-be").OpenAPI)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/openapi.json", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	var doc struct {
+		OpenAPI    string                    `json:"openapi"`
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK || !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Fatalf("status %d, openapi %q; want 200 and an OpenAPI 3 document", resp.StatusCode, doc.OpenAPI)
+	}
+
+	for path, method := range map[string]string{
+		"/questions/generate":                    "get",
+		"/questions/answer":                      "post",
+		"/sessions":                              "post",
+		"/sessions/{session_id}/resume":          "get",
+		"/report/sessions/{session_id}":          "get",
+		"/chatbot/sessions/{session_id}":         "post",
+		"/chatbot/sessions/{session_id}/history": "get",
+	} {
+		if _, ok := doc.Paths[path][method]; !ok {
+			t.Errorf("spec is missing %s %s", strings.ToUpper(method), path)
+		}
+	}
+	for _, schema := range []string{"GeneratedQuestion", "SubmitAnswerRequest", "SessionReport", "ChatRequest"} {
+		if _, ok := doc.Components.Schemas[schema]; !ok {
+			t.Errorf("spec is missing the %s schema", schema)
+		}
+	}
+}
+
+func TestSwaggerUI(t *testing.T) {
+	app := fiber.New()
+	app.Get("/docs", NewDocsHandler("").SwaggerUI)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/docs", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusOK || !strings.HasPrefix(resp.Header.Get(fiber.HeaderContentType), "text/html") {
+		t.Errorf("status %d, content type %q; want 200 text/html", resp.StatusCode, resp.Header.Get(fiber.HeaderContentType))
+	}
+	if !strings.Contains(string(body), `url: "openapi.json"`) {
+		t.Error("Swagger UI does not load the served spec")
+	}
+}
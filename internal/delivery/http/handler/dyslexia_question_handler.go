@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/evandrarf/dinacom-be/internal/delivery/http/domain"
 	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
 	"github.com/evandrarf/dinacom-be/internal/delivery/http/usecase"
+	"github.com/evandrarf/dinacom-be/internal/pkg/llm"
 	"github.com/evandrarf/dinacom-be/internal/pkg/response"
 	"github.com/evandrarf/dinacom-be/internal/pkg/validate"
 	"github.com/gofiber/fiber/v2"
@@ -16,11 +20,38 @@ import (
 type (
 	DyslexiaQuestionHandler interface {
 		Generate(ctx *fiber.Ctx) error
+		GenerateFromBody(ctx *fiber.Ctx) error
+		GetTemplates(ctx *fiber.Ctx) error
+		PreviewFallback(ctx *fiber.Ctx) error
+		CreateQuestion(ctx *fiber.Ctx) error
+		RegenerateOptions(ctx *fiber.Ctx) error
+		BulkGenerate(ctx *fiber.Ctx) error
+		GetServeLog(ctx *fiber.Ctx) error
+		GetQuestionPrompt(ctx *fiber.Ctx) error
+		GetAnswerDistribution(ctx *fiber.Ctx) error
+		ListQuestions(ctx *fiber.Ctx) error
+		RegradeSession(ctx *fiber.Ctx) error
+		RegradeQuestion(ctx *fiber.Ctx) error
 		SubmitAnswer(ctx *fiber.Ctx) error
 		GetSessionAnswers(ctx *fiber.Ctx) error
+		GetSessionProgress(ctx *fiber.Ctx) error
 		GetSessionReport(ctx *fiber.Ctx) error
+		CompareSessions(ctx *fiber.Ctx) error
+		GetBatchReports(ctx *fiber.Ctx) error
 		ChatWithBot(ctx *fiber.Ctx) error
 		GetChatHistory(ctx *fiber.Ctx) error
+		GetChatTranscript(ctx *fiber.Ctx) error
+		StartSession(ctx *fiber.Ctx) error
+		ResumeSession(ctx *fiber.Ctx) error
+		NextDrillQuestion(ctx *fiber.Ctx) error
+		ImportAnswers(ctx *fiber.Ctx) error
+		GetCohortAnalytics(ctx *fiber.Ctx) error
+		GetPairAnalytics(ctx *fiber.Ctx) error
+		GetUserTrends(ctx *fiber.Ctx) error
+		DeleteUser(ctx *fiber.Ctx) error
+		RestoreUser(ctx *fiber.Ctx) error
+		GetUserPreferences(ctx *fiber.Ctx) error
+		SaveUserPreferences(ctx *fiber.Ctx) error
 	}
 
 	dyslexiaQuestionHandler struct {
@@ -38,15 +69,231 @@ func NewDyslexiaQuestionHandler(validator *validate.Validator, logger *logrus.Lo
 	}
 }
 
-// GET /questions/generate?difficulty=easy|medium|hard&count=1&includeAnswer=false&pattern=b-d&use_ai=true&session_id=xxx
+// GET /questions/generate?difficulty=easy|medium|hard&count=1&includeAnswer=false&pattern=b-d&use_ai=true&session_id=xxx&template_id=e-bd-1&shuffle=true
 func (h *dyslexiaQuestionHandler) Generate(ctx *fiber.Ctx) error {
-	_ = h.validator
+	var query entity.GenerateQuestionQuery
+	if err := h.validator.ParseQueryAndValidate(ctx, &query); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_GENERATE_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+
+	count := query.Count
+	if count == 0 {
+		count = 1
+	}
+
+	useAI := true // Default true (use AI)
+	if query.UseAI != nil {
+		useAI = *query.UseAI
+	}
+
+	shuffle := true // Default true; false returns cached questions and options in a stable order
+	if query.Shuffle != nil {
+		shuffle = *query.Shuffle
+	}
+
+	if query.TemplateID != "" {
+		return h.generateFromTemplate(ctx, query.TemplateID, query.Difficulty, query.IncludeAnswer, query.SessionID)
+	}
 
-	count := 1
-	if v := strings.TrimSpace(ctx.Query("count")); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			count = n
+	// Session ID (optional) - to avoid duplicate questions in same session
+	sessionID := query.SessionID
+
+	questions, meta, err := h.usecase.Generate(ctx.UserContext(), entity.GenerateOptions{
+		Difficulty:    query.Difficulty,
+		Count:         count,
+		IncludeAnswer: query.IncludeAnswer,
+		Patterns:      query.Patterns,
+		UseAI:         useAI,
+		SessionID:     sessionID,
+		Shuffle:       shuffle,
+		Model:         query.Model,
+	})
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_GENERATE_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+	h.setQuotaHeader(ctx, sessionID, usecase.QuotaKindGenerate)
+
+	return response.NewSuccess(domain.DYSLEXIA_QUESTION_GENERATE_SUCCESS, questions, meta).Send(ctx)
+}
+
+// POST /questions/generate
+func (h *dyslexiaQuestionHandler) GenerateFromBody(ctx *fiber.Ctx) error {
+	var req entity.GenerateQuestionRequest
+
+	if err := h.validator.ParseAndValidate(ctx, &req); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_GENERATE_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+
+	count := req.Count
+	if count == 0 {
+		count = 1
+	}
+
+	useAI := true
+	if req.UseAI != nil {
+		useAI = *req.UseAI
+	}
+
+	shuffle := true
+	if req.Shuffle != nil {
+		shuffle = *req.Shuffle
+	}
+
+	difficulty := req.Difficulty
+	sessionID := strings.TrimSpace(req.SessionID)
+
+	if templateID := strings.TrimSpace(req.TemplateID); templateID != "" {
+		return h.generateFromTemplate(ctx, templateID, difficulty, req.IncludeAnswer, sessionID)
+	}
+
+	questions, meta, err := h.usecase.Generate(ctx.UserContext(), entity.GenerateOptions{
+		Difficulty:    difficulty,
+		Count:         count,
+		IncludeAnswer: req.IncludeAnswer,
+		Patterns:      req.Patterns,
+		UseAI:         useAI,
+		SessionID:     sessionID,
+		Shuffle:       shuffle,
+		Model:         req.Model,
+	})
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_GENERATE_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+	h.setQuotaHeader(ctx, sessionID, usecase.QuotaKindGenerate)
+
+	return response.NewSuccess(domain.DYSLEXIA_QUESTION_GENERATE_SUCCESS, questions, meta).Send(ctx)
+}
+
+// generateFromTemplate serves a single known bank template, keeping the list response shape of Generate
+func (h *dyslexiaQuestionHandler) generateFromTemplate(ctx *fiber.Ctx, templateID string, difficulty entity.Difficulty, includeAnswer bool, sessionID string) error {
+	question, err := h.usecase.GenerateFromTemplate(ctx.UserContext(), templateID, difficulty, includeAnswer, sessionID)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_GENERATE_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_QUESTION_GENERATE_SUCCESS, []entity.GeneratedQuestion{*question}, nil).Send(ctx)
+}
+
+// GET /admin/sessions/:session_id/served
+func (h *dyslexiaQuestionHandler) GetServeLog(ctx *fiber.Ctx) error {
+	var params entity.SessionPathParams
+	if err := h.validator.ParseRouteAndValidate(ctx, &params); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_SERVE_LOG_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+	sessionID := params.SessionID
+
+	logs, err := h.usecase.GetServeLog(ctx.UserContext(), sessionID)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_SERVE_LOG_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_SERVE_LOG_SUCCESS, logs, nil).Send(ctx)
+}
+
+// GET /admin/questions/:question_id/prompt
+func (h *dyslexiaQuestionHandler) GetQuestionPrompt(ctx *fiber.Ctx) error {
+	questionID := ctx.Params("question_id")
+	if questionID == "" {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_PROMPT_FAILED, fiber.NewError(fiber.StatusBadRequest, "question_id is required"), h.logger).Send(ctx)
+	}
+
+	prompt, err := h.usecase.GetQuestionPrompt(ctx.UserContext(), questionID)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_PROMPT_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_QUESTION_PROMPT_SUCCESS, prompt, nil).Send(ctx)
+}
+
+// GET /admin/questions/:question_id/distribution
+func (h *dyslexiaQuestionHandler) GetAnswerDistribution(ctx *fiber.Ctx) error {
+	questionID := ctx.Params("question_id")
+	if questionID == "" {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_DISTRIBUTION_FAILED, fiber.NewError(fiber.StatusBadRequest, "question_id is required"), h.logger).Send(ctx)
+	}
+
+	distribution, err := h.usecase.GetAnswerDistribution(ctx.UserContext(), questionID)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_DISTRIBUTION_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_QUESTION_DISTRIBUTION_SUCCESS, distribution, nil).Send(ctx)
+}
+
+// GET /admin/questions?generated_by=api.openai.com/gpt-4o-mini&difficulty=easy&pair=b-d&page=1&per_page=50
+func (h *dyslexiaQuestionHandler) ListQuestions(ctx *fiber.Ctx) error {
+	filter := entity.QuestionListFilter{
+		GeneratedBy: strings.TrimSpace(ctx.Query("generated_by")),
+		LetterPair:  strings.TrimSpace(ctx.Query("pair")),
+	}
+	if raw := ctx.Query("difficulty"); raw != "" {
+		difficulty, err := entity.ParseDifficulty(raw)
+		if err != nil {
+			return response.NewFailed(domain.DYSLEXIA_QUESTION_LIST_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
 		}
+		filter.Difficulty = string(difficulty)
+	}
+
+	page := 1
+	if n, err := strconv.Atoi(ctx.Query("page")); err == nil && n > 0 {
+		page = n
+	}
+
+	perPage := 50
+	if n, err := strconv.Atoi(ctx.Query("per_page")); err == nil && n > 0 {
+		perPage = n
+	}
+	if perPage > 100 {
+		perPage = 100
+	}
+
+	questions, total, err := h.usecase.ListQuestions(ctx.UserContext(), filter, page, perPage)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_LIST_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewPaginatedSuccess(domain.DYSLEXIA_QUESTION_LIST_SUCCESS, questions, response.NewPaginationMeta(total, page, perPage)).Send(ctx)
+}
+
+// POST /admin/sessions/:session_id/regrade
+func (h *dyslexiaQuestionHandler) RegradeSession(ctx *fiber.Ctx) error {
+	var params entity.SessionPathParams
+	if err := h.validator.ParseRouteAndValidate(ctx, &params); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_ANSWER_REGRADE_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+
+	result, err := h.usecase.RegradeSession(ctx.UserContext(), params.SessionID)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_ANSWER_REGRADE_FAILED, usecaseError(err), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_ANSWER_REGRADE_SUCCESS, result, nil).Send(ctx)
+}
+
+// POST /admin/questions/:question_id/regrade
+func (h *dyslexiaQuestionHandler) RegradeQuestion(ctx *fiber.Ctx) error {
+	questionID := ctx.Params("question_id")
+	if questionID == "" {
+		return response.NewFailed(domain.DYSLEXIA_ANSWER_REGRADE_FAILED, fiber.NewError(fiber.StatusBadRequest, "question_id is required"), h.logger).Send(ctx)
+	}
+
+	result, err := h.usecase.RegradeQuestion(ctx.UserContext(), questionID)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_ANSWER_REGRADE_FAILED, usecaseError(err), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_ANSWER_REGRADE_SUCCESS, result, nil).Send(ctx)
+}
+
+// GET /questions/templates?difficulty=easy&includeAnswer=false&page=1&limit=20
+func (h *dyslexiaQuestionHandler) GetTemplates(ctx *fiber.Ctx) error {
+	var difficulty entity.Difficulty
+	if d := strings.TrimSpace(ctx.Query("difficulty")); d != "" {
+		parsed, err := entity.ParseDifficulty(d)
+		if err != nil {
+			return response.NewFailed(domain.DYSLEXIA_QUESTION_GET_TEMPLATES_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+		}
+		difficulty = parsed
 	}
 
 	includeAnswer := false
@@ -54,45 +301,113 @@ func (h *dyslexiaQuestionHandler) Generate(ctx *fiber.Ctx) error {
 		includeAnswer = (v == "1" || strings.EqualFold(v, "true"))
 	}
 
-	useAI := true // Default true (use AI)
-	if v := strings.TrimSpace(ctx.Query("use_ai")); v != "" {
-		useAI = (v == "1" || strings.EqualFold(v, "true"))
+	page := 1
+	if n, err := strconv.Atoi(ctx.Query("page")); err == nil && n > 0 {
+		page = n
 	}
 
-	// Session ID (optional) - to avoid duplicate questions in same session
-	sessionID := strings.TrimSpace(ctx.Query("session_id"))
-
-	// Pattern filter (optional) - specific letter pairs to generate (comma-separated)
-	// Example: pattern=p-q,m-w,n-u
-	patternStr := strings.TrimSpace(ctx.Query("pattern"))
-	var patterns []string
-	if patternStr != "" {
-		// Split by comma and trim spaces
-		for _, p := range strings.Split(patternStr, ",") {
-			p = strings.TrimSpace(p)
-			if p != "" {
-				patterns = append(patterns, p)
-			}
-		}
+	limit := 20
+	if n, err := strconv.Atoi(ctx.Query("limit")); err == nil && n > 0 {
+		limit = n
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	templates, total, err := h.usecase.GetTemplates(ctx.UserContext(), difficulty, includeAnswer, page, limit)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_GET_TEMPLATES_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
 	}
 
-	difficulty := entity.DifficultyEasy
+	return response.NewPaginatedSuccess(domain.DYSLEXIA_QUESTION_GET_TEMPLATES_SUCCESS, templates, response.NewPaginationMeta(total, page, limit)).Send(ctx)
+}
+
+// GET /questions/fallback?difficulty=easy&pattern=b-d&seed=1
+func (h *dyslexiaQuestionHandler) PreviewFallback(ctx *fiber.Ctx) error {
+	var difficulty entity.Difficulty
 	if d := strings.TrimSpace(ctx.Query("difficulty")); d != "" {
-		difficulty = entity.Difficulty(strings.ToLower(d))
-		switch difficulty {
-		case entity.DifficultyEasy, entity.DifficultyMedium, entity.DifficultyHard:
-			// ok
-		default:
-			return response.NewFailed(domain.DYSLEXIA_QUESTION_GENERATE_FAILED, fiber.NewError(fiber.StatusBadRequest, "invalid difficulty"), h.logger).Send(ctx)
+		parsed, err := entity.ParseDifficulty(d)
+		if err != nil {
+			return response.NewFailed(domain.DYSLEXIA_QUESTION_GENERATE_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
 		}
+		difficulty = parsed
+	}
+
+	pattern := strings.TrimSpace(ctx.Query("pattern"))
+	if pattern == "" {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_GENERATE_FAILED, fiber.NewError(fiber.StatusBadRequest, "pattern is required"), h.logger).Send(ctx)
 	}
 
-	questions, err := h.usecase.Generate(ctx.UserContext(), difficulty, count, includeAnswer, patterns, useAI, sessionID)
+	seed := int64(1) // fixed by default so QA sees reproducible output
+	if v := strings.TrimSpace(ctx.Query("seed")); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return response.NewFailed(domain.DYSLEXIA_QUESTION_GENERATE_FAILED, fiber.NewError(fiber.StatusBadRequest, "invalid seed"), h.logger).Send(ctx)
+		}
+		seed = n
+	}
+
+	question, err := h.usecase.PreviewFallback(ctx.UserContext(), difficulty, pattern, seed)
 	if err != nil {
 		return response.NewFailed(domain.DYSLEXIA_QUESTION_GENERATE_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
 	}
 
-	return response.NewSuccess(domain.DYSLEXIA_QUESTION_GENERATE_SUCCESS, questions, nil).Send(ctx)
+	return response.NewSuccess(domain.DYSLEXIA_QUESTION_GENERATE_SUCCESS, question, nil).Send(ctx)
+}
+
+// POST /admin/questions
+func (h *dyslexiaQuestionHandler) CreateQuestion(ctx *fiber.Ctx) error {
+	var req entity.CreateQuestionRequest
+
+	if err := h.validator.ParseAndValidate(ctx, &req); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_CREATE_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+
+	question, err := h.usecase.CreateQuestion(ctx.UserContext(), req)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_CREATE_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_QUESTION_CREATE_SUCCESS, question, nil).Send(ctx)
+}
+
+// POST /admin/questions/regenerate?pattern=b-d&difficulty=easy
+func (h *dyslexiaQuestionHandler) RegenerateOptions(ctx *fiber.Ctx) error {
+	pattern := strings.TrimSpace(ctx.Query("pattern"))
+	if pattern == "" {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_REGENERATE_FAILED, fiber.NewError(fiber.StatusBadRequest, "pattern is required"), h.logger).Send(ctx)
+	}
+
+	var difficulty entity.Difficulty // empty = all difficulties
+	if d := strings.TrimSpace(ctx.Query("difficulty")); d != "" {
+		parsed, err := entity.ParseDifficulty(d)
+		if err != nil {
+			return response.NewFailed(domain.DYSLEXIA_QUESTION_REGENERATE_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+		}
+		difficulty = parsed
+	}
+
+	result, err := h.usecase.RegenerateOptions(ctx.UserContext(), pattern, difficulty)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_REGENERATE_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_QUESTION_REGENERATE_SUCCESS, result, nil).Send(ctx)
+}
+
+// POST /admin/questions/bulk-generate
+func (h *dyslexiaQuestionHandler) BulkGenerate(ctx *fiber.Ctx) error {
+	var req entity.BulkGenerateRequest
+	if err := h.validator.ParseAndValidate(ctx, &req); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_BULK_GENERATE_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+
+	result, err := h.usecase.BulkGenerate(ctx.UserContext(), req)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_BULK_GENERATE_FAILED, usecaseError(err), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_QUESTION_BULK_GENERATE_SUCCESS, result, nil).Send(ctx)
 }
 
 // POST /questions/answer
@@ -100,7 +415,7 @@ func (h *dyslexiaQuestionHandler) SubmitAnswer(ctx *fiber.Ctx) error {
 	var req entity.SubmitAnswerRequest
 
 	if err := h.validator.ParseAndValidate(ctx, &req); err != nil {
-		return response.NewFailed(domain.DYSLEXIA_QUESTION_SUBMIT_ANSWER_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_SUBMIT_ANSWER_FAILED, requestError(err), h.logger).Send(ctx)
 	}
 
 	result, err := h.usecase.SubmitAnswer(ctx.UserContext(), req)
@@ -113,69 +428,407 @@ func (h *dyslexiaQuestionHandler) SubmitAnswer(ctx *fiber.Ctx) error {
 
 // GET /questions/sessions/:session_id
 func (h *dyslexiaQuestionHandler) GetSessionAnswers(ctx *fiber.Ctx) error {
-	sessionID := ctx.Params("session_id")
-	if sessionID == "" {
-		return response.NewFailed(domain.DYSLEXIA_QUESTION_GET_SESSION_FAILED, fiber.NewError(fiber.StatusBadRequest, "session_id is required"), h.logger).Send(ctx)
+	var params entity.SessionPathParams
+	if err := h.validator.ParseRouteAndValidate(ctx, &params); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_GET_SESSION_FAILED, requestError(err), h.logger).Send(ctx)
 	}
 
-	answers, err := h.usecase.GetSessionAnswers(ctx.UserContext(), sessionID)
+	// Sessions started with a target_count also report their progress as meta
+	answers, progress, err := h.usecase.GetSessionAnswers(ctx.UserContext(), params.SessionID)
 	if err != nil {
-		return response.NewFailed(domain.DYSLEXIA_QUESTION_GET_SESSION_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_GET_SESSION_FAILED, usecaseError(err), h.logger).Send(ctx)
 	}
 
-	return response.NewSuccess(domain.DYSLEXIA_QUESTION_GET_SESSION_SUCCESS, answers, nil).Send(ctx)
+	return response.NewSuccess(domain.DYSLEXIA_QUESTION_GET_SESSION_SUCCESS, answers, progress).Send(ctx)
+}
+
+// GET /sessions/:session_id/progress
+func (h *dyslexiaQuestionHandler) GetSessionProgress(ctx *fiber.Ctx) error {
+	var params entity.SessionPathParams
+	if err := h.validator.ParseRouteAndValidate(ctx, &params); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_SESSION_PROGRESS_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+
+	progress, err := h.usecase.GetSessionProgress(ctx.UserContext(), params.SessionID)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_SESSION_PROGRESS_FAILED, usecaseError(err), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_SESSION_PROGRESS_SUCCESS, progress, nil).Send(ctx)
 }
 
 // GET /report/sessions/:session_id
 func (h *dyslexiaQuestionHandler) GetSessionReport(ctx *fiber.Ctx) error {
-	sessionID := ctx.Params("session_id")
-	if sessionID == "" {
-		return response.NewFailed(domain.DYSLEXIA_QUESTION_GET_REPORT_FAILED, fiber.NewError(fiber.StatusBadRequest, "session_id is required"), h.logger).Send(ctx)
+	var params entity.SessionPathParams
+	if err := h.validator.ParseRouteAndValidate(ctx, &params); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_GET_REPORT_FAILED, requestError(err), h.logger).Send(ctx)
 	}
+	sessionID := params.SessionID
 
 	report, err := h.usecase.GenerateSessionReport(ctx.UserContext(), sessionID)
 	if err != nil {
-		return response.NewFailed(domain.DYSLEXIA_QUESTION_GET_REPORT_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+		return response.NewFailed(domain.DYSLEXIA_QUESTION_GET_REPORT_FAILED, usecaseError(err), h.logger).Send(ctx)
 	}
 
 	return response.NewSuccess(domain.DYSLEXIA_QUESTION_GET_REPORT_SUCCESS, report, nil).Send(ctx)
 }
 
-// POST /chatbot/sessions/:session_id
-func (h *dyslexiaQuestionHandler) ChatWithBot(ctx *fiber.Ctx) error {
-	sessionID := ctx.Params("session_id")
-	if sessionID == "" {
-		return response.NewFailed(domain.DYSLEXIA_CHATBOT_SEND_FAILED, fiber.NewError(fiber.StatusBadRequest, "session_id is required"), h.logger).Send(ctx)
+// GET /report/compare?a=sessionA&b=sessionB&same_user=true
+func (h *dyslexiaQuestionHandler) CompareSessions(ctx *fiber.Ctx) error {
+	sessionA := strings.TrimSpace(ctx.Query("a"))
+	sessionB := strings.TrimSpace(ctx.Query("b"))
+	if sessionA == "" || sessionB == "" {
+		return response.NewFailed(domain.DYSLEXIA_REPORT_COMPARE_FAILED, fiber.NewError(fiber.StatusBadRequest, "a and b session ids are required"), h.logger).Send(ctx)
 	}
 
-	var req entity.ChatRequest
+	sameUser := false
+	if v := strings.TrimSpace(ctx.Query("same_user")); v != "" {
+		sameUser = (v == "1" || strings.EqualFold(v, "true"))
+	}
+
+	result, err := h.usecase.CompareSessions(ctx.UserContext(), sessionA, sessionB, sameUser)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_REPORT_COMPARE_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_REPORT_COMPARE_SUCCESS, result, nil).Send(ctx)
+}
+
+// POST /report/batch - cached reports only, never triggers the LLM
+func (h *dyslexiaQuestionHandler) GetBatchReports(ctx *fiber.Ctx) error {
+	var req entity.BatchReportRequest
 	if err := h.validator.ParseAndValidate(ctx, &req); err != nil {
-		return response.NewFailed(domain.DYSLEXIA_CHATBOT_SEND_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+		return response.NewFailed(domain.DYSLEXIA_REPORT_BATCH_FAILED, requestError(err), h.logger).Send(ctx)
 	}
 
-	if strings.TrimSpace(req.Message) == "" {
-		return response.NewFailed(domain.DYSLEXIA_CHATBOT_SEND_FAILED, fiber.NewError(fiber.StatusBadRequest, "message cannot be empty"), h.logger).Send(ctx)
+	items, err := h.usecase.GetBatchReports(ctx.UserContext(), req.SessionIDs)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_REPORT_BATCH_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
 	}
 
-	result, err := h.usecase.ChatWithBot(ctx.UserContext(), sessionID, req.Message)
+	return response.NewSuccess(domain.DYSLEXIA_REPORT_BATCH_SUCCESS, items, nil).Send(ctx)
+}
+
+// POST /chatbot/sessions/:session_id
+func (h *dyslexiaQuestionHandler) ChatWithBot(ctx *fiber.Ctx) error {
+	var req entity.ChatRequest
+	if err := h.validator.ParseParamsAndValidate(ctx, &req); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_CHATBOT_SEND_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+
+	result, err := h.usecase.ChatWithBot(ctx.UserContext(), req.SessionID, req.Message, req.Model)
 	if err != nil {
-		return response.NewFailed(domain.DYSLEXIA_CHATBOT_SEND_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+		return response.NewFailed(domain.DYSLEXIA_CHATBOT_SEND_FAILED, usecaseError(err), h.logger).Send(ctx)
 	}
+	h.setQuotaHeader(ctx, req.SessionID, usecase.QuotaKindChat)
 
 	return response.NewSuccess(domain.DYSLEXIA_CHATBOT_SEND_SUCCESS, result, nil).Send(ctx)
 }
 
-// GET /chatbot/sessions/:session_id/history
+// GET /chatbot/sessions/:session_id/history?page=1&per_page=50
 func (h *dyslexiaQuestionHandler) GetChatHistory(ctx *fiber.Ctx) error {
-	sessionID := ctx.Params("session_id")
-	if sessionID == "" {
-		return response.NewFailed(domain.DYSLEXIA_CHATBOT_HISTORY_FAILED, fiber.NewError(fiber.StatusBadRequest, "session_id is required"), h.logger).Send(ctx)
+	var params entity.SessionPathParams
+	if err := h.validator.ParseRouteAndValidate(ctx, &params); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_CHATBOT_HISTORY_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+	sessionID := params.SessionID
+
+	page := 1
+	if n, err := strconv.Atoi(ctx.Query("page")); err == nil && n > 0 {
+		page = n
 	}
 
-	history, err := h.usecase.GetChatHistory(ctx.UserContext(), sessionID)
+	perPage := 50
+	if n, err := strconv.Atoi(ctx.Query("per_page")); err == nil && n > 0 {
+		perPage = n
+	}
+	if perPage > 100 {
+		perPage = 100
+	}
+
+	history, total, err := h.usecase.GetChatHistory(ctx.UserContext(), sessionID, page, perPage)
 	if err != nil {
 		return response.NewFailed(domain.DYSLEXIA_CHATBOT_HISTORY_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
 	}
 
-	return response.NewSuccess(domain.DYSLEXIA_CHATBOT_HISTORY_SUCCESS, history, nil).Send(ctx)
+	return response.NewPaginatedSuccess(domain.DYSLEXIA_CHATBOT_HISTORY_SUCCESS, history, response.NewPaginationMeta(total, page, perPage)).Send(ctx)
+}
+
+// GET /chatbot/sessions/:session_id/transcript?format=md|txt
+func (h *dyslexiaQuestionHandler) GetChatTranscript(ctx *fiber.Ctx) error {
+	var params entity.SessionPathParams
+	if err := h.validator.ParseRouteAndValidate(ctx, &params); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_CHATBOT_TRANSCRIPT_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+	var query entity.ChatTranscriptQuery
+	if err := h.validator.ParseQueryAndValidate(ctx, &query); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_CHATBOT_TRANSCRIPT_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+
+	transcript, err := h.usecase.GetChatTranscript(ctx.UserContext(), params.SessionID, query.Format)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_CHATBOT_TRANSCRIPT_FAILED, usecaseError(err), h.logger).Send(ctx)
+	}
+
+	ctx.Set(fiber.HeaderContentType, transcript.ContentType)
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, transcript.Filename))
+	return ctx.Send(transcript.Body)
+}
+
+// POST /sessions
+func (h *dyslexiaQuestionHandler) StartSession(ctx *fiber.Ctx) error {
+	var req entity.StartSessionRequest
+
+	if err := h.validator.ParseAndValidate(ctx, &req); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_SESSION_START_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+
+	result, err := h.usecase.StartSession(ctx.UserContext(), req)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_SESSION_START_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_SESSION_START_SUCCESS, result, nil).Send(ctx)
+}
+
+// GET /sessions/:session_id/resume?use_ai=true
+func (h *dyslexiaQuestionHandler) ResumeSession(ctx *fiber.Ctx) error {
+	var params entity.SessionPathParams
+	if err := h.validator.ParseRouteAndValidate(ctx, &params); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_SESSION_RESUME_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+	sessionID := params.SessionID
+
+	useAI := true
+	if v := strings.TrimSpace(ctx.Query("use_ai")); v != "" {
+		useAI = (v == "1" || strings.EqualFold(v, "true"))
+	}
+
+	result, err := h.usecase.ResumeSession(ctx.UserContext(), sessionID, useAI)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_SESSION_RESUME_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_SESSION_RESUME_SUCCESS, result, nil).Send(ctx)
+}
+
+// GET /sessions/:session_id/drill?pair=b-d&difficulty=easy&target=80&window=5&use_ai=true
+func (h *dyslexiaQuestionHandler) NextDrillQuestion(ctx *fiber.Ctx) error {
+	var params entity.SessionPathParams
+	if err := h.validator.ParseRouteAndValidate(ctx, &params); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_SESSION_DRILL_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+	sessionID := params.SessionID
+
+	var query entity.DrillQuery
+	if err := h.validator.ParseQueryAndValidate(ctx, &query); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_SESSION_DRILL_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+
+	result, err := h.usecase.NextDrillQuestion(ctx.UserContext(), sessionID, query)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_SESSION_DRILL_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_SESSION_DRILL_SUCCESS, result, nil).Send(ctx)
+}
+
+// POST /sessions/:session_id/import
+func (h *dyslexiaQuestionHandler) ImportAnswers(ctx *fiber.Ctx) error {
+	var req entity.ImportAnswersRequest
+	if err := h.validator.ParseParamsAndValidate(ctx, &req); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_SESSION_IMPORT_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+
+	result, err := h.usecase.ImportAnswers(ctx.UserContext(), req)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_SESSION_IMPORT_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_SESSION_IMPORT_SUCCESS, result, nil).Send(ctx)
+}
+
+// GET /analytics/cohort?user_ids=a,b,c&from=2024-01-01&to=2024-01-31
+func (h *dyslexiaQuestionHandler) GetCohortAnalytics(ctx *fiber.Ctx) error {
+	var userIDs []string
+	for _, id := range strings.Split(ctx.Query("user_ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			userIDs = append(userIDs, id)
+		}
+	}
+	if len(userIDs) == 0 {
+		return response.NewFailed(domain.DYSLEXIA_ANALYTICS_COHORT_FAILED, fiber.NewError(fiber.StatusBadRequest, "user_ids is required"), h.logger).Send(ctx)
+	}
+
+	from, err := parseDateParam(ctx.Query("from"), false)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_ANALYTICS_COHORT_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+	to, err := parseDateParam(ctx.Query("to"), true)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_ANALYTICS_COHORT_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	result, err := h.usecase.GetCohortAnalytics(ctx.UserContext(), userIDs, from, to)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_ANALYTICS_COHORT_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_ANALYTICS_COHORT_SUCCESS, result, nil).Send(ctx)
+}
+
+// GET /analytics/pairs?difficulty=easy
+func (h *dyslexiaQuestionHandler) GetPairAnalytics(ctx *fiber.Ctx) error {
+	var difficulty entity.Difficulty
+	if d := strings.TrimSpace(ctx.Query("difficulty")); d != "" {
+		parsed, err := entity.ParseDifficulty(d)
+		if err != nil {
+			return response.NewFailed(domain.DYSLEXIA_ANALYTICS_PAIRS_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+		}
+		difficulty = parsed
+	}
+
+	result, err := h.usecase.GetPairAnalytics(ctx.UserContext(), difficulty)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_ANALYTICS_PAIRS_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_ANALYTICS_PAIRS_SUCCESS, result, nil).Send(ctx)
+}
+
+// GET /users/:user_id/trends?pair=b-d&from=2024-01-01&to=2024-03-31
+func (h *dyslexiaQuestionHandler) GetUserTrends(ctx *fiber.Ctx) error {
+	var params entity.UserPathParams
+	if err := h.validator.ParseRouteAndValidate(ctx, &params); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_USER_TRENDS_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+	userID := params.UserID
+
+	from, err := parseDateParam(ctx.Query("from"), false)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_USER_TRENDS_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+	to, err := parseDateParam(ctx.Query("to"), true)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_USER_TRENDS_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	trends, err := h.usecase.GetUserTrends(ctx.UserContext(), userID, strings.TrimSpace(ctx.Query("pair")), from, to)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_USER_TRENDS_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_USER_TRENDS_SUCCESS, trends, nil).Send(ctx)
+}
+
+// DELETE /users/:user_id
+func (h *dyslexiaQuestionHandler) DeleteUser(ctx *fiber.Ctx) error {
+	var params entity.UserPathParams
+	if err := h.validator.ParseRouteAndValidate(ctx, &params); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_USER_DELETE_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+	userID := params.UserID
+
+	result, err := h.usecase.DeleteUserData(ctx.UserContext(), userID)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_USER_DELETE_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_USER_DELETE_SUCCESS, result, nil).Send(ctx)
+}
+
+// GET /users/:user_id/preferences
+func (h *dyslexiaQuestionHandler) GetUserPreferences(ctx *fiber.Ctx) error {
+	var params entity.UserPathParams
+	if err := h.validator.ParseRouteAndValidate(ctx, &params); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_USER_PREFERENCES_GET_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+
+	prefs, err := h.usecase.GetUserPreferences(ctx.UserContext(), params.UserID)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_USER_PREFERENCES_GET_FAILED, usecaseError(err), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_USER_PREFERENCES_GET_SUCCESS, prefs, nil).Send(ctx)
+}
+
+// PUT /users/:user_id/preferences
+func (h *dyslexiaQuestionHandler) SaveUserPreferences(ctx *fiber.Ctx) error {
+	var req entity.UserPreferencesRequest
+	if err := h.validator.ParseParamsAndValidate(ctx, &req); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_USER_PREFERENCES_SAVE_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+
+	prefs, err := h.usecase.SaveUserPreferences(ctx.UserContext(), req.UserID, req)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_USER_PREFERENCES_SAVE_FAILED, usecaseError(err), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_USER_PREFERENCES_SAVE_SUCCESS, prefs, nil).Send(ctx)
+}
+
+// POST /users/:user_id/restore
+func (h *dyslexiaQuestionHandler) RestoreUser(ctx *fiber.Ctx) error {
+	var params entity.UserPathParams
+	if err := h.validator.ParseRouteAndValidate(ctx, &params); err != nil {
+		return response.NewFailed(domain.DYSLEXIA_USER_RESTORE_FAILED, requestError(err), h.logger).Send(ctx)
+	}
+	userID := params.UserID
+
+	result, err := h.usecase.RestoreUserData(ctx.UserContext(), userID)
+	if err != nil {
+		return response.NewFailed(domain.DYSLEXIA_USER_RESTORE_FAILED, fiber.NewError(fiber.StatusBadRequest, err.Error()), h.logger).Send(ctx)
+	}
+
+	return response.NewSuccess(domain.DYSLEXIA_USER_RESTORE_SUCCESS, result, nil).Send(ctx)
+}
+
+// requestError keeps field errors and fiber errors as-is so they render uniformly; anything else is a 400
+func requestError(err error) error {
+	switch err.(type) {
+	case *validate.FieldsError, *fiber.Error:
+		return err
+	}
+	return fiber.NewError(fiber.StatusBadRequest, err.Error())
+}
+
+// usecaseError maps the usecase sentinel errors to status codes: not found 404, invalid input 400,
+// LLM/provider failures 502 and anything else 500
+func usecaseError(err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, usecase.ErrNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidInput):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, usecase.ErrUpstream), errors.Is(err, llm.ErrCircuitOpen):
+		status = fiber.StatusBadGateway
+	}
+	return fiber.NewError(status, err.Error())
+}
+
+// parseDateParam accepts YYYY-MM-DD or RFC3339; a date-only end bound covers the whole day
+func parseDateParam(value string, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date: %s (use YYYY-MM-DD or RFC3339)", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// setQuotaHeader exposes the remaining daily AI quota (X-AI-Quota-Remaining) when a quota applies
+func (h *dyslexiaQuestionHandler) setQuotaHeader(ctx *fiber.Ctx, sessionID string, kind string) {
+	if remaining, limited := h.usecase.RemainingQuota(ctx.UserContext(), sessionID, kind); limited {
+		ctx.Set("X-AI-Quota-Remaining", strconv.Itoa(remaining))
+	}
 }
@@ -0,0 +1,1531 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/middleware"
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/usecase"
+	"github.com/evandrarf/dinacom-be/internal/pkg/llm"
+	"github.com/evandrarf/dinacom-be/internal/pkg/validate"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// fakeUsecase records the arguments the handler passes on. Methods a test does not stub panic through
+// the nil embedded interface.
+type fakeUsecase struct {
+	usecase.DyslexiaQuestionUsecase
+
+	generate  generateCall
+	templates templatesCall
+	err       error
+	quota     int // remaining daily AI quota; negative means no quota applies
+	fallback  fallbackCall
+	created   *entity.CreateQuestionRequest
+	trends    trendsCall
+	chat      chatCall
+	template  templateCall
+	compare   compareCall
+	batch     []string // session ids passed to GetBatchReports
+	regen     regenerateCall
+	bulk      *entity.BulkGenerateRequest
+	served    string // session passed to GetServeLog
+	prompt    string // question passed to GetQuestionPrompt
+	imported  *entity.ImportAnswersRequest
+	submitted *entity.SubmitAnswerRequest
+	pairs     *entity.Difficulty // difficulty passed to GetPairAnalytics
+	dist      string             // question passed to GetAnswerDistribution
+	drill     drillCall
+	list      *listCall
+	regraded  string // session or question passed to RegradeSession/RegradeQuestion
+	meta      *entity.GenerateMeta
+	format    *string // format passed to GetChatTranscript
+	prefs     *entity.UserPreferencesRequest
+	prefsUser string // user passed to GetUserPreferences/SaveUserPreferences
+	progress  *entity.SessionProgress
+}
+
+type generateCall struct {
+	difficulty    entity.Difficulty
+	count         int
+	includeAnswer bool
+	patterns      []string
+	useAI         bool
+	sessionID     string
+	shuffle       bool
+	model         string
+}
+
+func (f *fakeUsecase) Generate(_ context.Context, opts entity.GenerateOptions) ([]entity.GeneratedQuestion, *entity.GenerateMeta, error) {
+	f.generate = generateCall{difficulty: opts.Difficulty, count: opts.Count, includeAnswer: opts.IncludeAnswer, patterns: opts.Patterns, useAI: opts.UseAI, sessionID: opts.SessionID, shuffle: opts.Shuffle, model: opts.Model}
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+	return []entity.GeneratedQuestion{{ID: "q-1"}}, f.meta, nil
+}
+
+type templateCall struct {
+	templateID    string
+	difficulty    entity.Difficulty
+	includeAnswer bool
+	sessionID     string
+}
+
+func (f *fakeUsecase) GenerateFromTemplate(_ context.Context, templateID string, difficulty entity.Difficulty, includeAnswer bool, sessionID string) (*entity.GeneratedQuestion, error) {
+	f.template = templateCall{templateID: templateID, difficulty: difficulty, includeAnswer: includeAnswer, sessionID: sessionID}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.GeneratedQuestion{ID: "q-tpl"}, nil
+}
+
+type templatesCall struct {
+	difficulty    entity.Difficulty
+	includeAnswer bool
+	page, limit   int
+}
+
+func (f *fakeUsecase) GetTemplates(_ context.Context, difficulty entity.Difficulty, includeAnswer bool, page, limit int) ([]entity.QuestionTemplate, int64, error) {
+	f.templates = templatesCall{difficulty: difficulty, includeAnswer: includeAnswer, page: page, limit: limit}
+	if f.err != nil {
+		return nil, 0, f.err
+	}
+	tpl := entity.QuestionTemplate{ID: "e-bd-1", Difficulty: difficulty, Distractors: []string{"dola"}}
+	if includeAnswer {
+		tpl.CorrectWord = "bola"
+	}
+	return []entity.QuestionTemplate{tpl}, 21, nil
+}
+
+func (f *fakeUsecase) RemainingQuota(_ context.Context, _ string, _ string) (int, bool) {
+	return f.quota, f.quota >= 0
+}
+
+type fallbackCall struct {
+	difficulty entity.Difficulty
+	pattern    string
+	seed       int64
+}
+
+func (f *fakeUsecase) PreviewFallback(_ context.Context, difficulty entity.Difficulty, pattern string, seed int64) (*entity.GeneratedQuestion, error) {
+	f.fallback = fallbackCall{difficulty: difficulty, pattern: pattern, seed: seed}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.GeneratedQuestion{ID: "q-1", TargetLetterPair: pattern, Answer: "bola"}, nil
+}
+
+func (f *fakeUsecase) CreateQuestion(_ context.Context, req entity.CreateQuestionRequest) (*entity.GeneratedQuestion, error) {
+	f.created = &req
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.GeneratedQuestion{ID: "q-manual", Options: req.Options, Answer: req.CorrectAnswer}, nil
+}
+
+type trendsCall struct {
+	userID, pair string
+	from, to     time.Time
+}
+
+func (f *fakeUsecase) GetUserTrends(_ context.Context, userID string, pair string, from, to time.Time) ([]entity.LetterPairTrend, error) {
+	f.trends = trendsCall{userID: userID, pair: pair, from: from, to: to}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []entity.LetterPairTrend{{LetterPair: "b-d", Points: []entity.TrendPoint{{SessionID: "sess-1", AccuracyRate: "50.0%"}}}}, nil
+}
+
+func (f *fakeUsecase) GetPairAnalytics(_ context.Context, difficulty entity.Difficulty) (*entity.PairAnalytics, error) {
+	f.pairs = &difficulty
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.PairAnalytics{Difficulty: difficulty, Pairs: []entity.ErrorPattern{{LetterPair: "b-d", ErrorRate: "0.0%"}}}, nil
+}
+
+func (f *fakeUsecase) GetAnswerDistribution(_ context.Context, questionID string) (*entity.AnswerDistribution, error) {
+	f.dist = questionID
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.AnswerDistribution{QuestionID: questionID, Options: []entity.OptionCount{{Option: "bola", Count: 1}}}, nil
+}
+
+type listCall struct {
+	filter        entity.QuestionListFilter
+	page, perPage int
+}
+
+func (f *fakeUsecase) ListQuestions(_ context.Context, filter entity.QuestionListFilter, page, perPage int) ([]entity.AdminQuestionItem, int64, error) {
+	f.list = &listCall{filter: filter, page: page, perPage: perPage}
+	if f.err != nil {
+		return nil, 0, f.err
+	}
+	return []entity.AdminQuestionItem{{QuestionID: "q-1", GeneratedBy: filter.GeneratedBy}}, 1, nil
+}
+
+type drillCall struct {
+	sessionID string
+	query     entity.DrillQuery
+}
+
+func (f *fakeUsecase) NextDrillQuestion(_ context.Context, sessionID string, query entity.DrillQuery) (*entity.DrillResponse, error) {
+	f.drill = drillCall{sessionID: sessionID, query: query}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.DrillResponse{SessionID: sessionID, LetterPair: query.Pair, Mastered: true}, nil
+}
+
+type chatCall struct {
+	sessionID, message, model string
+	page, perPage             int
+}
+
+func (f *fakeUsecase) ChatWithBot(_ context.Context, sessionID string, message string, model string) (*entity.ChatResponse, error) {
+	f.chat = chatCall{sessionID: sessionID, message: message, model: model}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.ChatResponse{Response: "halo", SessionID: sessionID}, nil
+}
+
+func (f *fakeUsecase) GetChatHistory(_ context.Context, sessionID string, page, perPage int) ([]entity.ChatHistoryItem, int64, error) {
+	f.chat = chatCall{sessionID: sessionID, page: page, perPage: perPage}
+	if f.err != nil {
+		return nil, 0, f.err
+	}
+	if sessionID == "empty" {
+		return nil, 0, nil
+	}
+	return []entity.ChatHistoryItem{{Role: "user", Message: "halo"}}, 120, nil
+}
+
+func (f *fakeUsecase) GetChatTranscript(_ context.Context, sessionID string, format string) (*entity.ChatTranscript, error) {
+	f.chat = chatCall{sessionID: sessionID}
+	f.format = &format
+	if f.err != nil {
+		return nil, f.err
+	}
+	contentType := "text/markdown; charset=utf-8"
+	if format == entity.TranscriptFormatText {
+		contentType = "text/plain; charset=utf-8"
+	}
+	return &entity.ChatTranscript{Filename: "chat-" + sessionID + "." + format, ContentType: contentType, Body: []byte("halo")}, nil
+}
+
+func (f *fakeUsecase) SubmitAnswer(_ context.Context, req entity.SubmitAnswerRequest) (*entity.SubmitAnswerResponse, error) {
+	f.submitted = &req
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.SubmitAnswerResponse{Skipped: req.Skipped, UserAnswer: req.Answer, SessionID: req.SessionID}, nil
+}
+
+func (f *fakeUsecase) GetSessionAnswers(_ context.Context, sessionID string) ([]entity.UserAnswerLog, *entity.SessionProgress, error) {
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+	if sessionID == "empty" {
+		return nil, f.progress, nil
+	}
+	return []entity.UserAnswerLog{{QuestionID: "q-1"}}, f.progress, nil
+}
+
+func (f *fakeUsecase) GetSessionProgress(_ context.Context, sessionID string) (*entity.SessionProgress, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.SessionProgress{SessionID: sessionID, AnsweredCount: 3, TargetCount: 10, RemainingCount: 7, CompletionPercent: 30}, nil
+}
+
+func (f *fakeUsecase) GenerateSessionReport(_ context.Context, sessionID string) (*entity.SessionReport, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.SessionReport{SessionID: sessionID}, nil
+}
+
+func (f *fakeUsecase) DeleteUserData(_ context.Context, userID string) (*entity.UserDataResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.UserDataResult{UserID: userID, Answers: 2}, nil
+}
+
+func (f *fakeUsecase) GetUserPreferences(_ context.Context, userID string) (*entity.UserPreferences, error) {
+	f.prefsUser = userID
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.UserPreferences{UserID: userID, LetterPairs: []string{}}, nil
+}
+
+func (f *fakeUsecase) SaveUserPreferences(_ context.Context, userID string, req entity.UserPreferencesRequest) (*entity.UserPreferences, error) {
+	f.prefsUser = userID
+	f.prefs = &req
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.UserPreferences{UserID: userID, PreferredDifficulty: string(req.PreferredDifficulty), LetterPairs: req.LetterPairs}, nil
+}
+
+func (f *fakeUsecase) RestoreUserData(_ context.Context, userID string) (*entity.UserDataResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.UserDataResult{UserID: userID, Answers: 2}, nil
+}
+
+type compareCall struct {
+	a, b     string
+	sameUser bool
+}
+
+func (f *fakeUsecase) CompareSessions(_ context.Context, a, b string, sameUser bool) (*entity.SessionComparison, error) {
+	f.compare = compareCall{a: a, b: b, sameUser: sameUser}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.SessionComparison{SessionA: a, SessionB: b, Verdict: "similar"}, nil
+}
+
+func (f *fakeUsecase) GetBatchReports(_ context.Context, sessionIDs []string) ([]entity.BatchReportItem, error) {
+	f.batch = sessionIDs
+	if f.err != nil {
+		return nil, f.err
+	}
+	items := make([]entity.BatchReportItem, 0, len(sessionIDs))
+	for _, id := range sessionIDs {
+		items = append(items, entity.BatchReportItem{SessionID: id, Status: entity.BatchReportStatusNotGenerated})
+	}
+	return items, nil
+}
+
+type regenerateCall struct {
+	pattern    string
+	difficulty entity.Difficulty
+}
+
+func (f *fakeUsecase) RegenerateOptions(_ context.Context, pattern string, difficulty entity.Difficulty) (*entity.RegenerateResult, error) {
+	f.regen = regenerateCall{pattern: pattern, difficulty: difficulty}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.RegenerateResult{Matched: 1, Updated: 1, FailedIDs: []string{}}, nil
+}
+
+func (f *fakeUsecase) BulkGenerate(_ context.Context, req entity.BulkGenerateRequest) (*entity.BulkGenerateResult, error) {
+	f.bulk = &req
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.BulkGenerateResult{Requested: 2, Created: 2, Targets: []entity.BulkGenerateTargetResult{}}, nil
+}
+
+func (f *fakeUsecase) GetServeLog(_ context.Context, sessionID string) ([]entity.ServeLogItem, error) {
+	f.served = sessionID
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []entity.ServeLogItem{{QuestionID: "q-1", SessionID: sessionID}}, nil
+}
+
+func (f *fakeUsecase) RegradeSession(_ context.Context, sessionID string) (*entity.RegradeResult, error) {
+	f.regraded = sessionID
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.RegradeResult{Checked: 1, InvalidatedSessions: []string{}}, nil
+}
+
+func (f *fakeUsecase) RegradeQuestion(_ context.Context, questionID string) (*entity.RegradeResult, error) {
+	f.regraded = questionID
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.RegradeResult{Checked: 1, InvalidatedSessions: []string{}}, nil
+}
+
+func (f *fakeUsecase) GetQuestionPrompt(_ context.Context, questionID string) (*entity.QuestionPrompt, error) {
+	f.prompt = questionID
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.QuestionPrompt{QuestionID: questionID, GeneratedBy: "gemini", Prompt: "prompt"}, nil
+}
+
+func (f *fakeUsecase) ImportAnswers(_ context.Context, req entity.ImportAnswersRequest) (*entity.ImportAnswersResult, error) {
+	f.imported = &req
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &entity.ImportAnswersResult{SessionID: req.SessionID, Imported: len(req.Answers), Conflicts: []entity.ImportConflict{}}, nil
+}
+
+// newTestApp mounts the handler routes a test needs on a bare fiber app
+func newTestApp(uc usecase.DyslexiaQuestionUsecase) *fiber.App {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	validator := validate.NewValidator()
+	// Registered at bootstrap from the configured levels
+	validator.RegisterRule("difficulty", func(value string) bool {
+		_, err := entity.ParseDifficulty(value)
+		return err == nil
+	}, "{0} must be a configured difficulty")
+	h := NewDyslexiaQuestionHandler(validator, logger, uc)
+
+	app := fiber.New()
+	app.Get("/questions/generate", h.Generate)
+	app.Post("/questions/generate", h.GenerateFromBody)
+	app.Get("/questions/templates", h.GetTemplates)
+	app.Get("/questions/fallback", h.PreviewFallback)
+	app.Post("/questions/answer", h.SubmitAnswer)
+	app.Get("/questions/sessions/:session_id", h.GetSessionAnswers)
+	app.Get("/sessions/:session_id/progress", h.GetSessionProgress)
+	app.Get("/users/:user_id/trends", h.GetUserTrends)
+	app.Get("/analytics/pairs", h.GetPairAnalytics)
+	app.Get("/admin/questions/:question_id/distribution", h.GetAnswerDistribution)
+	app.Get("/report/sessions/:session_id", h.GetSessionReport)
+	app.Get("/report/compare", h.CompareSessions)
+	app.Post("/report/batch", h.GetBatchReports)
+	app.Delete("/users/:user_id", h.DeleteUser)
+	app.Post("/users/:user_id/restore", h.RestoreUser)
+	app.Get("/users/:user_id/preferences", h.GetUserPreferences)
+	app.Put("/users/:user_id/preferences", h.SaveUserPreferences)
+	app.Post("/chatbot/sessions/:session_id", h.ChatWithBot)
+	app.Get("/chatbot/sessions/:session_id/history", h.GetChatHistory)
+	app.Get("/chatbot/sessions/:session_id/transcript", h.GetChatTranscript)
+	app.Post("/sessions/:session_id/import", h.ImportAnswers)
+	app.Get("/sessions/:session_id/drill", h.NextDrillQuestion)
+
+	config := viper.New()
+	config.Set("api.admin_token", testAdminToken)
+	m := middleware.NewMiddleware(&middleware.MiddlewareConfig{Log: logger, Config: config})
+	app.Post("/admin/questions", m.AdminMiddleware(), h.CreateQuestion)
+	app.Post("/admin/questions/regenerate", m.AdminMiddleware(), h.RegenerateOptions)
+	app.Post("/admin/questions/bulk-generate", m.AdminMiddleware(), h.BulkGenerate)
+	app.Get("/admin/questions", m.AdminMiddleware(), h.ListQuestions)
+	app.Get("/admin/questions/:question_id/prompt", m.AdminMiddleware(), h.GetQuestionPrompt)
+	app.Get("/admin/sessions/:session_id/served", m.AdminMiddleware(), h.GetServeLog)
+	app.Post("/admin/sessions/:session_id/regrade", m.AdminMiddleware(), h.RegradeSession)
+	app.Post("/admin/questions/:question_id/regrade", m.AdminMiddleware(), h.RegradeQuestion)
+	return app
+}
+
+const testAdminToken = "admin-secret"
+
+// do sends a request and decodes the response envelope
+func do(t *testing.T, app *fiber.App, method, target, body string) (int, map[string]any) {
+	t.Helper()
+	resp, envelope := doResponse(t, app, method, target, body, nil)
+	return resp.StatusCode, envelope
+}
+
+// doResponse is do for tests that set request headers or check response headers
+func doResponse(t *testing.T, app *fiber.App, method, target, body string, header map[string]string) (*http.Response, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+	var envelope map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp, envelope
+}
+
+func TestGenerateFromBody(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       generateCall
+	}{
+		{
+			name:       "defaults",
+			body:       `{}`,
+			wantStatus: fiber.StatusOK,
+			want:       generateCall{count: 1, useAI: true, shuffle: true},
+		},
+		{
+			name:       "multiple patterns",
+			body:       `{"difficulty":"medium","count":4,"patterns":["b-d","p-q","m-n"],"include_answer":true,"use_ai":false,"session_id":" s-1 ","shuffle":false,"model":"local-model"}`,
+			wantStatus: fiber.StatusOK,
+			want:       generateCall{difficulty: entity.DifficultyMedium, count: 4, includeAnswer: true, patterns: []string{"b-d", "p-q", "m-n"}, sessionID: "s-1", model: "local-model"},
+		},
+		{
+			name:       "mixed-case difficulty",
+			body:       `{"difficulty":" Hard "}`,
+			wantStatus: fiber.StatusOK,
+			want:       generateCall{difficulty: entity.DifficultyHard, count: 1, useAI: true, shuffle: true},
+		},
+		{name: "unknown difficulty", body: `{"difficulty":"extreme"}`, wantStatus: fiber.StatusBadRequest},
+		{name: "count above the limit", body: `{"count":11}`, wantStatus: fiber.StatusBadRequest},
+		{name: "blank pattern", body: `{"patterns":["b-d",""]}`, wantStatus: fiber.StatusBadRequest},
+		{name: "malformed JSON", body: `{"count":`, wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{quota: -1}
+			status, envelope := do(t, newTestApp(uc), fiber.MethodPost, "/questions/generate", tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			if tt.wantStatus != fiber.StatusOK {
+				return
+			}
+			got := uc.generate
+			if got.difficulty != tt.want.difficulty || got.count != tt.want.count || got.includeAnswer != tt.want.includeAnswer ||
+				!slices.Equal(got.patterns, tt.want.patterns) || got.useAI != tt.want.useAI || got.sessionID != tt.want.sessionID || got.shuffle != tt.want.shuffle || got.model != tt.want.model {
+				t.Errorf("Generate called with %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateShuffleParam(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "", want: true},
+		{query: "?shuffle=true", want: true},
+		{query: "?shuffle=false", want: false},
+		{query: "?shuffle=0", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			uc := &fakeUsecase{quota: -1}
+			if status, envelope := do(t, newTestApp(uc), fiber.MethodGet, "/questions/generate"+tt.query, ""); status != fiber.StatusOK {
+				t.Fatalf("status = %d, want 200 (%v)", status, envelope)
+			}
+			if uc.generate.shuffle != tt.want {
+				t.Errorf("shuffle = %v, want %v", uc.generate.shuffle, tt.want)
+			}
+		})
+	}
+}
+
+// GET /questions/generate binds its query through the validator: typed values, defaults and field errors
+func TestGenerateQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       generateCall
+	}{
+		{name: "defaults", wantStatus: fiber.StatusOK, want: generateCall{count: 1, useAI: true, shuffle: true}},
+		{
+			name:       "all params",
+			query:      "?difficulty=%20HARD%20&count=3&includeAnswer=true&use_ai=0&session_id=%20s-1%20&pattern=b-d,p-q&pattern=m-n&model=%20local-model%20",
+			wantStatus: fiber.StatusOK,
+			want:       generateCall{difficulty: entity.DifficultyHard, count: 3, includeAnswer: true, patterns: []string{"b-d", "p-q", "m-n"}, sessionID: "s-1", shuffle: true, model: "local-model"},
+		},
+		{name: "invalid difficulty", query: "?difficulty=extreme", wantStatus: fiber.StatusBadRequest},
+		{name: "count over max", query: "?count=11", wantStatus: fiber.StatusBadRequest},
+		{name: "malformed count", query: "?count=abc", wantStatus: fiber.StatusBadRequest},
+		{name: "malformed bool", query: "?use_ai=maybe", wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{quota: -1}
+			status, envelope := do(t, newTestApp(uc), fiber.MethodGet, "/questions/generate"+tt.query, "")
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			got := uc.generate
+			if got.difficulty != tt.want.difficulty || got.count != tt.want.count || got.includeAnswer != tt.want.includeAnswer ||
+				!slices.Equal(got.patterns, tt.want.patterns) || got.useAI != tt.want.useAI || got.sessionID != tt.want.sessionID || got.shuffle != tt.want.shuffle || got.model != tt.want.model {
+				t.Errorf("Generate called with %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Request validation follows the configured difficulty levels
+func TestGenerateCustomDifficulty(t *testing.T) {
+	if err := entity.SetDifficultyLevels([]entity.DifficultyLevel{{Name: "beginner", MinLength: 3, MaxLength: 4}, {Name: "easy"}}); err != nil {
+		t.Fatalf("SetDifficultyLevels: %v", err)
+	}
+	t.Cleanup(func() { _ = entity.SetDifficultyLevels(entity.DefaultDifficultyLevels) })
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{name: "custom level in body", method: fiber.MethodPost, target: "/questions/generate", body: `{"difficulty":"Beginner"}`, wantStatus: fiber.StatusOK},
+		{name: "custom level in query", method: fiber.MethodGet, target: "/questions/generate?difficulty=beginner", wantStatus: fiber.StatusOK},
+		{name: "unconfigured built-in level", method: fiber.MethodPost, target: "/questions/generate", body: `{"difficulty":"hard"}`, wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{quota: -1}
+			status, envelope := do(t, newTestApp(uc), tt.method, tt.target, tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			if tt.wantStatus == fiber.StatusOK && uc.generate.difficulty != "beginner" {
+				t.Errorf("Generate got difficulty %q, want beginner", uc.generate.difficulty)
+			}
+		})
+	}
+}
+
+// A usecase error (e.g. an unknown pattern) is reported as a bad request
+func TestGenerateFromBodyUsecaseError(t *testing.T) {
+	uc := &fakeUsecase{err: errInvalidPattern, quota: -1}
+	status, envelope := do(t, newTestApp(uc), fiber.MethodPost, "/questions/generate", `{"patterns":["x-y"]}`)
+	if status != fiber.StatusBadRequest || envelope["error"] != errInvalidPattern.Error() {
+		t.Errorf("status %d, body %v; want 400 with the usecase error", status, envelope)
+	}
+}
+
+// A shortfall meta from the usecase reaches the client on both generate endpoints; without one it is omitted
+func TestGenerateShortfallMeta(t *testing.T) {
+	meta := &entity.GenerateMeta{Requested: 5, Returned: 2, Reason: entity.GenerateShortfallCacheExhausted}
+	for _, call := range []struct{ method, target, body string }{
+		{fiber.MethodGet, "/questions/generate?count=5&use_ai=false", ""},
+		{fiber.MethodPost, "/questions/generate", `{"count":5,"use_ai":false}`},
+	} {
+		status, envelope := do(t, newTestApp(&fakeUsecase{quota: -1, meta: meta}), call.method, call.target, call.body)
+		want := map[string]any{"requested": float64(5), "returned": float64(2), "reason": "cache_exhausted"}
+		if status != fiber.StatusOK || !reflect.DeepEqual(envelope["meta"], want) {
+			t.Errorf("%s %s: status %d, meta %v; want 200 with %v", call.method, call.target, status, envelope["meta"], want)
+		}
+
+		_, envelope = do(t, newTestApp(&fakeUsecase{quota: -1}), call.method, call.target, call.body)
+		if _, ok := envelope["meta"]; ok {
+			t.Errorf("%s %s: meta %v sent without a shortfall", call.method, call.target, envelope["meta"])
+		}
+	}
+}
+
+// The remaining daily AI quota is exposed as a header only when a quota applies
+func TestGenerateQuotaHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		quota int
+		want  string
+	}{
+		{name: "no quota", quota: -1},
+		{name: "remaining", quota: 4, want: "4"},
+		{name: "exhausted", quota: 0, want: "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{quota: tt.quota}
+			resp, envelope := doResponse(t, newTestApp(uc), fiber.MethodGet, "/questions/generate?session_id=s-1", "", nil)
+			if resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("status = %d, want 200 (%v)", resp.StatusCode, envelope)
+			}
+			if got := resp.Header.Get("X-AI-Quota-Remaining"); got != tt.want {
+				t.Errorf("X-AI-Quota-Remaining = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+var errInvalidPattern = errors.New("invalid pattern: x-y (allowed: b-d, p-q, m-w, n-u, m-n)")
+
+func TestGetTemplates(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       templatesCall
+	}{
+		{name: "answers hidden by default", query: "difficulty=easy", wantStatus: fiber.StatusOK, want: templatesCall{difficulty: entity.DifficultyEasy, page: 1, limit: 20}},
+		{name: "answers requested", query: "difficulty=Medium&includeAnswer=true&page=2&limit=5", wantStatus: fiber.StatusOK, want: templatesCall{difficulty: entity.DifficultyMedium, includeAnswer: true, page: 2, limit: 5}},
+		{name: "limit capped", query: "limit=500&page=0", wantStatus: fiber.StatusOK, want: templatesCall{page: 1, limit: 100}},
+		{name: "unknown difficulty", query: "difficulty=extreme", wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{}
+			status, envelope := do(t, newTestApp(uc), fiber.MethodGet, "/questions/templates?"+tt.query, "")
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			if tt.wantStatus != fiber.StatusOK {
+				return
+			}
+			if uc.templates != tt.want {
+				t.Errorf("GetTemplates called with %+v, want %+v", uc.templates, tt.want)
+			}
+
+			data, _ := envelope["data"].([]any)
+			if len(data) != 1 {
+				t.Fatalf("data = %v, want one template", envelope["data"])
+			}
+			template := data[0].(map[string]any)
+			if _, ok := template["correctWord"]; ok != tt.want.includeAnswer {
+				t.Errorf("template %v exposes correctWord=%v, want %v", template, ok, tt.want.includeAnswer)
+			}
+			meta, _ := envelope["meta"].(map[string]any)
+			hasMore := tt.want.page*tt.want.limit < 21
+			if meta["page"] != float64(tt.want.page) || meta["per_page"] != float64(tt.want.limit) || meta["total"] != float64(21) || meta["has_more"] != hasMore {
+				t.Errorf("meta = %v, want page %d per_page %d total 21 has_more %v", meta, tt.want.page, tt.want.limit, hasMore)
+			}
+		})
+	}
+}
+
+func TestGetTemplatesUsecaseError(t *testing.T) {
+	uc := &fakeUsecase{err: errors.New("failed to count templates: connection refused")}
+	if status, envelope := do(t, newTestApp(uc), fiber.MethodGet, "/questions/templates", ""); status != fiber.StatusBadRequest {
+		t.Errorf("status %d, body %v; want 400", status, envelope)
+	}
+}
+
+func TestPreviewFallback(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		err        error
+		wantStatus int
+		want       fallbackCall
+	}{
+		{name: "fixed seed by default", query: "pattern=b-d", wantStatus: fiber.StatusOK, want: fallbackCall{pattern: "b-d", seed: 1}},
+		{name: "explicit seed", query: "difficulty=Hard&pattern=p-q&seed=42", wantStatus: fiber.StatusOK, want: fallbackCall{difficulty: entity.DifficultyHard, pattern: "p-q", seed: 42}},
+		{name: "missing pattern", query: "difficulty=easy", wantStatus: fiber.StatusBadRequest},
+		{name: "unknown difficulty", query: "difficulty=extreme&pattern=b-d", wantStatus: fiber.StatusBadRequest},
+		{name: "invalid seed", query: "pattern=b-d&seed=abc", wantStatus: fiber.StatusBadRequest},
+		{name: "unknown pattern", query: "pattern=x-y", err: errInvalidPattern, wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			status, envelope := do(t, newTestApp(uc), fiber.MethodGet, "/questions/fallback?"+tt.query, "")
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			if tt.wantStatus == fiber.StatusOK && uc.fallback != tt.want {
+				t.Errorf("PreviewFallback called with %+v, want %+v", uc.fallback, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateQuestion(t *testing.T) {
+	valid := `{"options":["bola","dola","bolb"],"correct_answer":"bola","difficulty":"easy","target_letter_pair":"b-d"}`
+	tests := []struct {
+		name       string
+		token      string
+		body       string
+		err        error
+		wantStatus int
+	}{
+		{name: "valid insert", token: testAdminToken, body: valid, wantStatus: fiber.StatusOK},
+		{name: "options missing the correct answer", token: testAdminToken, body: `{"options":["dola","bolb"],"correct_answer":"bola","difficulty":"easy","target_letter_pair":"b-d"}`,
+			err: errors.New("options must include the correct answer"), wantStatus: fiber.StatusBadRequest},
+		{name: "single option", token: testAdminToken, body: `{"options":["bola"],"correct_answer":"bola","difficulty":"easy","target_letter_pair":"b-d"}`, wantStatus: fiber.StatusBadRequest},
+		{name: "unknown difficulty", token: testAdminToken, body: `{"options":["bola","dola"],"correct_answer":"bola","difficulty":"extreme","target_letter_pair":"b-d"}`, wantStatus: fiber.StatusBadRequest},
+		{name: "missing admin token", body: valid, wantStatus: fiber.StatusUnauthorized},
+		{name: "wrong admin token", token: "guess", body: valid, wantStatus: fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			resp, envelope := doResponse(t, newTestApp(uc), fiber.MethodPost, "/admin/questions", tt.body, map[string]string{"X-Admin-Token": tt.token})
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", resp.StatusCode, tt.wantStatus, envelope)
+			}
+			if tt.err != nil && envelope["error"] != tt.err.Error() {
+				t.Errorf("error = %v, want %q", envelope["error"], tt.err.Error())
+			}
+			reachedUsecase := uc.created != nil
+			if wantReached := tt.wantStatus == fiber.StatusOK || tt.err != nil; reachedUsecase != wantReached {
+				t.Errorf("usecase called = %v, want %v", reachedUsecase, wantReached)
+			}
+		})
+	}
+}
+
+func TestGetUserTrends(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       trendsCall
+	}{
+		{name: "all pairs", wantStatus: fiber.StatusOK, want: trendsCall{userID: "user-1"}},
+		{name: "pair and range", query: "pair=b-d&from=2026-03-01&to=2026-03-31", wantStatus: fiber.StatusOK, want: trendsCall{
+			userID: "user-1", pair: "b-d",
+			from: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), to: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		}},
+		{name: "bad date", query: "from=March", wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{}
+			status, envelope := do(t, newTestApp(uc), fiber.MethodGet, "/users/user-1/trends?"+tt.query, "")
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			if tt.wantStatus == fiber.StatusOK && (uc.trends.userID != tt.want.userID || uc.trends.pair != tt.want.pair ||
+				!uc.trends.from.Equal(tt.want.from) || !uc.trends.to.Equal(tt.want.to)) {
+				t.Errorf("GetUserTrends called with %+v, want %+v", uc.trends, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetPairAnalytics(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		err        error
+		wantStatus int
+		want       entity.Difficulty
+	}{
+		{name: "all difficulties", wantStatus: fiber.StatusOK},
+		{name: "difficulty", query: "difficulty=Hard", wantStatus: fiber.StatusOK, want: entity.DifficultyHard},
+		{name: "unknown difficulty", query: "difficulty=extreme", wantStatus: fiber.StatusBadRequest},
+		{name: "usecase error", err: errors.New("boom"), wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			status, envelope := do(t, newTestApp(uc), fiber.MethodGet, "/analytics/pairs?"+tt.query, "")
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			if tt.wantStatus == fiber.StatusOK && (uc.pairs == nil || *uc.pairs != tt.want) {
+				t.Errorf("GetPairAnalytics called with %v, want %q", uc.pairs, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAnswerDistribution(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "found", wantStatus: fiber.StatusOK},
+		{name: "unknown question", err: errors.New("question not found: record not found"), wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			status, envelope := do(t, newTestApp(uc), fiber.MethodGet, "/admin/questions/q-1/distribution", "")
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			if uc.dist != "q-1" {
+				t.Errorf("GetAnswerDistribution called with %q, want q-1", uc.dist)
+			}
+		})
+	}
+}
+
+func TestNextDrillQuestion(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		err        error
+		wantStatus int
+		want       entity.DrillQuery
+	}{
+		{name: "pair", target: "/sessions/sess-1/drill?pair=%20B-D", wantStatus: fiber.StatusOK, want: entity.DrillQuery{Pair: "b-d"}},
+		{name: "all params", target: "/sessions/sess-1/drill?pair=b-d&difficulty=Hard&target=90&window=4", wantStatus: fiber.StatusOK,
+			want: entity.DrillQuery{Pair: "b-d", Difficulty: entity.DifficultyHard, Target: 90, Window: 4}},
+		{name: "missing pair", target: "/sessions/sess-1/drill", wantStatus: fiber.StatusBadRequest},
+		{name: "target above 100", target: "/sessions/sess-1/drill?pair=b-d&target=120", wantStatus: fiber.StatusBadRequest},
+		{name: "window too large", target: "/sessions/sess-1/drill?pair=b-d&window=51", wantStatus: fiber.StatusBadRequest},
+		{name: "unknown difficulty", target: "/sessions/sess-1/drill?pair=b-d&difficulty=extreme", wantStatus: fiber.StatusBadRequest},
+		{name: "malformed session id", target: "/sessions/sess.1/drill?pair=b-d", wantStatus: fiber.StatusBadRequest},
+		{name: "usecase error", target: "/sessions/sess-1/drill?pair=x-y", err: errors.New("invalid letter pair"), wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			status, envelope := do(t, newTestApp(uc), fiber.MethodGet, tt.target, "")
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			if tt.wantStatus != fiber.StatusOK {
+				return
+			}
+			got := uc.drill.query
+			if uc.drill.sessionID != "sess-1" || got.Pair != tt.want.Pair || got.Difficulty != tt.want.Difficulty || got.Target != tt.want.Target || got.Window != tt.want.Window {
+				t.Errorf("NextDrillQuestion called with %s %+v, want sess-1 %+v", uc.drill.sessionID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatWithBot(t *testing.T) {
+	tests := []struct {
+		name       string
+		sessionID  string
+		body       string
+		wantStatus int
+		wantField  string
+		wantModel  string
+	}{
+		{name: "valid", sessionID: "sess-1", body: `{"message":"halo"}`, wantStatus: fiber.StatusOK},
+		{name: "model override", sessionID: "sess-1", body: `{"message":"halo","model":"local-model"}`, wantStatus: fiber.StatusOK, wantModel: "local-model"},
+		{name: "empty message", sessionID: "sess-1", body: `{"message":""}`, wantStatus: fiber.StatusBadRequest, wantField: "message"},
+		{name: "blank message", sessionID: "sess-1", body: `{"message":"   "}`, wantStatus: fiber.StatusBadRequest, wantField: "message"},
+		{name: "message too long", sessionID: "sess-1", body: `{"message":"` + strings.Repeat("a", 2001) + `"}`, wantStatus: fiber.StatusBadRequest, wantField: "message"},
+		{name: "malformed session id", sessionID: "sess.1", body: `{"message":"halo"}`, wantStatus: fiber.StatusBadRequest, wantField: "session_id"},
+		{name: "session id too long", sessionID: strings.Repeat("s", 65), body: `{"message":"halo"}`, wantStatus: fiber.StatusBadRequest, wantField: "session_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{quota: -1}
+			status, envelope := do(t, newTestApp(uc), fiber.MethodPost, "/chatbot/sessions/"+tt.sessionID, tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			if tt.wantStatus == fiber.StatusOK {
+				if uc.chat != (chatCall{sessionID: tt.sessionID, message: "halo", model: tt.wantModel}) {
+					t.Errorf("ChatWithBot called with %+v", uc.chat)
+				}
+				return
+			}
+			if uc.chat != (chatCall{}) {
+				t.Errorf("usecase called for an invalid request: %+v", uc.chat)
+			}
+			if !strings.Contains(fmt.Sprint(envelope), tt.wantField) {
+				t.Errorf("error does not name %q: %v", tt.wantField, envelope)
+			}
+		})
+	}
+}
+
+// Usecase sentinel errors pick the status: unknown session 404, malformed id or invalid input 400,
+// LLM failures 502 and anything else 500
+// Sessions started with a target_count report their progress, as meta of the answer log and on its own
+func TestSessionProgress(t *testing.T) {
+	progress := &entity.SessionProgress{SessionID: "sess-1", AnsweredCount: 2, TargetCount: 8, RemainingCount: 6, CompletionPercent: 25}
+	want := map[string]any{
+		"session_id": "sess-1", "answered_count": float64(2), "target_count": float64(8),
+		"remaining_count": float64(6), "completion_percent": float64(25), "completed": false,
+	}
+	status, envelope := do(t, newTestApp(&fakeUsecase{progress: progress}), fiber.MethodGet, "/questions/sessions/sess-1", "")
+	if status != fiber.StatusOK || !reflect.DeepEqual(envelope["meta"], want) {
+		t.Errorf("answers: status %d, meta %v; want 200 with %v", status, envelope["meta"], want)
+	}
+	_, envelope = do(t, newTestApp(&fakeUsecase{}), fiber.MethodGet, "/questions/sessions/sess-1", "")
+	if _, ok := envelope["meta"]; ok {
+		t.Errorf("answers: meta %v sent for a session without a target", envelope["meta"])
+	}
+
+	status, envelope = do(t, newTestApp(&fakeUsecase{}), fiber.MethodGet, "/sessions/sess-1/progress", "")
+	data, _ := envelope["data"].(map[string]any)
+	if status != fiber.StatusOK || data["remaining_count"] != float64(7) || data["completion_percent"] != float64(30) {
+		t.Errorf("progress: status %d, data %v", status, data)
+	}
+}
+
+func TestSessionErrorStatus(t *testing.T) {
+	targets := map[string]struct{ method, target, body string }{
+		"answers":  {fiber.MethodGet, "/questions/sessions/%s", ""},
+		"progress": {fiber.MethodGet, "/sessions/%s/progress", ""},
+		"report":   {fiber.MethodGet, "/report/sessions/%s", ""},
+		"chat":     {fiber.MethodPost, "/chatbot/sessions/%s", `{"message":"halo"}`},
+	}
+	tests := []struct {
+		name       string
+		sessionID  string
+		err        error
+		wantStatus int
+	}{
+		{name: "found", sessionID: "sess-1", wantStatus: fiber.StatusOK},
+		{name: "unknown session", sessionID: "sess-1", err: fmt.Errorf("%w: session sess-1", usecase.ErrNotFound), wantStatus: fiber.StatusNotFound},
+		{name: "malformed session id", sessionID: "sess.1", wantStatus: fiber.StatusBadRequest},
+		{name: "invalid input", sessionID: "sess-1", err: fmt.Errorf("%w: no answers found for session", usecase.ErrInvalidInput), wantStatus: fiber.StatusBadRequest},
+		{name: "llm failure", sessionID: "sess-1", err: fmt.Errorf("%w: timeout", usecase.ErrUpstream), wantStatus: fiber.StatusBadGateway},
+		{name: "open breaker", sessionID: "sess-1", err: fmt.Errorf("analysis: %w", llm.ErrCircuitOpen), wantStatus: fiber.StatusBadGateway},
+		{name: "internal failure", sessionID: "sess-1", err: errors.New("connection refused"), wantStatus: fiber.StatusInternalServerError},
+	}
+	for endpoint, call := range targets {
+		for _, tt := range tests {
+			t.Run(endpoint+"/"+tt.name, func(t *testing.T) {
+				uc := &fakeUsecase{err: tt.err, quota: -1}
+				status, envelope := do(t, newTestApp(uc), call.method, fmt.Sprintf(call.target, tt.sessionID), call.body)
+				if status != tt.wantStatus {
+					t.Errorf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+				}
+			})
+		}
+	}
+}
+
+func TestUserPreferences(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		userID     string
+		body       string
+		err        error
+		wantStatus int
+		wantSaved  *entity.UserPreferencesRequest
+	}{
+		{name: "get", method: fiber.MethodGet, userID: "user-1", wantStatus: fiber.StatusOK},
+		{name: "get failure", method: fiber.MethodGet, userID: "user-1", err: errors.New("connection refused"), wantStatus: fiber.StatusInternalServerError},
+		{name: "get user id too long", method: fiber.MethodGet, userID: strings.Repeat("u", 101), wantStatus: fiber.StatusBadRequest},
+		{name: "save normalized", method: fiber.MethodPut, userID: "user-1", body: `{"preferred_difficulty":"Hard","letter_pairs":["b-d"],"language":" EN "}`,
+			wantStatus: fiber.StatusOK, wantSaved: &entity.UserPreferencesRequest{UserID: "user-1", PreferredDifficulty: "hard", LetterPairs: []string{"b-d"}, Language: "en"}},
+		{name: "save unknown difficulty", method: fiber.MethodPut, userID: "user-1", body: `{"preferred_difficulty":"extreme"}`, wantStatus: fiber.StatusBadRequest},
+		{name: "save unsupported language", method: fiber.MethodPut, userID: "user-1", body: `{"language":"fr"}`, wantStatus: fiber.StatusBadRequest},
+		{name: "save user id too long", method: fiber.MethodPut, userID: strings.Repeat("u", 101), body: `{}`, wantStatus: fiber.StatusBadRequest},
+		{name: "save rejected pair", method: fiber.MethodPut, userID: "user-1", body: `{"letter_pairs":["x-y"]}`,
+			err: fmt.Errorf("%w: unknown letter pair x-y", usecase.ErrInvalidInput), wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			status, envelope := do(t, newTestApp(uc), tt.method, "/users/"+tt.userID+"/preferences", tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			if status == fiber.StatusBadRequest && tt.err == nil && uc.prefsUser != "" {
+				t.Errorf("usecase called for an invalid request")
+			}
+			if tt.wantSaved != nil && !reflect.DeepEqual(uc.prefs, tt.wantSaved) {
+				t.Errorf("saved %+v, want %+v", uc.prefs, tt.wantSaved)
+			}
+		})
+	}
+}
+
+// Route params are validated before the usecase runs
+func TestPathParamsValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+	}{
+		{name: "chat history", target: "/chatbot/sessions/sess-1/history", wantStatus: fiber.StatusOK},
+		{name: "chat history malformed session id", target: "/chatbot/sessions/sess.1/history", wantStatus: fiber.StatusBadRequest},
+		{name: "trends user id too long", target: "/users/" + strings.Repeat("u", 101) + "/trends", wantStatus: fiber.StatusBadRequest},
+		{name: "delete user", method: fiber.MethodDelete, target: "/users/ana@example.com", wantStatus: fiber.StatusOK},
+		{name: "delete user id too long", method: fiber.MethodDelete, target: "/users/" + strings.Repeat("u", 101), wantStatus: fiber.StatusBadRequest},
+		{name: "restore user", method: fiber.MethodPost, target: "/users/user-1/restore", wantStatus: fiber.StatusOK},
+		{name: "restore user id too long", method: fiber.MethodPost, target: "/users/" + strings.Repeat("u", 101) + "/restore", wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = fiber.MethodGet
+			}
+			status, envelope := do(t, newTestApp(&fakeUsecase{}), method, tt.target, "")
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+		})
+	}
+}
+
+func TestGetChatHistoryPagination(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		want     chatCall
+		wantMeta map[string]any
+	}{
+		{name: "defaults", want: chatCall{sessionID: "sess-1", page: 1, perPage: 50},
+			wantMeta: map[string]any{"total": 120.0, "page": 1.0, "per_page": 50.0, "has_more": true}},
+		{name: "last page", query: "page=3&per_page=50", want: chatCall{sessionID: "sess-1", page: 3, perPage: 50},
+			wantMeta: map[string]any{"total": 120.0, "page": 3.0, "per_page": 50.0, "has_more": false}},
+		{name: "per_page capped", query: "per_page=500", want: chatCall{sessionID: "sess-1", page: 1, perPage: 100},
+			wantMeta: map[string]any{"total": 120.0, "page": 1.0, "per_page": 100.0, "has_more": true}},
+		{name: "invalid values fall back", query: "page=0&per_page=abc", want: chatCall{sessionID: "sess-1", page: 1, perPage: 50},
+			wantMeta: map[string]any{"total": 120.0, "page": 1.0, "per_page": 50.0, "has_more": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{}
+			status, envelope := do(t, newTestApp(uc), fiber.MethodGet, "/chatbot/sessions/sess-1/history?"+tt.query, "")
+			if status != fiber.StatusOK {
+				t.Fatalf("status = %d (%v)", status, envelope)
+			}
+			if uc.chat != tt.want {
+				t.Errorf("GetChatHistory called with %+v, want %+v", uc.chat, tt.want)
+			}
+			if !reflect.DeepEqual(envelope["meta"], tt.wantMeta) {
+				t.Errorf("meta = %v, want %v", envelope["meta"], tt.wantMeta)
+			}
+		})
+	}
+}
+
+// The transcript is sent as a file with the usecase's content type instead of the JSON envelope
+func TestGetChatTranscript(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		err             error
+		wantStatus      int
+		wantFormat      string
+		wantContentType string
+	}{
+		{name: "markdown", query: "?format=md", wantStatus: fiber.StatusOK, wantFormat: "md", wantContentType: "text/markdown; charset=utf-8"},
+		{name: "text", query: "?format=txt", wantStatus: fiber.StatusOK, wantFormat: "txt", wantContentType: "text/plain; charset=utf-8"},
+		{name: "unknown format", query: "?format=pdf", wantStatus: fiber.StatusBadRequest},
+		{name: "usecase error", err: errors.New("db down"), wantStatus: fiber.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			resp, err := newTestApp(uc).Test(httptest.NewRequest(fiber.MethodGet, "/chatbot/sessions/sess-1/transcript"+tt.query, nil))
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", resp.StatusCode, tt.wantStatus, body)
+			}
+			if tt.wantStatus == fiber.StatusBadRequest {
+				if uc.format != nil {
+					t.Errorf("usecase called with format %q for an invalid request", *uc.format)
+				}
+				return
+			}
+			if tt.wantStatus != fiber.StatusOK {
+				return
+			}
+			if uc.format == nil || *uc.format != tt.wantFormat {
+				t.Errorf("format = %v, want %q", uc.format, tt.wantFormat)
+			}
+			if got := resp.Header.Get(fiber.HeaderContentType); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			wantDisposition := fmt.Sprintf(`attachment; filename="chat-sess-1.%s"`, tt.wantFormat)
+			if got := resp.Header.Get(fiber.HeaderContentDisposition); got != wantDisposition {
+				t.Errorf("Content-Disposition = %q, want %q", got, wantDisposition)
+			}
+			if string(body) != "halo" {
+				t.Errorf("body = %q, want the transcript", body)
+			}
+		})
+	}
+}
+
+// A session with no answers or chat messages lists [] rather than null
+func TestEmptyListsEncodeAsArray(t *testing.T) {
+	for _, target := range []string{"/questions/sessions/empty", "/chatbot/sessions/empty/history"} {
+		t.Run(target, func(t *testing.T) {
+			status, envelope := do(t, newTestApp(&fakeUsecase{}), fiber.MethodGet, target, "")
+			if status != fiber.StatusOK {
+				t.Fatalf("status = %d (%v)", status, envelope)
+			}
+			if data, ok := envelope["data"].([]any); !ok || len(data) != 0 {
+				t.Errorf("data = %#v, want []", envelope["data"])
+			}
+		})
+	}
+}
+
+// template_id serves that bank template instead of generating
+func TestGenerateFromTemplateID(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		err        error
+		wantStatus int
+		want       templateCall
+	}{
+		{name: "query", method: fiber.MethodGet, target: "/questions/generate?template_id=e-bd-1&difficulty=easy&includeAnswer=true&session_id=sess-1",
+			wantStatus: fiber.StatusOK, want: templateCall{templateID: "e-bd-1", difficulty: entity.DifficultyEasy, includeAnswer: true, sessionID: "sess-1"}},
+		{name: "body", method: fiber.MethodPost, target: "/questions/generate", body: `{"template_id":"e-bd-1","session_id":"sess-1"}`,
+			wantStatus: fiber.StatusOK, want: templateCall{templateID: "e-bd-1", sessionID: "sess-1"}},
+		{name: "unknown template", method: fiber.MethodGet, target: "/questions/generate?template_id=x-1", err: errors.New("template not found: x-1"),
+			wantStatus: fiber.StatusBadRequest, want: templateCall{templateID: "x-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err, quota: -1}
+			status, envelope := do(t, newTestApp(uc), tt.method, tt.target, tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			if uc.template != tt.want || uc.generate.count != 0 {
+				t.Errorf("GenerateFromTemplate called with %+v (generate %+v), want %+v only", uc.template, uc.generate, tt.want)
+			}
+			if data, _ := envelope["data"].([]any); tt.wantStatus == fiber.StatusOK && len(data) != 1 {
+				t.Errorf("data = %v, want the template question as a list", envelope["data"])
+			}
+		})
+	}
+}
+
+func TestCompareSessions(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		err        error
+		wantStatus int
+		want       compareCall
+	}{
+		{name: "valid", query: "a=s-1&b=s-2", wantStatus: fiber.StatusOK, want: compareCall{a: "s-1", b: "s-2"}},
+		{name: "same user", query: "a=s-1&b=s-2&same_user=true", wantStatus: fiber.StatusOK, want: compareCall{a: "s-1", b: "s-2", sameUser: true}},
+		{name: "missing b", query: "a=s-1", wantStatus: fiber.StatusBadRequest},
+		{name: "usecase error", query: "a=s-1&b=s-2", err: errors.New("sessions to compare must be different"), wantStatus: fiber.StatusBadRequest, want: compareCall{a: "s-1", b: "s-2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			status, envelope := do(t, newTestApp(uc), fiber.MethodGet, "/report/compare?"+tt.query, "")
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			if uc.compare != tt.want {
+				t.Errorf("CompareSessions called with %+v, want %+v", uc.compare, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetBatchReports(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		wantStatus int
+		want       []string
+	}{
+		{name: "valid", body: `{"session_ids":["s-1","s-2"]}`, wantStatus: fiber.StatusOK, want: []string{"s-1", "s-2"}},
+		{name: "empty list", body: `{"session_ids":[]}`, wantStatus: fiber.StatusBadRequest},
+		{name: "missing list", body: `{}`, wantStatus: fiber.StatusBadRequest},
+		{name: "malformed session id", body: `{"session_ids":["s-1","s 2"]}`, wantStatus: fiber.StatusBadRequest},
+		{name: "over the cap", body: `{"session_ids":["s-1","s-2"]}`, err: errors.New("too many sessions: 2 (max 1)"), wantStatus: fiber.StatusBadRequest, want: []string{"s-1", "s-2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			status, envelope := do(t, newTestApp(uc), fiber.MethodPost, "/report/batch", tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			if !slices.Equal(uc.batch, tt.want) {
+				t.Errorf("GetBatchReports called with %v, want %v", uc.batch, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegenerateOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		query      string
+		err        error
+		wantStatus int
+		want       regenerateCall
+	}{
+		{name: "all difficulties", token: testAdminToken, query: "pattern=b-d", wantStatus: fiber.StatusOK, want: regenerateCall{pattern: "b-d"}},
+		{name: "difficulty is normalized", token: testAdminToken, query: "pattern=b-d&difficulty=EASY", wantStatus: fiber.StatusOK, want: regenerateCall{pattern: "b-d", difficulty: entity.DifficultyEasy}},
+		{name: "missing pattern", token: testAdminToken, query: "difficulty=easy", wantStatus: fiber.StatusBadRequest},
+		{name: "unknown difficulty", token: testAdminToken, query: "pattern=b-d&difficulty=extreme", wantStatus: fiber.StatusBadRequest},
+		{name: "usecase error", token: testAdminToken, query: "pattern=x-y", err: errors.New("invalid pattern"), wantStatus: fiber.StatusBadRequest, want: regenerateCall{pattern: "x-y"}},
+		{name: "no admin token", query: "pattern=b-d", wantStatus: fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			resp, envelope := doResponse(t, newTestApp(uc), fiber.MethodPost, "/admin/questions/regenerate?"+tt.query, "", map[string]string{"X-Admin-Token": tt.token})
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", resp.StatusCode, tt.wantStatus, envelope)
+			}
+			if uc.regen != tt.want {
+				t.Errorf("RegenerateOptions called with %+v, want %+v", uc.regen, tt.want)
+			}
+		})
+	}
+}
+
+func TestBulkGenerate(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		body       string
+		err        error
+		wantStatus int
+		want       *entity.BulkGenerateRequest
+	}{
+		{name: "default matrix", token: testAdminToken, body: `{"count_per_target":2}`, wantStatus: fiber.StatusOK, want: &entity.BulkGenerateRequest{CountPerTarget: 2}},
+		{
+			name: "difficulty is normalized", token: testAdminToken, wantStatus: fiber.StatusOK,
+			body: `{"targets":[{"difficulty":"EASY","target_letter_pair":"b-d","count":3}]}`,
+			want: &entity.BulkGenerateRequest{Targets: []entity.BulkGenerateTarget{{Difficulty: entity.DifficultyEasy, TargetLetterPair: "b-d", Count: 3}}},
+		},
+		{name: "count out of range", token: testAdminToken, body: `{"count_per_target":101}`, wantStatus: fiber.StatusBadRequest},
+		{name: "target without count", token: testAdminToken, body: `{"targets":[{"difficulty":"easy","target_letter_pair":"b-d"}]}`, wantStatus: fiber.StatusBadRequest},
+		{name: "unknown difficulty", token: testAdminToken, body: `{"targets":[{"difficulty":"extreme","target_letter_pair":"b-d","count":1}]}`, wantStatus: fiber.StatusBadRequest},
+		{name: "invalid input from usecase", token: testAdminToken, body: `{}`, err: fmt.Errorf("%w: unknown pattern", usecase.ErrInvalidInput), wantStatus: fiber.StatusBadRequest, want: &entity.BulkGenerateRequest{}},
+		{name: "usecase error", token: testAdminToken, body: `{}`, err: errors.New("db down"), wantStatus: fiber.StatusInternalServerError, want: &entity.BulkGenerateRequest{}},
+		{name: "no admin token", body: `{}`, wantStatus: fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			resp, envelope := doResponse(t, newTestApp(uc), fiber.MethodPost, "/admin/questions/bulk-generate", tt.body, map[string]string{"X-Admin-Token": tt.token})
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", resp.StatusCode, tt.wantStatus, envelope)
+			}
+			if !reflect.DeepEqual(uc.bulk, tt.want) {
+				t.Errorf("BulkGenerate called with %+v, want %+v", uc.bulk, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetServeLog(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		sessionID  string
+		err        error
+		wantStatus int
+		want       string
+	}{
+		{name: "valid", token: testAdminToken, sessionID: "sess-1", wantStatus: fiber.StatusOK, want: "sess-1"},
+		{name: "invalid session id", token: testAdminToken, sessionID: "sess%20one", wantStatus: fiber.StatusBadRequest},
+		{name: "usecase error", token: testAdminToken, sessionID: "sess-1", err: errors.New("failed to get serve log"), wantStatus: fiber.StatusBadRequest, want: "sess-1"},
+		{name: "no admin token", sessionID: "sess-1", wantStatus: fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			resp, envelope := doResponse(t, newTestApp(uc), fiber.MethodGet, "/admin/sessions/"+tt.sessionID+"/served", "", map[string]string{"X-Admin-Token": tt.token})
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", resp.StatusCode, tt.wantStatus, envelope)
+			}
+			if uc.served != tt.want {
+				t.Errorf("GetServeLog called with %q, want %q", uc.served, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegrade(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		target     string
+		err        error
+		wantStatus int
+		want       string
+	}{
+		{name: "session", token: testAdminToken, target: "/admin/sessions/sess-1/regrade", wantStatus: fiber.StatusOK, want: "sess-1"},
+		{name: "question", token: testAdminToken, target: "/admin/questions/q-1/regrade", wantStatus: fiber.StatusOK, want: "q-1"},
+		{name: "unknown session", token: testAdminToken, target: "/admin/sessions/sess-1/regrade", err: fmt.Errorf("%w: session sess-1", usecase.ErrNotFound), wantStatus: fiber.StatusNotFound, want: "sess-1"},
+		{name: "unknown question", token: testAdminToken, target: "/admin/questions/q-1/regrade", err: fmt.Errorf("%w: question q-1", usecase.ErrNotFound), wantStatus: fiber.StatusNotFound, want: "q-1"},
+		{name: "malformed session id", token: testAdminToken, target: "/admin/sessions/sess.1/regrade", wantStatus: fiber.StatusBadRequest},
+		{name: "write failure", token: testAdminToken, target: "/admin/questions/q-1/regrade", err: errors.New("connection refused"), wantStatus: fiber.StatusInternalServerError, want: "q-1"},
+		{name: "no admin token", target: "/admin/sessions/sess-1/regrade", wantStatus: fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			resp, envelope := doResponse(t, newTestApp(uc), fiber.MethodPost, tt.target, "", map[string]string{"X-Admin-Token": tt.token})
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", resp.StatusCode, tt.wantStatus, envelope)
+			}
+			if uc.regraded != tt.want {
+				t.Errorf("regrade called with %q, want %q", uc.regraded, tt.want)
+			}
+		})
+	}
+}
+
+func TestListQuestions(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		token      string
+		err        error
+		wantStatus int
+		want       *listCall
+	}{
+		{name: "defaults", token: testAdminToken, wantStatus: fiber.StatusOK, want: &listCall{page: 1, perPage: 50}},
+		{name: "filters", token: testAdminToken, query: "generated_by=api.openai.com/gpt-4o-mini&difficulty=Hard&pair=b-d&page=2&per_page=500",
+			wantStatus: fiber.StatusOK, want: &listCall{
+				filter: entity.QuestionListFilter{GeneratedBy: "api.openai.com/gpt-4o-mini", Difficulty: "hard", LetterPair: "b-d"}, page: 2, perPage: 100,
+			}},
+		{name: "unknown difficulty", token: testAdminToken, query: "difficulty=extreme", wantStatus: fiber.StatusBadRequest},
+		{name: "usecase error", token: testAdminToken, err: errors.New("failed to list questions"), wantStatus: fiber.StatusBadRequest, want: &listCall{page: 1, perPage: 50}},
+		{name: "no admin token", wantStatus: fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			resp, envelope := doResponse(t, newTestApp(uc), fiber.MethodGet, "/admin/questions?"+tt.query, "", map[string]string{"X-Admin-Token": tt.token})
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", resp.StatusCode, tt.wantStatus, envelope)
+			}
+			if (uc.list == nil) != (tt.want == nil) || (uc.list != nil && *uc.list != *tt.want) {
+				t.Errorf("ListQuestions called with %+v, want %+v", uc.list, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetQuestionPrompt(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		err        error
+		wantStatus int
+		want       string
+	}{
+		{name: "valid", token: testAdminToken, wantStatus: fiber.StatusOK, want: "q-1"},
+		{name: "no prompt stored", token: testAdminToken, err: errors.New("no prompt stored for question q-1"), wantStatus: fiber.StatusBadRequest, want: "q-1"},
+		{name: "no admin token", wantStatus: fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			resp, envelope := doResponse(t, newTestApp(uc), fiber.MethodGet, "/admin/questions/q-1/prompt", "", map[string]string{"X-Admin-Token": tt.token})
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", resp.StatusCode, tt.wantStatus, envelope)
+			}
+			if uc.prompt != tt.want {
+				t.Errorf("GetQuestionPrompt called with %q, want %q", uc.prompt, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportAnswers(t *testing.T) {
+	const answer = `{"question_id":"q-1","user_answer":"bola","is_correct":true,"answered_at":"2026-01-02T10:00:00Z"}`
+	tests := []struct {
+		name       string
+		sessionID  string
+		body       string
+		err        error
+		wantStatus int
+		wantCalled bool
+	}{
+		{name: "valid", sessionID: "sess-1", body: `{"user_id":"user-1","answers":[` + answer + `]}`, wantStatus: fiber.StatusOK, wantCalled: true},
+		{name: "no answers", sessionID: "sess-1", body: `{"user_id":"user-1","answers":[]}`, wantStatus: fiber.StatusBadRequest},
+		{name: "missing answered_at", sessionID: "sess-1", body: `{"user_id":"user-1","answers":[{"question_id":"q-1","user_answer":"bola"}]}`, wantStatus: fiber.StatusBadRequest},
+		{name: "missing user", sessionID: "sess-1", body: `{"answers":[` + answer + `]}`, wantStatus: fiber.StatusBadRequest},
+		{name: "invalid session id", sessionID: "sess%20one", body: `{"user_id":"user-1","answers":[` + answer + `]}`, wantStatus: fiber.StatusBadRequest},
+		{name: "usecase error", sessionID: "sess-1", body: `{"user_id":"user-1","answers":[` + answer + `]}`, err: errors.New("answers[0]: answered_at is in the future"), wantStatus: fiber.StatusBadRequest, wantCalled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			status, envelope := do(t, newTestApp(uc), fiber.MethodPost, "/sessions/"+tt.sessionID+"/import", tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			if (uc.imported != nil) != tt.wantCalled {
+				t.Fatalf("ImportAnswers called = %v, want %v", uc.imported != nil, tt.wantCalled)
+			}
+			if tt.wantCalled && (uc.imported.SessionID != tt.sessionID || uc.imported.UserID != "user-1" || len(uc.imported.Answers) != 1) {
+				t.Errorf("ImportAnswers got %+v", uc.imported)
+			}
+		})
+	}
+}
+
+// An empty answer is only valid as an explicit skip
+func TestSubmitAnswerSkip(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		err         error
+		wantStatus  int
+		wantSkipped bool
+		wantCalled  bool
+	}{
+		{name: "answer", body: `{"user_id":"user-1","session_id":"sess-1","question_id":"q-1","answer":"bola"}`, wantStatus: fiber.StatusOK, wantCalled: true},
+		{name: "skip", body: `{"user_id":"user-1","session_id":"sess-1","question_id":"q-1","answer":"","skipped":true}`,
+			wantStatus: fiber.StatusOK, wantSkipped: true, wantCalled: true},
+		{name: "empty answer without skip", body: `{"user_id":"user-1","session_id":"sess-1","question_id":"q-1","answer":""}`, wantStatus: fiber.StatusBadRequest},
+		{name: "skip disabled", body: `{"user_id":"user-1","session_id":"sess-1","question_id":"q-1","skipped":true}`,
+			err: errors.New("skipping questions is disabled"), wantStatus: fiber.StatusBadRequest, wantSkipped: true, wantCalled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{err: tt.err}
+			status, envelope := do(t, newTestApp(uc), fiber.MethodPost, "/questions/answer", tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			if (uc.submitted != nil) != tt.wantCalled {
+				t.Fatalf("SubmitAnswer called = %v, want %v", uc.submitted != nil, tt.wantCalled)
+			}
+			if tt.wantCalled && uc.submitted.Skipped != tt.wantSkipped {
+				t.Errorf("SubmitAnswer got skipped = %v, want %v", uc.submitted.Skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+// answer_index replaces the answer text; sending both, or a negative index, is rejected before the usecase
+func TestSubmitAnswerIndex(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantIndex  *int
+	}{
+		{name: "index", body: `{"user_id":"user-1","session_id":"sess-1","question_id":"q-1","answer_index":2}`, wantStatus: fiber.StatusOK, wantIndex: intPtr(2)},
+		{name: "index zero", body: `{"user_id":"user-1","session_id":"sess-1","question_id":"q-1","answer_index":0}`, wantStatus: fiber.StatusOK, wantIndex: intPtr(0)},
+		{name: "text", body: `{"user_id":"user-1","session_id":"sess-1","question_id":"q-1","answer":"bola"}`, wantStatus: fiber.StatusOK},
+		{name: "both", body: `{"user_id":"user-1","session_id":"sess-1","question_id":"q-1","answer":"bola","answer_index":1}`, wantStatus: fiber.StatusBadRequest},
+		{name: "negative index", body: `{"user_id":"user-1","session_id":"sess-1","question_id":"q-1","answer_index":-1}`, wantStatus: fiber.StatusBadRequest},
+		{name: "neither", body: `{"user_id":"user-1","session_id":"sess-1","question_id":"q-1"}`, wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &fakeUsecase{}
+			status, envelope := do(t, newTestApp(uc), fiber.MethodPost, "/questions/answer", tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, envelope)
+			}
+			if tt.wantStatus != fiber.StatusOK {
+				if uc.submitted != nil {
+					t.Error("invalid request reached the usecase")
+				}
+				return
+			}
+			got := uc.submitted.AnswerIndex
+			if (got == nil) != (tt.wantIndex == nil) || (got != nil && *got != *tt.wantIndex) {
+				t.Errorf("SubmitAnswer got answer_index %v, want %v", got, tt.wantIndex)
+			}
+		})
+	}
+}
+
+func intPtr(v int) *int { return &v }
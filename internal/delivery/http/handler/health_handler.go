@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"github.com/evandrarf/dinacom-be/internal/pkg/llm"
+	"github.com/evandrarf/dinacom-be/internal/pkg/metrics"
+	"github.com/evandrarf/dinacom-be/internal/pkg/readiness"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	HealthHandler interface {
+		Ready(ctx *fiber.Ctx) error
+		Metrics(ctx *fiber.Ctx) error
+	}
+
+	healthHandler struct {
+		readiness *readiness.Flag
+		breaker   *llm.Breaker
+		metrics   *metrics.Registry
+	}
+)
+
+func NewHealthHandler(readiness *readiness.Flag, breaker *llm.Breaker, metrics *metrics.Registry) HealthHandler {
+	return &healthHandler{readiness: readiness, breaker: breaker, metrics: metrics}
+}
+
+// GET /readyz - 503 until migrations and seeding have completed.
+// An open LLM breaker is reported but does not fail readiness: requests still fall back to the bank/cache.
+func (h *healthHandler) Ready(ctx *fiber.Ctx) error {
+	llmCircuit := h.breaker.State()
+	if !h.readiness.IsReady() {
+		return ctx.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"ready": false, "llm_circuit": llmCircuit})
+	}
+	return ctx.JSON(fiber.Map{"ready": true, "llm_circuit": llmCircuit})
+}
+
+// GET /metrics - in-process counters (e.g. llm_parse_failures_<path>) in the Prometheus text format
+func (h *healthHandler) Metrics(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	return h.metrics.WriteText(ctx.Response().BodyWriter())
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/pkg/llm"
+	"github.com/evandrarf/dinacom-be/internal/pkg/metrics"
+	"github.com/evandrarf/dinacom-be/internal/pkg/readiness"
+	"github.com/gofiber/fiber/v2"
+)
+
+// /readyz answers 503 while startup is still migrating and seeding, 200 once the flag is set, and reports
+// the LLM breaker either way without letting an open circuit fail readiness
+func TestReady(t *testing.T) {
+	ready := readiness.New()
+	breaker := llm.NewBreaker(1, time.Hour)
+	app := fiber.New()
+	app.Get("/readyz", NewHealthHandler(ready, breaker, metrics.NewRegistry()).Ready)
+
+	check := func(wantStatus int, wantBody string) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/readyz", nil))
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != wantStatus || string(body) != wantBody {
+			t.Errorf("GET /readyz = %d %s, want %d %s", resp.StatusCode, body, wantStatus, wantBody)
+		}
+	}
+	check(fiber.StatusServiceUnavailable, `{"llm_circuit":"closed","ready":false}`)
+	ready.SetReady()
+	check(fiber.StatusOK, `{"llm_circuit":"closed","ready":true}`)
+
+	_ = breaker.Allow()
+	breaker.Record(context.DeadlineExceeded)
+	check(fiber.StatusOK, `{"llm_circuit":"open","ready":true}`)
+}
+
+func TestMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	registry.Inc("llm_parse_failures_generate_single")
+	app := fiber.New()
+	app.Get("/metrics", NewHealthHandler(nil, nil, registry).Metrics)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/metrics", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusOK || !strings.HasPrefix(resp.Header.Get(fiber.HeaderContentType), "text/plain") {
+		t.Errorf("status %d, content type %q; want 200 text/plain", resp.StatusCode, resp.Header.Get(fiber.HeaderContentType))
+	}
+	if !strings.Contains(string(body), "llm_parse_failures_generate_single 1\n") {
+		t.Errorf("body %q does not expose the counter", body)
+	}
+}
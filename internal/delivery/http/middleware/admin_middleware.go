@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/evandrarf/dinacom-be/internal/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminMiddleware requires the X-Admin-Token header to match api.admin_token.
+// Admin routes are closed entirely when no token is configured.
+func (m *Middleware) AdminMiddleware() fiber.Handler {
+	token := ""
+	if m != nil && m.Config != nil {
+		token = m.Config.GetString("api.admin_token")
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		provided := ctx.Get("X-Admin-Token")
+		if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			return response.NewFailed("Unauthorized", fiber.NewError(fiber.StatusUnauthorized, "invalid admin token"), nil).Send(ctx)
+		}
+		return ctx.Next()
+	}
+}
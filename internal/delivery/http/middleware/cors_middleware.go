@@ -5,18 +5,33 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 )
 
+// CorsEnabled reports api.cors.enabled (default true); disable for same-origin deployments
+func (m *Middleware) CorsEnabled() bool {
+	if m == nil || m.Config == nil || !m.Config.IsSet("api.cors.enabled") {
+		return true
+	}
+	return m.Config.GetBool("api.cors.enabled")
+}
+
+// defaultExposeHeaders are readable by browser JS unless api.cors.expose_headers overrides them
+const defaultExposeHeaders = "Content-Length, Content-Type, X-Request-ID, Retry-After, ETag, X-AI-Quota-Remaining"
+
 func (m *Middleware) CorsMiddleware() fiber.Handler {
 	allowOrigins := "*"
+	exposeHeaders := defaultExposeHeaders
 	if m != nil && m.Config != nil {
 		if v := m.Config.GetString("api.cors.origins"); v != "" {
 			allowOrigins = v
 		}
+		if v := m.Config.GetString("api.cors.expose_headers"); v != "" {
+			exposeHeaders = v
+		}
 	}
 
 	return cors.New(cors.Config{
 		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, Content-Length, Accept-Encoding",
 		AllowMethods:  "GET, POST, PUT, PATCH, DELETE",
 		AllowOrigins:  allowOrigins,
-		ExposeHeaders: "Content-Length, Content-Type",
+		ExposeHeaders: exposeHeaders,
 	})
 }
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+func TestCorsExposeHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		expose string
+		want   string
+	}{
+		{name: "default includes operational headers", want: defaultExposeHeaders},
+		{name: "configured", expose: "X-Request-ID, X-Total-Count", want: "X-Request-ID, X-Total-Count"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := viper.New()
+			if tt.expose != "" {
+				config.Set("api.cors.expose_headers", tt.expose)
+			}
+			m := NewMiddleware(&MiddlewareConfig{Config: config})
+
+			app := fiber.New()
+			app.Use(m.CorsMiddleware())
+			app.Get("/ok", func(c *fiber.Ctx) error { return c.SendString("fine") })
+
+			req := httptest.NewRequest("GET", "/ok", nil)
+			req.Header.Set(fiber.HeaderOrigin, "https://example.com")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			// fiber's cors middleware drops the spaces after commas
+			if got := resp.Header.Get(fiber.HeaderAccessControlExposeHeaders); got != strings.ReplaceAll(tt.want, " ", "") {
+				t.Errorf("Access-Control-Expose-Headers = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	exposed := strings.Split(defaultExposeHeaders, ", ")
+	for _, header := range []string{fiber.HeaderXRequestID, fiber.HeaderRetryAfter, fiber.HeaderETag, "X-AI-Quota-Remaining"} {
+		if !slices.Contains(exposed, header) {
+			t.Errorf("default expose headers %q lack %s", defaultExposeHeaders, header)
+		}
+	}
+}
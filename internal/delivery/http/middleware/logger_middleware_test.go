@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+func TestJSONAccessLog(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantPath   string
+		requestID  string
+	}{
+		{name: "ok", target: "/ok", wantStatus: fiber.StatusOK, wantPath: "/ok"},
+		{name: "handler error", target: "/missing", wantStatus: fiber.StatusNotFound, wantPath: "/missing"},
+		{name: "client request id is escaped", target: "/ok", wantStatus: fiber.StatusOK, wantPath: "/ok", requestID: `a","status":0,"x":"\`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			log := logrus.New()
+			log.SetOutput(&out)
+			config := viper.New()
+			config.Set("api.access_log.format", "json")
+			m := NewMiddleware(&MiddlewareConfig{Log: log, Config: config})
+
+			app := fiber.New()
+			app.Use(m.RequestIDMiddleware(), m.AccessLogMiddleware())
+			app.Get("/ok", func(c *fiber.Ctx) error { return c.SendString("fine") })
+
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.requestID != "" {
+				req.Header.Set(fiber.HeaderXRequestID, tt.requestID)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+
+			var entry map[string]any
+			if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+				t.Fatalf("access log %q is not JSON: %v", out.String(), err)
+			}
+			if entry["msg"] != "access" || entry["path"] != tt.wantPath || entry["status"] != float64(tt.wantStatus) {
+				t.Errorf("entry = %v, want path %q status %d", entry, tt.wantPath, tt.wantStatus)
+			}
+			if id, _ := entry["request_id"].(string); id == "" || id != resp.Header.Get(fiber.HeaderXRequestID) {
+				t.Errorf("request_id = %q, want the X-Request-ID %q", id, resp.Header.Get(fiber.HeaderXRequestID))
+			}
+		})
+	}
+}
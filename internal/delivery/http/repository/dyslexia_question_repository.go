@@ -1,8 +1,13 @@
 package repository
 
 import (
+	"database/sql"
+	"strings"
+	"time"
+
 	"github.com/evandrarf/dinacom-be/internal/entity"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type (
@@ -10,34 +15,138 @@ type (
 		// Template operations
 		CreateTemplate(db *gorm.DB, template *entity.QuestionBankTemplate) error
 		FindTemplatesByDifficulty(db *gorm.DB, difficulty string) ([]entity.QuestionBankTemplate, error)
+		FindTemplatesByDifficultyPaginated(db *gorm.DB, difficulty string, offset, limit int) ([]entity.QuestionBankTemplate, error)
 		FindTemplateByTemplateID(db *gorm.DB, templateID string) (*entity.QuestionBankTemplate, error)
 		CountTemplatesByDifficulty(db *gorm.DB, difficulty string) (int64, error)
 
 		// Generated question operations
 		CreateGenerated(db *gorm.DB, question *entity.GeneratedQuestion) error
 		FindGeneratedByQuestionID(db *gorm.DB, questionID string) (*entity.GeneratedQuestion, error)
-		FindRandomGeneratedByDifficulty(db *gorm.DB, difficulty string, limit int, excludeIDs []string) ([]entity.GeneratedQuestion, error)
+		FindGeneratedByQuestionIDs(db *gorm.DB, questionIDs []string) ([]entity.GeneratedQuestion, error)
+		FindGeneratedByTemplateID(db *gorm.DB, templateID string, generatedBy string) (*entity.GeneratedQuestion, error)
+		FindRandomGeneratedByDifficulty(db *gorm.DB, difficulty string, letterPairs []string, limit int, excludeIDs []string, freshSince time.Time) ([]entity.GeneratedQuestion, error)
+		FindOrderedGeneratedByDifficulty(db *gorm.DB, difficulty string, letterPairs []string, limit int, excludeIDs []string) ([]entity.GeneratedQuestion, error)
 		IncrementUsageCount(db *gorm.DB, questionID string) error
+		FindGeneratedByPairAndDifficulty(db *gorm.DB, letterPair string, difficulty string) ([]entity.GeneratedQuestion, error)
+		UpdateGeneratedOptions(db *gorm.DB, questionID string, options string) error
+
+		// Serve log operations
+		CreateServeLogs(db *gorm.DB, logs []entity.QuestionServeLog) error
+		FindServeLogsBySessionID(db *gorm.DB, sessionID string) ([]entity.QuestionServeLog, error)
+		FindLatestServeLog(db *gorm.DB, sessionID string, questionID string) (*entity.QuestionServeLog, error)
 
 		// User answer operations
 		CreateUserAnswer(db *gorm.DB, answer *entity.UserAnswer) error
 		FindUserAnswersBySessionID(db *gorm.DB, sessionID string) ([]entity.UserAnswer, error)
 		FindUserAnswersByUserID(db *gorm.DB, userID string) ([]entity.UserAnswer, error)
 		FindExistingAnswer(db *gorm.DB, userID, sessionID, questionID string) (*entity.UserAnswer, error)
+		CountAnswerAttempts(db *gorm.DB, userID, sessionID, questionID string) (int64, error)
+		FindUserAnswersByQuestionID(db *gorm.DB, questionID string) ([]entity.UserAnswer, error)
+		UpdateAnswerGrade(db *gorm.DB, id uint, correctAnswer string, isCorrect bool, partialCredit float64) error
+		CorrectUserAnswer(db *gorm.DB, answer *entity.UserAnswer) error
 
 		// Session analysis cache operations
 		CreateOrUpdateAnalysisCache(db *gorm.DB, cache *entity.SessionAnalysisCache) error
 		FindAnalysisCacheBySessionID(db *gorm.DB, sessionID string) (*entity.SessionAnalysisCache, error)
+		FindAnalysisCachesBySessionIDs(db *gorm.DB, sessionIDs []string) ([]entity.SessionAnalysisCache, error)
 		FindAnalysisCacheByUserID(db *gorm.DB, userID string, limit int) ([]entity.SessionAnalysisCache, error)
+		UpdateAnalysisCacheScore(db *gorm.DB, sessionID string, score float64) error
+		InvalidateAnalysisCaches(db *gorm.DB, sessionIDs []string, at time.Time) (int64, error)
+
+		// Analytics operations
+		AggregateAccuracyByUsers(db *gorm.DB, userIDs []string, from, to time.Time) ([]UserAccuracyRow, error)
+		AggregateLetterPairStatsByUsers(db *gorm.DB, userIDs []string, from, to time.Time) ([]LetterPairStatRow, error)
+		AggregateDifficultyByUsers(db *gorm.DB, userIDs []string, from, to time.Time) ([]DifficultyCountRow, error)
+		AggregateLetterPairStats(db *gorm.DB, difficulty string) ([]LetterPairStatRow, error)
+		AggregateAnswersByQuestion(db *gorm.DB, questionID string) ([]AnswerCountRow, error)
+		SoftDeleteUserData(db *gorm.DB, userID string, deletedAt time.Time) (*UserDataCounts, error)
+		RestoreUserData(db *gorm.DB, userID string, deletedSince time.Time) (*UserDataCounts, error)
+		AggregateLetterPairTrendByUser(db *gorm.DB, userID string, pair string, from, to time.Time) ([]LetterPairTrendRow, error)
+
+		// LLM usage (quota) operations
+		GetLLMUsage(db *gorm.DB, userID, usageDate, kind string) (int, error)
+		ConsumeLLMUsage(db *gorm.DB, userID, usageDate, kind string, amount, limit int) (bool, error)
+
+		// User preferences operations
+		FindUserPreferences(db *gorm.DB, userID string) (*entity.UserPreferences, error)
+		UpsertUserPreferences(db *gorm.DB, prefs *entity.UserPreferences) error
+
+		// Session operations
+		CountActiveSessionsByUser(db *gorm.DB, userID string) (int64, error)
+		CreateSessionIfAbsent(db *gorm.DB, session *entity.Session) (bool, error)
+		LockUserSessions(db *gorm.DB, userID string) error
+		FindSessionBySessionID(db *gorm.DB, sessionID string) (*entity.Session, error)
+		FindIdleSessions(db *gorm.DB, idleSince time.Time, limit int) ([]IdleSessionRow, error)
+		ExpireSession(db *gorm.DB, sessionID, userID string, expiredAt time.Time, pruneAnswers bool) error
 
 		// Chat message operations
 		CreateChatMessage(db *gorm.DB, message *entity.ChatMessage) error
-		FindChatMessagesBySessionID(db *gorm.DB, sessionID string, limit int) ([]entity.ChatMessage, error)
+		FindChatMessagesBySessionID(db *gorm.DB, sessionID string, limit int, order ChatHistoryOrder) ([]entity.ChatMessage, error)
+		FindChatMessagesPaginated(db *gorm.DB, sessionID string, offset, limit int) ([]entity.ChatMessage, int64, error)
+		FindGeneratedPaginated(db *gorm.DB, generatedByPrefix string, difficulty string, letterPair string, offset, limit int) ([]entity.GeneratedQuestion, int64, error)
+		HasFeedbackMessage(db *gorm.DB, sessionID string) (bool, error)
 	}
 
 	dyslexiaQuestionRepository struct {
 		db *gorm.DB
 	}
+
+	// Aggregation rows (results of GROUP BY queries)
+	UserAccuracyRow struct {
+		UserID  string
+		Total   int
+		Correct int
+	}
+
+	LetterPairStatRow struct {
+		LetterPair string
+		Total      int
+		Errors     int
+	}
+
+	AnswerCountRow struct {
+		Answer    string
+		Skipped   bool
+		IsCorrect bool
+		Total     int
+	}
+
+	DifficultyCountRow struct {
+		Difficulty string
+		Total      int
+	}
+
+	UserDataCounts struct {
+		Answers        int64
+		Sessions       int64
+		AnalysisCaches int64
+		ChatMessages   int64
+		ServeLogs      int64
+	}
+
+	IdleSessionRow struct {
+		SessionID    string
+		UserID       string
+		LastActivity time.Time
+	}
+
+	LetterPairTrendRow struct {
+		SessionID  string
+		LetterPair string
+		StartedAt  time.Time
+		Total      int
+		Correct    int
+	}
+
+	// ChatHistoryOrder selects which end of a session's chat FindChatMessagesBySessionID limits from
+	ChatHistoryOrder int
+)
+
+const (
+	// ChatHistoryOldestFirst returns the first messages of the session (full history display)
+	ChatHistoryOldestFirst ChatHistoryOrder = iota
+	// ChatHistoryRecentFirst returns the latest messages of the session (LLM context window)
+	ChatHistoryRecentFirst
 )
 
 func NewDyslexiaQuestionRepository(db *gorm.DB) DyslexiaQuestionRepository {
@@ -61,6 +170,15 @@ func (r *dyslexiaQuestionRepository) FindTemplatesByDifficulty(db *gorm.DB, diff
 	return templates, err
 }
 
+func (r *dyslexiaQuestionRepository) FindTemplatesByDifficultyPaginated(db *gorm.DB, difficulty string, offset, limit int) ([]entity.QuestionBankTemplate, error) {
+	if db == nil {
+		db = r.db
+	}
+	var templates []entity.QuestionBankTemplate
+	err := db.Where("difficulty = ?", difficulty).Order("template_id ASC").Offset(offset).Limit(limit).Find(&templates).Error
+	return templates, err
+}
+
 func (r *dyslexiaQuestionRepository) FindTemplateByTemplateID(db *gorm.DB, templateID string) (*entity.QuestionBankTemplate, error) {
 	if db == nil {
 		db = r.db
@@ -90,6 +208,30 @@ func (r *dyslexiaQuestionRepository) CreateGenerated(db *gorm.DB, question *enti
 	return db.Create(question).Error
 }
 
+func (r *dyslexiaQuestionRepository) FindGeneratedByQuestionIDs(db *gorm.DB, questionIDs []string) ([]entity.GeneratedQuestion, error) {
+	if db == nil {
+		db = r.db
+	}
+	var questions []entity.GeneratedQuestion
+	if len(questionIDs) == 0 {
+		return questions, nil
+	}
+	err := db.Where("question_id IN ?", questionIDs).Find(&questions).Error
+	return questions, err
+}
+
+func (r *dyslexiaQuestionRepository) FindGeneratedByTemplateID(db *gorm.DB, templateID string, generatedBy string) (*entity.GeneratedQuestion, error) {
+	if db == nil {
+		db = r.db
+	}
+	var question entity.GeneratedQuestion
+	err := db.Where("template_id = ? AND generated_by = ?", templateID, generatedBy).Order("created_at ASC").First(&question).Error
+	if err != nil {
+		return nil, err
+	}
+	return &question, nil
+}
+
 func (r *dyslexiaQuestionRepository) FindGeneratedByQuestionID(db *gorm.DB, questionID string) (*entity.GeneratedQuestion, error) {
 	if db == nil {
 		db = r.db
@@ -102,16 +244,46 @@ func (r *dyslexiaQuestionRepository) FindGeneratedByQuestionID(db *gorm.DB, ques
 	return &question, nil
 }
 
-func (r *dyslexiaQuestionRepository) FindRandomGeneratedByDifficulty(db *gorm.DB, difficulty string, limit int, excludeIDs []string) ([]entity.GeneratedQuestion, error) {
+// FindRandomGeneratedByDifficulty returns random questions, limited to letterPairs when given and preferring
+// ones created after freshSince. Older questions are only used to fill the limit when the fresh pool is too small.
+// Ad hoc questions are never served.
+func (r *dyslexiaQuestionRepository) FindRandomGeneratedByDifficulty(db *gorm.DB, difficulty string, letterPairs []string, limit int, excludeIDs []string, freshSince time.Time) ([]entity.GeneratedQuestion, error) {
+	if db == nil {
+		db = r.db
+	}
+	var questions []entity.GeneratedQuestion
+	query := db.Where("difficulty = ? AND generated_by <> ?", difficulty, entity.GeneratedByAdHoc)
+	if len(letterPairs) > 0 {
+		query = query.Where("target_letter_pair IN ?", letterPairs)
+	}
+	if len(excludeIDs) > 0 {
+		query = query.Where("question_id NOT IN ?", excludeIDs)
+	}
+	// Order only accepts strings and clause.OrderBy, and a later Order() would drop an OrderBy expression,
+	// so the freshness preference and the random tiebreak go into one clause
+	if freshSince.IsZero() {
+		query = query.Order("RANDOM()")
+	} else {
+		query = query.Clauses(clause.OrderBy{Expression: clause.Expr{SQL: "(created_at >= ?) DESC, RANDOM()", Vars: []interface{}{freshSince}}})
+	}
+	err := query.Limit(limit).Find(&questions).Error
+	return questions, err
+}
+
+// FindOrderedGeneratedByDifficulty returns questions in insertion order (deterministic counterpart of FindRandomGeneratedByDifficulty)
+func (r *dyslexiaQuestionRepository) FindOrderedGeneratedByDifficulty(db *gorm.DB, difficulty string, letterPairs []string, limit int, excludeIDs []string) ([]entity.GeneratedQuestion, error) {
 	if db == nil {
 		db = r.db
 	}
 	var questions []entity.GeneratedQuestion
-	query := db.Where("difficulty = ?", difficulty)
+	query := db.Where("difficulty = ? AND generated_by <> ?", difficulty, entity.GeneratedByAdHoc)
+	if len(letterPairs) > 0 {
+		query = query.Where("target_letter_pair IN ?", letterPairs)
+	}
 	if len(excludeIDs) > 0 {
 		query = query.Where("question_id NOT IN ?", excludeIDs)
 	}
-	err := query.Order("RANDOM()").Limit(limit).Find(&questions).Error
+	err := query.Order("id ASC").Limit(limit).Find(&questions).Error
 	return questions, err
 }
 
@@ -124,6 +296,64 @@ func (r *dyslexiaQuestionRepository) IncrementUsageCount(db *gorm.DB, questionID
 		UpdateColumn("usage_count", gorm.Expr("usage_count + ?", 1)).Error
 }
 
+// FindGeneratedByPairAndDifficulty returns all cached questions for a letter pair; empty difficulty matches any
+func (r *dyslexiaQuestionRepository) FindGeneratedByPairAndDifficulty(db *gorm.DB, letterPair string, difficulty string) ([]entity.GeneratedQuestion, error) {
+	if db == nil {
+		db = r.db
+	}
+	var questions []entity.GeneratedQuestion
+	query := db.Where("target_letter_pair = ? AND generated_by <> ?", letterPair, entity.GeneratedByAdHoc)
+	if difficulty != "" {
+		query = query.Where("difficulty = ?", difficulty)
+	}
+	err := query.Order("id ASC").Find(&questions).Error
+	return questions, err
+}
+
+func (r *dyslexiaQuestionRepository) UpdateGeneratedOptions(db *gorm.DB, questionID string, options string) error {
+	if db == nil {
+		db = r.db
+	}
+	return db.Model(&entity.GeneratedQuestion{}).
+		Where("question_id = ?", questionID).
+		Update("options", options).Error
+}
+
+// Serve log operations
+func (r *dyslexiaQuestionRepository) CreateServeLogs(db *gorm.DB, logs []entity.QuestionServeLog) error {
+	if db == nil {
+		db = r.db
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+	return db.Create(&logs).Error
+}
+
+func (r *dyslexiaQuestionRepository) FindServeLogsBySessionID(db *gorm.DB, sessionID string) ([]entity.QuestionServeLog, error) {
+	if db == nil {
+		db = r.db
+	}
+	var logs []entity.QuestionServeLog
+	err := db.Where("session_id = ?", sessionID).Order("served_at ASC, id ASC").Find(&logs).Error
+	return logs, err
+}
+
+// FindLatestServeLog returns the most recent serve of questionID to sessionID
+func (r *dyslexiaQuestionRepository) FindLatestServeLog(db *gorm.DB, sessionID string, questionID string) (*entity.QuestionServeLog, error) {
+	if db == nil {
+		db = r.db
+	}
+	var log entity.QuestionServeLog
+	err := db.Where("session_id = ? AND question_id = ?", sessionID, questionID).
+		Order("served_at DESC, id DESC").
+		First(&log).Error
+	if err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
 // User answer operations
 func (r *dyslexiaQuestionRepository) CreateUserAnswer(db *gorm.DB, answer *entity.UserAnswer) error {
 	if db == nil {
@@ -141,6 +371,43 @@ func (r *dyslexiaQuestionRepository) FindUserAnswersBySessionID(db *gorm.DB, ses
 	return answers, err
 }
 
+func (r *dyslexiaQuestionRepository) FindUserAnswersByQuestionID(db *gorm.DB, questionID string) ([]entity.UserAnswer, error) {
+	if db == nil {
+		db = r.db
+	}
+	var answers []entity.UserAnswer
+	err := db.Where("question_id = ?", questionID).Order("answered_at DESC").Find(&answers).Error
+	return answers, err
+}
+
+// UpdateAnswerGrade stores the result of regrading an answer against the question's current correct answer
+func (r *dyslexiaQuestionRepository) UpdateAnswerGrade(db *gorm.DB, id uint, correctAnswer string, isCorrect bool, partialCredit float64) error {
+	if db == nil {
+		db = r.db
+	}
+	return db.Model(&entity.UserAnswer{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"correct_answer": correctAnswer,
+		"is_correct":     isCorrect,
+		"partial_credit": partialCredit,
+	}).Error
+}
+
+// CorrectUserAnswer overwrites a stored answer with its correction; answered_at keeps the first submit time
+func (r *dyslexiaQuestionRepository) CorrectUserAnswer(db *gorm.DB, answer *entity.UserAnswer) error {
+	if db == nil {
+		db = r.db
+	}
+	return db.Model(&entity.UserAnswer{}).Where("id = ?", answer.ID).Updates(map[string]interface{}{
+		"user_answer":     answer.UserAnswer,
+		"correct_answer":  answer.CorrectAnswer,
+		"is_correct":      answer.IsCorrect,
+		"skipped":         answer.Skipped,
+		"partial_credit":  answer.PartialCredit,
+		"previous_answer": answer.PreviousAnswer,
+		"corrected_at":    answer.CorrectedAt,
+	}).Error
+}
+
 func (r *dyslexiaQuestionRepository) FindUserAnswersByUserID(db *gorm.DB, userID string) ([]entity.UserAnswer, error) {
 	if db == nil {
 		db = r.db
@@ -162,13 +429,50 @@ func (r *dyslexiaQuestionRepository) FindExistingAnswer(db *gorm.DB, userID, ses
 	return &answer, nil
 }
 
+func (r *dyslexiaQuestionRepository) CountAnswerAttempts(db *gorm.DB, userID, sessionID, questionID string) (int64, error) {
+	if db == nil {
+		db = r.db
+	}
+	var count int64
+	err := db.Model(&entity.UserAnswer{}).
+		Where("user_id = ? AND session_id = ? AND question_id = ?", userID, sessionID, questionID).
+		Count(&count).Error
+	return count, err
+}
+
 // Session analysis cache operations
 func (r *dyslexiaQuestionRepository) CreateOrUpdateAnalysisCache(db *gorm.DB, cache *entity.SessionAnalysisCache) error {
 	if db == nil {
 		db = r.db
 	}
 	// Upsert: update if exists, create if not
-	return db.Where("session_id = ?", cache.SessionID).Assign(cache).FirstOrCreate(cache).Error
+	if err := db.Where("session_id = ?", cache.SessionID).Assign(cache).FirstOrCreate(cache).Error; err != nil {
+		return err
+	}
+	// Assign skips nil fields, so a fresh analysis clears an earlier invalidation explicitly
+	return db.Model(&entity.SessionAnalysisCache{}).Where("session_id = ? AND invalidated_at IS NOT NULL", cache.SessionID).
+		Update("invalidated_at", nil).Error
+}
+
+// InvalidateAnalysisCaches marks the cached analyses of sessionIDs stale without deleting them, so the
+// sessions still count as reported (active session limits, completion webhooks)
+func (r *dyslexiaQuestionRepository) InvalidateAnalysisCaches(db *gorm.DB, sessionIDs []string, at time.Time) (int64, error) {
+	if db == nil {
+		db = r.db
+	}
+	if len(sessionIDs) == 0 {
+		return 0, nil
+	}
+	result := db.Model(&entity.SessionAnalysisCache{}).Where("session_id IN ?", sessionIDs).Update("invalidated_at", at)
+	return result.RowsAffected, result.Error
+}
+
+// UpdateAnalysisCacheScore sets only the session score, leaving updated_at (the cache freshness) untouched
+func (r *dyslexiaQuestionRepository) UpdateAnalysisCacheScore(db *gorm.DB, sessionID string, score float64) error {
+	if db == nil {
+		db = r.db
+	}
+	return db.Model(&entity.SessionAnalysisCache{}).Where("session_id = ?", sessionID).UpdateColumn("session_score", score).Error
 }
 
 func (r *dyslexiaQuestionRepository) FindAnalysisCacheBySessionID(db *gorm.DB, sessionID string) (*entity.SessionAnalysisCache, error) {
@@ -183,6 +487,18 @@ func (r *dyslexiaQuestionRepository) FindAnalysisCacheBySessionID(db *gorm.DB, s
 	return &cache, nil
 }
 
+func (r *dyslexiaQuestionRepository) FindAnalysisCachesBySessionIDs(db *gorm.DB, sessionIDs []string) ([]entity.SessionAnalysisCache, error) {
+	if db == nil {
+		db = r.db
+	}
+	var caches []entity.SessionAnalysisCache
+	if len(sessionIDs) == 0 {
+		return caches, nil
+	}
+	err := db.Where("session_id IN ?", sessionIDs).Find(&caches).Error
+	return caches, err
+}
+
 func (r *dyslexiaQuestionRepository) FindAnalysisCacheByUserID(db *gorm.DB, userID string, limit int) ([]entity.SessionAnalysisCache, error) {
 	if db == nil {
 		db = r.db
@@ -211,6 +527,430 @@ func (r *dyslexiaQuestionRepository) FindAnalysisCacheByUserID(db *gorm.DB, user
 	return caches, err
 }
 
+// Analytics operations
+func answersInRange(db *gorm.DB, userIDs []string, from, to time.Time) *gorm.DB {
+	query := db.Model(&entity.UserAnswer{}).Where("user_answers.user_id IN ?", userIDs)
+	if !from.IsZero() {
+		query = query.Where("user_answers.answered_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("user_answers.answered_at < ?", to)
+	}
+	return query
+}
+
+func (r *dyslexiaQuestionRepository) AggregateAccuracyByUsers(db *gorm.DB, userIDs []string, from, to time.Time) ([]UserAccuracyRow, error) {
+	if db == nil {
+		db = r.db
+	}
+	var rows []UserAccuracyRow
+	err := answersInRange(db, userIDs, from, to).
+		Select("user_answers.user_id AS user_id, COUNT(*) AS total, SUM(CASE WHEN user_answers.is_correct THEN 1 ELSE 0 END) AS correct").
+		Group("user_answers.user_id").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *dyslexiaQuestionRepository) AggregateLetterPairStatsByUsers(db *gorm.DB, userIDs []string, from, to time.Time) ([]LetterPairStatRow, error) {
+	if db == nil {
+		db = r.db
+	}
+	var rows []LetterPairStatRow
+	err := answersInRange(db, userIDs, from, to).
+		Joins("JOIN generated_questions ON generated_questions.question_id = user_answers.question_id").
+		Where("generated_questions.target_letter_pair <> '' AND NOT user_answers.skipped").
+		Select("generated_questions.target_letter_pair AS letter_pair, COUNT(*) AS total, SUM(CASE WHEN user_answers.is_correct THEN 0 ELSE 1 END) AS errors").
+		Group("generated_questions.target_letter_pair").
+		Order("letter_pair").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// AggregateLetterPairStats counts answers and errors per letter pair across all users, optionally for one difficulty
+func (r *dyslexiaQuestionRepository) AggregateLetterPairStats(db *gorm.DB, difficulty string) ([]LetterPairStatRow, error) {
+	if db == nil {
+		db = r.db
+	}
+	var rows []LetterPairStatRow
+	query := db.Model(&entity.UserAnswer{}).
+		Joins("JOIN generated_questions ON generated_questions.question_id = user_answers.question_id").
+		Where("generated_questions.target_letter_pair <> '' AND NOT user_answers.skipped")
+	if difficulty != "" {
+		query = query.Where("LOWER(user_answers.difficulty) = ?", difficulty)
+	}
+	err := query.
+		Select("generated_questions.target_letter_pair AS letter_pair, COUNT(*) AS total, SUM(CASE WHEN user_answers.is_correct THEN 0 ELSE 1 END) AS errors").
+		Group("generated_questions.target_letter_pair").
+		Order("letter_pair").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// AggregateAnswersByQuestion counts every recorded answer to questionID per chosen word (trimmed, uppercased)
+func (r *dyslexiaQuestionRepository) AggregateAnswersByQuestion(db *gorm.DB, questionID string) ([]AnswerCountRow, error) {
+	if db == nil {
+		db = r.db
+	}
+	var rows []AnswerCountRow
+	err := db.Model(&entity.UserAnswer{}).
+		Where("question_id = ?", questionID).
+		Select("UPPER(TRIM(user_answer)) AS answer, skipped, is_correct, COUNT(*) AS total").
+		Group("UPPER(TRIM(user_answer)), skipped, is_correct").
+		Order("total DESC, answer").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *dyslexiaQuestionRepository) AggregateDifficultyByUsers(db *gorm.DB, userIDs []string, from, to time.Time) ([]DifficultyCountRow, error) {
+	if db == nil {
+		db = r.db
+	}
+	var rows []DifficultyCountRow
+	err := answersInRange(db, userIDs, from, to).
+		Select("user_answers.difficulty AS difficulty, COUNT(*) AS total").
+		Group("user_answers.difficulty").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// LLM usage (quota) operations
+func (r *dyslexiaQuestionRepository) GetLLMUsage(db *gorm.DB, userID, usageDate, kind string) (int, error) {
+	if db == nil {
+		db = r.db
+	}
+	var usage entity.LLMUsage
+	err := db.Where("user_id = ? AND usage_date = ? AND kind = ?", userID, usageDate, kind).Limit(1).Find(&usage).Error
+	return usage.Count, err
+}
+
+// ConsumeLLMUsage adds amount to the user's usage in a single upsert that only applies while the new count
+// stays within limit, so concurrent requests cannot overshoot the quota. It reports whether amount was added.
+func (r *dyslexiaQuestionRepository) ConsumeLLMUsage(db *gorm.DB, userID, usageDate, kind string, amount, limit int) (bool, error) {
+	if db == nil {
+		db = r.db
+	}
+	if amount > limit {
+		return false, nil
+	}
+	usage := &entity.LLMUsage{UserID: userID, UsageDate: usageDate, Kind: kind, Count: amount}
+	res := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "usage_date"}, {Name: "kind"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"count": gorm.Expr("llm_usages.count + ?", amount), "updated_at": time.Now()}),
+		Where:     clause.Where{Exprs: []clause.Expression{gorm.Expr("llm_usages.count + ? <= ?", amount, limit)}},
+	}).Create(usage)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *dyslexiaQuestionRepository) FindUserPreferences(db *gorm.DB, userID string) (*entity.UserPreferences, error) {
+	if db == nil {
+		db = r.db
+	}
+	var prefs entity.UserPreferences
+	err := db.Where("user_id = ?", userID).First(&prefs).Error
+	if err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// UpsertUserPreferences replaces all preference fields of the user, creating the row on first save
+func (r *dyslexiaQuestionRepository) UpsertUserPreferences(db *gorm.DB, prefs *entity.UserPreferences) error {
+	if db == nil {
+		db = r.db
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"preferred_difficulty", "letter_pairs", "language", "voice", "updated_at"}),
+	}).Create(prefs).Error
+}
+
+func (r *dyslexiaQuestionRepository) AggregateLetterPairTrendByUser(db *gorm.DB, userID string, pair string, from, to time.Time) ([]LetterPairTrendRow, error) {
+	if db == nil {
+		db = r.db
+	}
+	var rows []LetterPairTrendRow
+	query := answersInRange(db, []string{userID}, from, to).
+		Joins("JOIN generated_questions ON generated_questions.question_id = user_answers.question_id").
+		Where("generated_questions.target_letter_pair <> '' AND NOT user_answers.skipped")
+	if pair != "" {
+		query = query.Where("generated_questions.target_letter_pair = ?", pair)
+	}
+	err := query.
+		Select("user_answers.session_id AS session_id, generated_questions.target_letter_pair AS letter_pair, MIN(user_answers.answered_at) AS started_at, COUNT(*) AS total, SUM(CASE WHEN user_answers.is_correct THEN 1 ELSE 0 END) AS correct").
+		Group("user_answers.session_id, generated_questions.target_letter_pair").
+		Order("started_at ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// User data operations
+
+// userSessionIDs lists every session the user owns, including soft-deleted rows
+func userSessionIDs(db *gorm.DB, userID string) ([]string, error) {
+	var fromAnswers, fromSessions []string
+	if err := db.Unscoped().Model(&entity.UserAnswer{}).Where("user_id = ?", userID).Distinct().Pluck("session_id", &fromAnswers).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Unscoped().Model(&entity.Session{}).Where("user_id = ?", userID).Pluck("session_id", &fromSessions).Error; err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	ids := []string{}
+	for _, id := range append(fromAnswers, fromSessions...) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// SoftDeleteUserData soft-deletes answers, sessions, analysis caches and chat messages of a user, stamping
+// every row with the same deletedAt so RestoreUserData can tell this erasure apart from other soft deletes.
+// Pass a transaction so the erasure is all-or-nothing.
+func (r *dyslexiaQuestionRepository) SoftDeleteUserData(db *gorm.DB, userID string, deletedAt time.Time) (*UserDataCounts, error) {
+	if db == nil {
+		db = r.db
+	}
+	sessionIDs, err := userSessionIDs(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Postgres keeps microseconds; truncating keeps the stamp comparable by equality on restore
+	deletedAt = deletedAt.UTC().Truncate(time.Microsecond)
+	softDelete := func(model any, column string, value any) (int64, error) {
+		res := db.Model(model).Where(column, value).Update("deleted_at", deletedAt)
+		return res.RowsAffected, res.Error
+	}
+
+	counts := &UserDataCounts{}
+	if counts.Answers, err = softDelete(&entity.UserAnswer{}, "user_id = ?", userID); err != nil {
+		return nil, err
+	}
+	if counts.Sessions, err = softDelete(&entity.Session{}, "user_id = ?", userID); err != nil {
+		return nil, err
+	}
+	if len(sessionIDs) > 0 {
+		if counts.AnalysisCaches, err = softDelete(&entity.SessionAnalysisCache{}, "session_id IN ?", sessionIDs); err != nil {
+			return nil, err
+		}
+		if counts.ChatMessages, err = softDelete(&entity.ChatMessage{}, "session_id IN ?", sessionIDs); err != nil {
+			return nil, err
+		}
+		if counts.ServeLogs, err = softDelete(&entity.QuestionServeLog{}, "session_id IN ?", sessionIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	return counts, nil
+}
+
+// RestoreUserData undoes the user's latest SoftDeleteUserData at or after deletedSince. Only rows stamped
+// with that erasure's deleted_at come back; rows soft-deleted by other flows (e.g. the pruning of expired
+// sessions) stay deleted.
+func (r *dyslexiaQuestionRepository) RestoreUserData(db *gorm.DB, userID string, deletedSince time.Time) (*UserDataCounts, error) {
+	if db == nil {
+		db = r.db
+	}
+	sessionIDs, err := userSessionIDs(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	erasedAt, err := latestErasure(db, userID, sessionIDs, deletedSince)
+	if err != nil || erasedAt.IsZero() {
+		return &UserDataCounts{}, err
+	}
+	return restoreErasure(db, userID, sessionIDs, erasedAt)
+}
+
+// latestErasure returns the deleted_at of the user's latest erasure, or zero when there is none.
+// Sessions, caches and chat messages are soft-deleted only by an erasure, so they are checked first;
+// answers are the fallback for users who never had a session row.
+func latestErasure(db *gorm.DB, userID string, sessionIDs []string, deletedSince time.Time) (time.Time, error) {
+	latest := func(model any, column string, value any) (time.Time, error) {
+		var stamps []sql.NullTime
+		err := db.Unscoped().Model(model).
+			Where(column, value).
+			Where("deleted_at IS NOT NULL AND deleted_at >= ?", deletedSince).
+			Pluck("MAX(deleted_at)", &stamps).Error
+		if err != nil || len(stamps) == 0 || !stamps[0].Valid {
+			return time.Time{}, err
+		}
+		return stamps[0].Time, nil
+	}
+
+	erasedAt, err := latest(&entity.Session{}, "user_id = ?", userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(sessionIDs) > 0 {
+		for _, model := range []any{&entity.SessionAnalysisCache{}, &entity.ChatMessage{}} {
+			at, err := latest(model, "session_id IN ?", sessionIDs)
+			if err != nil {
+				return time.Time{}, err
+			}
+			if at.After(erasedAt) {
+				erasedAt = at
+			}
+		}
+	}
+	if !erasedAt.IsZero() {
+		return erasedAt, nil
+	}
+
+	// Answers soft-deleted outside an erasure belong to users with a session row; only users without one fall back
+	var sessions int64
+	if err := db.Unscoped().Model(&entity.Session{}).Where("user_id = ?", userID).Count(&sessions).Error; err != nil || sessions > 0 {
+		return time.Time{}, err
+	}
+	return latest(&entity.UserAnswer{}, "user_id = ?", userID)
+}
+
+// restoreErasure clears deleted_at on the user's rows stamped with erasedAt
+func restoreErasure(db *gorm.DB, userID string, sessionIDs []string, erasedAt time.Time) (*UserDataCounts, error) {
+	restore := func(model any, column string, value any) (int64, error) {
+		res := db.Unscoped().Model(model).
+			Where(column, value).
+			Where("deleted_at = ?", erasedAt).
+			Update("deleted_at", nil)
+		return res.RowsAffected, res.Error
+	}
+
+	var err error
+	counts := &UserDataCounts{}
+	if counts.Answers, err = restore(&entity.UserAnswer{}, "user_id = ?", userID); err != nil {
+		return nil, err
+	}
+	if counts.Sessions, err = restore(&entity.Session{}, "user_id = ?", userID); err != nil {
+		return nil, err
+	}
+	if len(sessionIDs) > 0 {
+		if counts.AnalysisCaches, err = restore(&entity.SessionAnalysisCache{}, "session_id IN ?", sessionIDs); err != nil {
+			return nil, err
+		}
+		if counts.ChatMessages, err = restore(&entity.ChatMessage{}, "session_id IN ?", sessionIDs); err != nil {
+			return nil, err
+		}
+		if counts.ServeLogs, err = restore(&entity.QuestionServeLog{}, "session_id IN ?", sessionIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	return counts, nil
+}
+
+// Session operations
+
+// CountActiveSessionsByUser counts the user's sessions (started or answered) that have no report yet
+func (r *dyslexiaQuestionRepository) CountActiveSessionsByUser(db *gorm.DB, userID string) (int64, error) {
+	if db == nil {
+		db = r.db
+	}
+	var count int64
+	err := db.Raw(`
+		SELECT COUNT(*) FROM (
+			SELECT session_id FROM sessions WHERE user_id = ? AND deleted_at IS NULL
+			UNION
+			SELECT session_id FROM user_answers WHERE user_id = ? AND deleted_at IS NULL
+		) AS user_sessions
+		WHERE NOT EXISTS (
+			SELECT 1 FROM session_analysis_cache c
+			WHERE c.session_id = user_sessions.session_id AND c.deleted_at IS NULL
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM sessions s
+			WHERE s.session_id = user_sessions.session_id AND s.expired_at IS NOT NULL
+		)`, userID, userID).Scan(&count).Error
+	return count, err
+}
+
+// FindIdleSessions returns unreported, unexpired sessions (started or only answered) whose last
+// activity is before idleSince, oldest first
+func (r *dyslexiaQuestionRepository) FindIdleSessions(db *gorm.DB, idleSince time.Time, limit int) ([]IdleSessionRow, error) {
+	if db == nil {
+		db = r.db
+	}
+	var rows []IdleSessionRow
+	err := db.Raw(`
+		SELECT activity.session_id, activity.user_id, MAX(activity.at) AS last_activity FROM (
+			SELECT session_id, user_id, updated_at AS at FROM sessions WHERE deleted_at IS NULL
+			UNION ALL
+			SELECT session_id, user_id, answered_at AS at FROM user_answers WHERE deleted_at IS NULL
+		) AS activity
+		WHERE NOT EXISTS (
+			SELECT 1 FROM session_analysis_cache c
+			WHERE c.session_id = activity.session_id AND c.deleted_at IS NULL
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM sessions s
+			WHERE s.session_id = activity.session_id AND s.expired_at IS NOT NULL
+		)
+		GROUP BY activity.session_id, activity.user_id
+		HAVING MAX(activity.at) < ?
+		ORDER BY last_activity ASC
+		LIMIT ?`, idleSince, limit).Scan(&rows).Error
+	return rows, err
+}
+
+// ExpireSession marks a session expired, creating its sessions row when it was only ever answered.
+// With pruneAnswers the session's answers and serve log are soft deleted as well.
+func (r *dyslexiaQuestionRepository) ExpireSession(db *gorm.DB, sessionID, userID string, expiredAt time.Time, pruneAnswers bool) error {
+	if db == nil {
+		db = r.db
+	}
+	return WithTransaction(db, func(tx *gorm.DB) error {
+		session := &entity.Session{SessionID: sessionID, UserID: userID, ExpiredAt: &expiredAt}
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "session_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"expired_at": expiredAt}),
+		}).Create(session).Error
+		if err != nil {
+			return err
+		}
+		if !pruneAnswers {
+			return nil
+		}
+		if err := tx.Where("session_id = ?", sessionID).Delete(&entity.UserAnswer{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("session_id = ?", sessionID).Delete(&entity.QuestionServeLog{}).Error
+	})
+}
+
+// CreateSessionIfAbsent inserts session unless its session_id already exists, in one statement so
+// concurrent starts of the same id cannot both succeed. It reports whether the row was created.
+func (r *dyslexiaQuestionRepository) CreateSessionIfAbsent(db *gorm.DB, session *entity.Session) (bool, error) {
+	if db == nil {
+		db = r.db
+	}
+	res := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "session_id"}}, DoNothing: true}).Create(session)
+	return res.RowsAffected > 0, res.Error
+}
+
+// LockUserSessions serializes session creation per user until the surrounding transaction ends, so the
+// active-session count and the insert that follows it cannot interleave with another request's
+func (r *dyslexiaQuestionRepository) LockUserSessions(db *gorm.DB, userID string) error {
+	if db == nil {
+		db = r.db
+	}
+	return db.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "sessions:"+userID).Error
+}
+
+func (r *dyslexiaQuestionRepository) FindSessionBySessionID(db *gorm.DB, sessionID string) (*entity.Session, error) {
+	if db == nil {
+		db = r.db
+	}
+	var session entity.Session
+	err := db.Where("session_id = ?", sessionID).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
 // Chat message operations
 func (r *dyslexiaQuestionRepository) CreateChatMessage(db *gorm.DB, message *entity.ChatMessage) error {
 	if db == nil {
@@ -219,15 +959,85 @@ func (r *dyslexiaQuestionRepository) CreateChatMessage(db *gorm.DB, message *ent
 	return db.Create(message).Error
 }
 
-func (r *dyslexiaQuestionRepository) FindChatMessagesBySessionID(db *gorm.DB, sessionID string, limit int) ([]entity.ChatMessage, error) {
+// FindChatMessagesBySessionID returns up to limit messages (all when limit <= 0) in chronological order.
+// ChatHistoryRecentFirst selects the latest messages rather than the first ones, which is what chat context needs.
+func (r *dyslexiaQuestionRepository) FindChatMessagesBySessionID(db *gorm.DB, sessionID string, limit int, order ChatHistoryOrder) ([]entity.ChatMessage, error) {
 	if db == nil {
 		db = r.db
 	}
 	var messages []entity.ChatMessage
-	query := db.Where("session_id = ?", sessionID).Order("created_at ASC")
+	query := db.Where("session_id = ?", sessionID)
+	if order == ChatHistoryRecentFirst {
+		query = query.Order("created_at DESC, id DESC")
+	} else {
+		query = query.Order("created_at ASC, id ASC")
+	}
 	if limit > 0 {
 		query = query.Limit(limit)
 	}
-	err := query.Find(&messages).Error
-	return messages, err
+	if err := query.Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	if order == ChatHistoryRecentFirst {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+	return messages, nil
+}
+
+// HasFeedbackMessage reports whether the report feedback was already posted to the session's chat
+func (r *dyslexiaQuestionRepository) HasFeedbackMessage(db *gorm.DB, sessionID string) (bool, error) {
+	if db == nil {
+		db = r.db
+	}
+	var count int64
+	err := db.Model(&entity.ChatMessage{}).Where("session_id = ? AND kind = ?", sessionID, entity.ChatMessageKindFeedback).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *dyslexiaQuestionRepository) FindChatMessagesPaginated(db *gorm.DB, sessionID string, offset, limit int) ([]entity.ChatMessage, int64, error) {
+	if db == nil {
+		db = r.db
+	}
+	var total int64
+	if err := db.Model(&entity.ChatMessage{}).Where("session_id = ?", sessionID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var messages []entity.ChatMessage
+	err := db.Where("session_id = ?", sessionID).Order("created_at ASC, id ASC").Offset(offset).Limit(limit).Find(&messages).Error
+	return messages, total, err
+}
+
+// FindGeneratedPaginated lists cached questions, newest first. generatedByPrefix matches the start of generated_by
+// so a provider ("api.openai.com/") or a full provider/model can be selected; empty filters match everything
+// except ad hoc grading records, which are only listed when generatedByPrefix selects them.
+func (r *dyslexiaQuestionRepository) FindGeneratedPaginated(db *gorm.DB, generatedByPrefix string, difficulty string, letterPair string, offset, limit int) ([]entity.GeneratedQuestion, int64, error) {
+	if db == nil {
+		db = r.db
+	}
+	query := db.Model(&entity.GeneratedQuestion{})
+	if generatedByPrefix != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(generatedByPrefix)
+		query = query.Where("generated_by LIKE ?", escaped+"%")
+	} else {
+		query = query.Where("generated_by <> ?", entity.GeneratedByAdHoc)
+	}
+	if difficulty != "" {
+		query = query.Where("difficulty = ?", difficulty)
+	}
+	if letterPair != "" {
+		query = query.Where("target_letter_pair = ?", letterPair)
+	}
+
+	// A fresh session per statement so the count does not leak into the page query
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var questions []entity.GeneratedQuestion
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&questions).Error
+	return questions, total, err
 }
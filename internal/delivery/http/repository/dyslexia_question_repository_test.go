@@ -0,0 +1,711 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/entity"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
+)
+
+// dryRunDB builds SQL without a database connection
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	return db
+}
+
+// lastSQL runs fn in a dry-run session and returns the statement it built
+func lastSQL(t *testing.T, fn func(db *gorm.DB)) string {
+	t.Helper()
+	var sql string
+	db := dryRunDB(t)
+	capture := func(tx *gorm.DB) {
+		sql = tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+	}
+	db.Callback().Query().After("gorm:query").Register("test:capture", capture)
+	// Scan into plain structs goes through the row callbacks
+	db.Callback().Row().After("gorm:row").Register("test:capture", capture)
+	fn(db)
+	return sql
+}
+
+// allSQL runs fn in a dry-run session and returns every query, write and raw statement it built, in order
+func allSQL(t *testing.T, fn func(db *gorm.DB)) []string {
+	t.Helper()
+	var statements []string
+	db := dryRunDB(t)
+	capture := func(tx *gorm.DB) {
+		statements = append(statements, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+	}
+	db.Callback().Query().After("gorm:query").Register("test:capture", capture)
+	db.Callback().Update().After("gorm:update").Register("test:capture", capture)
+	db.Callback().Create().After("gorm:create").Register("test:capture", capture)
+	db.Callback().Raw().After("gorm:raw").Register("test:capture", capture)
+	fn(db)
+	return statements
+}
+
+func TestFindRandomGeneratedByDifficultyOrder(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	freshSince := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		freshSince time.Time
+		wantOrder  string
+	}{
+		{name: "fresh preferred", freshSince: freshSince, wantOrder: "ORDER BY (created_at >= '2026-01-02 00:00:00') DESC, RANDOM()"},
+		{name: "no freshness window", wantOrder: "ORDER BY RANDOM()"},
+	}
+	for _, tt := range tests {
+		sql := lastSQL(t, func(db *gorm.DB) {
+			_, _ = repo.FindRandomGeneratedByDifficulty(db, "easy", nil, 5, []string{"q-1"}, tt.freshSince)
+		})
+		if !strings.Contains(sql, tt.wantOrder) {
+			t.Errorf("%s: SQL %q does not contain %q", tt.name, sql, tt.wantOrder)
+		}
+	}
+}
+
+// The deterministic lookup orders by insertion, never randomly
+func TestFindOrderedGeneratedByDifficultySQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	sql := lastSQL(t, func(db *gorm.DB) {
+		_, _ = repo.FindOrderedGeneratedByDifficulty(db, "easy", nil, 5, []string{"q-1"})
+	})
+	if !strings.Contains(sql, "ORDER BY id ASC") || strings.Contains(sql, "RANDOM()") {
+		t.Errorf("SQL %q, want ORDER BY id ASC without RANDOM()", sql)
+	}
+}
+
+// Requested letter pairs filter the cached questions in SQL
+func TestFindGeneratedByDifficultyLetterPairs(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	random := lastSQL(t, func(db *gorm.DB) {
+		_, _ = repo.FindRandomGeneratedByDifficulty(db, "easy", []string{"b-d", "p-q"}, 5, nil, time.Time{})
+	})
+	want := `SELECT * FROM "generated_questions" WHERE (difficulty = 'easy' AND generated_by <> 'adhoc') AND target_letter_pair IN ('b-d','p-q') AND "generated_questions"."deleted_at" IS NULL ORDER BY RANDOM() LIMIT 5`
+	if random != want {
+		t.Errorf("random SQL = %q, want %q", random, want)
+	}
+	ordered := lastSQL(t, func(db *gorm.DB) {
+		_, _ = repo.FindOrderedGeneratedByDifficulty(db, "easy", []string{"b-d"}, 5, []string{"q-1"})
+	})
+	want = `SELECT * FROM "generated_questions" WHERE (difficulty = 'easy' AND generated_by <> 'adhoc') AND target_letter_pair IN ('b-d') AND question_id NOT IN ('q-1') AND "generated_questions"."deleted_at" IS NULL ORDER BY id ASC LIMIT 5`
+	if ordered != want {
+		t.Errorf("ordered SQL = %q, want %q", ordered, want)
+	}
+}
+
+// Cohort aggregation happens in SQL, grouped per user and bounded by the answered_at window
+func TestAggregateAccuracyByUsersSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	sql := lastSQL(t, func(db *gorm.DB) {
+		_, _ = repo.AggregateAccuracyByUsers(db, []string{"user-1", "user-2"}, from, to)
+	})
+	for _, want := range []string{
+		"user_answers.user_id IN ('user-1','user-2')",
+		"user_answers.answered_at >= '2026-01-01 00:00:00'",
+		"user_answers.answered_at < '2026-02-01 00:00:00'",
+		"GROUP BY \"user_answers\".\"user_id\"",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL %q does not contain %q", sql, want)
+		}
+	}
+}
+
+func TestAggregateLetterPairTrendByUserSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	tests := []struct {
+		name     string
+		pair     string
+		wantPair bool
+	}{
+		{name: "all pairs"},
+		{name: "one pair", pair: "b-d", wantPair: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql := lastSQL(t, func(db *gorm.DB) {
+				_, _ = repo.AggregateLetterPairTrendByUser(db, "user-1", tt.pair, time.Time{}, time.Time{})
+			})
+			for _, want := range []string{
+				"user_answers.user_id IN ('user-1')",
+				"JOIN generated_questions ON generated_questions.question_id = user_answers.question_id",
+				"GROUP BY user_answers.session_id, generated_questions.target_letter_pair",
+				"NOT user_answers.skipped",
+				"ORDER BY started_at ASC",
+			} {
+				if !strings.Contains(sql, want) {
+					t.Errorf("SQL %q does not contain %q", sql, want)
+				}
+			}
+			if got := strings.Contains(sql, "generated_questions.target_letter_pair = 'b-d'"); got != tt.wantPair {
+				t.Errorf("SQL %q filters the pair = %v, want %v", sql, got, tt.wantPair)
+			}
+		})
+	}
+}
+
+// Global pair stats are one group-by over every user's answers, optionally for one difficulty
+func TestAggregateLetterPairStatsSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	tests := []struct {
+		name           string
+		difficulty     string
+		wantDifficulty bool
+	}{
+		{name: "all difficulties"},
+		{name: "one difficulty", difficulty: "hard", wantDifficulty: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql := lastSQL(t, func(db *gorm.DB) {
+				_, _ = repo.AggregateLetterPairStats(db, tt.difficulty)
+			})
+			for _, want := range []string{
+				"JOIN generated_questions ON generated_questions.question_id = user_answers.question_id",
+				"NOT user_answers.skipped",
+				"\"user_answers\".\"deleted_at\" IS NULL",
+				"GROUP BY \"generated_questions\".\"target_letter_pair\"",
+			} {
+				if !strings.Contains(sql, want) {
+					t.Errorf("SQL %q does not contain %q", sql, want)
+				}
+			}
+			if strings.Contains(sql, "user_answers.user_id") {
+				t.Errorf("SQL %q filters by user", sql)
+			}
+			if got := strings.Contains(sql, "LOWER(user_answers.difficulty) = 'hard'"); got != tt.wantDifficulty {
+				t.Errorf("SQL %q filters the difficulty = %v, want %v", sql, got, tt.wantDifficulty)
+			}
+		})
+	}
+}
+
+// Answers to one question are grouped by the normalized word, skip and stored grade in a single query
+func TestAggregateAnswersByQuestionSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	sql := lastSQL(t, func(db *gorm.DB) {
+		_, _ = repo.AggregateAnswersByQuestion(db, "q-1")
+	})
+	for _, want := range []string{
+		"question_id = 'q-1'",
+		"\"user_answers\".\"deleted_at\" IS NULL",
+		"GROUP BY UPPER(TRIM(user_answer)), skipped, is_correct",
+		"ORDER BY total DESC, answer",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL %q does not contain %q", sql, want)
+		}
+	}
+}
+
+// The generated_by filter is a prefix match with LIKE wildcards in the prefix escaped; count and page share the filters
+func TestFindGeneratedPaginatedSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	statements := allSQL(t, func(db *gorm.DB) {
+		_, _, _ = repo.FindGeneratedPaginated(db, "my_host/100%", "easy", "b-d", 20, 10)
+	})
+	if len(statements) != 2 {
+		t.Fatalf("statements = %q, want a count and a page", statements)
+	}
+	filter := `generated_by LIKE 'my\_host/100\%%' AND difficulty = 'easy' AND target_letter_pair = 'b-d'`
+	for i, want := range [][]string{
+		{"SELECT count(*)", filter},
+		{filter, "ORDER BY created_at DESC, id DESC", "LIMIT 10 OFFSET 20"},
+	} {
+		for _, w := range want {
+			if !strings.Contains(statements[i], w) {
+				t.Errorf("SQL %q does not contain %q", statements[i], w)
+			}
+		}
+	}
+
+	sql := lastSQL(t, func(db *gorm.DB) {
+		_, _, _ = repo.FindGeneratedPaginated(db, "", "", "", 0, 10)
+	})
+	if strings.Contains(sql, "LIKE") || strings.Contains(sql, "difficulty =") {
+		t.Errorf("SQL %q filters without a filter", sql)
+	}
+}
+
+// The quota check and the increment are one statement, so concurrent requests cannot both pass the check
+func TestConsumeLLMUsageIsConditionalUpsert(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	tests := []struct {
+		name    string
+		amount  int
+		limit   int
+		wantSQL bool
+	}{
+		{name: "fits", amount: 3, limit: 5, wantSQL: true},
+		{name: "larger than the limit", amount: 6, limit: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ok bool
+			statements := allSQL(t, func(db *gorm.DB) {
+				ok, _ = repo.ConsumeLLMUsage(db, "user-1", "2026-01-02", "generate", tt.amount, tt.limit)
+			})
+			if ok {
+				t.Error("dry run reported usage as consumed")
+			}
+			if !tt.wantSQL {
+				if len(statements) != 0 {
+					t.Errorf("statements = %q, want none", statements)
+				}
+				return
+			}
+			if len(statements) != 1 {
+				t.Fatalf("statements = %q, want one upsert", statements)
+			}
+			want := `ON CONFLICT ("user_id","usage_date","kind") DO UPDATE SET "count"=llm_usages.count + 3`
+			if !strings.Contains(statements[0], want) || !strings.Contains(statements[0], "WHERE llm_usages.count + 3 <= 5") {
+				t.Errorf("upsert %q is not conditional on the limit", statements[0])
+			}
+		})
+	}
+}
+
+// recordingPool is a connection that fails every statement but counts how often it was used
+type recordingPool struct {
+	mu    sync.Mutex
+	calls int
+}
+
+var errRecorded = errors.New("recorded")
+
+func (p *recordingPool) hit() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+}
+
+func (p *recordingPool) used() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+func (p *recordingPool) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	p.hit()
+	return nil, errRecorded
+}
+
+func (p *recordingPool) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	p.hit()
+	return nil, errRecorded
+}
+
+func (p *recordingPool) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	p.hit()
+	return nil, errRecorded
+}
+
+func (p *recordingPool) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	p.hit()
+	return nil
+}
+
+// With a read replica registered, Find* reads go to the replica while writes and reads pinned with
+// dbresolver.Write stay on the primary
+func TestReadReplicaRouting(t *testing.T) {
+	tests := []struct {
+		name        string
+		run         func(repo *dyslexiaQuestionRepository, db *gorm.DB)
+		wantPrimary bool
+	}{
+		{name: "read", run: func(repo *dyslexiaQuestionRepository, _ *gorm.DB) {
+			_, _ = repo.FindExistingAnswer(nil, "user-1", "sess-1", "q-1")
+		}},
+		{name: "write", wantPrimary: true, run: func(repo *dyslexiaQuestionRepository, _ *gorm.DB) {
+			_ = repo.CreateUserAnswer(nil, &entity.UserAnswer{UserID: "user-1", SessionID: "sess-1", QuestionID: "q-1"})
+		}},
+		{name: "read pinned to the primary", wantPrimary: true, run: func(repo *dyslexiaQuestionRepository, db *gorm.DB) {
+			_, _ = repo.FindExistingAnswer(db.Clauses(dbresolver.Write), "user-1", "sess-1", "q-1")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary, replica := &recordingPool{}, &recordingPool{}
+			db, err := gorm.Open(postgres.New(postgres.Config{Conn: primary}), &gorm.Config{SkipDefaultTransaction: true, Logger: logger.Discard})
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			if err := db.Use(dbresolver.Register(dbresolver.Config{Replicas: []gorm.Dialector{postgres.New(postgres.Config{Conn: replica})}})); err != nil {
+				t.Fatalf("register replica: %v", err)
+			}
+
+			tt.run(&dyslexiaQuestionRepository{db: db}, db)
+
+			wantPrimary, wantReplica := 0, 1
+			if tt.wantPrimary {
+				wantPrimary, wantReplica = 1, 0
+			}
+			if primary.used() != wantPrimary || replica.used() != wantReplica {
+				t.Errorf("primary used %d times, replica %d; want %d and %d", primary.used(), replica.used(), wantPrimary, wantReplica)
+			}
+		})
+	}
+}
+
+// Every erased row gets the same stamp and restore matches that exact stamp, so rows soft-deleted by
+// other flows (the expired-session prune) are left alone
+func TestUserDataErasureStamp(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	erasedAt := time.Date(2026, 3, 4, 5, 6, 7, 123456789, time.UTC)
+	stamp := "'2026-03-04 05:06:07.123" // Explain renders milliseconds
+
+	deletes := allSQL(t, func(db *gorm.DB) {
+		_, _ = repo.SoftDeleteUserData(db, "user-1", erasedAt)
+	})
+	var updates []string
+	for _, sql := range deletes {
+		if strings.HasPrefix(sql, "UPDATE") {
+			updates = append(updates, sql)
+		}
+	}
+	if len(updates) != 2 {
+		t.Fatalf("erasure updates = %q, want answers and sessions", updates)
+	}
+	for _, sql := range updates {
+		if !strings.Contains(sql, `SET "deleted_at"=`+stamp) || !strings.Contains(sql, `"deleted_at" IS NULL`) {
+			t.Errorf("erasure %q should stamp live rows with %s", sql, stamp)
+		}
+	}
+
+	restores := allSQL(t, func(db *gorm.DB) {
+		_, _ = restoreErasure(db, "user-1", []string{"s-1"}, erasedAt.Truncate(time.Microsecond))
+	})
+	if len(restores) != 5 {
+		t.Fatalf("restore statements = %d, want one per table", len(restores))
+	}
+	for _, sql := range restores {
+		if !strings.Contains(sql, "deleted_at = "+stamp) || strings.Contains(sql, ">=") {
+			t.Errorf("restore %q should only match rows stamped %s", sql, stamp)
+		}
+	}
+}
+
+func TestLatestErasureChecksErasureOnlyTablesFirst(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	statements := allSQL(t, func(db *gorm.DB) {
+		_, _ = latestErasure(db, "user-1", []string{"s-1"}, since)
+	})
+	want := []string{
+		`SELECT MAX(deleted_at) FROM "sessions"`,
+		`SELECT MAX(deleted_at) FROM "session_analysis_cache"`,
+		`SELECT MAX(deleted_at) FROM "chat_messages"`,
+		`SELECT count(*) FROM "sessions" WHERE user_id = 'user-1'`,
+		`SELECT MAX(deleted_at) FROM "user_answers"`,
+	}
+	if len(statements) != len(want) {
+		t.Fatalf("statements = %q, want %d lookups", statements, len(want))
+	}
+	for i, sql := range statements {
+		if !strings.HasPrefix(sql, want[i]) {
+			t.Errorf("lookup %d = %q, want %s", i, sql, want[i])
+		}
+	}
+}
+
+// Chat history pages are counted on the same filter and read oldest first with a stable tie-break
+func TestFindChatMessagesPaginatedSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	statements := allSQL(t, func(db *gorm.DB) {
+		_, _, _ = repo.FindChatMessagesPaginated(db, "sess-1", 50, 25)
+	})
+	want := []string{
+		`SELECT count(*) FROM "chat_messages" WHERE session_id = 'sess-1' AND "chat_messages"."deleted_at" IS NULL`,
+		`SELECT * FROM "chat_messages" WHERE session_id = 'sess-1' AND "chat_messages"."deleted_at" IS NULL ORDER BY created_at ASC, id ASC LIMIT 25 OFFSET 50`,
+	}
+	if !slices.Equal(statements, want) {
+		t.Errorf("statements = %q, want %q", statements, want)
+	}
+}
+
+// The batch report reads every cache in one query; a repaired score leaves updated_at (the cache freshness) alone
+func TestAnalysisCacheBatchSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	statements := allSQL(t, func(db *gorm.DB) {
+		_, _ = repo.FindAnalysisCachesBySessionIDs(db, []string{"s-1", "s-2"})
+		_ = repo.UpdateAnalysisCacheScore(db, "s-1", 1.5)
+	})
+	want := []string{
+		`SELECT * FROM "session_analysis_cache" WHERE session_id IN ('s-1','s-2') AND "session_analysis_cache"."deleted_at" IS NULL`,
+		`UPDATE "session_analysis_cache" SET "session_score"=1.5 WHERE session_id = 's-1' AND "session_analysis_cache"."deleted_at" IS NULL`,
+	}
+	if !slices.Equal(statements, want) {
+		t.Errorf("statements = %q, want %q", statements, want)
+	}
+}
+
+// The feedback guard looks for the session's feedback message by kind, not at the first message's role
+// updatedAtStamp matches the wall-clock updated_at that gorm adds to every Update
+var updatedAtStamp = regexp.MustCompile(`,"updated_at"='[^']*'`)
+
+// Regrading updates single answers by id and marks analyses stale instead of deleting them
+func TestRegradeSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	at := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	statements := allSQL(t, func(db *gorm.DB) {
+		_, _ = repo.FindUserAnswersByQuestionID(db, "q-1")
+		_ = repo.UpdateAnswerGrade(db, 7, "DOLA", true, 1)
+		_, _ = repo.InvalidateAnalysisCaches(db, []string{"s-1", "s-2"}, at)
+		_, _ = repo.InvalidateAnalysisCaches(db, nil, at)
+	})
+	for i := range statements {
+		statements[i] = updatedAtStamp.ReplaceAllString(statements[i], "")
+	}
+	want := []string{
+		`SELECT * FROM "user_answers" WHERE question_id = 'q-1' AND "user_answers"."deleted_at" IS NULL ORDER BY answered_at DESC`,
+		`UPDATE "user_answers" SET "correct_answer"='DOLA',"is_correct"=true,"partial_credit"=1 WHERE id = 7 AND "user_answers"."deleted_at" IS NULL`,
+		`UPDATE "session_analysis_cache" SET "invalidated_at"='2026-03-02 09:00:00' WHERE session_id IN ('s-1','s-2') AND "session_analysis_cache"."deleted_at" IS NULL`,
+	}
+	if !slices.Equal(statements, want) {
+		t.Errorf("statements = %q, want %q", statements, want)
+	}
+}
+
+// A correction updates the stored answer by id and leaves answered_at (the first submit) alone
+func TestCorrectUserAnswerSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	previous := "DOLA"
+	at := time.Date(2026, 3, 2, 9, 0, 5, 0, time.UTC)
+	statements := allSQL(t, func(db *gorm.DB) {
+		_ = repo.CorrectUserAnswer(db, &entity.UserAnswer{ID: 7, UserAnswer: "BOLA", CorrectAnswer: "BOLA", IsCorrect: true, PartialCredit: 1, PreviousAnswer: &previous, CorrectedAt: &at})
+	})
+	sql := updatedAtStamp.ReplaceAllString(statements[len(statements)-1], "")
+	want := `UPDATE "user_answers" SET "correct_answer"='BOLA',"corrected_at"='2026-03-02 09:00:05',"is_correct"=true,"partial_credit"=1,"previous_answer"='DOLA',"skipped"=false,"user_answer"='BOLA' WHERE id = 7 AND "user_answers"."deleted_at" IS NULL`
+	if sql != want {
+		t.Errorf("SQL = %q, want %q", sql, want)
+	}
+}
+
+// Saving preferences is one upsert that replaces every field of an existing row
+func TestUpsertUserPreferencesSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	at := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	statements := allSQL(t, func(db *gorm.DB) {
+		_ = repo.UpsertUserPreferences(db, &entity.UserPreferences{UserID: "user-1", PreferredDifficulty: "hard", LetterPairs: "b-d,p-q", Language: "id", UpdatedAt: at})
+	})
+	sql := statements[len(statements)-1]
+	want := `ON CONFLICT ("user_id") DO UPDATE SET "preferred_difficulty"="excluded"."preferred_difficulty","letter_pairs"="excluded"."letter_pairs","language"="excluded"."language","voice"="excluded"."voice","updated_at"="excluded"."updated_at"`
+	if !strings.HasPrefix(sql, `INSERT INTO "user_preferences"`) || !strings.Contains(sql, want) {
+		t.Errorf("SQL = %q, want an insert with %q", sql, want)
+	}
+}
+
+// A fresh analysis clears an earlier invalidation, which Assign alone would skip as a nil field
+func TestCreateOrUpdateAnalysisCacheClearsInvalidation(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	statements := allSQL(t, func(db *gorm.DB) {
+		_ = repo.CreateOrUpdateAnalysisCache(db, &entity.SessionAnalysisCache{SessionID: "s-1", TotalQuestions: 2})
+	})
+	sql := updatedAtStamp.ReplaceAllString(statements[len(statements)-1], "")
+	want := `UPDATE "session_analysis_cache" SET "invalidated_at"=NULL WHERE (session_id = 's-1' AND invalidated_at IS NOT NULL) AND "session_analysis_cache"."deleted_at" IS NULL`
+	if sql != want {
+		t.Errorf("SQL = %q, want %q", sql, want)
+	}
+}
+
+// Chat context takes the newest messages; full history reads keep the oldest first
+func TestFindChatMessagesBySessionIDOrder(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	tests := []struct {
+		order ChatHistoryOrder
+		want  string
+	}{
+		{order: ChatHistoryOldestFirst, want: `SELECT * FROM "chat_messages" WHERE session_id = 's-1' AND "chat_messages"."deleted_at" IS NULL ORDER BY created_at ASC, id ASC LIMIT 10`},
+		{order: ChatHistoryRecentFirst, want: `SELECT * FROM "chat_messages" WHERE session_id = 's-1' AND "chat_messages"."deleted_at" IS NULL ORDER BY created_at DESC, id DESC LIMIT 10`},
+	}
+	for _, tt := range tests {
+		sql := lastSQL(t, func(db *gorm.DB) {
+			_, _ = repo.FindChatMessagesBySessionID(db, "s-1", 10, tt.order)
+		})
+		if sql != tt.want {
+			t.Errorf("order %d: SQL = %q, want %q", tt.order, sql, tt.want)
+		}
+	}
+}
+
+func TestHasFeedbackMessageSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	statements := allSQL(t, func(db *gorm.DB) {
+		_, _ = repo.HasFeedbackMessage(db, "sess-1")
+	})
+	want := []string{
+		`SELECT count(*) FROM "chat_messages" WHERE (session_id = 'sess-1' AND kind = 'feedback') AND "chat_messages"."deleted_at" IS NULL`,
+	}
+	if !slices.Equal(statements, want) {
+		t.Errorf("statements = %q, want %q", statements, want)
+	}
+}
+
+func TestFindGeneratedByQuestionIDsSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	statements := allSQL(t, func(db *gorm.DB) {
+		_, _ = repo.FindGeneratedByQuestionIDs(db, []string{"q-1", "q-2"})
+	})
+	want := []string{`SELECT * FROM "generated_questions" WHERE question_id IN ('q-1','q-2') AND "generated_questions"."deleted_at" IS NULL`}
+	if !slices.Equal(statements, want) {
+		t.Errorf("statements = %q, want %q", statements, want)
+	}
+
+	if empty := allSQL(t, func(db *gorm.DB) { _, _ = repo.FindGeneratedByQuestionIDs(db, nil) }); len(empty) != 0 {
+		t.Errorf("no ids still queried: %q", empty)
+	}
+}
+
+func TestFindGeneratedByPairAndDifficultySQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	tests := []struct {
+		name       string
+		difficulty string
+		want       string
+	}{
+		{name: "any difficulty", want: `SELECT * FROM "generated_questions" WHERE (target_letter_pair = 'b-d' AND generated_by <> 'adhoc') AND "generated_questions"."deleted_at" IS NULL ORDER BY id ASC`},
+		{name: "one difficulty", difficulty: "easy", want: `SELECT * FROM "generated_questions" WHERE (target_letter_pair = 'b-d' AND generated_by <> 'adhoc') AND difficulty = 'easy' AND "generated_questions"."deleted_at" IS NULL ORDER BY id ASC`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements := allSQL(t, func(db *gorm.DB) {
+				_, _ = repo.FindGeneratedByPairAndDifficulty(db, "b-d", tt.difficulty)
+			})
+			if !slices.Equal(statements, []string{tt.want}) {
+				t.Errorf("statements = %q, want %q", statements, []string{tt.want})
+			}
+		})
+	}
+}
+
+// Active sessions are the user's started or answered sessions that have no live report
+func TestCountActiveSessionsByUserSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	sql := lastSQL(t, func(db *gorm.DB) {
+		_, _ = repo.CountActiveSessionsByUser(db, "user-1")
+	})
+	for _, want := range []string{
+		"SELECT session_id FROM sessions WHERE user_id = 'user-1' AND deleted_at IS NULL",
+		"UNION",
+		"SELECT session_id FROM user_answers WHERE user_id = 'user-1' AND deleted_at IS NULL",
+		"NOT EXISTS",
+		"c.deleted_at IS NULL",
+		"s.expired_at IS NOT NULL",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL %q does not contain %q", sql, want)
+		}
+	}
+}
+
+// Idle sessions are judged by their latest session update or answer and skip reported or expired ones
+func TestFindIdleSessionsSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	idleSince := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	sql := lastSQL(t, func(db *gorm.DB) {
+		_, _ = repo.FindIdleSessions(db, idleSince, 500)
+	})
+	for _, want := range []string{
+		"SELECT session_id, user_id, updated_at AS at FROM sessions WHERE deleted_at IS NULL",
+		"UNION ALL",
+		"SELECT session_id, user_id, answered_at AS at FROM user_answers WHERE deleted_at IS NULL",
+		"c.session_id = activity.session_id AND c.deleted_at IS NULL",
+		"s.expired_at IS NOT NULL",
+		"HAVING MAX(activity.at) < '2026-01-02 10:00:00'",
+		"ORDER BY last_activity ASC",
+		"LIMIT 500",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL %q does not contain %q", sql, want)
+		}
+	}
+}
+
+func TestLockUserSessionsSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	statements := allSQL(t, func(db *gorm.DB) { _ = repo.LockUserSessions(db, "user-1") })
+	if want := []string{"SELECT pg_advisory_xact_lock(hashtext('sessions:user-1'))"}; !slices.Equal(statements, want) {
+		t.Errorf("statements = %q, want %q", statements, want)
+	}
+}
+
+func TestCreateSessionIfAbsentSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	statements := allSQL(t, func(db *gorm.DB) {
+		_, _ = repo.CreateSessionIfAbsent(db, &entity.Session{SessionID: "s-1", UserID: "user-1"})
+	})
+	if len(statements) != 1 || !strings.Contains(statements[0], `ON CONFLICT ("session_id") DO NOTHING`) {
+		t.Errorf("statements = %q, want one insert that ignores an existing session_id", statements)
+	}
+}
+
+func TestServeLogSQL(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	servedAt := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	inserts := allSQL(t, func(db *gorm.DB) {
+		_ = repo.CreateServeLogs(db, []entity.QuestionServeLog{
+			{QuestionID: "q-1", UserID: "user-1", SessionID: "s-1", ServedAt: servedAt},
+			{QuestionID: "q-2", UserID: "user-1", SessionID: "s-1", ServedAt: servedAt},
+		})
+	})
+	if len(inserts) != 1 || !strings.HasPrefix(inserts[0], `INSERT INTO "question_serve_logs"`) || !strings.Contains(inserts[0], "'q-1'") || !strings.Contains(inserts[0], "'q-2'") {
+		t.Errorf("inserts = %q, want one batch insert of both rows", inserts)
+	}
+	if empty := allSQL(t, func(db *gorm.DB) { _ = repo.CreateServeLogs(db, nil) }); len(empty) != 0 {
+		t.Errorf("no logs still inserted: %q", empty)
+	}
+
+	statements := allSQL(t, func(db *gorm.DB) {
+		_, _ = repo.FindServeLogsBySessionID(db, "s-1")
+	})
+	want := []string{`SELECT * FROM "question_serve_logs" WHERE session_id = 's-1' AND "question_serve_logs"."deleted_at" IS NULL ORDER BY served_at ASC, id ASC`}
+	if !slices.Equal(statements, want) {
+		t.Errorf("statements = %q, want %q", statements, want)
+	}
+}
+
+func TestFindGeneratedPaginatedAdHocFilter(t *testing.T) {
+	repo := &dyslexiaQuestionRepository{}
+	adHoc := "generated_by <> '" + entity.GeneratedByAdHoc + "'"
+
+	tests := []struct {
+		name       string
+		prefix     string
+		wantClause string
+		hidesAdHoc bool
+	}{
+		{name: "default list hides ad hoc records", wantClause: adHoc, hidesAdHoc: true},
+		{name: "explicit prefix selects them", prefix: entity.GeneratedByAdHoc, wantClause: "generated_by LIKE '" + entity.GeneratedByAdHoc + "%'"},
+	}
+	for _, tt := range tests {
+		sql := lastSQL(t, func(db *gorm.DB) {
+			_, _, _ = repo.FindGeneratedPaginated(db, tt.prefix, "", "", 0, 20)
+		})
+		if !strings.Contains(sql, tt.wantClause) {
+			t.Errorf("%s: SQL %q does not contain %q", tt.name, sql, tt.wantClause)
+		}
+		if strings.Contains(sql, adHoc) != tt.hidesAdHoc {
+			t.Errorf("%s: SQL %q, want ad hoc hidden = %v", tt.name, sql, tt.hidesAdHoc)
+		}
+	}
+}
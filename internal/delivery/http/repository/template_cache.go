@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/entity"
+	"gorm.io/gorm"
+)
+
+// templateCachingRepository serves question bank template reads from memory. Templates only change through
+// seeding and CreateTemplate, so entries live until a template write through this repository or, when ttl > 0,
+// until they expire (which also picks up writes made outside the API, e.g. a manual seed).
+type templateCachingRepository struct {
+	DyslexiaQuestionRepository
+	ttl time.Duration
+	now func() time.Time
+
+	mu           sync.RWMutex
+	generation   uint64 // bumped on every invalidation so loads that raced with a write are not cached
+	byDifficulty map[string]templateListEntry
+	byID         map[string]templateEntry
+}
+
+type templateListEntry struct {
+	templates []entity.QuestionBankTemplate // ordered by template_id
+	loadedAt  time.Time
+}
+
+type templateEntry struct {
+	template entity.QuestionBankTemplate
+	loadedAt time.Time
+}
+
+// NewTemplateCachingRepository wraps next with an in-memory template cache; ttl <= 0 keeps entries until
+// the next template write
+func NewTemplateCachingRepository(next DyslexiaQuestionRepository, ttl time.Duration) DyslexiaQuestionRepository {
+	return &templateCachingRepository{
+		DyslexiaQuestionRepository: next,
+		ttl:                        ttl,
+		now:                        time.Now,
+		byDifficulty:               make(map[string]templateListEntry),
+		byID:                       make(map[string]templateEntry),
+	}
+}
+
+func (r *templateCachingRepository) fresh(loadedAt time.Time) bool {
+	return r.ttl <= 0 || r.now().Sub(loadedAt) < r.ttl
+}
+
+// InvalidateTemplates drops every cached template
+func (r *templateCachingRepository) InvalidateTemplates() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generation++
+	r.byDifficulty = make(map[string]templateListEntry)
+	r.byID = make(map[string]templateEntry)
+}
+
+func (r *templateCachingRepository) CreateTemplate(db *gorm.DB, template *entity.QuestionBankTemplate) error {
+	err := r.DyslexiaQuestionRepository.CreateTemplate(db, template)
+	r.InvalidateTemplates()
+	return err
+}
+
+// templatesByDifficulty returns the cached list for difficulty, loading it on a miss. Callers must not modify it.
+func (r *templateCachingRepository) templatesByDifficulty(db *gorm.DB, difficulty string) ([]entity.QuestionBankTemplate, error) {
+	r.mu.RLock()
+	entry, ok := r.byDifficulty[difficulty]
+	generation := r.generation
+	r.mu.RUnlock()
+	if ok && r.fresh(entry.loadedAt) {
+		return entry.templates, nil
+	}
+
+	templates, err := r.DyslexiaQuestionRepository.FindTemplatesByDifficulty(db, difficulty)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].TemplateID < templates[j].TemplateID })
+
+	r.mu.Lock()
+	if r.generation == generation {
+		r.byDifficulty[difficulty] = templateListEntry{templates: templates, loadedAt: r.now()}
+	}
+	r.mu.Unlock()
+	return templates, nil
+}
+
+func (r *templateCachingRepository) FindTemplatesByDifficulty(db *gorm.DB, difficulty string) ([]entity.QuestionBankTemplate, error) {
+	templates, err := r.templatesByDifficulty(db, difficulty)
+	if err != nil {
+		return nil, err
+	}
+	return append([]entity.QuestionBankTemplate(nil), templates...), nil
+}
+
+func (r *templateCachingRepository) FindTemplatesByDifficultyPaginated(db *gorm.DB, difficulty string, offset, limit int) ([]entity.QuestionBankTemplate, error) {
+	templates, err := r.templatesByDifficulty(db, difficulty)
+	if err != nil {
+		return nil, err
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(templates) {
+		return []entity.QuestionBankTemplate{}, nil
+	}
+	end := len(templates)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return append([]entity.QuestionBankTemplate(nil), templates[offset:end]...), nil
+}
+
+func (r *templateCachingRepository) CountTemplatesByDifficulty(db *gorm.DB, difficulty string) (int64, error) {
+	templates, err := r.templatesByDifficulty(db, difficulty)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(templates)), nil
+}
+
+// FindTemplateByTemplateID caches found templates only; misses (record not found) always reach the DB
+func (r *templateCachingRepository) FindTemplateByTemplateID(db *gorm.DB, templateID string) (*entity.QuestionBankTemplate, error) {
+	r.mu.RLock()
+	entry, ok := r.byID[templateID]
+	generation := r.generation
+	r.mu.RUnlock()
+	if ok && r.fresh(entry.loadedAt) {
+		template := entry.template
+		return &template, nil
+	}
+
+	template, err := r.DyslexiaQuestionRepository.FindTemplateByTemplateID(db, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	if r.generation == generation {
+		r.byID[templateID] = templateEntry{template: *template, loadedAt: r.now()}
+	}
+	r.mu.Unlock()
+	return template, nil
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/entity"
+	"gorm.io/gorm"
+)
+
+// countingTemplateRepo counts the template reads that reach the wrapped repository
+type countingTemplateRepo struct {
+	DyslexiaQuestionRepository
+	mu        sync.Mutex
+	templates []entity.QuestionBankTemplate
+	listLoads int
+	idLoads   int
+}
+
+func (r *countingTemplateRepo) FindTemplatesByDifficulty(_ *gorm.DB, difficulty string) ([]entity.QuestionBankTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listLoads++
+	var found []entity.QuestionBankTemplate
+	for _, tpl := range r.templates {
+		if tpl.Difficulty == difficulty {
+			found = append(found, tpl)
+		}
+	}
+	return found, nil
+}
+
+func (r *countingTemplateRepo) FindTemplateByTemplateID(_ *gorm.DB, templateID string) (*entity.QuestionBankTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.idLoads++
+	for _, tpl := range r.templates {
+		if tpl.TemplateID == templateID {
+			return &tpl, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *countingTemplateRepo) CreateTemplate(_ *gorm.DB, template *entity.QuestionBankTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.templates = append(r.templates, *template)
+	return nil
+}
+
+func TestTemplateCacheExpiry(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		elapse    time.Duration
+		wantLoads int
+	}{
+		{name: "no ttl keeps entries", ttl: 0, elapse: 24 * time.Hour, wantLoads: 1},
+		{name: "fresh entry is served", ttl: time.Minute, elapse: 59 * time.Second, wantLoads: 1},
+		{name: "expired entry reloads", ttl: time.Minute, elapse: time.Minute, wantLoads: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &countingTemplateRepo{templates: []entity.QuestionBankTemplate{
+				{TemplateID: "tpl-b", Difficulty: "easy"},
+				{TemplateID: "tpl-a", Difficulty: "easy"},
+			}}
+			now := time.Unix(0, 0)
+			repo := NewTemplateCachingRepository(next, tt.ttl).(*templateCachingRepository)
+			repo.now = func() time.Time { return now }
+
+			if _, err := repo.FindTemplatesByDifficulty(nil, "easy"); err != nil {
+				t.Fatalf("FindTemplatesByDifficulty: %v", err)
+			}
+			if _, err := repo.FindTemplateByTemplateID(nil, "tpl-a"); err != nil {
+				t.Fatalf("FindTemplateByTemplateID: %v", err)
+			}
+			now = now.Add(tt.elapse)
+			if _, err := repo.FindTemplatesByDifficulty(nil, "easy"); err != nil {
+				t.Fatalf("FindTemplatesByDifficulty: %v", err)
+			}
+			if _, err := repo.FindTemplateByTemplateID(nil, "tpl-a"); err != nil {
+				t.Fatalf("FindTemplateByTemplateID: %v", err)
+			}
+			if next.listLoads != tt.wantLoads || next.idLoads != tt.wantLoads {
+				t.Errorf("loads = %d list / %d by id, want %d each", next.listLoads, next.idLoads, tt.wantLoads)
+			}
+		})
+	}
+}
+
+func TestTemplateCacheInvalidatesOnWrite(t *testing.T) {
+	next := &countingTemplateRepo{templates: []entity.QuestionBankTemplate{{TemplateID: "tpl-b", Difficulty: "easy"}}}
+	repo := NewTemplateCachingRepository(next, 0)
+
+	if count, _ := repo.CountTemplatesByDifficulty(nil, "easy"); count != 1 {
+		t.Fatalf("count = %d, want 1", count)
+	}
+	if err := repo.CreateTemplate(nil, &entity.QuestionBankTemplate{TemplateID: "tpl-a", Difficulty: "easy"}); err != nil {
+		t.Fatalf("CreateTemplate: %v", err)
+	}
+	page, err := repo.FindTemplatesByDifficultyPaginated(nil, "easy", 0, 1)
+	if err != nil {
+		t.Fatalf("FindTemplatesByDifficultyPaginated: %v", err)
+	}
+	if len(page) != 1 || page[0].TemplateID != "tpl-a" {
+		t.Errorf("first page = %+v, want tpl-a (ordered by template_id)", page)
+	}
+	if next.listLoads != 2 {
+		t.Errorf("list loads = %d, want a reload after the write", next.listLoads)
+	}
+}
+
+func TestTemplateCacheSkipsMisses(t *testing.T) {
+	next := &countingTemplateRepo{}
+	repo := NewTemplateCachingRepository(next, 0)
+	for i := 0; i < 2; i++ {
+		if _, err := repo.FindTemplateByTemplateID(nil, "missing"); err == nil {
+			t.Fatal("FindTemplateByTemplateID found a missing template")
+		}
+	}
+	if next.idLoads != 2 {
+		t.Errorf("id loads = %d, want every miss to reach the DB", next.idLoads)
+	}
+}
+
+// Repeated reads reach the DB once until a write invalidates the cache; run with -race
+func TestTemplateCacheConcurrentReads(t *testing.T) {
+	next := &countingTemplateRepo{templates: []entity.QuestionBankTemplate{
+		{TemplateID: "tpl-a", Difficulty: "easy"},
+		{TemplateID: "tpl-b", Difficulty: "easy"},
+	}}
+	repo := NewTemplateCachingRepository(next, 0)
+	if _, err := repo.FindTemplatesByDifficulty(nil, "easy"); err != nil {
+		t.Fatalf("FindTemplatesByDifficulty: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			templates, err := repo.FindTemplatesByDifficulty(nil, "easy")
+			if err != nil || len(templates) != 2 {
+				t.Errorf("FindTemplatesByDifficulty = %d templates, %v", len(templates), err)
+				return
+			}
+			// Callers get their own copy; scribbling on it never reaches the cache
+			templates[0].TemplateID = "scribbled"
+		}()
+	}
+	wg.Wait()
+	if next.listLoads != 1 {
+		t.Errorf("list loads = %d, want 1 before any write", next.listLoads)
+	}
+
+	// Reads racing with a write see the template set either before or after it, never a torn one
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			templates, err := repo.FindTemplatesByDifficulty(nil, "easy")
+			if err != nil || len(templates) < 2 {
+				t.Errorf("FindTemplatesByDifficulty during writes = %d templates, %v", len(templates), err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			_ = repo.CreateTemplate(nil, &entity.QuestionBankTemplate{TemplateID: "tpl-new", Difficulty: "hard"})
+		}()
+	}
+	wg.Wait()
+
+	templates, err := repo.FindTemplatesByDifficulty(nil, "easy")
+	if err != nil || len(templates) != 2 || templates[0].TemplateID != "tpl-a" {
+		t.Errorf("templates after writes = %+v, %v", templates, err)
+	}
+}
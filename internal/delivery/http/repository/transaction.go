@@ -0,0 +1,10 @@
+package repository
+
+import "gorm.io/gorm"
+
+// WithTransaction runs fn inside a transaction on db, committing when fn returns nil and rolling back
+// otherwise (including on panic). Pass tx to the repository methods inside fn; their nil-db fallback
+// still points at the root connection and therefore runs outside the transaction.
+func WithTransaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	return db.Transaction(fn)
+}
@@ -0,0 +1,11 @@
+package route
+
+import (
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/handler"
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupDocsRoute(api *fiber.App, handler handler.DocsHandler) {
+	api.Get("/openapi.json", handler.OpenAPI)
+	api.Get("/docs", handler.SwaggerUI)
+}
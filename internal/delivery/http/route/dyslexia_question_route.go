@@ -10,18 +10,62 @@ func SetupDyslexiaQuestionRoute(api *fiber.App, handler handler.DyslexiaQuestion
 	router := api.Group("/questions")
 	{
 		router.Get("/generate", handler.Generate)
+		router.Post("/generate", handler.GenerateFromBody)
+		router.Get("/templates", handler.GetTemplates)
+		router.Get("/fallback", handler.PreviewFallback)
 		router.Post("/answer", handler.SubmitAnswer)
 		router.Get("/sessions/:session_id", handler.GetSessionAnswers)
 	}
 
+	sessionRouter := api.Group("/sessions")
+	{
+		sessionRouter.Post("/", handler.StartSession)
+		sessionRouter.Get("/:session_id/resume", handler.ResumeSession)
+		sessionRouter.Get("/:session_id/progress", handler.GetSessionProgress)
+		sessionRouter.Get("/:session_id/drill", handler.NextDrillQuestion)
+		sessionRouter.Post("/:session_id/import", handler.ImportAnswers)
+	}
+
 	reportRouter := api.Group("/report")
 	{
 		reportRouter.Get("/sessions/:session_id", handler.GetSessionReport)
+		reportRouter.Get("/compare", handler.CompareSessions)
+		reportRouter.Post("/batch", handler.GetBatchReports)
+	}
+
+	analyticsRouter := api.Group("/analytics")
+	{
+		analyticsRouter.Get("/cohort", handler.GetCohortAnalytics)
+		analyticsRouter.Get("/pairs", handler.GetPairAnalytics)
+	}
+
+	userRouter := api.Group("/users")
+	{
+		userRouter.Get("/:user_id/trends", handler.GetUserTrends)
+		userRouter.Get("/:user_id/preferences", handler.GetUserPreferences)
+		userRouter.Put("/:user_id/preferences", handler.SaveUserPreferences)
+		// Erasure requests are operator-only
+		userRouter.Delete("/:user_id", m.AdminMiddleware(), handler.DeleteUser)
+		userRouter.Post("/:user_id/restore", m.AdminMiddleware(), handler.RestoreUser)
+	}
+
+	adminRouter := api.Group("/admin", m.AdminMiddleware())
+	{
+		adminRouter.Get("/questions", handler.ListQuestions)
+		adminRouter.Post("/questions", handler.CreateQuestion)
+		adminRouter.Post("/questions/regenerate", handler.RegenerateOptions)
+		adminRouter.Post("/questions/bulk-generate", handler.BulkGenerate)
+		adminRouter.Get("/questions/:question_id/prompt", handler.GetQuestionPrompt)
+		adminRouter.Get("/questions/:question_id/distribution", handler.GetAnswerDistribution)
+		adminRouter.Post("/questions/:question_id/regrade", handler.RegradeQuestion)
+		adminRouter.Get("/sessions/:session_id/served", handler.GetServeLog)
+		adminRouter.Post("/sessions/:session_id/regrade", handler.RegradeSession)
 	}
 
 	chatbotRouter := api.Group("/chatbot")
 	{
 		chatbotRouter.Post("/sessions/:session_id", handler.ChatWithBot)
 		chatbotRouter.Get("/sessions/:session_id/history", handler.GetChatHistory)
+		chatbotRouter.Get("/sessions/:session_id/transcript", handler.GetChatTranscript)
 	}
 }
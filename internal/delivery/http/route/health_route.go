@@ -0,0 +1,11 @@
+package route
+
+import (
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/handler"
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupHealthRoute(api *fiber.App, handler handler.HealthHandler) {
+	api.Get("/readyz", handler.Ready)
+	api.Get("/metrics", handler.Metrics)
+}
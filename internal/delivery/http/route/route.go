@@ -4,7 +4,6 @@ import (
 	"github.com/evandrarf/dinacom-be/internal/delivery/http/handler"
 	"github.com/evandrarf/dinacom-be/internal/delivery/http/middleware"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 )
 
@@ -12,14 +11,21 @@ type RouteConfig struct {
 	Api                     *fiber.App
 	Middleware              *middleware.Middleware
 	DyslexiaQuestionHandler handler.DyslexiaQuestionHandler
+	HealthHandler           handler.HealthHandler
+	DocsHandler             handler.DocsHandler // nil = docs disabled
 }
 
 func Setup(c *RouteConfig) {
 	c.Api.Use(recover.New())
-	c.Api.Use(logger.New(logger.Config{
-		Format: "[${ip}]:${port} ${status} - ${method} ${path}\n",
-	}))
-	c.Api.Use(c.Middleware.CorsMiddleware())
+	c.Api.Use(c.Middleware.RequestIDMiddleware())
+	c.Api.Use(c.Middleware.AccessLogMiddleware())
+	if c.Middleware.CorsEnabled() {
+		c.Api.Use(c.Middleware.CorsMiddleware())
+	}
 
+	SetupHealthRoute(c.Api, c.HealthHandler)
 	SetupDyslexiaQuestionRoute(c.Api, c.DyslexiaQuestionHandler, c.Middleware)
+	if c.DocsHandler != nil {
+		SetupDocsRoute(c.Api, c.DocsHandler)
+	}
 }
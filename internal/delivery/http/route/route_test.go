@@ -0,0 +1,59 @@
+package route
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/handler"
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/middleware"
+	"github.com/evandrarf/dinacom-be/internal/pkg/metrics"
+	"github.com/evandrarf/dinacom-be/internal/pkg/validate"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+func TestSetupCors(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    interface{}
+		wantHeader string
+	}{
+		{name: "enabled by default", wantHeader: "*"},
+		{name: "enabled", enabled: true, wantHeader: "*"},
+		{name: "disabled", enabled: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := viper.New()
+			config.Set("api.cors.origins", "*")
+			if tt.enabled != nil {
+				config.Set("api.cors.enabled", tt.enabled)
+			}
+			log := logrus.New()
+			log.SetOutput(io.Discard)
+
+			app := fiber.New()
+			Setup(&RouteConfig{
+				Api:                     app,
+				Middleware:              middleware.NewMiddleware(&middleware.MiddlewareConfig{Log: log, Config: config}),
+				DyslexiaQuestionHandler: handler.NewDyslexiaQuestionHandler(validate.NewValidator(), log, nil),
+				HealthHandler:           handler.NewHealthHandler(nil, nil, metrics.NewRegistry()),
+			})
+
+			req := httptest.NewRequest(fiber.MethodGet, "/metrics", nil)
+			req.Header.Set(fiber.HeaderOrigin, "https://spa.example.com")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			if resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+			if got := resp.Header.Get(fiber.HeaderAccessControlAllowOrigin); got != tt.wantHeader {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantHeader)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+// adHocQuestion stores a synthetic question for a submit whose question_id is not in the DB, so integrations
+// with their own questions can use grading and reports (answers.allow_adhoc). The record is marked
+// generated_by=adhoc and never served from the cache. Options default to just the correct answer.
+func (u *dyslexiaQuestionUsecase) adHocQuestion(req entity.SubmitAnswerRequest) (*internalEntity.GeneratedQuestion, error) {
+	options := req.Options
+	if len(options) == 0 {
+		options = []string{req.CorrectAnswer}
+	}
+	found := false
+	for _, opt := range options {
+		found = found || strings.EqualFold(strings.TrimSpace(opt), req.CorrectAnswer)
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: options must contain correct_answer %q", ErrInvalidInput, req.CorrectAnswer)
+	}
+
+	letterPair := req.TargetLetterPair
+	if letterPair != "" {
+		if _, rejected := splitPatterns([]string{letterPair}); len(rejected) > 0 {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, invalidPatternsError(rejected))
+		}
+	}
+	targetLetter := ""
+	if letterPair != "" {
+		targetLetter = strings.Split(letterPair, "-")[0]
+	}
+	difficulty := req.Difficulty
+	if difficulty == "" {
+		difficulty = u.defaultDifficulty
+	}
+
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	q := &internalEntity.GeneratedQuestion{
+		QuestionID:       req.QuestionID,
+		TemplateID:       internalEntity.GeneratedByAdHoc,
+		Difficulty:       string(difficulty),
+		QuestionText:     "Dengarkan kata berikut: ",
+		TargetLetterPair: letterPair,
+		TargetLetter:     targetLetter,
+		Options:          string(optionsJSON),
+		CorrectAnswer:    req.CorrectAnswer,
+		GeneratedBy:      internalEntity.GeneratedByAdHoc,
+	}
+	if err := u.cfg.Repository.CreateGenerated(u.cfg.DB, q); err != nil {
+		// A concurrent submit for the same question may have created it first
+		if existing, findErr := u.cfg.Repository.FindGeneratedByQuestionID(u.cfg.DB, req.QuestionID); findErr == nil {
+			return existing, nil
+		}
+		return nil, fmt.Errorf("failed to save ad hoc question: %w", err)
+	}
+	u.logger().Infof("[ADHOC] Created question %s for grading", q.QuestionID)
+	return q, nil
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+func TestAdHocGrading(t *testing.T) {
+	tests := []struct {
+		name        string
+		answer      string
+		wantCorrect bool
+	}{
+		{name: "correct answer", answer: "bola", wantCorrect: true},
+		{name: "incorrect answer", answer: "dola"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			u := newTestUsecase(t, repo)
+			u.cfg.Config.Set("answers.allow_adhoc", true)
+			logs := captureLog(u)
+
+			resp, err := u.SubmitAnswer(context.Background(), entity.SubmitAnswerRequest{
+				UserID: "user-1", SessionID: "sess-1", QuestionID: "ext-1", Answer: tt.answer,
+				CorrectAnswer: "bola", Options: []string{"bola", "dola"}, TargetLetterPair: "b-d",
+			})
+			if err != nil {
+				t.Fatalf("SubmitAnswer: %v", err)
+			}
+			if resp.IsCorrect != tt.wantCorrect {
+				t.Errorf("IsCorrect = %v, want %v", resp.IsCorrect, tt.wantCorrect)
+			}
+			q := repo.questions["ext-1"]
+			if q == nil || q.GeneratedBy != internalEntity.GeneratedByAdHoc {
+				t.Fatalf("synthetic question not stored as ad hoc: %+v", q)
+			}
+			if len(repo.answers) != 1 || repo.answers[0].IsCorrect != tt.wantCorrect {
+				t.Errorf("stored answers %+v", repo.answers)
+			}
+			if strings.Contains(strings.ToLower(logs.String()), "bola") {
+				t.Errorf("log leaks the correct answer: %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestAdHocGradingDisabled(t *testing.T) {
+	repo := newFakeRepo()
+	u := newTestUsecase(t, repo)
+
+	_, err := u.SubmitAnswer(context.Background(), entity.SubmitAnswerRequest{
+		UserID: "user-1", SessionID: "sess-1", QuestionID: "ext-1", Answer: "bola", CorrectAnswer: "bola",
+	})
+	if err == nil {
+		t.Fatalf("ad hoc submit accepted without answers.allow_adhoc")
+	}
+	if len(repo.questions) != 0 || len(repo.answers) != 0 {
+		t.Errorf("nothing may be stored when ad hoc grading is off")
+	}
+}
+
+func TestAdHocGradingRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name string
+		req  entity.SubmitAnswerRequest
+	}{
+		{name: "options without the correct answer", req: entity.SubmitAnswerRequest{Options: []string{"dola", "pola"}}},
+		{name: "unknown letter pair", req: entity.SubmitAnswerRequest{TargetLetterPair: "x-y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			u := newTestUsecase(t, repo)
+			u.cfg.Config.Set("answers.allow_adhoc", true)
+
+			req := tt.req
+			req.UserID, req.SessionID, req.QuestionID, req.Answer, req.CorrectAnswer = "user-1", "sess-1", "ext-1", "bola", "bola"
+			if _, err := u.SubmitAnswer(context.Background(), req); !errors.Is(err, ErrInvalidInput) {
+				t.Fatalf("err = %v, want ErrInvalidInput", err)
+			}
+			if len(repo.questions) != 0 || len(repo.answers) != 0 {
+				t.Errorf("nothing may be stored for an invalid ad hoc submit")
+			}
+		})
+	}
+}
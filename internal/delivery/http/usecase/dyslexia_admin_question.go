@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+)
+
+// GetQuestionPrompt returns the prompt that produced an AI-generated question (stored with llm.store_prompts)
+func (u *dyslexiaQuestionUsecase) GetQuestionPrompt(ctx context.Context, questionID string) (*entity.QuestionPrompt, error) {
+	q, err := u.cfg.Repository.FindGeneratedByQuestionID(u.dbWithContext(ctx), questionID)
+	if err != nil {
+		return nil, fmt.Errorf("question not found: %w", err)
+	}
+	if q.Prompt == nil {
+		return nil, fmt.Errorf("no prompt stored for question %s", questionID)
+	}
+
+	return &entity.QuestionPrompt{
+		QuestionID:  q.QuestionID,
+		GeneratedBy: q.GeneratedBy,
+		Prompt:      *q.Prompt,
+		CreatedAt:   q.CreatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+// ListQuestions pages through the cached questions for admins, filterable by the model that generated them
+func (u *dyslexiaQuestionUsecase) ListQuestions(ctx context.Context, filter entity.QuestionListFilter, page, perPage int) ([]entity.AdminQuestionItem, int64, error) {
+	questions, total, err := u.cfg.Repository.FindGeneratedPaginated(u.dbWithContext(ctx), filter.GeneratedBy, filter.Difficulty, filter.LetterPair, (page-1)*perPage, perPage)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list questions: %w", err)
+	}
+
+	items := make([]entity.AdminQuestionItem, 0, len(questions))
+	for _, q := range questions {
+		var options []string
+		if err := json.Unmarshal([]byte(q.Options), &options); err != nil {
+			u.logger().Warnf("[ADMIN] Failed to parse options of question %s: %v", q.QuestionID, err)
+		}
+		items = append(items, entity.AdminQuestionItem{
+			QuestionID:       q.QuestionID,
+			Difficulty:       q.Difficulty,
+			TargetLetterPair: q.TargetLetterPair,
+			CorrectAnswer:    q.CorrectAnswer,
+			Options:          options,
+			GeneratedBy:      q.GeneratedBy,
+			UsageCount:       q.UsageCount,
+			CreatedAt:        q.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return items, total, nil
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+func TestListQuestions(t *testing.T) {
+	repo := newFakeRepo()
+	repo.questions["q-1"] = &internalEntity.GeneratedQuestion{QuestionID: "q-1", Difficulty: "easy", GeneratedBy: "template", Options: `["bola","dola"]`, CorrectAnswer: "bola"}
+	repo.questions["q-2"] = &internalEntity.GeneratedQuestion{QuestionID: "q-2", Difficulty: "easy", GeneratedBy: "api.openai.com/gpt-4o-mini", Options: `["dadu","badu"]`, CorrectAnswer: "dadu"}
+	repo.questions["q-3"] = &internalEntity.GeneratedQuestion{QuestionID: "q-3", Difficulty: "hard", GeneratedBy: "template", Options: `not json`, CorrectAnswer: "pagi"}
+	repo.questions["q-4"] = &internalEntity.GeneratedQuestion{QuestionID: "q-4", Difficulty: "easy", GeneratedBy: "api.openai.com/gpt-4o", Options: `["pita","qita"]`, CorrectAnswer: "pita"}
+	u := newTestUsecase(t, repo)
+
+	tests := []struct {
+		name      string
+		filter    entity.QuestionListFilter
+		page      int
+		perPage   int
+		wantIDs   []string
+		wantTotal int64
+	}{
+		{name: "first page", page: 1, perPage: 2, wantIDs: []string{"q-1", "q-2"}, wantTotal: 4},
+		{name: "second page", page: 2, perPage: 2, wantIDs: []string{"q-3", "q-4"}, wantTotal: 4},
+		{name: "by generator", filter: entity.QuestionListFilter{GeneratedBy: "template"}, page: 1, perPage: 10, wantIDs: []string{"q-1", "q-3"}, wantTotal: 2},
+		{name: "by provider", filter: entity.QuestionListFilter{GeneratedBy: "api.openai.com/"}, page: 1, perPage: 10, wantIDs: []string{"q-2", "q-4"}, wantTotal: 2},
+		{name: "by model", filter: entity.QuestionListFilter{GeneratedBy: "api.openai.com/gpt-4o-mini"}, page: 1, perPage: 10, wantIDs: []string{"q-2"}, wantTotal: 1},
+		{name: "by difficulty", filter: entity.QuestionListFilter{Difficulty: "hard"}, page: 1, perPage: 10, wantIDs: []string{"q-3"}, wantTotal: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, total, err := u.ListQuestions(context.Background(), tt.filter, tt.page, tt.perPage)
+			if err != nil {
+				t.Fatalf("ListQuestions: %v", err)
+			}
+			var ids []string
+			for _, item := range items {
+				ids = append(ids, item.QuestionID)
+				if item.QuestionID == "q-1" && !slices.Equal(item.Options, []string{"bola", "dola"}) {
+					t.Errorf("q-1 options = %v", item.Options)
+				}
+				if item.QuestionID == "q-3" && item.Options != nil {
+					t.Errorf("unparseable options = %v, want none", item.Options)
+				}
+			}
+			if !slices.Equal(ids, tt.wantIDs) || total != tt.wantTotal {
+				t.Errorf("ids = %v (total %d), want %v (total %d)", ids, total, tt.wantIDs, tt.wantTotal)
+			}
+		})
+	}
+}
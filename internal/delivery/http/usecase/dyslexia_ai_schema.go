@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Defaults for llm.gemini.strict_schema_*
+const (
+	defaultAIOptionCount   = 4
+	defaultAISchemaRetries = 1
+)
+
+// strictAISchema reports whether AI question output is checked against aiQuestionSchema (llm.gemini.strict_schema)
+func (u *dyslexiaQuestionUsecase) strictAISchema() bool {
+	return u.cfg.Config.GetBool("llm.gemini.strict_schema")
+}
+
+// aiSchemaRetries is how many extra generations a single question gets after a schema violation
+func (u *dyslexiaQuestionUsecase) aiSchemaRetries() int {
+	if u.cfg.Config.IsSet("llm.gemini.strict_schema_retries") {
+		return u.cfg.Config.GetInt("llm.gemini.strict_schema_retries")
+	}
+	return defaultAISchemaRetries
+}
+
+// aiOptionCount is the exact number of options a strictly validated AI question must carry
+func (u *dyslexiaQuestionUsecase) aiOptionCount() int {
+	if u.cfg.Config.IsSet("llm.gemini.strict_schema_option_count") {
+		return u.cfg.Config.GetInt("llm.gemini.strict_schema_option_count")
+	}
+	return defaultAIOptionCount
+}
+
+// checkAISchema validates one raw AI question object against the expected schema: exactly the fields
+// correctAnswer (non-empty string) and options (array of optionCount non-empty strings, one of which is
+// the correct answer). Violations are counted per code path (llm_schema_violations_<path>).
+func (u *dyslexiaQuestionUsecase) checkAISchema(path string, raw []byte) error {
+	err := aiQuestionSchema(raw, u.aiOptionCount())
+	if err != nil {
+		count := u.cfg.Metrics.Inc("llm_schema_violations_" + path)
+		u.logger().Warnf("[SCHEMA] %s: AI output rejected (total violations: %d): %v", path, count, err)
+	}
+	return err
+}
+
+func aiQuestionSchema(raw []byte, optionCount int) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil || fields == nil {
+		return fmt.Errorf("AI output is not a json object")
+	}
+	for name := range fields {
+		if name != "correctAnswer" && name != "options" {
+			return fmt.Errorf("AI output has unexpected field %q", name)
+		}
+	}
+
+	var correct string
+	if err := decodeStrict(fields["correctAnswer"], &correct); err != nil {
+		return fmt.Errorf("AI output correctAnswer must be a string")
+	}
+	correct = strings.TrimSpace(correct)
+	if correct == "" {
+		return fmt.Errorf("AI output correctAnswer is empty")
+	}
+
+	var options []string
+	if err := decodeStrict(fields["options"], &options); err != nil {
+		return fmt.Errorf("AI output options must be an array of strings")
+	}
+	if optionCount > 0 && len(options) != optionCount {
+		return fmt.Errorf("AI output has %d options, expected %d", len(options), optionCount)
+	}
+	found := false
+	for _, opt := range options {
+		opt = strings.TrimSpace(opt)
+		if opt == "" {
+			return fmt.Errorf("AI output has an empty option")
+		}
+		found = found || strings.EqualFold(opt, correct)
+	}
+	if !found {
+		return fmt.Errorf("AI output correctAnswer %q is not among the options", correct)
+	}
+	return nil
+}
+
+// decodeStrict decodes a present, non-null json value into v; a missing field or null is an error
+func decodeStrict(raw json.RawMessage, v any) error {
+	if len(raw) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return fmt.Errorf("missing")
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/pkg/metrics"
+)
+
+// Parseable json that is not a well-formed question object is rejected with the violated rule
+func TestAIQuestionSchema(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		optionCount int
+		wantErr     string
+	}{
+		{name: "valid", raw: `{"correctAnswer":"dadu","options":["dadu","badu","dabu","babu"]}`, optionCount: 4},
+		{name: "correct answer matched case-insensitively", raw: `{"correctAnswer":"Dadu","options":["DADU","badu","dabu","babu"]}`, optionCount: 4},
+		{name: "option count not enforced", raw: `{"correctAnswer":"dadu","options":["dadu","badu"]}`},
+		{name: "array", raw: `[{"correctAnswer":"dadu"}]`, optionCount: 4, wantErr: "not a json object"},
+		{name: "null", raw: `null`, optionCount: 4, wantErr: "not a json object"},
+		{name: "string", raw: `"dadu"`, optionCount: 4, wantErr: "not a json object"},
+		{name: "unexpected field", raw: `{"correctAnswer":"dadu","options":["dadu","badu","dabu","babu"],"hint":"d"}`, optionCount: 4, wantErr: `unexpected field "hint"`},
+		{name: "missing correct answer", raw: `{"options":["dadu","badu","dabu","babu"]}`, optionCount: 4, wantErr: "correctAnswer must be a string"},
+		{name: "null correct answer", raw: `{"correctAnswer":null,"options":["dadu","badu","dabu","babu"]}`, optionCount: 4, wantErr: "correctAnswer must be a string"},
+		{name: "numeric correct answer", raw: `{"correctAnswer":7,"options":["dadu","badu","dabu","babu"]}`, optionCount: 4, wantErr: "correctAnswer must be a string"},
+		{name: "blank correct answer", raw: `{"correctAnswer":"  ","options":["dadu","badu","dabu","babu"]}`, optionCount: 4, wantErr: "correctAnswer is empty"},
+		{name: "missing options", raw: `{"correctAnswer":"dadu"}`, optionCount: 4, wantErr: "options must be an array of strings"},
+		{name: "options as string", raw: `{"correctAnswer":"dadu","options":"dadu,badu"}`, optionCount: 4, wantErr: "options must be an array of strings"},
+		{name: "options with a number", raw: `{"correctAnswer":"dadu","options":["dadu",1,"dabu","babu"]}`, optionCount: 4, wantErr: "options must be an array of strings"},
+		{name: "too few options", raw: `{"correctAnswer":"dadu","options":["dadu","badu","dabu"]}`, optionCount: 4, wantErr: "has 3 options, expected 4"},
+		{name: "blank option", raw: `{"correctAnswer":"dadu","options":["dadu"," ","dabu","babu"]}`, optionCount: 4, wantErr: "empty option"},
+		{name: "correct answer not among options", raw: `{"correctAnswer":"dadu","options":["bola","badu","dabu","babu"]}`, optionCount: 4, wantErr: "not among the options"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := aiQuestionSchema([]byte(tt.raw), tt.optionCount)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Violations are counted per code path and logged; valid output touches neither
+func TestCheckAISchemaCountsViolations(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	u.cfg.Metrics = metrics.NewRegistry()
+	logs := captureLog(u)
+
+	if err := u.checkAISchema("generate_single", []byte(`{"correctAnswer":"dadu","options":["dadu","badu","dabu","babu"]}`)); err != nil {
+		t.Fatalf("valid output rejected: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := u.checkAISchema("generate_batch", []byte(`{"correctAnswer":"dadu","options":[]}`)); err == nil {
+			t.Fatalf("empty options accepted")
+		}
+	}
+
+	if got := u.cfg.Metrics.Get("llm_schema_violations_generate_batch"); got != 2 {
+		t.Errorf("generate_batch violations = %d, want 2", got)
+	}
+	if got := u.cfg.Metrics.Get("llm_schema_violations_generate_single"); got != 0 {
+		t.Errorf("generate_single violations = %d, want 0", got)
+	}
+	if !strings.Contains(logs.String(), "[SCHEMA] generate_batch: AI output rejected (total violations: 2)") {
+		t.Errorf("violation not logged: %s", logs.String())
+	}
+}
+
+// With llm.gemini.strict_schema a structurally invalid reply is regenerated up to strict_schema_retries times
+func TestGenerateFromAIStrictSchema(t *testing.T) {
+	const valid = `{"correctAnswer":"dadu","options":["dadu","badu","dabu","babu"]}`
+	const threeOptions = `{"correctAnswer":"dadu","options":["dadu","badu","dabu"]}`
+	tests := []struct {
+		name      string
+		strict    bool
+		retries   int
+		replies   []string
+		wantCalls int64
+		wantErr   bool
+	}{
+		{name: "lenient accepts three options", replies: []string{threeOptions}, wantCalls: 1},
+		{name: "strict retries then succeeds", strict: true, retries: 1, replies: []string{threeOptions, valid}, wantCalls: 2},
+		{name: "strict gives up after retries", strict: true, retries: 1, replies: []string{threeOptions, `{"correctAnswer":"dadu","options":["dadu","badu","dabu","babu"],"note":"x"}`}, wantCalls: 2, wantErr: true},
+		{name: "strict without retries", strict: true, retries: 0, replies: []string{threeOptions, valid}, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newTestUsecase(t, newFakeRepo())
+			u.cfg.PromptTemplate = defaultPromptTemplate
+			u.cfg.Config.Set("llm.gemini.strict_schema", tt.strict)
+			u.cfg.Config.Set("llm.gemini.strict_schema_retries", tt.retries)
+			captureLog(u)
+			var n atomic.Int64
+			var fake *fakeLLM
+			u.cfg.Gemini, fake = newFakeLLM(t, func(string) (string, int) {
+				i := int(n.Add(1) - 1)
+				return tt.replies[min(i, len(tt.replies)-1)], http.StatusOK
+			})
+
+			q, _, err := u.generateFromAI(context.Background(), "easy", "b-d", true, false)
+			if got := fake.calls.Load(); got != tt.wantCalls {
+				t.Errorf("LLM calls = %d, want %d", got, tt.wantCalls)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("invalid output accepted: %+v", q)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("generateFromAI: %v", err)
+			}
+			if !strings.EqualFold(q.Answer, "dadu") {
+				t.Errorf("answer = %q, want dadu", q.Answer)
+			}
+		})
+	}
+}
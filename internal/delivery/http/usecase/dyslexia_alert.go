@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"math"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+// Defaults for webhooks.accuracy_alert.*
+const (
+	defaultAccuracyDropThreshold  = 20.0
+	defaultAccuracyDropMinHistory = 2
+)
+
+// checkAccuracyDrop posts a user.accuracy_drop alert (webhooks.accuracy_alert_url) when the session's accuracy
+// is at least webhooks.accuracy_alert.drop_threshold percentage points below the average of the user's previous
+// analysed sessions. history is the lookup generateAIAnalysis already made; the current session is skipped if
+// it was cached before. Delivery happens in the background and never affects the report.
+func (u *dyslexiaQuestionUsecase) checkAccuracyDrop(userID string, answers []internalEntity.UserAnswer, history []internalEntity.SessionAnalysisCache) {
+	if !u.cfg.AccuracyAlert.Enabled() || len(answers) == 0 {
+		return
+	}
+	sessionID := answers[0].SessionID
+
+	threshold := defaultAccuracyDropThreshold
+	if u.cfg.Config.IsSet("webhooks.accuracy_alert.drop_threshold") {
+		threshold = u.cfg.Config.GetFloat64("webhooks.accuracy_alert.drop_threshold")
+	}
+	minHistory := defaultAccuracyDropMinHistory
+	if u.cfg.Config.IsSet("webhooks.accuracy_alert.min_history") {
+		minHistory = u.cfg.Config.GetInt("webhooks.accuracy_alert.min_history")
+	}
+
+	sum, sessions := 0.0, 0
+	for _, h := range history {
+		if h.SessionID == sessionID || h.TotalQuestions == 0 {
+			continue
+		}
+		sum += float64(h.CorrectAnswers) / float64(h.TotalQuestions) * 100
+		sessions++
+	}
+	if sessions == 0 || sessions < minHistory {
+		return
+	}
+
+	current := float64(countCorrect(answers)) / float64(len(answers)) * 100
+	historical := sum / float64(sessions)
+	delta := current - historical
+	if -delta < threshold {
+		return
+	}
+
+	alert := entity.AccuracyDropAlert{
+		UserID:             userID,
+		SessionID:          sessionID,
+		SessionAccuracy:    math.Round(current*10) / 10,
+		HistoricalAccuracy: math.Round(historical*10) / 10,
+		Delta:              math.Round(delta*10) / 10,
+		Threshold:          threshold,
+		HistorySessions:    sessions,
+	}
+	u.goBackground(func() {
+		if err := u.cfg.AccuracyAlert.Send(context.Background(), "user.accuracy_drop", sessionID, alert); err != nil {
+			u.logger().Warnf("[WEBHOOK] Failed to deliver user.accuracy_drop for %s: %v", sessionID, err)
+			return
+		}
+		u.logger().Infof("[WEBHOOK] Delivered user.accuracy_drop for %s (%.1f%% vs %.1f%%)", sessionID, alert.SessionAccuracy, alert.HistoricalAccuracy)
+	})
+}
@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+	"github.com/evandrarf/dinacom-be/internal/pkg/webhook"
+)
+
+// alertReceiver records the user.accuracy_drop alerts posted to it
+type alertReceiver struct {
+	mu     sync.Mutex
+	alerts []entity.AccuracyDropAlert
+}
+
+func newAlertReceiver(t *testing.T) (*alertReceiver, *webhook.Client) {
+	t.Helper()
+	recv := &alertReceiver{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert entity.AccuracyDropAlert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("decode alert: %v", err)
+		}
+		if event := r.Header.Get(webhook.EventHeader); event != "user.accuracy_drop" {
+			t.Errorf("event = %q, want user.accuracy_drop", event)
+		}
+		recv.mu.Lock()
+		recv.alerts = append(recv.alerts, alert)
+		recv.mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return recv, webhook.NewClient(server.URL, "", 1, time.Second)
+}
+
+// answersWithAccuracy builds a session of ten answers, correct of which are correct
+func answersWithAccuracy(sessionID string, correct int) []internalEntity.UserAnswer {
+	answers := make([]internalEntity.UserAnswer, 10)
+	for i := range answers {
+		answers[i] = internalEntity.UserAnswer{SessionID: sessionID, UserID: "user-1", QuestionID: fmt.Sprintf("q-%d", i), IsCorrect: i < correct}
+	}
+	return answers
+}
+
+func TestCheckAccuracyDrop(t *testing.T) {
+	history := []internalEntity.SessionAnalysisCache{
+		{SessionID: "s-1", TotalQuestions: 10, CorrectAnswers: 9},
+		{SessionID: "s-2", TotalQuestions: 10, CorrectAnswers: 8},
+		{SessionID: "s-empty"}, // no answers, skipped
+	}
+	tests := []struct {
+		name    string
+		correct int
+		history []internalEntity.SessionAnalysisCache
+		config  map[string]any
+		want    *entity.AccuracyDropAlert
+	}{
+		{name: "sharp drop alerts", correct: 4, history: history, want: &entity.AccuracyDropAlert{
+			UserID: "user-1", SessionID: "s-now", SessionAccuracy: 40, HistoricalAccuracy: 85, Delta: -45, Threshold: 20, HistorySessions: 2}},
+		{name: "normal session", correct: 8, history: history},
+		{name: "drop just below the threshold", correct: 7, history: history, config: map[string]any{"webhooks.accuracy_alert.drop_threshold": 16}},
+		{name: "configured threshold", correct: 7, history: history, config: map[string]any{"webhooks.accuracy_alert.drop_threshold": 15}, want: &entity.AccuracyDropAlert{
+			UserID: "user-1", SessionID: "s-now", SessionAccuracy: 70, HistoricalAccuracy: 85, Delta: -15, Threshold: 15, HistorySessions: 2}},
+		{name: "too little history", correct: 0, history: history[:1]},
+		{name: "current session in history is skipped", correct: 4, history: append([]internalEntity.SessionAnalysisCache{{SessionID: "s-now", TotalQuestions: 10, CorrectAnswers: 4}}, history[:1]...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recv, client := newAlertReceiver(t)
+			u := newTestUsecase(t, newFakeRepo())
+			u.cfg.AccuracyAlert = client
+			for k, v := range tt.config {
+				u.cfg.Config.Set(k, v)
+			}
+
+			u.checkAccuracyDrop("user-1", answersWithAccuracy("s-now", tt.correct), tt.history)
+			u.background.Wait()
+
+			switch {
+			case tt.want == nil && len(recv.alerts) != 0:
+				t.Errorf("alerts = %+v, want none", recv.alerts)
+			case tt.want != nil && (len(recv.alerts) != 1 || recv.alerts[0] != *tt.want):
+				t.Errorf("alerts = %+v, want %+v", recv.alerts, *tt.want)
+			}
+		})
+	}
+}
+
+// Without a configured URL nothing is sent, and a failing endpoint never fails the report
+func TestAccuracyDropAlertOptional(t *testing.T) {
+	repo := newFakeRepo()
+	repo.answers = answersWithAccuracy("s-now", 2)
+	repo.caches["s-1"] = &internalEntity.SessionAnalysisCache{SessionID: "s-1", TotalQuestions: 10, CorrectAnswers: 9}
+	repo.caches["s-2"] = &internalEntity.SessionAnalysisCache{SessionID: "s-2", TotalQuestions: 10, CorrectAnswers: 9}
+
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		client   *webhook.Client
+		wantHits bool
+	}{
+		{name: "no client"},
+		{name: "empty url", client: webhook.NewClient("", "", 1, time.Second)},
+		{name: "failing endpoint", client: webhook.NewClient(server.URL, "", 1, time.Second), wantHits: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits.Store(0)
+			u := newTestUsecase(t, repo)
+			u.cfg.DB, _ = txTestDB(t)
+			u.cfg.AccuracyAlert = tt.client
+			u.cfg.Gemini, _ = newFakeLLM(t, replyWith(analysisReply))
+			delete(repo.caches, "s-now")
+
+			if _, err := u.GenerateSessionReport(context.Background(), "s-now"); err != nil {
+				t.Fatalf("GenerateSessionReport: %v", err)
+			}
+			u.background.Wait()
+			if got := hits.Load() > 0; got != tt.wantHits {
+				t.Errorf("alert endpoint called = %v, want %v", got, tt.wantHits)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+)
+
+// GetCohortAnalytics aggregates accuracy, letter-pair errors, and difficulty distribution across users.
+// Average accuracy is the mean of per-user accuracy, so every child weighs equally.
+func (u *dyslexiaQuestionUsecase) GetCohortAnalytics(ctx context.Context, userIDs []string, from, to time.Time) (*entity.CohortAnalytics, error) {
+	if len(userIDs) == 0 {
+		return nil, fmt.Errorf("user_ids is required")
+	}
+
+	accuracyRows, err := u.cfg.Repository.AggregateAccuracyByUsers(u.dbWithContext(ctx), userIDs, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate accuracy: %w", err)
+	}
+
+	pairRows, err := u.cfg.Repository.AggregateLetterPairStatsByUsers(u.dbWithContext(ctx), userIDs, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate letter pairs: %w", err)
+	}
+
+	difficultyRows, err := u.cfg.Repository.AggregateDifficultyByUsers(u.dbWithContext(ctx), userIDs, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate difficulty: %w", err)
+	}
+
+	totalAnswers := 0
+	accuracySum := 0.0
+	activeUsers := 0
+	for _, row := range accuracyRows {
+		if row.Total == 0 {
+			continue
+		}
+		activeUsers++
+		totalAnswers += row.Total
+		accuracySum += float64(row.Correct) / float64(row.Total) * 100
+	}
+
+	averageAccuracy := "0.0%"
+	if activeUsers > 0 {
+		averageAccuracy = fmt.Sprintf("%.1f%%", accuracySum/float64(activeUsers))
+	}
+
+	errorPatterns := make([]entity.ErrorPattern, 0, len(pairRows))
+	for _, row := range pairRows {
+		if row.Total == 0 {
+			continue
+		}
+		errorPatterns = append(errorPatterns, entity.ErrorPattern{
+			LetterPair: row.LetterPair,
+			ErrorCount: row.Errors,
+			TotalCount: row.Total,
+			ErrorRate:  fmt.Sprintf("%.1f%%", float64(row.Errors)/float64(row.Total)*100),
+		})
+	}
+	sortErrorPatterns(errorPatterns)
+
+	difficultyStats := make(map[string]int)
+	for _, row := range difficultyRows {
+		// Legacy rows may differ only in case; fold them into the canonical key
+		difficultyStats[string(entity.NormalizeDifficulty(row.Difficulty))] += row.Total
+	}
+
+	result := &entity.CohortAnalytics{
+		UserIDs:         userIDs,
+		ActiveUsers:     activeUsers,
+		TotalAnswers:    totalAnswers,
+		AverageAccuracy: averageAccuracy,
+		ErrorPatterns:   errorPatterns,
+		DifficultyStats: difficultyStats,
+	}
+	if !from.IsZero() {
+		result.From = from.Format(time.RFC3339)
+	}
+	if !to.IsZero() {
+		result.To = to.Format(time.RFC3339)
+	}
+
+	return result, nil
+}
+
+// GetPairAnalytics returns the global error rate per letter pair across every user's answers, hardest first.
+// Known pairs without any answers are listed last with a zero sample size so authors see the gaps too.
+func (u *dyslexiaQuestionUsecase) GetPairAnalytics(ctx context.Context, difficulty entity.Difficulty) (*entity.PairAnalytics, error) {
+	rows, err := u.cfg.Repository.AggregateLetterPairStats(u.dbWithContext(ctx), string(difficulty))
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate letter pairs: %w", err)
+	}
+
+	result := &entity.PairAnalytics{Difficulty: difficulty, Pairs: make([]entity.ErrorPattern, 0, len(rows))}
+	seen := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		if row.Total == 0 {
+			continue
+		}
+		seen[row.LetterPair] = true
+		result.TotalAnswers += row.Total
+		result.Pairs = append(result.Pairs, entity.ErrorPattern{
+			LetterPair: row.LetterPair,
+			ErrorCount: row.Errors,
+			TotalCount: row.Total,
+			ErrorRate:  fmt.Sprintf("%.1f%%", float64(row.Errors)/float64(row.Total)*100),
+		})
+	}
+	sortErrorPatterns(result.Pairs)
+
+	for _, pair := range allLetterPairs {
+		if !seen[pair] {
+			result.Pairs = append(result.Pairs, entity.ErrorPattern{LetterPair: pair, ErrorRate: "0.0%"})
+		}
+	}
+
+	return result, nil
+}
+
+// GetAnswerDistribution counts how often each option of a question was chosen. Every option is listed,
+// including ones nobody picked; answers outside the options (typed answers) are listed with in_options false.
+// Wrong answers follow the stored is_correct grade, so fuzzy matches and regrades count as graded.
+func (u *dyslexiaQuestionUsecase) GetAnswerDistribution(ctx context.Context, questionID string) (*entity.AnswerDistribution, error) {
+	q, err := u.cfg.Repository.FindGeneratedByQuestionID(u.dbWithContext(ctx), questionID)
+	if err != nil {
+		return nil, fmt.Errorf("question not found: %w", err)
+	}
+
+	rows, err := u.cfg.Repository.AggregateAnswersByQuestion(u.dbWithContext(ctx), questionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate answers: %w", err)
+	}
+
+	var options []string
+	if err := json.Unmarshal([]byte(q.Options), &options); err != nil {
+		return nil, fmt.Errorf("failed to parse options of question %s: %w", questionID, err)
+	}
+
+	correct := strings.ToUpper(strings.TrimSpace(q.CorrectAnswer))
+	result := &entity.AnswerDistribution{
+		QuestionID:    questionID,
+		CorrectAnswer: q.CorrectAnswer,
+		Options:       make([]entity.OptionCount, 0, len(options)),
+	}
+
+	index := make(map[string]int, len(options))
+	for _, option := range options {
+		key := strings.ToUpper(strings.TrimSpace(option))
+		if _, ok := index[key]; ok {
+			continue
+		}
+		index[key] = len(result.Options)
+		result.Options = append(result.Options, entity.OptionCount{Option: option, IsCorrect: key == correct, InOptions: true})
+	}
+
+	for _, row := range rows {
+		result.TotalAnswers += row.Total
+		if row.Skipped {
+			result.SkippedAnswers += row.Total
+			continue
+		}
+		if !row.IsCorrect {
+			result.WrongAnswers += row.Total
+		}
+
+		i, ok := index[row.Answer]
+		if !ok {
+			i = len(result.Options)
+			index[row.Answer] = i
+			result.Options = append(result.Options, entity.OptionCount{Option: row.Answer})
+		}
+		result.Options[i].Count += row.Total
+		if !result.Options[i].InOptions && row.IsCorrect {
+			result.Options[i].IsCorrect = true
+		}
+	}
+
+	sort.SliceStable(result.Options, func(i, j int) bool {
+		return result.Options[i].Count > result.Options[j].Count
+	})
+
+	result.ErrorRate = "0.0%"
+	if graded := result.TotalAnswers - result.SkippedAnswers; graded > 0 {
+		result.ErrorRate = fmt.Sprintf("%.1f%%", float64(result.WrongAnswers)/float64(graded)*100)
+	}
+
+	return result, nil
+}
+
+// GetUserTrends returns per-session accuracy for each letter pair (or only pair), oldest session first
+func (u *dyslexiaQuestionUsecase) GetUserTrends(ctx context.Context, userID string, pair string, from, to time.Time) ([]entity.LetterPairTrend, error) {
+	if pair != "" {
+		patterns, err := validatePatterns([]string{pair})
+		if err != nil {
+			return nil, err
+		}
+		pair = patterns[0]
+	}
+
+	rows, err := u.cfg.Repository.AggregateLetterPairTrendByUser(u.dbWithContext(ctx), userID, pair, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate trends: %w", err)
+	}
+
+	// Rows are chronological; group by pair keeping first-seen pair order
+	trends := []entity.LetterPairTrend{}
+	index := make(map[string]int)
+	for _, row := range rows {
+		if row.Total == 0 {
+			continue
+		}
+		i, ok := index[row.LetterPair]
+		if !ok {
+			i = len(trends)
+			index[row.LetterPair] = i
+			trends = append(trends, entity.LetterPairTrend{LetterPair: row.LetterPair, Points: []entity.TrendPoint{}})
+		}
+		trends[i].Points = append(trends[i].Points, entity.TrendPoint{
+			SessionID:    row.SessionID,
+			StartedAt:    row.StartedAt.Format(time.RFC3339),
+			TotalCount:   row.Total,
+			CorrectCount: row.Correct,
+			AccuracyRate: fmt.Sprintf("%.1f%%", float64(row.Correct)/float64(row.Total)*100),
+		})
+	}
+
+	return trends, nil
+}
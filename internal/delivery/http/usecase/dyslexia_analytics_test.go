@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+// Accuracy is averaged per user, so the busy user-1 does not outweigh the others
+func TestGetCohortAnalytics(t *testing.T) {
+	day := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	answer := func(user, question, difficulty string, correct bool, at time.Time) internalEntity.UserAnswer {
+		return internalEntity.UserAnswer{UserID: user, QuestionID: question, Difficulty: difficulty, IsCorrect: correct, AnsweredAt: at}
+	}
+	repo := newFakeRepo()
+	repo.questions["q-bd"] = &internalEntity.GeneratedQuestion{QuestionID: "q-bd", TargetLetterPair: "b-d"}
+	repo.questions["q-pq"] = &internalEntity.GeneratedQuestion{QuestionID: "q-pq", TargetLetterPair: "p-q"}
+	repo.answers = []internalEntity.UserAnswer{
+		// user-1: 3 of 4 correct (75%)
+		answer("user-1", "q-bd", "easy", true, day),
+		answer("user-1", "q-bd", "easy", true, day),
+		answer("user-1", "q-bd", "easy", false, day),
+		answer("user-1", "q-pq", "medium", true, day),
+		// user-2: 1 of 2 correct (50%)
+		answer("user-2", "q-bd", "easy", false, day),
+		answer("user-2", "q-pq", "medium", true, day),
+		// user-3: 1 of 1 correct (100%), plus one answer outside the range
+		answer("user-3", "q-pq", "hard", true, day),
+		answer("user-3", "q-pq", "hard", false, day.AddDate(0, -1, 0)),
+	}
+	u := newTestUsecase(t, repo)
+
+	got, err := u.GetCohortAnalytics(context.Background(), []string{"user-1", "user-2", "user-3"}, day.AddDate(0, 0, -1), day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("GetCohortAnalytics: %v", err)
+	}
+	if got.ActiveUsers != 3 || got.TotalAnswers != 7 || got.AverageAccuracy != "75.0%" {
+		t.Errorf("cohort = %d users, %d answers, %s accuracy; want 3, 7, 75.0%%", got.ActiveUsers, got.TotalAnswers, got.AverageAccuracy)
+	}
+
+	wantPatterns := map[string][2]int{"b-d": {2, 4}, "p-q": {0, 3}}
+	if len(got.ErrorPatterns) != len(wantPatterns) {
+		t.Fatalf("error patterns = %+v, want %d pairs", got.ErrorPatterns, len(wantPatterns))
+	}
+	for _, p := range got.ErrorPatterns {
+		if want := wantPatterns[p.LetterPair]; p.ErrorCount != want[0] || p.TotalCount != want[1] {
+			t.Errorf("%s = %d/%d errors, want %d/%d", p.LetterPair, p.ErrorCount, p.TotalCount, want[0], want[1])
+		}
+	}
+	if got.DifficultyStats["easy"] != 4 || got.DifficultyStats["medium"] != 2 || got.DifficultyStats["hard"] != 1 {
+		t.Errorf("difficulty stats = %v, want easy 4, medium 2, hard 1", got.DifficultyStats)
+	}
+}
+
+// Users without answers in range neither count as active nor drag the average down
+func TestGetCohortAnalyticsEmpty(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+
+	got, err := u.GetCohortAnalytics(context.Background(), []string{"user-1", "user-2"}, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("GetCohortAnalytics: %v", err)
+	}
+	if got.ActiveUsers != 0 || got.TotalAnswers != 0 || got.AverageAccuracy != "0.0%" || len(got.ErrorPatterns) != 0 {
+		t.Errorf("empty cohort = %+v, want zero totals", got)
+	}
+
+	if _, err := u.GetCohortAnalytics(context.Background(), nil, time.Time{}, time.Time{}); err == nil {
+		t.Error("GetCohortAnalytics without users succeeded")
+	}
+}
+
+// Error rates are pooled across every user's answers; pairs without answers come last with no samples
+func TestGetPairAnalytics(t *testing.T) {
+	answer := func(user, question, difficulty string, correct bool) internalEntity.UserAnswer {
+		return internalEntity.UserAnswer{UserID: user, QuestionID: question, Difficulty: difficulty, IsCorrect: correct}
+	}
+	repo := newFakeRepo()
+	repo.questions["q-bd"] = &internalEntity.GeneratedQuestion{QuestionID: "q-bd", TargetLetterPair: "b-d"}
+	repo.questions["q-pq"] = &internalEntity.GeneratedQuestion{QuestionID: "q-pq", TargetLetterPair: "p-q"}
+	repo.questions["q-mw"] = &internalEntity.GeneratedQuestion{QuestionID: "q-mw", TargetLetterPair: "m-w"}
+	repo.answers = []internalEntity.UserAnswer{
+		answer("user-1", "q-bd", "easy", false),
+		answer("user-1", "q-bd", "easy", true),
+		answer("user-2", "q-bd", "hard", false),
+		answer("user-2", "q-bd", "hard", false),
+		answer("user-1", "q-pq", "easy", true),
+		answer("user-3", "q-pq", "hard", false),
+		answer("user-3", "q-mw", "easy", true),
+		answer("user-3", "q-mw", "easy", true),
+	}
+	u := newTestUsecase(t, repo)
+
+	type pairStat struct {
+		pair          string
+		errors, total int
+		rate          string
+	}
+	tests := []struct {
+		name       string
+		difficulty entity.Difficulty
+		wantTotal  int
+		want       []pairStat
+	}{
+		{name: "all difficulties", wantTotal: 8, want: []pairStat{
+			{"b-d", 3, 4, "75.0%"}, {"p-q", 1, 2, "50.0%"}, {"m-w", 0, 2, "0.0%"}, {"n-u", 0, 0, "0.0%"}, {"m-n", 0, 0, "0.0%"},
+		}},
+		{name: "hard only", difficulty: entity.DifficultyHard, wantTotal: 3, want: []pairStat{
+			{"b-d", 2, 2, "100.0%"}, {"p-q", 1, 1, "100.0%"}, {"m-w", 0, 0, "0.0%"}, {"n-u", 0, 0, "0.0%"}, {"m-n", 0, 0, "0.0%"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.GetPairAnalytics(context.Background(), tt.difficulty)
+			if err != nil {
+				t.Fatalf("GetPairAnalytics: %v", err)
+			}
+			if got.TotalAnswers != tt.wantTotal || got.Difficulty != tt.difficulty {
+				t.Errorf("analytics = %d answers for %q, want %d for %q", got.TotalAnswers, got.Difficulty, tt.wantTotal, tt.difficulty)
+			}
+			var stats []pairStat
+			for _, p := range got.Pairs {
+				stats = append(stats, pairStat{p.LetterPair, p.ErrorCount, p.TotalCount, p.ErrorRate})
+			}
+			if !slices.Equal(stats, tt.want) {
+				t.Errorf("pairs = %+v, want %+v", stats, tt.want)
+			}
+		})
+	}
+}
+
+// Every option is listed with its count, typed answers outside the options included; wrong answers follow
+// the stored grade and skips count toward the total but not the error rate
+func TestGetAnswerDistribution(t *testing.T) {
+	answer := func(text string, correct, skipped bool) internalEntity.UserAnswer {
+		return internalEntity.UserAnswer{QuestionID: "q-1", UserAnswer: text, IsCorrect: correct, Skipped: skipped}
+	}
+	repo := newFakeRepo()
+	repo.questions["q-1"] = &internalEntity.GeneratedQuestion{QuestionID: "q-1", Options: `["bola","dola","pola"]`, CorrectAnswer: "bola"}
+	repo.answers = []internalEntity.UserAnswer{
+		answer("bola", true, false),
+		answer(" BOLA", true, false),
+		answer("dola", false, false),
+		answer("dola", false, false),
+		answer("Dola", false, false),
+		answer("bolla", true, false), // typo accepted by fuzzy grading
+		answer("", false, true),
+		{QuestionID: "q-2", UserAnswer: "pola"},
+	}
+	u := newTestUsecase(t, repo)
+
+	got, err := u.GetAnswerDistribution(context.Background(), "q-1")
+	if err != nil {
+		t.Fatalf("GetAnswerDistribution: %v", err)
+	}
+	if got.TotalAnswers != 7 || got.WrongAnswers != 3 || got.SkippedAnswers != 1 || got.ErrorRate != "50.0%" {
+		t.Errorf("totals = %d/%d wrong/%d skipped, rate %s; want 7/3/1, 50.0%%", got.TotalAnswers, got.WrongAnswers, got.SkippedAnswers, got.ErrorRate)
+	}
+
+	want := []entity.OptionCount{
+		{Option: "dola", Count: 3, InOptions: true},
+		{Option: "bola", Count: 2, IsCorrect: true, InOptions: true},
+		{Option: "BOLLA", Count: 1, IsCorrect: true},
+		{Option: "pola", Count: 0, InOptions: true},
+	}
+	if !slices.Equal(got.Options, want) {
+		t.Errorf("options = %+v, want %+v", got.Options, want)
+	}
+
+	if _, err := u.GetAnswerDistribution(context.Background(), "missing"); err == nil {
+		t.Error("distribution of an unknown question succeeded")
+	}
+}
+
+// Each pair gets one point per session, oldest session first, filtered by pair and date range
+func TestGetUserTrends(t *testing.T) {
+	week1 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	week2 := week1.AddDate(0, 0, 7)
+	week3 := week1.AddDate(0, 0, 14)
+	answer := func(session, question string, correct bool, at time.Time) internalEntity.UserAnswer {
+		return internalEntity.UserAnswer{UserID: "user-1", SessionID: session, QuestionID: question, IsCorrect: correct, AnsweredAt: at}
+	}
+	repo := newFakeRepo()
+	repo.questions["q-bd"] = &internalEntity.GeneratedQuestion{QuestionID: "q-bd", TargetLetterPair: "b-d"}
+	repo.questions["q-pq"] = &internalEntity.GeneratedQuestion{QuestionID: "q-pq", TargetLetterPair: "p-q"}
+	repo.answers = []internalEntity.UserAnswer{
+		// Stored out of order: the series must follow the session dates
+		answer("sess-3", "q-bd", true, week3),
+		answer("sess-3", "q-bd", true, week3.Add(time.Minute)),
+		answer("sess-1", "q-bd", false, week1),
+		answer("sess-1", "q-bd", true, week1.Add(time.Minute)),
+		answer("sess-1", "q-pq", false, week1.Add(2*time.Minute)),
+		answer("sess-2", "q-bd", false, week2),
+		answer("sess-2", "q-bd", true, week2.Add(time.Minute)),
+		answer("sess-2", "q-bd", true, week2.Add(2*time.Minute)),
+		{UserID: "user-2", SessionID: "sess-x", QuestionID: "q-bd", AnsweredAt: week2},
+	}
+	u := newTestUsecase(t, repo)
+
+	tests := []struct {
+		name     string
+		pair     string
+		from, to time.Time
+		want     map[string][]string // pair -> "session:accuracy" in order
+	}{
+		{name: "all pairs", want: map[string][]string{
+			"b-d": {"sess-1:50.0%", "sess-2:66.7%", "sess-3:100.0%"},
+			"p-q": {"sess-1:0.0%"},
+		}},
+		{name: "one pair", pair: "B-D", want: map[string][]string{"b-d": {"sess-1:50.0%", "sess-2:66.7%", "sess-3:100.0%"}}},
+		{name: "date range", pair: "b-d", from: week2.AddDate(0, 0, -1), to: week2.AddDate(0, 0, 1), want: map[string][]string{"b-d": {"sess-2:66.7%"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trends, err := u.GetUserTrends(context.Background(), "user-1", tt.pair, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("GetUserTrends: %v", err)
+			}
+			got := map[string][]string{}
+			for _, trend := range trends {
+				for _, p := range trend.Points {
+					got[trend.LetterPair] = append(got[trend.LetterPair], p.SessionID+":"+p.AccuracyRate)
+				}
+			}
+			if !maps.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("trends = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := u.GetUserTrends(context.Background(), "user-1", "x-y", time.Time{}, time.Time{}); err == nil {
+		t.Error("unknown pair accepted")
+	}
+}
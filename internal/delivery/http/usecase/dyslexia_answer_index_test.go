@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+func TestOptionAt(t *testing.T) {
+	options := []string{"BOLA", "DOLA", "BELA"}
+	tests := []struct {
+		index   int
+		want    string
+		wantErr bool
+	}{
+		{index: 0, want: "BOLA"},
+		{index: 2, want: "BELA"},
+		{index: 3, wantErr: true},
+		{index: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := optionAt(options, tt.index)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("optionAt(%d) = %q, %v; want %q, error %v", tt.index, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	_, err := optionAt(nil, 0)
+	if err == nil || err.Error() != "answer_index 0 is out of range (question has 0 options)" {
+		t.Errorf("optionAt on a question without options: %v", err)
+	}
+}
+
+// An index is graded as the option text it points at; the text path grades the same way
+func TestSubmitAnswerIndex(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         entity.SubmitAnswerRequest
+		wantCorrect bool
+		wantAnswer  string
+		wantErr     bool
+	}{
+		{name: "correct index", req: entity.SubmitAnswerRequest{AnswerIndex: intPtr(0)}, wantCorrect: true, wantAnswer: "BOLA"},
+		{name: "wrong index", req: entity.SubmitAnswerRequest{AnswerIndex: intPtr(1)}, wantAnswer: "DOLA"},
+		{name: "out of range index", req: entity.SubmitAnswerRequest{AnswerIndex: intPtr(4)}, wantErr: true},
+		{name: "text", req: entity.SubmitAnswerRequest{Answer: "bola"}, wantCorrect: true, wantAnswer: "bola"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.questions["q-1"] = &internalEntity.GeneratedQuestion{
+				QuestionID: "q-1", Difficulty: "easy", TargetLetterPair: "b-d",
+				Options: `["BOLA","DOLA","BELA","POLA"]`, CorrectAnswer: "BOLA",
+			}
+			u := newTestUsecase(t, repo)
+
+			req := tt.req
+			req.UserID, req.SessionID, req.QuestionID = "user-1", "sess-1", "q-1"
+			resp, err := u.SubmitAnswer(context.Background(), req)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("SubmitAnswer accepted %+v", req)
+				}
+				if len(repo.answers) != 0 {
+					t.Errorf("rejected answer was stored: %+v", repo.answers)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SubmitAnswer: %v", err)
+			}
+			if resp.IsCorrect != tt.wantCorrect || resp.UserAnswer != tt.wantAnswer {
+				t.Errorf("graded %q correct=%v, want %q correct=%v", resp.UserAnswer, resp.IsCorrect, tt.wantAnswer, tt.wantCorrect)
+			}
+		})
+	}
+}
+
+func TestSetCorrectIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  string
+		options []string
+		want    *int
+	}{
+		{name: "found", answer: "bola", options: []string{"DOLA", "BOLA"}, want: intPtr(1)},
+		{name: "hidden answer", answer: "", options: []string{"DOLA", "BOLA"}},
+		{name: "not among options", answer: "kata", options: []string{"DOLA", "BOLA"}},
+		{name: "padded answer and option", answer: " Bola ", options: []string{"DOLA", " bola "}, want: intPtr(1)},
+		{name: "first match wins", answer: "bola", options: []string{"BOLA", "DOLA", "bola"}, want: intPtr(0)},
+		{name: "blank answer", answer: "  ", options: []string{"  "}},
+		{name: "no options", answer: "bola"},
+	}
+	for _, tt := range tests {
+		q := entity.GeneratedQuestion{Answer: tt.answer, Options: tt.options, CorrectIndex: intPtr(9)}
+		setCorrectIndex(&q)
+		if (q.CorrectIndex == nil) != (tt.want == nil) || (q.CorrectIndex != nil && *q.CorrectIndex != *tt.want) {
+			t.Errorf("%s: correct_index = %v, want %v", tt.name, q.CorrectIndex, tt.want)
+		}
+	}
+}
+
+// Generated questions carry correct_index into their shuffled options only when the answer is included
+func TestGenerateCorrectIndex(t *testing.T) {
+	for _, includeAnswer := range []bool{true, false} {
+		repo := newFakeRepo()
+		for i, word := range []string{"BOLA", "BELA", "BODO"} {
+			id := fmt.Sprintf("q-%d", i)
+			repo.questions[id] = &internalEntity.GeneratedQuestion{QuestionID: id, Difficulty: "easy", TargetLetterPair: "b-d",
+				Options: fmt.Sprintf(`[%q,"DOLA","DELA","DODO"]`, word), CorrectAnswer: word}
+		}
+		u := newTestUsecase(t, repo)
+		u.cfg.Config.Set("dyslexia.allow_include_answer", true)
+
+		questions, _, err := u.Generate(context.Background(), entity.GenerateOptions{
+			Difficulty: entity.DifficultyEasy, Count: 3, IncludeAnswer: includeAnswer, Patterns: []string{"b-d"}, Shuffle: true,
+		})
+		if err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+		for _, q := range questions {
+			if !includeAnswer {
+				if q.CorrectIndex != nil {
+					t.Errorf("question %s exposes correct_index %d with the answer hidden", q.ID, *q.CorrectIndex)
+				}
+				continue
+			}
+			if q.Answer == "" || q.CorrectIndex == nil || q.Options[*q.CorrectIndex] != q.Answer {
+				t.Errorf("question %s: correct_index %v does not point at %q in %v", q.ID, q.CorrectIndex, q.Answer, q.Options)
+			}
+		}
+	}
+}
+
+// answer_index must resolve against the options as served to the session, not the stored order
+func TestSubmitAnswerIndexUsesServedOrder(t *testing.T) {
+	repo := newFakeRepo()
+	repo.questions["q-1"] = &internalEntity.GeneratedQuestion{
+		QuestionID: "q-1", Difficulty: "easy", TargetLetterPair: "b-d",
+		Options: `["BOLA","DOLA","BELA","POLA"]`, CorrectAnswer: "BOLA",
+	}
+	u := newTestUsecase(t, repo)
+
+	served := entity.GeneratedQuestion{ID: "q-1", Options: []string{"POLA", "DOLA", "BELA", "BOLA"}}
+	u.logServedQuestions("sess-1", []entity.GeneratedQuestion{served})
+
+	resp, err := u.SubmitAnswer(context.Background(), entity.SubmitAnswerRequest{
+		UserID: "user-1", SessionID: "sess-1", QuestionID: "q-1", AnswerIndex: intPtr(3),
+	})
+	if err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+	if !resp.IsCorrect {
+		t.Fatalf("tap on the served BOLA graded wrong: answered %q", resp.UserAnswer)
+	}
+	if resp.Options[3] != "BOLA" {
+		t.Errorf("response options %v are not in served order", resp.Options)
+	}
+
+	// A question the session was never served resolves against the stored order
+	resp, err = u.SubmitAnswer(context.Background(), entity.SubmitAnswerRequest{
+		UserID: "user-1", SessionID: "sess-2", QuestionID: "q-1", AnswerIndex: intPtr(0),
+	})
+	if err != nil || !resp.IsCorrect {
+		t.Errorf("unserved question should resolve against the stored order: %+v, %v", resp, err)
+	}
+}
+
+// A served question's correct_index, echoed back as answer_index, must grade correct even though the
+// options were shuffled on serve and differ from the stored order
+func TestCorrectIndexRoundTripsAsAnswerIndex(t *testing.T) {
+	repo := newFakeRepo()
+	repo.questions["q-1"] = &internalEntity.GeneratedQuestion{
+		QuestionID: "q-1", Difficulty: "easy", TargetLetterPair: "b-d",
+		Options: `["BOLA","DOLA","BELA","POLA"]`, CorrectAnswer: "BOLA",
+	}
+	u := newTestUsecase(t, repo)
+
+	served := entity.GeneratedQuestion{ID: "q-1", Answer: "BOLA", Options: []string{"POLA", "DOLA", "BELA", "BOLA"}}
+	setCorrectIndex(&served)
+	u.logServedQuestions("sess-1", []entity.GeneratedQuestion{served})
+
+	resp, err := u.SubmitAnswer(context.Background(), entity.SubmitAnswerRequest{
+		UserID: "user-1", SessionID: "sess-1", QuestionID: "q-1", AnswerIndex: served.CorrectIndex,
+	})
+	if err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+	if !resp.IsCorrect {
+		t.Fatalf("correct_index %d graded wrong: answered %q", *served.CorrectIndex, resp.UserAnswer)
+	}
+	if resp.Options[*served.CorrectIndex] != "BOLA" {
+		t.Errorf("response options %v are not in served order", resp.Options)
+	}
+}
+
+func intPtr(v int) *int { return &v }
@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/sirupsen/logrus"
+)
+
+// Defaults for POST /admin/questions/bulk-generate (questions.bulk_generate.*)
+const (
+	defaultBulkGenerateConcurrency = 4
+	defaultBulkGenerateMaxLLMCalls = 500
+	defaultBulkGenerateTimeout     = 5 * time.Minute
+)
+
+// bulkGenerateJob is one question to generate for a (difficulty, pair) target
+type bulkGenerateJob struct {
+	target     int // index into the result's targets
+	difficulty entity.Difficulty
+	letterPair string
+}
+
+// BulkGenerate pre-populates the question cache: for every target it generates count questions with the AI
+// and saves them before returning. Targets default to every difficulty and letter pair with count_per_target.
+// At most questions.bulk_generate.max_llm_calls questions are attempted and the call runs synchronously for at
+// most questions.bulk_generate.timeout_seconds; jobs beyond the budget, and everything left when the timeout
+// hits or ctx is cancelled, are never scheduled and are reported as skipped.
+func (u *dyslexiaQuestionUsecase) BulkGenerate(ctx context.Context, req entity.BulkGenerateRequest) (*entity.BulkGenerateResult, error) {
+	if u.cfg.Gemini == nil {
+		return nil, fmt.Errorf("%w: gemini client not configured", ErrUpstream)
+	}
+
+	targets, err := bulkGenerateTargets(req, u.difficulties)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &entity.BulkGenerateResult{Targets: make([]entity.BulkGenerateTargetResult, len(targets))}
+	var jobs []bulkGenerateJob
+	for i, target := range targets {
+		result.Targets[i] = entity.BulkGenerateTargetResult{
+			Difficulty:       target.Difficulty,
+			TargetLetterPair: target.TargetLetterPair,
+			Requested:        target.Count,
+		}
+		result.Requested += target.Count
+		for n := 0; n < target.Count; n++ {
+			jobs = append(jobs, bulkGenerateJob{target: i, difficulty: target.Difficulty, letterPair: target.TargetLetterPair})
+		}
+	}
+
+	concurrency := defaultBulkGenerateConcurrency
+	if n := u.cfg.Config.GetInt("questions.bulk_generate.concurrency"); n > 0 {
+		concurrency = n
+	}
+	budget := defaultBulkGenerateMaxLLMCalls
+	if u.cfg.Config.IsSet("questions.bulk_generate.max_llm_calls") {
+		budget = max(u.cfg.Config.GetInt("questions.bulk_generate.max_llm_calls"), 0)
+	}
+	if len(jobs) > budget {
+		jobs = jobs[:budget] // the rest are counted as skipped below
+	}
+	timeout := defaultBulkGenerateTimeout
+	if n := u.cfg.Config.GetInt("questions.bulk_generate.timeout_seconds"); n > 0 {
+		timeout = time.Duration(n) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	generatedBy := u.generatedBy(ctx)
+	var mu sync.Mutex
+	runBounded(ctx, len(jobs), concurrency, func(index int) {
+		job := jobs[index]
+		err := u.bulkGenerateOne(ctx, job, generatedBy)
+
+		mu.Lock()
+		defer mu.Unlock()
+		target := &result.Targets[job.target]
+		if err != nil {
+			u.logger().Warnf("bulk generate %s/%s failed: %v", job.difficulty, job.letterPair, err)
+			target.Failed++
+			result.Failed++
+			return
+		}
+		target.Created++
+		result.Created++
+	})
+
+	for i := range result.Targets {
+		target := &result.Targets[i]
+		target.Skipped = target.Requested - target.Created - target.Failed
+	}
+	result.Skipped = result.Requested - result.Created - result.Failed
+
+	u.logger().WithFields(logrus.Fields{
+		"event":     "bulk_generate",
+		"targets":   len(targets),
+		"requested": result.Requested,
+		"created":   result.Created,
+		"failed":    result.Failed,
+		"skipped":   result.Skipped,
+		"cancelled": ctx.Err() != nil,
+	}).Info("bulk generate finished")
+	return result, nil
+}
+
+// bulkGenerateOne generates one AI question and saves it right away, so the summary only counts persisted ones
+func (u *dyslexiaQuestionUsecase) bulkGenerateOne(ctx context.Context, job bulkGenerateJob, generatedBy string) error {
+	q, prompt, err := u.generateFromAI(ctx, job.difficulty, job.letterPair, true, true)
+	if err != nil {
+		return err
+	}
+	return u.saveGeneratedToDB(ctx, q, job.letterPair, prompt, generatedBy)
+}
+
+// bulkGenerateTargets validates explicit targets or expands the default matrix of every level and pair
+func bulkGenerateTargets(req entity.BulkGenerateRequest, levels []entity.DifficultyLevel) ([]entity.BulkGenerateTarget, error) {
+	if len(req.Targets) == 0 {
+		if req.CountPerTarget <= 0 {
+			return nil, fmt.Errorf("%w: targets or count_per_target is required", ErrInvalidInput)
+		}
+		var targets []entity.BulkGenerateTarget
+		for _, level := range levels {
+			for _, pair := range allLetterPairs {
+				targets = append(targets, entity.BulkGenerateTarget{Difficulty: level.Name, TargetLetterPair: pair, Count: req.CountPerTarget})
+			}
+		}
+		return targets, nil
+	}
+
+	targets := make([]entity.BulkGenerateTarget, len(req.Targets))
+	for i, target := range req.Targets {
+		patterns, rejected := splitPatterns([]string{target.TargetLetterPair})
+		if len(rejected) > 0 {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, invalidPatternsError(rejected))
+		}
+		if len(patterns) == 0 {
+			return nil, fmt.Errorf("%w: target_letter_pair is required", ErrInvalidInput)
+		}
+		target.TargetLetterPair = patterns[0]
+		targets[i] = target
+	}
+	return targets, nil
+}
@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+)
+
+// BulkGenerate never schedules jobs past the call budget or the deadline, and reports them as skipped
+func TestBulkGenerateMatrix(t *testing.T) {
+	tests := []struct {
+		name        string
+		targets     []entity.BulkGenerateTarget
+		budget      int
+		status      int
+		cancelled   bool
+		wantCalls   int64
+		wantCreated int
+		wantFailed  int
+		wantSkipped int
+	}{
+		{
+			name:      "every job created",
+			targets:   []entity.BulkGenerateTarget{{Difficulty: "easy", TargetLetterPair: "b-d", Count: 2}, {Difficulty: "easy", TargetLetterPair: "p-q", Count: 1}},
+			status:    http.StatusOK,
+			wantCalls: 3, wantCreated: 3,
+		},
+		{
+			name:      "budget caps scheduled jobs",
+			targets:   []entity.BulkGenerateTarget{{Difficulty: "easy", TargetLetterPair: "b-d", Count: 5}},
+			budget:    2,
+			status:    http.StatusOK,
+			wantCalls: 2, wantCreated: 2, wantSkipped: 3,
+		},
+		{
+			name:      "zero budget schedules nothing",
+			targets:   []entity.BulkGenerateTarget{{Difficulty: "easy", TargetLetterPair: "b-d", Count: 2}},
+			budget:    -1,
+			status:    http.StatusOK,
+			wantCalls: 0, wantSkipped: 2,
+		},
+		{
+			name:      "llm failures are counted",
+			targets:   []entity.BulkGenerateTarget{{Difficulty: "easy", TargetLetterPair: "b-d", Count: 2}},
+			status:    http.StatusBadRequest,
+			wantCalls: 2, wantFailed: 2,
+		},
+		{
+			name:      "cancelled request skips everything",
+			targets:   []entity.BulkGenerateTarget{{Difficulty: "easy", TargetLetterPair: "b-d", Count: 3}},
+			status:    http.StatusOK,
+			cancelled: true,
+			wantCalls: 0, wantSkipped: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newTestUsecase(t, newFakeRepo())
+			u.cfg.PromptTemplate = defaultPromptTemplate
+			switch {
+			case tt.budget > 0:
+				u.cfg.Config.Set("questions.bulk_generate.max_llm_calls", tt.budget)
+			case tt.budget < 0:
+				u.cfg.Config.Set("questions.bulk_generate.max_llm_calls", 0)
+			}
+			words := []string{"dadu", "bola", "buku", "dari", "bata", "pagi"}
+			var n atomic.Int64
+			var fake *fakeLLM
+			u.cfg.Gemini, fake = newFakeLLM(t, func(string) (string, int) {
+				word := words[int(n.Add(1)-1)%len(words)]
+				return fmt.Sprintf(`{"correctAnswer":%q,"options":[%q,"badu","dabu","babu"]}`, word, word), tt.status
+			})
+
+			ctx := context.Background()
+			if tt.cancelled {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				cancel()
+			}
+			result, err := u.BulkGenerate(ctx, entity.BulkGenerateRequest{Targets: tt.targets})
+			if err != nil {
+				t.Fatalf("BulkGenerate: %v", err)
+			}
+			if got := fake.calls.Load(); got != tt.wantCalls {
+				t.Errorf("LLM calls = %d, want %d", got, tt.wantCalls)
+			}
+			if result.Created != tt.wantCreated || result.Failed != tt.wantFailed || result.Skipped != tt.wantSkipped {
+				t.Errorf("created/failed/skipped = %d/%d/%d, want %d/%d/%d",
+					result.Created, result.Failed, result.Skipped, tt.wantCreated, tt.wantFailed, tt.wantSkipped)
+			}
+			perTarget := 0
+			for _, target := range result.Targets {
+				perTarget += target.Created + target.Failed + target.Skipped
+			}
+			if perTarget != result.Requested {
+				t.Errorf("per-target totals = %d, want requested %d", perTarget, result.Requested)
+			}
+		})
+	}
+}
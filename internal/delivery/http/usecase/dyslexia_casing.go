@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+)
+
+// Casing policies for served options and correct answers (questions.options.case)
+const (
+	OptionCaseUpper   = "upper"   // default: matches the question bank words
+	OptionCaseLower   = "lower"   // every word lowercase
+	OptionCaseNatural = "natural" // as written by the source (lowercase common nouns, capitalized proper nouns)
+)
+
+func ParseOptionCase(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case OptionCaseUpper:
+		return OptionCaseUpper, nil
+	case OptionCaseLower:
+		return OptionCaseLower, nil
+	case OptionCaseNatural:
+		return OptionCaseNatural, nil
+	default:
+		return "", fmt.Errorf("unknown option case %q (supported: %s, %s, %s)", s, OptionCaseUpper, OptionCaseLower, OptionCaseNatural)
+	}
+}
+
+// applyOptionCase returns word in the casing of policy
+func applyOptionCase(policy string, word string) string {
+	word = strings.TrimSpace(word)
+	switch policy {
+	case OptionCaseUpper:
+		return strings.ToUpper(word)
+	case OptionCaseLower:
+		return strings.ToLower(word)
+	default:
+		return word
+	}
+}
+
+// caseQuestion applies the casing policy to the options and answer of a served question, whatever its source
+// (AI, DB cache, fallback words, templates), so clients always see one style. The options are copied, as
+// the original slice may still be queued for the background save.
+func (u *dyslexiaQuestionUsecase) caseQuestion(q *entity.GeneratedQuestion) {
+	options := make([]string, len(q.Options))
+	for i, opt := range q.Options {
+		options[i] = applyOptionCase(u.optionCase, opt)
+	}
+	q.Options = options
+	if q.Answer != "" {
+		q.Answer = applyOptionCase(u.optionCase, q.Answer)
+	}
+}
+
+// optionCasePrompt is the casing instruction given to the LLM, so generated words already match the policy
+func optionCasePrompt(policy string) string {
+	switch policy {
+	case OptionCaseUpper:
+		return "Write the correct answer and ALL options in UPPERCASE"
+	case OptionCaseLower:
+		return "Write the correct answer and ALL options in lowercase"
+	default:
+		return "Use NATURAL capitalization for the correct answer and all options (lowercase for common nouns, capitalize proper nouns)"
+	}
+}
+
+// optionCaseExample renders the prompt's JSON example in the casing of policy
+func optionCaseExample(policy string, correct string, options ...string) string {
+	cased := make([]string, len(options))
+	for i, opt := range options {
+		cased[i] = fmt.Sprintf("%q", applyOptionCase(policy, opt))
+	}
+	return fmt.Sprintf(`{"correctAnswer":%q,"options":[%s]}`, applyOptionCase(policy, correct), strings.Join(cased, ","))
+}
+
+// answersMatch compares a submitted answer with the correct answer. Casing never affects grading: both sides
+// are trimmed and case-folded, whichever questions.options.case policy the options were served in.
+func answersMatch(answer string, correct string) bool {
+	return strings.EqualFold(strings.TrimSpace(answer), strings.TrimSpace(correct))
+}
@@ -0,0 +1,242 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+	"github.com/spf13/viper"
+)
+
+func TestParseOptionCase(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "upper", want: OptionCaseUpper},
+		{in: " Lower ", want: OptionCaseLower},
+		{in: "NATURAL", want: OptionCaseNatural},
+		{in: "title", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseOptionCase(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseOptionCase(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestApplyOptionCase(t *testing.T) {
+	tests := []struct {
+		policy string
+		word   string
+		want   string
+	}{
+		{policy: OptionCaseUpper, word: "bola", want: "BOLA"},
+		{policy: OptionCaseUpper, word: " Dadu ", want: "DADU"},
+		{policy: OptionCaseLower, word: "BOLA", want: "bola"},
+		{policy: OptionCaseLower, word: "Jakarta", want: "jakarta"},
+		{policy: OptionCaseNatural, word: " Jakarta ", want: "Jakarta"},
+		{policy: OptionCaseNatural, word: "bola", want: "bola"},
+		{policy: "", word: "Bola", want: "Bola"},
+	}
+	for _, tt := range tests {
+		if got := applyOptionCase(tt.policy, tt.word); got != tt.want {
+			t.Errorf("applyOptionCase(%q, %q) = %q, want %q", tt.policy, tt.word, got, tt.want)
+		}
+	}
+}
+
+// Grading never depends on the casing policy the options were served in
+func TestAnswersMatch(t *testing.T) {
+	tests := []struct {
+		answer  string
+		correct string
+		want    bool
+	}{
+		{answer: "bola", correct: "bola", want: true},
+		{answer: "BOLA", correct: "bola", want: true},
+		{answer: " Bola ", correct: "BOLA", want: true},
+		{answer: "dola", correct: "bola"},
+		{answer: "", correct: "bola"},
+		{answer: "bola ku", correct: "bola"},
+	}
+	for _, tt := range tests {
+		if got := answersMatch(tt.answer, tt.correct); got != tt.want {
+			t.Errorf("answersMatch(%q, %q) = %v, want %v", tt.answer, tt.correct, got, tt.want)
+		}
+	}
+}
+
+// The prompt's casing instruction and JSON example follow questions.options.case
+func TestGenerateFromAIPromptFollowsOptionCase(t *testing.T) {
+	tests := []struct {
+		policy          string
+		wantExample     string
+		wantInstruction string
+		wantAnswer      string
+	}{
+		{
+			policy:          OptionCaseUpper,
+			wantExample:     `{"correctAnswer":"KATA","options":["KATA","DATA","KACA","KAPA"]}`,
+			wantInstruction: "in UPPERCASE",
+			wantAnswer:      "DADU",
+		},
+		{
+			policy:          OptionCaseLower,
+			wantExample:     `{"correctAnswer":"kata","options":["kata","data","kaca","kapa"]}`,
+			wantInstruction: "in lowercase",
+			wantAnswer:      "dadu",
+		},
+		{
+			policy:          OptionCaseNatural,
+			wantExample:     `{"correctAnswer":"kata","options":["kata","data","kaca","kapa"]}`,
+			wantInstruction: "NATURAL capitalization",
+			wantAnswer:      "Dadu",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			u := newTestUsecase(t, newFakeRepo())
+			u.cfg.PromptTemplate = defaultPromptTemplate
+			u.optionCase = tt.policy
+			var mu sync.Mutex
+			var prompts []string
+			u.cfg.Gemini, _ = newFakeLLM(t, func(prompt string) (string, int) {
+				mu.Lock()
+				prompts = append(prompts, prompt)
+				mu.Unlock()
+				return `{"correctAnswer":"Dadu","options":["Dadu","badu","dabu","babu"]}`, http.StatusOK
+			})
+
+			q, _, err := u.generateFromAI(context.Background(), "easy", "b-d", true, false)
+			if err != nil {
+				t.Fatalf("generateFromAI: %v", err)
+			}
+			if len(prompts) != 1 {
+				t.Fatalf("LLM calls = %d, want 1", len(prompts))
+			}
+			if !strings.Contains(prompts[0], tt.wantExample) {
+				t.Errorf("prompt lacks example %s:\n%s", tt.wantExample, prompts[0])
+			}
+			if !strings.Contains(prompts[0], tt.wantInstruction) {
+				t.Errorf("prompt lacks instruction %q", tt.wantInstruction)
+			}
+			u.caseQuestion(&q)
+			if q.Answer != tt.wantAnswer || q.Options[0] != tt.wantAnswer {
+				t.Errorf("served answer/first option = %q/%q, want %q", q.Answer, q.Options[0], tt.wantAnswer)
+			}
+			if !answersMatch("dadu", q.Answer) {
+				t.Errorf("lowercase submission does not match %q", q.Answer)
+			}
+		})
+	}
+}
+
+func TestCaseQuestionKeepsHiddenAnswerEmpty(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	u.optionCase = OptionCaseLower
+	q := entity.GeneratedQuestion{Options: []string{"BOLA", "Dola"}}
+	u.caseQuestion(&q)
+	if q.Answer != "" || q.Options[0] != "bola" || q.Options[1] != "dola" {
+		t.Errorf("cased question = %+v", q)
+	}
+}
+
+// The served copy is cased without touching the options slice it shares with the queued save
+func TestCaseQuestionCopiesOptions(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	u.optionCase = OptionCaseUpper
+	queued := []string{"bola", "dola"}
+	q := entity.GeneratedQuestion{Options: queued}
+	u.caseQuestion(&q)
+	if q.Options[0] != "BOLA" || queued[0] != "bola" {
+		t.Errorf("served options %v, queued options %v; want only the served ones cased", q.Options, queued)
+	}
+}
+
+// Fallback and cached questions are served in the configured casing, and a submit echoes the options the
+// same way while grading case-insensitively
+func TestServedAndSubmittedOptionsFollowOptionCase(t *testing.T) {
+	tests := []struct {
+		policy   string
+		isCased  func(string) bool
+		wantOpts []string
+	}{
+		{policy: OptionCaseUpper, isCased: func(s string) bool { return s == strings.ToUpper(s) }, wantOpts: []string{"BOLA", "DOLA"}},
+		{policy: OptionCaseLower, isCased: func(s string) bool { return s == strings.ToLower(s) }, wantOpts: []string{"bola", "dola"}},
+		{policy: OptionCaseNatural, isCased: func(string) bool { return true }, wantOpts: []string{"Bola", "dola"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.questions["q-cached"] = &internalEntity.GeneratedQuestion{QuestionID: "q-cached", Difficulty: "easy", TargetLetterPair: "b-d", Options: `["Bola","dola"]`, CorrectAnswer: "Bola"}
+			u := newTestUsecase(t, repo)
+			u.optionCase = tt.policy
+			u.cfg.Config.Set("llm.gemini.disable_ai_prompt", true)
+			u.cfg.Config.Set("dyslexia.allow_include_answer", true)
+
+			fallback, _, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 2, Patterns: []string{"b-d"}, UseAI: true, IncludeAnswer: true})
+			if err != nil {
+				t.Fatalf("Generate fallback: %v", err)
+			}
+			cached, _, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 1, Patterns: []string{"b-d"}, IncludeAnswer: true})
+			if err != nil {
+				t.Fatalf("Generate cached: %v", err)
+			}
+			for _, q := range append(fallback, cached...) {
+				for _, word := range append(q.Options, q.Answer) {
+					if !tt.isCased(word) {
+						t.Errorf("question %s serves %q outside the %s policy", q.ID, word, tt.policy)
+					}
+				}
+			}
+			if len(cached) != 1 || cached[0].Answer != tt.wantOpts[0] {
+				t.Fatalf("cached = %+v, want answer %q", cached, tt.wantOpts[0])
+			}
+
+			resp, err := u.SubmitAnswer(context.Background(), entity.SubmitAnswerRequest{UserID: "user-1", SessionID: "sess-1", QuestionID: "q-cached", Answer: "bOlA"})
+			if err != nil {
+				t.Fatalf("SubmitAnswer: %v", err)
+			}
+			if !resp.IsCorrect {
+				t.Errorf("a differently cased answer was graded wrong")
+			}
+			if !slices.Equal(resp.Options, tt.wantOpts) {
+				t.Errorf("submit options = %v, want %v", resp.Options, tt.wantOpts)
+			}
+		})
+	}
+}
+
+// The constructor reads questions.options.case; unknown values are rejected at startup by config.ValidateAPIConfig
+// and keep the uppercase default here
+func TestNewUsecaseOptionCase(t *testing.T) {
+	tests := []struct {
+		configured string
+		want       string
+	}{
+		{want: OptionCaseUpper},
+		{configured: " Natural ", want: OptionCaseNatural},
+		{configured: "lower", want: OptionCaseLower},
+		{configured: "title", want: OptionCaseUpper},
+	}
+	for _, tt := range tests {
+		config := viper.New()
+		if tt.configured != "" {
+			config.Set("questions.options.case", tt.configured)
+		}
+		u := NewDyslexiaQuestionUsecase(DyslexiaQuestionConfig{Config: config, Difficulties: entity.DifficultyLevels()}).(*dyslexiaQuestionUsecase)
+		_ = u.Shutdown(context.Background())
+		if u.optionCase != tt.want {
+			t.Errorf("questions.options.case %q: optionCase = %q, want %q", tt.configured, u.optionCase, tt.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+const (
+	VerdictImproved = "improved"
+	VerdictDeclined = "declined"
+	VerdictSimilar  = "similar"
+
+	// Default accuracy change (percentage points) before a comparison counts as improved/declined
+	defaultCompareThreshold = 5.0
+)
+
+// CompareSessions diffs session b against session a (typically last week vs this week) without calling the LLM
+func (u *dyslexiaQuestionUsecase) CompareSessions(ctx context.Context, sessionA, sessionB string, requireSameUser bool) (*entity.SessionComparison, error) {
+	if sessionA == sessionB {
+		return nil, fmt.Errorf("sessions to compare must be different")
+	}
+
+	if requireSameUser {
+		userA, userB := u.resolveUserID(sessionA), u.resolveUserID(sessionB)
+		if userA == "" || userA != userB {
+			return nil, fmt.Errorf("sessions %s and %s do not belong to the same user", sessionA, sessionB)
+		}
+	}
+
+	cacheA, err := u.getOrGenerateAnalysisCache(ctx, sessionA)
+	if err != nil {
+		return nil, fmt.Errorf("session %s: %w", sessionA, err)
+	}
+	cacheB, err := u.getOrGenerateAnalysisCache(ctx, sessionB)
+	if err != nil {
+		return nil, fmt.Errorf("session %s: %w", sessionB, err)
+	}
+
+	accuracyA := cacheAccuracy(cacheA)
+	accuracyB := cacheAccuracy(cacheB)
+	delta := roundPoints(accuracyB - accuracyA)
+
+	return &entity.SessionComparison{
+		SessionA:         sessionA,
+		SessionB:         sessionB,
+		AccuracyA:        roundPoints(accuracyA),
+		AccuracyB:        roundPoints(accuracyB),
+		AccuracyDelta:    delta,
+		LetterPairDeltas: letterPairDeltas(cacheErrorRates(cacheA), cacheErrorRates(cacheB)),
+		Verdict:          compareVerdict(delta, u.compareThreshold()),
+	}, nil
+}
+
+func (u *dyslexiaQuestionUsecase) compareThreshold() float64 {
+	if !u.cfg.Config.IsSet("report.compare_threshold") {
+		return defaultCompareThreshold
+	}
+	return u.cfg.Config.GetFloat64("report.compare_threshold")
+}
+
+func compareVerdict(accuracyDelta, threshold float64) string {
+	switch {
+	case accuracyDelta >= threshold:
+		return VerdictImproved
+	case accuracyDelta <= -threshold:
+		return VerdictDeclined
+	default:
+		return VerdictSimilar
+	}
+}
+
+// cacheAccuracy returns accuracy in percent (0-100)
+func cacheAccuracy(cache *internalEntity.SessionAnalysisCache) float64 {
+	if cache.TotalQuestions == 0 {
+		return 0
+	}
+	return float64(cache.CorrectAnswers) / float64(cache.TotalQuestions) * 100
+}
+
+// cacheErrorRates returns error rate in percent per letter pair
+func cacheErrorRates(cache *internalEntity.SessionAnalysisCache) map[string]float64 {
+	rates := make(map[string]float64)
+	var patterns []entity.ErrorPattern
+	if err := json.Unmarshal([]byte(cache.ErrorPatterns), &patterns); err != nil {
+		return rates
+	}
+	for _, p := range patterns {
+		if p.TotalCount > 0 {
+			rates[p.LetterPair] = float64(p.ErrorCount) / float64(p.TotalCount) * 100
+		}
+	}
+	return rates
+}
+
+// letterPairDeltas lists pairs practised in either session; a pair missing from one side has a nil rate there
+func letterPairDeltas(ratesA, ratesB map[string]float64) []entity.LetterPairDelta {
+	pairs := make([]string, 0, len(ratesA)+len(ratesB))
+	for pair := range ratesA {
+		pairs = append(pairs, pair)
+	}
+	for pair := range ratesB {
+		if _, ok := ratesA[pair]; !ok {
+			pairs = append(pairs, pair)
+		}
+	}
+	sort.Strings(pairs)
+
+	deltas := make([]entity.LetterPairDelta, 0, len(pairs))
+	for _, pair := range pairs {
+		d := entity.LetterPairDelta{LetterPair: pair}
+		a, okA := ratesA[pair]
+		b, okB := ratesB[pair]
+		if okA {
+			v := roundPoints(a)
+			d.ErrorRateA = &v
+		}
+		if okB {
+			v := roundPoints(b)
+			d.ErrorRateB = &v
+		}
+		if okA && okB {
+			v := roundPoints(b - a)
+			d.ErrorRateDelta = &v
+		}
+		deltas = append(deltas, d)
+	}
+	return deltas
+}
+
+func roundPoints(v float64) float64 {
+	return math.Round(v*10) / 10
+}
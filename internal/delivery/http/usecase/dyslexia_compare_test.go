@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+// Session b (this week) against a (last week): accuracy and per-pair error rates are diffed b - a
+func TestCompareSessions(t *testing.T) {
+	repo := newFakeRepo()
+	repo.sessions["week-1"] = &internalEntity.Session{SessionID: "week-1", UserID: "user-1"}
+	repo.sessions["week-2"] = &internalEntity.Session{SessionID: "week-2", UserID: "user-1"}
+	repo.sessions["other"] = &internalEntity.Session{SessionID: "other", UserID: "user-2"}
+	repo.caches["week-1"] = &internalEntity.SessionAnalysisCache{SessionID: "week-1", TotalQuestions: 10, CorrectAnswers: 5,
+		ErrorPatterns: `[{"letter_pair":"b-d","error_count":4,"total_count":6},{"letter_pair":"p-q","error_count":1,"total_count":4}]`}
+	repo.caches["week-2"] = &internalEntity.SessionAnalysisCache{SessionID: "week-2", TotalQuestions: 10, CorrectAnswers: 8,
+		ErrorPatterns: `[{"letter_pair":"b-d","error_count":1,"total_count":5},{"letter_pair":"m-w","error_count":1,"total_count":5}]`}
+	repo.caches["other"] = &internalEntity.SessionAnalysisCache{SessionID: "other", TotalQuestions: 4, CorrectAnswers: 2, ErrorPatterns: `[]`}
+	u := newTestUsecase(t, repo)
+
+	improved := &entity.SessionComparison{
+		SessionA: "week-1", SessionB: "week-2", AccuracyA: 50, AccuracyB: 80, AccuracyDelta: 30,
+		LetterPairDeltas: []entity.LetterPairDelta{
+			{LetterPair: "b-d", ErrorRateA: ptr(66.7), ErrorRateB: ptr(20), ErrorRateDelta: ptr(-46.7)},
+			{LetterPair: "m-w", ErrorRateB: ptr(20)},
+			{LetterPair: "p-q", ErrorRateA: ptr(25)},
+		},
+		Verdict: VerdictImproved,
+	}
+	tests := []struct {
+		name     string
+		a, b     string
+		sameUser bool
+		want     *entity.SessionComparison
+		wantErr  string
+	}{
+		{name: "better session", a: "week-1", b: "week-2", sameUser: true, want: improved},
+		{name: "worse session", a: "week-2", b: "week-1", want: &entity.SessionComparison{
+			SessionA: "week-2", SessionB: "week-1", AccuracyA: 80, AccuracyB: 50, AccuracyDelta: -30,
+			LetterPairDeltas: []entity.LetterPairDelta{
+				{LetterPair: "b-d", ErrorRateA: ptr(20), ErrorRateB: ptr(66.7), ErrorRateDelta: ptr(46.7)},
+				{LetterPair: "m-w", ErrorRateA: ptr(20)},
+				{LetterPair: "p-q", ErrorRateB: ptr(25)},
+			},
+			Verdict: VerdictDeclined,
+		}},
+		{name: "same session", a: "week-1", b: "week-1", wantErr: "must be different"},
+		{name: "different users", a: "week-1", b: "other", sameUser: true, wantErr: "do not belong to the same user"},
+		{name: "unknown session", a: "week-1", b: "missing", wantErr: "session missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.CompareSessions(context.Background(), tt.a, tt.b, tt.sameUser)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CompareSessions: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("comparison = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareVerdict(t *testing.T) {
+	tests := []struct {
+		delta, threshold float64
+		want             string
+	}{
+		{delta: 5, threshold: 5, want: VerdictImproved},
+		{delta: 4.9, threshold: 5, want: VerdictSimilar},
+		{delta: -4.9, threshold: 5, want: VerdictSimilar},
+		{delta: -5, threshold: 5, want: VerdictDeclined},
+		{delta: 1, threshold: 0.5, want: VerdictImproved},
+	}
+	for _, tt := range tests {
+		if got := compareVerdict(tt.delta, tt.threshold); got != tt.want {
+			t.Errorf("compareVerdict(%v, %v) = %s, want %s", tt.delta, tt.threshold, got, tt.want)
+		}
+	}
+}
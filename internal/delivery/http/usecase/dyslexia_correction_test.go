@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+func TestAnswerCorrectionWindow(t *testing.T) {
+	answeredAt := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		window        int
+		elapsed       time.Duration
+		wantCorrected bool
+		wantStored    string
+	}{
+		{name: "within the window overwrites", window: 30, elapsed: 10 * time.Second, wantCorrected: true, wantStored: "BOLA"},
+		{name: "at the window edge overwrites", window: 30, elapsed: 30 * time.Second, wantCorrected: true, wantStored: "BOLA"},
+		{name: "outside the window is a no-op", window: 30, elapsed: 31 * time.Second, wantStored: "DOLA"},
+		{name: "no window keeps the first answer", elapsed: time.Second, wantStored: "DOLA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.questions["q-1"] = &internalEntity.GeneratedQuestion{
+				QuestionID: "q-1", Difficulty: "easy", TargetLetterPair: "b-d",
+				Options: `["bola","dola","bela","pola"]`, CorrectAnswer: "bola",
+			}
+			repo.answers = []internalEntity.UserAnswer{{
+				ID: 1, UserID: "user-1", SessionID: "sess-1", QuestionID: "q-1", UserAnswer: "DOLA",
+				CorrectAnswer: "BOLA", AttemptNumber: 1, AnsweredAt: answeredAt,
+			}}
+			repo.caches["sess-1"] = &internalEntity.SessionAnalysisCache{SessionID: "sess-1", TotalQuestions: 1}
+			u := newTestUsecase(t, repo)
+			u.cfg.DB, _ = txTestDB(t)
+			u.cfg.Config.Set("answers.correction_window_seconds", tt.window)
+			u.now = func() time.Time { return answeredAt.Add(tt.elapsed) }
+
+			resp, err := u.SubmitAnswer(context.Background(), entity.SubmitAnswerRequest{
+				UserID: "user-1", SessionID: "sess-1", QuestionID: "q-1", Answer: "BOLA",
+			})
+			if err != nil {
+				t.Fatalf("SubmitAnswer: %v", err)
+			}
+			if resp.Corrected != tt.wantCorrected || resp.IsCorrect != tt.wantCorrected {
+				t.Errorf("corrected=%v correct=%v, want %v", resp.Corrected, resp.IsCorrect, tt.wantCorrected)
+			}
+			if len(repo.answers) != 1 || repo.answers[0].UserAnswer != tt.wantStored {
+				t.Fatalf("stored answers %+v, want one with %q", repo.answers, tt.wantStored)
+			}
+			if tt.wantCorrected && (repo.answers[0].PreviousAnswer == nil || *repo.answers[0].PreviousAnswer != "DOLA") {
+				t.Errorf("previous answer not kept: %v", repo.answers[0].PreviousAnswer)
+			}
+			invalidated := repo.caches["sess-1"].InvalidatedAt != nil
+			if invalidated != tt.wantCorrected {
+				t.Errorf("cached analysis invalidated = %v, want %v", invalidated, tt.wantCorrected)
+			}
+		})
+	}
+}
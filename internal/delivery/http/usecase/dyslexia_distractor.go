@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"strings"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/evandrarf/dinacom-be/internal/pkg/mapper"
+)
+
+// generateSwapDistractors builds distractors by swapping confusable letters in word.
+// Swaps for the target letterPair come first, then swaps for the other confusable pairs.
+// Results keep the casing of word, are deduplicated, and never equal word.
+func generateSwapDistractors(word string, letterPair string, limit int) []string {
+	upper := strings.ToUpper(word)
+	isLower := word == strings.ToLower(word)
+
+	pairs := []string{letterPair}
+	for _, p := range allLetterPairs {
+		if p != letterPair {
+			pairs = append(pairs, p)
+		}
+	}
+
+	seen := map[string]bool{upper: true}
+	variants := []string{}
+	add := func(candidate string) {
+		if seen[candidate] || !isWordLike(candidate) {
+			return
+		}
+		seen[candidate] = true
+		if isLower {
+			candidate = strings.ToLower(candidate)
+		}
+		variants = append(variants, candidate)
+	}
+
+	for _, pair := range pairs {
+		letters := strings.Split(strings.ToUpper(pair), "-")
+		if len(letters) != 2 || len(letters[0]) != 1 || len(letters[1]) != 1 {
+			continue
+		}
+		a, b := letters[0][0], letters[1][0]
+
+		// Swap one position at a time
+		for i := 0; i < len(upper); i++ {
+			switch upper[i] {
+			case a:
+				add(upper[:i] + string(b) + upper[i+1:])
+			case b:
+				add(upper[:i] + string(a) + upper[i+1:])
+			}
+		}
+
+		// Swap every occurrence at once
+		add(strings.Map(func(r rune) rune {
+			switch byte(r) {
+			case a:
+				return rune(b)
+			case b:
+				return rune(a)
+			}
+			return r
+		}, upper))
+	}
+
+	if limit > 0 && len(variants) > limit {
+		variants = variants[:limit]
+	}
+	return variants
+}
+
+// isWordLike rejects candidates without vowels or with three identical letters in a row
+func isWordLike(word string) bool {
+	if !strings.ContainsAny(word, "AIUEO") {
+		return false
+	}
+	for i := 2; i < len(word); i++ {
+		if word[i] == word[i-1] && word[i] == word[i-2] {
+			return false
+		}
+	}
+	return true
+}
+
+// bankTemplatesForPair returns static question bank templates for a difficulty and letter pair
+func bankTemplatesForPair(difficulty entity.Difficulty, letterPair string) []entity.QuestionTemplate {
+	templates := []entity.QuestionTemplate{}
+	for _, tpl := range QuestionBank {
+		if tpl.Difficulty == difficulty && tpl.TargetLetterPair == letterPair {
+			templates = append(templates, tpl)
+		}
+	}
+	return templates
+}
+
+// swapOptionsFromTemplate returns [correct, distractors...] using letter swaps,
+// topped up with the template's own distractors when swaps yield too few
+func swapOptionsFromTemplate(tpl entity.QuestionTemplate, letterPair string) []string {
+	correct := strings.ToLower(tpl.CorrectWord)
+	distractors := generateSwapDistractors(correct, letterPair, 3)
+	for _, d := range mapper.FilterDistractors(correct, tpl.Distractors) {
+		if len(distractors) >= 3 {
+			break
+		}
+		d = strings.ToLower(d)
+		if !containsFold(distractors, d) {
+			distractors = append(distractors, d)
+		}
+	}
+	return append([]string{correct}, distractors...)
+}
+
+func containsFold(words []string, word string) bool {
+	for _, w := range words {
+		if strings.EqualFold(w, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+)
+
+// Target pair swaps come first, then the other confusable pairs; results keep the word's casing,
+// are unique and never equal the word
+func TestGenerateSwapDistractors(t *testing.T) {
+	tests := []struct {
+		name       string
+		word       string
+		letterPair string
+		limit      int
+		want       []string
+	}{
+		{name: "single swap", word: "bola", letterPair: "b-d", want: []string{"dola"}},
+		{name: "uppercase kept", word: "BOLA", letterPair: "b-d", want: []string{"DOLA"}},
+		{name: "each position then all at once", word: "baba", letterPair: "b-d", want: []string{"daba", "bada", "dada"}},
+		{name: "target pair before other pairs", word: "dadu", letterPair: "b-d", want: []string{"badu", "dabu", "babu", "dadn"}},
+		{name: "other pairs follow in order", word: "nama", letterPair: "m-n", want: []string{"mama", "nana", "mana", "nawa", "uama"}},
+		{name: "limit", word: "dadu", letterPair: "b-d", limit: 2, want: []string{"badu", "dabu"}},
+		{name: "limit above the variants", word: "bola", letterPair: "b-d", limit: 3, want: []string{"dola"}},
+		{name: "no confusable letters", word: "kaki", letterPair: "b-d", want: []string{}},
+		{name: "repeated letters", word: "bubu", letterPair: "b-d", want: []string{"dubu", "budu", "dudu", "bnbu", "bubn"}},
+		{name: "vowel-less swap rejected", word: "bu", letterPair: "b-d", want: []string{"du"}},
+		{name: "triple letters rejected", word: "addb", letterPair: "b-d", want: []string{"abdb", "adbb", "abbd"}},
+		{name: "malformed pair skipped", word: "bola", letterPair: "bd", want: []string{"dola"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := generateSwapDistractors(tt.word, tt.letterPair, tt.limit)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("generateSwapDistractors(%q, %q, %d) = %q, want %q", tt.word, tt.letterPair, tt.limit, got, tt.want)
+			}
+			if slices.Contains(got, tt.word) {
+				t.Errorf("result contains the word itself")
+			}
+		})
+	}
+}
+
+func TestIsWordLike(t *testing.T) {
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{word: "BOLA", want: true},
+		{word: "DADU", want: true},
+		{word: "BDBD"},
+		{word: "BAAAK"},
+		{word: "BAAK", want: true},
+	}
+	for _, tt := range tests {
+		if got := isWordLike(tt.word); got != tt.want {
+			t.Errorf("isWordLike(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
+
+// Swaps are topped up with the template's own distractors, lowercased and without duplicates
+func TestSwapOptionsFromTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		tpl  entity.QuestionTemplate
+		want []string
+	}{
+		{
+			name: "swaps fill all slots",
+			tpl:  entity.QuestionTemplate{CorrectWord: "DADU", Distractors: []string{"BATU"}},
+			want: []string{"dadu", "badu", "dabu", "babu"},
+		},
+		{
+			name: "template distractors top up",
+			tpl:  entity.QuestionTemplate{CorrectWord: "BOLA", Distractors: []string{"DOLA", "BOLA", "POLA", "BELA", "BOTA"}},
+			want: []string{"bola", "dola", "pola", "bela"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := swapOptionsFromTemplate(tt.tpl, "b-d"); !slices.Equal(got, tt.want) {
+				t.Errorf("swapOptionsFromTemplate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+)
+
+const (
+	defaultDrillTarget = 80.0
+	defaultDrillWindow = 5
+)
+
+// NextDrillQuestion drives a "practice until mastered" drill on one letter pair: the pair is mastered once
+// the last window answers to that pair in the session reach target accuracy; until then the next question
+// for the pair is returned. Defaults come from sessions.drill.target_accuracy and sessions.drill.window.
+func (u *dyslexiaQuestionUsecase) NextDrillQuestion(ctx context.Context, sessionID string, query entity.DrillQuery) (*entity.DrillResponse, error) {
+	patterns, err := validatePatterns([]string{query.Pair})
+	if err != nil {
+		return nil, err
+	}
+	pair := patterns[0]
+
+	target := query.Target
+	if target == 0 {
+		target = defaultDrillTarget
+		if u.cfg.Config.IsSet("sessions.drill.target_accuracy") {
+			target = u.cfg.Config.GetFloat64("sessions.drill.target_accuracy")
+		}
+	}
+	window := query.Window
+	if window == 0 {
+		window = defaultDrillWindow
+		if u.cfg.Config.IsSet("sessions.drill.window") {
+			window = u.cfg.Config.GetInt("sessions.drill.window")
+		}
+	}
+
+	// A drill may run inside a started session or an implicit one (answers only)
+	difficulty := query.Difficulty
+	if session, err := u.cfg.Repository.FindSessionBySessionID(u.dbWithContext(ctx), sessionID); err == nil {
+		if session.ExpiredAt != nil {
+			return nil, fmt.Errorf("session %s expired at %s, start a new session", sessionID, session.ExpiredAt.Format(time.RFC3339))
+		}
+		if difficulty == "" {
+			difficulty = entity.NormalizeDifficulty(session.Difficulty)
+		}
+	}
+
+	answers, err := u.cfg.Repository.FindUserAnswersBySessionID(u.dbWithContext(ctx), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session answers: %w", err)
+	}
+
+	// Answers are newest first; keep the last window answers to this pair
+	questions := u.generatedQuestionsFor(answers)
+	considered, correct := 0, 0
+	for _, answer := range answers {
+		if considered == window {
+			break
+		}
+		if questions[answer.QuestionID].TargetLetterPair != pair {
+			continue
+		}
+		considered++
+		if answer.IsCorrect {
+			correct++
+		}
+	}
+
+	accuracy := 0.0
+	if considered > 0 {
+		accuracy = float64(correct) / float64(considered) * 100
+	}
+
+	result := &entity.DrillResponse{
+		SessionID:       sessionID,
+		LetterPair:      pair,
+		Target:          target,
+		Window:          window,
+		WindowAnswers:   considered,
+		RollingAccuracy: fmt.Sprintf("%.1f%%", accuracy),
+		Mastered:        considered >= window && accuracy >= target,
+	}
+	if result.Mastered {
+		return result, nil
+	}
+
+	useAI := query.UseAI == nil || *query.UseAI
+	generated, _, err := u.Generate(ctx, entity.GenerateOptions{
+		Difficulty: difficulty,
+		Count:      1,
+		Patterns:   []string{pair},
+		UseAI:      useAI,
+		SessionID:  sessionID,
+		Shuffle:    true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate drill question: %w", err)
+	}
+	if len(generated) == 0 {
+		return nil, fmt.Errorf("no drill question available for pair %s", pair)
+	}
+	result.Question = &generated[0]
+
+	return result, nil
+}
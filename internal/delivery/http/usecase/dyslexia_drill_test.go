@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+// Mastery looks only at the newest window answers to the drilled pair; until it is reached the next
+// question for that pair is served
+func TestNextDrillQuestion(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		answers      []bool // answers to b-d, oldest first
+		query        entity.DrillQuery
+		config       map[string]any
+		wantMastered bool
+		wantWindow   int
+		wantAccuracy string
+	}{
+		{name: "mastered at the target", answers: []bool{false, false, true, true, false, true, true}, wantMastered: true, wantWindow: 5, wantAccuracy: "80.0%"},
+		{name: "below the target", answers: []bool{true, true, false, true, false, true, false}, wantWindow: 5, wantAccuracy: "40.0%"},
+		{name: "window not filled yet", answers: []bool{true, true, true}, wantWindow: 3, wantAccuracy: "100.0%"},
+		{name: "no answers yet", wantAccuracy: "0.0%"},
+		{name: "query overrides", answers: []bool{false, true, true}, query: entity.DrillQuery{Target: 100, Window: 2}, wantMastered: true, wantWindow: 2, wantAccuracy: "100.0%"},
+		{name: "configured defaults", answers: []bool{false, true, true, true},
+			config: map[string]any{"sessions.drill.target_accuracy": 90, "sessions.drill.window": 3}, wantMastered: true, wantWindow: 3, wantAccuracy: "100.0%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			for _, id := range []string{"q-bd-1", "q-bd-2"} {
+				repo.questions[id] = &internalEntity.GeneratedQuestion{QuestionID: id, Difficulty: "easy", TargetLetterPair: "b-d", Options: `["bola","dola"]`, CorrectAnswer: "bola"}
+			}
+			repo.questions["q-pq"] = &internalEntity.GeneratedQuestion{QuestionID: "q-pq", Difficulty: "easy", TargetLetterPair: "p-q", Options: `["pita","qita"]`, CorrectAnswer: "pita"}
+			at := start
+			for i, correct := range tt.answers {
+				at = at.Add(time.Minute)
+				repo.answers = append(repo.answers, internalEntity.UserAnswer{SessionID: "sess-1", QuestionID: "q-bd-1", IsCorrect: correct, AnsweredAt: at})
+				if i%2 == 0 {
+					// Misses on another pair never count against the drilled one
+					at = at.Add(time.Minute)
+					repo.answers = append(repo.answers, internalEntity.UserAnswer{SessionID: "sess-1", QuestionID: "q-pq", AnsweredAt: at})
+				}
+			}
+			u := newTestUsecase(t, repo)
+			for k, v := range tt.config {
+				u.cfg.Config.Set(k, v)
+			}
+
+			query := tt.query
+			query.Pair = "b-d"
+			query.UseAI = new(bool)
+			got, err := u.NextDrillQuestion(context.Background(), "sess-1", query)
+			if err != nil {
+				t.Fatalf("NextDrillQuestion: %v", err)
+			}
+			if got.Mastered != tt.wantMastered || got.WindowAnswers != tt.wantWindow || got.RollingAccuracy != tt.wantAccuracy {
+				t.Errorf("drill = mastered %v over %d answers at %s, want %v over %d at %s",
+					got.Mastered, got.WindowAnswers, got.RollingAccuracy, tt.wantMastered, tt.wantWindow, tt.wantAccuracy)
+			}
+			if tt.wantMastered {
+				if got.Question != nil {
+					t.Errorf("mastered drill served %+v", got.Question)
+				}
+				return
+			}
+			if got.Question == nil || got.Question.TargetLetterPair != "b-d" {
+				t.Errorf("question = %+v, want the next b-d question", got.Question)
+			}
+		})
+	}
+}
+
+func TestNextDrillQuestionRejects(t *testing.T) {
+	expired := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	repo := newFakeRepo()
+	repo.sessions["sess-old"] = &internalEntity.Session{SessionID: "sess-old", ExpiredAt: &expired}
+	u := newTestUsecase(t, repo)
+
+	if _, err := u.NextDrillQuestion(context.Background(), "sess-1", entity.DrillQuery{Pair: "x-y"}); err == nil {
+		t.Error("drill on an unknown pair succeeded")
+	}
+	if _, err := u.NextDrillQuestion(context.Background(), "sess-old", entity.DrillQuery{Pair: "b-d"}); err == nil {
+		t.Error("drill in an expired session succeeded")
+	}
+}
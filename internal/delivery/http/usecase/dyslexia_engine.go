@@ -5,27 +5,68 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"math/rand"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
 	"github.com/evandrarf/dinacom-be/internal/delivery/http/repository"
 	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
 	"github.com/evandrarf/dinacom-be/internal/pkg/llm"
+	"github.com/evandrarf/dinacom-be/internal/pkg/mapper"
+	"github.com/evandrarf/dinacom-be/internal/pkg/metrics"
+	"github.com/evandrarf/dinacom-be/internal/pkg/webhook"
 	openai "github.com/sashabaranov/go-openai"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
 type DyslexiaQuestionUsecase interface {
-	Generate(ctx context.Context, difficulty entity.Difficulty, count int, includeAnswer bool, patterns []string, useAI bool, sessionID string) ([]entity.GeneratedQuestion, error)
+	Generate(ctx context.Context, opts entity.GenerateOptions) ([]entity.GeneratedQuestion, *entity.GenerateMeta, error)
+	GenerateFromTemplate(ctx context.Context, templateID string, difficulty entity.Difficulty, includeAnswer bool, sessionID string) (*entity.GeneratedQuestion, error)
+	RegenerateOptions(ctx context.Context, letterPair string, difficulty entity.Difficulty) (*entity.RegenerateResult, error)
+	BulkGenerate(ctx context.Context, req entity.BulkGenerateRequest) (*entity.BulkGenerateResult, error)
+	GetServeLog(ctx context.Context, sessionID string) ([]entity.ServeLogItem, error)
+	GetTemplates(ctx context.Context, difficulty entity.Difficulty, includeAnswer bool, page, limit int) ([]entity.QuestionTemplate, int64, error)
 	SubmitAnswer(ctx context.Context, req entity.SubmitAnswerRequest) (*entity.SubmitAnswerResponse, error)
-	GetSessionAnswers(ctx context.Context, sessionID string) ([]entity.UserAnswerLog, error)
+	GetSessionAnswers(ctx context.Context, sessionID string) ([]entity.UserAnswerLog, *entity.SessionProgress, error)
+	GetSessionProgress(ctx context.Context, sessionID string) (*entity.SessionProgress, error)
 	GenerateSessionReport(ctx context.Context, sessionID string) (*entity.SessionReport, error)
-	ChatWithBot(ctx context.Context, sessionID string, userMessage string) (*entity.ChatResponse, error)
-	GetChatHistory(ctx context.Context, sessionID string) ([]entity.ChatHistoryItem, error)
+	ChatWithBot(ctx context.Context, sessionID string, userMessage string, model string) (*entity.ChatResponse, error)
+	GetChatHistory(ctx context.Context, sessionID string, page, perPage int) ([]entity.ChatHistoryItem, int64, error)
+	GetChatTranscript(ctx context.Context, sessionID string, format string) (*entity.ChatTranscript, error)
+	StartSession(ctx context.Context, req entity.StartSessionRequest) (*entity.SessionInfo, error)
+	ResumeSession(ctx context.Context, sessionID string, useAI bool) (*entity.SessionResumeResponse, error)
+	NextDrillQuestion(ctx context.Context, sessionID string, query entity.DrillQuery) (*entity.DrillResponse, error)
+	ImportAnswers(ctx context.Context, req entity.ImportAnswersRequest) (*entity.ImportAnswersResult, error)
+	CompareSessions(ctx context.Context, sessionA, sessionB string, requireSameUser bool) (*entity.SessionComparison, error)
+	GetBatchReports(ctx context.Context, sessionIDs []string) ([]entity.BatchReportItem, error)
+	GetCohortAnalytics(ctx context.Context, userIDs []string, from, to time.Time) (*entity.CohortAnalytics, error)
+	GetPairAnalytics(ctx context.Context, difficulty entity.Difficulty) (*entity.PairAnalytics, error)
+	DeleteUserData(ctx context.Context, userID string) (*entity.UserDataResult, error)
+	RestoreUserData(ctx context.Context, userID string) (*entity.UserDataResult, error)
+	GetUserTrends(ctx context.Context, userID string, pair string, from, to time.Time) ([]entity.LetterPairTrend, error)
+	GetUserPreferences(ctx context.Context, userID string) (*entity.UserPreferences, error)
+	SaveUserPreferences(ctx context.Context, userID string, req entity.UserPreferencesRequest) (*entity.UserPreferences, error)
+	RemainingQuota(ctx context.Context, sessionID string, kind string) (int, bool)
+	PreviewFallback(ctx context.Context, difficulty entity.Difficulty, pattern string, seed int64) (*entity.GeneratedQuestion, error)
+	CreateQuestion(ctx context.Context, req entity.CreateQuestionRequest) (*entity.GeneratedQuestion, error)
+	GetQuestionPrompt(ctx context.Context, questionID string) (*entity.QuestionPrompt, error)
+	GetAnswerDistribution(ctx context.Context, questionID string) (*entity.AnswerDistribution, error)
+	RegradeSession(ctx context.Context, sessionID string) (*entity.RegradeResult, error)
+	RegradeQuestion(ctx context.Context, questionID string) (*entity.RegradeResult, error)
+	ListQuestions(ctx context.Context, filter entity.QuestionListFilter, page, perPage int) ([]entity.AdminQuestionItem, int64, error)
+	ExpireIdleSessions(ctx context.Context) (int, error)
+	Shutdown(ctx context.Context) error
 }
 
 type DyslexiaQuestionConfig struct {
@@ -34,76 +75,259 @@ type DyslexiaQuestionConfig struct {
 	PromptTemplate string
 	Repository     repository.DyslexiaQuestionRepository
 	Config         *viper.Viper
+	Webhook        *webhook.Client
+	AccuracyAlert  *webhook.Client // optional early-intervention alert (webhooks.accuracy_alert_url)
+	Metrics        *metrics.Registry
+	Log            *logrus.Logger
+	// Difficulties are the selectable levels in their configured order; nil uses the registered entity.DifficultyLevels()
+	Difficulties []entity.DifficultyLevel
+	// PreforkChild is set in Fiber prefork child processes: scheduled jobs run in the primary process only,
+	// otherwise every child would repeat them against the same database
+	PreforkChild bool
 }
 
 type dyslexiaQuestionUsecase struct {
-	cfg DyslexiaQuestionConfig
-	rnd *rand.Rand
+	cfg                DyslexiaQuestionConfig
+	rnd                *rand.Rand
+	difficulties       []entity.DifficultyLevel
+	defaultDifficulty  entity.Difficulty
+	defaultPatterns    []string
+	generationStrategy string
+	optionCase         string // questions.options.case
+	reportLocks        *keyedMutex
+	now                func() time.Time // clock for answer correction windows; tests pin it
+	saveOutbox         *generatedOutbox
+	stopBackground     context.CancelFunc
+	background         sync.WaitGroup // detached writes that Shutdown waits for
 }
 
 func NewDyslexiaQuestionUsecase(cfg DyslexiaQuestionConfig) DyslexiaQuestionUsecase {
 	if cfg.PromptTemplate == "" {
 		cfg.PromptTemplate = defaultPromptTemplate
 	}
-	return &dyslexiaQuestionUsecase{
-		cfg: cfg,
-		rnd: rand.New(rand.NewSource(time.Now().UnixNano())),
+	var seed int64
+	difficulties := cfg.Difficulties
+	if len(difficulties) == 0 {
+		difficulties = entity.DifficultyLevels()
+	}
+	// Without dyslexia.default_difficulty the first configured level is the default, whatever its name
+	defaultDifficulty := difficulties[0].Name
+	defaultPatterns := allLetterPairs
+	generationStrategy := GenerationStrategyAIFirst
+	optionCase := OptionCaseUpper
+	outboxSize, outboxRetries, outboxBackoffMs := 256, 3, 500
+	if cfg.Config != nil {
+		seed = cfg.Config.GetInt64("dyslexia.random_seed")
+
+		// Configured defaults are validated at startup (config.ValidateAPIConfig) with every other bad key;
+		// a value that still fails to parse here keeps the built-in default
+		if level, ok := lookupLevel(difficulties, entity.NormalizeDifficulty(cfg.Config.GetString("dyslexia.default_difficulty"))); ok {
+			defaultDifficulty = level.Name
+		}
+		if validated, err := validatePatterns(cfg.Config.GetStringSlice("dyslexia.default_patterns")); err == nil && len(validated) > 0 {
+			defaultPatterns = validated
+		}
+		if parsed, err := ParseGenerationStrategy(cfg.Config.GetString("questions.generation_strategy")); err == nil {
+			generationStrategy = parsed
+		}
+		if parsed, err := ParseOptionCase(cfg.Config.GetString("questions.options.case")); err == nil {
+			optionCase = parsed
+		}
+		if cfg.Config.IsSet("questions.save_queue_size") {
+			outboxSize = cfg.Config.GetInt("questions.save_queue_size")
+		}
+		if cfg.Config.IsSet("questions.save_max_retries") {
+			outboxRetries = cfg.Config.GetInt("questions.save_max_retries")
+		}
+		if cfg.Config.IsSet("questions.save_retry_backoff_ms") {
+			outboxBackoffMs = cfg.Config.GetInt("questions.save_retry_backoff_ms")
+		}
 	}
+	u := &dyslexiaQuestionUsecase{
+		cfg:                cfg,
+		rnd:                newSafeRand(seed),
+		difficulties:       difficulties,
+		defaultDifficulty:  defaultDifficulty,
+		defaultPatterns:    defaultPatterns,
+		generationStrategy: generationStrategy,
+		optionCase:         optionCase,
+		reportLocks:        newKeyedMutex(),
+		now:                time.Now,
+	}
+	u.saveOutbox = newGeneratedOutbox(outboxSize, outboxRetries, time.Duration(outboxBackoffMs)*time.Millisecond, u.logger(), func(q entity.GeneratedQuestion, letterPair string, prompt string, generatedBy string) error {
+		return u.saveGeneratedToDB(context.Background(), q, letterPair, prompt, generatedBy)
+	})
+
+	backgroundCtx, stop := context.WithCancel(context.Background())
+	u.stopBackground = stop
+	if u.sessionIdleWindow() > 0 && !cfg.PreforkChild {
+		u.startSessionCleaner(backgroundCtx)
+	}
+	return u
 }
 
-func (u *dyslexiaQuestionUsecase) Generate(ctx context.Context, difficulty entity.Difficulty, count int, includeAnswer bool, patterns []string, useAI bool, sessionID string) ([]entity.GeneratedQuestion, error) {
-	startTime := time.Now()
-	fmt.Printf("[PERF] Generate started for difficulty=%s count=%d patterns=%v use_ai=%v session_id=%s\n", difficulty, count, patterns, useAI, sessionID)
+// Shutdown stops background jobs, flushes queued writes and waits for detached writes (usage counts,
+// serve logs, webhooks) until ctx expires; call it after the HTTP server stopped accepting requests
+func (u *dyslexiaQuestionUsecase) Shutdown(ctx context.Context) error {
+	u.stopBackground()
+	if err := u.saveOutbox.Close(ctx); err != nil {
+		return err
+	}
 
-	if difficulty == "" {
-		difficulty = entity.DifficultyEasy
+	done := make(chan struct{})
+	go func() {
+		u.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background writes still running at shutdown: %w", ctx.Err())
 	}
-	if count <= 0 {
-		count = 1
+}
+
+// goBackground runs fn detached from the request while letting Shutdown wait for it
+func (u *dyslexiaQuestionUsecase) goBackground(fn func()) {
+	u.background.Add(1)
+	go func() {
+		defer u.background.Done()
+		fn()
+	}()
+}
+
+// allLetterPairs - Common letter pairs for dyslexia practice
+var allLetterPairs = entity.LetterPairs
+
+// dbWithContext binds request-scoped queries to ctx so a cancelled or timed-out request aborts its query
+func (u *dyslexiaQuestionUsecase) dbWithContext(ctx context.Context) *gorm.DB {
+	if ctx == nil {
+		return u.cfg.DB
 	}
-	if count > 10 {
-		count = 10
+	return u.cfg.DB.WithContext(ctx)
+}
+
+// primaryDB pins a query to the primary when read replicas are configured (database.replicas). Checks that
+// guard a write (idempotency, "already generated") must see the latest writes, which a lagging replica may not.
+func (u *dyslexiaQuestionUsecase) primaryDB(ctx context.Context) *gorm.DB {
+	return u.dbWithContext(ctx).Clauses(dbresolver.Write)
+}
+
+// validatePatterns normalizes patterns and rejects ones outside allLetterPairs
+func validatePatterns(patterns []string) ([]string, error) {
+	validatedPatterns, rejected := splitPatterns(patterns)
+	if len(rejected) > 0 {
+		return nil, invalidPatternsError(rejected)
 	}
+	return validatedPatterns, nil
+}
 
-	// Get list of question IDs already used in this session (to avoid duplicates)
-	excludedQuestionIDs := []string{}
-	if sessionID != "" {
-		userAnswers, err := u.cfg.Repository.FindUserAnswersBySessionID(u.cfg.DB, sessionID)
-		if err == nil {
-			for _, answer := range userAnswers {
-				excludedQuestionIDs = append(excludedQuestionIDs, answer.QuestionID)
+// splitPatterns normalizes patterns and separates known letter pairs from rejected ones
+func splitPatterns(patterns []string) (valid []string, rejected []string) {
+	valid = []string{}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+
+		validPattern := false
+		for _, lp := range allLetterPairs {
+			if lp == pattern {
+				validPattern = true
+				break
 			}
-			fmt.Printf("[SESSION] Found %d questions already used in session %s\n", len(excludedQuestionIDs), sessionID)
 		}
+
+		if !validPattern {
+			rejected = append(rejected, pattern)
+			continue
+		}
+		valid = append(valid, pattern)
 	}
+	return valid, rejected
+}
 
-	// Define common letter pairs for dyslexia practice
-	allLetterPairs := []string{"b-d", "p-q", "m-w", "n-u", "m-n"}
-	letterPairs := allLetterPairs // Default: use all
+func invalidPatternsError(rejected []string) error {
+	if len(rejected) == 1 {
+		return fmt.Errorf("invalid pattern: %s (allowed: %s)", rejected[0], strings.Join(allLetterPairs, ", "))
+	}
+	return fmt.Errorf("invalid patterns: %s (allowed: %s)", strings.Join(rejected, ", "), strings.Join(allLetterPairs, ", "))
+}
 
-	// If patterns are specified, validate and use only those patterns
-	if len(patterns) > 0 {
-		validatedPatterns := []string{}
-		for _, pattern := range patterns {
-			pattern = strings.ToLower(strings.TrimSpace(pattern))
-			if pattern == "" {
-				continue
-			}
+// requestPatterns validates patterns from a Generate request. With dyslexia.pattern_mode=lenient
+// unknown patterns are dropped with a warning as long as at least one valid pattern remains.
+func (u *dyslexiaQuestionUsecase) requestPatterns(patterns []string) ([]string, error) {
+	valid, rejected := splitPatterns(patterns)
+	if len(rejected) == 0 {
+		return valid, nil
+	}
 
-			// Validate pattern
-			validPattern := false
-			for _, lp := range allLetterPairs {
-				if lp == pattern {
-					validPattern = true
-					break
-				}
-			}
+	if !strings.EqualFold(u.cfg.Config.GetString("dyslexia.pattern_mode"), "lenient") || len(valid) == 0 {
+		return nil, invalidPatternsError(rejected)
+	}
 
-			if !validPattern {
-				return nil, fmt.Errorf("invalid pattern: %s (allowed: b-d, p-q, m-w, n-u, m-n)", pattern)
-			}
+	u.logger().Warnf("Ignoring invalid patterns %v, continuing with %v", rejected, valid)
+	return valid, nil
+}
+
+// Generate returns opts.Count questions. Shuffle=false keeps options in their stored (cache) or generated
+// (AI, fallback) order, with the correct answer first for new questions; cached questions also come in
+// insertion order. Model optionally overrides the LLM for this call and must be in llm.allowed_models.
+// An omitted Difficulty or Patterns falls back to the preferences of the session's user. With
+// sessions.enforce_target_count a started session refuses to serve more than its remaining target_count.
+// The meta is non-nil when fewer than opts.Count questions could be returned (or fallback questions
+// topped up the cache) and says why.
+func (u *dyslexiaQuestionUsecase) Generate(ctx context.Context, opts entity.GenerateOptions) ([]entity.GeneratedQuestion, *entity.GenerateMeta, error) {
+	ctx, err := u.withRequestedModel(ctx, opts.Model)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Clamp before the target check, so count=0 is checked as the one question it serves
+	opts.Count = min(max(opts.Count, 1), 10)
+	if err := u.checkSessionTarget(ctx, opts.SessionID, opts.Count); err != nil {
+		return nil, nil, err
+	}
+	opts.Difficulty, opts.Patterns = u.applyUserPreferences(ctx, opts.SessionID, opts.Difficulty, opts.Patterns)
 
-			validatedPatterns = append(validatedPatterns, pattern)
+	startTime := time.Now()
+	ctx, stats := withGenerateStats(ctx)
+	questions, meta, err := u.generate(ctx, opts)
+	u.logGenerateSummary(stats, opts.Difficulty, opts.Count, opts.UseAI, questions, time.Since(startTime), err)
+	if err == nil {
+		for i := range questions {
+			u.caseQuestion(&questions[i])
+			setCorrectIndex(&questions[i])
+		}
+		u.logServedQuestions(opts.SessionID, questions)
+	}
+	return questions, meta, err
+}
+
+func (u *dyslexiaQuestionUsecase) generate(ctx context.Context, opts entity.GenerateOptions) ([]entity.GeneratedQuestion, *entity.GenerateMeta, error) {
+	log := u.logger()
+	stats := generateStatsFrom(ctx)
+	log.Debugf("[GENERATE] started for difficulty=%s count=%d patterns=%v use_ai=%v session_id=%s", opts.Difficulty, opts.Count, opts.Patterns, opts.UseAI, opts.SessionID)
+
+	opts.IncludeAnswer = u.answerExposureAllowed(opts.IncludeAnswer)
+	if opts.Difficulty == "" {
+		opts.Difficulty = u.defaultDifficulty
+	}
+
+	// Questions already answered or served (even if unanswered) in this session are never shown again
+	excludedQuestionIDs := []string{}
+	if opts.SessionID != "" {
+		excludedQuestionIDs = u.sessionUsedQuestionIDs(ctx, opts.SessionID)
+		log.Debugf("[SESSION] Found %d questions already used in session %s", len(excludedQuestionIDs), opts.SessionID)
+	}
+
+	letterPairs := u.defaultPatterns // Default: configured patterns (all pairs unless overridden)
+
+	// If patterns are specified, validate and use only those patterns
+	if len(opts.Patterns) > 0 {
+		validatedPatterns, err := u.requestPatterns(opts.Patterns)
+		if err != nil {
+			return nil, nil, err
 		}
 
 		if len(validatedPatterns) > 0 {
@@ -112,66 +336,70 @@ func (u *dyslexiaQuestionUsecase) Generate(ctx context.Context, difficulty entit
 	}
 
 	// If use_ai=false, retrieve from DB cache
-	if !useAI {
-		fmt.Printf("[PERF] Using DB cache (use_ai=false)\n")
-		return u.generateFromDBCache(ctx, difficulty, count, includeAnswer, letterPairs, excludedQuestionIDs)
+	if !opts.UseAI {
+		log.Debugf("[GENERATE] Using DB cache (use_ai=false)")
+		return u.generateFromCacheOnly(ctx, opts.Difficulty, opts.Count, opts.IncludeAnswer, letterPairs, excludedQuestionIDs, opts.Shuffle)
 	}
 
-	// Check if AI prompt is disabled via env
-	disableAI := u.cfg.Config.GetBool("llm.gemini.disable_ai_prompt")
-
-	// Use goroutines for parallel generation to speed up
-	type result struct {
-		question entity.GeneratedQuestion
-		index    int
-		err      error
+	if u.generationStrategy == GenerationStrategyCacheFirst {
+		return u.generateCacheFirst(ctx, opts.Difficulty, opts.Count, opts.IncludeAnswer, letterPairs, excludedQuestionIDs, opts.Shuffle, opts.SessionID)
 	}
 
-	resultChan := make(chan result, count)
+	// Check if AI prompt is disabled via env
+	disableAI := u.cfg.Config.GetBool("llm.gemini.disable_ai_prompt")
 
-	// Generate all questions in parallel
-	for i := 0; i < count; i++ {
-		go func(index int) {
-			iterStart := time.Now()
-			// Pick random letter pair for each question
-			letterPair := letterPairs[u.rnd.Intn(len(letterPairs))]
-
-			var q entity.GeneratedQuestion
-			var err error
-
-			if disableAI {
-				// Skip AI, use simple fallback
-				q = u.createFallbackQuestionWithShuffle(difficulty, letterPair, true)
-			} else {
-				// Generate from AI
-				aiStart := time.Now()
-				q, err = u.generateFromAI(ctx, difficulty, letterPair, true)
-				fmt.Printf("[PERF] AI call %d took: %v\n", index+1, time.Since(aiStart))
-
-				if err != nil {
-					fmt.Printf("Question %d: AI generate error: %v, using fallback\n", index+1, err)
-					q = u.createFallbackQuestionWithShuffle(difficulty, letterPair, true)
-				} else {
-					// Save asynchronously (non-blocking)
-					go func(question entity.GeneratedQuestion, pair string) {
-						if saveErr := u.saveGeneratedToDB(ctx, question, pair); saveErr != nil {
-							fmt.Printf("Warning: failed to save question to DB: %v\n", saveErr)
-						}
-					}(q, letterPair)
-				}
-			}
+	// Daily per-user AI quota: once exhausted, downgrade to DB cache, then fallback
+	if !disableAI && !u.consumeQuota(u.resolveUserID(opts.SessionID), QuotaKindGenerate, opts.Count) {
+		if cached, err := u.generateFromDBCache(ctx, opts.Difficulty, opts.Count, opts.IncludeAnswer, letterPairs, excludedQuestionIDs, opts.Shuffle); err == nil {
+			log.Debugf("[GENERATE] AI quota exhausted, served from DB cache")
+			return cached, shortfallMeta(opts.Count, len(cached), entity.GenerateShortfallQuotaExceeded, 0), nil
+		}
+		disableAI = true
+	}
+
+	// The AI fan-out and the duplicate refill share one concurrency bound and one per-request budget of
+	// LLM calls; once the budget is spent the remaining questions come from the fallback word lists
+	concurrency := u.generateConcurrency()
+	budget := int64(u.llmCallBudget(opts.Count))
+	var llmCalls atomic.Int64
+
+	generateOne := func(label string) entity.GeneratedQuestion {
+		// Pick random letter pair for each question
+		letterPair := letterPairs[u.rnd.Intn(len(letterPairs))]
+		fallback := func() entity.GeneratedQuestion {
+			q := u.createFallbackQuestionWithShuffle(opts.Difficulty, letterPair, true, opts.Shuffle)
+			stats.source(q.ID, generateSourceFallback)
+			return q
+		}
+		if disableAI {
+			// Skip AI, use simple fallback
+			return fallback()
+		}
+		if llmCalls.Add(1) > budget {
+			log.Debugf("[GENERATE] Question %s: LLM call budget (%d) spent, using fallback", label, budget)
+			return fallback()
+		}
 
-			fmt.Printf("[PERF] Question %d took: %v\n", index+1, time.Since(iterStart))
-			resultChan <- result{question: q, index: index, err: err}
-		}(i)
+		aiStart := time.Now()
+		q, prompt, err := u.generateFromAI(ctx, opts.Difficulty, letterPair, true, opts.Shuffle)
+		log.Debugf("[GENERATE] AI call %s took: %v", label, time.Since(aiStart))
+		if err != nil {
+			u.logger().Warnf("Question %s: AI generate error: %v, using fallback", label, err)
+			return fallback()
+		}
+		stats.source(q.ID, generateSourceAI)
+		// Save asynchronously (non-blocking, retried by the outbox)
+		u.saveOutbox.Enqueue(q, letterPair, prompt, u.generatedBy(ctx))
+		return q
 	}
 
-	// Collect results
-	results := make([]entity.GeneratedQuestion, count)
-	for i := 0; i < count; i++ {
-		r := <-resultChan
-		results[r.index] = r.question
-	}
+	// Generate all questions in parallel
+	results := make([]entity.GeneratedQuestion, opts.Count)
+	runBounded(ctx, opts.Count, concurrency, func(index int) {
+		iterStart := time.Now()
+		results[index] = generateOne(fmt.Sprint(index + 1))
+		log.Debugf("[GENERATE] Question %d took: %v", index+1, time.Since(iterStart))
+	})
 
 	// Deduplicate questions within the same response (ensure no duplicates in current batch)
 	seenIDs := make(map[string]bool)
@@ -182,68 +410,114 @@ func (u *dyslexiaQuestionUsecase) Generate(ctx context.Context, difficulty entit
 		seenIDs[id] = true
 	}
 
-	for _, q := range results {
-		if !seenIDs[q.ID] {
+	// Slots never scheduled because the request was cancelled stay empty and are skipped
+	addUnique := func(candidates []entity.GeneratedQuestion, label string) {
+		for _, q := range candidates {
+			if q.ID == "" || len(uniqueResults) >= opts.Count {
+				continue
+			}
+			if seenIDs[q.ID] {
+				stats.duplicate()
+				log.Debugf("[DUPLICATE] Filtered out duplicate question in same batch: %s", q.ID)
+				continue
+			}
 			seenIDs[q.ID] = true
 			uniqueResults = append(uniqueResults, q)
-		} else {
-			fmt.Printf("[DUPLICATE] Filtered out duplicate question in same batch: %s\n", q.ID)
-		}
-	}
-
-	// If we filtered out questions and have less than requested, try to generate more
-	if len(uniqueResults) < count {
-		shortage := count - len(uniqueResults)
-		fmt.Printf("[DUPLICATE] Need %d more questions due to duplicates, generating...\n", shortage)
-
-		// Generate additional questions to fill the shortage
-		for i := 0; i < shortage && i < 5; i++ { // Max 5 retries
-			letterPair := letterPairs[u.rnd.Intn(len(letterPairs))]
-			var q entity.GeneratedQuestion
-
-			if disableAI {
-				q = u.createFallbackQuestionWithShuffle(difficulty, letterPair, true)
-			} else {
-				var err error
-				q, err = u.generateFromAI(ctx, difficulty, letterPair, true)
-				if err != nil {
-					q = u.createFallbackQuestionWithShuffle(difficulty, letterPair, true)
-				} else {
-					go func(question entity.GeneratedQuestion, pair string) {
-						_ = u.saveGeneratedToDB(ctx, question, pair)
-					}(q, letterPair)
-				}
+			if label != "" {
+				log.Debugf("[DUPLICATE] Added %s question: %s", label, q.ID)
 			}
+		}
+	}
+	addUnique(results, "")
 
-			// Check if this new question is also unique
-			if !seenIDs[q.ID] {
-				seenIDs[q.ID] = true
-				uniqueResults = append(uniqueResults, q)
-				fmt.Printf("[DUPLICATE] Added replacement question: %s\n", q.ID)
-			}
+	// If we filtered out questions and have less than requested, refill in parallel rounds until the
+	// shortfall is met, maxRefillAttempts are used up or the request is cancelled
+	refillAttempts := 0
+	for len(uniqueResults) < opts.Count && refillAttempts < maxRefillAttempts && ctx.Err() == nil {
+		shortage := opts.Count - len(uniqueResults)
+		batch := min(shortage, maxRefillAttempts-refillAttempts)
+		log.Debugf("[DUPLICATE] Need %d more questions due to duplicates, generating %d...", shortage, batch)
 
-			if len(uniqueResults) >= count {
-				break
-			}
-		}
+		first := refillAttempts
+		refill := make([]entity.GeneratedQuestion, batch)
+		refillAttempts += runBounded(ctx, batch, concurrency, func(index int) {
+			refill[index] = generateOne(fmt.Sprintf("refill %d", first+index+1))
+		})
+		addUnique(refill, "replacement")
+	}
+	if len(uniqueResults) < opts.Count {
+		log.Warnf("[DUPLICATE] Shortfall not met: returning %d of %d questions (refill attempts: %d, LLM calls: %d/%d, ctx: %v)",
+			len(uniqueResults), opts.Count, refillAttempts, min(llmCalls.Load(), budget), budget, ctx.Err())
 	}
 
 	results = uniqueResults
 
 	// Remove answer from response if not requested by user
-	if !includeAnswer {
+	if !opts.IncludeAnswer {
 		for i := range results {
 			results[i].Answer = ""
 		}
 	}
 
-	fmt.Printf("[PERF] Total Generate time: %v (parallel execution)\n", time.Since(startTime))
-	return results, nil
+	return results, shortfallMeta(opts.Count, len(results), entity.GenerateShortfallDuplicates, 0), nil
 }
 
-func (u *dyslexiaQuestionUsecase) fallbackFromDB(_ context.Context, tpl entity.QuestionTemplate, includeAnswer bool) (entity.GeneratedQuestion, error) {
+// shortfallMeta describes an under-delivered (or topped up) generate result; nil when count was met from
+// the intended source
+func shortfallMeta(requested int, returned int, reason string, toppedUp int) *entity.GenerateMeta {
+	if returned >= requested && toppedUp == 0 {
+		return nil
+	}
+	return &entity.GenerateMeta{Requested: requested, Returned: returned, Reason: reason, ToppedUp: toppedUp}
+}
+
+// generateFromCacheOnly serves use_ai=false. When the cache runs out of questions not yet used in the session,
+// questions.cache_topup_fallback fills the rest from the fallback word lists; otherwise fewer questions are
+// returned and the meta reports the shortfall.
+func (u *dyslexiaQuestionUsecase) generateFromCacheOnly(ctx context.Context, difficulty entity.Difficulty, count int, includeAnswer bool, letterPairs []string, excludeIDs []string, shuffle bool) ([]entity.GeneratedQuestion, *entity.GenerateMeta, error) {
+	questions, err := u.generateFromDBCache(ctx, difficulty, count, includeAnswer, letterPairs, excludeIDs, shuffle)
+	if len(questions) >= count || !u.cfg.Config.GetBool("questions.cache_topup_fallback") {
+		if err != nil {
+			return nil, nil, err
+		}
+		return questions, shortfallMeta(count, len(questions), entity.GenerateShortfallCacheExhausted, 0), nil
+	}
+
+	seenIDs := make(map[string]bool, len(excludeIDs)+count)
+	for _, id := range excludeIDs {
+		seenIDs[id] = true
+	}
+	for _, q := range questions {
+		seenIDs[q.ID] = true
+	}
+
+	pairs := pairsWithFallbackWords(difficulty, letterPairs)
+	if len(pairs) == 0 {
+		pairs = letterPairs
+	}
+	toppedUp := 0
+	for attempts := 0; len(questions) < count && attempts < count*3; attempts++ {
+		q := u.createFallbackQuestionWithShuffle(difficulty, pairs[u.rnd.Intn(len(pairs))], includeAnswer, shuffle)
+		if seenIDs[q.ID] {
+			generateStatsFrom(ctx).duplicate()
+			continue
+		}
+		seenIDs[q.ID] = true
+		generateStatsFrom(ctx).source(q.ID, generateSourceFallback)
+		questions = append(questions, q)
+		toppedUp++
+	}
+	if len(questions) == 0 {
+		return nil, nil, err
+	}
+
+	u.logger().Debugf("[CACHE] Topped up %d fallback questions (cache had %d of %d)", toppedUp, len(questions)-toppedUp, count)
+	return questions, shortfallMeta(count, len(questions), entity.GenerateShortfallCacheExhausted, toppedUp), nil
+}
+
+func (u *dyslexiaQuestionUsecase) fallbackFromDB(ctx context.Context, tpl entity.QuestionTemplate, includeAnswer bool) (entity.GeneratedQuestion, error) {
 	// Try to find previously generated questions for this template from DB
-	dbQuestions, err := u.cfg.Repository.FindRandomGeneratedByDifficulty(u.cfg.DB, string(tpl.Difficulty), 1, []string{})
+	dbQuestions, err := u.cfg.Repository.FindRandomGeneratedByDifficulty(u.dbWithContext(ctx), string(tpl.Difficulty), nil, 1, []string{}, u.freshSince())
 	if err != nil || len(dbQuestions) == 0 {
 		return entity.GeneratedQuestion{}, fmt.Errorf("no fallback questions in DB")
 	}
@@ -258,7 +532,7 @@ func (u *dyslexiaQuestionUsecase) fallbackFromDB(_ context.Context, tpl entity.Q
 
 	q := entity.GeneratedQuestion{
 		ID:               dbQ.QuestionID,
-		Difficulty:       entity.Difficulty(dbQ.Difficulty),
+		Difficulty:       entity.NormalizeDifficulty(dbQ.Difficulty),
 		QuestionText:     "Dengarkan kata berikut: ",
 		TargetLetterPair: dbQ.TargetLetterPair,
 		TargetLetter:     dbQ.TargetLetter,
@@ -269,12 +543,12 @@ func (u *dyslexiaQuestionUsecase) fallbackFromDB(_ context.Context, tpl entity.Q
 	}
 
 	// Increment usage count
-	_ = u.cfg.Repository.IncrementUsageCount(u.cfg.DB, dbQ.QuestionID)
+	_ = u.cfg.Repository.IncrementUsageCount(u.dbWithContext(ctx), dbQ.QuestionID)
 
 	return q, nil
 }
 
-func (u *dyslexiaQuestionUsecase) saveGeneratedToDB(_ context.Context, q entity.GeneratedQuestion, letterPair string) error {
+func (u *dyslexiaQuestionUsecase) saveGeneratedToDB(_ context.Context, q entity.GeneratedQuestion, letterPair string, prompt string, generatedBy string) error {
 	// Check if already exists
 	existing, _ := u.cfg.Repository.FindGeneratedByQuestionID(u.cfg.DB, q.ID)
 	if existing != nil {
@@ -297,30 +571,31 @@ func (u *dyslexiaQuestionUsecase) saveGeneratedToDB(_ context.Context, q entity.
 		TargetLetter:     q.TargetLetter,
 		Options:          string(optionsJSON),
 		CorrectAnswer:    q.Answer,
-		GeneratedBy:      "ai",
+		GeneratedBy:      generatedBy,
 		UsageCount:       1,
 	}
+	// Prompts are large; they are only kept when llm.store_prompts is on
+	if prompt != "" && u.cfg.Config.GetBool("llm.store_prompts") {
+		dbQuestion.Prompt = &prompt
+	}
 
 	return u.cfg.Repository.CreateGenerated(u.cfg.DB, dbQuestion)
 }
 
 // generateFromDBCache retrieves previously generated questions from database
-func (u *dyslexiaQuestionUsecase) generateFromDBCache(_ context.Context, difficulty entity.Difficulty, count int, includeAnswer bool, patterns []string, excludeIDs []string) ([]entity.GeneratedQuestion, error) {
+func (u *dyslexiaQuestionUsecase) generateFromDBCache(ctx context.Context, difficulty entity.Difficulty, count int, includeAnswer bool, patterns []string, excludeIDs []string, shuffle bool) ([]entity.GeneratedQuestion, error) {
 	startTime := time.Now()
-
-	// Build filters for repository query
-	filters := []string{}
-	if len(patterns) > 0 {
-		// Build IN clause for multiple patterns
-		quotedPatterns := make([]string, len(patterns))
-		for i, p := range patterns {
-			quotedPatterns[i] = fmt.Sprintf("'%s'", p)
-		}
-		filters = append(filters, fmt.Sprintf("target_letter_pair IN (%s)", strings.Join(quotedPatterns, ",")))
-	}
+	stats := generateStatsFrom(ctx)
 
 	// Get random questions from DB matching criteria, excluding already used question IDs
-	dbQuestions, err := u.cfg.Repository.FindRandomGeneratedByDifficulty(u.cfg.DB, string(difficulty), count, excludeIDs)
+	// (oldest first instead when shuffle is off, so the same data yields the same output)
+	var dbQuestions []internalEntity.GeneratedQuestion
+	var err error
+	if shuffle {
+		dbQuestions, err = u.cfg.Repository.FindRandomGeneratedByDifficulty(u.dbWithContext(ctx), string(difficulty), patterns, count, excludeIDs, u.freshSince())
+	} else {
+		dbQuestions, err = u.cfg.Repository.FindOrderedGeneratedByDifficulty(u.dbWithContext(ctx), string(difficulty), patterns, count, excludeIDs)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve questions from cache: %w", err)
 	}
@@ -336,68 +611,99 @@ func (u *dyslexiaQuestionUsecase) generateFromDBCache(_ context.Context, difficu
 	for _, dbQ := range dbQuestions {
 		// Check for duplicate question IDs (safety check)
 		if seenIDs[dbQ.QuestionID] {
-			fmt.Printf("[DUPLICATE] Skipped duplicate from DB: %s\n", dbQ.QuestionID)
+			stats.duplicate()
+			u.logger().Debugf("[DUPLICATE] Skipped duplicate from DB: %s", dbQ.QuestionID)
 			continue
 		}
 
 		// Unmarshal options
 		var options []string
 		if err := json.Unmarshal([]byte(dbQ.Options), &options); err != nil {
-			fmt.Printf("Warning: failed to parse options for question %s: %v\n", dbQ.QuestionID, err)
+			u.logger().Warnf("Failed to parse options for question %s: %v", dbQ.QuestionID, err)
 			continue
 		}
 
 		// Shuffle options for randomness
-		shuffledOptions := u.shuffleOptions(options)
+		if shuffle {
+			options = u.shuffleOptions(options)
+		}
 
 		q := entity.GeneratedQuestion{
 			ID:               dbQ.QuestionID,
-			Difficulty:       entity.Difficulty(dbQ.Difficulty),
+			Difficulty:       entity.NormalizeDifficulty(dbQ.Difficulty),
 			QuestionText:     dbQ.QuestionText,
 			TargetLetterPair: dbQ.TargetLetterPair,
 			TargetLetter:     dbQ.TargetLetter,
-			Options:          shuffledOptions,
+			Options:          options,
 		}
 		if includeAnswer {
 			q.Answer = dbQ.CorrectAnswer
 		}
 
 		seenIDs[dbQ.QuestionID] = true
+		stats.source(q.ID, generateSourceCache)
 		results = append(results, q)
 
 		// Increment usage count asynchronously
-		go func(questionID string) {
+		questionID := dbQ.QuestionID
+		u.goBackground(func() {
 			if err := u.cfg.Repository.IncrementUsageCount(u.cfg.DB, questionID); err != nil {
-				fmt.Printf("Warning: failed to increment usage count for %s: %v\n", questionID, err)
+				u.logger().Warnf("Failed to increment usage count for %s: %v", questionID, err)
 			}
-		}(dbQ.QuestionID)
+		})
 	}
 
-	fmt.Printf("[PERF] DB cache retrieval took: %v (found %d questions)\n", time.Since(startTime), len(results))
+	u.logger().Debugf("[CACHE] DB cache retrieval took: %v (found %d questions)", time.Since(startTime), len(results))
 	return results, nil
 }
 
-// Simple fallback when AI is disabled or fails
-func (u *dyslexiaQuestionUsecase) createFallbackQuestionWithShuffle(difficulty entity.Difficulty, letterPair string, includeAnswer bool) entity.GeneratedQuestion {
-	// Hardcoded fallback examples per letter pair (natural lowercase for common nouns)
-	fallbackWords := map[string][]string{
-		"b-d": {"bola", "dola", "bela", "dela"},
-		"p-q": {"pagi", "qagi", "patu", "qatu"},
-		"m-w": {"maju", "waju", "mata", "wata"},
-		"n-u": {"nasi", "uasi", "nama", "uama"},
-		"m-n": {"makan", "nakan", "main", "nain"},
+// freshSince returns the cutoff for "fresh" cached questions (zero when questions.max_age_days is unset)
+func (u *dyslexiaQuestionUsecase) freshSince() time.Time {
+	days := u.cfg.Config.GetInt("questions.max_age_days")
+	if days <= 0 {
+		return time.Time{}
 	}
+	return time.Now().AddDate(0, 0, -days)
+}
+
+// Simple fallback when AI is disabled or fails; shuffle=false keeps the correct answer first
+func (u *dyslexiaQuestionUsecase) createFallbackQuestionWithShuffle(difficulty entity.Difficulty, letterPair string, includeAnswer bool, shuffle bool) entity.GeneratedQuestion {
+	return u.createFallbackQuestionWithRand(u.rnd, difficulty, letterPair, includeAnswer, shuffle)
+}
+
+// Hardcoded fallback examples per letter pair (natural lowercase for common nouns)
+var fallbackWords = map[string][]string{
+	"b-d": {"bola", "dola", "bela", "dela"},
+	"p-q": {"pagi", "qagi", "patu", "qatu"},
+	"m-w": {"maju", "waju", "mata", "wata"},
+	"n-u": {"nasi", "uasi", "nama", "uama"},
+	"m-n": {"makan", "nakan", "main", "nain"},
+}
 
+// createFallbackQuestionWithRand builds the fallback question using rnd for every random choice
+func (u *dyslexiaQuestionUsecase) createFallbackQuestionWithRand(rnd *rand.Rand, difficulty entity.Difficulty, letterPair string, includeAnswer bool, shuffle bool) entity.GeneratedQuestion {
 	words, ok := fallbackWords[letterPair]
+
+	// The hardcoded list holds a single question per pair; for variety, also draw
+	// correct words from the question bank and build distractors by letter swaps
+	templates := bankTemplatesForPair(difficulty, letterPair)
+	if len(templates) > 0 && (!ok || rnd.Intn(len(templates)+1) > 0) {
+		if generated := swapOptionsFromTemplate(templates[rnd.Intn(len(templates))], letterPair); len(generated) == 4 {
+			words = generated
+			ok = true
+		}
+	}
 	if !ok {
 		words = []string{"bola", "dola", "bela", "dela"} // Default
 	}
 
 	correctAnswer := words[0]
-	id := generateQuestionID(correctAnswer, difficulty)
+	id := u.questionID(correctAnswer, difficulty, words)
 
-	// Shuffle options
-	shuffledOptions := u.shuffleOptions(words)
+	options := words
+	if shuffle {
+		options = shuffleOptionsWith(rnd, words)
+	}
 
 	q := entity.GeneratedQuestion{
 		ID:               id,
@@ -405,7 +711,7 @@ func (u *dyslexiaQuestionUsecase) createFallbackQuestionWithShuffle(difficulty e
 		QuestionText:     "Dengarkan kata berikut: ",
 		TargetLetterPair: letterPair,
 		TargetLetter:     strings.Split(letterPair, "-")[0],
-		Options:          shuffledOptions,
+		Options:          options,
 	}
 	if includeAnswer {
 		q.Answer = correctAnswer
@@ -413,8 +719,38 @@ func (u *dyslexiaQuestionUsecase) createFallbackQuestionWithShuffle(difficulty e
 	return q
 }
 
+// PreviewFallback returns the fallback question (with answer) for a pair/difficulty under a fixed seed
+func (u *dyslexiaQuestionUsecase) PreviewFallback(ctx context.Context, difficulty entity.Difficulty, pattern string, seed int64) (*entity.GeneratedQuestion, error) {
+	if difficulty == "" {
+		difficulty = u.defaultDifficulty
+	}
+
+	patterns, err := validatePatterns([]string{pattern})
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("pattern is required")
+	}
+
+	q := u.createFallbackQuestionWithRand(rand.New(rand.NewSource(seed)), difficulty, patterns[0], u.answerExposureAllowed(true), true)
+	u.caseQuestion(&q)
+	setCorrectIndex(&q)
+	return &q, nil
+}
+
+// answerExposureAllowed overrides includeAnswer unless dyslexia.allow_include_answer is enabled,
+// so production clients can never read the correct answer before submitting
+func (u *dyslexiaQuestionUsecase) answerExposureAllowed(includeAnswer bool) bool {
+	if includeAnswer && !u.cfg.Config.GetBool("dyslexia.allow_include_answer") {
+		u.logger().Warnf("[SECURITY] includeAnswer requested but dyslexia.allow_include_answer is disabled, stripping answers")
+		return false
+	}
+	return includeAnswer
+}
+
 // Legacy createFallbackQuestion for backward compatibility
-func createFallbackQuestion(difficulty entity.Difficulty, letterPair string, includeAnswer bool) entity.GeneratedQuestion {
+func createFallbackQuestion(difficulty entity.Difficulty, letterPair string, includeAnswer bool, shuffle bool) entity.GeneratedQuestion {
 	// Hardcoded fallback examples per letter pair (natural lowercase for common nouns)
 	fallbackWords := map[string][]string{
 		"b-d": {"bola", "dola", "bela", "dela"},
@@ -455,6 +791,24 @@ type geminiBatchJSON struct {
 	Questions []geminiQuestionJSON `json:"questions"`
 }
 
+// normalize trims the correct answer and options, dropping blank options.
+// A blank correct answer is rejected so the caller can skip or fall back.
+func (g geminiQuestionJSON) normalize() (geminiQuestionJSON, error) {
+	correct := strings.TrimSpace(g.CorrectAnswer)
+	if correct == "" {
+		return geminiQuestionJSON{}, fmt.Errorf("AI output has blank correct answer")
+	}
+
+	options := make([]string, 0, len(g.Options))
+	for _, opt := range g.Options {
+		if opt = strings.TrimSpace(opt); opt != "" {
+			options = append(options, opt)
+		}
+	}
+
+	return geminiQuestionJSON{CorrectAnswer: correct, Options: options}, nil
+}
+
 // generateBatchFromAI generates multiple questions in ONE API call
 func (u *dyslexiaQuestionUsecase) generateBatchFromAI(ctx context.Context, difficulty entity.Difficulty, count int, letterPairs []string, includeAnswer bool) ([]entity.GeneratedQuestion, error) {
 	if u.cfg.Gemini == nil {
@@ -463,9 +817,10 @@ func (u *dyslexiaQuestionUsecase) generateBatchFromAI(ctx context.Context, diffi
 
 	// Build batch prompt asking for N questions at once
 	pairsStr := strings.Join(letterPairs, ", ")
+	level, _ := u.lookupDifficulty(difficulty)
 	prompt := fmt.Sprintf(`Generate %d different listening questions for Indonesian dyslexic children.
 
-Difficulty: %s
+Difficulty: %s (%s)
 Available letter pairs to use: %s
 
 For each question:
@@ -473,19 +828,22 @@ For each question:
 2. Create ONE real Indonesian word containing that pair
 3. Generate EXACTLY 3 UNIQUE distractor words that look visually similar (swap confusing letters)
 4. ALL 4 OPTIONS MUST BE DIFFERENT - NO DUPLICATES ALLOWED
-5. Use NATURAL capitalization (lowercase for common nouns, capitalize proper nouns)
+5. %s
 
 Return JSON array of %d questions. Each question must have:
-- "correctAnswer": the correct word to be spoken (with natural capitalization)
+- "correctAnswer": the correct word to be spoken
 - "options": array of 4 UNIQUE words shuffled randomly (1 correct + 3 unique distractors)
 
 CRITICAL: Ensure all 4 options in each question are UNIQUE and DIFFERENT!
 
 IMPORTANT: Return ONLY valid JSON, NO markdown, NO code blocks.
 JSON format:
-{"questions":[{"correctAnswer":"bola","options":["bola","dola","bela","pola"]},{"correctAnswer":"kata","options":["kata","data","kaca","kapa"]},...]}`,
-		count, difficulty, pairsStr, count)
+{"questions":[%s,%s,...]}`,
+		count, difficulty, difficultyGuidance(level), pairsStr, optionCasePrompt(u.optionCase), count,
+		optionCaseExample(u.optionCase, "bola", "bola", "dola", "bela", "pola"),
+		optionCaseExample(u.optionCase, "kata", "kata", "data", "kaca", "kapa"))
 
+	generateStatsFrom(ctx).llmCall()
 	text, err := u.cfg.Gemini.GenerateText(ctx, prompt)
 	if err != nil {
 		return nil, err
@@ -500,7 +858,7 @@ JSON format:
 
 	var parsed geminiBatchJSON
 	if err := json.Unmarshal([]byte(clean), &parsed); err != nil {
-		fmt.Printf("Batch JSON Parse Error - Raw output (%d chars): %s\n", len(clean), clean)
+		u.recordParseFailure("generate_batch", prompt, clean, err)
 		return nil, fmt.Errorf("AI output is not valid json: %w", err)
 	}
 
@@ -508,12 +866,33 @@ JSON format:
 		return nil, fmt.Errorf("AI returned no questions")
 	}
 
+	// With llm.gemini.strict_schema each question object is checked raw; violating ones are skipped
+	var rawQuestions struct {
+		Questions []json.RawMessage `json:"questions"`
+	}
+	strict := u.strictAISchema()
+	if strict {
+		if err := json.Unmarshal([]byte(clean), &rawQuestions); err != nil {
+			return nil, fmt.Errorf("AI output is not valid json: %w", err)
+		}
+	}
+
 	// Convert to GeneratedQuestion format
 	results := make([]entity.GeneratedQuestion, 0, len(parsed.Questions))
-	for _, qData := range parsed.Questions {
-		if len(qData.Options) < 2 {
+	for i, qData := range parsed.Questions {
+		if strict && u.checkAISchema("generate_batch", rawQuestions.Questions[i]) != nil {
+			continue
+		}
+		qData, err := qData.normalize()
+		if err == nil {
+			qData, err = u.sanitizeAIOptions(qData)
+		}
+		if err != nil || len(qData.Options) < 2 {
 			continue // Skip invalid questions
 		}
+		if err := u.validateWordLength(qData.CorrectAnswer, difficulty); err != nil {
+			continue // Skip words outside the difficulty's length range
+		}
 
 		// Deduplicate options (in case AI returns duplicates)
 		uniqueOptions := deduplicateOptions(qData.Options, qData.CorrectAnswer)
@@ -525,7 +904,7 @@ JSON format:
 		letterPair := detectLetterPair(qData.CorrectAnswer, letterPairs)
 		targetLetter := strings.Split(letterPair, "-")[0]
 
-		id := generateQuestionID(qData.CorrectAnswer, difficulty)
+		id := u.questionID(qData.CorrectAnswer, difficulty, uniqueOptions)
 		q := entity.GeneratedQuestion{
 			ID:               id,
 			Difficulty:       difficulty,
@@ -547,26 +926,18 @@ JSON format:
 	return results, nil
 }
 
-// deduplicateOptions removes duplicate options and ensures correct answer is included
+// deduplicateOptions removes duplicate options and ensures correct answer is included.
+// Comparison is case-insensitive so a distractor can never repeat the correct answer.
 func deduplicateOptions(options []string, correctAnswer string) []string {
-	seen := make(map[string]bool)
 	unique := make([]string, 0, len(options))
 
 	// Ensure correct answer is first
-	if correctAnswer != "" && !seen[correctAnswer] {
+	if strings.TrimSpace(correctAnswer) != "" {
 		unique = append(unique, correctAnswer)
-		seen[correctAnswer] = true
 	}
 
 	// Add other unique options
-	for _, opt := range options {
-		if opt != "" && !seen[opt] {
-			unique = append(unique, opt)
-			seen[opt] = true
-		}
-	}
-
-	return unique
+	return append(unique, mapper.FilterDistractors(correctAnswer, options)...)
 }
 
 // detectLetterPair detects which letter pair is in the word
@@ -581,64 +952,153 @@ func detectLetterPair(word string, letterPairs []string) string {
 	return letterPairs[0] // Default fallback
 }
 
-func (u *dyslexiaQuestionUsecase) generateFromAI(ctx context.Context, difficulty entity.Difficulty, letterPair string, includeAnswer bool) (entity.GeneratedQuestion, error) {
+// generateFromAI also returns the exact prompt sent so it can be stored with the question for auditing.
+// shuffle=false keeps the correct answer first.
+func (u *dyslexiaQuestionUsecase) generateFromAI(ctx context.Context, difficulty entity.Difficulty, letterPair string, includeAnswer bool, shuffle bool) (entity.GeneratedQuestion, string, error) {
 	if u.cfg.Gemini == nil {
-		return entity.GeneratedQuestion{}, fmt.Errorf("gemini client not configured")
+		return entity.GeneratedQuestion{}, "", fmt.Errorf("gemini client not configured")
 	}
 
 	prompt := u.cfg.PromptTemplate
 	prompt = strings.ReplaceAll(prompt, "{{difficulty}}", string(difficulty))
+	prompt = strings.ReplaceAll(prompt, "{{difficultyLevels}}", u.difficultyLevelsPrompt())
 	prompt = strings.ReplaceAll(prompt, "{{targetLetterPair}}", letterPair)
+	prompt = strings.ReplaceAll(prompt, "{{optionCase}}", optionCasePrompt(u.optionCase))
+	prompt = strings.ReplaceAll(prompt, "{{exampleJSON}}", optionCaseExample(u.optionCase, "kata", "kata", "data", "kaca", "kapa"))
 
-	text, err := u.cfg.Gemini.GenerateText(ctx, prompt)
-	if err != nil {
-		return entity.GeneratedQuestion{}, err
-	}
+	// With llm.gemini.strict_schema a structurally invalid answer is regenerated up to strict_schema_retries times
+	var parsed geminiQuestionJSON
+	for attempt := 0; ; attempt++ {
+		generateStatsFrom(ctx).llmCall()
+		text, err := u.cfg.Gemini.GenerateText(ctx, prompt)
+		if err != nil {
+			return entity.GeneratedQuestion{}, "", err
+		}
 
-	// Try parse JSON from model output (strip code fences if present)
-	clean := strings.TrimSpace(text)
-	clean = strings.TrimPrefix(clean, "```json")
-	clean = strings.TrimPrefix(clean, "```")
-	clean = strings.TrimSuffix(clean, "```")
-	clean = strings.TrimSpace(clean)
+		// Try parse JSON from model output (strip code fences if present)
+		clean := strings.TrimSpace(text)
+		clean = strings.TrimPrefix(clean, "```json")
+		clean = strings.TrimPrefix(clean, "```")
+		clean = strings.TrimSuffix(clean, "```")
+		clean = strings.TrimSpace(clean)
 
-	// Debug log
-	if len(clean) < 30 {
-		fmt.Printf("WARNING: AI response too short (%d chars): %s\n", len(clean), clean)
-	}
+		// Debug log
+		if len(clean) < 30 {
+			u.logger().Warnf("AI response too short (%d chars): %s", len(clean), clean)
+		}
 
-	var parsed geminiQuestionJSON
-	if err := json.Unmarshal([]byte(clean), &parsed); err != nil {
-		fmt.Printf("JSON Parse Error - Raw output (%d chars): %s\n", len(clean), clean)
-		return entity.GeneratedQuestion{}, fmt.Errorf("AI output is not valid json: %w", err)
+		parsed = geminiQuestionJSON{}
+		if err := json.Unmarshal([]byte(clean), &parsed); err != nil {
+			u.recordParseFailure("generate_single", prompt, clean, err)
+			return entity.GeneratedQuestion{}, "", fmt.Errorf("AI output is not valid json: %w", err)
+		}
+		if !u.strictAISchema() {
+			break
+		}
+		if err := u.checkAISchema("generate_single", []byte(clean)); err != nil {
+			if attempt < u.aiSchemaRetries() {
+				continue
+			}
+			return entity.GeneratedQuestion{}, "", err
+		}
+		break
+	}
+	parsed, err := parsed.normalize()
+	if err != nil {
+		return entity.GeneratedQuestion{}, "", err
 	}
-	if len(parsed.Options) < 2 || parsed.CorrectAnswer == "" {
-		return entity.GeneratedQuestion{}, fmt.Errorf("AI output missing required fields")
+	parsed, err = u.sanitizeAIOptions(parsed)
+	if err != nil {
+		return entity.GeneratedQuestion{}, "", err
+	}
+	if len(parsed.Options) < 2 {
+		return entity.GeneratedQuestion{}, "", fmt.Errorf("AI output missing required fields")
+	}
+
+	if err := u.validateWordLength(parsed.CorrectAnswer, difficulty); err != nil {
+		return entity.GeneratedQuestion{}, "", err
 	}
 
 	// Deduplicate options (in case AI returns duplicates)
 	uniqueOptions := deduplicateOptions(parsed.Options, parsed.CorrectAnswer)
 	if len(uniqueOptions) < 2 {
-		return entity.GeneratedQuestion{}, fmt.Errorf("not enough unique options after deduplication")
+		return entity.GeneratedQuestion{}, "", fmt.Errorf("not enough unique options after deduplication")
 	}
 
 	// Shuffle options for randomness
-	shuffledOptions := u.shuffleOptions(uniqueOptions)
+	options := uniqueOptions
+	if shuffle {
+		options = u.shuffleOptions(uniqueOptions)
+	}
 
-	id := generateQuestionID(parsed.CorrectAnswer, difficulty)
+	id := u.questionID(parsed.CorrectAnswer, difficulty, uniqueOptions)
 	q := entity.GeneratedQuestion{
 		ID:               id,
 		Difficulty:       difficulty,
 		QuestionText:     "Dengarkan kata berikut: ",
 		TargetLetterPair: letterPair,
 		TargetLetter:     strings.Split(letterPair, "-")[0], // First letter of pair
-		Options:          shuffledOptions,
+		Options:          options,
 	}
 	if includeAnswer {
 		q.Answer = parsed.CorrectAnswer
 	}
 
-	return q, nil
+	return q, prompt, nil
+}
+
+// validateWordLength checks word against the difficulty level's word length, overridable per
+// difficulty with dyslexia.word_length.<difficulty>.min/max
+func (u *dyslexiaQuestionUsecase) validateWordLength(word string, difficulty entity.Difficulty) error {
+	level, _ := u.lookupDifficulty(difficulty)
+	minLen, maxLen := level.MinLength, level.MaxLength
+	key := "dyslexia.word_length." + string(difficulty)
+	if u.cfg.Config.IsSet(key + ".min") {
+		minLen = u.cfg.Config.GetInt(key + ".min")
+	}
+	if u.cfg.Config.IsSet(key + ".max") {
+		maxLen = u.cfg.Config.GetInt(key + ".max")
+	}
+
+	length := utf8.RuneCountInString(word)
+	if length < minLen || (maxLen > 0 && length > maxLen) {
+		return fmt.Errorf("AI word %q has %d letters, outside %s range %d-%d", word, length, difficulty, minLen, maxLen)
+	}
+	return nil
+}
+
+// recordParseFailure counts AI JSON parse failures per code path (llm_parse_failures_<path>).
+// With llm.gemini.debug_parse_errors the prompt and raw output are logged so QA can trace bad prompts.
+func (u *dyslexiaQuestionUsecase) recordParseFailure(path string, prompt string, raw string, err error) {
+	count := u.cfg.Metrics.Inc("llm_parse_failures_" + path)
+	u.logger().Warnf("[PARSE] %s: AI output is not valid json (total failures: %d): %v", path, count, err)
+
+	if u.cfg.Config.GetBool("llm.gemini.debug_parse_errors") {
+		u.logger().Debugf("[PARSE] %s prompt:\n%s", path, prompt)
+		u.logger().Debugf("[PARSE] %s raw output (%d chars): %s", path, len(raw), raw)
+	}
+}
+
+// questionID picks the id scheme from questions.id_scheme: "entropy" (default, always unique)
+// or "content" (stable hash of word, difficulty, and sorted options)
+func (u *dyslexiaQuestionUsecase) questionID(word string, difficulty entity.Difficulty, options []string) string {
+	if strings.EqualFold(u.cfg.Config.GetString("questions.id_scheme"), "content") {
+		return contentQuestionID(word, difficulty, options)
+	}
+	return generateQuestionID(word, difficulty)
+}
+
+// contentQuestionID hashes only the content, so regenerating identical content yields the same id
+func contentQuestionID(word string, difficulty entity.Difficulty, options []string) string {
+	normalized := make([]string, len(options))
+	for i, opt := range options {
+		normalized[i] = strings.ToUpper(strings.TrimSpace(opt))
+	}
+	sort.Strings(normalized)
+
+	content := strings.ToUpper(strings.TrimSpace(word)) + "|" + string(difficulty) + "|" + strings.Join(normalized, ",")
+	sum := sha256.Sum256([]byte(content))
+	return "q-" + hex.EncodeToString(sum[:8])
 }
 
 func generateQuestionID(word string, difficulty entity.Difficulty) string {
@@ -653,18 +1113,56 @@ func generateQuestionID(word string, difficulty entity.Difficulty) string {
 
 // shuffleOptions randomly shuffles the options array
 func (u *dyslexiaQuestionUsecase) shuffleOptions(options []string) []string {
+	return shuffleOptionsWith(u.rnd, options)
+}
+
+func shuffleOptionsWith(rnd *rand.Rand, options []string) []string {
 	shuffled := make([]string, len(options))
 	copy(shuffled, options)
 
 	// Fisher-Yates shuffle
 	for i := len(shuffled) - 1; i > 0; i-- {
-		j := u.rnd.Intn(i + 1)
+		j := rnd.Intn(i + 1)
 		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
 	}
 
 	return shuffled
 }
 
+// lookupDifficulty returns the usecase's level named d
+func (u *dyslexiaQuestionUsecase) lookupDifficulty(d entity.Difficulty) (entity.DifficultyLevel, bool) {
+	return lookupLevel(u.difficulties, d)
+}
+
+func lookupLevel(levels []entity.DifficultyLevel, d entity.Difficulty) (entity.DifficultyLevel, bool) {
+	for _, level := range levels {
+		if level.Name == d {
+			return level, true
+		}
+	}
+	return entity.DifficultyLevel{}, false
+}
+
+// difficultyLevelsPrompt renders the configured difficulty levels as the prompt's guidance list
+func (u *dyslexiaQuestionUsecase) difficultyLevelsPrompt() string {
+	lines := make([]string, 0, len(u.difficulties))
+	for _, level := range u.difficulties {
+		lines = append(lines, fmt.Sprintf("- %s: %s", strings.ToUpper(string(level.Name)), difficultyGuidance(level)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// difficultyGuidance is the level's configured guidance, or a description of its word length when none is set
+func difficultyGuidance(level entity.DifficultyLevel) string {
+	if level.Guidance != "" {
+		return level.Guidance
+	}
+	if level.MaxLength == 0 {
+		return fmt.Sprintf("Words of %d+ letters", level.MinLength)
+	}
+	return fmt.Sprintf("Words of %d-%d letters", level.MinLength, level.MaxLength)
+}
+
 const defaultPromptTemplate = `You are generating audio-based listening questions for Indonesian dyslexic children (TK-SD).
 
 Design principles:
@@ -672,12 +1170,10 @@ Design principles:
 - This is a LISTENING test where a word will be spoken aloud
 - Child must identify the spoken word from 4 visual options
 - Focus on Indonesian words with confusing letter pairs that dyslexic children struggle with
-- Use UPPERCASE for all options to aid visual recognition
+- {{optionCase}}
 
 Difficulty levels:
-- EASY: Short words (4-5 letters) with ONE confusing letter pair (e.g., bola vs dola, pagi vs qagi)
-- MEDIUM: Medium words (5-6 letters) with confusing letters in multiple positions (e.g., bunga vs dunga, panas vs qanas)
-- HARD: Longer words (6+ letters) with multiple confusing letter patterns (e.g., beruang vs deruang, membaca vs memdaca)
+{{difficultyLevels}}
 
 Common confusing pairs: b-d, p-q, m-w, n-u, m-n
 
@@ -694,79 +1190,242 @@ Task:
 
 IMPORTANT: Return ONLY valid JSON, NO markdown, NO code blocks.
 JSON format:
-{"correctAnswer":"KATA","options":["KATA","DATA","KAFA","KAFA"]}
+{{exampleJSON}}
 `
 
 func (u *dyslexiaQuestionUsecase) SubmitAnswer(ctx context.Context, req entity.SubmitAnswerRequest) (*entity.SubmitAnswerResponse, error) {
-	// Check if answer already exists for this user, session, and question
-	existingAnswer, err := u.cfg.Repository.FindExistingAnswer(u.cfg.DB, req.UserID, req.SessionID, req.QuestionID)
-	if err == nil && existingAnswer != nil {
-		// Answer already exists, return existing answer without saving
-		return &entity.SubmitAnswerResponse{
-			IsCorrect:     existingAnswer.IsCorrect,
-			UserAnswer:    existingAnswer.UserAnswer,
-			CorrectAnswer: existingAnswer.CorrectAnswer,
-			QuestionID:    existingAnswer.QuestionID,
-			SessionID:     existingAnswer.SessionID,
-		}, nil
+	if req.Skipped {
+		if !u.cfg.Config.GetBool("answers.allow_skip") {
+			return nil, fmt.Errorf("skipping questions is disabled")
+		}
+		if strings.TrimSpace(req.Answer) != "" || req.AnswerIndex != nil {
+			return nil, fmt.Errorf("a skipped question must not carry an answer")
+		}
+	}
+
+	// With answers.allow_reattempt every attempt is recorded; otherwise the first answer wins,
+	// except for a correction within answers.correction_window_seconds of the first submit
+	attemptNumber := 1
+	var correcting *internalEntity.UserAnswer
+	if u.cfg.Config.GetBool("answers.allow_reattempt") {
+		attempts, err := u.cfg.Repository.CountAnswerAttempts(u.primaryDB(ctx), req.UserID, req.SessionID, req.QuestionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count attempts: %w", err)
+		}
+		attemptNumber = int(attempts) + 1
+	} else {
+		// Check if answer already exists for this user, session, and question
+		existingAnswer, err := u.cfg.Repository.FindExistingAnswer(u.primaryDB(ctx), req.UserID, req.SessionID, req.QuestionID)
+		if err == nil && existingAnswer != nil && u.withinCorrectionWindow(existingAnswer) {
+			correcting = existingAnswer
+			attemptNumber = existingAnswer.AttemptNumber
+		} else if err == nil && existingAnswer != nil {
+			// Answer already exists, return existing answer without saving
+			response := &entity.SubmitAnswerResponse{
+				IsCorrect:     existingAnswer.IsCorrect,
+				Skipped:       existingAnswer.Skipped,
+				PartialCredit: existingAnswer.PartialCredit,
+				AttemptNumber: existingAnswer.AttemptNumber,
+				UserAnswer:    existingAnswer.UserAnswer,
+				CorrectAnswer: existingAnswer.CorrectAnswer,
+				QuestionID:    existingAnswer.QuestionID,
+				SessionID:     existingAnswer.SessionID,
+			}
+			if q, err := u.cfg.Repository.FindGeneratedByQuestionID(u.cfg.DB, req.QuestionID); err == nil {
+				options, _ := u.servedOptions(ctx, req.SessionID, q)
+				u.addQuestionContext(response, q, options)
+			}
+			if u.feedbackEnabled() {
+				response.Feedback = u.answerFeedback(response, 0)
+			}
+			return response, nil
+		}
 	}
 
-	// Find the generated question from database
+	// Find the generated question from database; unknown ids may be graded ad hoc against an inline answer
 	generatedQ, err := u.cfg.Repository.FindGeneratedByQuestionID(u.cfg.DB, req.QuestionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) && req.CorrectAnswer != "" && u.cfg.Config.GetBool("answers.allow_adhoc") {
+		generatedQ, err = u.adHocQuestion(req)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("question not found: %w", err)
 	}
 
+	// A multiple-choice index is graded as the option text it points at, in the order the options were served
+	options, err := u.servedOptions(ctx, req.SessionID, generatedQ)
+	if err != nil && req.AnswerIndex != nil {
+		return nil, err
+	}
+	if req.AnswerIndex != nil {
+		answer, err := optionAt(options, *req.AnswerIndex)
+		if err != nil {
+			return nil, err
+		}
+		req.Answer = answer
+	}
+
+	// The first answer of an unstarted session implicitly opens it, so it counts against the cap
+	if u.cfg.Config.GetInt("sessions.max_active_per_user") > 0 && u.isNewSessionForUser(req.UserID, req.SessionID) {
+		if err := u.checkActiveSessionLimit(u.dbWithContext(ctx), req.UserID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Normalize answers for comparison (case-insensitive, trim spaces)
 	userAnswer := strings.TrimSpace(strings.ToUpper(req.Answer))
 	correctAnswer := strings.TrimSpace(strings.ToUpper(generatedQ.CorrectAnswer))
-	isCorrect := userAnswer == correctAnswer
+	isCorrect := answersMatch(userAnswer, correctAnswer)
+	partialCredit := u.partialCredit(userAnswer, correctAnswer, isCorrect)
+	if req.Skipped {
+		// A skip is its own outcome: never correct and worth no partial credit
+		isCorrect, partialCredit = false, 0
+	}
 
-	// Save to database
+	// Save to database; stored casing follows answers.store_case and the response mirrors the stored record
+	storedUserAnswer := u.storedAnswerCase(req.Answer)
+	storedCorrectAnswer := u.storedAnswerCase(generatedQ.CorrectAnswer)
 	userAnswerEntity := &internalEntity.UserAnswer{
 		UserID:        req.UserID,
 		SessionID:     req.SessionID,
 		QuestionID:    req.QuestionID,
-		UserAnswer:    req.Answer,
-		CorrectAnswer: generatedQ.CorrectAnswer,
+		UserAnswer:    storedUserAnswer,
+		CorrectAnswer: storedCorrectAnswer,
 		IsCorrect:     isCorrect,
+		Skipped:       req.Skipped,
+		PartialCredit: partialCredit,
+		AttemptNumber: attemptNumber,
 		QuestionText:  generatedQ.QuestionText,
-		Difficulty:    generatedQ.Difficulty,
-	}
-
-	if err := u.cfg.Repository.CreateUserAnswer(u.cfg.DB, userAnswerEntity); err != nil {
+		Difficulty:    string(entity.NormalizeDifficulty(generatedQ.Difficulty)),
+	}
+
+	if correcting != nil {
+		// Like a regrade, a correction changes the session's numbers, so its cached analysis is invalidated with it
+		now := u.now()
+		previous := correcting.UserAnswer
+		userAnswerEntity.ID = correcting.ID
+		userAnswerEntity.PreviousAnswer = &previous
+		userAnswerEntity.CorrectedAt = &now
+		err := repository.WithTransaction(u.dbWithContext(ctx), func(tx *gorm.DB) error {
+			if err := u.cfg.Repository.CorrectUserAnswer(tx, userAnswerEntity); err != nil {
+				return fmt.Errorf("failed to save answer correction: %w", err)
+			}
+			if _, err := u.cfg.Repository.InvalidateAnalysisCaches(tx, []string{req.SessionID}, now); err != nil {
+				return fmt.Errorf("failed to invalidate cached analysis: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else if err := u.cfg.Repository.CreateUserAnswer(u.cfg.DB, userAnswerEntity); err != nil {
 		return nil, fmt.Errorf("failed to save answer: %w", err)
 	}
 
 	// Return response
 	response := &entity.SubmitAnswerResponse{
 		IsCorrect:     isCorrect,
-		UserAnswer:    req.Answer,
-		CorrectAnswer: generatedQ.CorrectAnswer,
+		Skipped:       req.Skipped,
+		PartialCredit: partialCredit,
+		AttemptNumber: attemptNumber,
+		UserAnswer:    storedUserAnswer,
+		CorrectAnswer: storedCorrectAnswer,
 		QuestionID:    req.QuestionID,
 		SessionID:     req.SessionID,
+		Corrected:     correcting != nil,
+	}
+	u.addQuestionContext(response, generatedQ, options)
+	if u.feedbackEnabled() {
+		streak := 0
+		if isCorrect && u.feedbackStreakMin() > 0 {
+			streak = u.correctStreak(req.UserID, req.SessionID)
+		}
+		response.Feedback = u.answerFeedback(response, streak)
 	}
 
 	return response, nil
 }
 
-func (u *dyslexiaQuestionUsecase) GetSessionAnswers(ctx context.Context, sessionID string) ([]entity.UserAnswerLog, error) {
+// withinCorrectionWindow reports whether a re-submit may still overwrite existing (a misclick correction):
+// answers.correction_window_seconds > 0 and the first submit was at most that long ago
+func (u *dyslexiaQuestionUsecase) withinCorrectionWindow(existing *internalEntity.UserAnswer) bool {
+	window := u.cfg.Config.GetInt("answers.correction_window_seconds")
+	if window <= 0 {
+		return false
+	}
+	return u.now().Sub(existing.AnsweredAt) <= time.Duration(window)*time.Second
+}
+
+// addQuestionContext fills the question text, options (as served, see servedOptions), letter pair and
+// (for wrong answers) a hint from the stored question so clients don't have to fetch it again
+func (u *dyslexiaQuestionUsecase) addQuestionContext(response *entity.SubmitAnswerResponse, q *internalEntity.GeneratedQuestion, options []string) {
+	response.QuestionText = q.QuestionText
+	response.TargetLetterPair = q.TargetLetterPair
+	response.Options = options
+	if response.Options == nil {
+		response.Options = []string{}
+	}
+	if !response.IsCorrect {
+		response.Hint = answerHint(q.TargetLetterPair)
+	}
+}
+
+// setCorrectIndex points correct_index at the answer within the options as served, so clients that
+// render options in a fixed order need not match strings. The served order is recorded in the serve log,
+// so a correct_index echoed back as answer_index grades correct. It stays unset while the answer is hidden.
+func setCorrectIndex(q *entity.GeneratedQuestion) {
+	q.CorrectIndex = nil
+	answer := strings.TrimSpace(q.Answer)
+	if answer == "" {
+		return
+	}
+	for i, opt := range q.Options {
+		if strings.EqualFold(strings.TrimSpace(opt), answer) {
+			index := i
+			q.CorrectIndex = &index
+			return
+		}
+	}
+}
+
+// optionAt resolves a 0-based answer_index against options
+func optionAt(options []string, index int) (string, error) {
+	if index < 0 || index >= len(options) {
+		return "", fmt.Errorf("answer_index %d is out of range (question has %d options)", index, len(options))
+	}
+	return options[index], nil
+}
+
+// answerHint points the child at the letter pair the question trains ("b-d" -> b dan d)
+func answerHint(letterPair string) string {
+	letters := strings.SplitN(letterPair, "-", 2)
+	if len(letters) != 2 {
+		return "Dengarkan lagi kata tersebut dan perhatikan setiap hurufnya."
+	}
+	return fmt.Sprintf("Perhatikan lagi perbedaan huruf %s dan %s.", letters[0], letters[1])
+}
+
+func (u *dyslexiaQuestionUsecase) GetSessionAnswers(ctx context.Context, sessionID string) ([]entity.UserAnswerLog, *entity.SessionProgress, error) {
 	// Get all answers for this session
-	answers, err := u.cfg.Repository.FindUserAnswersBySessionID(u.cfg.DB, sessionID)
+	answers, err := u.cfg.Repository.FindUserAnswersBySessionID(u.dbWithContext(ctx), sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get session answers: %w", err)
+		return nil, nil, fmt.Errorf("failed to get session answers: %w", err)
+	}
+	// The start record doubles as the existence check for sessions without answers
+	progress, err := u.progressFor(ctx, sessionID, answers)
+	if err != nil {
+		return nil, nil, err
 	}
+	if len(answers) == 0 && progress == nil {
+		return nil, nil, fmt.Errorf("%w: session %s", ErrNotFound, sessionID)
+	}
+
+	// Fetch all generated questions at once to get target_letter_pair
+	questions := u.generatedQuestionsFor(answers)
 
 	// Convert to UserAnswerLog
 	logs := make([]entity.UserAnswerLog, 0, len(answers))
 	for _, answer := range answers {
-		// Get generated question to fetch target_letter_pair
-		generatedQ, _ := u.cfg.Repository.FindGeneratedByQuestionID(u.cfg.DB, answer.QuestionID)
-
-		targetLetterPair := ""
-		if generatedQ != nil {
-			targetLetterPair = generatedQ.TargetLetterPair
-		}
+		targetLetterPair := questions[answer.QuestionID].TargetLetterPair
 
 		log := entity.UserAnswerLog{
 			ID:               answer.ID,
@@ -775,6 +1434,9 @@ func (u *dyslexiaQuestionUsecase) GetSessionAnswers(ctx context.Context, session
 			UserAnswer:       answer.UserAnswer,
 			CorrectAnswer:    answer.CorrectAnswer,
 			IsCorrect:        answer.IsCorrect,
+			Skipped:          answer.Skipped,
+			PartialCredit:    answer.PartialCredit,
+			AttemptNumber:    answer.AttemptNumber,
 			Difficulty:       answer.Difficulty,
 			TargetLetterPair: targetLetterPair,
 			AnsweredAt:       answer.AnsweredAt.Format(time.RFC3339),
@@ -782,10 +1444,19 @@ func (u *dyslexiaQuestionUsecase) GetSessionAnswers(ctx context.Context, session
 		logs = append(logs, log)
 	}
 
-	return logs, nil
+	return logs, progress, nil
 }
 
+// GenerateSessionReport is serialized per session so concurrent callers don't double-invoke the LLM
 func (u *dyslexiaQuestionUsecase) GenerateSessionReport(ctx context.Context, sessionID string) (*entity.SessionReport, error) {
+	unlock := u.reportLocks.Lock(sessionID)
+	defer unlock()
+
+	return u.generateSessionReport(ctx, sessionID, u.minAnswersForAnalysis())
+}
+
+// generateSessionReport skips the AI analysis below minAnswers; the chatbot and comparison pass 0 so they always get one
+func (u *dyslexiaQuestionUsecase) generateSessionReport(ctx context.Context, sessionID string, minAnswers int) (*entity.SessionReport, error) {
 	// Get all answers for this session
 	answers, err := u.cfg.Repository.FindUserAnswersBySessionID(u.cfg.DB, sessionID)
 	if err != nil {
@@ -793,33 +1464,50 @@ func (u *dyslexiaQuestionUsecase) GenerateSessionReport(ctx context.Context, ses
 	}
 
 	if len(answers) == 0 {
-		return nil, fmt.Errorf("no answers found for session")
+		started, err := u.sessionStarted(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if !started {
+			return nil, fmt.Errorf("%w: session %s", ErrNotFound, sessionID)
+		}
+		return nil, fmt.Errorf("%w: no answers found for session", ErrInvalidInput)
 	}
 
+	// Count a single attempt per question (answers.report_attempt: last or best)
+	answers = u.selectReportedAttempts(answers)
+
 	// Calculate basic stats
 	totalQuestions := len(answers)
 	correctAnswers := 0
 	wrongAnswers := 0
+	skippedAnswers := 0
+	sessionScore := scoreAnswers(answers)
 	difficultyStats := make(map[string]int)
 	letterPairErrors := make(map[string]struct {
 		errors int
 		total  int
 	})
 
+	questions := u.generatedQuestionsFor(answers)
 	for _, answer := range answers {
-		if answer.IsCorrect {
+		switch {
+		case answer.IsCorrect:
 			correctAnswers++
-		} else {
+		case answer.Skipped:
+			skippedAnswers++
+		default:
 			wrongAnswers++
 		}
 
 		// Count by difficulty
-		difficultyStats[answer.Difficulty]++
+		difficultyStats[string(entity.NormalizeDifficulty(answer.Difficulty))]++
 
-		// Get letter pair info
-		generatedQ, _ := u.cfg.Repository.FindGeneratedByQuestionID(u.cfg.DB, answer.QuestionID)
-		if generatedQ != nil && generatedQ.TargetLetterPair != "" {
-			pair := generatedQ.TargetLetterPair
+		// Get letter pair info; a skip says nothing about confusing the letters
+		if answer.Skipped {
+			continue
+		}
+		if pair := questions[answer.QuestionID].TargetLetterPair; pair != "" {
 			stats := letterPairErrors[pair]
 			stats.total++
 			if !answer.IsCorrect {
@@ -845,17 +1533,67 @@ func (u *dyslexiaQuestionUsecase) GenerateSessionReport(ctx context.Context, ses
 			})
 		}
 	}
+	sortErrorPatterns(errorPatterns)
+
+	// Too few answers for a meaningful analysis: return the numbers only, without calling the LLM
+	// or caching, so the full report is generated once the session has enough answers
+	if totalQuestions < minAnswers {
+		u.logger().Infof("[SESSION REPORT] Session %s has %d answers (< %d), skipping AI analysis", sessionID, totalQuestions, minAnswers)
+		return &entity.SessionReport{
+			SessionID:        sessionID,
+			TotalQuestions:   totalQuestions,
+			CorrectAnswers:   correctAnswers,
+			WrongAnswers:     wrongAnswers,
+			SkippedAnswers:   skippedAnswers,
+			SessionScore:     math.Round(sessionScore*100) / 100,
+			AccuracyRate:     accuracyRate,
+			ErrorPatterns:    errorPatterns,
+			DifficultyStats:  difficultyStats,
+			AIAnalysys:       u.insufficientDataNote(minAnswers),
+			InsufficientData: true,
+		}, nil
+	}
+
+	// Repeated calls (e.g. a refresh) reuse the cached analysis while no new answers arrived since it was written
+	// Read from the primary: it also decides the first completion, which a lagging replica would repeat
+	existingCache, _ := u.cfg.Repository.FindAnalysisCacheBySessionID(u.primaryDB(ctx), sessionID)
+	if cacheIsFresh(existingCache, answers, correctAnswers) {
+		u.logger().Debugf("[SESSION REPORT] Using cached analysis for session %s", sessionID)
+		report := &entity.SessionReport{
+			SessionID:       sessionID,
+			TotalQuestions:  totalQuestions,
+			CorrectAnswers:  correctAnswers,
+			WrongAnswers:    wrongAnswers,
+			SkippedAnswers:  skippedAnswers,
+			SessionScore:    math.Round(sessionScore*100) / 100,
+			AccuracyRate:    accuracyRate,
+			OverallValue:    existingCache.OverallValue,
+			ErrorPatterns:   errorPatterns,
+			DifficultyStats: difficultyStats,
+			AIAnalysys:      existingCache.AIAnalysis,
+			Recommendations: existingCache.Recommendations,
+		}
+		// Posts the feedback if an earlier attempt failed to; a no-op otherwise
+		if u.writeChatFeedback() {
+			if err := u.saveFeedbackToChat(u.cfg.DB, sessionID, existingCache.AIAnalysis, existingCache.Recommendations); err != nil {
+				u.logger().Warnf("Failed to save feedback to chat: %v", err)
+			}
+		}
+		return report, nil
+	}
 
 	// Generate Gemini analysis (with 3x retry built-in)
-	fmt.Printf("[SESSION REPORT] Generating AI analysis for session %s...\n", sessionID)
+	u.logger().Debugf("[SESSION REPORT] Generating AI analysis for session %s...", sessionID)
 	geminiAnalysis, recommendations, overallValue := u.generateAIAnalysis(ctx, answers, errorPatterns, accuracyRate)
-	fmt.Printf("[SESSION REPORT] AI analysis generated successfully\n")
+	u.logger().Infof("[SESSION REPORT] AI analysis generated successfully")
 
 	report := &entity.SessionReport{
 		SessionID:       sessionID,
 		TotalQuestions:  totalQuestions,
 		CorrectAnswers:  correctAnswers,
 		WrongAnswers:    wrongAnswers,
+		SkippedAnswers:  skippedAnswers,
+		SessionScore:    math.Round(sessionScore*100) / 100,
 		AccuracyRate:    accuracyRate,
 		OverallValue:    overallValue,
 		ErrorPatterns:   errorPatterns,
@@ -864,23 +1602,140 @@ func (u *dyslexiaQuestionUsecase) GenerateSessionReport(ctx context.Context, ses
 		Recommendations: recommendations,
 	}
 
-	// Save analysis to cache for chatbot
-	if err := u.saveAnalysisCache(ctx, report); err != nil {
-		fmt.Printf("Warning: failed to save analysis cache: %v\n", err)
+	// Only the first report of a session counts as completion (for webhook idempotency)
+	isFirstCompletion := existingCache == nil
+
+	// The cache (read by the chatbot) and the chat feedback are written together, so a failure never
+	// leaves feedback in the chat for an analysis that was not cached, or the other way round
+	err = repository.WithTransaction(u.dbWithContext(ctx), func(tx *gorm.DB) error {
+		if err := u.saveAnalysisCache(tx, report); err != nil {
+			return fmt.Errorf("failed to save analysis cache: %w", err)
+		}
+
+		// Save AI analysis as first message in chat history, unless the UI renders the report on its own
+		if u.writeChatFeedback() {
+			u.logger().Debugf("[SESSION REPORT] Saving feedback to chat history...")
+			if err := u.saveFeedbackToChat(tx, sessionID, geminiAnalysis, recommendations); err != nil {
+				return fmt.Errorf("failed to save feedback to chat: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		u.logger().Warnf("Failed to persist session report: %v", err)
 	}
 
-	// Save AI analysis as first message in chat history
-	fmt.Printf("[SESSION REPORT] Saving feedback to chat history...\n")
-	if err := u.saveFeedbackToChat(ctx, sessionID, geminiAnalysis, recommendations); err != nil {
-		fmt.Printf("Warning: failed to save feedback to chat: %v\n", err)
-	} else {
-		fmt.Printf("[SESSION REPORT] Feedback saved to chat successfully\n")
+	if isFirstCompletion {
+		u.notifySessionComplete(report)
 	}
 
 	return report, nil
 }
 
-func (u *dyslexiaQuestionUsecase) saveAnalysisCache(_ context.Context, report *entity.SessionReport) error {
+// cacheIsFresh reports whether cache was written for exactly these answers: same counts and
+// no answer recorded after the cache was last updated
+func cacheIsFresh(cache *internalEntity.SessionAnalysisCache, answers []internalEntity.UserAnswer, correctAnswers int) bool {
+	if cache == nil || cache.InvalidatedAt != nil || cache.TotalQuestions != len(answers) || cache.CorrectAnswers != correctAnswers {
+		return false
+	}
+	for _, answer := range answers {
+		if answer.AnsweredAt.After(cache.UpdatedAt) {
+			return false
+		}
+	}
+	return true
+}
+
+// Default minimum number of answers before a session report gets an AI analysis
+const defaultMinAnswersForAnalysis = 3
+
+func (u *dyslexiaQuestionUsecase) minAnswersForAnalysis() int {
+	if !u.cfg.Config.IsSet("report.min_answers_for_analysis") {
+		return defaultMinAnswersForAnalysis
+	}
+	return u.cfg.Config.GetInt("report.min_answers_for_analysis")
+}
+
+func (u *dyslexiaQuestionUsecase) insufficientDataNote(minAnswers int) string {
+	if u.analysisLanguage() == "en" {
+		return fmt.Sprintf("Not enough data for analysis yet. Answer at least %d questions to get feedback.", minAnswers)
+	}
+	return fmt.Sprintf("Data belum cukup untuk dianalisis. Jawab minimal %d soal untuk mendapatkan umpan balik.", minAnswers)
+}
+
+// analysisTaskTemplate closes the analysis prompt; %[1]s is the output language and %[2]s a sample recommendation in it (literal percent signs are doubled)
+const analysisTaskTemplate = `
+Task:
+1. Provide a brief, caring analysis in %[1]s about the child's learning patterns
+2. **IF there's previous session data**: Compare current performance with previous sessions and mention if there's improvement, decline, or consistency  
+3. **IF this is first session**: Focus on current performance and set baseline expectations
+4. Identify which letter pairs need most attention
+5. Give 2-3 specific, actionable recommendations for improvement
+6. Determine overall performance level by considering MULTIPLE factors:
+   - Accuracy rate (primary factor)
+   - **Progress trend** (improved/declined compared to previous sessions)
+   - Error patterns and consistency (which letter pairs are most problematic)
+   - Error rate per letter pair (high error rate on specific pairs indicates focused difficulty)
+   - Number of total questions attempted (shows engagement)
+   - Pattern of improvement or consistent mistakes
+
+Return response as JSON with THREE fields:
+{"analysis":"...","recommendations":"...","overall_value":"..."}
+
+IMPORTANT: 
+- For recommendations field, return as a SINGLE STRING, not an array
+- Example: "%[2]s"
+- Write the analysis and recommendations in %[1]s
+- Don't judge only by accuracy percentage
+
+For overall_value, use one of these terms exactly as written (they are stored as-is) based on HOLISTIC evaluation:
+- "excellent" (90-100%% accuracy, minimal/no consistent error patterns, good engagement)
+- "sangat baik" (80-89%% accuracy, few errors, minor patterns, good progress)
+- "baik" (70-79%% accuracy, some error patterns, showing improvement potential)
+- "cukup" (60-69%% accuracy, notable error patterns, needs focused practice)
+- "perlu peningkatan" (below 60%% accuracy, significant error patterns, needs intensive support)
+
+Keep the language simple, encouraging, and suitable for parents/teachers of young children.`
+
+// recommendationExamples shows the model a recommendation in the output language
+var recommendationExamples = map[string]string{
+	"id": "Fokus latihan pada huruf b-d. Gunakan metode visual. Berlatih setiap hari.",
+	"en": "Focus practice on the letters b-d. Use visual methods. Practise every day.",
+}
+
+// analysisFallbacks are the canned analysis and recommendations per output language, used when the
+// LLM call fails ("failed") or keeps returning unusable output ("unusable"). overall_value stays "baik",
+// as it is one of the stored terms.
+var analysisFallbacks = map[string]map[string][2]string{
+	"id": {
+		"failed":   {"Sesi latihan telah selesai. Terus berlatih untuk meningkatkan kemampuan membaca.", "Fokus pada huruf-huruf yang masih sering tertukar."},
+		"unusable": {"Sesi latihan telah selesai. Anak menunjukkan kemajuan yang baik.", "Terus berlatih secara konsisten untuk hasil yang lebih baik."},
+	},
+	"en": {
+		"failed":   {"The practice session is complete. Keep practising to improve reading skills.", "Focus on the letters that are still often swapped."},
+		"unusable": {"The practice session is complete. The child is making good progress.", "Keep practising consistently for better results."},
+	},
+}
+
+// fallbackAnalysis returns the canned analysis of kind in lang (English for languages without one)
+func fallbackAnalysis(lang, kind string) (string, string, string) {
+	texts, ok := analysisFallbacks[lang]
+	if !ok {
+		texts = analysisFallbacks["en"]
+	}
+	return texts[kind][0], texts[kind][1], "baik"
+}
+
+// analysisInstructions renders analysisTaskTemplate for lang (llm.analysis_language)
+func analysisInstructions(lang string) string {
+	example, ok := recommendationExamples[lang]
+	if !ok {
+		example = recommendationExamples["en"]
+	}
+	return fmt.Sprintf(analysisTaskTemplate, languageName(lang), example)
+}
+
+func (u *dyslexiaQuestionUsecase) saveAnalysisCache(db *gorm.DB, report *entity.SessionReport) error {
 	// Convert error patterns and difficulty stats to JSON
 	errorPatternsJSON, err := json.Marshal(report.ErrorPatterns)
 	if err != nil {
@@ -897,6 +1752,7 @@ func (u *dyslexiaQuestionUsecase) saveAnalysisCache(_ context.Context, report *e
 		TotalQuestions:  report.TotalQuestions,
 		CorrectAnswers:  report.CorrectAnswers,
 		WrongAnswers:    report.WrongAnswers,
+		SessionScore:    report.SessionScore,
 		AccuracyRate:    report.AccuracyRate,
 		OverallValue:    report.OverallValue,
 		AIAnalysis:      report.AIAnalysys,
@@ -905,28 +1761,92 @@ func (u *dyslexiaQuestionUsecase) saveAnalysisCache(_ context.Context, report *e
 		DifficultyStats: string(difficultyStatsJSON),
 	}
 
-	return u.cfg.Repository.CreateOrUpdateAnalysisCache(u.cfg.DB, cache)
+	return u.cfg.Repository.CreateOrUpdateAnalysisCache(db, cache)
 }
 
-func (u *dyslexiaQuestionUsecase) saveFeedbackToChat(_ context.Context, sessionID string, analysis string, recommendations string) error {
-	// Check if feedback already exists for this session
-	existingMessages, _ := u.cfg.Repository.FindChatMessagesBySessionID(u.cfg.DB, sessionID, 1)
-	if len(existingMessages) > 0 && existingMessages[0].Role == "assistant" {
-		// Feedback already exists, don't add duplicate
+// notifySessionComplete sends the report to the configured webhook without blocking the caller
+func (u *dyslexiaQuestionUsecase) notifySessionComplete(report *entity.SessionReport) {
+	if !u.cfg.Webhook.Enabled() {
+		return
+	}
+
+	payload := *report
+	u.goBackground(func() {
+		if err := u.cfg.Webhook.Send(context.Background(), "session.complete", payload.SessionID, payload); err != nil {
+			u.logger().Warnf("[WEBHOOK] Failed to deliver session.complete for %s: %v", payload.SessionID, err)
+			return
+		}
+		u.logger().Infof("[WEBHOOK] Delivered session.complete for %s", payload.SessionID)
+	})
+}
+
+// writeChatFeedback returns report.write_chat_feedback (default true). When off the chatbot still gets
+// the analysis through its system context, so an empty history is fine.
+func (u *dyslexiaQuestionUsecase) writeChatFeedback() bool {
+	return !u.cfg.Config.IsSet("report.write_chat_feedback") || u.cfg.Config.GetBool("report.write_chat_feedback")
+}
+
+func (u *dyslexiaQuestionUsecase) saveFeedbackToChat(db *gorm.DB, sessionID string, analysis string, recommendations string) error {
+	// Feedback is posted once per session, however often the report is requested
+	exists, err := u.cfg.Repository.HasFeedbackMessage(db, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to check existing feedback: %w", err)
+	}
+	if exists {
 		return nil
 	}
 
 	// Combine analysis and recommendations into feedback message
-	feedbackMessage := fmt.Sprintf("**📊 Hasil Analisis Ujian Kamu**\n\n%s\n\n**💡 Rekomendasi:**\n%s", analysis, recommendations)
+	feedbackMessage := u.buildFeedbackMessage(analysis, recommendations)
 
 	// Save as assistant message
 	chatMsg := &internalEntity.ChatMessage{
 		SessionID: sessionID,
 		Role:      "assistant",
+		Kind:      internalEntity.ChatMessageKindFeedback,
 		Message:   feedbackMessage,
 	}
 
-	return u.cfg.Repository.CreateChatMessage(u.cfg.DB, chatMsg)
+	return u.cfg.Repository.CreateChatMessage(db, chatMsg)
+}
+
+// Emoji are written as escapes so the source encoding can never corrupt them
+const (
+	feedbackIconAnalysis       = "\U0001F4CA" // 📊
+	feedbackIconRecommendation = "\U0001F4A1" // 💡
+)
+
+const defaultFeedbackTemplate = "**{{icon_analysis}} Hasil Analisis Ujian Kamu**\n\n{{analysis}}\n\n**{{icon_recommendation}} Rekomendasi:**\n{{recommendations}}"
+
+// buildFeedbackMessage renders chat.feedback_template; chat.disable_feedback_emoji drops the icons
+func (u *dyslexiaQuestionUsecase) buildFeedbackMessage(analysis string, recommendations string) string {
+	template := u.cfg.Config.GetString("chat.feedback_template")
+	if template == "" {
+		template = defaultFeedbackTemplate
+	}
+
+	iconAnalysis, iconRecommendation := feedbackIconAnalysis, feedbackIconRecommendation
+	if u.cfg.Config.GetBool("chat.disable_feedback_emoji") {
+		iconAnalysis, iconRecommendation = "", ""
+	}
+
+	message := strings.NewReplacer(
+		"{{icon_analysis}} ", prefixIcon(iconAnalysis),
+		"{{icon_recommendation}} ", prefixIcon(iconRecommendation),
+		"{{icon_analysis}}", iconAnalysis,
+		"{{icon_recommendation}}", iconRecommendation,
+		"{{analysis}}", analysis,
+		"{{recommendations}}", recommendations,
+	).Replace(template)
+
+	return strings.ToValidUTF8(message, "")
+}
+
+func prefixIcon(icon string) string {
+	if icon == "" {
+		return ""
+	}
+	return icon + " "
 }
 
 func (u *dyslexiaQuestionUsecase) generateAIAnalysis(ctx context.Context, answers []internalEntity.UserAnswer, errorPatterns []entity.ErrorPattern, accuracyRate string) (string, string, string) {
@@ -944,12 +1864,20 @@ func (u *dyslexiaQuestionUsecase) generateAIAnalysis(ctx context.Context, answer
 	var historyContext string
 	if userID != "" {
 		historicalSessions, err := u.cfg.Repository.FindAnalysisCacheByUserID(u.cfg.DB, userID, 5) // Last 5 sessions
+		if err == nil {
+			u.checkAccuracyDrop(userID, answers, historicalSessions)
+		}
 		if err == nil && len(historicalSessions) > 0 {
 			historyContext = "\n\n**Previous Session History (showing improvement/decline):**\n"
 			for i, session := range historicalSessions {
+				// session_id may be as short as one character
+				sessionLabel := session.SessionID
+				if len(sessionLabel) > 12 {
+					sessionLabel = sessionLabel[:12] + "..."
+				}
 				historyContext += fmt.Sprintf("%d. Session %s: %s accuracy, %d/%d correct, Overall: %s (Date: %s)\n",
 					i+1,
-					session.SessionID[:12]+"...",
+					sessionLabel,
 					session.AccuracyRate,
 					session.CorrectAnswers,
 					session.TotalQuestions,
@@ -981,37 +1909,8 @@ Error Patterns by Letter Pairs:
 	// Add historical context
 	prompt += historyContext
 
-	prompt += `
-Task:
-1. Provide a brief, caring analysis in Indonesian about the child's learning patterns
-2. **IF there's previous session data**: Compare current performance with previous sessions and mention if there's improvement, decline, or consistency  
-3. **IF this is first session**: Focus on current performance and set baseline expectations
-4. Identify which letter pairs need most attention
-5. Give 2-3 specific, actionable recommendations for improvement
-6. Determine overall performance level by considering MULTIPLE factors:
-   - Accuracy rate (primary factor)
-   - **Progress trend** (improved/declined compared to previous sessions)
-   - Error patterns and consistency (which letter pairs are most problematic)
-   - Error rate per letter pair (high error rate on specific pairs indicates focused difficulty)
-   - Number of total questions attempted (shows engagement)
-   - Pattern of improvement or consistent mistakes
-
-Return response as JSON with THREE fields:
-{"analysis":"...","recommendations":"...","overall_value":"..."}
-
-IMPORTANT: 
-- For recommendations field, return as a SINGLE STRING, not an array
-- Example: "Fokus latihan pada huruf b-d. Gunakan metode visual. Berlatih setiap hari."
-- Don't judge only by accuracy percentage
-
-For overall_value, use one of these Indonesian terms based on HOLISTIC evaluation:
-- "excellent" (90-100% accuracy, minimal/no consistent error patterns, good engagement)
-- "sangat baik" (80-89% accuracy, few errors, minor patterns, good progress)
-- "baik" (70-79% accuracy, some error patterns, showing improvement potential)
-- "cukup" (60-69% accuracy, notable error patterns, needs focused practice)
-- "perlu peningkatan" (below 60% accuracy, significant error patterns, needs intensive support)
-
-Keep the language simple, encouraging, and suitable for parents/teachers of young children.`
+	targetLanguage := u.analysisLanguage()
+	prompt += analysisInstructions(targetLanguage)
 
 	// Retry mechanism: try up to 3 times before falling back
 	maxRetries := 3
@@ -1019,20 +1918,19 @@ Keep the language simple, encouraging, and suitable for parents/teachers of youn
 	var err error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		fmt.Printf("[AI ANALYSIS] Attempt %d/%d...\n", attempt, maxRetries)
+		u.logger().Debugf("[AI ANALYSIS] Attempt %d/%d...", attempt, maxRetries)
 		text, err = u.cfg.Gemini.GenerateText(ctx, prompt)
 
 		if err != nil {
-			fmt.Printf("[AI ANALYSIS] Attempt %d failed: %v\n", attempt, err)
-			if attempt < maxRetries {
+			u.logger().Warnf("[AI ANALYSIS] Attempt %d failed: %v", attempt, err)
+			// An open breaker means the provider is known to be down; retrying only adds latency
+			if attempt < maxRetries && !errors.Is(err, llm.ErrCircuitOpen) {
 				time.Sleep(time.Duration(attempt) * 500 * time.Millisecond) // Backoff delay
 				continue
 			}
 			// All retries failed
-			fmt.Printf("[AI ANALYSIS] All %d attempts failed, using fallback\n", maxRetries)
-			return "Sesi latihan telah selesai. Terus berlatih untuk meningkatkan kemampuan membaca.",
-				"Fokus pada huruf-huruf yang masih sering tertukar.",
-				"baik"
+			u.logger().Warnf("[AI ANALYSIS] All %d attempts failed, using fallback", maxRetries)
+			return fallbackAnalysis(targetLanguage, "failed")
 		}
 
 		// Parse JSON response
@@ -1049,28 +1947,37 @@ Keep the language simple, encouraging, and suitable for parents/teachers of youn
 		}
 
 		if err := json.Unmarshal([]byte(clean), &result); err != nil {
-			fmt.Printf("[AI ANALYSIS] Attempt %d - Parse error: %v\n", attempt, err)
-			fmt.Printf("[AI ANALYSIS] Response text: %s\n", text)
+			u.logger().Warnf("[AI ANALYSIS] Attempt %d - Parse error: %v", attempt, err)
+			u.recordParseFailure("analysis", prompt, text, err)
 			if attempt < maxRetries {
 				time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
 				continue
 			}
 			// All retries failed
-			fmt.Printf("[AI ANALYSIS] All %d attempts failed to parse, using fallback\n", maxRetries)
-			return "Sesi latihan telah selesai. Anak menunjukkan kemajuan yang baik.",
-				"Terus berlatih secara konsisten untuk hasil yang lebih baik.",
-				"baik"
+			u.logger().Warnf("[AI ANALYSIS] All %d attempts failed to parse, using fallback", maxRetries)
+			return fallbackAnalysis(targetLanguage, "unusable")
+		}
+
+		// Model sometimes ignores the requested language; retry with a stronger instruction
+		if !isExpectedLanguage(result.Analysis+" "+result.Recommendations, targetLanguage) {
+			u.logger().Warnf("[AI ANALYSIS] Attempt %d - Response not in %s", attempt, targetLanguage)
+			if attempt < maxRetries {
+				if !strings.HasSuffix(prompt, languageRetryInstruction(targetLanguage)) {
+					prompt += languageRetryInstruction(targetLanguage)
+				}
+				continue
+			}
+			u.logger().Warnf("[AI ANALYSIS] All %d attempts returned the wrong language, using fallback", maxRetries)
+			return fallbackAnalysis(targetLanguage, "unusable")
 		}
 
 		// Success!
-		fmt.Printf("[AI ANALYSIS] Success on attempt %d\n", attempt)
+		u.logger().Debugf("[AI ANALYSIS] Success on attempt %d", attempt)
 		return result.Analysis, result.Recommendations, result.OverallValue
 	}
 
 	// Shouldn't reach here, but just in case
-	return "Sesi latihan telah selesai. Anak menunjukkan kemajuan yang baik.",
-		"Terus berlatih secara konsisten untuk hasil yang lebih baik.",
-		"baik"
+	return fallbackAnalysis(targetLanguage, "unusable")
 }
 
 func countCorrect(answers []internalEntity.UserAnswer) int {
@@ -1084,20 +1991,24 @@ func countCorrect(answers []internalEntity.UserAnswer) int {
 }
 
 // ChatWithBot handles chatbot conversation with session context
-func (u *dyslexiaQuestionUsecase) ChatWithBot(ctx context.Context, sessionID string, userMessage string) (*entity.ChatResponse, error) {
+func (u *dyslexiaQuestionUsecase) ChatWithBot(ctx context.Context, sessionID string, userMessage string, model string) (*entity.ChatResponse, error) {
+	ctx, err := u.withRequestedModel(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+
+	// Daily per-user chat quota: reply without calling the LLM (not even for the analysis) once exhausted
+	if !u.consumeQuota(u.resolveUserID(sessionID), QuotaKindChat, 1) {
+		return &entity.ChatResponse{
+			Response:  quotaExceededChatMessage,
+			SessionID: sessionID,
+		}, nil
+	}
+
 	// 1. Check for cached analysis, generate if missing
-	cachedAnalysis, err := u.cfg.Repository.FindAnalysisCacheBySessionID(u.cfg.DB, sessionID)
-	if err != nil || cachedAnalysis == nil {
-		// Generate report to create analysis cache
-		_, err := u.GenerateSessionReport(ctx, sessionID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate analysis for chatbot: %w", err)
-		}
-		// Fetch again after generation
-		cachedAnalysis, err = u.cfg.Repository.FindAnalysisCacheBySessionID(u.cfg.DB, sessionID)
-		if err != nil || cachedAnalysis == nil {
-			return nil, fmt.Errorf("failed to fetch analysis cache: %w", err)
-		}
+	cachedAnalysis, err := u.getOrGenerateAnalysisCache(ctx, sessionID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get error patterns for training recommendations
@@ -1136,7 +2047,7 @@ Tugas kamu:
 	)
 
 	// 3. Retrieve last 10 chat messages for conversation continuity
-	chatHistory, err := u.cfg.Repository.FindChatMessagesBySessionID(u.cfg.DB, sessionID, 10)
+	chatHistory, err := u.cfg.Repository.FindChatMessagesBySessionID(u.cfg.DB, sessionID, 10, repository.ChatHistoryRecentFirst)
 	if err != nil {
 		chatHistory = []internalEntity.ChatMessage{} // Continue with empty history
 	}
@@ -1169,35 +2080,44 @@ Tugas kamu:
 		Content: userMessage,
 	})
 
+	// Drop oldest history messages so the request stays within the token budget
+	if truncated := truncateChatHistory(messages, u.chatContextBudget()); len(truncated) < len(messages) {
+		u.logger().Infof("[CHAT BOT] Dropped %d old messages to fit token budget", len(messages)-len(truncated))
+		messages = truncated
+	}
+
 	// 5. Call LLM with full context (plain text response) - with retry
 	maxRetries := 3
 	var botResponse string
 	var chatErr error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		fmt.Printf("[CHAT BOT] Attempt %d/%d...\n", attempt, maxRetries)
+		u.logger().Debugf("[CHAT BOT] Attempt %d/%d...", attempt, maxRetries)
 		botResponse, chatErr = u.cfg.Gemini.GenerateChatResponse(ctx, messages)
 
 		if chatErr != nil {
-			fmt.Printf("[CHAT BOT] Attempt %d failed: %v\n", attempt, chatErr)
-			if attempt < maxRetries {
+			u.logger().Warnf("[CHAT BOT] Attempt %d failed: %v", attempt, chatErr)
+			if attempt < maxRetries && !errors.Is(chatErr, llm.ErrCircuitOpen) {
 				time.Sleep(time.Duration(attempt) * 500 * time.Millisecond) // Backoff delay
 				continue
 			}
 			// All retries failed
-			fmt.Printf("[CHAT BOT] All %d attempts failed\n", maxRetries)
-			return nil, fmt.Errorf("failed to generate chatbot response after %d attempts: %w", maxRetries, chatErr)
+			u.logger().Warnf("[CHAT BOT] All %d attempts failed", maxRetries)
+			return nil, fmt.Errorf("%w: failed to generate chatbot response after %d attempts: %w", ErrUpstream, maxRetries, chatErr)
 		}
 
 		// Success!
-		fmt.Printf("[CHAT BOT] Success on attempt %d\n", attempt)
+		u.logger().Debugf("[CHAT BOT] Success on attempt %d", attempt)
 		break
 	}
 
 	if chatErr != nil {
-		return nil, fmt.Errorf("failed to generate chatbot response: %w", chatErr)
+		return nil, fmt.Errorf("%w: failed to generate chatbot response: %w", ErrUpstream, chatErr)
 	}
 
+	// Screen output before persisting and returning it (children read this)
+	botResponse = u.screenChatResponse(ctx, messages, botResponse)
+
 	// 6. Save both user message and bot response to database
 	// Save user message
 	userMsg := &internalEntity.ChatMessage{
@@ -1225,11 +2145,40 @@ Tugas kamu:
 	}, nil
 }
 
-// GetChatHistory retrieves chat history for a session
-func (u *dyslexiaQuestionUsecase) GetChatHistory(ctx context.Context, sessionID string) ([]entity.ChatHistoryItem, error) {
-	messages, err := u.cfg.Repository.FindChatMessagesBySessionID(u.cfg.DB, sessionID, 50)
+// getOrGenerateAnalysisCache returns the cached analysis, generating the report once if missing.
+// Concurrent callers wait on the session lock and then read the fresh cache.
+func (u *dyslexiaQuestionUsecase) getOrGenerateAnalysisCache(ctx context.Context, sessionID string) (*internalEntity.SessionAnalysisCache, error) {
+	// An invalidated analysis (answers regraded or corrected) is a miss: it is regenerated below
+	if cached, err := u.cfg.Repository.FindAnalysisCacheBySessionID(u.cfg.DB, sessionID); err == nil && cached != nil && cached.InvalidatedAt == nil {
+		return cached, nil
+	}
+
+	unlock := u.reportLocks.Lock(sessionID)
+	defer unlock()
+
+	// Re-check on the primary: another request may have generated it while we waited
+	if cached, err := u.cfg.Repository.FindAnalysisCacheBySessionID(u.primaryDB(ctx), sessionID); err == nil && cached != nil && cached.InvalidatedAt == nil {
+		return cached, nil
+	}
+
+	// Generate report to create analysis cache; report.min_answers_for_analysis only gates the report endpoint
+	if _, err := u.generateSessionReport(ctx, sessionID, 0); err != nil {
+		return nil, fmt.Errorf("failed to generate analysis for chatbot: %w", err)
+	}
+
+	// Fetch again after generation
+	cached, err := u.cfg.Repository.FindAnalysisCacheBySessionID(u.primaryDB(ctx), sessionID)
+	if err != nil || cached == nil {
+		return nil, fmt.Errorf("failed to fetch analysis cache: %w", err)
+	}
+	return cached, nil
+}
+
+// GetChatHistory retrieves one page of chat history for a session, oldest first
+func (u *dyslexiaQuestionUsecase) GetChatHistory(ctx context.Context, sessionID string, page, perPage int) ([]entity.ChatHistoryItem, int64, error) {
+	messages, total, err := u.cfg.Repository.FindChatMessagesPaginated(u.dbWithContext(ctx), sessionID, (page-1)*perPage, perPage)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch chat history: %w", err)
+		return nil, 0, fmt.Errorf("failed to fetch chat history: %w", err)
 	}
 
 	history := make([]entity.ChatHistoryItem, 0, len(messages))
@@ -1241,7 +2190,45 @@ func (u *dyslexiaQuestionUsecase) GetChatHistory(ctx context.Context, sessionID
 		})
 	}
 
-	return history, nil
+	return history, total, nil
+}
+
+// generatedQuestionsFor loads the generated questions behind answers in a single query, keyed by question id
+func (u *dyslexiaQuestionUsecase) generatedQuestionsFor(answers []internalEntity.UserAnswer) map[string]internalEntity.GeneratedQuestion {
+	ids := make([]string, 0, len(answers))
+	seen := make(map[string]bool)
+	for _, answer := range answers {
+		if !seen[answer.QuestionID] {
+			seen[answer.QuestionID] = true
+			ids = append(ids, answer.QuestionID)
+		}
+	}
+
+	byID := make(map[string]internalEntity.GeneratedQuestion, len(ids))
+	questions, err := u.cfg.Repository.FindGeneratedByQuestionIDs(u.cfg.DB, ids)
+	if err != nil {
+		u.logger().Warnf("Failed to load generated questions: %v", err)
+		return byID
+	}
+	for _, q := range questions {
+		byID[q.QuestionID] = q
+	}
+	return byID
+}
+
+// sortErrorPatterns orders patterns worst first: error rate desc, then error count desc, then letter pair.
+// Rates are compared as errors/total cross products so rounding in the ErrorRate string never ties them.
+func sortErrorPatterns(patterns []entity.ErrorPattern) {
+	sort.SliceStable(patterns, func(i, j int) bool {
+		a, b := patterns[i], patterns[j]
+		if left, right := a.ErrorCount*b.TotalCount, b.ErrorCount*a.TotalCount; left != right {
+			return left > right
+		}
+		if a.ErrorCount != b.ErrorCount {
+			return a.ErrorCount > b.ErrorCount
+		}
+		return a.LetterPair < b.LetterPair
+	})
 }
 
 // analyzeErrorPatterns analyzes user answers to find problematic letter pairs
@@ -1254,11 +2241,13 @@ func (u *dyslexiaQuestionUsecase) analyzeErrorPatterns(answers []internalEntity.
 		total  int
 	})
 
+	questions := u.generatedQuestionsFor(answers)
 	for _, answer := range answers {
+		if answer.Skipped {
+			continue
+		}
 		// Get letter pair info
-		generatedQ, _ := u.cfg.Repository.FindGeneratedByQuestionID(u.cfg.DB, answer.QuestionID)
-		if generatedQ != nil && generatedQ.TargetLetterPair != "" {
-			pair := generatedQ.TargetLetterPair
+		if pair := questions[answer.QuestionID].TargetLetterPair; pair != "" {
 			stats := letterPairErrors[pair]
 			stats.total++
 			if !answer.IsCorrect {
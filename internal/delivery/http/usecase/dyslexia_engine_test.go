@@ -0,0 +1,898 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+	"github.com/evandrarf/dinacom-be/internal/pkg/llm"
+	"github.com/evandrarf/dinacom-be/internal/pkg/metrics"
+	"github.com/spf13/viper"
+)
+
+// The correct answer comes first and no other option repeats it, whatever its case
+func TestDeduplicateOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []string
+		correct string
+		want    []string
+	}{
+		{name: "already unique", options: []string{"dola", "pola"}, correct: "bola", want: []string{"bola", "dola", "pola"}},
+		{name: "correct answer among the options", options: []string{"dola", "bola", "pola"}, correct: "bola", want: []string{"bola", "dola", "pola"}},
+		{name: "case-insensitive collision", options: []string{"BOLA", "dola"}, correct: "bola", want: []string{"bola", "dola"}},
+		{name: "duplicates and blanks", options: []string{"dola", "DOLA", ""}, correct: "bola", want: []string{"bola", "dola"}},
+		{name: "no correct answer", options: []string{"dola", "pola"}, correct: " ", want: []string{"dola", "pola"}},
+	}
+	for _, tt := range tests {
+		if got := deduplicateOptions(tt.options, tt.correct); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: deduplicateOptions = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFreshSince(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	if got := u.freshSince(); !got.IsZero() {
+		t.Errorf("freshSince without max_age_days = %v, want zero", got)
+	}
+
+	u.cfg.Config.Set("questions.max_age_days", 30)
+	want := time.Now().AddDate(0, 0, -30)
+	if got := u.freshSince(); got.Sub(want).Abs() > time.Minute {
+		t.Errorf("freshSince = %v, want about %v", got, want)
+	}
+}
+
+// Requests without difficulty or pattern fall back to dyslexia.default_difficulty and default_patterns
+func TestGenerateConfiguredDefaults(t *testing.T) {
+	tests := []struct {
+		name           string
+		set            map[string]interface{}
+		wantDifficulty entity.Difficulty
+		wantPairs      []string
+	}{
+		{name: "built-in defaults", wantDifficulty: entity.DifficultyEasy, wantPairs: allLetterPairs},
+		{
+			name:           "overridden",
+			set:            map[string]interface{}{"dyslexia.default_difficulty": "Medium", "dyslexia.default_patterns": []string{"p-q", " M-N "}},
+			wantDifficulty: entity.DifficultyMedium,
+			wantPairs:      []string{"p-q", "m-n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := viper.New()
+			config.Set("llm.gemini.disable_ai_prompt", true)
+			for k, v := range tt.set {
+				config.Set(k, v)
+			}
+			u := NewDyslexiaQuestionUsecase(DyslexiaQuestionConfig{Config: config, Repository: newFakeRepo()})
+
+			questions, _, err := u.Generate(context.Background(), entity.GenerateOptions{Count: 10, UseAI: true, Shuffle: true})
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			for _, q := range questions {
+				if q.Difficulty != tt.wantDifficulty || !slices.Contains(tt.wantPairs, q.TargetLetterPair) {
+					t.Errorf("question %s is %s/%s, want %s with a pair in %v", q.ID, q.Difficulty, q.TargetLetterPair, tt.wantDifficulty, tt.wantPairs)
+				}
+			}
+		})
+	}
+}
+
+// Without dyslexia.default_difficulty the first configured level is the default
+func TestDefaultDifficultyFollowsConfiguredLevels(t *testing.T) {
+	levels := []entity.DifficultyLevel{{Name: "medium", MinLength: 5, MaxLength: 6}, {Name: "hard", MinLength: 6}}
+	tests := []struct {
+		name       string
+		configured string
+		want       entity.Difficulty
+	}{
+		{name: "first level without a configured default", want: "medium"},
+		{name: "configured default", configured: " HARD ", want: "hard"},
+		{name: "unknown default keeps the first level", configured: "easy", want: "medium"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := viper.New()
+			if tt.configured != "" {
+				config.Set("dyslexia.default_difficulty", tt.configured)
+			}
+			u := NewDyslexiaQuestionUsecase(DyslexiaQuestionConfig{Config: config, Difficulties: levels}).(*dyslexiaQuestionUsecase)
+			t.Cleanup(func() { _ = u.Shutdown(context.Background()) })
+
+			if u.defaultDifficulty != tt.want {
+				t.Errorf("defaultDifficulty = %q, want %q", u.defaultDifficulty, tt.want)
+			}
+		})
+	}
+}
+
+// A custom level reaches the prompt with its guidance, and its word length bounds the AI's answer
+func TestGenerateCustomDifficulty(t *testing.T) {
+	tests := []struct {
+		name    string
+		word    string
+		wantErr bool
+	}{
+		{name: "within the level's length", word: "bola"},
+		{name: "too long for the level", word: "bunga", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newTestUsecase(t, newFakeRepo())
+			u.cfg.PromptTemplate = defaultPromptTemplate
+			u.difficulties = []entity.DifficultyLevel{
+				{Name: "beginner", MinLength: 3, MaxLength: 4, Guidance: "Very short words with ONE confusing letter pair"},
+				{Name: "easy", MinLength: 4, MaxLength: 5},
+			}
+			var prompt atomic.Value
+			u.cfg.Gemini, _ = newFakeLLM(t, func(p string) (string, int) {
+				prompt.Store(p)
+				return fmt.Sprintf(`{"correctAnswer":%q,"options":[%q,"dola","pola","boda"]}`, tt.word, tt.word), http.StatusOK
+			})
+
+			q, _, err := u.generateFromAI(context.Background(), "beginner", "b-d", true, true)
+			sent, _ := prompt.Load().(string)
+			if !strings.Contains(sent, "- BEGINNER: Very short words with ONE confusing letter pair") || !strings.Contains(sent, "- EASY: Words of 4-5 letters") {
+				t.Errorf("prompt does not list the configured levels:\n%s", sent)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("generateFromAI = %+v, want a word length error", q)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("generateFromAI: %v", err)
+			}
+			if q.Difficulty != "beginner" || q.Answer != tt.word {
+				t.Errorf("question = %s/%q, want beginner/%q", q.Difficulty, q.Answer, tt.word)
+			}
+		})
+	}
+}
+
+// A blank correct answer or too few non-blank options makes generateFromAI fail, so Generate falls back
+func TestGenerateFromAIRejectsBlankWords(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+	}{
+		{name: "empty correct answer", reply: `{"correctAnswer":"","options":["bola","dola","bela","pola"]}`},
+		{name: "whitespace correct answer", reply: `{"correctAnswer":"   ","options":["bola","dola","bela","pola"]}`},
+		{name: "whitespace options", reply: `{"correctAnswer":"bola","options":["bola"," ","\t",""]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newTestUsecase(t, newFakeRepo())
+			u.cfg.Config.Set("dyslexia.allow_include_answer", true)
+			u.cfg.Gemini, _ = newFakeLLM(t, replyWith(tt.reply))
+
+			if q, _, err := u.generateFromAI(context.Background(), entity.DifficultyEasy, "b-d", true, true); err == nil {
+				t.Fatalf("generateFromAI accepted %s: %+v", tt.reply, q)
+			}
+
+			questions, _, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 1, IncludeAnswer: true, Patterns: []string{"b-d"}, UseAI: true, Shuffle: true})
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			if len(questions) != 1 || strings.TrimSpace(questions[0].Answer) == "" {
+				t.Fatalf("Generate = %+v, want one fallback question with an answer", questions)
+			}
+			for _, opt := range questions[0].Options {
+				if strings.TrimSpace(opt) == "" {
+					t.Errorf("fallback question has a blank option: %q", questions[0].Options)
+				}
+			}
+		})
+	}
+}
+
+// The batch path skips questions with blank words and trims the rest
+func TestGenerateBatchFromAISkipsBlankWords(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	u.cfg.Gemini, _ = newFakeLLM(t, replyWith(`{"questions":[
+		{"correctAnswer":" ","options":["bola","dola"]},
+		{"correctAnswer":"pagi","options":[" ",""," pagi","qagi "]},
+		{"correctAnswer":"kaki","options":["kaki","  "]}
+	]}`))
+
+	questions, err := u.generateBatchFromAI(context.Background(), entity.DifficultyEasy, 3, []string{"p-q"}, true)
+	if err != nil {
+		t.Fatalf("generateBatchFromAI: %v", err)
+	}
+	if len(questions) != 1 || questions[0].Answer != "pagi" {
+		t.Fatalf("questions = %+v, want only the pagi question", questions)
+	}
+	for _, opt := range questions[0].Options {
+		if opt != strings.TrimSpace(opt) || opt == "" {
+			t.Errorf("option %q is not trimmed", opt)
+		}
+	}
+}
+
+// Once the breaker opens Generate falls back without contacting the provider, and after the cooldown a
+// successful probe closes it again
+func TestGenerateShortCircuitsOnOpenBreaker(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	client, fake := newFakeLLM(t, func(string) (string, int) {
+		if down.Load() {
+			return "", http.StatusServiceUnavailable
+		}
+		return `{"correctAnswer":"bola","options":["bola","dola","pola","boda"]}`, http.StatusOK
+	})
+	breaker := llm.NewBreaker(2, 50*time.Millisecond)
+	client.SetBreaker(breaker)
+	u := newTestUsecase(t, newFakeRepo())
+	u.cfg.Gemini = client
+
+	generate := func() {
+		t.Helper()
+		questions, _, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 1, Patterns: []string{"b-d"}, UseAI: true, Shuffle: true})
+		if err != nil || len(questions) != 1 {
+			t.Fatalf("Generate = %+v, %v; want one question", questions, err)
+		}
+	}
+
+	generate()
+	generate()
+	if got := breaker.State(); got != llm.BreakerOpen {
+		t.Fatalf("breaker after 2 provider failures = %s, want open", got)
+	}
+	generate()
+	if got := fake.calls.Load(); got != 2 {
+		t.Errorf("provider calls = %d, want 2: the open breaker must short-circuit to the fallback", got)
+	}
+
+	down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	generate()
+	if got, calls := breaker.State(), fake.calls.Load(); got != llm.BreakerClosed || calls != 3 {
+		t.Errorf("after cooldown: breaker %s with %d provider calls, want closed after one probe", got, calls)
+	}
+}
+
+// The stored feedback carries real emoji, never the double-encoded "ðŸ" bytes
+func TestSaveFeedbackToChatEmoji(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     map[string]interface{}
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "default template",
+			want:    []string{"**📊 Hasil Analisis Ujian Kamu**", "**💡 Rekomendasi:**\nlatihan b-d"},
+			notWant: []string{"ðŸ"},
+		},
+		{
+			name:    "emoji disabled",
+			set:     map[string]interface{}{"chat.disable_feedback_emoji": true},
+			want:    []string{"**Hasil Analisis Ujian Kamu**", "**Rekomendasi:**"},
+			notWant: []string{"📊", "💡", "ðŸ"},
+		},
+		{
+			name: "custom template",
+			set:  map[string]interface{}{"chat.feedback_template": "{{icon_analysis}} {{analysis}} | {{recommendations}}"},
+			want: []string{"📊 bagus | latihan b-d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			u := newTestUsecase(t, repo)
+			for k, v := range tt.set {
+				u.cfg.Config.Set(k, v)
+			}
+
+			if err := u.saveFeedbackToChat(u.cfg.DB, "s-1", "bagus", "latihan b-d"); err != nil {
+				t.Fatalf("saveFeedbackToChat: %v", err)
+			}
+			if len(repo.chats) != 1 {
+				t.Fatalf("stored %d messages, want 1", len(repo.chats))
+			}
+			message := repo.chats[0].Message
+			if !utf8.ValidString(message) {
+				t.Errorf("message %q is not valid UTF-8", message)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(message, want) {
+					t.Errorf("message %q does not contain %q", message, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(message, notWant) {
+					t.Errorf("message %q contains %q", message, notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateWordLength(t *testing.T) {
+	tests := []struct {
+		word       string
+		difficulty entity.Difficulty
+		set        map[string]interface{}
+		wantErr    bool
+	}{
+		{word: "bus", difficulty: entity.DifficultyEasy, wantErr: true},
+		{word: "bola", difficulty: entity.DifficultyEasy},
+		{word: "dadu", difficulty: entity.DifficultyEasy},
+		{word: "bunga", difficulty: entity.DifficultyEasy},
+		{word: "boneka", difficulty: entity.DifficultyEasy, wantErr: true},
+		{word: "buku", difficulty: entity.DifficultyMedium, wantErr: true},
+		{word: "bunga", difficulty: entity.DifficultyMedium},
+		{word: "pindah", difficulty: entity.DifficultyMedium},
+		{word: "bendera", difficulty: entity.DifficultyMedium, wantErr: true},
+		{word: "bunga", difficulty: entity.DifficultyHard, wantErr: true},
+		{word: "pendidikan", difficulty: entity.DifficultyHard},
+		{word: "bus", difficulty: entity.DifficultyEasy, set: map[string]interface{}{"dyslexia.word_length.easy.min": 3}},
+		{word: "bunga", difficulty: entity.DifficultyEasy, set: map[string]interface{}{"dyslexia.word_length.easy.max": 4}, wantErr: true},
+		{word: "pendidikan", difficulty: entity.DifficultyHard, set: map[string]interface{}{"dyslexia.word_length.hard.max": 8}, wantErr: true},
+	}
+	for _, tt := range tests {
+		u := newTestUsecase(t, newFakeRepo())
+		for k, v := range tt.set {
+			u.cfg.Config.Set(k, v)
+		}
+		if err := u.validateWordLength(tt.word, tt.difficulty); (err != nil) != tt.wantErr {
+			t.Errorf("validateWordLength(%q, %s) with %v = %v, want error %v", tt.word, tt.difficulty, tt.set, err, tt.wantErr)
+		}
+	}
+}
+
+// generateFromAI rejects a word outside the difficulty's range, so Generate serves a fallback instead
+func TestGenerateFromAIRejectsWordLength(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	u.cfg.Gemini, _ = newFakeLLM(t, replyWith(`{"correctAnswer":"bus","options":["bus","dus","pus","bis"]}`))
+
+	if q, _, err := u.generateFromAI(context.Background(), entity.DifficultyEasy, "b-d", true, true); err == nil {
+		t.Fatalf("generateFromAI accepted a 3-letter easy word: %+v", q)
+	}
+	u.cfg.Gemini, _ = newFakeLLM(t, replyWith(`{"questions":[{"correctAnswer":"bus","options":["bus","dus"]},{"correctAnswer":"bola","options":["bola","dola"]}]}`))
+	questions, err := u.generateBatchFromAI(context.Background(), entity.DifficultyEasy, 2, []string{"b-d"}, true)
+	if err != nil {
+		t.Fatalf("generateBatchFromAI: %v", err)
+	}
+	if len(questions) != 1 || questions[0].Answer != "bola" {
+		t.Errorf("batch kept %+v, want only bola", questions)
+	}
+}
+
+// Every AI reply that is not JSON counts once against its code path
+func TestParseFailureCounter(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	u.cfg.Metrics = metrics.NewRegistry()
+	u.cfg.Gemini, _ = newFakeLLM(t, replyWith("Maaf, saya tidak bisa membuat soal itu."))
+
+	for range 2 {
+		if _, _, err := u.generateFromAI(context.Background(), entity.DifficultyEasy, "b-d", true, true); err == nil {
+			t.Fatal("generateFromAI accepted a non-JSON reply")
+		}
+	}
+	if _, err := u.generateBatchFromAI(context.Background(), entity.DifficultyEasy, 2, []string{"b-d"}, true); err == nil {
+		t.Fatal("generateBatchFromAI accepted a non-JSON reply")
+	}
+
+	want := map[string]int64{"llm_parse_failures_generate_single": 2, "llm_parse_failures_generate_batch": 1}
+	if got := u.cfg.Metrics.Snapshot(); !maps.Equal(got, want) {
+		t.Errorf("counters = %v, want %v", got, want)
+	}
+}
+
+// The fallback preview is reproducible under a seed and always carries the answer
+func TestPreviewFallback(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	u.cfg.Config.Set("dyslexia.allow_include_answer", true)
+
+	got, err := u.PreviewFallback(context.Background(), entity.DifficultyEasy, "b-d", 1)
+	if err != nil {
+		t.Fatalf("PreviewFallback: %v", err)
+	}
+	want := []string{"bola", "dola", "kola", "sola"}
+	if got.Answer != "bola" || !slices.Equal(got.Options, want) || got.TargetLetterPair != "b-d" || got.Difficulty != entity.DifficultyEasy {
+		t.Errorf("fallback = %+v, want answer bola with options %v", got, want)
+	}
+
+	again, _ := u.PreviewFallback(context.Background(), entity.DifficultyEasy, "b-d", 1)
+	if again.Answer != got.Answer || !slices.Equal(again.Options, got.Options) {
+		t.Errorf("seed 1 gave %+v, then %+v", got, again)
+	}
+
+	if _, err := u.PreviewFallback(context.Background(), entity.DifficultyEasy, "x-y", 1); err == nil {
+		t.Error("unknown pattern accepted")
+	}
+}
+
+// Content ids depend only on the word, difficulty and option set; entropy ids never repeat
+func TestQuestionIDScheme(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	options := []string{"bola", "dola", "bolb"}
+
+	u.cfg.Config.Set("questions.id_scheme", "content")
+	first := u.questionID("bola", entity.DifficultyEasy, options)
+	if again := u.questionID(" BOLA ", entity.DifficultyEasy, []string{"Bolb", "bola", "DOLA"}); again != first {
+		t.Errorf("same content gave %s and %s", first, again)
+	}
+	for _, other := range []string{
+		u.questionID("dola", entity.DifficultyEasy, options),
+		u.questionID("bola", entity.DifficultyMedium, options),
+		u.questionID("bola", entity.DifficultyEasy, []string{"bola", "dola", "pola"}),
+	} {
+		if other == first {
+			t.Errorf("different content shares the id %s", first)
+		}
+	}
+
+	u.cfg.Config.Set("questions.id_scheme", "entropy")
+	if a, b := u.questionID("bola", entity.DifficultyEasy, options), u.questionID("bola", entity.DifficultyEasy, options); a == b {
+		t.Errorf("entropy ids repeat: %s", a)
+	}
+}
+
+// Session ids shorter than the 12-character label are printed whole instead of panicking
+func TestAnalysisHistoryShortSessionID(t *testing.T) {
+	repo := newFakeRepo()
+	repo.caches["s1"] = &internalEntity.SessionAnalysisCache{SessionID: "s1", AccuracyRate: "80.00%", CreatedAt: time.Now()}
+	repo.caches["session-with-a-long-id"] = &internalEntity.SessionAnalysisCache{SessionID: "session-with-a-long-id", AccuracyRate: "60.00%", CreatedAt: time.Now()}
+	u := newTestUsecase(t, repo)
+
+	var prompt string
+	u.cfg.Gemini, _ = newFakeLLM(t, func(p string) (string, int) {
+		prompt = p
+		return `{"analysis":"Anak sudah lebih baik dalam membedakan huruf b dan d.","recommendations":"Latihan setiap hari.","overall_value":"baik"}`, http.StatusOK
+	})
+
+	answers := []internalEntity.UserAnswer{{UserID: "user-1", SessionID: "s2"}}
+	u.generateAIAnalysis(context.Background(), answers, nil, "50%")
+	if !strings.Contains(prompt, "Session s1:") || !strings.Contains(prompt, "Session session-with...:") {
+		t.Errorf("history labels missing from prompt:\n%s", prompt)
+	}
+}
+
+// includeAnswer=true only exposes answers while dyslexia.allow_include_answer is enabled
+func TestIncludeAnswerGuard(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        map[string]any
+		includeAnswer bool
+		wantAnswer    bool
+	}{
+		{name: "guard unset", includeAnswer: true},
+		{name: "guard active", config: map[string]any{"dyslexia.allow_include_answer": false}, includeAnswer: true},
+		{name: "guard off", config: map[string]any{"dyslexia.allow_include_answer": true}, includeAnswer: true, wantAnswer: true},
+		{name: "not requested", config: map[string]any{"dyslexia.allow_include_answer": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.questions["q-cached"] = &internalEntity.GeneratedQuestion{QuestionID: "q-cached", Difficulty: "easy", TargetLetterPair: "b-d", Options: `["bola","dola"]`, CorrectAnswer: "bola"}
+			u := newTestUsecase(t, repo)
+			for key, value := range tt.config {
+				u.cfg.Config.Set(key, value)
+			}
+
+			questions, _, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 1, IncludeAnswer: tt.includeAnswer, Patterns: []string{"b-d"}, Shuffle: true})
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			if len(questions) != 1 || (questions[0].Answer != "") != tt.wantAnswer {
+				t.Errorf("Generate = %+v, want the answer exposed %v", questions, tt.wantAnswer)
+			}
+
+			preview, err := u.PreviewFallback(context.Background(), entity.DifficultyEasy, "b-d", 1)
+			if err != nil {
+				t.Fatalf("PreviewFallback: %v", err)
+			}
+			if wantPreview := u.cfg.Config.GetBool("dyslexia.allow_include_answer"); (preview.Answer != "") != wantPreview {
+				t.Errorf("PreviewFallback answer = %q, want exposed %v", preview.Answer, wantPreview)
+			}
+		})
+	}
+}
+
+func TestGetChatHistoryPage(t *testing.T) {
+	repo := newFakeRepo()
+	for i := 1; i <= 5; i++ {
+		repo.chats = append(repo.chats, internalEntity.ChatMessage{SessionID: "sess-1", Role: "user", Message: fmt.Sprintf("m%d", i)})
+	}
+	repo.chats = append(repo.chats, internalEntity.ChatMessage{SessionID: "sess-2", Role: "user", Message: "other"})
+	u := newTestUsecase(t, repo)
+
+	tests := []struct {
+		page, perPage int
+		want          []string
+	}{
+		{page: 1, perPage: 2, want: []string{"m1", "m2"}},
+		{page: 3, perPage: 2, want: []string{"m5"}},
+		{page: 4, perPage: 2},
+	}
+	for _, tt := range tests {
+		history, total, err := u.GetChatHistory(context.Background(), "sess-1", tt.page, tt.perPage)
+		if err != nil {
+			t.Fatalf("GetChatHistory: %v", err)
+		}
+		var got []string
+		for _, item := range history {
+			got = append(got, item.Message)
+		}
+		if total != 5 || !slices.Equal(got, tt.want) {
+			t.Errorf("page %d = %v (total %d), want %v (total 5)", tt.page, got, total, tt.want)
+		}
+	}
+}
+
+// A started session with no answers or chat messages yields empty, non-nil lists
+func TestEmptySessionLists(t *testing.T) {
+	repo := newFakeRepo()
+	repo.sessions["sess-empty"] = &internalEntity.Session{SessionID: "sess-empty"}
+	u := newTestUsecase(t, repo)
+
+	answers, _, err := u.GetSessionAnswers(context.Background(), "sess-empty")
+	if err != nil || answers == nil || len(answers) != 0 {
+		t.Errorf("GetSessionAnswers = %#v, %v; want an empty slice", answers, err)
+	}
+	history, total, err := u.GetChatHistory(context.Background(), "sess-empty", 1, 50)
+	if err != nil || history == nil || len(history) != 0 || total != 0 {
+		t.Errorf("GetChatHistory = %#v (total %d), %v; want an empty slice", history, total, err)
+	}
+}
+
+func TestSplitPatterns(t *testing.T) {
+	tests := []struct {
+		name         string
+		patterns     []string
+		wantValid    []string
+		wantRejected []string
+	}{
+		{name: "none", patterns: nil, wantValid: []string{}},
+		{name: "normalized", patterns: []string{" B-D ", "p-q"}, wantValid: []string{"b-d", "p-q"}},
+		{name: "blanks skipped", patterns: []string{"", "  ", "m-w"}, wantValid: []string{"m-w"}},
+		{name: "unknown rejected", patterns: []string{"b-d", "X-Y", "d-b"}, wantValid: []string{"b-d"}, wantRejected: []string{"x-y", "d-b"}},
+		{name: "all rejected", patterns: []string{"bd"}, wantValid: []string{}, wantRejected: []string{"bd"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, rejected := splitPatterns(tt.patterns)
+			if !slices.Equal(valid, tt.wantValid) || !slices.Equal(rejected, tt.wantRejected) {
+				t.Errorf("splitPatterns(%q) = %q, %q; want %q, %q", tt.patterns, valid, rejected, tt.wantValid, tt.wantRejected)
+			}
+		})
+	}
+}
+
+// Strict mode (default) rejects any unknown pattern; lenient mode drops them while a valid one remains
+func TestRequestPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		patterns []string
+		want     []string
+		wantErr  string
+	}{
+		{name: "strict accepts known", patterns: []string{"b-d", "P-Q"}, want: []string{"b-d", "p-q"}},
+		{name: "strict rejects one", patterns: []string{"b-d", "x-y"}, wantErr: "invalid pattern: x-y (allowed: b-d, p-q, m-w, n-u, m-n)"},
+		{name: "strict lists every rejected", patterns: []string{"x-y", "z"}, wantErr: "invalid patterns: x-y, z"},
+		{name: "lenient drops unknown", mode: "Lenient", patterns: []string{"b-d", "x-y"}, want: []string{"b-d"}},
+		{name: "lenient without a valid pattern", mode: "lenient", patterns: []string{"x-y"}, wantErr: "invalid pattern: x-y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newTestUsecase(t, newFakeRepo())
+			u.cfg.Config.Set("dyslexia.pattern_mode", tt.mode)
+			logs := captureLog(u)
+
+			got, err := u.requestPatterns(tt.patterns)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("requestPatterns: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("requestPatterns = %q, want %q", got, tt.want)
+			}
+			if dropped := len(tt.patterns) > len(tt.want); dropped != strings.Contains(logs.String(), "Ignoring invalid patterns") {
+				t.Errorf("dropped patterns logged = %v, want %v: %s", !dropped, dropped, logs.String())
+			}
+		})
+	}
+}
+
+// Reports and answer logs look every answered question up in one repository call, whatever the answer count
+func TestSessionReportSingleQuestionLookup(t *testing.T) {
+	for _, n := range []int{1, 5, 20} {
+		t.Run(fmt.Sprintf("%d answers", n), func(t *testing.T) {
+			repo := newFakeRepo()
+			pairs := []string{"b-d", "p-q"}
+			for i := 0; i < n; i++ {
+				id := fmt.Sprintf("q-%d", i)
+				repo.questions[id] = &internalEntity.GeneratedQuestion{QuestionID: id, Difficulty: "easy", TargetLetterPair: pairs[i%2]}
+				repo.answers = append(repo.answers, internalEntity.UserAnswer{
+					ID: uint(i + 1), UserID: "user-1", SessionID: "sess-1", QuestionID: id, Difficulty: "easy", IsCorrect: i%3 == 0,
+				})
+			}
+			u := newTestUsecase(t, repo)
+
+			report, err := u.GenerateSessionReport(context.Background(), "sess-1")
+			if err != nil {
+				t.Fatalf("GenerateSessionReport: %v", err)
+			}
+			if repo.questionLookups != 1 {
+				t.Errorf("report question lookups = %d, want 1", repo.questionLookups)
+			}
+			var total int
+			for _, p := range report.ErrorPatterns {
+				total += p.TotalCount
+			}
+			if total != n {
+				t.Errorf("error patterns cover %d answers, want %d: %+v", total, n, report.ErrorPatterns)
+			}
+
+			repo.questionLookups = 0
+			logs, _, err := u.GetSessionAnswers(context.Background(), "sess-1")
+			if err != nil {
+				t.Fatalf("GetSessionAnswers: %v", err)
+			}
+			if repo.questionLookups != 1 || len(logs) != n || logs[0].TargetLetterPair != "b-d" {
+				t.Errorf("answer logs = %d with %d lookups (first pair %q), want %d with 1", len(logs), repo.questionLookups, logs[0].TargetLetterPair, n)
+			}
+		})
+	}
+}
+
+// Every question Generate serves gets one serve log row with the session's user
+func TestGenerateLogsServedQuestions(t *testing.T) {
+	repo := newFakeRepo()
+	for _, id := range []string{"q-1", "q-2", "q-3"} {
+		repo.questions[id] = &internalEntity.GeneratedQuestion{QuestionID: id, Difficulty: "easy", TargetLetterPair: "b-d", Options: `["bola","dola"]`, CorrectAnswer: "bola"}
+	}
+	repo.sessions["sess-1"] = &internalEntity.Session{SessionID: "sess-1", UserID: "user-1"}
+	u := newTestUsecase(t, repo)
+
+	questions, _, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 3, Patterns: []string{"b-d"}, SessionID: "sess-1", Shuffle: true})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	logs := waitForServeLogs(t, repo, "sess-1", len(questions))
+	if len(logs) != len(questions) {
+		t.Fatalf("serve logs = %d, want one per question (%d)", len(logs), len(questions))
+	}
+	for i, l := range logs {
+		if l.QuestionID != questions[i].ID || l.UserID != "user-1" || l.ServedAt.IsZero() {
+			t.Errorf("serve log %d = %+v, want %s served to user-1", i, l, questions[i].ID)
+		}
+	}
+}
+
+// Questions served to a session are excluded from its next Generate even before they are answered
+func TestGenerateExcludesServedQuestions(t *testing.T) {
+	repo := newFakeRepo()
+	for _, id := range []string{"q-1", "q-2", "q-3", "q-4"} {
+		repo.questions[id] = &internalEntity.GeneratedQuestion{QuestionID: id, Difficulty: "easy", TargetLetterPair: "b-d", Options: `["bola","dola"]`, CorrectAnswer: "bola"}
+	}
+	repo.answers = []internalEntity.UserAnswer{{SessionID: "sess-1", QuestionID: "q-4"}}
+	u := newTestUsecase(t, repo)
+
+	seen := map[string]bool{"q-4": true}
+	for call := 1; call <= 3; call++ {
+		questions, _, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 1, Patterns: []string{"b-d"}, SessionID: "sess-1", Shuffle: true})
+		if err != nil || len(questions) != 1 {
+			t.Fatalf("Generate %d = %+v, %v; want one question", call, questions, err)
+		}
+		if id := questions[0].ID; seen[id] {
+			t.Fatalf("Generate %d served %s again", call, id)
+		} else {
+			seen[id] = true
+		}
+	}
+
+	// The served set is per session
+	other, _, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 4, Patterns: []string{"b-d"}, SessionID: "sess-2", Shuffle: true})
+	if err != nil || len(other) != 4 {
+		t.Errorf("another session got %d questions (%v), want all 4 cached ones", len(other), err)
+	}
+}
+
+// Concurrent Generate calls each log their own questions; run with -race
+func TestGenerateServeLogConcurrent(t *testing.T) {
+	repo := newFakeRepo()
+	repo.questions["q-1"] = &internalEntity.GeneratedQuestion{QuestionID: "q-1", Difficulty: "easy", TargetLetterPair: "b-d", Options: `["bola","dola"]`, CorrectAnswer: "bola"}
+	u := newTestUsecase(t, repo)
+
+	const sessions = 8
+	var wg sync.WaitGroup
+	for i := 0; i < sessions; i++ {
+		wg.Add(1)
+		go func(sessionID string) {
+			defer wg.Done()
+			if _, _, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 1, Patterns: []string{"b-d"}, SessionID: sessionID, Shuffle: true}); err != nil {
+				t.Errorf("Generate %s: %v", sessionID, err)
+			}
+		}(fmt.Sprintf("sess-%d", i))
+	}
+	wg.Wait()
+
+	for i := 0; i < sessions; i++ {
+		sessionID := fmt.Sprintf("sess-%d", i)
+		if logs := waitForServeLogs(t, repo, sessionID, 1); len(logs) != 1 || logs[0].QuestionID != "q-1" {
+			t.Errorf("%s serve logs = %+v, want q-1 once", sessionID, logs)
+		}
+	}
+}
+
+// Request-scoped queries carry the request context so a cancelled request or its deadline aborts them
+func TestDBWithContext(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if got := u.dbWithContext(ctx).Statement.Context; got != ctx {
+		t.Errorf("dbWithContext context = %v, want the request context", got)
+	}
+	if got := u.primaryDB(ctx).Statement.Context; got != ctx {
+		t.Errorf("primaryDB context = %v, want the request context", got)
+	}
+	if got := u.dbWithContext(nil); got != u.cfg.DB {
+		t.Error("dbWithContext(nil) should return the bare connection")
+	}
+}
+
+// shuffle=false over a fixed cached set returns the same questions with the stored option order every time
+func TestGenerateUnshuffledIsStable(t *testing.T) {
+	repo := newFakeRepo()
+	for _, q := range []*internalEntity.GeneratedQuestion{
+		{QuestionID: "q-3", Difficulty: "easy", TargetLetterPair: "p-q", CorrectAnswer: "paku", Options: `["paku","qaku","baku","daku"]`},
+		{QuestionID: "q-1", Difficulty: "easy", TargetLetterPair: "b-d", CorrectAnswer: "bola", Options: `["bola","dola","pola","boda"]`},
+		{QuestionID: "q-2", Difficulty: "easy", TargetLetterPair: "m-n", CorrectAnswer: "mata", Options: `["mata","nata","mana","nama"]`},
+		{QuestionID: "q-4", Difficulty: "medium", TargetLetterPair: "b-d", CorrectAnswer: "dadu", Options: `["dadu","badu","babu","dabu"]`},
+	} {
+		repo.questions[q.QuestionID] = q
+	}
+	u := newTestUsecase(t, repo)
+
+	want := []entity.GeneratedQuestion{
+		{ID: "q-1", Difficulty: entity.DifficultyEasy, TargetLetterPair: "b-d", Options: []string{"bola", "dola", "pola", "boda"}},
+		{ID: "q-2", Difficulty: entity.DifficultyEasy, TargetLetterPair: "m-n", Options: []string{"mata", "nata", "mana", "nama"}},
+		{ID: "q-3", Difficulty: entity.DifficultyEasy, TargetLetterPair: "p-q", Options: []string{"paku", "qaku", "baku", "daku"}},
+	}
+	for i := 0; i < 5; i++ {
+		got, _, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 5})
+		if err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+		if !slices.EqualFunc(got, want, func(a, b entity.GeneratedQuestion) bool {
+			return a.ID == b.ID && a.Difficulty == b.Difficulty && a.TargetLetterPair == b.TargetLetterPair &&
+				slices.Equal(a.Options, b.Options)
+		}) {
+			t.Fatalf("run %d: got %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+// shuffle=false must keep the generated order (correct answer first) on the AI and fallback paths too
+func TestShuffleAppliesToAIAndFallback(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	u.cfg.PromptTemplate = defaultPromptTemplate
+	u.cfg.Gemini, _ = newFakeLLM(t, replyWith(`{"correctAnswer":"bola","options":["bola","dola","pola","boda"]}`))
+
+	sources := map[string]func(shuffle bool) (entity.GeneratedQuestion, error){
+		"ai": func(shuffle bool) (entity.GeneratedQuestion, error) {
+			q, _, err := u.generateFromAI(context.Background(), "easy", "b-d", true, shuffle)
+			return q, err
+		},
+		"fallback": func(shuffle bool) (entity.GeneratedQuestion, error) {
+			return u.createFallbackQuestionWithShuffle("easy", "b-d", true, shuffle), nil
+		},
+	}
+	for name, generate := range sources {
+		t.Run(name, func(t *testing.T) {
+			shuffled := false
+			for i := 0; i < 20; i++ {
+				ordered, err := generate(false)
+				if err != nil {
+					t.Fatalf("generate: %v", err)
+				}
+				if ordered.Options[0] != ordered.Answer {
+					t.Fatalf("shuffle=false options %v, want %q first", ordered.Options, ordered.Answer)
+				}
+
+				q, err := generate(true)
+				if err != nil {
+					t.Fatalf("generate: %v", err)
+				}
+				shuffled = shuffled || q.Options[0] != q.Answer
+			}
+			if !shuffled {
+				t.Errorf("shuffle=true never moved the correct answer in 20 questions")
+			}
+		})
+	}
+}
+
+// llm.store_prompts keeps the exact generation prompt with the cached question so admins can audit it
+func TestGenerateStoresPrompt(t *testing.T) {
+	for _, store := range []bool{true, false} {
+		t.Run(fmt.Sprintf("store_prompts=%v", store), func(t *testing.T) {
+			repo := newFakeRepo()
+			u := newTestUsecase(t, repo)
+			u.cfg.PromptTemplate = defaultPromptTemplate
+			u.cfg.Config.Set("llm.store_prompts", store)
+			u.cfg.Gemini, _ = newFakeLLM(t, replyWith(`{"correctAnswer":"bola","options":["bola","dola","pola","boda"]}`))
+			u.saveOutbox = newGeneratedOutbox(4, 0, 0, nil, func(q entity.GeneratedQuestion, letterPair, prompt, generatedBy string) error {
+				return u.saveGeneratedToDB(context.Background(), q, letterPair, prompt, generatedBy)
+			})
+
+			questions, _, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 1, Patterns: []string{"b-d"}, UseAI: true, Shuffle: true})
+			if err != nil || len(questions) != 1 {
+				t.Fatalf("Generate = %+v, %v; want one question", questions, err)
+			}
+			if err := u.saveOutbox.Close(context.Background()); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			got, err := u.GetQuestionPrompt(context.Background(), questions[0].ID)
+			if !store {
+				if err == nil {
+					t.Fatalf("GetQuestionPrompt = %+v, want an error when prompts are not stored", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetQuestionPrompt: %v", err)
+			}
+			if got.QuestionID != questions[0].ID || !strings.Contains(got.Prompt, "b-d") {
+				t.Errorf("GetQuestionPrompt = %+v, want the b-d generation prompt", got)
+			}
+		})
+	}
+}
+
+func TestGetQuestionPromptUnknownQuestion(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	if got, err := u.GetQuestionPrompt(context.Background(), "missing"); err == nil {
+		t.Fatalf("GetQuestionPrompt = %+v, want an error for an unknown question", got)
+	}
+}
+
+// llm.gemini.json_mode=false drops response_format for proxies that reject it; the JSON is then cleaned
+// out of the plain text reply
+func TestGenerateJSONMode(t *testing.T) {
+	for _, jsonMode := range []bool{true, false} {
+		t.Run(fmt.Sprintf("json_mode=%v", jsonMode), func(t *testing.T) {
+			u := newTestUsecase(t, newFakeRepo())
+			var fake *fakeLLM
+			u.cfg.Gemini, fake = newFakeLLM(t, replyWith("```json\n{\"correctAnswer\":\"bola\",\"options\":[\"bola\",\"dola\",\"pola\",\"boda\"]}\n```"))
+			u.cfg.Gemini.JSONMode = jsonMode
+
+			q, _, err := u.generateFromAI(context.Background(), entity.DifficultyEasy, "b-d", true, true)
+			if err != nil {
+				t.Fatalf("generateFromAI: %v", err)
+			}
+			if q.Answer != "bola" {
+				t.Errorf("answer = %q, want bola", q.Answer)
+			}
+			if got := fake.jsonMode.Load(); got != jsonMode {
+				t.Errorf("request response_format=json_object = %v, want %v", got, jsonMode)
+			}
+		})
+	}
+}
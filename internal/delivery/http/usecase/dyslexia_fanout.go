@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+)
+
+// Defaults for the Generate fan-out (questions.generate_concurrency, questions.max_llm_calls_per_request)
+const (
+	defaultGenerateConcurrency = 10
+	maxRefillAttempts          = 5 // extra questions generated at most to replace duplicates
+)
+
+// generateConcurrency bounds the parallel AI calls of one Generate request, refill included
+func (u *dyslexiaQuestionUsecase) generateConcurrency() int {
+	if n := u.cfg.Config.GetInt("questions.generate_concurrency"); n > 0 {
+		return n
+	}
+	return defaultGenerateConcurrency
+}
+
+// llmCallBudget caps the LLM calls one Generate request may make for count questions
+// (questions.max_llm_calls_per_request; default count plus every refill attempt)
+func (u *dyslexiaQuestionUsecase) llmCallBudget(count int) int {
+	if n := u.cfg.Config.GetInt("questions.max_llm_calls_per_request"); n > 0 {
+		return n
+	}
+	return count + maxRefillAttempts
+}
+
+// runBounded calls fn(0..n-1) with at most limit calls running at once and waits for them. It stops
+// scheduling once ctx is done (in-flight calls finish or fail on ctx) and returns how many calls started.
+func runBounded(ctx context.Context, n int, limit int, fn func(index int)) int {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(limit, 1))
+
+	// acquire takes a slot unless ctx is done; a slot won in a tie with cancellation is handed back
+	acquire := func() bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case sem <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			<-sem
+			return false
+		}
+		return true
+	}
+
+	started := 0
+	for i := 0; i < n && acquire(); i++ {
+		started++
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(index)
+		}(i)
+	}
+	wg.Wait()
+	return started
+}
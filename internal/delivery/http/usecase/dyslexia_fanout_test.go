@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+)
+
+// runBounded never runs more than limit calls at once, visits every index once and stops scheduling on cancel
+func TestRunBounded(t *testing.T) {
+	tests := []struct {
+		name        string
+		n           int
+		limit       int
+		cancelAfter int // cancel ctx from inside the call with this index+1; 0 never cancels
+		cancelled   bool
+		wantStarted int
+	}{
+		{name: "all calls", n: 8, limit: 3, wantStarted: 8},
+		{name: "limit below one runs serially", n: 3, limit: 0, wantStarted: 3},
+		{name: "nothing to do", n: 0, limit: 2, wantStarted: 0},
+		{name: "cancelled before start", n: 5, limit: 2, cancelled: true, wantStarted: 0},
+		{name: "cancel mid-way stops scheduling", n: 6, limit: 1, cancelAfter: 2, wantStarted: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			var inFlight, peak atomic.Int64
+			visited := make([]atomic.Int64, tt.n)
+			started := runBounded(ctx, tt.n, tt.limit, func(index int) {
+				now := inFlight.Add(1)
+				for {
+					old := peak.Load()
+					if now <= old || peak.CompareAndSwap(old, now) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				visited[index].Add(1)
+				if tt.cancelAfter > 0 && index+1 == tt.cancelAfter {
+					cancel()
+				}
+				inFlight.Add(-1)
+			})
+
+			if started != tt.wantStarted {
+				t.Errorf("started = %d, want %d", started, tt.wantStarted)
+			}
+			if got := peak.Load(); got > int64(max(tt.limit, 1)) {
+				t.Errorf("peak concurrency = %d, limit %d", got, tt.limit)
+			}
+			for i := range visited {
+				want := int64(0)
+				if i < tt.wantStarted {
+					want = 1
+				}
+				if got := visited[i].Load(); got != want {
+					t.Errorf("index %d called %d times, want %d", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+// The AI fan-out and the duplicate refill share questions.max_llm_calls_per_request and
+// questions.generate_concurrency; cancellation stops new LLM calls
+func TestGenerateLLMCallBudget(t *testing.T) {
+	tests := []struct {
+		name        string
+		budget      int
+		status      int
+		cancelled   bool
+		wantCalls   int64
+		concurrency int
+	}{
+		{name: "default budget covers count plus refills", status: http.StatusOK, wantCalls: 3 + maxRefillAttempts, concurrency: 2},
+		{name: "budget caps duplicate refills", budget: 4, status: http.StatusOK, wantCalls: 4, concurrency: 2},
+		{name: "budget below count", budget: 1, status: http.StatusOK, wantCalls: 1, concurrency: 3},
+		{name: "failures count against the budget", budget: 2, status: http.StatusInternalServerError, wantCalls: 2, concurrency: 1},
+		{name: "cancelled request makes no calls", status: http.StatusOK, cancelled: true, wantCalls: 0, concurrency: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newTestUsecase(t, newFakeRepo())
+			u.cfg.PromptTemplate = defaultPromptTemplate
+			// Content ids make every identical LLM reply a duplicate, so the refill keeps asking for more
+			u.cfg.Config.Set("questions.id_scheme", "content")
+			u.cfg.Config.Set("questions.generate_concurrency", tt.concurrency)
+			if tt.budget > 0 {
+				u.cfg.Config.Set("questions.max_llm_calls_per_request", tt.budget)
+			}
+
+			var inFlight, peak atomic.Int64
+			var fake *fakeLLM
+			u.cfg.Gemini, fake = newFakeLLM(t, func(string) (string, int) {
+				now := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					old := peak.Load()
+					if now <= old || peak.CompareAndSwap(old, now) {
+						break
+					}
+				}
+				time.Sleep(2 * time.Millisecond)
+				return `{"correctAnswer":"dadu","options":["dadu","badu","dabu","babu"]}`, tt.status
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+			if _, _, err := u.generate(ctx, entity.GenerateOptions{Difficulty: "easy", Count: 3, IncludeAnswer: true, Patterns: []string{"b-d"}, UseAI: true}); err != nil {
+				t.Fatalf("generate: %v", err)
+			}
+
+			if got := fake.calls.Load(); got != tt.wantCalls {
+				t.Errorf("LLM calls = %d, want %d", got, tt.wantCalls)
+			}
+			if got := peak.Load(); got > int64(tt.concurrency) {
+				t.Errorf("peak concurrent LLM calls = %d, want at most %d", got, tt.concurrency)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+)
+
+const (
+	defaultCorrectFeedback   = "Bagus! Jawabanmu benar."
+	defaultIncorrectFeedback = "Coba lagi! Jawaban yang benar adalah {correct_answer}."
+	defaultStreakFeedback    = "Hebat! {streak} jawaban benar berturut-turut!"
+)
+
+// feedbackEnabled reads answers.feedback.enabled (default true)
+func (u *dyslexiaQuestionUsecase) feedbackEnabled() bool {
+	if !u.cfg.Config.IsSet("answers.feedback.enabled") {
+		return true
+	}
+	return u.cfg.Config.GetBool("answers.feedback.enabled")
+}
+
+// answerFeedback picks the encouraging message for a submitted answer. The streak template wins over the
+// correct one once the child has answers.feedback.streak_min correct answers in a row (0 disables it).
+// Templates support {correct_answer}, {user_answer} and {streak}.
+func (u *dyslexiaQuestionUsecase) answerFeedback(response *entity.SubmitAnswerResponse, streak int) string {
+	template := u.feedbackTemplate("answers.feedback.incorrect", defaultIncorrectFeedback)
+	if response.IsCorrect {
+		template = u.feedbackTemplate("answers.feedback.correct", defaultCorrectFeedback)
+		if streakMin := u.feedbackStreakMin(); streakMin > 0 && streak >= streakMin {
+			template = u.feedbackTemplate("answers.feedback.streak", defaultStreakFeedback)
+		}
+	}
+
+	return strings.NewReplacer(
+		"{correct_answer}", response.CorrectAnswer,
+		"{user_answer}", response.UserAnswer,
+		"{streak}", strconv.Itoa(streak),
+	).Replace(template)
+}
+
+func (u *dyslexiaQuestionUsecase) feedbackTemplate(key string, fallback string) string {
+	if template := strings.TrimSpace(u.cfg.Config.GetString(key)); template != "" {
+		return template
+	}
+	return fallback
+}
+
+func (u *dyslexiaQuestionUsecase) feedbackStreakMin() int {
+	if !u.cfg.Config.IsSet("answers.feedback.streak_min") {
+		return 3
+	}
+	return u.cfg.Config.GetInt("answers.feedback.streak_min")
+}
+
+// correctStreak counts the user's consecutive correct answers in the session, newest first
+func (u *dyslexiaQuestionUsecase) correctStreak(userID string, sessionID string) int {
+	answers, err := u.cfg.Repository.FindUserAnswersBySessionID(u.cfg.DB, sessionID)
+	if err != nil {
+		u.logger().Warnf("Failed to load answers for streak of session %s: %v", sessionID, err)
+		return 0
+	}
+
+	streak := 0
+	for _, answer := range answers {
+		if answer.UserID != userID {
+			continue
+		}
+		if !answer.IsCorrect {
+			break
+		}
+		streak++
+	}
+	return streak
+}
@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+// The submit response carries the correct or incorrect template with its placeholders filled in; the
+// streak template takes over once answers.feedback.streak_min correct answers follow each other
+func TestSubmitAnswerFeedback(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]any
+		answers []string // submitted in order, one question each; the last response is checked
+		want    string
+	}{
+		{name: "correct", answers: []string{"bola"}, want: "Bagus! Jawabanmu benar."},
+		{name: "incorrect", answers: []string{"dola"}, want: "Coba lagi! Jawaban yang benar adalah bola."},
+		{name: "streak", answers: []string{"bola", "bola", "bola"}, want: "Hebat! 3 jawaban benar berturut-turut!"},
+		{name: "streak after a miss", answers: []string{"dola", "bola", "bola", "bola"}, want: "Hebat! 3 jawaban benar berturut-turut!"},
+		{name: "streak broken", answers: []string{"bola", "dola", "bola", "bola"}, want: "Bagus! Jawabanmu benar."},
+		{name: "streak disabled", config: map[string]any{"answers.feedback.streak_min": 0},
+			answers: []string{"bola", "bola", "bola"}, want: "Bagus! Jawabanmu benar."},
+		{name: "custom templates", config: map[string]any{"answers.feedback.incorrect": "Kamu pilih {user_answer}, yang benar {correct_answer}."},
+			answers: []string{"dola"}, want: "Kamu pilih dola, yang benar bola."},
+		{name: "disabled", config: map[string]any{"answers.feedback.enabled": false}, answers: []string{"bola"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			u := newTestUsecase(t, repo)
+			for key, value := range tt.config {
+				u.cfg.Config.Set(key, value)
+			}
+
+			var resp *entity.SubmitAnswerResponse
+			for i, answer := range tt.answers {
+				questionID := fmt.Sprintf("q-%d", i)
+				repo.questions[questionID] = &internalEntity.GeneratedQuestion{
+					QuestionID: questionID, TargetLetterPair: "b-d", CorrectAnswer: "bola", Options: `["bola","dola","pola","boda"]`,
+				}
+				var err error
+				resp, err = u.SubmitAnswer(context.Background(), entity.SubmitAnswerRequest{UserID: "user-1", SessionID: "sess-1", QuestionID: questionID, Answer: answer})
+				if err != nil {
+					t.Fatalf("SubmitAnswer %d: %v", i, err)
+				}
+			}
+			if resp.Feedback != tt.want {
+				t.Errorf("feedback = %q, want %q", resp.Feedback, tt.want)
+			}
+		})
+	}
+}
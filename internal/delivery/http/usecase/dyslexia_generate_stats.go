@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/sirupsen/logrus"
+)
+
+// Where a served question came from, reported in the generate summary
+const (
+	generateSourceAI       = "ai"
+	generateSourceCache    = "cache"
+	generateSourceFallback = "fallback"
+)
+
+// generateStats collects what one Generate call did for its summary log line. It travels in the context
+// so the parallel paths can record into it; every method is a no-op on nil (calls outside Generate).
+type generateStats struct {
+	mu         sync.Mutex
+	sources    map[string]string // question id -> generateSource*
+	llmCalls   int
+	duplicates int
+}
+
+type generateStatsKey struct{}
+
+func withGenerateStats(ctx context.Context) (context.Context, *generateStats) {
+	stats := &generateStats{sources: make(map[string]string)}
+	return context.WithValue(ctx, generateStatsKey{}, stats), stats
+}
+
+func generateStatsFrom(ctx context.Context) *generateStats {
+	stats, _ := ctx.Value(generateStatsKey{}).(*generateStats)
+	return stats
+}
+
+// source records where the question with id came from
+func (s *generateStats) source(id string, source string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sources[id] = source
+}
+
+// llmCall counts one request to the LLM provider (retries included)
+func (s *generateStats) llmCall() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.llmCalls++
+}
+
+// duplicate counts one candidate question dropped because its id was already served or picked
+func (s *generateStats) duplicate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.duplicates++
+}
+
+// logger returns the configured logger, or the logrus standard logger when none was wired in
+func (u *dyslexiaQuestionUsecase) logger() *logrus.Logger {
+	if u.cfg.Log != nil {
+		return u.cfg.Log
+	}
+	return logrus.StandardLogger()
+}
+
+// logGenerateSummary emits the one structured line per Generate call used for capacity planning:
+// requested/returned counts, the source of each returned question, LLM calls, duplicates and duration
+func (u *dyslexiaQuestionUsecase) logGenerateSummary(stats *generateStats, difficulty entity.Difficulty, requested int, useAI bool, questions []entity.GeneratedQuestion, duration time.Duration, err error) {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	bySource := map[string]int{generateSourceAI: 0, generateSourceCache: 0, generateSourceFallback: 0}
+	for _, q := range questions {
+		bySource[stats.sources[q.ID]]++
+	}
+	if difficulty == "" {
+		difficulty = u.defaultDifficulty
+	}
+
+	fields := logrus.Fields{
+		"event":               "generate_summary",
+		"difficulty":          string(difficulty),
+		"strategy":            u.generationStrategy,
+		"use_ai":              useAI,
+		"requested":           requested,
+		"returned":            len(questions),
+		"source_ai":           bySource[generateSourceAI],
+		"source_cache":        bySource[generateSourceCache],
+		"source_fallback":     bySource[generateSourceFallback],
+		"llm_calls":           stats.llmCalls,
+		"duplicates_filtered": stats.duplicates,
+		"duration_ms":         duration.Milliseconds(),
+	}
+	if err != nil {
+		u.logger().WithFields(fields).WithError(err).Warn("generate summary")
+		return
+	}
+	u.logger().WithFields(fields).Info("generate summary")
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+	"github.com/sirupsen/logrus"
+)
+
+// generateSummaries decodes the generate_summary lines of a JSON log
+func generateSummaries(t *testing.T, log string) []map[string]any {
+	t.Helper()
+	var summaries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(log), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("decode log line %q: %v", line, err)
+		}
+		if entry["event"] == "generate_summary" {
+			summaries = append(summaries, entry)
+		}
+	}
+	return summaries
+}
+
+// Every Generate call logs exactly one structured summary with where its questions came from
+func TestGenerateSummaryLog(t *testing.T) {
+	const reply = `{"correctAnswer":"dadu","options":["dadu","badu","dabu","babu"]}`
+	tests := []struct {
+		name      string
+		useAI     bool
+		disableAI bool
+		contentID bool
+		status    int
+		want      map[string]any
+		wantLevel string
+	}{
+		{name: "ai", useAI: true, status: http.StatusOK, wantLevel: "info", want: map[string]any{
+			"requested": 2.0, "returned": 2.0, "source_ai": 2.0, "source_cache": 0.0, "source_fallback": 0.0, "llm_calls": 2.0, "duplicates_filtered": 0.0}},
+		{name: "ai failures fall back", useAI: true, status: http.StatusInternalServerError, wantLevel: "info", want: map[string]any{
+			"returned": 2.0, "source_ai": 0.0, "source_fallback": 2.0}},
+		{name: "duplicates filtered", useAI: true, contentID: true, status: http.StatusOK, wantLevel: "info", want: map[string]any{
+			"returned": 1.0, "source_ai": 1.0, "duplicates_filtered": 6.0, "llm_calls": 7.0}},
+		{name: "ai disabled", useAI: true, disableAI: true, wantLevel: "info", want: map[string]any{
+			"source_fallback": 2.0, "llm_calls": 0.0}},
+		{name: "cache only", wantLevel: "info", want: map[string]any{
+			"use_ai": false, "returned": 1.0, "source_cache": 1.0, "llm_calls": 0.0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.questions["q-cached"] = &internalEntity.GeneratedQuestion{QuestionID: "q-cached", Difficulty: "easy", TargetLetterPair: "b-d", Options: `["bola","dola"]`, CorrectAnswer: "bola"}
+			u := newTestUsecase(t, repo)
+			u.cfg.PromptTemplate = defaultPromptTemplate
+			u.cfg.Config.Set("llm.gemini.disable_ai_prompt", tt.disableAI)
+			if tt.contentID {
+				// Content ids make every identical reply a duplicate of the first
+				u.cfg.Config.Set("questions.id_scheme", "content")
+			}
+			u.cfg.Gemini, _ = newFakeLLM(t, func(string) (string, int) { return reply, tt.status })
+			logs := captureLog(u)
+			u.cfg.Log.SetFormatter(&logrus.JSONFormatter{})
+
+			if _, _, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 2, Patterns: []string{"b-d"}, UseAI: tt.useAI, Shuffle: true}); err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+
+			summaries := generateSummaries(t, logs.String())
+			if len(summaries) != 1 {
+				t.Fatalf("got %d summaries, want 1:\n%s", len(summaries), logs.String())
+			}
+			got := summaries[0]
+			if got["level"] != tt.wantLevel || got["difficulty"] != "easy" || got["strategy"] != u.generationStrategy {
+				t.Errorf("summary = %v", got)
+			}
+			if _, ok := got["duration_ms"]; !ok {
+				t.Errorf("summary has no duration_ms: %v", got)
+			}
+			for field, want := range tt.want {
+				if got[field] != want {
+					t.Errorf("%s = %v, want %v", field, got[field], want)
+				}
+			}
+		})
+	}
+}
+
+// A failed Generate still logs its summary, at warn level with the error
+func TestGenerateSummaryLogsError(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	logs := captureLog(u)
+	u.cfg.Log.SetFormatter(&logrus.JSONFormatter{})
+
+	if _, _, err := u.Generate(context.Background(), entity.GenerateOptions{Count: 1, Patterns: []string{"x-y"}, UseAI: true}); err == nil {
+		t.Fatal("Generate accepted an unknown pattern")
+	}
+	summaries := generateSummaries(t, logs.String())
+	if len(summaries) != 1 || summaries[0]["level"] != "warning" || summaries[0]["error"] == nil || summaries[0]["returned"] != 0.0 {
+		t.Errorf("summaries = %v, want one warning with the error", summaries)
+	}
+}
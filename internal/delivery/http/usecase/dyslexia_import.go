@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/repository"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+	"gorm.io/gorm"
+)
+
+// Client clocks on offline kiosks drift; answers further in the future than this are rejected
+const importClockSkew = 5 * time.Minute
+
+// ImportAnswers persists answers graded offline, keeping the client's answered_at. When the question
+// is stored server-side its correctness is recomputed and disagreements are reported as conflicts.
+func (u *dyslexiaQuestionUsecase) ImportAnswers(ctx context.Context, req entity.ImportAnswersRequest) (*entity.ImportAnswersResult, error) {
+	now := time.Now()
+	ids := make([]string, 0, len(req.Answers))
+	for i, a := range req.Answers {
+		if a.AnsweredAt.After(now.Add(importClockSkew)) {
+			return nil, fmt.Errorf("answers[%d]: answered_at is in the future", i)
+		}
+		ids = append(ids, a.QuestionID)
+	}
+
+	stored, err := u.cfg.Repository.FindGeneratedByQuestionIDs(u.dbWithContext(ctx), ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load questions: %w", err)
+	}
+	questions := make(map[string]internalEntity.GeneratedQuestion, len(stored))
+	for _, q := range stored {
+		questions[q.QuestionID] = q
+	}
+
+	result := &entity.ImportAnswersResult{SessionID: req.SessionID, Conflicts: []entity.ImportConflict{}}
+	err = repository.WithTransaction(u.dbWithContext(ctx), func(tx *gorm.DB) error {
+		seen := make(map[string]bool)
+		for _, a := range req.Answers {
+			// First answer wins, both within the import and against answers already recorded
+			if seen[a.QuestionID] {
+				result.Skipped++
+				continue
+			}
+			seen[a.QuestionID] = true
+			if existing, _ := u.cfg.Repository.FindExistingAnswer(tx, req.UserID, req.SessionID, a.QuestionID); existing != nil {
+				result.Skipped++
+				continue
+			}
+
+			answer := &internalEntity.UserAnswer{
+				UserID:        req.UserID,
+				SessionID:     req.SessionID,
+				QuestionID:    a.QuestionID,
+				UserAnswer:    u.storedAnswerCase(a.UserAnswer),
+				IsCorrect:     a.IsCorrect,
+				AttemptNumber: 1,
+				AnsweredAt:    a.AnsweredAt,
+			}
+
+			if q, ok := questions[a.QuestionID]; ok {
+				userAnswer := strings.TrimSpace(strings.ToUpper(a.UserAnswer))
+				correctAnswer := strings.TrimSpace(strings.ToUpper(q.CorrectAnswer))
+				isCorrect := answersMatch(userAnswer, correctAnswer)
+				if isCorrect != a.IsCorrect {
+					result.Conflicts = append(result.Conflicts, entity.ImportConflict{
+						QuestionID:      a.QuestionID,
+						ClientIsCorrect: a.IsCorrect,
+						ServerIsCorrect: isCorrect,
+					})
+				}
+				answer.IsCorrect = isCorrect
+				answer.CorrectAnswer = u.storedAnswerCase(q.CorrectAnswer)
+				answer.PartialCredit = u.partialCredit(userAnswer, correctAnswer, isCorrect)
+				answer.QuestionText = q.QuestionText
+				answer.Difficulty = string(entity.NormalizeDifficulty(q.Difficulty))
+			} else {
+				result.Unverified++
+			}
+
+			if err := u.cfg.Repository.CreateUserAnswer(tx, answer); err != nil {
+				return fmt.Errorf("failed to save answer %s: %w", a.QuestionID, err)
+			}
+			result.Imported++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.logger().Infof("[IMPORT] Session %s: imported=%d skipped=%d unverified=%d conflicts=%d",
+		req.SessionID, result.Imported, result.Skipped, result.Unverified, len(result.Conflicts))
+	return result, nil
+}
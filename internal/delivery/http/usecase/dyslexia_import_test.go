@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+	"gorm.io/gorm"
+)
+
+// failingAnswerRepo fails the insert of one question's answer, after the earlier ones went through
+type failingAnswerRepo struct {
+	*fakeRepo
+	failOn string
+}
+
+func (r *failingAnswerRepo) CreateUserAnswer(db *gorm.DB, answer *internalEntity.UserAnswer) error {
+	if answer.QuestionID == r.failOn {
+		return errors.New("insert failed")
+	}
+	return r.fakeRepo.CreateUserAnswer(db, answer)
+}
+
+func importRepo() *fakeRepo {
+	repo := newFakeRepo()
+	repo.questions["q-1"] = &internalEntity.GeneratedQuestion{QuestionID: "q-1", Difficulty: "easy", QuestionText: "Pilih bola", CorrectAnswer: "bola"}
+	repo.questions["q-2"] = &internalEntity.GeneratedQuestion{QuestionID: "q-2", Difficulty: "easy", QuestionText: "Pilih dadu", CorrectAnswer: "dadu"}
+	return repo
+}
+
+// A backdated import keeps the client timestamps, skips repeats and trusts the client only for unknown questions
+func TestImportAnswers(t *testing.T) {
+	repo := importRepo()
+	repo.answers = []internalEntity.UserAnswer{{ID: 1, UserID: "user-1", SessionID: "s-1", QuestionID: "q-2", UserAnswer: "dadu", IsCorrect: true}}
+	u := newTestUsecase(t, repo)
+	db, tx := txTestDB(t)
+	u.cfg.DB = db
+
+	answeredAt := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	result, err := u.ImportAnswers(context.Background(), entity.ImportAnswersRequest{
+		SessionID: "s-1",
+		UserID:    "user-1",
+		Answers: []entity.ImportedAnswer{
+			{QuestionID: "q-1", UserAnswer: "bola", IsCorrect: true, AnsweredAt: answeredAt},
+			{QuestionID: "q-1", UserAnswer: "dola", AnsweredAt: answeredAt.Add(time.Minute)},
+			{QuestionID: "q-2", UserAnswer: "badu", AnsweredAt: answeredAt.Add(2 * time.Minute)},
+			{QuestionID: "q-offline", UserAnswer: "pita", IsCorrect: true, AnsweredAt: answeredAt.Add(3 * time.Minute)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ImportAnswers: %v", err)
+	}
+	want := &entity.ImportAnswersResult{SessionID: "s-1", Imported: 2, Skipped: 2, Unverified: 1, Conflicts: []entity.ImportConflict{}}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("result = %+v, want %+v", result, want)
+	}
+
+	answers := repo.answers
+	if len(answers) != 3 {
+		t.Fatalf("stored %d answers, want 3", len(answers))
+	}
+	verified, offline := answers[1], answers[2]
+	if verified.QuestionID != "q-1" || verified.UserAnswer != "bola" || !verified.IsCorrect || !verified.AnsweredAt.Equal(answeredAt) ||
+		verified.CorrectAnswer != "bola" || verified.QuestionText != "Pilih bola" || verified.Difficulty != "easy" {
+		t.Errorf("verified answer = %+v", verified)
+	}
+	if offline.QuestionID != "q-offline" || !offline.IsCorrect || !offline.AnsweredAt.Equal(answeredAt.Add(3*time.Minute)) {
+		t.Errorf("unverified answer = %+v", offline)
+	}
+	if commits, rollbacks := tx.counts(); commits != 1 || rollbacks != 0 {
+		t.Errorf("transactions: %d commits, %d rollbacks, want 1 and 0", commits, rollbacks)
+	}
+}
+
+// When the client's grading disagrees with the stored question, the server's correctness is stored and reported
+func TestImportAnswersConflictingCorrectness(t *testing.T) {
+	repo := importRepo()
+	u := newTestUsecase(t, repo)
+	u.cfg.DB, _ = txTestDB(t)
+
+	result, err := u.ImportAnswers(context.Background(), entity.ImportAnswersRequest{
+		SessionID: "s-1",
+		UserID:    "user-1",
+		Answers: []entity.ImportedAnswer{
+			{QuestionID: "q-1", UserAnswer: "dola", IsCorrect: true, AnsweredAt: time.Now().Add(-time.Hour)},
+			{QuestionID: "q-2", UserAnswer: " DADU ", IsCorrect: false, AnsweredAt: time.Now().Add(-time.Hour)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ImportAnswers: %v", err)
+	}
+	wantConflicts := []entity.ImportConflict{
+		{QuestionID: "q-1", ClientIsCorrect: true, ServerIsCorrect: false},
+		{QuestionID: "q-2", ClientIsCorrect: false, ServerIsCorrect: true},
+	}
+	if result.Imported != 2 || !reflect.DeepEqual(result.Conflicts, wantConflicts) {
+		t.Errorf("result = %+v, want 2 imported and conflicts %+v", result, wantConflicts)
+	}
+
+	answers := repo.answers
+	if len(answers) != 2 || answers[0].IsCorrect || !answers[1].IsCorrect {
+		t.Errorf("stored answers = %+v, want the server's grading", answers)
+	}
+}
+
+// An answer timestamped beyond the allowed clock skew rejects the whole import before anything is written
+func TestImportAnswersRejectsFutureTimestamps(t *testing.T) {
+	repo := importRepo()
+	u := newTestUsecase(t, repo)
+	db, tx := txTestDB(t)
+	u.cfg.DB = db
+
+	_, err := u.ImportAnswers(context.Background(), entity.ImportAnswersRequest{
+		SessionID: "s-1",
+		UserID:    "user-1",
+		Answers: []entity.ImportedAnswer{
+			{QuestionID: "q-1", UserAnswer: "bola", AnsweredAt: time.Now()},
+			{QuestionID: "q-2", UserAnswer: "dadu", AnsweredAt: time.Now().Add(time.Hour)},
+		},
+	})
+	if err == nil {
+		t.Fatal("ImportAnswers accepted an answer an hour in the future")
+	}
+	if answers, _ := repo.FindUserAnswersBySessionID(nil, "s-1"); len(answers) != 0 {
+		t.Errorf("stored %d answers, want none", len(answers))
+	}
+	if commits, rollbacks := tx.counts(); commits != 0 || rollbacks != 0 {
+		t.Errorf("transactions: %d commits, %d rollbacks, want none", commits, rollbacks)
+	}
+}
+
+// A failed insert part-way through rolls the import back instead of committing the answers before it
+func TestImportAnswersRollsBackOnFailure(t *testing.T) {
+	repo := &failingAnswerRepo{fakeRepo: importRepo(), failOn: "q-2"}
+	u := newTestUsecase(t, repo)
+	db, tx := txTestDB(t)
+	u.cfg.DB = db
+
+	result, err := u.ImportAnswers(context.Background(), entity.ImportAnswersRequest{
+		SessionID: "s-1",
+		UserID:    "user-1",
+		Answers: []entity.ImportedAnswer{
+			{QuestionID: "q-1", UserAnswer: "bola", IsCorrect: true, AnsweredAt: time.Now()},
+			{QuestionID: "q-2", UserAnswer: "dadu", IsCorrect: true, AnsweredAt: time.Now()},
+		},
+	})
+	if err == nil || result != nil {
+		t.Fatalf("ImportAnswers = %+v, %v, want an error and no result", result, err)
+	}
+	if commits, rollbacks := tx.counts(); commits != 0 || rollbacks != 1 {
+		t.Errorf("transactions: %d commits, %d rollbacks, want 0 and 1", commits, rollbacks)
+	}
+}
@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"strings"
+	"unicode"
+)
+
+// languageStopwords holds frequent function words used to guess the language of AI output
+var languageStopwords = map[string][]string{
+	"id": {"yang", "dan", "dengan", "untuk", "pada", "ini", "itu", "anak", "tidak", "akan", "dalam", "juga", "lebih", "sudah", "masih", "huruf", "latihan"},
+	"en": {"the", "and", "with", "for", "this", "that", "child", "not", "will", "in", "also", "more", "has", "is", "are", "letter", "practice"},
+}
+
+var languageNames = map[string]string{
+	"id": "Indonesian (Bahasa Indonesia)",
+	"en": "English",
+}
+
+// analysisLanguage returns llm.analysis_language (default "id")
+func (u *dyslexiaQuestionUsecase) analysisLanguage() string {
+	if lang := strings.ToLower(strings.TrimSpace(u.cfg.Config.GetString("llm.analysis_language"))); lang != "" {
+		return lang
+	}
+	return "id"
+}
+
+// detectLanguage guesses the language of text by stopword hits; "" when undecided
+func detectLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	best, bestHits, tie := "", 0, false
+	for lang, stopwords := range languageStopwords {
+		set := make(map[string]bool, len(stopwords))
+		for _, w := range stopwords {
+			set[w] = true
+		}
+
+		hits := 0
+		for _, w := range words {
+			if set[w] {
+				hits++
+			}
+		}
+
+		switch {
+		case hits > bestHits:
+			best, bestHits, tie = lang, hits, false
+		case hits == bestHits:
+			tie = true
+		}
+	}
+
+	if bestHits == 0 || tie {
+		return ""
+	}
+	return best
+}
+
+// isExpectedLanguage reports whether text is not detectably in a language other than lang
+func isExpectedLanguage(text string, lang string) bool {
+	detected := detectLanguage(text)
+	return detected == "" || detected == lang
+}
+
+func languageName(lang string) string {
+	if name, ok := languageNames[lang]; ok {
+		return name
+	}
+	return lang
+}
+
+// languageRetryInstruction is appended to the prompt after the model answered in the wrong language
+func languageRetryInstruction(lang string) string {
+	return "\n\nCRITICAL: Your previous answer used the wrong language. Write the \"analysis\" and \"recommendations\" fields ONLY in " + languageName(lang) + "."
+}
@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAnalysisInstructionsLanguage(t *testing.T) {
+	tests := []struct {
+		lang    string
+		want    string
+		notWant string
+		sample  string
+	}{
+		{lang: "id", want: "analysis in Indonesian (Bahasa Indonesia)", notWant: "in English", sample: "Fokus latihan"},
+		{lang: "en", want: "analysis in English", notWant: "in Indonesian", sample: "Focus practice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			got := analysisInstructions(tt.lang)
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("missing %q", tt.want)
+			}
+			if strings.Contains(got, tt.notWant) {
+				t.Errorf("contains %q", tt.notWant)
+			}
+			if !strings.Contains(got, tt.sample) {
+				t.Errorf("example not in %s: missing %q", tt.lang, tt.sample)
+			}
+			if strings.Contains(got, "%!") {
+				t.Errorf("template has a broken verb: %s", got)
+			}
+		})
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"indonesian", "Anak sudah lebih baik dalam membedakan huruf b dan d.", "id"},
+		{"english", "The child is making progress with the letter pairs and needs more practice.", "en"},
+		{"no stopwords", "b-d p-q", ""},
+		{"tie", "anak child", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectLanguage(tt.text); got != tt.want {
+				t.Errorf("detectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+// The canned analysis used when the model keeps failing follows llm.analysis_language
+func TestAnalysisFallbackLanguage(t *testing.T) {
+	tests := []struct {
+		lang  string
+		reply string // always in the other language, so every attempt is rejected
+		want  string
+	}{
+		{lang: "en", reply: `{"analysis":"Anak sudah lebih baik dalam membedakan huruf b dan d.","recommendations":"Latihan setiap hari.","overall_value":"baik"}`, want: "The practice session is complete."},
+		{lang: "id", reply: `{"analysis":"The child is making progress with the letter pairs.","recommendations":"Practice every day.","overall_value":"baik"}`, want: "Sesi latihan telah selesai."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			u := newTestUsecase(t, newFakeRepo())
+			u.cfg.Config.Set("llm.analysis_language", tt.lang)
+			u.cfg.Gemini, _ = newFakeLLM(t, replyWith(tt.reply))
+
+			analysis, recommendations, overall := u.generateAIAnalysis(context.Background(), nil, nil, "50%")
+			if !strings.HasPrefix(analysis, tt.want) || detectLanguage(recommendations) != tt.lang || overall != "baik" {
+				t.Errorf("fallback = %q / %q / %q, want it in %s", analysis, recommendations, overall, tt.lang)
+			}
+		})
+	}
+
+	// Languages without canned text fall back to English, like the prompt example
+	if analysis, _, _ := fallbackAnalysis("fr", "failed"); !strings.HasPrefix(analysis, "The practice session") {
+		t.Errorf("fr fallback = %q, want English", analysis)
+	}
+}
+
+// An answer in the wrong language is retried once with a stronger instruction
+func TestAnalysisLanguageRetry(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	var prompts []string
+	u.cfg.Gemini, _ = newFakeLLM(t, func(prompt string) (string, int) {
+		prompts = append(prompts, prompt)
+		if len(prompts) == 1 {
+			return `{"analysis":"The child is making progress with the letter pairs.","recommendations":"Practice every day.","overall_value":"baik"}`, http.StatusOK
+		}
+		return `{"analysis":"Anak sudah lebih baik dalam membedakan huruf b dan d.","recommendations":"Latihan setiap hari.","overall_value":"baik"}`, http.StatusOK
+	})
+
+	analysis, _, _ := u.generateAIAnalysis(context.Background(), nil, nil, "50%")
+	if detectLanguage(analysis) != "id" {
+		t.Errorf("analysis = %q, want the Indonesian retry", analysis)
+	}
+	if len(prompts) != 2 || !strings.HasSuffix(prompts[1], languageRetryInstruction("id")) {
+		t.Errorf("prompts = %d, want a retry ending with the language instruction", len(prompts))
+	}
+}
@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+// Holders of one key never overlap, different keys run concurrently, and released keys are forgotten
+func TestKeyedMutex(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []string
+		wantPeak int64
+	}{
+		{name: "same key serialized", keys: []string{"sess-1", "sess-1", "sess-1", "sess-1"}, wantPeak: 1},
+		{name: "different keys in parallel", keys: []string{"sess-1", "sess-2", "sess-3"}, wantPeak: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := newKeyedMutex()
+			var inFlight, peak atomic.Int64
+			var wg sync.WaitGroup
+			start := make(chan struct{})
+			for _, key := range tt.keys {
+				wg.Add(1)
+				go func(key string) {
+					defer wg.Done()
+					<-start
+					unlock := k.Lock(key)
+					defer unlock()
+					now := inFlight.Add(1)
+					for {
+						old := peak.Load()
+						if now <= old || peak.CompareAndSwap(old, now) {
+							break
+						}
+					}
+					// Hold the lock until every expected holder is in, or long enough for an overlap to show
+					for deadline := time.Now().Add(50 * time.Millisecond); inFlight.Load() < tt.wantPeak && time.Now().Before(deadline); {
+						time.Sleep(time.Millisecond)
+					}
+					time.Sleep(5 * time.Millisecond)
+					inFlight.Add(-1)
+				}(key)
+			}
+			close(start)
+			wg.Wait()
+
+			if got := peak.Load(); got != tt.wantPeak {
+				t.Errorf("peak holders = %d, want %d", got, tt.wantPeak)
+			}
+			k.mu.Lock()
+			defer k.mu.Unlock()
+			if len(k.locks) != 0 {
+				t.Errorf("%d keys still tracked after release", len(k.locks))
+			}
+		})
+	}
+}
+
+// A waiter keeps the key's entry alive, so it is only dropped after the last holder releases it
+func TestKeyedMutexRefCount(t *testing.T) {
+	k := newKeyedMutex()
+	unlock := k.Lock("sess-1")
+
+	acquired := make(chan func())
+	go func() { acquired <- k.Lock("sess-1") }()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		k.mu.Lock()
+		refs := k.locks["sess-1"].refs
+		k.mu.Unlock()
+		if refs == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("waiter never registered (refs = %d)", refs)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	select {
+	case <-acquired:
+		t.Fatalf("second Lock acquired while the key was held")
+	default:
+	}
+
+	unlock()
+	second := <-acquired
+	k.mu.Lock()
+	if _, ok := k.locks["sess-1"]; !ok {
+		t.Errorf("key dropped while still held")
+	}
+	k.mu.Unlock()
+
+	second()
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.locks["sess-1"]; ok {
+		t.Errorf("key still tracked after the last release")
+	}
+}
+
+// Two chats on a session without an analysis generate the report once; the second reads the fresh cache
+func TestChatConcurrentSingleReport(t *testing.T) {
+	repo := newFakeRepo()
+	repo.answers = []internalEntity.UserAnswer{
+		{SessionID: "sess-1", QuestionID: "q-1", IsCorrect: true},
+		{SessionID: "sess-1", QuestionID: "q-2"},
+	}
+	u := newTestUsecase(t, repo)
+	u.cfg.DB, _ = txTestDB(t) // the report persists in a transaction
+
+	var reportCalls atomic.Int64
+	u.cfg.Gemini, _ = newFakeLLM(t, func(prompt string) (string, int) {
+		if strings.Contains(prompt, "overall_value") {
+			reportCalls.Add(1)
+			// Keep the first generation in flight long enough for the other chat to reach the lock
+			time.Sleep(50 * time.Millisecond)
+			return `{"analysis":"Anak perlu berlatih huruf b dan d.","recommendations":"Latihan setiap hari.","overall_value":"cukup"}`, http.StatusOK
+		}
+		return "Semangat!", http.StatusOK
+	})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := u.ChatWithBot(context.Background(), "sess-1", "halo", "")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("ChatWithBot: %v", err)
+		}
+	}
+	if got := reportCalls.Load(); got != 1 {
+		t.Errorf("report LLM calls = %d, want 1", got)
+	}
+	if _, ok := repo.caches["sess-1"]; !ok {
+		t.Errorf("analysis cache not saved")
+	}
+}
@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/evandrarf/dinacom-be/internal/pkg/llm"
+)
+
+// withRequestedModel binds a per-request model override to ctx. Only models listed in
+// llm.allowed_models are accepted; an empty model keeps the configured llm.gemini.model.
+func (u *dyslexiaQuestionUsecase) withRequestedModel(ctx context.Context, model string) (context.Context, error) {
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return ctx, nil
+	}
+
+	for _, allowed := range u.cfg.Config.GetStringSlice("llm.allowed_models") {
+		if strings.TrimSpace(allowed) == model {
+			return llm.WithModel(ctx, model), nil
+		}
+	}
+	return ctx, fmt.Errorf("%w: model %q is not allowed", ErrInvalidInput, model)
+}
+
+// generatedBy labels AI-generated questions with the provider and model that produced them
+// (e.g. api.openai.com/gpt-4o-mini), so question quality can be compared across model upgrades.
+func (u *dyslexiaQuestionUsecase) generatedBy(ctx context.Context) string {
+	if u.cfg.Gemini == nil {
+		return "ai"
+	}
+	label := u.cfg.Gemini.Provider() + "/" + u.cfg.Gemini.ModelFor(ctx)
+	if len(label) > 150 {
+		label = label[:150] // generated_questions.generated_by is size:150
+	}
+	return label
+}
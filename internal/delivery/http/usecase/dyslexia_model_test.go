@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+// A model from llm.allowed_models reaches the provider for that call only; any other model is rejected
+// before the LLM is called
+func TestRequestedModel(t *testing.T) {
+	calls := map[string]func(u *dyslexiaQuestionUsecase, model string) error{
+		"generate": func(u *dyslexiaQuestionUsecase, model string) error {
+			_, _, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 1, Patterns: []string{"b-d"}, UseAI: true, Shuffle: true, Model: model})
+			return err
+		},
+		"chat": func(u *dyslexiaQuestionUsecase, model string) error {
+			_, err := u.ChatWithBot(context.Background(), "sess-1", "halo", model)
+			return err
+		},
+	}
+	tests := []struct {
+		name      string
+		model     string
+		wantErr   bool
+		wantModel string
+	}{
+		{name: "allowed override", model: "local-model", wantModel: "local-model"},
+		{name: "no override", wantModel: "test-model"},
+		{name: "not allowed", model: "gpt-4o", wantErr: true},
+	}
+	for call, run := range calls {
+		for _, tt := range tests {
+			t.Run(call+"/"+tt.name, func(t *testing.T) {
+				repo := newFakeRepo()
+				repo.caches["sess-1"] = &internalEntity.SessionAnalysisCache{SessionID: "sess-1", TotalQuestions: 2}
+				u := newTestUsecase(t, repo)
+				u.cfg.Config.Set("llm.allowed_models", []string{"gpt-4o-mini", "local-model"})
+				var fake *fakeLLM
+				u.cfg.Gemini, fake = newFakeLLM(t, replyWith(`{"correctAnswer":"bola","options":["bola","dola","pola","boda"]}`))
+
+				err := run(u, tt.model)
+				if tt.wantErr {
+					if err == nil {
+						t.Fatal("want an error for a model outside llm.allowed_models")
+					}
+					if got := fake.calls.Load(); got != 0 {
+						t.Errorf("LLM calls = %d, want none for a rejected model", got)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("call: %v", err)
+				}
+				if got, _ := fake.lastModel.Load().(string); got != tt.wantModel {
+					t.Errorf("provider got model %q, want %q", got, tt.wantModel)
+				}
+				if u.cfg.Gemini.Model != "test-model" {
+					t.Errorf("client model changed to %q; the override must not outlive the call", u.cfg.Gemini.Model)
+				}
+			})
+		}
+	}
+}
+
+// AI questions are stored with the provider host and the model that produced them, override included
+func TestGeneratedByRecordsModel(t *testing.T) {
+	tests := []struct {
+		name  string
+		model string
+		want  string
+	}{
+		{name: "configured model", want: "test-model"},
+		{name: "requested model", model: "local-model", want: "local-model"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			u := newTestUsecase(t, repo)
+			u.cfg.PromptTemplate = defaultPromptTemplate
+			u.cfg.Config.Set("llm.allowed_models", []string{"local-model"})
+			u.cfg.Gemini, _ = newFakeLLM(t, replyWith(`{"correctAnswer":"bola","options":["bola","dola","pola","boda"]}`))
+			u.saveOutbox = newGeneratedOutbox(4, 0, 0, nil, func(q entity.GeneratedQuestion, letterPair, prompt, generatedBy string) error {
+				return u.saveGeneratedToDB(context.Background(), q, letterPair, prompt, generatedBy)
+			})
+
+			questions, _, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 1, Patterns: []string{"b-d"}, UseAI: true, Shuffle: true, Model: tt.model})
+			if err != nil || len(questions) != 1 {
+				t.Fatalf("Generate = %+v, %v; want one question", questions, err)
+			}
+			if err := u.saveOutbox.Close(context.Background()); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			stored, err := repo.FindGeneratedByQuestionID(nil, questions[0].ID)
+			if err != nil {
+				t.Fatalf("question %s was not stored: %v", questions[0].ID, err)
+			}
+			want := u.cfg.Gemini.Provider() + "/" + tt.want
+			if !strings.HasPrefix(want, "127.0.0.1:") || stored.GeneratedBy != want {
+				t.Errorf("generated_by = %q, want %q", stored.GeneratedBy, want)
+			}
+		})
+	}
+
+	if got := newTestUsecase(t, newFakeRepo()).generatedBy(context.Background()); got != "ai" {
+		t.Errorf("generated_by without an LLM client = %q, want ai", got)
+	}
+}
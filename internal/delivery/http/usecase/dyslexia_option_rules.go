@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Default cap on the length of an option word (questions.options.max_length)
+const defaultOptionMaxLength = 20
+
+// optionRules reads the option word rules: questions.options.max_length (letters, 0 = no limit),
+// questions.options.charset (latin = A-Z only, unicode = any letter, for locales with diacritics)
+// and questions.options.extra_chars (characters allowed besides letters, e.g. "-'").
+func (u *dyslexiaQuestionUsecase) optionRules() (maxLength int, unicodeLetters bool, extraChars string) {
+	maxLength = defaultOptionMaxLength
+	if u.cfg.Config.IsSet("questions.options.max_length") {
+		maxLength = u.cfg.Config.GetInt("questions.options.max_length")
+	}
+	unicodeLetters = strings.EqualFold(strings.TrimSpace(u.cfg.Config.GetString("questions.options.charset")), "unicode")
+	return maxLength, unicodeLetters, u.cfg.Config.GetString("questions.options.extra_chars")
+}
+
+// validateOptionWord rejects an option that is too long or contains characters other than letters
+// (and the configured extra characters)
+func (u *dyslexiaQuestionUsecase) validateOptionWord(word string) error {
+	maxLength, unicodeLetters, extraChars := u.optionRules()
+
+	if length := utf8.RuneCountInString(word); maxLength > 0 && length > maxLength {
+		return fmt.Errorf("option %q has %d characters, more than the maximum %d", truncateRunes(word, maxLength), length, maxLength)
+	}
+	for _, r := range word {
+		letter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (unicodeLetters && unicode.IsLetter(r))
+		if !letter && !strings.ContainsRune(extraChars, r) {
+			return fmt.Errorf("option %q contains %q, only letters are allowed", word, r)
+		}
+	}
+	return nil
+}
+
+// sanitizeAIOptions rejects AI output whose correct answer breaks the option rules and drops
+// distractors that do; the caller's minimum-options check decides whether enough remain
+func (u *dyslexiaQuestionUsecase) sanitizeAIOptions(g geminiQuestionJSON) (geminiQuestionJSON, error) {
+	if err := u.validateOptionWord(g.CorrectAnswer); err != nil {
+		return geminiQuestionJSON{}, fmt.Errorf("AI correct answer rejected: %w", err)
+	}
+
+	options := make([]string, 0, len(g.Options))
+	for _, opt := range g.Options {
+		if err := u.validateOptionWord(opt); err != nil {
+			u.logger().Warnf("[OPTIONS] Dropped AI option: %v", err)
+			continue
+		}
+		options = append(options, opt)
+	}
+	g.Options = options
+	return g, nil
+}
+
+// truncateRunes keeps error messages short when a malformed "word" is very long
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "..."
+}
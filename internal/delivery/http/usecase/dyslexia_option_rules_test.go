@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateOptionWord(t *testing.T) {
+	tests := []struct {
+		name    string
+		word    string
+		config  map[string]any
+		wantErr bool
+	}{
+		{name: "letters", word: "BOLA"},
+		{name: "at the default maximum", word: strings.Repeat("b", 20)},
+		{name: "over the default maximum", word: strings.Repeat("b", 21), wantErr: true},
+		{name: "over a configured maximum", word: "bolabola", config: map[string]any{"questions.options.max_length": 5}, wantErr: true},
+		{name: "no limit", word: strings.Repeat("b", 500), config: map[string]any{"questions.options.max_length": 0}},
+		{name: "digit", word: "b0la", wantErr: true},
+		{name: "space", word: "bo la", wantErr: true},
+		{name: "punctuation", word: "bola!", wantErr: true},
+		{name: "diacritic in latin", word: "bóla", wantErr: true},
+		{name: "diacritic in unicode", word: "bóla", config: map[string]any{"questions.options.charset": "unicode"}},
+		{name: "extra chars", word: "ke-bola", config: map[string]any{"questions.options.extra_chars": "-'"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newTestUsecase(t, newFakeRepo())
+			for k, v := range tt.config {
+				u.cfg.Config.Set(k, v)
+			}
+			if err := u.validateOptionWord(tt.word); (err != nil) != tt.wantErr {
+				t.Errorf("validateOptionWord(%q) = %v, want error %v", tt.word, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// A bad correct answer rejects the AI question, bad distractors are dropped
+func TestSanitizeAIOptions(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+
+	got, err := u.sanitizeAIOptions(geminiQuestionJSON{CorrectAnswer: "bola", Options: []string{"bola", "dola", strings.Repeat("x", 500), "bo1a", "bela"}})
+	if err != nil {
+		t.Fatalf("sanitizeAIOptions: %v", err)
+	}
+	if want := []string{"bola", "dola", "bela"}; !slices.Equal(got.Options, want) {
+		t.Errorf("options = %v, want %v", got.Options, want)
+	}
+
+	if _, err := u.sanitizeAIOptions(geminiQuestionJSON{CorrectAnswer: strings.Repeat("b", 500), Options: []string{"bola", "dola"}}); err == nil {
+		t.Error("over-length correct answer accepted")
+	}
+	if _, err := u.sanitizeAIOptions(geminiQuestionJSON{CorrectAnswer: "bola<script>", Options: []string{"bola<script>", "dola"}}); err == nil {
+		t.Error("non-letter correct answer accepted")
+	}
+}
+
+// generateFromAI and the batch generator never return an option that breaks the rules
+func TestGenerateFromAIOptionRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		wantErr bool
+	}{
+		{name: "bad distractor dropped", reply: `{"correctAnswer":"bola","options":["bola","dola","` + strings.Repeat("d", 500) + `","bela"]}`},
+		{name: "over-length correct answer", reply: `{"correctAnswer":"` + strings.Repeat("b", 500) + `","options":["` + strings.Repeat("b", 500) + `","dola"]}`, wantErr: true},
+		{name: "non-letter correct answer", reply: `{"correctAnswer":"b0la","options":["b0la","dola"]}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newTestUsecase(t, newFakeRepo())
+			u.cfg.PromptTemplate = defaultPromptTemplate
+			u.cfg.Gemini, _ = newFakeLLM(t, replyWith(tt.reply))
+
+			q, _, err := u.generateFromAI(context.Background(), "easy", "b-d", true, false)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("generateFromAI accepted %+v", q)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("generateFromAI: %v", err)
+			}
+			for _, opt := range q.Options {
+				if len(opt) > 20 {
+					t.Errorf("option %q longer than the maximum was kept", opt)
+				}
+			}
+			if len(q.Options) != 3 {
+				t.Errorf("options = %v, want the 3 valid ones", q.Options)
+			}
+		})
+	}
+
+	u := newTestUsecase(t, newFakeRepo())
+	u.cfg.Gemini, _ = newFakeLLM(t, replyWith(`{"questions":[{"correctAnswer":"bola","options":["bola","dola","b3la"]},{"correctAnswer":"b@la","options":["b@la","dola"]}]}`))
+	questions, err := u.generateBatchFromAI(context.Background(), "easy", 2, []string{"b-d"}, true)
+	if err != nil {
+		t.Fatalf("generateBatchFromAI: %v", err)
+	}
+	if len(questions) != 1 || slices.Contains(questions[0].Options, "b3la") {
+		t.Errorf("batch = %+v, want only the valid question without its bad distractor", questions)
+	}
+}
@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/sirupsen/logrus"
+)
+
+// generatedOutbox persists AI-generated questions off the request path. Writes are queued on a buffered
+// channel and drained by a single worker that retries with backoff, so a brief DB outage delays the cache
+// instead of losing the questions. Close drains whatever is still queued before shutdown.
+type generatedOutbox struct {
+	mu         sync.RWMutex
+	closed     bool
+	items      chan outboxItem
+	save       func(q entity.GeneratedQuestion, letterPair string, prompt string, generatedBy string) error
+	maxRetries int
+	backoff    time.Duration
+	abort      chan struct{}
+	done       chan struct{}
+	detached   sync.WaitGroup // single-attempt writes made while the queue was full or closed
+	log        *logrus.Logger
+}
+
+type outboxItem struct {
+	question    entity.GeneratedQuestion
+	letterPair  string
+	prompt      string
+	generatedBy string
+}
+
+func newGeneratedOutbox(size int, maxRetries int, backoff time.Duration, log *logrus.Logger, save func(entity.GeneratedQuestion, string, string, string) error) *generatedOutbox {
+	if size <= 0 {
+		size = 1
+	}
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	if log == nil {
+		log = logrus.StandardLogger()
+	}
+	o := &generatedOutbox{
+		items:      make(chan outboxItem, size),
+		save:       save,
+		maxRetries: maxRetries,
+		backoff:    backoff,
+		abort:      make(chan struct{}),
+		done:       make(chan struct{}),
+		log:        log,
+	}
+	go o.run()
+	return o
+}
+
+// Enqueue never blocks the caller: when the queue is full or already closed the write is attempted once, detached
+func (o *generatedOutbox) Enqueue(q entity.GeneratedQuestion, letterPair string, prompt string, generatedBy string) {
+	o.mu.RLock()
+	if !o.closed {
+		select {
+		case o.items <- outboxItem{question: q, letterPair: letterPair, prompt: prompt, generatedBy: generatedBy}:
+			o.mu.RUnlock()
+			return
+		default:
+			o.log.Warnf("[OUTBOX] Queue full, saving question %s without retry", q.ID)
+		}
+	}
+	o.detached.Add(1)
+	o.mu.RUnlock()
+
+	go func() {
+		defer o.detached.Done()
+		if err := o.save(q, letterPair, prompt, generatedBy); err != nil {
+			o.log.Warnf("Failed to save question to DB: %v", err)
+		}
+	}()
+}
+
+func (o *generatedOutbox) run() {
+	defer close(o.done)
+	for item := range o.items {
+		o.deliver(item)
+	}
+}
+
+func (o *generatedOutbox) deliver(item outboxItem) {
+	delay := o.backoff
+	for attempt := 0; ; attempt++ {
+		err := o.save(item.question, item.letterPair, item.prompt, item.generatedBy)
+		if err == nil {
+			return
+		}
+		if attempt >= o.maxRetries {
+			o.log.Warnf("Failed to save question %s to DB after %d attempts: %v", item.question.ID, attempt+1, err)
+			return
+		}
+		o.log.Warnf("[OUTBOX] Save of question %s failed (attempt %d/%d): %v", item.question.ID, attempt+1, o.maxRetries+1, err)
+
+		select {
+		case <-time.After(delay):
+		case <-o.abort:
+			// Shutdown deadline passed: make one last attempt per item instead of sleeping
+		}
+		delay *= 2
+	}
+}
+
+// Close stops accepting new writes and waits until the queue and detached writes are drained or ctx expires.
+// After ctx expires pending retries stop waiting for their backoff.
+func (o *generatedOutbox) Close(ctx context.Context) error {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		<-o.done
+		return nil
+	}
+	o.closed = true
+	close(o.items)
+	o.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		<-o.done
+		o.detached.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		close(o.abort)
+		return fmt.Errorf("outbox drain interrupted with %d questions pending: %w", len(o.items), ctx.Err())
+	}
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/sirupsen/logrus"
+)
+
+// The outbox retries failed saves with backoff up to maxRetries and logs every failed attempt
+func TestGeneratedOutboxRetries(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int64
+		maxRetries  int
+		wantSaves   int64
+		wantRetries int
+		wantGivenUp bool
+	}{
+		{name: "first attempt succeeds", failures: 0, maxRetries: 2, wantSaves: 1},
+		{name: "retry succeeds", failures: 2, maxRetries: 2, wantSaves: 3, wantRetries: 2},
+		{name: "retries exhausted", failures: 5, maxRetries: 1, wantSaves: 2, wantRetries: 1, wantGivenUp: true},
+		{name: "no retries", failures: 1, maxRetries: 0, wantSaves: 1, wantGivenUp: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log := logrus.New()
+			log.SetOutput(&buf)
+
+			var saves atomic.Int64
+			o := newGeneratedOutbox(4, tt.maxRetries, time.Millisecond, log, func(entity.GeneratedQuestion, string, string, string) error {
+				if saves.Add(1) <= tt.failures {
+					return errors.New("db down")
+				}
+				return nil
+			})
+			o.Enqueue(entity.GeneratedQuestion{ID: "q-1"}, "b-d", "prompt", "ai")
+			if err := o.Close(context.Background()); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			if got := saves.Load(); got != tt.wantSaves {
+				t.Errorf("saves = %d, want %d", got, tt.wantSaves)
+			}
+			if got := strings.Count(buf.String(), "[OUTBOX] Save of question q-1 failed"); got != tt.wantRetries {
+				t.Errorf("logged %d retries, want %d: %s", got, tt.wantRetries, buf.String())
+			}
+			if got := strings.Contains(buf.String(), "Failed to save question q-1 to DB after"); got != tt.wantGivenUp {
+				t.Errorf("give-up logged = %v, want %v: %s", got, tt.wantGivenUp, buf.String())
+			}
+		})
+	}
+}
+
+// Writes enqueued after Close are still saved once, detached, and never block the caller
+func TestGeneratedOutboxEnqueueAfterClose(t *testing.T) {
+	var buf bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&buf)
+
+	var saves atomic.Int64
+	o := newGeneratedOutbox(1, 3, time.Hour, log, func(entity.GeneratedQuestion, string, string, string) error {
+		saves.Add(1)
+		return errors.New("db down")
+	})
+	if err := o.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	o.Enqueue(entity.GeneratedQuestion{ID: "q-late"}, "b-d", "prompt", "ai")
+	o.detached.Wait()
+
+	if got := saves.Load(); got != 1 {
+		t.Errorf("saves = %d, want a single detached attempt", got)
+	}
+	if !strings.Contains(buf.String(), "level=warning msg=\"Failed to save question to DB: db down\"") {
+		t.Errorf("detached failure not logged: %s", buf.String())
+	}
+}
+
+// Close gives up waiting at the deadline and cuts the remaining backoff short
+func TestGeneratedOutboxCloseDeadline(t *testing.T) {
+	var saves atomic.Int64
+	o := newGeneratedOutbox(1, 1, time.Hour, logrus.New(), func(entity.GeneratedQuestion, string, string, string) error {
+		saves.Add(1)
+		return errors.New("db down")
+	})
+	o.Enqueue(entity.GeneratedQuestion{ID: "q-1"}, "b-d", "prompt", "ai")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := o.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close error = %v, want deadline exceeded", err)
+	}
+	<-o.done
+	if got := saves.Load(); got != 2 {
+		t.Errorf("saves = %d, want the first attempt plus one last attempt after abort", got)
+	}
+}
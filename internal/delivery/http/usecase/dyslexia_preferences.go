@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+	"gorm.io/gorm"
+)
+
+// GetUserPreferences returns the saved preferences of userID; a user who never saved any gets empty preferences
+func (u *dyslexiaQuestionUsecase) GetUserPreferences(ctx context.Context, userID string) (*entity.UserPreferences, error) {
+	prefs, err := u.cfg.Repository.FindUserPreferences(u.dbWithContext(ctx), userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &entity.UserPreferences{UserID: userID, LetterPairs: []string{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch preferences: %w", err)
+	}
+	return toUserPreferences(prefs), nil
+}
+
+// SaveUserPreferences replaces the preferences of userID. Letter pairs are always validated strictly,
+// regardless of dyslexia.pattern_mode, so a typo is reported instead of being dropped on every generate.
+func (u *dyslexiaQuestionUsecase) SaveUserPreferences(ctx context.Context, userID string, req entity.UserPreferencesRequest) (*entity.UserPreferences, error) {
+	pairs, rejected := splitPatterns(req.LetterPairs)
+	if len(rejected) > 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, invalidPatternsError(rejected))
+	}
+
+	prefs := &internalEntity.UserPreferences{
+		UserID:              userID,
+		PreferredDifficulty: string(req.PreferredDifficulty),
+		LetterPairs:         strings.Join(pairs, ","),
+		Language:            req.Language,
+		Voice:               req.Voice,
+		UpdatedAt:           time.Now(),
+	}
+	if err := u.cfg.Repository.UpsertUserPreferences(u.dbWithContext(ctx), prefs); err != nil {
+		return nil, fmt.Errorf("failed to save preferences: %w", err)
+	}
+	return toUserPreferences(prefs), nil
+}
+
+// applyUserPreferences fills an omitted difficulty or pattern list from the preferences of the session's
+// user. Explicit request values always win; a preferred difficulty that is no longer configured is ignored.
+func (u *dyslexiaQuestionUsecase) applyUserPreferences(ctx context.Context, sessionID string, difficulty entity.Difficulty, patterns []string) (entity.Difficulty, []string) {
+	if difficulty != "" && len(patterns) > 0 {
+		return difficulty, patterns
+	}
+	userID := u.resolveUserID(sessionID)
+	if userID == "" {
+		return difficulty, patterns
+	}
+	prefs, err := u.cfg.Repository.FindUserPreferences(u.dbWithContext(ctx), userID)
+	if err != nil {
+		return difficulty, patterns
+	}
+
+	if difficulty == "" && prefs.PreferredDifficulty != "" {
+		if _, ok := u.lookupDifficulty(entity.Difficulty(prefs.PreferredDifficulty)); ok {
+			difficulty = entity.Difficulty(prefs.PreferredDifficulty)
+		}
+	}
+	if len(patterns) == 0 && prefs.LetterPairs != "" {
+		patterns = strings.Split(prefs.LetterPairs, ",")
+	}
+	return difficulty, patterns
+}
+
+func toUserPreferences(prefs *internalEntity.UserPreferences) *entity.UserPreferences {
+	pairs := []string{}
+	if prefs.LetterPairs != "" {
+		pairs = strings.Split(prefs.LetterPairs, ",")
+	}
+	return &entity.UserPreferences{
+		UserID:              prefs.UserID,
+		PreferredDifficulty: prefs.PreferredDifficulty,
+		LetterPairs:         pairs,
+		Language:            prefs.Language,
+		Voice:               prefs.Voice,
+		UpdatedAt:           prefs.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+func TestSaveUserPreferences(t *testing.T) {
+	repo := newFakeRepo()
+	u := newTestUsecase(t, repo)
+
+	saved, err := u.SaveUserPreferences(context.Background(), "user-1", entity.UserPreferencesRequest{
+		UserID: "user-1", PreferredDifficulty: "medium", LetterPairs: []string{" B-D ", "p-q"}, Language: "en",
+	})
+	if err != nil {
+		t.Fatalf("SaveUserPreferences: %v", err)
+	}
+	if !slices.Equal(saved.LetterPairs, []string{"b-d", "p-q"}) || saved.PreferredDifficulty != "medium" {
+		t.Errorf("saved = %+v", saved)
+	}
+
+	got, err := u.GetUserPreferences(context.Background(), "user-1")
+	if err != nil || got.Language != "en" || !slices.Equal(got.LetterPairs, saved.LetterPairs) {
+		t.Errorf("GetUserPreferences = %+v, %v", got, err)
+	}
+
+	_, err = u.SaveUserPreferences(context.Background(), "user-1", entity.UserPreferencesRequest{UserID: "user-1", LetterPairs: []string{"x-y"}})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("unknown pair: err = %v, want ErrInvalidInput", err)
+	}
+	if repo.prefs["user-1"].LetterPairs != "b-d,p-q" {
+		t.Errorf("rejected save overwrote the preferences: %+v", repo.prefs["user-1"])
+	}
+}
+
+// Saved preferences fill what a generate request omits; explicit values win
+func TestApplyUserPreferencesDefaults(t *testing.T) {
+	repo := newFakeRepo()
+	repo.sessions["sess-1"] = &internalEntity.Session{SessionID: "sess-1", UserID: "user-1"}
+	repo.prefs["user-1"] = &internalEntity.UserPreferences{UserID: "user-1", PreferredDifficulty: "hard", LetterPairs: "m-n"}
+	repo.prefs["user-2"] = &internalEntity.UserPreferences{UserID: "user-2", PreferredDifficulty: "retired"}
+	repo.sessions["sess-2"] = &internalEntity.Session{SessionID: "sess-2", UserID: "user-2"}
+	u := newTestUsecase(t, repo)
+
+	tests := []struct {
+		name           string
+		sessionID      string
+		difficulty     entity.Difficulty
+		patterns       []string
+		wantDifficulty entity.Difficulty
+		wantPatterns   []string
+	}{
+		{name: "omitted values come from preferences", sessionID: "sess-1", wantDifficulty: "hard", wantPatterns: []string{"m-n"}},
+		{name: "explicit values win", sessionID: "sess-1", difficulty: "easy", patterns: []string{"b-d"}, wantDifficulty: "easy", wantPatterns: []string{"b-d"}},
+		{name: "unknown session keeps the request", sessionID: "sess-x"},
+		{name: "unconfigured preferred difficulty is ignored", sessionID: "sess-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			difficulty, patterns := u.applyUserPreferences(context.Background(), tt.sessionID, tt.difficulty, tt.patterns)
+			if difficulty != tt.wantDifficulty || !slices.Equal(patterns, tt.wantPatterns) {
+				t.Errorf("got %q %v, want %q %v", difficulty, patterns, tt.wantDifficulty, tt.wantPatterns)
+			}
+		})
+	}
+}
+
+// Generate serves the preferred difficulty and pairs when the request names neither
+func TestGenerateAppliesUserPreferences(t *testing.T) {
+	repo := newFakeRepo()
+	repo.sessions["sess-1"] = &internalEntity.Session{SessionID: "sess-1", UserID: "user-1"}
+	repo.prefs["user-1"] = &internalEntity.UserPreferences{UserID: "user-1", PreferredDifficulty: "medium", LetterPairs: "p-q"}
+	u := newTestUsecase(t, repo)
+	u.cfg.Config.Set("llm.gemini.disable_ai_prompt", true)
+
+	questions, _, err := u.Generate(context.Background(), entity.GenerateOptions{Count: 5, UseAI: true, SessionID: "sess-1", Shuffle: true})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(questions) == 0 {
+		t.Fatal("no questions generated")
+	}
+	for _, q := range questions {
+		if q.Difficulty != entity.DifficultyMedium || q.TargetLetterPair != "p-q" {
+			t.Errorf("question %s is %s/%s, want medium/p-q", q.ID, q.Difficulty, q.TargetLetterPair)
+		}
+	}
+}
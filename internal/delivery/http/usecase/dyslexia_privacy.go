@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/repository"
+	"gorm.io/gorm"
+)
+
+// Default window (in days) during which erased user data can still be restored
+const defaultRestoreGraceDays = 30
+
+// DeleteUserData soft-deletes all answers, sessions, analysis caches and chat messages of a user in one transaction
+func (u *dyslexiaQuestionUsecase) DeleteUserData(ctx context.Context, userID string) (*entity.UserDataResult, error) {
+	var counts *repository.UserDataCounts
+	err := repository.WithTransaction(u.dbWithContext(ctx), func(tx *gorm.DB) error {
+		var err error
+		counts, err = u.cfg.Repository.SoftDeleteUserData(tx, userID, u.now())
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete user data: %w", err)
+	}
+
+	u.logger().Infof("[PRIVACY] Erased data of user %s: %d answers, %d sessions, %d caches, %d chat messages",
+		userID, counts.Answers, counts.Sessions, counts.AnalysisCaches, counts.ChatMessages)
+	return toUserDataResult(userID, counts), nil
+}
+
+// RestoreUserData undoes DeleteUserData for rows erased within the grace period
+func (u *dyslexiaQuestionUsecase) RestoreUserData(ctx context.Context, userID string) (*entity.UserDataResult, error) {
+	since := u.now().AddDate(0, 0, -u.restoreGraceDays())
+
+	var counts *repository.UserDataCounts
+	err := repository.WithTransaction(u.dbWithContext(ctx), func(tx *gorm.DB) error {
+		var err error
+		counts, err = u.cfg.Repository.RestoreUserData(tx, userID, since)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore user data: %w", err)
+	}
+
+	if counts.Answers+counts.Sessions+counts.AnalysisCaches+counts.ChatMessages+counts.ServeLogs == 0 {
+		return nil, fmt.Errorf("no deleted data to restore for user %s within %d days", userID, u.restoreGraceDays())
+	}
+
+	u.logger().Infof("[PRIVACY] Restored data of user %s: %d answers, %d sessions, %d caches, %d chat messages",
+		userID, counts.Answers, counts.Sessions, counts.AnalysisCaches, counts.ChatMessages)
+	return toUserDataResult(userID, counts), nil
+}
+
+func (u *dyslexiaQuestionUsecase) restoreGraceDays() int {
+	if !u.cfg.Config.IsSet("privacy.restore_grace_days") {
+		return defaultRestoreGraceDays
+	}
+	return u.cfg.Config.GetInt("privacy.restore_grace_days")
+}
+
+func toUserDataResult(userID string, counts *repository.UserDataCounts) *entity.UserDataResult {
+	return &entity.UserDataResult{
+		UserID:         userID,
+		Answers:        counts.Answers,
+		Sessions:       counts.Sessions,
+		AnalysisCaches: counts.AnalysisCaches,
+		ChatMessages:   counts.ChatMessages,
+		ServeLogs:      counts.ServeLogs,
+	}
+}
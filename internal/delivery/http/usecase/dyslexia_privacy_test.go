@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/repository"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+	"gorm.io/gorm"
+)
+
+// seedUserData gives user-1 a session with answers, a cache and chat messages plus an older session whose
+// answer another flow already soft-deleted at removedAt, and user-2 a session an erasure of user-1 must not touch
+func seedUserData(repo *fakeRepo, removedAt time.Time) {
+	repo.sessions["s-1"] = &internalEntity.Session{SessionID: "s-1", UserID: "user-1"}
+	repo.sessions["s-old"] = &internalEntity.Session{SessionID: "s-old", UserID: "user-1"}
+	repo.sessions["s-2"] = &internalEntity.Session{SessionID: "s-2", UserID: "user-2"}
+	repo.answers = []internalEntity.UserAnswer{
+		{ID: 1, UserID: "user-1", SessionID: "s-1", QuestionID: "q-1", UserAnswer: "bola", IsCorrect: true},
+		{ID: 2, UserID: "user-1", SessionID: "s-1", QuestionID: "q-2", UserAnswer: "dola"},
+		{ID: 3, UserID: "user-2", SessionID: "s-2", QuestionID: "q-1", UserAnswer: "bola", IsCorrect: true},
+		{ID: 4, UserID: "user-1", SessionID: "s-old", QuestionID: "q-3", UserAnswer: "pita", DeletedAt: gorm.DeletedAt{Time: removedAt, Valid: true}},
+	}
+	repo.caches["s-1"] = &internalEntity.SessionAnalysisCache{SessionID: "s-1", AccuracyRate: "50.00%"}
+	repo.chats = []internalEntity.ChatMessage{
+		{SessionID: "s-1", Role: "user", Message: "halo"},
+		{SessionID: "s-1", Role: "assistant", Message: "halo juga"},
+	}
+	repo.serveLogs = []internalEntity.QuestionServeLog{{QuestionID: "q-1", UserID: "user-1", SessionID: "s-1"}}
+}
+
+// Deleting then restoring a user brings back exactly the rows the erasure hid, in one transaction each
+func TestUserDataRoundTrip(t *testing.T) {
+	repo := newFakeRepo()
+	seedUserData(repo, time.Now().Add(-time.Hour))
+	u := newTestUsecase(t, repo)
+	db, tx := txTestDB(t)
+	u.cfg.DB = db
+	ctx := context.Background()
+
+	before := func() ([]internalEntity.UserAnswer, []internalEntity.ChatMessage, []internalEntity.QuestionServeLog) {
+		answers, _ := repo.FindUserAnswersBySessionID(nil, "s-1")
+		chats, _ := repo.FindChatMessagesBySessionID(nil, "s-1", 0, repository.ChatHistoryOldestFirst)
+		served, _ := repo.FindServeLogsBySessionID(nil, "s-1")
+		return answers, chats, served
+	}
+	wantAnswers, wantChats, wantServed := before()
+	wantCounts := &entity.UserDataResult{UserID: "user-1", Answers: 2, Sessions: 2, AnalysisCaches: 1, ChatMessages: 2, ServeLogs: 1}
+
+	deleted, err := u.DeleteUserData(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("DeleteUserData: %v", err)
+	}
+	if !reflect.DeepEqual(deleted, wantCounts) {
+		t.Errorf("deleted = %+v, want %+v", deleted, wantCounts)
+	}
+	if answers, chats, served := before(); len(answers) != 0 || len(chats) != 0 || len(served) != 0 {
+		t.Errorf("erased data still visible: %d answers, %d chat messages, %d serve logs", len(answers), len(chats), len(served))
+	}
+	if _, err := repo.FindAnalysisCacheBySessionID(nil, "s-1"); err == nil {
+		t.Error("erased analysis cache still visible")
+	}
+	if other, _ := repo.FindUserAnswersBySessionID(nil, "s-2"); len(other) != 1 {
+		t.Errorf("other user's answers = %d, want 1", len(other))
+	}
+
+	restored, err := u.RestoreUserData(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("RestoreUserData: %v", err)
+	}
+	if !reflect.DeepEqual(restored, wantCounts) {
+		t.Errorf("restored = %+v, want %+v", restored, wantCounts)
+	}
+	if answers, chats, served := before(); !reflect.DeepEqual(answers, wantAnswers) || !reflect.DeepEqual(chats, wantChats) || !reflect.DeepEqual(served, wantServed) {
+		t.Errorf("restored data differs:\nanswers %+v\nwant    %+v\nchats %+v\nwant  %+v\nserved %+v\nwant   %+v", answers, wantAnswers, chats, wantChats, served, wantServed)
+	}
+	if _, err := repo.FindAnalysisCacheBySessionID(nil, "s-1"); err != nil {
+		t.Errorf("analysis cache not restored: %v", err)
+	}
+	if old, _ := repo.FindUserAnswersBySessionID(nil, "s-old"); len(old) != 0 {
+		t.Error("restore brought back an answer deleted outside the erasure")
+	}
+	if commits, rollbacks := tx.counts(); commits != 2 || rollbacks != 0 {
+		t.Errorf("transactions: %d commits, %d rollbacks, want 2 and 0", commits, rollbacks)
+	}
+
+	// Nothing left from the erasure; the other deleted answer is not mistaken for one
+	if _, err := u.RestoreUserData(ctx, "user-1"); err == nil || !strings.Contains(err.Error(), "no deleted data") {
+		t.Errorf("second restore err = %v, want nothing to restore", err)
+	}
+}
+
+// An erasure older than privacy.restore_grace_days can no longer be undone
+func TestRestoreUserDataGraceWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		erasedAgo time.Duration
+		wantErr   bool
+	}{
+		{name: "within grace", erasedAgo: 12 * time.Hour},
+		{name: "past grace", erasedAgo: 48 * time.Hour, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			seedUserData(repo, time.Now().Add(-72*time.Hour))
+			if _, err := repo.SoftDeleteUserData(nil, "user-1", time.Now().Add(-tt.erasedAgo)); err != nil {
+				t.Fatalf("SoftDeleteUserData: %v", err)
+			}
+			u := newTestUsecase(t, repo)
+			u.cfg.DB, _ = txTestDB(t)
+			u.cfg.Config.Set("privacy.restore_grace_days", 1)
+
+			_, err := u.RestoreUserData(context.Background(), "user-1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RestoreUserData err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
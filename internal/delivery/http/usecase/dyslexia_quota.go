@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	QuotaKindGenerate = "generate"
+	QuotaKindChat     = "chat"
+)
+
+const quotaExceededChatMessage = "Wah, kamu sudah banyak belajar hari ini! 🌟 Istirahat dulu ya, besok kita ngobrol lagi."
+
+// quotaLimit returns the daily limit for kind (0 = unlimited)
+func (u *dyslexiaQuestionUsecase) quotaLimit(kind string) int {
+	switch kind {
+	case QuotaKindGenerate:
+		return u.cfg.Config.GetInt("llm.quota.daily_generations")
+	case QuotaKindChat:
+		return u.cfg.Config.GetInt("llm.quota.daily_chat_messages")
+	}
+	return 0
+}
+
+// resolveUserID finds the user owning a session from session metadata or its answers
+func (u *dyslexiaQuestionUsecase) resolveUserID(sessionID string) string {
+	if sessionID == "" {
+		return ""
+	}
+	if session, _ := u.cfg.Repository.FindSessionBySessionID(u.cfg.DB, sessionID); session != nil {
+		return session.UserID
+	}
+	if answers, _ := u.cfg.Repository.FindUserAnswersBySessionID(u.cfg.DB, sessionID); len(answers) > 0 {
+		return answers[0].UserID
+	}
+	return ""
+}
+
+// consumeQuota records amount LLM calls for userID and reports whether they fit in today's quota.
+// Requests without a known user or without a configured limit are always allowed.
+func (u *dyslexiaQuestionUsecase) consumeQuota(userID string, kind string, amount int) bool {
+	limit := u.quotaLimit(kind)
+	if limit <= 0 || userID == "" {
+		return true
+	}
+
+	today := time.Now().Format("2006-01-02")
+	ok, err := u.cfg.Repository.ConsumeLLMUsage(u.cfg.DB, userID, today, kind, amount, limit)
+	if err != nil {
+		u.logger().Warnf("Failed to record LLM usage for %s: %v", userID, err)
+		return true
+	}
+	if !ok {
+		u.logger().Warnf("[QUOTA] User %s exceeded daily %s quota (limit %d)", userID, kind, limit)
+	}
+	return ok
+}
+
+// RemainingQuota returns today's remaining LLM quota for the session's user; limited is false when no quota applies
+func (u *dyslexiaQuestionUsecase) RemainingQuota(ctx context.Context, sessionID string, kind string) (int, bool) {
+	limit := u.quotaLimit(kind)
+	userID := u.resolveUserID(sessionID)
+	if limit <= 0 || userID == "" {
+		return 0, false
+	}
+
+	used, err := u.cfg.Repository.GetLLMUsage(u.cfg.DB, userID, time.Now().Format("2006-01-02"), kind)
+	if err != nil {
+		return 0, false
+	}
+
+	remaining := limit - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, true
+}
@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+// Concurrent consumers never get more than the daily limit between them
+func TestConsumeQuotaConcurrent(t *testing.T) {
+	tests := []struct {
+		name        string
+		limit       int
+		amount      int
+		callers     int
+		wantAllowed int64
+	}{
+		{name: "single calls", limit: 5, amount: 1, callers: 20, wantAllowed: 5},
+		{name: "batches", limit: 10, amount: 3, callers: 20, wantAllowed: 3},
+		{name: "batch above limit", limit: 2, amount: 3, callers: 4, wantAllowed: 0},
+		{name: "no limit", limit: 0, amount: 3, callers: 4, wantAllowed: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			u := newTestUsecase(t, repo)
+			u.cfg.Config.Set("llm.quota.daily_generations", tt.limit)
+
+			var allowed atomic.Int64
+			var wg sync.WaitGroup
+			for i := 0; i < tt.callers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if u.consumeQuota("user-1", QuotaKindGenerate, tt.amount) {
+						allowed.Add(1)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if got := allowed.Load(); got != tt.wantAllowed {
+				t.Errorf("allowed = %d, want %d", got, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+func TestRemainingQuota(t *testing.T) {
+	repo := newFakeRepo()
+	repo.sessions["sess-1"] = &internalEntity.Session{SessionID: "sess-1", UserID: "user-1"}
+	u := newTestUsecase(t, repo)
+	u.cfg.Config.Set("llm.quota.daily_chat_messages", 3)
+
+	if _, limited := u.RemainingQuota(context.Background(), "unknown", QuotaKindChat); limited {
+		t.Error("a session without a user should not be limited")
+	}
+	for _, want := range []int{2, 1, 0, 0} {
+		u.consumeQuota("user-1", QuotaKindChat, 1)
+		if remaining, limited := u.RemainingQuota(context.Background(), "sess-1", QuotaKindChat); !limited || remaining != want {
+			t.Errorf("remaining = %d (limited %v), want %d", remaining, limited, want)
+		}
+	}
+}
+
+// A user over the generation quota is served from the DB cache without calling the LLM, and the meta says why
+// the cache came up short
+func TestGenerateQuotaDowngradesToCache(t *testing.T) {
+	repo := newFakeRepo()
+	repo.sessions["sess-1"] = &internalEntity.Session{SessionID: "sess-1", UserID: "user-1"}
+	repo.questions["q-cached"] = &internalEntity.GeneratedQuestion{QuestionID: "q-cached", Difficulty: "easy", TargetLetterPair: "b-d", Options: `["bola","dola"]`, CorrectAnswer: "bola"}
+	u := newTestUsecase(t, repo)
+	u.cfg.Config.Set("llm.quota.daily_generations", 2)
+	var fake *fakeLLM
+	u.cfg.Gemini, fake = newFakeLLM(t, replyWith(`{"question_text":"Pilih kata yang benar","options":["bola","dola"],"correct_answer":"bola"}`))
+	u.consumeQuota("user-1", QuotaKindGenerate, 1)
+
+	questions, meta, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 2, IncludeAnswer: true, Patterns: []string{"b-d"}, UseAI: true, SessionID: "sess-1", Shuffle: true})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(questions) != 1 || questions[0].ID != "q-cached" {
+		t.Errorf("questions = %+v, want the cached question", questions)
+	}
+	want := entity.GenerateMeta{Requested: 2, Returned: 1, Reason: entity.GenerateShortfallQuotaExceeded}
+	if meta == nil || *meta != want {
+		t.Errorf("meta = %+v, want %+v", meta, want)
+	}
+	if calls := fake.calls.Load(); calls != 0 {
+		t.Errorf("LLM calls = %d, want none once the quota is spent", calls)
+	}
+}
+
+// An exhausted chat quota answers before any analysis work, so it never costs an LLM call
+func TestChatQuotaCheckedBeforeAnalysis(t *testing.T) {
+	repo := newFakeRepo()
+	repo.sessions["sess-1"] = &internalEntity.Session{SessionID: "sess-1", UserID: "user-1"}
+	repo.answers = []internalEntity.UserAnswer{{SessionID: "sess-1", UserID: "user-1", QuestionID: "q-1"}}
+	u := newTestUsecase(t, repo)
+	u.cfg.Config.Set("llm.quota.daily_chat_messages", 1)
+	var fake *fakeLLM
+	u.cfg.Gemini, fake = newFakeLLM(t, replyWith(`{"analysis":"Anak perlu berlatih.","recommendations":"Latihan.","overall_value":"cukup"}`))
+	u.consumeQuota("user-1", QuotaKindChat, 1)
+
+	resp, err := u.ChatWithBot(context.Background(), "sess-1", "halo", "")
+	if err != nil {
+		t.Fatalf("ChatWithBot: %v", err)
+	}
+	if resp.Response != quotaExceededChatMessage {
+		t.Errorf("response = %q, want the quota message", resp.Response)
+	}
+	if calls := fake.calls.Load(); calls != 0 {
+		t.Errorf("LLM calls = %d, want none once the quota is spent", calls)
+	}
+	if _, cached := repo.caches["sess-1"]; cached {
+		t.Errorf("analysis generated for a user out of quota")
+	}
+}
+
+// A rejected quota check is logged as a warning through the usecase logger
+func TestConsumeQuotaLogsRejection(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	u.cfg.Config.Set("llm.quota.daily_generations", 1)
+	logs := captureLog(u)
+
+	if !u.consumeQuota("user-1", QuotaKindGenerate, 1) {
+		t.Fatalf("first call rejected")
+	}
+	if logs.Len() != 0 {
+		t.Errorf("allowed call logged: %s", logs.String())
+	}
+	if u.consumeQuota("user-1", QuotaKindGenerate, 1) {
+		t.Fatalf("call over the limit allowed")
+	}
+	if !strings.Contains(logs.String(), `level=warning msg="[QUOTA] User user-1 exceeded daily`) {
+		t.Errorf("rejection not logged as a warning: %s", logs.String())
+	}
+}
@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+// Default number of questions regenerated in parallel by RegenerateOptions
+const defaultRegenerateConcurrency = 4
+
+const regenerateOptionsPrompt = `You are creating a dyslexia reading exercise in Indonesian.
+The correct word is "{{word}}" (difficulty: {{difficulty}}), targeting the confusable letter pair {{targetLetterPair}}.
+
+Create 3 NEW distractors that look visually similar to the correct word, mainly by swapping letters of the pair {{targetLetterPair}}.
+Distractors must differ from the correct word and from each other.
+
+IMPORTANT: Return ONLY valid JSON, NO markdown, NO code blocks.
+JSON format:
+{"correctAnswer":"{{word}}","options":["{{word}}","...","...","..."]}`
+
+// RegenerateOptions replaces the options of cached questions matching letterPair (and difficulty, if set)
+// with fresh AI distractors. Question ids and correct answers are kept so existing answers stay valid.
+func (u *dyslexiaQuestionUsecase) RegenerateOptions(ctx context.Context, letterPair string, difficulty entity.Difficulty) (*entity.RegenerateResult, error) {
+	if u.cfg.Gemini == nil {
+		return nil, fmt.Errorf("gemini client not configured")
+	}
+
+	patterns, err := validatePatterns([]string{letterPair})
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("pattern is required")
+	}
+	letterPair = patterns[0]
+
+	questions, err := u.cfg.Repository.FindGeneratedByPairAndDifficulty(u.cfg.DB, letterPair, string(difficulty))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find questions: %w", err)
+	}
+
+	concurrency := u.cfg.Config.GetInt("questions.regenerate_concurrency")
+	if concurrency <= 0 {
+		concurrency = defaultRegenerateConcurrency
+	}
+
+	result := &entity.RegenerateResult{Matched: len(questions), FailedIDs: []string{}}
+	var mu sync.Mutex
+	// Scheduling stops once the request is cancelled; in-flight ones finish or fail on ctx
+	started := runBounded(ctx, len(questions), concurrency, func(index int) {
+		q := questions[index]
+		err := u.regenerateQuestionOptions(ctx, q)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			u.logger().Warnf("[REGENERATE] Failed for %s: %v", q.QuestionID, err)
+			result.Failed++
+			result.FailedIDs = append(result.FailedIDs, q.QuestionID)
+			return
+		}
+		result.Updated++
+	})
+	result.Skipped = len(questions) - started
+
+	u.logger().Infof("[REGENERATE] pattern=%s difficulty=%s matched=%d updated=%d failed=%d skipped=%d",
+		letterPair, difficulty, result.Matched, result.Updated, result.Failed, result.Skipped)
+	return result, nil
+}
+
+func (u *dyslexiaQuestionUsecase) regenerateQuestionOptions(ctx context.Context, q internalEntity.GeneratedQuestion) error {
+	prompt := regenerateOptionsPrompt
+	prompt = strings.ReplaceAll(prompt, "{{word}}", q.CorrectAnswer)
+	prompt = strings.ReplaceAll(prompt, "{{difficulty}}", q.Difficulty)
+	prompt = strings.ReplaceAll(prompt, "{{targetLetterPair}}", q.TargetLetterPair)
+
+	text, err := u.cfg.Gemini.GenerateText(ctx, prompt)
+	if err != nil {
+		return err
+	}
+
+	clean := strings.TrimSpace(text)
+	clean = strings.TrimPrefix(clean, "```json")
+	clean = strings.TrimPrefix(clean, "```")
+	clean = strings.TrimSuffix(clean, "```")
+	clean = strings.TrimSpace(clean)
+
+	var parsed geminiQuestionJSON
+	if err := json.Unmarshal([]byte(clean), &parsed); err != nil {
+		u.recordParseFailure("regenerate", prompt, clean, err)
+		return fmt.Errorf("AI output is not valid json: %w", err)
+	}
+	parsed, err = parsed.normalize()
+	if err == nil {
+		parsed, err = u.sanitizeAIOptions(parsed)
+	}
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(parsed.CorrectAnswer, q.CorrectAnswer) {
+		return fmt.Errorf("AI changed the correct answer (%s)", parsed.CorrectAnswer)
+	}
+
+	options := deduplicateOptions(parsed.Options, q.CorrectAnswer)
+	if len(options) < 2 {
+		return fmt.Errorf("not enough unique options after deduplication")
+	}
+
+	optionsJSON, err := json.Marshal(u.shuffleOptions(options))
+	if err != nil {
+		return err
+	}
+	return u.cfg.Repository.UpdateGeneratedOptions(u.cfg.DB, q.QuestionID, string(optionsJSON))
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+func TestRegenerateOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		cancelled   bool
+		wantUpdated int
+		wantFailed  int
+		wantSkipped int
+	}{
+		{name: "matching rows get new options", status: http.StatusOK, wantUpdated: 3},
+		{name: "llm failures are reported", status: http.StatusBadRequest, wantFailed: 3},
+		{name: "cancelled request schedules nothing", status: http.StatusOK, cancelled: true, wantSkipped: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			for i := 0; i < 3; i++ {
+				id := fmt.Sprintf("q-%d", i)
+				repo.questions[id] = &internalEntity.GeneratedQuestion{
+					QuestionID: id, Difficulty: "easy", TargetLetterPair: "b-d", CorrectAnswer: "bola", Options: `["bola","x"]`,
+				}
+			}
+			repo.questions["q-other"] = &internalEntity.GeneratedQuestion{
+				QuestionID: "q-other", Difficulty: "easy", TargetLetterPair: "p-q", CorrectAnswer: "pagi", Options: `["pagi","x"]`,
+			}
+			u := newTestUsecase(t, repo)
+			u.cfg.Config.Set("questions.regenerate_concurrency", 2)
+			logs := captureLog(u)
+			var fake *fakeLLM
+			u.cfg.Gemini, fake = newFakeLLM(t, func(string) (string, int) {
+				return `{"correctAnswer":"bola","options":["bola","dola","boda","doda"]}`, tt.status
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+			result, err := u.RegenerateOptions(ctx, "b-d", "")
+			if err != nil {
+				t.Fatalf("RegenerateOptions: %v", err)
+			}
+			if result.Matched != 3 || result.Updated != tt.wantUpdated || result.Failed != tt.wantFailed || result.Skipped != tt.wantSkipped {
+				t.Errorf("result = %+v, want updated/failed/skipped %d/%d/%d", result, tt.wantUpdated, tt.wantFailed, tt.wantSkipped)
+			}
+			if !strings.Contains(logs.String(), "level=info msg=\"[REGENERATE] pattern=b-d") {
+				t.Errorf("summary not logged through the usecase logger: %s", logs.String())
+			}
+			if got := strings.Count(logs.String(), "level=warning msg=\"[REGENERATE] Failed for"); got != tt.wantFailed {
+				t.Errorf("logged %d failures, want %d", got, tt.wantFailed)
+			}
+			if tt.cancelled && fake.calls.Load() != 0 {
+				t.Errorf("LLM calls after cancel = %d, want 0", fake.calls.Load())
+			}
+			for id, q := range repo.questions {
+				changed := q.Options != `["bola","x"]` && q.Options != `["pagi","x"]`
+				if want := tt.wantUpdated > 0 && id != "q-other"; changed != want {
+					t.Errorf("%s options %s changed = %v, want %v", id, q.Options, changed, want)
+				}
+			}
+		})
+	}
+}
+
+// No more than questions.regenerate_concurrency LLM calls are in flight at once
+func TestRegenerateOptionsBounded(t *testing.T) {
+	repo := newFakeRepo()
+	for i := 0; i < 8; i++ {
+		id := fmt.Sprintf("q-%d", i)
+		repo.questions[id] = &internalEntity.GeneratedQuestion{
+			QuestionID: id, Difficulty: "easy", TargetLetterPair: "b-d", CorrectAnswer: "bola", Options: `["bola","x"]`,
+		}
+	}
+	u := newTestUsecase(t, repo)
+	u.cfg.Config.Set("questions.regenerate_concurrency", 2)
+	var inFlight, peak atomic.Int64
+	u.cfg.Gemini, _ = newFakeLLM(t, func(string) (string, int) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(10 * time.Millisecond)
+		return `{"correctAnswer":"bola","options":["bola","dola","boda","doda"]}`, http.StatusOK
+	})
+
+	result, err := u.RegenerateOptions(context.Background(), "b-d", "easy")
+	if err != nil {
+		t.Fatalf("RegenerateOptions: %v", err)
+	}
+	if result.Updated != 8 {
+		t.Errorf("updated = %d, want 8", result.Updated)
+	}
+	if got := peak.Load(); got > 2 {
+		t.Errorf("peak concurrent LLM calls = %d, want at most 2", got)
+	}
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/repository"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+	"gorm.io/gorm"
+)
+
+// RegradeSession re-evaluates the stored answers of a session against the current correct answers
+// of their questions, e.g. after an admin corrected a question
+func (u *dyslexiaQuestionUsecase) RegradeSession(ctx context.Context, sessionID string) (*entity.RegradeResult, error) {
+	answers, err := u.cfg.Repository.FindUserAnswersBySessionID(u.dbWithContext(ctx), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session answers: %w", err)
+	}
+	if len(answers) == 0 {
+		return nil, fmt.Errorf("%w: no answers for session %s", ErrNotFound, sessionID)
+	}
+	return u.regradeAnswers(ctx, answers)
+}
+
+// RegradeQuestion re-evaluates every stored answer to a question, across sessions
+func (u *dyslexiaQuestionUsecase) RegradeQuestion(ctx context.Context, questionID string) (*entity.RegradeResult, error) {
+	if _, err := u.cfg.Repository.FindGeneratedByQuestionID(u.dbWithContext(ctx), questionID); err != nil {
+		return nil, fmt.Errorf("%w: question %s", ErrNotFound, questionID)
+	}
+
+	answers, err := u.cfg.Repository.FindUserAnswersByQuestionID(u.dbWithContext(ctx), questionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get question answers: %w", err)
+	}
+	return u.regradeAnswers(ctx, answers)
+}
+
+// regradeAnswers grades answers the way SubmitAnswer does and writes back the ones whose grade changed.
+// Cached analyses of the affected sessions are invalidated in the same transaction, so the next report
+// recomputes them. Skipped answers stay skipped.
+func (u *dyslexiaQuestionUsecase) regradeAnswers(ctx context.Context, answers []internalEntity.UserAnswer) (*entity.RegradeResult, error) {
+	questions := u.generatedQuestionsFor(answers)
+	result := &entity.RegradeResult{InvalidatedSessions: []string{}}
+	affected := make(map[string]bool)
+
+	err := repository.WithTransaction(u.dbWithContext(ctx), func(tx *gorm.DB) error {
+		for _, answer := range answers {
+			if answer.Skipped {
+				continue
+			}
+			q, ok := questions[answer.QuestionID]
+			if !ok {
+				result.MissingQuestions++
+				continue
+			}
+			result.Checked++
+
+			userAnswer := strings.TrimSpace(strings.ToUpper(answer.UserAnswer))
+			correctAnswer := strings.TrimSpace(strings.ToUpper(q.CorrectAnswer))
+			isCorrect := answersMatch(userAnswer, correctAnswer)
+			partialCredit := u.partialCredit(userAnswer, correctAnswer, isCorrect)
+			storedCorrectAnswer := u.storedAnswerCase(q.CorrectAnswer)
+
+			if isCorrect == answer.IsCorrect && partialCredit == answer.PartialCredit && storedCorrectAnswer == answer.CorrectAnswer {
+				continue
+			}
+			if err := u.cfg.Repository.UpdateAnswerGrade(tx, answer.ID, storedCorrectAnswer, isCorrect, partialCredit); err != nil {
+				return fmt.Errorf("failed to update answer %d: %w", answer.ID, err)
+			}
+			result.Updated++
+			affected[answer.SessionID] = true
+
+			if isCorrect != answer.IsCorrect {
+				result.Flipped++
+				if isCorrect {
+					result.FlippedToCorrect++
+				} else {
+					result.FlippedToWrong++
+				}
+			}
+		}
+
+		for sessionID := range affected {
+			result.InvalidatedSessions = append(result.InvalidatedSessions, sessionID)
+		}
+		sort.Strings(result.InvalidatedSessions)
+		if _, err := u.cfg.Repository.InvalidateAnalysisCaches(tx, result.InvalidatedSessions, time.Now()); err != nil {
+			return fmt.Errorf("failed to invalidate cached analyses: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.logger().Infof("[REGRADE] Checked %d answers, updated %d, flipped %d (%d sessions invalidated)", result.Checked, result.Updated, result.Flipped, len(result.InvalidatedSessions))
+	return result, nil
+}
@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+// regradeRepo holds a question whose correct answer an admin changed from BOLA to DOLA after it was answered
+func regradeRepo() *fakeRepo {
+	repo := newFakeRepo()
+	repo.questions["q-1"] = &internalEntity.GeneratedQuestion{QuestionID: "q-1", Difficulty: "easy", TargetLetterPair: "b-d", Options: `["BOLA","DOLA"]`, CorrectAnswer: "DOLA"}
+	repo.questions["q-2"] = &internalEntity.GeneratedQuestion{QuestionID: "q-2", Difficulty: "easy", TargetLetterPair: "b-d", Options: `["BELA","DELA"]`, CorrectAnswer: "BELA"}
+	repo.answers = []internalEntity.UserAnswer{
+		{ID: 1, SessionID: "s-1", QuestionID: "q-1", UserAnswer: "BOLA", CorrectAnswer: "BOLA", IsCorrect: true, PartialCredit: 1, AttemptNumber: 1},
+		{ID: 2, SessionID: "s-1", QuestionID: "q-2", UserAnswer: "BELA", CorrectAnswer: "BELA", IsCorrect: true, PartialCredit: 1, AttemptNumber: 1},
+		{ID: 3, SessionID: "s-2", QuestionID: "q-1", UserAnswer: "DOLA", CorrectAnswer: "BOLA", AttemptNumber: 1},
+		{ID: 4, SessionID: "s-1", QuestionID: "q-1", Skipped: true, CorrectAnswer: "BOLA", AttemptNumber: 2},
+		{ID: 5, SessionID: "s-1", QuestionID: "q-gone", UserAnswer: "PAGI", CorrectAnswer: "PAGI", IsCorrect: true, PartialCredit: 1, AttemptNumber: 1},
+	}
+	for _, id := range []string{"s-1", "s-2"} {
+		repo.caches[id] = &internalEntity.SessionAnalysisCache{SessionID: id, TotalQuestions: 2, CorrectAnswers: 2, AIAnalysis: "Analisis lama", ErrorPatterns: `[]`}
+	}
+	return repo
+}
+
+func answerByID(repo *fakeRepo, id uint) internalEntity.UserAnswer {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, a := range repo.answers {
+		if a.ID == id {
+			return a
+		}
+	}
+	return internalEntity.UserAnswer{}
+}
+
+// Changing a question's correct answer and regrading flips the stored answers and invalidates their analyses
+func TestRegrade(t *testing.T) {
+	tests := []struct {
+		name        string
+		run         func(u *dyslexiaQuestionUsecase) (*entity.RegradeResult, error)
+		want        entity.RegradeResult
+		wantCorrect map[uint]bool
+	}{
+		{
+			name: "session",
+			run: func(u *dyslexiaQuestionUsecase) (*entity.RegradeResult, error) {
+				return u.RegradeSession(context.Background(), "s-1")
+			},
+			want:        entity.RegradeResult{Checked: 2, Updated: 1, Flipped: 1, FlippedToWrong: 1, MissingQuestions: 1, InvalidatedSessions: []string{"s-1"}},
+			wantCorrect: map[uint]bool{1: false, 2: true, 3: false},
+		},
+		{
+			name: "question",
+			run: func(u *dyslexiaQuestionUsecase) (*entity.RegradeResult, error) {
+				return u.RegradeQuestion(context.Background(), "q-1")
+			},
+			want:        entity.RegradeResult{Checked: 2, Updated: 2, Flipped: 2, FlippedToCorrect: 1, FlippedToWrong: 1, InvalidatedSessions: []string{"s-1", "s-2"}},
+			wantCorrect: map[uint]bool{1: false, 2: true, 3: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := regradeRepo()
+			u := newTestUsecase(t, repo)
+			var counter *txCounter
+			u.cfg.DB, counter = txTestDB(t)
+
+			got, err := tt.run(u)
+			if err != nil {
+				t.Fatalf("regrade: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("result = %+v, want %+v", *got, tt.want)
+			}
+
+			for id, want := range tt.wantCorrect {
+				if a := answerByID(repo, id); a.IsCorrect != want {
+					t.Errorf("answer %d is_correct = %v, want %v", id, a.IsCorrect, want)
+				}
+			}
+			if a := answerByID(repo, 1); a.CorrectAnswer != "DOLA" {
+				t.Errorf("answer 1 stores correct answer %q, want the corrected DOLA", a.CorrectAnswer)
+			}
+			if a := answerByID(repo, 4); !a.Skipped || a.CorrectAnswer != "BOLA" {
+				t.Errorf("skipped answer was regraded: %+v", a)
+			}
+			for _, id := range tt.want.InvalidatedSessions {
+				if repo.caches[id].InvalidatedAt == nil {
+					t.Errorf("analysis of %s not invalidated", id)
+				}
+			}
+			if commits, rollbacks := counter.counts(); commits != 1 || rollbacks != 0 {
+				t.Errorf("commits/rollbacks = %d/%d, want 1/0", commits, rollbacks)
+			}
+
+			// Regrading again finds nothing left to change
+			again, err := tt.run(u)
+			if err != nil || again.Updated != 0 || len(again.InvalidatedSessions) != 0 {
+				t.Errorf("second regrade = %+v, %v; want no updates", again, err)
+			}
+		})
+	}
+}
+
+func TestRegradeNotFound(t *testing.T) {
+	u := newTestUsecase(t, regradeRepo())
+	u.cfg.DB, _ = txTestDB(t)
+
+	if _, err := u.RegradeSession(context.Background(), "s-unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown session: err = %v, want ErrNotFound", err)
+	}
+	if _, err := u.RegradeQuestion(context.Background(), "q-unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown question: err = %v, want ErrNotFound", err)
+	}
+}
+
+// A failed write rolls the whole regrade back: no analysis is invalidated for a half-applied regrade
+func TestRegradeRollsBack(t *testing.T) {
+	repo := regradeRepo()
+	repo.gradeErr = errors.New("update failed")
+	u := newTestUsecase(t, repo)
+	var counter *txCounter
+	u.cfg.DB, counter = txTestDB(t)
+
+	if _, err := u.RegradeQuestion(context.Background(), "q-1"); err == nil {
+		t.Fatal("regrade with a failing write succeeded")
+	}
+	if commits, rollbacks := counter.counts(); commits != 0 || rollbacks != 1 {
+		t.Errorf("commits/rollbacks = %d/%d, want 0/1", commits, rollbacks)
+	}
+	for id, cache := range repo.caches {
+		if cache.InvalidatedAt != nil {
+			t.Errorf("analysis of %s invalidated by a rolled back regrade", id)
+		}
+	}
+}
+
+// After a regrade, the report and the chatbot both treat the invalidated analysis as a miss and regenerate it
+func TestRegradeInvalidatesAnalysis(t *testing.T) {
+	tests := []struct {
+		name string
+		read func(u *dyslexiaQuestionUsecase) error
+	}{
+		{name: "report", read: func(u *dyslexiaQuestionUsecase) error {
+			_, err := u.GenerateSessionReport(context.Background(), "s-2")
+			return err
+		}},
+		{name: "chat", read: func(u *dyslexiaQuestionUsecase) error {
+			_, err := u.ChatWithBot(context.Background(), "s-2", "halo", "")
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := regradeRepo()
+			// s-2 has a single answer; its cached analysis matches the counts, so only the invalidation forces a miss
+			repo.caches["s-2"] = &internalEntity.SessionAnalysisCache{SessionID: "s-2", TotalQuestions: 1, AIAnalysis: "Analisis lama", ErrorPatterns: `[]`}
+			u := newTestUsecase(t, repo)
+			u.cfg.DB, _ = txTestDB(t)
+			u.cfg.Config.Set("report.min_answers_for_analysis", 1)
+			u.cfg.Gemini, _ = newFakeLLM(t, replyWith(analysisReply))
+
+			if _, err := u.RegradeQuestion(context.Background(), "q-1"); err != nil {
+				t.Fatalf("RegradeQuestion: %v", err)
+			}
+			if err := tt.read(u); err != nil {
+				t.Fatalf("read after regrade: %v", err)
+			}
+			cache := repo.caches["s-2"]
+			if cache.InvalidatedAt != nil || cache.AIAnalysis != "Analisis dari model" || cache.CorrectAnswers != 1 {
+				t.Errorf("analysis after regrade = %+v, want a regenerated one with 1 correct answer", cache)
+			}
+		})
+	}
+}
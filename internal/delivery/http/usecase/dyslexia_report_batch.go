@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+// Default cap on session ids per POST /report/batch
+const defaultBatchReportMax = 50
+
+func (u *dyslexiaQuestionUsecase) batchReportMax() int {
+	if !u.cfg.Config.IsSet("report.batch_max_sessions") {
+		return defaultBatchReportMax
+	}
+	return u.cfg.Config.GetInt("report.batch_max_sessions")
+}
+
+// GetBatchReports returns the cached reports of many sessions in one query. It never calls the LLM:
+// sessions without a cached report are returned with status not_generated. Items keep the request order.
+func (u *dyslexiaQuestionUsecase) GetBatchReports(ctx context.Context, sessionIDs []string) ([]entity.BatchReportItem, error) {
+	unique := make([]string, 0, len(sessionIDs))
+	seen := make(map[string]bool, len(sessionIDs))
+	for _, id := range sessionIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if max := u.batchReportMax(); max > 0 && len(unique) > max {
+		return nil, fmt.Errorf("too many sessions: %d (max %d)", len(unique), max)
+	}
+
+	caches, err := u.cfg.Repository.FindAnalysisCachesBySessionIDs(u.dbWithContext(ctx), unique)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached reports: %w", err)
+	}
+	bySession := make(map[string]*internalEntity.SessionAnalysisCache, len(caches))
+	for i := range caches {
+		bySession[caches[i].SessionID] = &caches[i]
+	}
+
+	items := make([]entity.BatchReportItem, 0, len(unique))
+	for _, id := range unique {
+		cache, ok := bySession[id]
+		if !ok {
+			items = append(items, entity.BatchReportItem{SessionID: id, Status: entity.BatchReportStatusNotGenerated})
+			continue
+		}
+		status := entity.BatchReportStatusReady
+		if cache.InvalidatedAt != nil {
+			status = entity.BatchReportStatusStale
+		}
+		report := reportFromCache(cache)
+		if legacyScore(cache) {
+			report.SessionScore = u.repairCachedScore(ctx, cache)
+		}
+		items = append(items, entity.BatchReportItem{
+			SessionID: id,
+			Status:    status,
+			Report:    report,
+		})
+	}
+	return items, nil
+}
+
+// legacyScore reports whether cache may predate session_score: the column defaulted existing rows to 0,
+// which only a session without correct answers or partial credit really scores
+func legacyScore(cache *internalEntity.SessionAnalysisCache) bool {
+	return cache.SessionScore == 0 && cache.TotalQuestions > 0
+}
+
+// repairCachedScore recomputes the score of a legacy cache from the session's answers, the way the report
+// does, and stores it so the next read is a plain cache hit. On error the cached 0 is kept.
+func (u *dyslexiaQuestionUsecase) repairCachedScore(ctx context.Context, cache *internalEntity.SessionAnalysisCache) float64 {
+	answers, err := u.cfg.Repository.FindUserAnswersBySessionID(u.dbWithContext(ctx), cache.SessionID)
+	if err != nil {
+		u.logger().Warnf("Failed to recompute score of session %s: %v", cache.SessionID, err)
+		return cache.SessionScore
+	}
+	score := scoreAnswers(u.selectReportedAttempts(answers))
+	if score != cache.SessionScore {
+		if err := u.cfg.Repository.UpdateAnalysisCacheScore(u.dbWithContext(ctx), cache.SessionID, score); err != nil {
+			u.logger().Warnf("Failed to store recomputed score of session %s: %v", cache.SessionID, err)
+		}
+	}
+	return score
+}
+
+// reportFromCache rebuilds a SessionReport from its cached row; malformed JSON columns are left empty
+func reportFromCache(cache *internalEntity.SessionAnalysisCache) *entity.SessionReport {
+	errorPatterns := make([]entity.ErrorPattern, 0)
+	if cache.ErrorPatterns != "" {
+		_ = json.Unmarshal([]byte(cache.ErrorPatterns), &errorPatterns)
+	}
+	sortErrorPatterns(errorPatterns)
+
+	difficultyStats := make(map[string]int)
+	if cache.DifficultyStats != "" {
+		_ = json.Unmarshal([]byte(cache.DifficultyStats), &difficultyStats)
+	}
+
+	return &entity.SessionReport{
+		SessionID:       cache.SessionID,
+		TotalQuestions:  cache.TotalQuestions,
+		CorrectAnswers:  cache.CorrectAnswers,
+		WrongAnswers:    cache.WrongAnswers,
+		SkippedAnswers:  cache.TotalQuestions - cache.CorrectAnswers - cache.WrongAnswers,
+		SessionScore:    cache.SessionScore,
+		AccuracyRate:    cache.AccuracyRate,
+		OverallValue:    cache.OverallValue,
+		ErrorPatterns:   errorPatterns,
+		DifficultyStats: difficultyStats,
+		AIAnalysys:      cache.AIAnalysis,
+		Recommendations: cache.Recommendations,
+	}
+}
@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+// Cached sessions come back ready and the rest not_generated, deduplicated in request order, without an LLM call
+func TestGetBatchReports(t *testing.T) {
+	repo := newFakeRepo()
+	repo.caches["s-1"] = &internalEntity.SessionAnalysisCache{SessionID: "s-1", TotalQuestions: 4, CorrectAnswers: 3, WrongAnswers: 1, SessionScore: 3.5, AccuracyRate: "75.0%", AIAnalysis: "Bagus"}
+	repo.caches["s-3"] = &internalEntity.SessionAnalysisCache{SessionID: "s-3", TotalQuestions: 2, CorrectAnswers: 2, SessionScore: 2}
+	u := newTestUsecase(t, repo)
+	var fake *fakeLLM
+	u.cfg.Gemini, fake = newFakeLLM(t, replyWith(analysisReply))
+
+	items, err := u.GetBatchReports(context.Background(), []string{"s-1", "s-2", "s-3", "s-1"})
+	if err != nil {
+		t.Fatalf("GetBatchReports: %v", err)
+	}
+	want := []struct {
+		sessionID, status string
+		score             float64
+	}{
+		{"s-1", entity.BatchReportStatusReady, 3.5},
+		{"s-2", entity.BatchReportStatusNotGenerated, 0},
+		{"s-3", entity.BatchReportStatusReady, 2},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("items = %+v, want %d", items, len(want))
+	}
+	for i, w := range want {
+		got := items[i]
+		if got.SessionID != w.sessionID || got.Status != w.status {
+			t.Errorf("item %d = %s/%s, want %s/%s", i, got.SessionID, got.Status, w.sessionID, w.status)
+			continue
+		}
+		if w.status == entity.BatchReportStatusNotGenerated {
+			if got.Report != nil {
+				t.Errorf("item %d has a report without a cache: %+v", i, got.Report)
+			}
+			continue
+		}
+		if got.Report == nil || got.Report.SessionScore != w.score {
+			t.Errorf("item %d report = %+v, want score %v", i, got.Report, w.score)
+		}
+	}
+	if items[0].Report.AIAnalysys != "Bagus" || items[0].Report.AccuracyRate != "75.0%" {
+		t.Errorf("s-1 report = %+v, want the cached analysis", items[0].Report)
+	}
+	if got := fake.calls.Load(); got != 0 {
+		t.Errorf("LLM calls = %d, want none", got)
+	}
+}
+
+// report.batch_max_sessions caps the unique session ids of one batch; 0 lifts the cap
+func TestGetBatchReportsLimit(t *testing.T) {
+	ids := make([]string, 3)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("s-%d", i)
+	}
+	tests := []struct {
+		name    string
+		max     int
+		ids     []string
+		wantErr bool
+	}{
+		{name: "within the cap", max: 3, ids: ids},
+		{name: "duplicates count once", max: 3, ids: append(ids, ids...)},
+		{name: "over the cap", max: 2, ids: ids, wantErr: true},
+		{name: "no cap", max: 0, ids: ids},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newTestUsecase(t, newFakeRepo())
+			u.cfg.Config.Set("report.batch_max_sessions", tt.max)
+			_, err := u.GetBatchReports(context.Background(), tt.ids)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScoreAnswers(t *testing.T) {
+	answers := []internalEntity.UserAnswer{
+		{IsCorrect: true, PartialCredit: 1},
+		{IsCorrect: false, PartialCredit: 0.5},
+		{IsCorrect: false},
+		{Skipped: true, PartialCredit: 0.8}, // skips score nothing, whatever credit a legacy row carries
+	}
+	if got := scoreAnswers(answers); got != 1.5 {
+		t.Errorf("scoreAnswers = %v, want 1.5", got)
+	}
+}
+
+// Caches written before session_score existed read as 0 and are recomputed from the answers once
+func TestBatchReportRepairsLegacyScore(t *testing.T) {
+	repo := newFakeRepo()
+	repo.caches["legacy"] = &internalEntity.SessionAnalysisCache{SessionID: "legacy", TotalQuestions: 2, CorrectAnswers: 1, WrongAnswers: 1}
+	repo.caches["current"] = &internalEntity.SessionAnalysisCache{SessionID: "current", TotalQuestions: 1, CorrectAnswers: 1, SessionScore: 1}
+	repo.answers = []internalEntity.UserAnswer{
+		{SessionID: "legacy", QuestionID: "q-1", IsCorrect: true, PartialCredit: 1, AttemptNumber: 1},
+		{SessionID: "legacy", QuestionID: "q-2", PartialCredit: 0.5, AttemptNumber: 1},
+	}
+	u := newTestUsecase(t, repo)
+
+	items, err := u.GetBatchReports(context.Background(), []string{"legacy", "current"})
+	if err != nil {
+		t.Fatalf("GetBatchReports: %v", err)
+	}
+	if got := items[0].Report.SessionScore; got != 1.5 {
+		t.Errorf("legacy score = %v, want 1.5", got)
+	}
+	if repo.caches["legacy"].SessionScore != 1.5 {
+		t.Errorf("recomputed score was not stored")
+	}
+	if got := items[1].Report.SessionScore; got != 1 {
+		t.Errorf("current score = %v, want the cached 1", got)
+	}
+}
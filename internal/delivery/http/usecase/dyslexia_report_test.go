@@ -0,0 +1,409 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+const analysisReply = `{"analysis":"Analisis dari model","recommendations":"Latihan b-d setiap hari.","overall_value":"baik"}`
+
+// Below report.min_answers_for_analysis the report carries the numbers and a note, without an LLM call or cache
+func TestSessionReportMinimumAnswers(t *testing.T) {
+	tests := []struct {
+		name             string
+		answers          int
+		wantInsufficient bool
+		wantCalls        int64
+	}{
+		{name: "below the minimum", answers: 2, wantInsufficient: true},
+		{name: "at the minimum", answers: 3, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			for i := 1; i <= tt.answers; i++ {
+				repo.answers = append(repo.answers, internalEntity.UserAnswer{
+					SessionID: "s-1", QuestionID: fmt.Sprintf("q-%d", i), IsCorrect: i%2 == 1, PartialCredit: 1, AttemptNumber: 1,
+				})
+			}
+			u := newTestUsecase(t, repo)
+			u.cfg.DB, _ = txTestDB(t)
+			u.cfg.Config.Set("report.min_answers_for_analysis", 3)
+			var fake *fakeLLM
+			u.cfg.Gemini, fake = newFakeLLM(t, replyWith(analysisReply))
+
+			report, err := u.GenerateSessionReport(context.Background(), "s-1")
+			if err != nil {
+				t.Fatalf("GenerateSessionReport: %v", err)
+			}
+			if report.InsufficientData != tt.wantInsufficient || report.TotalQuestions != tt.answers {
+				t.Errorf("report insufficient=%v total=%d, want %v and %d", report.InsufficientData, report.TotalQuestions, tt.wantInsufficient, tt.answers)
+			}
+			if got := fake.calls.Load(); got != tt.wantCalls {
+				t.Errorf("LLM calls = %d, want %d", got, tt.wantCalls)
+			}
+			_, cached := repo.caches["s-1"]
+			if cached == tt.wantInsufficient {
+				t.Errorf("cached = %v, want %v", cached, !tt.wantInsufficient)
+			}
+			if tt.wantInsufficient && report.AIAnalysys != u.insufficientDataNote(3) {
+				t.Errorf("analysis = %q, want the not-enough-data note", report.AIAnalysys)
+			}
+			if !tt.wantInsufficient && report.AIAnalysys != "Analisis dari model" {
+				t.Errorf("analysis = %q, want the model's", report.AIAnalysys)
+			}
+		})
+	}
+}
+
+// report.min_answers_for_analysis gates the report endpoint only; the chatbot and comparison still get an analysis
+func TestAnalysisCacheIgnoresReportMinimum(t *testing.T) {
+	repo := newFakeRepo()
+	repo.answers = []internalEntity.UserAnswer{{SessionID: "s-1", QuestionID: "q-1", IsCorrect: true, PartialCredit: 1, AttemptNumber: 1}}
+	u := newTestUsecase(t, repo)
+	u.cfg.DB, _ = txTestDB(t)
+	u.cfg.Config.Set("report.min_answers_for_analysis", 3)
+
+	report, err := u.GenerateSessionReport(context.Background(), "s-1")
+	if err != nil {
+		t.Fatalf("GenerateSessionReport: %v", err)
+	}
+	if !report.InsufficientData {
+		t.Errorf("report with 1 of 3 answers should be marked insufficient")
+	}
+	if _, cached := repo.caches["s-1"]; cached {
+		t.Fatalf("insufficient report must not be cached")
+	}
+
+	cache, err := u.getOrGenerateAnalysisCache(context.Background(), "s-1")
+	if err != nil {
+		t.Fatalf("getOrGenerateAnalysisCache: %v", err)
+	}
+	if cache.TotalQuestions != 1 || cache.AIAnalysis == "" {
+		t.Errorf("cache = %+v, want an analysis of the single answer", cache)
+	}
+}
+
+// A repeated report reuses the fresh cache: one LLM call and one feedback message, even when the chat
+// already holds other messages. A new answer makes the cache stale, but the feedback is still posted once.
+func TestSessionReportIsIdempotent(t *testing.T) {
+	repo := newFakeRepo()
+	answeredAt := time.Now().Add(-time.Minute)
+	for i := 1; i <= 3; i++ {
+		repo.answers = append(repo.answers, internalEntity.UserAnswer{
+			SessionID: "s-1", QuestionID: fmt.Sprintf("q-%d", i), IsCorrect: true, PartialCredit: 1, AttemptNumber: 1, AnsweredAt: answeredAt,
+		})
+	}
+	repo.chats = []internalEntity.ChatMessage{{SessionID: "s-1", Role: "user", Kind: internalEntity.ChatMessageKindChat, Message: "halo"}}
+	u := newTestUsecase(t, repo)
+	u.cfg.DB, _ = txTestDB(t)
+	var fake *fakeLLM
+	u.cfg.Gemini, fake = newFakeLLM(t, replyWith(analysisReply))
+
+	feedbackCount := func() int {
+		n := 0
+		for _, m := range repo.chats {
+			if m.Kind == internalEntity.ChatMessageKindFeedback {
+				n++
+			}
+		}
+		return n
+	}
+
+	for i := 0; i < 2; i++ {
+		report, err := u.GenerateSessionReport(context.Background(), "s-1")
+		if err != nil {
+			t.Fatalf("report %d: %v", i+1, err)
+		}
+		if report.AIAnalysys != "Analisis dari model" || report.TotalQuestions != 3 {
+			t.Errorf("report %d = %+v, want the cached analysis of 3 answers", i+1, report)
+		}
+	}
+	if got := fake.calls.Load(); got != 1 {
+		t.Errorf("LLM calls = %d, want 1", got)
+	}
+	if got := feedbackCount(); got != 1 {
+		t.Errorf("feedback messages = %d, want 1", got)
+	}
+
+	repo.answers = append(repo.answers, internalEntity.UserAnswer{
+		SessionID: "s-1", QuestionID: "q-4", IsCorrect: false, PartialCredit: 0, AttemptNumber: 1, AnsweredAt: time.Now().Add(time.Second),
+	})
+	report, err := u.GenerateSessionReport(context.Background(), "s-1")
+	if err != nil {
+		t.Fatalf("report after a new answer: %v", err)
+	}
+	if report.TotalQuestions != 4 || fake.calls.Load() != 2 {
+		t.Errorf("report total=%d after %d LLM calls, want a fresh analysis of 4 answers", report.TotalQuestions, fake.calls.Load())
+	}
+	if got := feedbackCount(); got != 1 {
+		t.Errorf("feedback messages = %d after a re-analysis, want 1", got)
+	}
+}
+
+// report.write_chat_feedback=false keeps the analysis out of the chat history; the chatbot still answers
+// from an empty history
+func TestReportChatFeedbackToggle(t *testing.T) {
+	tests := []struct {
+		name         string
+		set          bool
+		value        bool
+		wantFeedback bool
+	}{
+		{name: "default", wantFeedback: true},
+		{name: "enabled", set: true, value: true, wantFeedback: true},
+		{name: "disabled", set: true, value: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			for i := 1; i <= 3; i++ {
+				repo.answers = append(repo.answers, internalEntity.UserAnswer{
+					SessionID: "s-1", QuestionID: fmt.Sprintf("q-%d", i), IsCorrect: i != 2, PartialCredit: 1, AttemptNumber: 1,
+				})
+			}
+			u := newTestUsecase(t, repo)
+			u.cfg.DB, _ = txTestDB(t)
+			if tt.set {
+				u.cfg.Config.Set("report.write_chat_feedback", tt.value)
+			}
+			u.cfg.Gemini, _ = newFakeLLM(t, func(prompt string) (string, int) {
+				if prompt == "Bagaimana hasilku?" {
+					return "Kamu hebat!", http.StatusOK
+				}
+				return analysisReply, http.StatusOK
+			})
+
+			if _, err := u.GenerateSessionReport(context.Background(), "s-1"); err != nil {
+				t.Fatalf("GenerateSessionReport: %v", err)
+			}
+			if got, _ := repo.HasFeedbackMessage(nil, "s-1"); got != tt.wantFeedback {
+				t.Fatalf("feedback message posted = %v, want %v", got, tt.wantFeedback)
+			}
+
+			resp, err := u.ChatWithBot(context.Background(), "s-1", "Bagaimana hasilku?", "")
+			if err != nil {
+				t.Fatalf("ChatWithBot: %v", err)
+			}
+			if resp.Response != "Kamu hebat!" {
+				t.Errorf("chat response = %q, want the model reply", resp.Response)
+			}
+		})
+	}
+}
+
+// Error patterns come worst first: by error rate, then error count, then letter pair, on every run
+func TestSessionReportErrorPatternOrder(t *testing.T) {
+	repo := newFakeRepo()
+	perPair := []struct {
+		pair          string
+		errors, total int
+	}{
+		{pair: "m-w", errors: 0, total: 1},
+		{pair: "n-u", errors: 1, total: 2},
+		{pair: "b-d", errors: 1, total: 2},
+		{pair: "m-n", errors: 2, total: 4},
+		{pair: "p-q", errors: 2, total: 3},
+	}
+	for _, p := range perPair {
+		for i := 0; i < p.total; i++ {
+			id := fmt.Sprintf("q-%s-%d", p.pair, i)
+			repo.questions[id] = &internalEntity.GeneratedQuestion{QuestionID: id, TargetLetterPair: p.pair}
+			repo.answers = append(repo.answers, internalEntity.UserAnswer{
+				SessionID: "s-1", QuestionID: id, IsCorrect: i >= p.errors, PartialCredit: 1, AttemptNumber: 1,
+			})
+		}
+	}
+	want := []entity.ErrorPattern{
+		{LetterPair: "p-q", ErrorCount: 2, TotalCount: 3, ErrorRate: "66.7%"},
+		{LetterPair: "m-n", ErrorCount: 2, TotalCount: 4, ErrorRate: "50.0%"},
+		{LetterPair: "b-d", ErrorCount: 1, TotalCount: 2, ErrorRate: "50.0%"},
+		{LetterPair: "n-u", ErrorCount: 1, TotalCount: 2, ErrorRate: "50.0%"},
+		{LetterPair: "m-w", ErrorCount: 0, TotalCount: 1, ErrorRate: "0.0%"},
+	}
+
+	for run := 0; run < 5; run++ {
+		delete(repo.caches, "s-1")
+		u := newTestUsecase(t, repo)
+		u.cfg.DB, _ = txTestDB(t)
+		u.cfg.Gemini, _ = newFakeLLM(t, replyWith(analysisReply))
+
+		report, err := u.GenerateSessionReport(context.Background(), "s-1")
+		if err != nil {
+			t.Fatalf("GenerateSessionReport: %v", err)
+		}
+		if !reflect.DeepEqual(report.ErrorPatterns, want) {
+			t.Fatalf("run %d: error patterns = %+v, want %+v", run, report.ErrorPatterns, want)
+		}
+	}
+}
+
+// Rates are compared exactly, so two pairs whose rounded ErrorRate strings tie are still ordered
+func TestSortErrorPatterns(t *testing.T) {
+	patterns := []entity.ErrorPattern{
+		{LetterPair: "b-d", ErrorCount: 333, TotalCount: 1000},
+		{LetterPair: "p-q", ErrorCount: 1, TotalCount: 3},
+		{LetterPair: "a-e", ErrorCount: 333, TotalCount: 1000},
+	}
+	sortErrorPatterns(patterns)
+	var got []string
+	for _, p := range patterns {
+		got = append(got, p.LetterPair)
+	}
+	if want := []string{"p-q", "a-e", "b-d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %q, want %q", got, want)
+	}
+}
+
+// The analysis cache and the chat feedback are written in one transaction on the request's context
+func TestSessionReportPersistRollsBack(t *testing.T) {
+	tests := []struct {
+		name          string
+		chatErr       error
+		wantCommits   int
+		wantRollbacks int
+	}{
+		{name: "both writes succeed", wantCommits: 1},
+		{name: "feedback write fails", chatErr: errors.New("chat insert failed"), wantRollbacks: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.answers = []internalEntity.UserAnswer{
+				{SessionID: "s-1", QuestionID: "q-1", IsCorrect: true, PartialCredit: 1, AttemptNumber: 1},
+				{SessionID: "s-1", QuestionID: "q-2", AttemptNumber: 1},
+				{SessionID: "s-1", QuestionID: "q-3", IsCorrect: true, PartialCredit: 1, AttemptNumber: 1},
+			}
+			repo.chatErr = tt.chatErr
+			u := newTestUsecase(t, repo)
+			var counter *txCounter
+			u.cfg.DB, counter = txTestDB(t)
+
+			report, err := u.GenerateSessionReport(context.Background(), "s-1")
+			if err != nil {
+				t.Fatalf("GenerateSessionReport: %v", err)
+			}
+			if report.TotalQuestions != 3 {
+				t.Errorf("TotalQuestions = %d, want 3", report.TotalQuestions)
+			}
+			if commits, rollbacks := counter.counts(); commits != tt.wantCommits || rollbacks != tt.wantRollbacks {
+				t.Errorf("commits/rollbacks = %d/%d, want %d/%d", commits, rollbacks, tt.wantCommits, tt.wantRollbacks)
+			}
+		})
+	}
+}
+
+// A cancelled request must not open the persist transaction on a detached connection
+func TestSessionReportPersistUsesRequestContext(t *testing.T) {
+	repo := newFakeRepo()
+	repo.answers = []internalEntity.UserAnswer{
+		{SessionID: "s-1", QuestionID: "q-1", IsCorrect: true, PartialCredit: 1, AttemptNumber: 1},
+		{SessionID: "s-1", QuestionID: "q-2", AttemptNumber: 1},
+		{SessionID: "s-1", QuestionID: "q-3", AttemptNumber: 1},
+	}
+	u := newTestUsecase(t, repo)
+	var counter *txCounter
+	u.cfg.DB, counter = txTestDB(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := u.GenerateSessionReport(ctx, "s-1"); err != nil {
+		t.Fatalf("GenerateSessionReport: %v", err)
+	}
+	if commits, _ := counter.counts(); commits != 0 {
+		t.Errorf("transaction committed on a cancelled context")
+	}
+	if _, cached := repo.caches["s-1"]; cached {
+		t.Errorf("cache written although the request was cancelled")
+	}
+}
+
+// A session id with neither a start record nor answers is unknown; a started session without answers is not
+func TestSessionLookupErrors(t *testing.T) {
+	repo := newFakeRepo()
+	repo.sessions["s-started"] = &internalEntity.Session{SessionID: "s-started"}
+	repo.answers = []internalEntity.UserAnswer{{SessionID: "s-answered", QuestionID: "q-1", IsCorrect: true, PartialCredit: 1, AttemptNumber: 1}}
+	u := newTestUsecase(t, repo)
+	u.cfg.DB, _ = txTestDB(t)
+	u.cfg.Gemini, _ = newFakeLLM(t, replyWith(analysisReply))
+
+	if _, _, err := u.GetSessionAnswers(context.Background(), "s-unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("answers of an unknown session: err = %v, want ErrNotFound", err)
+	}
+	if answers, _, err := u.GetSessionAnswers(context.Background(), "s-started"); err != nil || len(answers) != 0 {
+		t.Errorf("answers of a started session = %v, %v; want an empty list", answers, err)
+	}
+	if answers, _, err := u.GetSessionAnswers(context.Background(), "s-answered"); err != nil || len(answers) != 1 {
+		t.Errorf("answers of an answered session = %v, %v; want one answer", answers, err)
+	}
+
+	if _, err := u.GenerateSessionReport(context.Background(), "s-unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("report of an unknown session: err = %v, want ErrNotFound", err)
+	}
+	if _, err := u.GenerateSessionReport(context.Background(), "s-started"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("report of a session without answers: err = %v, want ErrInvalidInput", err)
+	}
+	if _, err := u.ChatWithBot(context.Background(), "s-unknown", "halo", ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("chat about an unknown session: err = %v, want ErrNotFound", err)
+	}
+}
+
+// LLM failures in the chatbot are upstream errors, a model outside llm.allowed_models is invalid input
+// With more than ten earlier messages, the model sees the latest ten in chronological order
+func TestChatWithBotUsesLatestHistory(t *testing.T) {
+	repo := newFakeRepo()
+	repo.answers = []internalEntity.UserAnswer{{SessionID: "s-1", QuestionID: "q-1", IsCorrect: true, PartialCredit: 1, AttemptNumber: 1}}
+	repo.caches["s-1"] = &internalEntity.SessionAnalysisCache{SessionID: "s-1", TotalQuestions: 1, CorrectAnswers: 1, AIAnalysis: "Analisis", Recommendations: "Latihan", OverallValue: "baik", ErrorPatterns: `[]`}
+	for i := 1; i <= 12; i++ {
+		repo.chats = append(repo.chats, internalEntity.ChatMessage{SessionID: "s-1", Role: "user", Kind: "chat", Message: fmt.Sprintf("pesan %d", i)})
+	}
+	u := newTestUsecase(t, repo)
+	var fake *fakeLLM
+	u.cfg.Gemini, fake = newFakeLLM(t, replyWith("Balasan"))
+
+	if _, err := u.ChatWithBot(context.Background(), "s-1", "halo", ""); err != nil {
+		t.Fatalf("ChatWithBot: %v", err)
+	}
+	messages, _ := fake.messages.Load().([]string)
+	if len(messages) < 2 {
+		t.Fatalf("request messages = %q", messages)
+	}
+	var history []string
+	for _, m := range messages[1:] { // after the system context
+		if strings.HasPrefix(m, "pesan ") {
+			history = append(history, m)
+		}
+	}
+	var want []string
+	for i := 3; i <= 12; i++ {
+		want = append(want, fmt.Sprintf("pesan %d", i))
+	}
+	if !slices.Equal(history, want) {
+		t.Errorf("history sent to the model = %q, want %q", history, want)
+	}
+}
+
+func TestChatWithBotErrors(t *testing.T) {
+	repo := newFakeRepo()
+	repo.answers = []internalEntity.UserAnswer{{SessionID: "s-1", QuestionID: "q-1", IsCorrect: true, PartialCredit: 1, AttemptNumber: 1}}
+	repo.caches["s-1"] = &internalEntity.SessionAnalysisCache{SessionID: "s-1", TotalQuestions: 1, CorrectAnswers: 1, AIAnalysis: "Analisis", Recommendations: "Latihan", OverallValue: "baik", ErrorPatterns: `[]`}
+	u := newTestUsecase(t, repo)
+	u.cfg.Gemini, _ = newFakeLLM(t, func(string) (string, int) { return "", http.StatusBadRequest })
+
+	if _, err := u.ChatWithBot(context.Background(), "s-1", "halo", ""); !errors.Is(err, ErrUpstream) {
+		t.Errorf("failed LLM reply: err = %v, want ErrUpstream", err)
+	}
+	if _, err := u.ChatWithBot(context.Background(), "s-1", "halo", "gpt-4o"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("disallowed model: err = %v, want ErrInvalidInput", err)
+	}
+}
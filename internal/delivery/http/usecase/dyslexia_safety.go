@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const defaultSafetyReplacement = "Maaf, aku belum bisa menjawab itu. Yuk, kita lanjut belajar membaca bersama! 😊"
+
+// screenChatResponse applies the optional chat.safety filter to a bot response.
+// Flagged responses are regenerated up to chat.safety.max_regenerations times, then replaced.
+func (u *dyslexiaQuestionUsecase) screenChatResponse(ctx context.Context, messages []openai.ChatCompletionMessage, botResponse string) string {
+	if !u.cfg.Config.GetBool("chat.safety.enabled") {
+		return botResponse
+	}
+
+	maxRegenerations := u.cfg.Config.GetInt("chat.safety.max_regenerations")
+	for attempt := 0; ; attempt++ {
+		reason := u.unsafeReason(ctx, botResponse)
+		if reason == "" {
+			return botResponse
+		}
+		u.logger().Warnf("[SAFETY] Chat response flagged (%s), attempt %d", reason, attempt+1)
+
+		if attempt >= maxRegenerations {
+			break
+		}
+
+		regenerated, err := u.cfg.Gemini.GenerateChatResponse(ctx, messages)
+		if err != nil {
+			u.logger().Warnf("[SAFETY] Regeneration failed: %v", err)
+			break
+		}
+		botResponse = regenerated
+	}
+
+	if replacement := u.cfg.Config.GetString("chat.safety.replacement"); replacement != "" {
+		return replacement
+	}
+	return defaultSafetyReplacement
+}
+
+// unsafeReason returns why text is unsafe, or "" when it passes the blocklist and moderation checks
+func (u *dyslexiaQuestionUsecase) unsafeReason(ctx context.Context, text string) string {
+	lower := strings.ToLower(text)
+	for _, blocked := range u.cfg.Config.GetStringSlice("chat.safety.blocklist") {
+		blocked = strings.ToLower(strings.TrimSpace(blocked))
+		if blocked != "" && strings.Contains(lower, blocked) {
+			return "blocklist: " + blocked
+		}
+	}
+
+	if u.cfg.Config.GetBool("chat.safety.use_moderation_api") && u.cfg.Gemini != nil {
+		flagged, err := u.cfg.Gemini.Moderate(ctx, text)
+		if err != nil {
+			// Moderation outage must not block the chat; the blocklist still applies
+			u.logger().Warnf("[SAFETY] Moderation check failed: %v", err)
+			return ""
+		}
+		if flagged {
+			return "moderation"
+		}
+	}
+
+	return ""
+}
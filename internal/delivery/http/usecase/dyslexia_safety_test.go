@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+// Flagged bot responses are regenerated, then replaced, before they are saved and returned
+func TestChatSafetyFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		replies []string
+		want    string
+	}{
+		{name: "disabled passes through", replies: []string{"Dasar bodoh!"}, want: "Dasar bodoh!"},
+		{name: "clean response kept", enabled: true, replies: []string{"Semangat belajar!"}, want: "Semangat belajar!"},
+		{name: "regenerated when flagged", enabled: true, replies: []string{"Dasar BODOH!", "Ayo coba lagi!"}, want: "Ayo coba lagi!"},
+		{name: "replaced when still flagged", enabled: true, replies: []string{"Dasar bodoh!", "Kamu bodoh sekali"}, want: "Maaf, coba tanya yang lain ya."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.caches["sess-1"] = &internalEntity.SessionAnalysisCache{SessionID: "sess-1", TotalQuestions: 2}
+			u := newTestUsecase(t, repo)
+			u.cfg.Config.Set("chat.safety.enabled", tt.enabled)
+			u.cfg.Config.Set("chat.safety.blocklist", []string{"bodoh"})
+			u.cfg.Config.Set("chat.safety.max_regenerations", 1)
+			u.cfg.Config.Set("chat.safety.replacement", "Maaf, coba tanya yang lain ya.")
+
+			var call atomic.Int64
+			u.cfg.Gemini, _ = newFakeLLM(t, func(string) (string, int) {
+				i := min(int(call.Add(1))-1, len(tt.replies)-1)
+				return tt.replies[i], http.StatusOK
+			})
+
+			resp, err := u.ChatWithBot(context.Background(), "sess-1", "halo", "")
+			if err != nil {
+				t.Fatalf("ChatWithBot: %v", err)
+			}
+			if resp.Response != tt.want {
+				t.Errorf("response = %q, want %q", resp.Response, tt.want)
+			}
+			last := repo.chats[len(repo.chats)-1]
+			if last.Role != "assistant" || last.Message != tt.want {
+				t.Errorf("saved bot message = %+v, want %q", last, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"math"
+	"strings"
+
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+// partialCredit scores an answer 0-1 by edit distance to the correct word (1 = exact match).
+// Without scoring.partial_credit it is binary: 1 when correct, 0 otherwise.
+func (u *dyslexiaQuestionUsecase) partialCredit(userAnswer string, correctAnswer string, isCorrect bool) float64 {
+	if isCorrect {
+		return 1
+	}
+	if !u.cfg.Config.GetBool("scoring.partial_credit") {
+		return 0
+	}
+	return editDistanceCredit(userAnswer, correctAnswer)
+}
+
+// scoreAnswers is the session score of the reported attempts: 1 per correct answer plus the partial
+// credit of wrong ones; skips score nothing
+func scoreAnswers(answers []internalEntity.UserAnswer) float64 {
+	score := 0.0
+	for _, answer := range answers {
+		switch {
+		case answer.IsCorrect:
+			score++
+		case !answer.Skipped:
+			score += answer.PartialCredit
+		}
+	}
+	return score
+}
+
+// storedAnswerCase applies answers.store_case to user/correct answers before they are persisted:
+// "upper" stores them trimmed and uppercased like the bank words, "original" (default) keeps them as given.
+func (u *dyslexiaQuestionUsecase) storedAnswerCase(answer string) string {
+	if strings.EqualFold(u.cfg.Config.GetString("answers.store_case"), "upper") {
+		return strings.ToUpper(strings.TrimSpace(answer))
+	}
+	return answer
+}
+
+func editDistanceCredit(a string, b string) float64 {
+	ra := []rune(strings.ToUpper(strings.TrimSpace(a)))
+	rb := []rune(strings.ToUpper(strings.TrimSpace(b)))
+
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+
+	credit := 1 - float64(levenshtein(ra, rb))/float64(longest)
+	return math.Round(credit*100) / 100
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a []rune, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// selectReportedAttempts keeps one answer per question: the latest attempt, or the
+// best one (correct first, then highest partial credit) when answers.report_attempt is "best"
+func (u *dyslexiaQuestionUsecase) selectReportedAttempts(answers []internalEntity.UserAnswer) []internalEntity.UserAnswer {
+	useBest := strings.EqualFold(u.cfg.Config.GetString("answers.report_attempt"), "best")
+
+	selected := make(map[string]int) // question_id -> index in result
+	result := make([]internalEntity.UserAnswer, 0, len(answers))
+	for _, answer := range answers {
+		idx, ok := selected[answer.QuestionID]
+		if !ok {
+			selected[answer.QuestionID] = len(result)
+			result = append(result, answer)
+			continue
+		}
+
+		current := result[idx]
+		replace := answer.AttemptNumber > current.AttemptNumber
+		if useBest {
+			replace = betterAttempt(answer, current)
+		}
+		if replace {
+			result[idx] = answer
+		}
+	}
+
+	return result
+}
+
+func betterAttempt(a internalEntity.UserAnswer, b internalEntity.UserAnswer) bool {
+	if a.IsCorrect != b.IsCorrect {
+		return a.IsCorrect
+	}
+	if a.PartialCredit != b.PartialCredit {
+		return a.PartialCredit > b.PartialCredit
+	}
+	return a.AttemptNumber < b.AttemptNumber
+}
@@ -0,0 +1,315 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "", b: "bola", want: 4},
+		{a: "bola", b: "", want: 4},
+		{a: "bola", b: "bola", want: 0},
+		{a: "bola", b: "dola", want: 1},
+		{a: "bola", b: "bol", want: 1},
+		{a: "bola", b: "obla", want: 2},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "ñata", b: "nata", want: 1}, // runes, not bytes
+	}
+	for _, tt := range tests {
+		if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := levenshtein([]rune(tt.b), []rune(tt.a)); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d (symmetric)", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+// Credit is 1 - distance/longest, case- and space-insensitive, rounded to two decimals
+func TestEditDistanceCredit(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{a: "bola", b: "BOLA", want: 1},
+		{a: " bola ", b: "bola", want: 1},
+		{a: "dola", b: "bola", want: 0.75},
+		{a: "bol", b: "bola", want: 0.75},
+		{a: "kaca", b: "bola", want: 0.25},
+		{a: "xyz", b: "bola", want: 0},
+		{a: "buku", b: "bukuku", want: 0.67},
+		{a: "", b: "", want: 0},
+	}
+	for _, tt := range tests {
+		if got := editDistanceCredit(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistanceCredit(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPartialCredit(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		answer    string
+		isCorrect bool
+		want      float64
+	}{
+		{name: "correct is full credit", answer: "bola", isCorrect: true, want: 1},
+		{name: "binary without partial credit", answer: "dola", want: 0},
+		{name: "edit distance with partial credit", enabled: true, answer: "dola", want: 0.75},
+		{name: "correct ignores partial credit", enabled: true, answer: "BOLA", isCorrect: true, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newTestUsecase(t, newFakeRepo())
+			u.cfg.Config.Set("scoring.partial_credit", tt.enabled)
+			if got := u.partialCredit(tt.answer, "bola", tt.isCorrect); got != tt.want {
+				t.Errorf("partialCredit = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// With allow_reattempt every attempt is a new numbered row; by default the first answer wins
+func TestSubmitAnswerAttempts(t *testing.T) {
+	tests := []struct {
+		name         string
+		reattempt    bool
+		wantAttempts []int
+		wantRows     int
+	}{
+		{name: "first answer wins", wantAttempts: []int{1, 1, 1}, wantRows: 1},
+		{name: "re-attempts recorded", reattempt: true, wantAttempts: []int{1, 2, 3}, wantRows: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.questions["q-1"] = &internalEntity.GeneratedQuestion{QuestionID: "q-1", CorrectAnswer: "bola"}
+			u := newTestUsecase(t, repo)
+			u.cfg.Config.Set("answers.allow_reattempt", tt.reattempt)
+
+			for i, answer := range []string{"dola", "bola", "dola"} {
+				resp, err := u.SubmitAnswer(context.Background(), entity.SubmitAnswerRequest{UserID: "user-1", SessionID: "sess-1", QuestionID: "q-1", Answer: answer})
+				if err != nil {
+					t.Fatalf("SubmitAnswer %d: %v", i+1, err)
+				}
+				if resp.AttemptNumber != tt.wantAttempts[i] {
+					t.Errorf("submit %d: attempt = %d, want %d", i+1, resp.AttemptNumber, tt.wantAttempts[i])
+				}
+				if !tt.reattempt && resp.UserAnswer != "dola" {
+					t.Errorf("submit %d: answer = %q, want the first answer", i+1, resp.UserAnswer)
+				}
+			}
+			if len(repo.answers) != tt.wantRows {
+				t.Errorf("rows = %d, want %d", len(repo.answers), tt.wantRows)
+			}
+		})
+	}
+}
+
+// Submit echoes the question context; only wrong answers get a hint, and a repeated submit keeps it
+func TestSubmitAnswerQuestionContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		answer   string
+		wantHint string
+	}{
+		{name: "correct", answer: "bola"},
+		{name: "incorrect", answer: "dola", wantHint: "Perhatikan lagi perbedaan huruf b dan d."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.questions["q-1"] = &internalEntity.GeneratedQuestion{
+				QuestionID: "q-1", QuestionText: "Pilih kata yang benar", TargetLetterPair: "b-d", CorrectAnswer: "bola",
+				Options: `["dola","bola","pola","boda"]`,
+			}
+			u := newTestUsecase(t, repo)
+
+			for attempt := 1; attempt <= 2; attempt++ {
+				resp, err := u.SubmitAnswer(context.Background(), entity.SubmitAnswerRequest{UserID: "user-1", SessionID: "sess-1", QuestionID: "q-1", Answer: tt.answer})
+				if err != nil {
+					t.Fatalf("SubmitAnswer %d: %v", attempt, err)
+				}
+				if resp.QuestionText != "Pilih kata yang benar" || resp.TargetLetterPair != "b-d" ||
+					!slices.Equal(resp.Options, []string{"dola", "bola", "pola", "boda"}) {
+					t.Errorf("submit %d: question context = %q/%q/%q", attempt, resp.QuestionText, resp.TargetLetterPair, resp.Options)
+				}
+				if resp.Hint != tt.wantHint {
+					t.Errorf("submit %d: hint = %q, want %q", attempt, resp.Hint, tt.wantHint)
+				}
+			}
+		})
+	}
+}
+
+func TestAnswerHint(t *testing.T) {
+	if got := answerHint("m-n"); got != "Perhatikan lagi perbedaan huruf m dan n." {
+		t.Errorf("answerHint(m-n) = %q", got)
+	}
+	if got := answerHint(""); got == "" {
+		t.Error("answerHint without a letter pair should still give a generic hint")
+	}
+}
+
+// The report counts one attempt per question: the last by default, the best when configured
+func TestSelectReportedAttempts(t *testing.T) {
+	answers := []internalEntity.UserAnswer{
+		{QuestionID: "q-1", AttemptNumber: 1, PartialCredit: 0.5},
+		{QuestionID: "q-1", AttemptNumber: 2, IsCorrect: true, PartialCredit: 1},
+		{QuestionID: "q-1", AttemptNumber: 3, PartialCredit: 0.25},
+		{QuestionID: "q-2", AttemptNumber: 1, PartialCredit: 0.75},
+		{QuestionID: "q-2", AttemptNumber: 2, PartialCredit: 0.5},
+		{QuestionID: "q-3", AttemptNumber: 1, PartialCredit: 0.5},
+		{QuestionID: "q-3", AttemptNumber: 2, PartialCredit: 0.5},
+	}
+	tests := []struct {
+		mode string
+		want map[string]int // question -> counted attempt
+	}{
+		{mode: "", want: map[string]int{"q-1": 3, "q-2": 2, "q-3": 2}},
+		{mode: "last", want: map[string]int{"q-1": 3, "q-2": 2, "q-3": 2}},
+		{mode: "Best", want: map[string]int{"q-1": 2, "q-2": 1, "q-3": 1}},
+	}
+	for _, tt := range tests {
+		u := newTestUsecase(t, newFakeRepo())
+		u.cfg.Config.Set("answers.report_attempt", tt.mode)
+		got := u.selectReportedAttempts(answers)
+		if len(got) != len(tt.want) {
+			t.Fatalf("%q: %d answers counted, want %d", tt.mode, len(got), len(tt.want))
+		}
+		for _, a := range got {
+			if a.AttemptNumber != tt.want[a.QuestionID] {
+				t.Errorf("%q: %s counts attempt %d, want %d", tt.mode, a.QuestionID, a.AttemptNumber, tt.want[a.QuestionID])
+			}
+		}
+	}
+}
+
+// Stored user/correct answers follow answers.store_case on both the submit and the offline import path
+func TestStoredAnswerCase(t *testing.T) {
+	tests := []struct {
+		name        string
+		storeCase   string
+		wantUser    string
+		wantCorrect string
+	}{
+		{name: "default keeps the original", wantUser: " Bola", wantCorrect: "bola"},
+		{name: "original", storeCase: "original", wantUser: " Bola", wantCorrect: "bola"},
+		{name: "upper", storeCase: "UPPER", wantUser: "BOLA", wantCorrect: "BOLA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.questions["q-1"] = &internalEntity.GeneratedQuestion{QuestionID: "q-1", CorrectAnswer: "bola"}
+			u := newTestUsecase(t, repo)
+			u.cfg.DB, _ = txTestDB(t)
+			if tt.storeCase != "" {
+				u.cfg.Config.Set("answers.store_case", tt.storeCase)
+			}
+
+			resp, err := u.SubmitAnswer(context.Background(), entity.SubmitAnswerRequest{UserID: "user-1", SessionID: "s-1", QuestionID: "q-1", Answer: " Bola"})
+			if err != nil {
+				t.Fatalf("SubmitAnswer: %v", err)
+			}
+			if !resp.IsCorrect || resp.UserAnswer != tt.wantUser || resp.CorrectAnswer != tt.wantCorrect {
+				t.Errorf("response = %+v, want correct with %q/%q", resp, tt.wantUser, tt.wantCorrect)
+			}
+			if _, err := u.ImportAnswers(context.Background(), entity.ImportAnswersRequest{
+				SessionID: "s-2", UserID: "user-1",
+				Answers: []entity.ImportedAnswer{{QuestionID: "q-1", UserAnswer: " Bola", IsCorrect: true, AnsweredAt: time.Now()}},
+			}); err != nil {
+				t.Fatalf("ImportAnswers: %v", err)
+			}
+
+			for _, a := range repo.answers {
+				if a.UserAnswer != tt.wantUser || a.CorrectAnswer != tt.wantCorrect {
+					t.Errorf("stored %s answer %q/%q, want %q/%q", a.SessionID, a.UserAnswer, a.CorrectAnswer, tt.wantUser, tt.wantCorrect)
+				}
+			}
+		})
+	}
+}
+
+// With answers.allow_skip a "don't know" is stored as its own outcome: not correct, no partial credit
+func TestSubmitSkippedAnswer(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowSkip bool
+		answer    string
+		wantErr   bool
+	}{
+		{name: "skip", allowSkip: true},
+		{name: "skip disabled", wantErr: true},
+		{name: "skip with an answer", allowSkip: true, answer: "bola", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.questions["q-1"] = &internalEntity.GeneratedQuestion{QuestionID: "q-1", TargetLetterPair: "b-d", CorrectAnswer: "bola"}
+			u := newTestUsecase(t, repo)
+			u.cfg.Config.Set("answers.allow_skip", tt.allowSkip)
+			u.cfg.Config.Set("scoring.partial_credit", true)
+
+			resp, err := u.SubmitAnswer(context.Background(), entity.SubmitAnswerRequest{UserID: "user-1", SessionID: "sess-1", QuestionID: "q-1", Answer: tt.answer, Skipped: true})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("SubmitAnswer = %+v, want an error", resp)
+				}
+				if len(repo.answers) != 0 {
+					t.Errorf("stored %d answers for a rejected skip", len(repo.answers))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SubmitAnswer: %v", err)
+			}
+			if !resp.Skipped || resp.IsCorrect || resp.PartialCredit != 0 {
+				t.Errorf("response = %+v, want a skip without credit", resp)
+			}
+			if len(repo.answers) != 1 || !repo.answers[0].Skipped || repo.answers[0].IsCorrect || repo.answers[0].PartialCredit != 0 {
+				t.Errorf("stored answers = %+v, want one skip without credit", repo.answers)
+			}
+		})
+	}
+}
+
+// Skips are counted apart from wrong answers and leave the letter-pair error rates alone
+func TestSessionReportExcludesSkips(t *testing.T) {
+	repo := newFakeRepo()
+	for id, pair := range map[string]string{"q-1": "b-d", "q-2": "b-d", "q-3": "b-d", "q-4": "m-n"} {
+		repo.questions[id] = &internalEntity.GeneratedQuestion{QuestionID: id, TargetLetterPair: pair}
+	}
+	repo.answers = []internalEntity.UserAnswer{
+		{SessionID: "s-1", QuestionID: "q-1", IsCorrect: true, PartialCredit: 1, AttemptNumber: 1},
+		{SessionID: "s-1", QuestionID: "q-2", AttemptNumber: 1},
+		{SessionID: "s-1", QuestionID: "q-3", Skipped: true, AttemptNumber: 1},
+		{SessionID: "s-1", QuestionID: "q-4", Skipped: true, AttemptNumber: 1},
+	}
+	u := newTestUsecase(t, repo)
+	u.cfg.Config.Set("report.min_answers_for_analysis", 10)
+
+	report, err := u.GenerateSessionReport(context.Background(), "s-1")
+	if err != nil {
+		t.Fatalf("GenerateSessionReport: %v", err)
+	}
+	if report.TotalQuestions != 4 || report.CorrectAnswers != 1 || report.WrongAnswers != 1 || report.SkippedAnswers != 2 || report.SessionScore != 1 {
+		t.Errorf("report = %d total, %d correct, %d wrong, %d skipped, score %v; want 4, 1, 1, 2, 1",
+			report.TotalQuestions, report.CorrectAnswers, report.WrongAnswers, report.SkippedAnswers, report.SessionScore)
+	}
+	want := []entity.ErrorPattern{{LetterPair: "b-d", ErrorCount: 1, TotalCount: 2, ErrorRate: "50.0%"}}
+	if !slices.Equal(report.ErrorPatterns, want) {
+		t.Errorf("error patterns = %+v, want %+v", report.ErrorPatterns, want)
+	}
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+// logServedQuestions records which questions were served to a session, with their options in the served
+// order. For a session the log is written before returning, because the next Generate call excludes served
+// questions and SubmitAnswer resolves answer_index against that order; otherwise it is written async.
+func (u *dyslexiaQuestionUsecase) logServedQuestions(sessionID string, questions []entity.GeneratedQuestion) {
+	if len(questions) == 0 {
+		return
+	}
+
+	servedAt := time.Now()
+	write := func() {
+		userID := u.resolveUserID(sessionID)
+		logs := make([]internalEntity.QuestionServeLog, 0, len(questions))
+		for _, q := range questions {
+			options, _ := json.Marshal(q.Options)
+			logs = append(logs, internalEntity.QuestionServeLog{
+				QuestionID: q.ID,
+				UserID:     userID,
+				SessionID:  sessionID,
+				ServedAt:   servedAt,
+				Options:    string(options),
+			})
+		}
+		if err := u.cfg.Repository.CreateServeLogs(u.cfg.DB, logs); err != nil {
+			u.logger().Warnf("Failed to write serve log for session %s: %v", sessionID, err)
+		}
+	}
+	if sessionID != "" {
+		write()
+		return
+	}
+	u.goBackground(write)
+}
+
+// servedOptions returns the options of q in the order they were last served to sessionID, which is the
+// order correct_index was computed on. Questions never served to the session (served without a session,
+// ad hoc, or logged before the order was recorded) fall back to the stored order.
+func (u *dyslexiaQuestionUsecase) servedOptions(ctx context.Context, sessionID string, q *internalEntity.GeneratedQuestion) ([]string, error) {
+	var options []string
+	if sessionID != "" {
+		if log, err := u.cfg.Repository.FindLatestServeLog(u.dbWithContext(ctx), sessionID, q.QuestionID); err == nil && log.Options != "" {
+			if err := json.Unmarshal([]byte(log.Options), &options); err == nil {
+				return options, nil
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(q.Options), &options); err != nil {
+		return nil, fmt.Errorf("failed to parse options of question %s: %w", q.QuestionID, err)
+	}
+	for i, opt := range options {
+		options[i] = applyOptionCase(u.optionCase, opt)
+	}
+	return options, nil
+}
+
+// sessionUsedQuestionIDs returns the distinct ids answered in or served to sessionID
+func (u *dyslexiaQuestionUsecase) sessionUsedQuestionIDs(ctx context.Context, sessionID string) []string {
+	seen := make(map[string]bool)
+	ids := []string{}
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	if answers, err := u.cfg.Repository.FindUserAnswersBySessionID(u.dbWithContext(ctx), sessionID); err == nil {
+		for _, answer := range answers {
+			add(answer.QuestionID)
+		}
+	}
+	if served, err := u.cfg.Repository.FindServeLogsBySessionID(u.dbWithContext(ctx), sessionID); err == nil {
+		for _, log := range served {
+			add(log.QuestionID)
+		}
+	} else {
+		u.logger().Warnf("Failed to read serve log for session %s: %v", sessionID, err)
+	}
+	return ids
+}
+
+// GetServeLog returns every question served to a session, oldest first
+func (u *dyslexiaQuestionUsecase) GetServeLog(ctx context.Context, sessionID string) ([]entity.ServeLogItem, error) {
+	logs, err := u.cfg.Repository.FindServeLogsBySessionID(u.dbWithContext(ctx), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get serve log: %w", err)
+	}
+
+	items := make([]entity.ServeLogItem, 0, len(logs))
+	for _, l := range logs {
+		items = append(items, entity.ServeLogItem{
+			QuestionID: l.QuestionID,
+			UserID:     l.UserID,
+			SessionID:  l.SessionID,
+			ServedAt:   l.ServedAt.Format(time.RFC3339),
+		})
+	}
+	return items, nil
+}
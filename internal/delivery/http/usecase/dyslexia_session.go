@@ -0,0 +1,246 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/repository"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+	"gorm.io/gorm"
+)
+
+// StartSession stores session metadata (target count, difficulty) so it can be resumed later.
+// The limit check and the insert run in one transaction under a per-user lock, so concurrent starts
+// cannot exceed sessions.max_active_per_user or create the same session twice.
+func (u *dyslexiaQuestionUsecase) StartSession(ctx context.Context, req entity.StartSessionRequest) (*entity.SessionInfo, error) {
+	difficulty := req.Difficulty
+	if difficulty == "" {
+		difficulty = u.defaultDifficulty
+	}
+
+	session := &internalEntity.Session{
+		SessionID:   req.SessionID,
+		UserID:      req.UserID,
+		TargetCount: req.TargetCount,
+		Difficulty:  string(difficulty),
+	}
+	err := repository.WithTransaction(u.dbWithContext(ctx), func(tx *gorm.DB) error {
+		if u.cfg.Config.GetInt("sessions.max_active_per_user") > 0 && req.UserID != "" {
+			if err := u.cfg.Repository.LockUserSessions(tx, req.UserID); err != nil {
+				return fmt.Errorf("failed to lock sessions of user %s: %w", req.UserID, err)
+			}
+			if err := u.checkActiveSessionLimit(tx, req.UserID); err != nil {
+				return err
+			}
+		}
+		created, err := u.cfg.Repository.CreateSessionIfAbsent(tx, session)
+		if err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		if !created {
+			return fmt.Errorf("session already exists")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toSessionInfo(session), nil
+}
+
+// ResumeSession returns progress of a session and fresh questions for the remaining slots
+func (u *dyslexiaQuestionUsecase) ResumeSession(ctx context.Context, sessionID string, useAI bool) (*entity.SessionResumeResponse, error) {
+	session, err := u.cfg.Repository.FindSessionBySessionID(u.dbWithContext(ctx), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	if session.ExpiredAt != nil {
+		return nil, fmt.Errorf("session %s expired at %s, start a new session", sessionID, session.ExpiredAt.Format(time.RFC3339))
+	}
+
+	answers, err := u.cfg.Repository.FindUserAnswersBySessionID(u.dbWithContext(ctx), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session answers: %w", err)
+	}
+
+	progress := sessionProgress(session, answers)
+	remaining := progress.RemainingCount
+
+	// With sessions.enforce_target_count questions served but never answered use up the target as well
+	toGenerate := remaining
+	if u.enforceTargetCount() {
+		toGenerate = min(toGenerate, u.servesLeft(ctx, session))
+	}
+
+	questions := []entity.GeneratedQuestion{}
+	if toGenerate > 0 {
+		// Generate already excludes questions answered in this session
+		questions, _, err = u.Generate(ctx, entity.GenerateOptions{
+			Difficulty: entity.NormalizeDifficulty(session.Difficulty),
+			Count:      toGenerate,
+			UseAI:      useAI,
+			SessionID:  sessionID,
+			Shuffle:    true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate remaining questions: %w", err)
+		}
+	}
+
+	return &entity.SessionResumeResponse{
+		SessionID:      sessionID,
+		AnsweredCount:  progress.AnsweredCount,
+		TargetCount:    session.TargetCount,
+		RemainingCount: remaining,
+		Questions:      questions,
+	}, nil
+}
+
+// GetSessionProgress returns how many of the session's target_count questions have been answered
+func (u *dyslexiaQuestionUsecase) GetSessionProgress(ctx context.Context, sessionID string) (*entity.SessionProgress, error) {
+	session, err := u.cfg.Repository.FindSessionBySessionID(u.dbWithContext(ctx), sessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: session %s was not started with a target_count", ErrNotFound, sessionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up session: %w", err)
+	}
+
+	answers, err := u.cfg.Repository.FindUserAnswersBySessionID(u.dbWithContext(ctx), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session answers: %w", err)
+	}
+	progress := sessionProgress(session, answers)
+	return &progress, nil
+}
+
+// progressFor is the progress of a session whose answers are already loaded; nil when the session was
+// never started with a target_count
+func (u *dyslexiaQuestionUsecase) progressFor(ctx context.Context, sessionID string, answers []internalEntity.UserAnswer) (*entity.SessionProgress, error) {
+	session, err := u.cfg.Repository.FindSessionBySessionID(u.dbWithContext(ctx), sessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up session: %w", err)
+	}
+	progress := sessionProgress(session, answers)
+	return &progress, nil
+}
+
+// sessionProgress counts answered questions (re-attempts of a question count once) against the target.
+// Remaining never goes below zero and the completion percentage is capped at 100.
+func sessionProgress(session *internalEntity.Session, answers []internalEntity.UserAnswer) entity.SessionProgress {
+	answered := make(map[string]struct{}, len(answers))
+	for _, answer := range answers {
+		answered[answer.QuestionID] = struct{}{}
+	}
+
+	progress := entity.SessionProgress{
+		SessionID:     session.SessionID,
+		AnsweredCount: len(answered),
+		TargetCount:   session.TargetCount,
+	}
+	if session.TargetCount > 0 {
+		progress.RemainingCount = max(session.TargetCount-progress.AnsweredCount, 0)
+		progress.CompletionPercent = min(roundPoints(float64(progress.AnsweredCount)*100/float64(session.TargetCount)), 100)
+	}
+	progress.Completed = session.TargetCount > 0 && progress.RemainingCount == 0
+	return progress
+}
+
+func (u *dyslexiaQuestionUsecase) enforceTargetCount() bool {
+	return u.cfg.Config.GetBool("sessions.enforce_target_count")
+}
+
+// checkSessionTarget refuses to serve more questions than a started session has left when
+// sessions.enforce_target_count is on. Every question served or answered counts, so calling Generate
+// without answering cannot get past the target. Sessions without a start record are never limited.
+func (u *dyslexiaQuestionUsecase) checkSessionTarget(ctx context.Context, sessionID string, count int) error {
+	if sessionID == "" || !u.enforceTargetCount() {
+		return nil
+	}
+	session, err := u.cfg.Repository.FindSessionBySessionID(u.dbWithContext(ctx), sessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up session: %w", err)
+	}
+	if session.TargetCount <= 0 {
+		return nil
+	}
+	if left := u.servesLeft(ctx, session); count > left {
+		return fmt.Errorf("%w: session %s was served %d of %d questions, cannot generate %d more",
+			ErrInvalidInput, sessionID, session.TargetCount-left, session.TargetCount, count)
+	}
+	return nil
+}
+
+// servesLeft is how many more distinct questions session may be served before reaching its target_count
+func (u *dyslexiaQuestionUsecase) servesLeft(ctx context.Context, session *internalEntity.Session) int {
+	used := len(u.sessionUsedQuestionIDs(ctx, session.SessionID))
+	return max(session.TargetCount-used, 0)
+}
+
+func toSessionInfo(session *internalEntity.Session) *entity.SessionInfo {
+	return &entity.SessionInfo{
+		SessionID:   session.SessionID,
+		UserID:      session.UserID,
+		TargetCount: session.TargetCount,
+		Difficulty:  session.Difficulty,
+		CreatedAt:   session.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// checkActiveSessionLimit rejects a new session when the user already has sessions.max_active_per_user
+// sessions without a report (0 = unlimited)
+func (u *dyslexiaQuestionUsecase) checkActiveSessionLimit(db *gorm.DB, userID string) error {
+	limit := u.cfg.Config.GetInt("sessions.max_active_per_user")
+	if limit <= 0 || userID == "" {
+		return nil
+	}
+
+	active, err := u.cfg.Repository.CountActiveSessionsByUser(db, userID)
+	if err != nil {
+		return fmt.Errorf("failed to count active sessions: %w", err)
+	}
+	if active >= int64(limit) {
+		return fmt.Errorf("user %s already has %d active sessions (limit %d); finish a session by requesting its report first", userID, active, limit)
+	}
+	return nil
+}
+
+// sessionStarted reports whether sessionID has a start record (POST /sessions). Sessions are optional, so
+// a session id is only unknown when it has neither a start record nor answers.
+func (u *dyslexiaQuestionUsecase) sessionStarted(ctx context.Context, sessionID string) (bool, error) {
+	_, err := u.cfg.Repository.FindSessionBySessionID(u.dbWithContext(ctx), sessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up session: %w", err)
+	}
+	return true, nil
+}
+
+// isNewSessionForUser reports whether sessionID has neither been started nor answered by userID yet
+func (u *dyslexiaQuestionUsecase) isNewSessionForUser(userID, sessionID string) bool {
+	if session, _ := u.cfg.Repository.FindSessionBySessionID(u.cfg.DB, sessionID); session != nil {
+		return false
+	}
+	answers, err := u.cfg.Repository.FindUserAnswersBySessionID(u.cfg.DB, sessionID)
+	if err != nil {
+		return false
+	}
+	for _, a := range answers {
+		if a.UserID == userID {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	SessionExpiryActionExpire = "expire" // mark the session expired, keep its answers
+	SessionExpiryActionPrune  = "prune"  // mark expired and soft delete its answers and serve log
+
+	defaultSessionExpiryInterval = 10 * time.Minute
+	sessionExpiryBatchSize       = 500
+)
+
+// sessionIdleWindow returns sessions.expiry.idle_minutes; 0 disables expiry
+func (u *dyslexiaQuestionUsecase) sessionIdleWindow() time.Duration {
+	if u.cfg.Config == nil {
+		return 0
+	}
+	return time.Duration(u.cfg.Config.GetInt("sessions.expiry.idle_minutes")) * time.Minute
+}
+
+// ExpireIdleSessions soft-completes sessions without a report and without activity for the idle window,
+// so they stop counting as active. Returns the number of sessions expired.
+func (u *dyslexiaQuestionUsecase) ExpireIdleSessions(ctx context.Context) (int, error) {
+	idle := u.sessionIdleWindow()
+	if idle <= 0 {
+		return 0, nil
+	}
+	prune := strings.EqualFold(u.cfg.Config.GetString("sessions.expiry.action"), SessionExpiryActionPrune)
+
+	now := time.Now()
+	rows, err := u.cfg.Repository.FindIdleSessions(u.dbWithContext(ctx), now.Add(-idle), sessionExpiryBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find idle sessions: %w", err)
+	}
+
+	expired := 0
+	for _, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return expired, err
+		}
+		if err := u.cfg.Repository.ExpireSession(u.dbWithContext(ctx), row.SessionID, row.UserID, now, prune); err != nil {
+			u.logger().Warnf("Failed to expire session %s: %v", row.SessionID, err)
+			continue
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// startSessionCleaner runs ExpireIdleSessions every sessions.expiry.check_interval_minutes until ctx is cancelled
+func (u *dyslexiaQuestionUsecase) startSessionCleaner(ctx context.Context) {
+	interval := defaultSessionExpiryInterval
+	if u.cfg.Config.IsSet("sessions.expiry.check_interval_minutes") {
+		interval = time.Duration(u.cfg.Config.GetFloat64("sessions.expiry.check_interval_minutes") * float64(time.Minute))
+	}
+	if interval <= 0 {
+		interval = defaultSessionExpiryInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				expired, err := u.ExpireIdleSessions(ctx)
+				if err != nil {
+					u.logger().Warnf("[SESSION EXPIRY] %v", err)
+				}
+				if expired > 0 {
+					u.logger().Infof("[SESSION EXPIRY] Expired %d idle sessions", expired)
+				}
+			}
+		}
+	}()
+}
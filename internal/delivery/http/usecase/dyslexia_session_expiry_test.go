@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+	"github.com/spf13/viper"
+)
+
+// Only sessions idle past sessions.expiry.idle_minutes expire; reported and fresh ones are left alone.
+// The prune action also soft deletes the expired session's answers and serve log.
+func TestExpireIdleSessions(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     string
+		wantPruned bool
+	}{
+		{name: "expire", action: SessionExpiryActionExpire},
+		{name: "prune", action: SessionExpiryActionPrune, wantPruned: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			repo := newFakeRepo()
+			repo.sessions["stale"] = &internalEntity.Session{SessionID: "stale", UserID: "user-1", UpdatedAt: now.Add(-3 * time.Hour)}
+			repo.sessions["fresh"] = &internalEntity.Session{SessionID: "fresh", UserID: "user-1", UpdatedAt: now.Add(-3 * time.Hour)}
+			repo.sessions["reported"] = &internalEntity.Session{SessionID: "reported", UserID: "user-1", UpdatedAt: now.Add(-3 * time.Hour)}
+			repo.caches["reported"] = &internalEntity.SessionAnalysisCache{SessionID: "reported"}
+			repo.answers = []internalEntity.UserAnswer{
+				{SessionID: "stale", UserID: "user-1", QuestionID: "q-1", AnsweredAt: now.Add(-2 * time.Hour)},
+				{SessionID: "fresh", UserID: "user-1", QuestionID: "q-1", AnsweredAt: now.Add(-10 * time.Minute)},
+				{SessionID: "answers-only", UserID: "user-2", QuestionID: "q-1", AnsweredAt: now.Add(-5 * time.Hour)},
+			}
+			repo.serveLogs = []internalEntity.QuestionServeLog{{SessionID: "stale", QuestionID: "q-1"}}
+			u := newTestUsecase(t, repo)
+			u.cfg.Config.Set("sessions.expiry.idle_minutes", 60)
+			u.cfg.Config.Set("sessions.expiry.action", tt.action)
+
+			expired, err := u.ExpireIdleSessions(context.Background())
+			if err != nil {
+				t.Fatalf("ExpireIdleSessions: %v", err)
+			}
+			if expired != 2 {
+				t.Errorf("expired = %d, want the stale and answers-only sessions", expired)
+			}
+			for id, want := range map[string]bool{"stale": true, "answers-only": true, "fresh": false, "reported": false} {
+				session, ok := repo.sessions[id]
+				if got := ok && session.ExpiredAt != nil; got != want {
+					t.Errorf("session %s expired = %v, want %v", id, got, want)
+				}
+			}
+			if got := repo.answers[0].DeletedAt.Valid; got != tt.wantPruned {
+				t.Errorf("stale answer deleted = %v, want %v", got, tt.wantPruned)
+			}
+			if got := repo.serveLogs[0].DeletedAt.Valid; got != tt.wantPruned {
+				t.Errorf("stale serve log deleted = %v, want %v", got, tt.wantPruned)
+			}
+			if repo.answers[1].DeletedAt.Valid {
+				t.Error("fresh session's answer was deleted")
+			}
+
+			// Expired sessions no longer count as active, nor do they expire twice
+			if active, _ := repo.CountActiveSessionsByUser(nil, "user-1"); active != 1 {
+				t.Errorf("active sessions of user-1 = %d, want only the fresh one", active)
+			}
+			if again, _ := u.ExpireIdleSessions(context.Background()); again != 0 {
+				t.Errorf("second run expired %d sessions, want 0", again)
+			}
+		})
+	}
+}
+
+// idle_minutes 0 (the default) disables expiry
+func TestExpireIdleSessionsDisabled(t *testing.T) {
+	repo := newFakeRepo()
+	repo.sessions["stale"] = &internalEntity.Session{SessionID: "stale", UserID: "user-1", UpdatedAt: time.Now().Add(-48 * time.Hour)}
+	u := newTestUsecase(t, repo)
+
+	if expired, err := u.ExpireIdleSessions(context.Background()); err != nil || expired != 0 {
+		t.Fatalf("ExpireIdleSessions = %d, %v; want nothing expired", expired, err)
+	}
+	if repo.sessions["stale"].ExpiredAt != nil {
+		t.Error("session expired with expiry disabled")
+	}
+}
+
+// An expired session cannot be resumed
+func TestResumeExpiredSession(t *testing.T) {
+	expiredAt := time.Now().Add(-time.Hour)
+	repo := newFakeRepo()
+	repo.sessions["s-1"] = &internalEntity.Session{SessionID: "s-1", UserID: "user-1", TargetCount: 3, Difficulty: "easy", ExpiredAt: &expiredAt}
+	u := newTestUsecase(t, repo)
+
+	if got, err := u.ResumeSession(context.Background(), "s-1", false); err == nil {
+		t.Fatalf("ResumeSession = %+v, want an error for an expired session", got)
+	}
+}
+
+// The idle-session cleaner runs in the primary process only; a prefork child never sweeps
+func TestSessionCleanerSkipsPreforkChild(t *testing.T) {
+	tests := []struct {
+		name         string
+		preforkChild bool
+		wantSweeps   bool
+	}{
+		{name: "primary process", wantSweeps: true},
+		{name: "prefork child", preforkChild: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := viper.New()
+			config.Set("sessions.expiry.idle_minutes", 60)
+			config.Set("sessions.expiry.check_interval_minutes", 0.0002) // ~12ms
+			repo := newFakeRepo()
+			u := NewDyslexiaQuestionUsecase(DyslexiaQuestionConfig{DB: dryRunDB(t), Repository: repo, Config: config, PreforkChild: tt.preforkChild})
+			t.Cleanup(func() { _ = u.Shutdown(context.Background()) })
+
+			sweeps := func() int {
+				repo.mu.Lock()
+				defer repo.mu.Unlock()
+				return repo.idleSweeps
+			}
+			// Give the cleaner many intervals; the primary process is done as soon as it swept once
+			for deadline := time.Now().Add(300 * time.Millisecond); sweeps() == 0 && time.Now().Before(deadline); {
+				time.Sleep(5 * time.Millisecond)
+			}
+			if got := sweeps() > 0; got != tt.wantSweeps {
+				t.Errorf("cleaner swept = %v, want %v", got, tt.wantSweeps)
+			}
+		})
+	}
+}
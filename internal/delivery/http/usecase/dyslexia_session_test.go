@@ -0,0 +1,390 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+// A session with one of three questions answered resumes with the other two, never the answered one
+func TestResumeSessionRemainingQuestions(t *testing.T) {
+	repo := newFakeRepo()
+	repo.sessions["s-1"] = &internalEntity.Session{SessionID: "s-1", UserID: "user-1", TargetCount: 3, Difficulty: "easy"}
+	for i := 1; i <= 4; i++ {
+		id := fmt.Sprintf("q-%d", i)
+		repo.questions[id] = &internalEntity.GeneratedQuestion{QuestionID: id, Difficulty: "easy", TargetLetterPair: "b-d", Options: `["bola","dola"]`, CorrectAnswer: "bola"}
+	}
+	repo.answers = []internalEntity.UserAnswer{{SessionID: "s-1", QuestionID: "q-1"}}
+	u := newTestUsecase(t, repo)
+
+	got, err := u.ResumeSession(context.Background(), "s-1", false)
+	if err != nil {
+		t.Fatalf("ResumeSession: %v", err)
+	}
+	if got.AnsweredCount != 1 || got.TargetCount != 3 || got.RemainingCount != 2 {
+		t.Errorf("progress = %d/%d remaining %d, want 1/3 remaining 2", got.AnsweredCount, got.TargetCount, got.RemainingCount)
+	}
+	if len(got.Questions) != 2 {
+		t.Fatalf("got %d questions, want 2", len(got.Questions))
+	}
+	for _, q := range got.Questions {
+		if q.ID == "q-1" {
+			t.Errorf("resumed session served the answered question %s", q.ID)
+		}
+		if q.Answer != "" {
+			t.Errorf("question %s leaked its answer", q.ID)
+		}
+	}
+}
+
+// A finished session resumes with nothing left to serve
+func TestResumeSessionComplete(t *testing.T) {
+	repo := newFakeRepo()
+	repo.sessions["s-1"] = &internalEntity.Session{SessionID: "s-1", TargetCount: 1, Difficulty: "easy"}
+	repo.answers = []internalEntity.UserAnswer{{SessionID: "s-1", QuestionID: "q-1"}, {SessionID: "s-1", QuestionID: "q-2"}}
+	u := newTestUsecase(t, repo)
+
+	got, err := u.ResumeSession(context.Background(), "s-1", false)
+	if err != nil {
+		t.Fatalf("ResumeSession: %v", err)
+	}
+	if got.RemainingCount != 0 || len(got.Questions) != 0 {
+		t.Errorf("remaining %d with %d questions, want none", got.RemainingCount, len(got.Questions))
+	}
+}
+
+// With sessions.enforce_target_count, questions served but never answered use up the target on resume too
+func TestResumeSessionEnforcedTarget(t *testing.T) {
+	repo := newFakeRepo()
+	repo.sessions["s-1"] = &internalEntity.Session{SessionID: "s-1", TargetCount: 3, Difficulty: "easy"}
+	for i := 1; i <= 4; i++ {
+		id := fmt.Sprintf("q-%d", i)
+		repo.questions[id] = &internalEntity.GeneratedQuestion{QuestionID: id, Difficulty: "easy", TargetLetterPair: "b-d", Options: `["bola","dola"]`, CorrectAnswer: "bola"}
+	}
+	repo.answers = []internalEntity.UserAnswer{{SessionID: "s-1", QuestionID: "q-1"}}
+	repo.serveLogs = []internalEntity.QuestionServeLog{{SessionID: "s-1", QuestionID: "q-1"}, {SessionID: "s-1", QuestionID: "q-2"}}
+	u := newTestUsecase(t, repo)
+	u.cfg.Config.Set("sessions.enforce_target_count", true)
+
+	got, err := u.ResumeSession(context.Background(), "s-1", false)
+	if err != nil {
+		t.Fatalf("ResumeSession: %v", err)
+	}
+	// Two answers are still missing, but only one question of the target was never served
+	if got.RemainingCount != 2 || len(got.Questions) != 1 {
+		t.Errorf("remaining %d with %d questions, want 2 with 1", got.RemainingCount, len(got.Questions))
+	}
+}
+
+func TestResumeSessionUnknown(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	if _, err := u.ResumeSession(context.Background(), "missing", false); err == nil {
+		t.Error("ResumeSession of an unknown session succeeded")
+	}
+}
+
+// StartSession defaults the difficulty and refuses a session id that is already taken
+func TestStartSession(t *testing.T) {
+	repo := newFakeRepo()
+	u := newTestUsecase(t, repo)
+	u.cfg.DB, _ = txTestDB(t)
+	req := entity.StartSessionRequest{UserID: "user-1", SessionID: "s-1", TargetCount: 5}
+
+	info, err := u.StartSession(context.Background(), req)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if info.Difficulty != "easy" || info.TargetCount != 5 {
+		t.Errorf("session info = %+v, want easy with target 5", info)
+	}
+	if _, err := u.StartSession(context.Background(), req); err == nil {
+		t.Error("StartSession reused an existing session id")
+	}
+	if len(repo.sessions) != 1 {
+		t.Errorf("stored %d sessions, want one", len(repo.sessions))
+	}
+}
+
+// The cap check and the insert share one transaction behind the per-user lock; duplicates are refused by
+// the insert itself rather than a separate lookup
+func TestStartSessionLimitAndDuplicates(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit         int
+		existing      []string // sessions user-1 already has
+		sessionID     string
+		wantErr       bool
+		wantCalls     []string
+		wantCommits   int
+		wantRollbacks int
+	}{
+		{name: "no limit skips the lock", sessionID: "s-new", wantCalls: []string{"create s-new"}, wantCommits: 1},
+		{name: "under the limit", limit: 2, existing: []string{"s-1"}, sessionID: "s-new",
+			wantCalls: []string{"lock user-1", "count user-1", "create s-new"}, wantCommits: 1},
+		{name: "at the limit", limit: 1, existing: []string{"s-1"}, sessionID: "s-new", wantErr: true,
+			wantCalls: []string{"lock user-1", "count user-1"}, wantRollbacks: 1},
+		{name: "duplicate id", existing: []string{"s-1"}, sessionID: "s-1", wantErr: true,
+			wantCalls: []string{"create s-1"}, wantRollbacks: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			for _, id := range tt.existing {
+				repo.sessions[id] = &internalEntity.Session{SessionID: id, UserID: "user-1"}
+			}
+			u := newTestUsecase(t, repo)
+			var counter *txCounter
+			u.cfg.DB, counter = txTestDB(t)
+			u.cfg.Config.Set("sessions.max_active_per_user", tt.limit)
+
+			_, err := u.StartSession(context.Background(), entity.StartSessionRequest{SessionID: tt.sessionID, UserID: "user-1", TargetCount: 10})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StartSession error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(repo.calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", repo.calls, tt.wantCalls)
+			}
+			if commits, rollbacks := counter.counts(); commits != tt.wantCommits || rollbacks != tt.wantRollbacks {
+				t.Errorf("commits/rollbacks = %d/%d, want %d/%d", commits, rollbacks, tt.wantCommits, tt.wantRollbacks)
+			}
+		})
+	}
+}
+
+// A user at sessions.max_active_per_user can neither start a session nor open one with a first answer,
+// can keep answering open sessions, and frees a slot once a session has a report
+func TestActiveSessionLimit(t *testing.T) {
+	repo := newFakeRepo()
+	repo.questions["q-1"] = &internalEntity.GeneratedQuestion{QuestionID: "q-1", CorrectAnswer: "bola"}
+	repo.questions["q-2"] = &internalEntity.GeneratedQuestion{QuestionID: "q-2", CorrectAnswer: "dadu"}
+	u := newTestUsecase(t, repo)
+	u.cfg.DB, _ = txTestDB(t)
+	u.cfg.Config.Set("sessions.max_active_per_user", 2)
+	ctx := context.Background()
+
+	if _, err := u.StartSession(ctx, entity.StartSessionRequest{UserID: "user-1", SessionID: "s-1", TargetCount: 5}); err != nil {
+		t.Fatalf("StartSession s-1: %v", err)
+	}
+	if _, err := u.SubmitAnswer(ctx, entity.SubmitAnswerRequest{UserID: "user-1", SessionID: "s-2", QuestionID: "q-1", Answer: "bola"}); err != nil {
+		t.Fatalf("first answer of s-2: %v", err)
+	}
+
+	if _, err := u.StartSession(ctx, entity.StartSessionRequest{UserID: "user-1", SessionID: "s-3", TargetCount: 5}); err == nil || !strings.Contains(err.Error(), "limit 2") {
+		t.Errorf("StartSession past the cap: err = %v, want the limit error", err)
+	}
+	if _, err := u.SubmitAnswer(ctx, entity.SubmitAnswerRequest{UserID: "user-1", SessionID: "s-3", QuestionID: "q-1", Answer: "bola"}); err == nil {
+		t.Error("first answer opened a session past the cap")
+	}
+	if _, err := u.SubmitAnswer(ctx, entity.SubmitAnswerRequest{UserID: "user-1", SessionID: "s-2", QuestionID: "q-2", Answer: "dadu"}); err != nil {
+		t.Errorf("answer to an open session at the cap: %v", err)
+	}
+	if _, err := u.StartSession(ctx, entity.StartSessionRequest{UserID: "user-2", SessionID: "s-other", TargetCount: 5}); err != nil {
+		t.Errorf("another user's StartSession: %v", err)
+	}
+
+	repo.caches["s-1"] = &internalEntity.SessionAnalysisCache{SessionID: "s-1"}
+	if _, err := u.StartSession(ctx, entity.StartSessionRequest{UserID: "user-1", SessionID: "s-3", TargetCount: 5}); err != nil {
+		t.Errorf("StartSession after a report freed a slot: %v", err)
+	}
+}
+
+func answersFor(questionIDs ...string) []internalEntity.UserAnswer {
+	answers := make([]internalEntity.UserAnswer, 0, len(questionIDs))
+	for _, id := range questionIDs {
+		answers = append(answers, internalEntity.UserAnswer{QuestionID: id})
+	}
+	return answers
+}
+
+func TestSessionProgress(t *testing.T) {
+	tests := []struct {
+		name          string
+		target        int
+		answers       []internalEntity.UserAnswer
+		wantAnswered  int
+		wantRemaining int
+		wantPercent   float64
+		wantCompleted bool
+	}{
+		{name: "not started", target: 10, wantRemaining: 10},
+		{name: "partial, rounded to one decimal", target: 3, answers: answersFor("a"), wantAnswered: 1, wantRemaining: 2, wantPercent: 33.3},
+		{name: "re-attempts count once", target: 4, answers: answersFor("a", "a", "b"), wantAnswered: 2, wantRemaining: 2, wantPercent: 50},
+		{name: "exactly complete", target: 2, answers: answersFor("a", "b"), wantAnswered: 2, wantPercent: 100, wantCompleted: true},
+		{name: "over target is capped", target: 2, answers: answersFor("a", "b", "c"), wantAnswered: 3, wantPercent: 100, wantCompleted: true},
+		{name: "no target", target: 0, answers: answersFor("a"), wantAnswered: 1},
+	}
+	for _, tt := range tests {
+		got := sessionProgress(&internalEntity.Session{SessionID: "s", TargetCount: tt.target}, tt.answers)
+		if got.AnsweredCount != tt.wantAnswered || got.RemainingCount != tt.wantRemaining ||
+			got.CompletionPercent != tt.wantPercent || got.Completed != tt.wantCompleted || got.TargetCount != tt.target {
+			t.Errorf("%s: got %+v", tt.name, got)
+		}
+	}
+}
+
+func TestCheckSessionTargetCountsServedQuestions(t *testing.T) {
+	repo := newFakeRepo()
+	repo.sessions["sess-1"] = &internalEntity.Session{SessionID: "sess-1", UserID: "user-1", TargetCount: 5}
+	repo.serveLogs = []internalEntity.QuestionServeLog{
+		{SessionID: "sess-1", QuestionID: "q-1"},
+		{SessionID: "sess-1", QuestionID: "q-2"},
+		{SessionID: "sess-1", QuestionID: "q-3"},
+	}
+	repo.answers = []internalEntity.UserAnswer{{SessionID: "sess-1", QuestionID: "q-1"}}
+	u := newTestUsecase(t, repo)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		enforce bool
+		session string
+		count   int
+		wantErr bool
+	}{
+		{name: "enforcement off", session: "sess-1", count: 50},
+		{name: "fits in what is left", enforce: true, session: "sess-1", count: 2},
+		// Only one question was answered, but three were served: asking for three more exceeds the target
+		{name: "served but unanswered questions count", enforce: true, session: "sess-1", count: 3, wantErr: true},
+		{name: "session without a start record", enforce: true, session: "sess-unknown", count: 50},
+		{name: "no session", enforce: true, count: 50},
+	}
+	for _, tt := range tests {
+		u.cfg.Config.Set("sessions.enforce_target_count", tt.enforce)
+		err := u.checkSessionTarget(ctx, tt.session, tt.count)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%s: error %v is not ErrInvalidInput", tt.name, err)
+		}
+	}
+}
+
+// Generate checks the normalized count against the target: count=0 serves (and so needs) one question,
+// and a count above 10 only needs the 10 it serves
+func TestGenerateRefusesOverTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  int
+		served  int
+		count   int
+		wantErr bool
+	}{
+		{name: "target reached", target: 1, served: 1, count: 1, wantErr: true},
+		{name: "count=0 with the target reached", target: 1, served: 1, count: 0, wantErr: true},
+		{name: "count=0 with one left", target: 2, served: 1, count: 0},
+		{name: "over the remaining target", target: 3, served: 1, count: 3, wantErr: true},
+		{name: "count above the maximum is clamped first", target: 10, count: 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.sessions["sess-1"] = &internalEntity.Session{SessionID: "sess-1", UserID: "user-1", TargetCount: tt.target}
+			for i := 1; i <= tt.served; i++ {
+				repo.serveLogs = append(repo.serveLogs, internalEntity.QuestionServeLog{SessionID: "sess-1", QuestionID: fmt.Sprintf("q-%d", i)})
+			}
+			u := newTestUsecase(t, repo)
+			u.cfg.Config.Set("sessions.enforce_target_count", true)
+			u.cfg.Config.Set("llm.gemini.disable_ai_prompt", true)
+
+			questions, _, err := u.Generate(context.Background(), entity.GenerateOptions{Count: tt.count, UseAI: true, SessionID: "sess-1", Shuffle: true})
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidInput) {
+					t.Fatalf("error = %v, want ErrInvalidInput", err)
+				}
+				if len(repo.serveLogs) != tt.served {
+					t.Errorf("refused Generate logged %d served questions, want %d", len(repo.serveLogs), tt.served)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			if want := min(max(tt.count, 1), 10); len(questions) != want {
+				t.Errorf("got %d questions, want %d", len(questions), want)
+			}
+		})
+	}
+}
+
+// The answer log carries progress only for started sessions; a session that was neither started nor answered is not found
+func TestGetSessionAnswersProgress(t *testing.T) {
+	tests := []struct {
+		name         string
+		session      *internalEntity.Session
+		answers      []internalEntity.UserAnswer
+		wantLogs     int
+		wantProgress *entity.SessionProgress
+		wantNotFound bool
+	}{
+		{
+			name:         "started with answers",
+			session:      &internalEntity.Session{SessionID: "sess-1", TargetCount: 4},
+			answers:      answersFor("q-1", "q-1", "q-2"),
+			wantLogs:     3,
+			wantProgress: &entity.SessionProgress{SessionID: "sess-1", AnsweredCount: 2, TargetCount: 4, RemainingCount: 2, CompletionPercent: 50},
+		},
+		{
+			name:         "started without answers",
+			session:      &internalEntity.Session{SessionID: "sess-1", TargetCount: 2},
+			wantProgress: &entity.SessionProgress{SessionID: "sess-1", TargetCount: 2, RemainingCount: 2},
+		},
+		{name: "answers without a start record", answers: answersFor("q-1"), wantLogs: 1},
+		{name: "unknown session", wantNotFound: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			if tt.session != nil {
+				repo.sessions[tt.session.SessionID] = tt.session
+			}
+			for _, a := range tt.answers {
+				a.SessionID = "sess-1"
+				repo.answers = append(repo.answers, a)
+			}
+			u := newTestUsecase(t, repo)
+
+			logs, progress, err := u.GetSessionAnswers(context.Background(), "sess-1")
+			if tt.wantNotFound {
+				if !errors.Is(err, ErrNotFound) {
+					t.Fatalf("error = %v, want ErrNotFound", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetSessionAnswers: %v", err)
+			}
+			if len(logs) != tt.wantLogs {
+				t.Errorf("logs = %d, want %d", len(logs), tt.wantLogs)
+			}
+			switch {
+			case tt.wantProgress == nil && progress != nil:
+				t.Errorf("progress = %+v, want none", *progress)
+			case tt.wantProgress != nil && (progress == nil || *progress != *tt.wantProgress):
+				t.Errorf("progress = %+v, want %+v", progress, *tt.wantProgress)
+			}
+		})
+	}
+}
+
+func TestGetSessionProgress(t *testing.T) {
+	repo := newFakeRepo()
+	repo.sessions["sess-1"] = &internalEntity.Session{SessionID: "sess-1", TargetCount: 5}
+	repo.answers = []internalEntity.UserAnswer{{SessionID: "sess-1", QuestionID: "q-1"}, {SessionID: "sess-1", QuestionID: "q-2"}}
+	u := newTestUsecase(t, repo)
+
+	progress, err := u.GetSessionProgress(context.Background(), "sess-1")
+	want := entity.SessionProgress{SessionID: "sess-1", AnsweredCount: 2, TargetCount: 5, RemainingCount: 3, CompletionPercent: 40}
+	if err != nil || progress == nil || *progress != want {
+		t.Errorf("GetSessionProgress = %+v, %v; want %+v", progress, err, want)
+	}
+	if _, err := u.GetSessionProgress(context.Background(), "sess-unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown session: error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+)
+
+// Generation strategies (questions.generation_strategy)
+const (
+	GenerationStrategyAIFirst    = "ai_first"
+	GenerationStrategyCacheFirst = "cache_first"
+)
+
+func ParseGenerationStrategy(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case GenerationStrategyAIFirst:
+		return GenerationStrategyAIFirst, nil
+	case GenerationStrategyCacheFirst:
+		return GenerationStrategyCacheFirst, nil
+	default:
+		return "", fmt.Errorf("unknown generation strategy %q (supported: %s, %s)", s, GenerationStrategyAIFirst, GenerationStrategyCacheFirst)
+	}
+}
+
+// pairsWithFallbackWords keeps the letter pairs that have real fallback words: a hardcoded list or bank templates
+func pairsWithFallbackWords(difficulty entity.Difficulty, letterPairs []string) []string {
+	pairs := make([]string, 0, len(letterPairs))
+	for _, pair := range letterPairs {
+		if _, ok := fallbackWords[pair]; ok || len(bankTemplatesForPair(difficulty, pair)) > 0 {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+// generateCacheFirst fills the request from the DB cache, calls the LLM for the shortfall (bounded by
+// questions.generate_concurrency and the per-request LLM budget) and tops up what is still missing from
+// the fallback word lists (questions.generation_strategy=cache_first). The meta reports fallback top-ups and
+// shortfalls with the reason the cache and the LLM did not cover the request.
+func (u *dyslexiaQuestionUsecase) generateCacheFirst(ctx context.Context, difficulty entity.Difficulty, count int, includeAnswer bool, letterPairs []string, excludedQuestionIDs []string, shuffle bool, sessionID string) ([]entity.GeneratedQuestion, *entity.GenerateMeta, error) {
+	startTime := time.Now()
+	log := u.logger()
+	stats := generateStatsFrom(ctx)
+
+	seenIDs := make(map[string]bool, len(excludedQuestionIDs)+count)
+	for _, id := range excludedQuestionIDs {
+		seenIDs[id] = true
+	}
+	results := make([]entity.GeneratedQuestion, 0, count)
+	duplicates := 0
+	add := func(q entity.GeneratedQuestion) {
+		if q.ID == "" || len(results) >= count {
+			return
+		}
+		if seenIDs[q.ID] {
+			stats.duplicate()
+			duplicates++
+			return
+		}
+		seenIDs[q.ID] = true
+		results = append(results, q)
+	}
+
+	cached, err := u.generateFromDBCache(ctx, difficulty, count, true, letterPairs, excludedQuestionIDs, shuffle)
+	if err != nil {
+		log.Debugf("[CACHE_FIRST] Cache miss: %v", err)
+	}
+	for _, q := range cached {
+		add(q)
+	}
+	fromCache := len(results)
+
+	// LLM for the shortfall; failed calls leave their slot empty for the fallback step
+	reason := entity.GenerateShortfallCacheExhausted
+	shortfall := count - len(results)
+	switch {
+	case shortfall <= 0 || u.cfg.Config.GetBool("llm.gemini.disable_ai_prompt"):
+	case !u.consumeQuota(u.resolveUserID(sessionID), QuotaKindGenerate, shortfall):
+		reason = entity.GenerateShortfallQuotaExceeded
+	default:
+		calls := min(shortfall, u.llmCallBudget(count))
+		generated := make([]entity.GeneratedQuestion, calls)
+		runBounded(ctx, calls, u.generateConcurrency(), func(index int) {
+			letterPair := letterPairs[u.rnd.Intn(len(letterPairs))]
+			q, prompt, err := u.generateFromAI(ctx, difficulty, letterPair, true, shuffle)
+			if err != nil {
+				log.Warnf("[CACHE_FIRST] Question %d: AI generate error: %v, using fallback", index+1, err)
+				return
+			}
+			stats.source(q.ID, generateSourceAI)
+			u.saveOutbox.Enqueue(q, letterPair, prompt, u.generatedBy(ctx))
+			generated[index] = q
+		})
+		duplicates = 0
+		for _, q := range generated {
+			add(q)
+		}
+		reason = entity.GenerateShortfallAIFailed
+		if duplicates > 0 {
+			reason = entity.GenerateShortfallDuplicates
+		}
+	}
+	fromAI := len(results) - fromCache
+
+	// Fallback words repeat quickly (one hardcoded question per pair), so stop after a bounded number of draws
+	pairs := pairsWithFallbackWords(difficulty, letterPairs)
+	for attempts := 0; len(results) < count && len(pairs) > 0 && attempts < count*3; attempts++ {
+		q := u.createFallbackQuestionWithShuffle(difficulty, pairs[u.rnd.Intn(len(pairs))], true, shuffle)
+		stats.source(q.ID, generateSourceFallback)
+		add(q)
+	}
+
+	if len(results) == 0 {
+		return nil, nil, fmt.Errorf("no questions available for difficulty=%s patterns=%v (excluded %d questions)", difficulty, letterPairs, len(excludedQuestionIDs))
+	}
+
+	if !includeAnswer {
+		for i := range results {
+			results[i].Answer = ""
+		}
+	}
+
+	fromFallback := len(results) - fromCache - fromAI
+	log.Debugf("[CACHE_FIRST] took: %v (cache=%d ai=%d fallback=%d)", time.Since(startTime), fromCache, fromAI, fromFallback)
+	return results, shortfallMeta(count, len(results), reason, fromFallback), nil
+}
@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+func TestParseGenerationStrategy(t *testing.T) {
+	for in, want := range map[string]string{"ai_first": GenerationStrategyAIFirst, " Cache_First ": GenerationStrategyCacheFirst} {
+		if got, err := ParseGenerationStrategy(in); err != nil || got != want {
+			t.Errorf("ParseGenerationStrategy(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseGenerationStrategy("llm_only"); err == nil {
+		t.Error("unknown strategy accepted")
+	}
+}
+
+// cache_first never calls the LLM when the cache alone covers the request
+func TestGenerateCacheFirstServesCache(t *testing.T) {
+	repo := newFakeRepo()
+	for _, id := range []string{"q-1", "q-2"} {
+		repo.questions[id] = &internalEntity.GeneratedQuestion{QuestionID: id, Difficulty: "easy", TargetLetterPair: "b-d", Options: `["bola","dola"]`, CorrectAnswer: "bola"}
+	}
+	u := newTestUsecase(t, repo)
+	u.generationStrategy = GenerationStrategyCacheFirst
+	var fake *fakeLLM
+	u.cfg.Gemini, fake = newFakeLLM(t, replyWith(`{"correctAnswer":"dodol","options":["dodol","bodol","dobol","bobol"]}`))
+
+	questions, meta, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 2, Patterns: []string{"b-d"}, UseAI: true, Shuffle: true})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if meta != nil {
+		t.Errorf("meta = %+v, want none when the cache covers the request", *meta)
+	}
+	if len(questions) != 2 {
+		t.Fatalf("got %d questions, want 2 from the cache", len(questions))
+	}
+	for _, q := range questions {
+		if q.ID != "q-1" && q.ID != "q-2" {
+			t.Errorf("question %q does not come from the cache", q.ID)
+		}
+	}
+	if got := fake.calls.Load(); got != 0 {
+		t.Errorf("LLM calls = %d, want none when the cache covers the request", got)
+	}
+}
+
+// cache_first serves the cache, asks the LLM for the shortfall and only then falls back; the meta names why
+// the fallback had to top up
+func TestGenerateCacheFirstOrder(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		budget       int
+		disableAI    bool
+		quota        int
+		wantCalls    int64
+		wantAI       int
+		wantFallback int
+		wantReason   string
+	}{
+		{name: "llm fills the shortfall", status: http.StatusOK, wantCalls: 2, wantAI: 2},
+		{name: "budget caps llm calls", status: http.StatusOK, budget: 1, wantCalls: 1, wantAI: 1, wantFallback: 1, wantReason: entity.GenerateShortfallAIFailed},
+		{name: "llm failures fall back", status: http.StatusInternalServerError, wantCalls: 2, wantFallback: 2, wantReason: entity.GenerateShortfallAIFailed},
+		{name: "ai disabled falls back", status: http.StatusOK, disableAI: true, wantFallback: 2, wantReason: entity.GenerateShortfallCacheExhausted},
+		{name: "quota exceeded falls back", status: http.StatusOK, quota: 1, wantFallback: 2, wantReason: entity.GenerateShortfallQuotaExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.questions["q-cached"] = &internalEntity.GeneratedQuestion{QuestionID: "q-cached", Difficulty: "easy", TargetLetterPair: "b-d", Options: `["bola","dola"]`, CorrectAnswer: "bola"}
+			u := newTestUsecase(t, repo)
+			u.cfg.PromptTemplate = defaultPromptTemplate
+			if tt.budget > 0 {
+				u.cfg.Config.Set("questions.max_llm_calls_per_request", tt.budget)
+			}
+			u.cfg.Config.Set("llm.gemini.disable_ai_prompt", tt.disableAI)
+			u.cfg.Config.Set("llm.quota.daily_generations", tt.quota)
+			repo.sessions["sess-1"] = &internalEntity.Session{SessionID: "sess-1", UserID: "user-1"}
+			var fake *fakeLLM
+			u.cfg.Gemini, fake = newFakeLLM(t, func(string) (string, int) {
+				return `{"correctAnswer":"dodol","options":["dodol","bodol","dobol","bobol"]}`, tt.status
+			})
+
+			questions, meta, err := u.generateCacheFirst(context.Background(), "easy", 3, true, []string{"b-d"}, nil, true, "sess-1")
+			if err != nil {
+				t.Fatalf("generateCacheFirst: %v", err)
+			}
+			if len(questions) != 3 || questions[0].ID != "q-cached" {
+				t.Fatalf("got %d questions starting with %q, want 3 starting with the cached one", len(questions), questions[0].ID)
+			}
+			if got := fake.calls.Load(); got != tt.wantCalls {
+				t.Errorf("LLM calls = %d, want %d", got, tt.wantCalls)
+			}
+			ai, fallback := 0, 0
+			for _, q := range questions[1:] {
+				if strings.EqualFold(q.Answer, "dodol") {
+					ai++
+				} else {
+					fallback++
+				}
+			}
+			if ai != tt.wantAI || fallback != tt.wantFallback {
+				t.Errorf("got ai=%d fallback=%d, want ai=%d fallback=%d", ai, fallback, tt.wantAI, tt.wantFallback)
+			}
+			switch {
+			case tt.wantReason == "" && meta != nil:
+				t.Errorf("meta = %+v, want nil", *meta)
+			case tt.wantReason != "" && (meta == nil || meta.Reason != tt.wantReason || meta.ToppedUp != tt.wantFallback):
+				t.Errorf("meta = %+v, want reason %q topped up %d", meta, tt.wantReason, tt.wantFallback)
+			}
+		})
+	}
+}
+
+func TestShortfallMeta(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested int
+		returned  int
+		toppedUp  int
+		want      *entity.GenerateMeta
+	}{
+		{name: "met from the intended source", requested: 3, returned: 3},
+		{name: "more than requested", requested: 3, returned: 4},
+		{name: "short", requested: 3, returned: 1, want: &entity.GenerateMeta{Requested: 3, Returned: 1, Reason: "r"}},
+		{name: "met with a top-up", requested: 3, returned: 3, toppedUp: 2, want: &entity.GenerateMeta{Requested: 3, Returned: 3, Reason: "r", ToppedUp: 2}},
+		{name: "short after a top-up", requested: 3, returned: 2, toppedUp: 1, want: &entity.GenerateMeta{Requested: 3, Returned: 2, Reason: "r", ToppedUp: 1}},
+	}
+	for _, tt := range tests {
+		got := shortfallMeta(tt.requested, tt.returned, "r", tt.toppedUp)
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%s: shortfallMeta = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// use_ai=false reports a cache shortfall, or the fallback top-up with questions.cache_topup_fallback
+func TestGenerateFromCacheOnlyMeta(t *testing.T) {
+	tests := []struct {
+		name     string
+		cached   int
+		topUp    bool
+		wantLen  int
+		wantMeta *entity.GenerateMeta
+	}{
+		{name: "cache covers the request", cached: 3, wantLen: 3},
+		{name: "cache short", cached: 1, wantLen: 1, wantMeta: &entity.GenerateMeta{Requested: 3, Returned: 1, Reason: entity.GenerateShortfallCacheExhausted}},
+		{name: "fallback tops up", cached: 1, topUp: true, wantLen: 3,
+			wantMeta: &entity.GenerateMeta{Requested: 3, Returned: 3, Reason: entity.GenerateShortfallCacheExhausted, ToppedUp: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			for i := 0; i < tt.cached; i++ {
+				id := fmt.Sprintf("q-cached-%d", i)
+				repo.questions[id] = &internalEntity.GeneratedQuestion{QuestionID: id, Difficulty: "easy", TargetLetterPair: "b-d", Options: `["bola","dola"]`, CorrectAnswer: "bola"}
+			}
+			u := newTestUsecase(t, repo)
+			u.cfg.Config.Set("questions.cache_topup_fallback", tt.topUp)
+
+			questions, meta, err := u.Generate(context.Background(), entity.GenerateOptions{Difficulty: entity.DifficultyEasy, Count: 3, Patterns: []string{"b-d"}, Shuffle: true})
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			if len(questions) != tt.wantLen {
+				t.Errorf("questions = %d, want %d", len(questions), tt.wantLen)
+			}
+			if (meta == nil) != (tt.wantMeta == nil) || (meta != nil && *meta != *tt.wantMeta) {
+				t.Errorf("meta = %+v, want %+v", meta, tt.wantMeta)
+			}
+		})
+	}
+}
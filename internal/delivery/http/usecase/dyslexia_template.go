@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+	"github.com/evandrarf/dinacom-be/internal/pkg/mapper"
+)
+
+// GetTemplates returns a page of question bank templates; the correct word is hidden unless includeAnswer
+func (u *dyslexiaQuestionUsecase) GetTemplates(ctx context.Context, difficulty entity.Difficulty, includeAnswer bool, page, limit int) ([]entity.QuestionTemplate, int64, error) {
+	if difficulty == "" {
+		difficulty = u.defaultDifficulty
+	}
+	includeAnswer = u.answerExposureAllowed(includeAnswer)
+
+	total, err := u.cfg.Repository.CountTemplatesByDifficulty(u.dbWithContext(ctx), string(difficulty))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count templates: %w", err)
+	}
+
+	dbTemplates, err := u.cfg.Repository.FindTemplatesByDifficultyPaginated(u.dbWithContext(ctx), string(difficulty), (page-1)*limit, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get templates: %w", err)
+	}
+
+	templates := make([]entity.QuestionTemplate, 0, len(dbTemplates))
+	for i := range dbTemplates {
+		tpl, err := mapper.ConvertToQuestionTemplate(&dbTemplates[i])
+		if err != nil {
+			u.logger().Warnf("Failed to parse distractors for template %s: %v", dbTemplates[i].TemplateID, err)
+			continue
+		}
+		if !includeAnswer {
+			tpl.CorrectWord = ""
+		}
+		templates = append(templates, tpl)
+	}
+
+	return templates, total, nil
+}
+
+// GenerateFromTemplate serves a specific question bank template (e.g. for a curated lesson).
+// The question is persisted once per template and reused afterwards; options are reshuffled per request.
+// Like Generate, the served question is logged so later requests of sessionID exclude it.
+func (u *dyslexiaQuestionUsecase) GenerateFromTemplate(ctx context.Context, templateID string, difficulty entity.Difficulty, includeAnswer bool, sessionID string) (*entity.GeneratedQuestion, error) {
+	includeAnswer = u.answerExposureAllowed(includeAnswer)
+
+	dbTemplate, err := u.cfg.Repository.FindTemplateByTemplateID(u.cfg.DB, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("template not found: %s", templateID)
+	}
+	if difficulty != "" && string(difficulty) != dbTemplate.Difficulty {
+		return nil, fmt.Errorf("template %s is %s, not %s", templateID, dbTemplate.Difficulty, difficulty)
+	}
+
+	tpl, err := mapper.ConvertToQuestionTemplate(dbTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var options []string
+	questionID := ""
+	if existing, _ := u.cfg.Repository.FindGeneratedByTemplateID(u.cfg.DB, tpl.ID, "template"); existing != nil {
+		if err := json.Unmarshal([]byte(existing.Options), &options); err != nil {
+			return nil, fmt.Errorf("failed to parse options: %w", err)
+		}
+		questionID = existing.QuestionID
+		_ = u.cfg.Repository.IncrementUsageCount(u.cfg.DB, questionID)
+	} else {
+		distractors := tpl.Distractors
+		if len(distractors) == 0 {
+			return nil, fmt.Errorf("template %s has no usable distractors", templateID)
+		}
+		if len(distractors) > 3 {
+			distractors = distractors[:3]
+		}
+		options = append([]string{tpl.CorrectWord}, distractors...)
+		questionID = u.questionID(tpl.CorrectWord, tpl.Difficulty, options)
+
+		optionsJSON, err := json.Marshal(options)
+		if err != nil {
+			return nil, err
+		}
+		dbQuestion := &internalEntity.GeneratedQuestion{
+			QuestionID:       questionID,
+			TemplateID:       tpl.ID,
+			Difficulty:       string(tpl.Difficulty),
+			QuestionText:     "Dengarkan kata berikut: ",
+			TargetLetterPair: tpl.TargetLetterPair,
+			TargetLetter:     tpl.TargetLetter,
+			Options:          string(optionsJSON),
+			CorrectAnswer:    tpl.CorrectWord,
+			GeneratedBy:      "template",
+			UsageCount:       1,
+		}
+		if err := u.cfg.Repository.CreateGenerated(u.cfg.DB, dbQuestion); err != nil {
+			return nil, fmt.Errorf("failed to save question: %w", err)
+		}
+	}
+
+	q := entity.GeneratedQuestion{
+		ID:               questionID,
+		Difficulty:       tpl.Difficulty,
+		QuestionText:     "Dengarkan kata berikut: ",
+		TargetLetterPair: tpl.TargetLetterPair,
+		TargetLetter:     tpl.TargetLetter,
+		Options:          shuffleOptionsWith(u.rnd, options),
+	}
+	if includeAnswer {
+		q.Answer = tpl.CorrectWord
+	}
+	u.caseQuestion(&q)
+	setCorrectIndex(&q)
+	u.logServedQuestions(sessionID, []entity.GeneratedQuestion{q})
+
+	return &q, nil
+}
+
+// CreateQuestion persists a hand-authored question, bypassing the AI
+func (u *dyslexiaQuestionUsecase) CreateQuestion(ctx context.Context, req entity.CreateQuestionRequest) (*entity.GeneratedQuestion, error) {
+	correctAnswer := strings.TrimSpace(req.CorrectAnswer)
+
+	seen := make(map[string]bool)
+	hasCorrect := false
+	options := make([]string, 0, len(req.Options))
+	for _, opt := range req.Options {
+		opt = strings.TrimSpace(opt)
+		if err := u.validateOptionWord(opt); err != nil {
+			return nil, err
+		}
+		key := strings.ToUpper(opt)
+		if seen[key] {
+			return nil, fmt.Errorf("options must be unique: %s", opt)
+		}
+		seen[key] = true
+		if strings.EqualFold(opt, correctAnswer) {
+			hasCorrect = true
+		}
+		options = append(options, opt)
+	}
+	if !hasCorrect {
+		return nil, fmt.Errorf("options must include the correct answer")
+	}
+
+	patterns, err := validatePatterns([]string{req.TargetLetterPair})
+	if err != nil || len(patterns) == 0 {
+		return nil, fmt.Errorf("invalid target_letter_pair: %s", req.TargetLetterPair)
+	}
+	letterPair := patterns[0]
+
+	questionText := req.QuestionText
+	if strings.TrimSpace(questionText) == "" {
+		questionText = "Dengarkan kata berikut: "
+	}
+	targetLetter := req.TargetLetter
+	if targetLetter == "" {
+		targetLetter = strings.Split(letterPair, "-")[0]
+	}
+
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	q := entity.GeneratedQuestion{
+		ID:               u.questionID(correctAnswer, req.Difficulty, options),
+		Difficulty:       req.Difficulty,
+		QuestionText:     questionText,
+		TargetLetterPair: letterPair,
+		TargetLetter:     targetLetter,
+		Options:          options,
+		Answer:           correctAnswer,
+	}
+
+	dbQuestion := &internalEntity.GeneratedQuestion{
+		QuestionID:       q.ID,
+		TemplateID:       letterPair,
+		Difficulty:       string(q.Difficulty),
+		QuestionText:     q.QuestionText,
+		TargetLetterPair: q.TargetLetterPair,
+		TargetLetter:     q.TargetLetter,
+		Options:          string(optionsJSON),
+		CorrectAnswer:    correctAnswer,
+		GeneratedBy:      "manual",
+	}
+	if err := u.cfg.Repository.CreateGenerated(u.cfg.DB, dbQuestion); err != nil {
+		return nil, fmt.Errorf("failed to save question: %w", err)
+	}
+	u.caseQuestion(&q)
+	setCorrectIndex(&q)
+
+	return &q, nil
+}
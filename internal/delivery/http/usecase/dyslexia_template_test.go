@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+func TestGetTemplates(t *testing.T) {
+	repo := newFakeRepo()
+	for i := 1; i <= 3; i++ {
+		id := fmt.Sprintf("e-bd-%d", i)
+		repo.templates[id] = &internalEntity.QuestionBankTemplate{
+			TemplateID: id, Difficulty: "easy", TargetLetterPair: "b-d", TargetLetter: "b",
+			CorrectWord: "bola", Distractors: `["dola","bolb","dolb"]`,
+		}
+	}
+	repo.templates["m-bd-1"] = &internalEntity.QuestionBankTemplate{TemplateID: "m-bd-1", Difficulty: "medium", CorrectWord: "bunga", Distractors: `["dunga"]`}
+	u := newTestUsecase(t, repo)
+
+	tests := []struct {
+		name          string
+		difficulty    entity.Difficulty
+		includeAnswer bool
+		allowAnswer   bool
+		page, limit   int
+		wantIDs       []string
+		wantTotal     int64
+	}{
+		{name: "answers hidden by default", difficulty: entity.DifficultyEasy, page: 1, limit: 2, wantIDs: []string{"e-bd-1", "e-bd-2"}, wantTotal: 3},
+		{name: "second page", difficulty: entity.DifficultyEasy, page: 2, limit: 2, wantIDs: []string{"e-bd-3"}, wantTotal: 3},
+		{name: "answers included", difficulty: entity.DifficultyEasy, includeAnswer: true, allowAnswer: true, page: 1, limit: 1, wantIDs: []string{"e-bd-1"}, wantTotal: 3},
+		{name: "answers stripped by the guard", difficulty: entity.DifficultyEasy, includeAnswer: true, page: 1, limit: 1, wantIDs: []string{"e-bd-1"}, wantTotal: 3},
+		{name: "default difficulty", page: 1, limit: 10, wantIDs: []string{"e-bd-1", "e-bd-2", "e-bd-3"}, wantTotal: 3},
+		{name: "past the end", difficulty: entity.DifficultyMedium, page: 3, limit: 10, wantTotal: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u.cfg.Config.Set("dyslexia.allow_include_answer", tt.allowAnswer)
+			templates, total, err := u.GetTemplates(context.Background(), tt.difficulty, tt.includeAnswer, tt.page, tt.limit)
+			if err != nil {
+				t.Fatalf("GetTemplates: %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", total, tt.wantTotal)
+			}
+			var ids []string
+			for _, tpl := range templates {
+				ids = append(ids, tpl.ID)
+				if hidden := tpl.CorrectWord == ""; hidden == (tt.includeAnswer && tt.allowAnswer) {
+					t.Errorf("template %s correct word %q with includeAnswer=%v", tpl.ID, tpl.CorrectWord, tt.includeAnswer)
+				}
+				if !slices.Equal(tpl.Distractors, []string{"dola", "bolb", "dolb"}) {
+					t.Errorf("template %s distractors = %q, want the parsed array", tpl.ID, tpl.Distractors)
+				}
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("template ids = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestCreateQuestion(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     entity.CreateQuestionRequest
+		wantErr string
+	}{
+		{
+			name: "valid",
+			req:  entity.CreateQuestionRequest{Options: []string{" bola ", "dola", "bolb"}, CorrectAnswer: "BOLA", Difficulty: entity.DifficultyEasy, TargetLetterPair: "B-D"},
+		},
+		{
+			name:    "options missing the correct answer",
+			req:     entity.CreateQuestionRequest{Options: []string{"dola", "bolb"}, CorrectAnswer: "bola", Difficulty: entity.DifficultyEasy, TargetLetterPair: "b-d"},
+			wantErr: "options must include the correct answer",
+		},
+		{
+			name:    "duplicate options",
+			req:     entity.CreateQuestionRequest{Options: []string{"bola", "Bola", "dola"}, CorrectAnswer: "bola", Difficulty: entity.DifficultyEasy, TargetLetterPair: "b-d"},
+			wantErr: "options must be unique: Bola",
+		},
+		{
+			name:    "unknown letter pair",
+			req:     entity.CreateQuestionRequest{Options: []string{"bola", "dola"}, CorrectAnswer: "bola", Difficulty: entity.DifficultyEasy, TargetLetterPair: "x-y"},
+			wantErr: "invalid target_letter_pair: x-y",
+		},
+		{
+			name:    "over-length option",
+			req:     entity.CreateQuestionRequest{Options: []string{"bola", strings.Repeat("d", 21)}, CorrectAnswer: "bola", Difficulty: entity.DifficultyEasy, TargetLetterPair: "b-d"},
+			wantErr: `option "dddddddddddddddddddd..." has 21 characters, more than the maximum 20`,
+		},
+		{
+			name:    "non-letter option",
+			req:     entity.CreateQuestionRequest{Options: []string{"bola", "d0la"}, CorrectAnswer: "bola", Difficulty: entity.DifficultyEasy, TargetLetterPair: "b-d"},
+			wantErr: `option "d0la" contains '0', only letters are allowed`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			u := newTestUsecase(t, repo)
+
+			q, err := u.CreateQuestion(context.Background(), tt.req)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				if len(repo.questions) != 0 {
+					t.Errorf("rejected question was saved")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateQuestion: %v", err)
+			}
+			saved, ok := repo.questions[q.ID]
+			if !ok {
+				t.Fatalf("question %s not saved", q.ID)
+			}
+			if saved.GeneratedBy != "manual" || saved.TargetLetterPair != "b-d" || saved.TargetLetter != "b" || saved.Options != `["bola","dola","bolb"]` || saved.CorrectAnswer != "BOLA" {
+				t.Errorf("saved = %+v", saved)
+			}
+		})
+	}
+}
+
+// A seeded template is served as a question, stored once and reused with the same id afterwards
+func TestGenerateFromTemplate(t *testing.T) {
+	repo := newFakeRepo()
+	repo.templates["e-bd-1"] = &internalEntity.QuestionBankTemplate{
+		TemplateID: "e-bd-1", Difficulty: "easy", TargetLetterPair: "b-d", TargetLetter: "b",
+		CorrectWord: "bola", Distractors: `["dola","bolb","dolb"]`,
+	}
+	u := newTestUsecase(t, repo)
+	u.cfg.Config.Set("dyslexia.allow_include_answer", true)
+
+	tests := []struct {
+		name       string
+		templateID string
+		difficulty entity.Difficulty
+		wantErr    string
+	}{
+		{name: "first serve", templateID: "e-bd-1"},
+		{name: "reuse with matching difficulty", templateID: "e-bd-1", difficulty: entity.DifficultyEasy},
+		{name: "difficulty mismatch", templateID: "e-bd-1", difficulty: entity.DifficultyHard, wantErr: "is easy, not hard"},
+		{name: "unknown template", templateID: "x-1", wantErr: "template not found"},
+	}
+	var firstID string
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := u.GenerateFromTemplate(context.Background(), tt.templateID, tt.difficulty, true, "")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateFromTemplate: %v", err)
+			}
+			if firstID == "" {
+				firstID = q.ID
+			}
+			sorted := slices.Sorted(slices.Values(q.Options))
+			if q.ID != firstID || q.Answer != "bola" || q.TargetLetterPair != "b-d" || !slices.Equal(sorted, []string{"bola", "bolb", "dola", "dolb"}) {
+				t.Errorf("question = %+v, want %s answering bola with the template options", q, firstID)
+			}
+		})
+	}
+	if len(repo.questions) != 1 {
+		t.Errorf("stored questions = %d, want the template question once", len(repo.questions))
+	}
+}
+
+// Template questions go through the serve log like generated ones, so the session excludes them later
+func TestGenerateFromTemplateLogsServe(t *testing.T) {
+	tests := []struct {
+		name      string
+		sessionID string
+	}{
+		{name: "first serve creates the question", sessionID: "sess-1"},
+		{name: "reused question is logged again", sessionID: "sess-2"},
+	}
+	repo := newFakeRepo()
+	repo.templates["tpl-1"] = &internalEntity.QuestionBankTemplate{
+		TemplateID: "tpl-1", Difficulty: "easy", TargetLetterPair: "b-d", TargetLetter: "b",
+		CorrectWord: "bola", Distractors: `["dola","bolb","dolb"]`,
+	}
+	u := newTestUsecase(t, repo)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := u.GenerateFromTemplate(context.Background(), "tpl-1", "", false, tt.sessionID)
+			if err != nil {
+				t.Fatalf("GenerateFromTemplate: %v", err)
+			}
+			logs, err := repo.FindServeLogsBySessionID(nil, tt.sessionID)
+			if err != nil {
+				t.Fatalf("FindServeLogsBySessionID: %v", err)
+			}
+			if len(logs) != 1 || logs[0].QuestionID != q.ID {
+				t.Fatalf("serve logs = %+v, want one for %s", logs, q.ID)
+			}
+			if excluded := u.sessionUsedQuestionIDs(context.Background(), tt.sessionID); !slices.Contains(excluded, q.ID) {
+				t.Errorf("session %s excludes %v, want %s", tt.sessionID, excluded, q.ID)
+			}
+		})
+	}
+	if len(repo.questions) != 1 {
+		t.Errorf("stored questions = %d, want the template question once", len(repo.questions))
+	}
+}
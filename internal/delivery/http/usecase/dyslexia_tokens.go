@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"unicode/utf8"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultChatContextTokens = 6000
+	tokensPerMessageOverhead = 4
+)
+
+// estimateTokens approximates token usage (~4 characters per token plus per-message overhead)
+func estimateTokens(messages []openai.ChatCompletionMessage) int {
+	total := 0
+	for _, msg := range messages {
+		total += utf8.RuneCountInString(msg.Content)/4 + tokensPerMessageOverhead
+	}
+	return total
+}
+
+// truncateChatHistory drops the oldest history messages until the estimate fits budget.
+// messages[0] (system context) and the last message (current user message) are always kept.
+func truncateChatHistory(messages []openai.ChatCompletionMessage, budget int) []openai.ChatCompletionMessage {
+	if budget <= 0 || len(messages) <= 2 {
+		return messages
+	}
+
+	system := messages[0]
+	history := messages[1 : len(messages)-1]
+	latest := messages[len(messages)-1]
+
+	used := estimateTokens([]openai.ChatCompletionMessage{system, latest}) + estimateTokens(history)
+	dropped := 0
+	for dropped < len(history) && used > budget {
+		used -= estimateTokens(history[dropped : dropped+1])
+		dropped++
+	}
+
+	truncated := make([]openai.ChatCompletionMessage, 0, len(history)-dropped+2)
+	truncated = append(truncated, system)
+	truncated = append(truncated, history[dropped:]...)
+	return append(truncated, latest)
+}
+
+// chatContextBudget returns chat.max_context_tokens (default 6000)
+func (u *dyslexiaQuestionUsecase) chatContextBudget() int {
+	if u.cfg.Config.IsSet("chat.max_context_tokens") {
+		return u.cfg.Config.GetInt("chat.max_context_tokens")
+	}
+	return defaultChatContextTokens
+}
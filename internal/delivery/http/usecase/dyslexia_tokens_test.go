@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents []string
+		want     int
+	}{
+		{name: "no messages", want: 0},
+		{name: "overhead only", contents: []string{""}, want: tokensPerMessageOverhead},
+		{name: "four characters per token", contents: []string{strings.Repeat("a", 40), "abc"}, want: 10 + 0 + 2*tokensPerMessageOverhead},
+		{name: "counts runes, not bytes", contents: []string{strings.Repeat("ñ", 8)}, want: 2 + tokensPerMessageOverhead},
+	}
+	for _, tt := range tests {
+		messages := make([]openai.ChatCompletionMessage, len(tt.contents))
+		for i, c := range tt.contents {
+			messages[i] = openai.ChatCompletionMessage{Content: c}
+		}
+		if got := estimateTokens(messages); got != tt.want {
+			t.Errorf("%s: estimateTokens = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// The oldest history goes first; the system context and the current message always stay
+func TestTruncateChatHistory(t *testing.T) {
+	// Every message is 40 characters: 10 tokens plus the overhead, 14 in total
+	message := func(name string) openai.ChatCompletionMessage {
+		return openai.ChatCompletionMessage{Content: fmt.Sprintf("%-40s", name)}
+	}
+	full := []openai.ChatCompletionMessage{message("system"), message("h1"), message("h2"), message("h3"), message("h4"), message("latest")}
+
+	tests := []struct {
+		name     string
+		messages []openai.ChatCompletionMessage
+		budget   int
+		want     []string
+	}{
+		{name: "no budget keeps everything", messages: full, budget: 0, want: []string{"system", "h1", "h2", "h3", "h4", "latest"}},
+		{name: "fits exactly", messages: full, budget: 84, want: []string{"system", "h1", "h2", "h3", "h4", "latest"}},
+		{name: "one token over drops the oldest", messages: full, budget: 83, want: []string{"system", "h2", "h3", "h4", "latest"}},
+		{name: "drops until it fits", messages: full, budget: 42, want: []string{"system", "h4", "latest"}},
+		{name: "system and latest survive any budget", messages: full, budget: 1, want: []string{"system", "latest"}},
+		{name: "nothing to drop", messages: full[:2], budget: 1, want: []string{"system", "h1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateChatHistory(tt.messages, tt.budget)
+			names := make([]string, len(got))
+			for i, m := range got {
+				names[i] = strings.TrimSpace(m.Content)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("truncateChatHistory(budget %d) = %v, want %v", tt.budget, names, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatContextBudget(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	if got := u.chatContextBudget(); got != defaultChatContextTokens {
+		t.Errorf("default budget = %d, want %d", got, defaultChatContextTokens)
+	}
+	u.cfg.Config.Set("chat.max_context_tokens", 0)
+	if got := u.chatContextBudget(); got != 0 {
+		t.Errorf("explicit 0 (no truncation) = %d, want 0", got)
+	}
+}
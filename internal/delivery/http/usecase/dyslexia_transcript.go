@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/repository"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+const transcriptTimeFormat = "2006-01-02 15:04 MST"
+
+// GetChatTranscript renders the whole chat of a session, oldest first, as a downloadable markdown (default)
+// or plain-text document. A session without messages yields a transcript that says so rather than an error.
+func (u *dyslexiaQuestionUsecase) GetChatTranscript(ctx context.Context, sessionID string, format string) (*entity.ChatTranscript, error) {
+	if format == "" {
+		format = entity.TranscriptFormatMarkdown
+	}
+	if format != entity.TranscriptFormatMarkdown && format != entity.TranscriptFormatText {
+		return nil, fmt.Errorf("%w: unsupported transcript format %q", ErrInvalidInput, format)
+	}
+
+	messages, err := u.cfg.Repository.FindChatMessagesBySessionID(u.dbWithContext(ctx), sessionID, 0, repository.ChatHistoryOldestFirst)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch chat history: %w", err)
+	}
+
+	var b strings.Builder
+	markdown := format == entity.TranscriptFormatMarkdown
+	if markdown {
+		fmt.Fprintf(&b, "# Transkrip Chat\n\nSession: `%s`\n\n", sessionID)
+	} else {
+		fmt.Fprintf(&b, "Transkrip Chat\nSession: %s\n\n", sessionID)
+	}
+	if len(messages) == 0 {
+		b.WriteString("Belum ada percakapan pada session ini.\n")
+	}
+	for _, msg := range messages {
+		label := transcriptRoleLabel(msg)
+		at := msg.CreatedAt.UTC().Format(transcriptTimeFormat)
+		if markdown {
+			fmt.Fprintf(&b, "**%s** — _%s_\n\n%s\n\n", label, at, strings.TrimSpace(msg.Message))
+		} else {
+			fmt.Fprintf(&b, "[%s] %s:\n%s\n\n", at, label, strings.TrimSpace(msg.Message))
+		}
+	}
+
+	transcript := &entity.ChatTranscript{
+		Filename:    "chat-" + sessionID + "." + format,
+		ContentType: "text/plain; charset=utf-8",
+		Body:        []byte(b.String()),
+	}
+	if markdown {
+		transcript.ContentType = "text/markdown; charset=utf-8"
+	}
+	return transcript, nil
+}
+
+// transcriptRoleLabel names the speaker of a chat message for parents reading the transcript
+func transcriptRoleLabel(msg internalEntity.ChatMessage) string {
+	switch {
+	case msg.Role == "user":
+		return "Pengguna"
+	case msg.Role == "system":
+		return "Sistem"
+	case msg.Kind == internalEntity.ChatMessageKindFeedback:
+		return "Asisten (hasil analisis)"
+	default:
+		return "Asisten"
+	}
+}
@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+func TestGetChatTranscript(t *testing.T) {
+	at := time.Date(2026, 3, 4, 5, 6, 0, 0, time.UTC)
+	messages := []internalEntity.ChatMessage{
+		{SessionID: "sess-1", Role: "assistant", Kind: internalEntity.ChatMessageKindFeedback, Message: "Hasil analisis sesi.", CreatedAt: at},
+		{SessionID: "sess-1", Role: "user", Kind: internalEntity.ChatMessageKindChat, Message: "  Kenapa b dan d tertukar?  ", CreatedAt: at.Add(time.Minute)},
+		{SessionID: "sess-1", Role: "assistant", Kind: internalEntity.ChatMessageKindChat, Message: "Karena bentuknya mirip.", CreatedAt: at.Add(2 * time.Minute)},
+		{SessionID: "sess-2", Role: "user", Kind: internalEntity.ChatMessageKindChat, Message: "sesi lain", CreatedAt: at},
+	}
+	tests := []struct {
+		name            string
+		sessionID       string
+		format          string
+		wantFilename    string
+		wantContentType string
+		wantBody        []string // in order
+		unwantBody      []string
+	}{
+		{
+			name: "markdown by default", sessionID: "sess-1",
+			wantFilename: "chat-sess-1.md", wantContentType: "text/markdown; charset=utf-8",
+			wantBody: []string{
+				"# Transkrip Chat\n\nSession: `sess-1`\n\n",
+				"**Asisten (hasil analisis)** — _2026-03-04 05:06 UTC_\n\nHasil analisis sesi.\n\n",
+				"**Pengguna** — _2026-03-04 05:07 UTC_\n\nKenapa b dan d tertukar?\n\n",
+				"**Asisten** — _2026-03-04 05:08 UTC_\n\nKarena bentuknya mirip.\n\n",
+			},
+			unwantBody: []string{"sesi lain"},
+		},
+		{
+			name: "plain text", sessionID: "sess-1", format: entity.TranscriptFormatText,
+			wantFilename: "chat-sess-1.txt", wantContentType: "text/plain; charset=utf-8",
+			wantBody: []string{
+				"Transkrip Chat\nSession: sess-1\n\n",
+				"[2026-03-04 05:06 UTC] Asisten (hasil analisis):\nHasil analisis sesi.\n\n",
+				"[2026-03-04 05:07 UTC] Pengguna:\nKenapa b dan d tertukar?\n\n",
+				"[2026-03-04 05:08 UTC] Asisten:\nKarena bentuknya mirip.\n\n",
+			},
+			unwantBody: []string{"**", "#", "sesi lain"},
+		},
+		{
+			name: "empty session", sessionID: "sess-empty", format: entity.TranscriptFormatMarkdown,
+			wantFilename: "chat-sess-empty.md", wantContentType: "text/markdown; charset=utf-8",
+			wantBody: []string{"Session: `sess-empty`", "Belum ada percakapan pada session ini.\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.chats = messages
+			u := newTestUsecase(t, repo)
+
+			transcript, err := u.GetChatTranscript(context.Background(), tt.sessionID, tt.format)
+			if err != nil {
+				t.Fatalf("GetChatTranscript: %v", err)
+			}
+			if transcript.Filename != tt.wantFilename || transcript.ContentType != tt.wantContentType {
+				t.Errorf("filename/content type = %q/%q, want %q/%q", transcript.Filename, transcript.ContentType, tt.wantFilename, tt.wantContentType)
+			}
+			body := string(transcript.Body)
+			rest := body
+			for _, want := range tt.wantBody {
+				i := strings.Index(rest, want)
+				if i < 0 {
+					t.Fatalf("body missing %q (or out of order):\n%s", want, body)
+				}
+				rest = rest[i+len(want):]
+			}
+			for _, unwanted := range tt.unwantBody {
+				if strings.Contains(body, unwanted) {
+					t.Errorf("body contains %q:\n%s", unwanted, body)
+				}
+			}
+		})
+	}
+}
+
+func TestGetChatTranscriptRejectsUnknownFormat(t *testing.T) {
+	u := newTestUsecase(t, newFakeRepo())
+	if _, err := u.GetChatTranscript(context.Background(), "sess-1", "pdf"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("error = %v, want ErrInvalidInput", err)
+	}
+}
@@ -0,0 +1,11 @@
+package usecase
+
+import "errors"
+
+// Sentinel errors classify usecase failures so handlers can pick a status code. Wrap them with %w to keep
+// the detail, e.g. fmt.Errorf("%w: session %s", ErrNotFound, id); unclassified errors are internal failures.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrInvalidInput = errors.New("invalid input")
+	ErrUpstream     = errors.New("upstream service failed")
+)
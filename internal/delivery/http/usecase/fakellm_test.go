@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/evandrarf/dinacom-be/internal/pkg/llm"
+)
+
+// fakeLLM serves the OpenAI chat completion endpoint from reply, which gets the prompt and returns the
+// completion text or an HTTP status >= 400 to fail the call
+type fakeLLM struct {
+	calls     atomic.Int64
+	lastModel atomic.Value // model named in the latest request
+	jsonMode  atomic.Bool  // whether the latest request asked for response_format=json_object
+	messages  atomic.Value // []string, the content of every message in the latest request
+	reply     func(prompt string) (string, int)
+}
+
+// newFakeLLM returns a client pointed at an in-process fake provider
+func newFakeLLM(t *testing.T, reply func(prompt string) (string, int)) (*llm.GeminiClient, *fakeLLM) {
+	t.Helper()
+	fake := &fakeLLM{reply: reply}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.calls.Add(1)
+		var req struct {
+			Model          string `json:"model"`
+			ResponseFormat *struct {
+				Type string `json:"type"`
+			} `json:"response_format"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		fake.lastModel.Store(req.Model)
+		fake.jsonMode.Store(req.ResponseFormat != nil && req.ResponseFormat.Type == "json_object")
+		contents := make([]string, len(req.Messages))
+		for i, m := range req.Messages {
+			contents[i] = m.Content
+		}
+		fake.messages.Store(contents)
+		prompt := ""
+		if len(req.Messages) > 0 {
+			prompt = req.Messages[len(req.Messages)-1].Content
+		}
+
+		text, status := fake.reply(prompt)
+		w.Header().Set("Content-Type", "application/json")
+		if status >= 400 {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"error":{"message":"fake failure","type":"server_error"}}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": text}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return llm.NewGeminiClient("test-key", "test-model", server.URL), fake
+}
+
+// replyWith answers every prompt with text
+func replyWith(text string) func(string) (string, int) {
+	return func(string) (string, int) { return text, http.StatusOK }
+}
@@ -0,0 +1,898 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
+	"github.com/evandrarf/dinacom-be/internal/delivery/http/repository"
+	internalEntity "github.com/evandrarf/dinacom-be/internal/entity"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// fakeRepo keeps the rows the usecase tests touch in memory. Methods a test does not stub panic through
+// the nil embedded interface, which points at the missing stub right away.
+type fakeRepo struct {
+	repository.DyslexiaQuestionRepository
+
+	mu        sync.Mutex
+	questions map[string]*internalEntity.GeneratedQuestion
+	answers   []internalEntity.UserAnswer
+	sessions  map[string]*internalEntity.Session
+	chats     []internalEntity.ChatMessage
+	templates map[string]*internalEntity.QuestionBankTemplate
+	caches    map[string]*internalEntity.SessionAnalysisCache
+	usage     map[string]int // user|date|kind -> LLM calls
+	serveLogs []internalEntity.QuestionServeLog
+	chatErr   error // returned by CreateChatMessage when set
+	gradeErr  error // returned by UpdateAnswerGrade when set
+	prefs     map[string]*internalEntity.UserPreferences
+
+	questionLookups int      // FindGeneratedByQuestionID(s) calls
+	idleSweeps      int      // FindIdleSessions calls
+	calls           []string // session writes in order, e.g. "lock user-1"
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{
+		questions: map[string]*internalEntity.GeneratedQuestion{},
+		sessions:  map[string]*internalEntity.Session{},
+		templates: map[string]*internalEntity.QuestionBankTemplate{},
+		caches:    map[string]*internalEntity.SessionAnalysisCache{},
+		usage:     map[string]int{},
+		prefs:     map[string]*internalEntity.UserPreferences{},
+	}
+}
+
+func (r *fakeRepo) FindGeneratedByQuestionID(_ *gorm.DB, questionID string) (*internalEntity.GeneratedQuestion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.questionLookups++
+	if q, ok := r.questions[questionID]; ok {
+		copied := *q
+		return &copied, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepo) FindGeneratedByQuestionIDs(_ *gorm.DB, questionIDs []string) ([]internalEntity.GeneratedQuestion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.questionLookups++
+	var questions []internalEntity.GeneratedQuestion
+	for _, id := range questionIDs {
+		if q, ok := r.questions[id]; ok {
+			questions = append(questions, *q)
+		}
+	}
+	return questions, nil
+}
+
+func (r *fakeRepo) CreateGenerated(_ *gorm.DB, question *internalEntity.GeneratedQuestion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.questions[question.QuestionID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	copied := *question
+	r.questions[question.QuestionID] = &copied
+	return nil
+}
+
+func (r *fakeRepo) FindTemplateByTemplateID(_ *gorm.DB, templateID string) (*internalEntity.QuestionBankTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if tpl, ok := r.templates[templateID]; ok {
+		copied := *tpl
+		return &copied, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepo) FindGeneratedByTemplateID(_ *gorm.DB, templateID, generatedBy string) (*internalEntity.GeneratedQuestion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, q := range r.questions {
+		if q.TemplateID == templateID && q.GeneratedBy == generatedBy {
+			copied := *q
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepo) FindExistingAnswer(_ *gorm.DB, userID, sessionID, questionID string) (*internalEntity.UserAnswer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.answers {
+		a := r.answers[i]
+		if a.UserID == userID && a.SessionID == sessionID && a.QuestionID == questionID {
+			return &a, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepo) CountAnswerAttempts(_ *gorm.DB, userID, sessionID, questionID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, a := range r.answers {
+		if a.UserID == userID && a.SessionID == sessionID && a.QuestionID == questionID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeRepo) CreateUserAnswer(_ *gorm.DB, answer *internalEntity.UserAnswer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	answer.ID = uint(len(r.answers) + 1)
+	if answer.AnsweredAt.IsZero() {
+		answer.AnsweredAt = time.Now() // autoCreateTime
+	}
+	r.answers = append(r.answers, *answer)
+	return nil
+}
+
+// CorrectUserAnswer keeps the first submit time, like the repository's update by id
+func (r *fakeRepo) CorrectUserAnswer(_ *gorm.DB, answer *internalEntity.UserAnswer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.answers {
+		if r.answers[i].ID == answer.ID {
+			answeredAt := r.answers[i].AnsweredAt
+			r.answers[i] = *answer
+			r.answers[i].AnsweredAt = answeredAt
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepo) FindUserAnswersBySessionID(_ *gorm.DB, sessionID string) ([]internalEntity.UserAnswer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var answers []internalEntity.UserAnswer
+	for _, a := range r.answers {
+		if a.SessionID == sessionID && !a.DeletedAt.Valid {
+			answers = append(answers, a)
+		}
+	}
+	// Newest first, like the real repository
+	slices.SortStableFunc(answers, func(a, b internalEntity.UserAnswer) int { return b.AnsweredAt.Compare(a.AnsweredAt) })
+	return answers, nil
+}
+
+func (r *fakeRepo) FindUserAnswersByQuestionID(_ *gorm.DB, questionID string) ([]internalEntity.UserAnswer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var answers []internalEntity.UserAnswer
+	for _, a := range r.answers {
+		if a.QuestionID == questionID && !a.DeletedAt.Valid {
+			answers = append(answers, a)
+		}
+	}
+	return answers, nil
+}
+
+func (r *fakeRepo) UpdateAnswerGrade(_ *gorm.DB, id uint, correctAnswer string, isCorrect bool, partialCredit float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.gradeErr != nil {
+		return r.gradeErr
+	}
+	for i := range r.answers {
+		if r.answers[i].ID == id {
+			r.answers[i].CorrectAnswer, r.answers[i].IsCorrect, r.answers[i].PartialCredit = correctAnswer, isCorrect, partialCredit
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepo) InvalidateAnalysisCaches(_ *gorm.DB, sessionIDs []string, at time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var invalidated int64
+	for _, id := range sessionIDs {
+		if c, ok := r.caches[id]; ok {
+			c.InvalidatedAt = &at
+			invalidated++
+		}
+	}
+	return invalidated, nil
+}
+
+func (r *fakeRepo) FindSessionBySessionID(_ *gorm.DB, sessionID string) (*internalEntity.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[sessionID]; ok && !s.DeletedAt.Valid {
+		copied := *s
+		return &copied, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepo) FindRandomGeneratedByDifficulty(_ *gorm.DB, difficulty string, letterPairs []string, limit int, excludeIDs []string, _ time.Time) ([]internalEntity.GeneratedQuestion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var questions []internalEntity.GeneratedQuestion
+	for _, q := range r.questions {
+		if q.Difficulty == difficulty && matchesPairs(q, letterPairs) && !slices.Contains(excludeIDs, q.QuestionID) && len(questions) < limit {
+			questions = append(questions, *q)
+		}
+	}
+	return questions, nil
+}
+
+// FindOrderedGeneratedByDifficulty orders by question id, standing in for insertion order
+func (r *fakeRepo) FindOrderedGeneratedByDifficulty(_ *gorm.DB, difficulty string, letterPairs []string, limit int, excludeIDs []string) ([]internalEntity.GeneratedQuestion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var questions []internalEntity.GeneratedQuestion
+	for _, id := range slices.Sorted(maps.Keys(r.questions)) {
+		q := r.questions[id]
+		if q.Difficulty == difficulty && matchesPairs(q, letterPairs) && !slices.Contains(excludeIDs, q.QuestionID) && len(questions) < limit {
+			questions = append(questions, *q)
+		}
+	}
+	return questions, nil
+}
+
+func matchesPairs(q *internalEntity.GeneratedQuestion, letterPairs []string) bool {
+	return len(letterPairs) == 0 || slices.Contains(letterPairs, q.TargetLetterPair)
+}
+
+func (r *fakeRepo) IncrementUsageCount(*gorm.DB, string) error { return nil }
+
+// templatesByDifficulty returns the difficulty's templates ordered by template id, like the repository
+func (r *fakeRepo) templatesByDifficulty(difficulty string) []internalEntity.QuestionBankTemplate {
+	var templates []internalEntity.QuestionBankTemplate
+	for _, id := range slices.Sorted(maps.Keys(r.templates)) {
+		if tpl := r.templates[id]; tpl.Difficulty == difficulty {
+			templates = append(templates, *tpl)
+		}
+	}
+	return templates
+}
+
+func (r *fakeRepo) CountTemplatesByDifficulty(_ *gorm.DB, difficulty string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(len(r.templatesByDifficulty(difficulty))), nil
+}
+
+func (r *fakeRepo) FindTemplatesByDifficultyPaginated(_ *gorm.DB, difficulty string, offset, limit int) ([]internalEntity.QuestionBankTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	templates := r.templatesByDifficulty(difficulty)
+	if offset >= len(templates) {
+		return nil, nil
+	}
+	return templates[offset:min(offset+limit, len(templates))], nil
+}
+
+func (r *fakeRepo) FindAnalysisCacheBySessionID(_ *gorm.DB, sessionID string) (*internalEntity.SessionAnalysisCache, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.caches[sessionID]; ok && !c.DeletedAt.Valid {
+		copied := *c
+		return &copied, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepo) CreateOrUpdateAnalysisCache(_ *gorm.DB, cache *internalEntity.SessionAnalysisCache) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *cache
+	copied.UpdatedAt = time.Now()
+	r.caches[cache.SessionID] = &copied
+	return nil
+}
+
+func (r *fakeRepo) FindAnalysisCachesBySessionIDs(_ *gorm.DB, sessionIDs []string) ([]internalEntity.SessionAnalysisCache, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var caches []internalEntity.SessionAnalysisCache
+	for _, id := range sessionIDs {
+		if c, ok := r.caches[id]; ok && !c.DeletedAt.Valid {
+			caches = append(caches, *c)
+		}
+	}
+	return caches, nil
+}
+
+func (r *fakeRepo) UpdateAnalysisCacheScore(_ *gorm.DB, sessionID string, score float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.caches[sessionID]; ok {
+		c.SessionScore = score
+	}
+	return nil
+}
+
+// FindAnalysisCacheByUserID returns every cache; tests keep a single user per repo
+func (r *fakeRepo) FindAnalysisCacheByUserID(_ *gorm.DB, _ string, limit int) ([]internalEntity.SessionAnalysisCache, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var caches []internalEntity.SessionAnalysisCache
+	for _, c := range r.caches {
+		caches = append(caches, *c)
+	}
+	if limit > 0 && len(caches) > limit {
+		caches = caches[:limit]
+	}
+	return caches, nil
+}
+
+func (r *fakeRepo) CreateChatMessage(_ *gorm.DB, message *internalEntity.ChatMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.chatErr != nil {
+		return r.chatErr
+	}
+	r.chats = append(r.chats, *message)
+	return nil
+}
+
+// FindChatMessagesBySessionID keeps insertion order, which the fake treats as chronological
+func (r *fakeRepo) FindChatMessagesBySessionID(_ *gorm.DB, sessionID string, limit int, order repository.ChatHistoryOrder) ([]internalEntity.ChatMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var messages []internalEntity.ChatMessage
+	for _, m := range r.chats {
+		if m.SessionID == sessionID && !m.DeletedAt.Valid {
+			messages = append(messages, m)
+		}
+	}
+	if limit > 0 && len(messages) > limit {
+		if order == repository.ChatHistoryRecentFirst {
+			messages = messages[len(messages)-limit:]
+		} else {
+			messages = messages[:limit]
+		}
+	}
+	return messages, nil
+}
+
+func (r *fakeRepo) HasFeedbackMessage(_ *gorm.DB, sessionID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.chats {
+		if m.SessionID == sessionID && m.Kind == internalEntity.ChatMessageKindFeedback && !m.DeletedAt.Valid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRepo) FindChatMessagesPaginated(db *gorm.DB, sessionID string, offset, limit int) ([]internalEntity.ChatMessage, int64, error) {
+	messages, _ := r.FindChatMessagesBySessionID(db, sessionID, 0, repository.ChatHistoryOldestFirst)
+	if offset >= len(messages) {
+		return nil, int64(len(messages)), nil
+	}
+	return messages[offset:min(offset+limit, len(messages))], int64(len(messages)), nil
+}
+
+// inRange mirrors the repository's answered_at window: from inclusive, to exclusive, zero means unbounded
+func inRange(at, from, to time.Time) bool {
+	return (from.IsZero() || !at.Before(from)) && (to.IsZero() || at.Before(to))
+}
+
+func (r *fakeRepo) AggregateAccuracyByUsers(_ *gorm.DB, userIDs []string, from, to time.Time) ([]repository.UserAccuracyRow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var rows []repository.UserAccuracyRow
+	for _, id := range userIDs {
+		row := repository.UserAccuracyRow{UserID: id}
+		for _, a := range r.answers {
+			if a.UserID == id && inRange(a.AnsweredAt, from, to) {
+				row.Total++
+				if a.IsCorrect {
+					row.Correct++
+				}
+			}
+		}
+		if row.Total > 0 {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func (r *fakeRepo) AggregateLetterPairStatsByUsers(_ *gorm.DB, userIDs []string, from, to time.Time) ([]repository.LetterPairStatRow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := map[string]*repository.LetterPairStatRow{}
+	for _, a := range r.answers {
+		q, ok := r.questions[a.QuestionID]
+		if !ok || q.TargetLetterPair == "" || !slices.Contains(userIDs, a.UserID) || !inRange(a.AnsweredAt, from, to) {
+			continue
+		}
+		row := stats[q.TargetLetterPair]
+		if row == nil {
+			row = &repository.LetterPairStatRow{LetterPair: q.TargetLetterPair}
+			stats[q.TargetLetterPair] = row
+		}
+		row.Total++
+		if !a.IsCorrect {
+			row.Errors++
+		}
+	}
+	var rows []repository.LetterPairStatRow
+	for _, pair := range slices.Sorted(maps.Keys(stats)) {
+		rows = append(rows, *stats[pair])
+	}
+	return rows, nil
+}
+
+// AggregateLetterPairStats counts every user's answers per pair, optionally for one difficulty
+func (r *fakeRepo) AggregateLetterPairStats(_ *gorm.DB, difficulty string) ([]repository.LetterPairStatRow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := map[string]*repository.LetterPairStatRow{}
+	for _, a := range r.answers {
+		q, ok := r.questions[a.QuestionID]
+		if !ok || q.TargetLetterPair == "" || a.Skipped || (difficulty != "" && !strings.EqualFold(a.Difficulty, difficulty)) {
+			continue
+		}
+		row := stats[q.TargetLetterPair]
+		if row == nil {
+			row = &repository.LetterPairStatRow{LetterPair: q.TargetLetterPair}
+			stats[q.TargetLetterPair] = row
+		}
+		row.Total++
+		if !a.IsCorrect {
+			row.Errors++
+		}
+	}
+	var rows []repository.LetterPairStatRow
+	for _, pair := range slices.Sorted(maps.Keys(stats)) {
+		rows = append(rows, *stats[pair])
+	}
+	return rows, nil
+}
+
+// AggregateAnswersByQuestion groups like the SQL: upper-cased trimmed answer, skipped and stored grade
+func (r *fakeRepo) AggregateAnswersByQuestion(_ *gorm.DB, questionID string) ([]repository.AnswerCountRow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var rows []repository.AnswerCountRow
+	for _, a := range r.answers {
+		if a.QuestionID != questionID {
+			continue
+		}
+		row := repository.AnswerCountRow{Answer: strings.ToUpper(strings.TrimSpace(a.UserAnswer)), Skipped: a.Skipped, IsCorrect: a.IsCorrect}
+		i := slices.IndexFunc(rows, func(r repository.AnswerCountRow) bool {
+			return r.Answer == row.Answer && r.Skipped == row.Skipped && r.IsCorrect == row.IsCorrect
+		})
+		if i < 0 {
+			rows = append(rows, row)
+			i = len(rows) - 1
+		}
+		rows[i].Total++
+	}
+	return rows, nil
+}
+
+// FindGeneratedPaginated filters by generated_by prefix like the LIKE query, ordered by question id
+func (r *fakeRepo) FindGeneratedPaginated(_ *gorm.DB, generatedBy, difficulty, letterPair string, offset, limit int) ([]internalEntity.GeneratedQuestion, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found []internalEntity.GeneratedQuestion
+	for _, q := range r.questions {
+		if strings.HasPrefix(q.GeneratedBy, generatedBy) && (difficulty == "" || q.Difficulty == difficulty) && (letterPair == "" || q.TargetLetterPair == letterPair) {
+			found = append(found, *q)
+		}
+	}
+	slices.SortFunc(found, func(a, b internalEntity.GeneratedQuestion) int { return strings.Compare(a.QuestionID, b.QuestionID) })
+	total := int64(len(found))
+	found = found[min(offset, len(found)):]
+	return found[:min(limit, len(found))], total, nil
+}
+
+// AggregateLetterPairTrendByUser groups answers by session and pair, oldest session first
+func (r *fakeRepo) AggregateLetterPairTrendByUser(_ *gorm.DB, userID string, pair string, from, to time.Time) ([]repository.LetterPairTrendRow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var rows []repository.LetterPairTrendRow
+	index := map[[2]string]int{}
+	for _, a := range r.answers {
+		q, ok := r.questions[a.QuestionID]
+		if !ok || q.TargetLetterPair == "" || a.UserID != userID || !inRange(a.AnsweredAt, from, to) || (pair != "" && q.TargetLetterPair != pair) {
+			continue
+		}
+		key := [2]string{a.SessionID, q.TargetLetterPair}
+		i, ok := index[key]
+		if !ok {
+			i = len(rows)
+			index[key] = i
+			rows = append(rows, repository.LetterPairTrendRow{SessionID: a.SessionID, LetterPair: q.TargetLetterPair, StartedAt: a.AnsweredAt})
+		}
+		if a.AnsweredAt.Before(rows[i].StartedAt) {
+			rows[i].StartedAt = a.AnsweredAt
+		}
+		rows[i].Total++
+		if a.IsCorrect {
+			rows[i].Correct++
+		}
+	}
+	slices.SortStableFunc(rows, func(a, b repository.LetterPairTrendRow) int { return a.StartedAt.Compare(b.StartedAt) })
+	return rows, nil
+}
+
+func (r *fakeRepo) AggregateDifficultyByUsers(_ *gorm.DB, userIDs []string, from, to time.Time) ([]repository.DifficultyCountRow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := map[string]int{}
+	for _, a := range r.answers {
+		if slices.Contains(userIDs, a.UserID) && inRange(a.AnsweredAt, from, to) {
+			counts[a.Difficulty]++
+		}
+	}
+	var rows []repository.DifficultyCountRow
+	for _, difficulty := range slices.Sorted(maps.Keys(counts)) {
+		rows = append(rows, repository.DifficultyCountRow{Difficulty: difficulty, Total: counts[difficulty]})
+	}
+	return rows, nil
+}
+
+func (r *fakeRepo) FindLatestServeLog(_ *gorm.DB, sessionID, questionID string) (*internalEntity.QuestionServeLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.serveLogs) - 1; i >= 0; i-- {
+		if l := r.serveLogs[i]; l.SessionID == sessionID && l.QuestionID == questionID {
+			return &l, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepo) FindUserPreferences(_ *gorm.DB, userID string) (*internalEntity.UserPreferences, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.prefs[userID]; ok {
+		copied := *p
+		return &copied, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepo) UpsertUserPreferences(_ *gorm.DB, prefs *internalEntity.UserPreferences) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *prefs
+	r.prefs[prefs.UserID] = &copied
+	return nil
+}
+
+// captureLog routes u's logger into a buffer for assertions on what was logged
+func captureLog(u *dyslexiaQuestionUsecase) *bytes.Buffer {
+	var buf bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&buf)
+	log.SetLevel(logrus.DebugLevel)
+	u.cfg.Log = log
+	return &buf
+}
+
+// dryRunDB builds SQL without a database connection; queries return no rows
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	return db
+}
+
+// newTestUsecase wires a usecase around repo with an empty config that tests adjust through cfg.Config
+func newTestUsecase(t *testing.T, repo repository.DyslexiaQuestionRepository) *dyslexiaQuestionUsecase {
+	t.Helper()
+	u := &dyslexiaQuestionUsecase{
+		cfg: DyslexiaQuestionConfig{
+			DB:         dryRunDB(t),
+			Repository: repo,
+			Config:     viper.New(),
+		},
+		rnd:               newSafeRand(1),
+		difficulties:      entity.DefaultDifficultyLevels,
+		defaultDifficulty: entity.DifficultyEasy,
+		defaultPatterns:   allLetterPairs,
+		reportLocks:       newKeyedMutex(),
+		now:               time.Now,
+	}
+	u.saveOutbox = newGeneratedOutbox(16, 0, 0, nil, func(entity.GeneratedQuestion, string, string, string) error { return nil })
+	t.Cleanup(func() {
+		_ = u.saveOutbox.Close(context.Background())
+		u.background.Wait()
+	})
+	return u
+}
+
+// ConsumeLLMUsage mirrors the conditional upsert: check and increment happen under one lock
+func (r *fakeRepo) ConsumeLLMUsage(_ *gorm.DB, userID, usageDate, kind string, amount, limit int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := userID + "|" + usageDate + "|" + kind
+	if r.usage[key]+amount > limit {
+		return false, nil
+	}
+	r.usage[key] += amount
+	return true, nil
+}
+
+func (r *fakeRepo) GetLLMUsage(_ *gorm.DB, userID, usageDate, kind string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.usage[userID+"|"+usageDate+"|"+kind], nil
+}
+
+// userRows calls fn with the deleted_at of every row SoftDeleteUserData and RestoreUserData touch for userID;
+// answers come last, separately from the tables only an erasure soft-deletes
+func (r *fakeRepo) userRows(userID string, answers bool, fn func(deletedAt *gorm.DeletedAt, counter func(*repository.UserDataCounts) *int64)) {
+	sessionIDs := map[string]bool{}
+	for _, a := range r.answers {
+		if a.UserID == userID {
+			sessionIDs[a.SessionID] = true
+		}
+	}
+	for _, s := range r.sessions {
+		if s.UserID == userID {
+			sessionIDs[s.SessionID] = true
+			fn(&s.DeletedAt, func(c *repository.UserDataCounts) *int64 { return &c.Sessions })
+		}
+	}
+	for id, c := range r.caches {
+		if sessionIDs[id] {
+			fn(&c.DeletedAt, func(c *repository.UserDataCounts) *int64 { return &c.AnalysisCaches })
+		}
+	}
+	for i := range r.chats {
+		if sessionIDs[r.chats[i].SessionID] {
+			fn(&r.chats[i].DeletedAt, func(c *repository.UserDataCounts) *int64 { return &c.ChatMessages })
+		}
+	}
+	for i := range r.serveLogs {
+		if sessionIDs[r.serveLogs[i].SessionID] {
+			fn(&r.serveLogs[i].DeletedAt, func(c *repository.UserDataCounts) *int64 { return &c.ServeLogs })
+		}
+	}
+	if !answers {
+		return
+	}
+	for i := range r.answers {
+		if r.answers[i].UserID == userID {
+			fn(&r.answers[i].DeletedAt, func(c *repository.UserDataCounts) *int64 { return &c.Answers })
+		}
+	}
+}
+
+// SoftDeleteUserData stamps the user's live rows with deletedAt, like the repository's UPDATE
+func (r *fakeRepo) SoftDeleteUserData(_ *gorm.DB, userID string, deletedAt time.Time) (*repository.UserDataCounts, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := &repository.UserDataCounts{}
+	r.userRows(userID, true, func(d *gorm.DeletedAt, counter func(*repository.UserDataCounts) *int64) {
+		if !d.Valid {
+			*d = gorm.DeletedAt{Time: deletedAt, Valid: true}
+			*counter(counts)++
+		}
+	})
+	return counts, nil
+}
+
+// RestoreUserData mirrors the repository: the latest erasure at or after deletedSince is found on the
+// erasure-only tables, on answers only for users without a session row, and just its rows come back
+func (r *fakeRepo) RestoreUserData(_ *gorm.DB, userID string, deletedSince time.Time) (*repository.UserDataCounts, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var erasedAt time.Time
+	latest := func(d *gorm.DeletedAt, _ func(*repository.UserDataCounts) *int64) {
+		if d.Valid && !d.Time.Before(deletedSince) && d.Time.After(erasedAt) {
+			erasedAt = d.Time
+		}
+	}
+	r.userRows(userID, false, latest)
+	if erasedAt.IsZero() && !slices.ContainsFunc(slices.Collect(maps.Values(r.sessions)), func(s *internalEntity.Session) bool { return s.UserID == userID }) {
+		r.userRows(userID, true, latest)
+	}
+
+	counts := &repository.UserDataCounts{}
+	if erasedAt.IsZero() {
+		return counts, nil
+	}
+	r.userRows(userID, true, func(d *gorm.DeletedAt, counter func(*repository.UserDataCounts) *int64) {
+		if d.Valid && d.Time.Equal(erasedAt) {
+			*d = gorm.DeletedAt{}
+			*counter(counts)++
+		}
+	})
+	return counts, nil
+}
+
+func (r *fakeRepo) FindGeneratedByPairAndDifficulty(_ *gorm.DB, letterPair, difficulty string) ([]internalEntity.GeneratedQuestion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found []internalEntity.GeneratedQuestion
+	for _, q := range r.questions {
+		if q.TargetLetterPair == letterPair && (difficulty == "" || q.Difficulty == difficulty) {
+			found = append(found, *q)
+		}
+	}
+	slices.SortFunc(found, func(a, b internalEntity.GeneratedQuestion) int { return strings.Compare(a.QuestionID, b.QuestionID) })
+	return found, nil
+}
+
+func (r *fakeRepo) UpdateGeneratedOptions(_ *gorm.DB, questionID, options string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	q, ok := r.questions[questionID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	q.Options = options
+	return nil
+}
+
+func (r *fakeRepo) CreateServeLogs(_ *gorm.DB, logs []internalEntity.QuestionServeLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.serveLogs = append(r.serveLogs, logs...)
+	return nil
+}
+
+func (r *fakeRepo) FindServeLogsBySessionID(_ *gorm.DB, sessionID string) ([]internalEntity.QuestionServeLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var logs []internalEntity.QuestionServeLog
+	for _, l := range r.serveLogs {
+		if l.SessionID == sessionID && !l.DeletedAt.Valid {
+			logs = append(logs, l)
+		}
+	}
+	return logs, nil
+}
+
+// waitForServeLogs polls until sessionID has want serve log rows; logServedQuestions writes them async
+func waitForServeLogs(t *testing.T, repo *fakeRepo, sessionID string, want int) []internalEntity.QuestionServeLog {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		logs, _ := repo.FindServeLogsBySessionID(nil, sessionID)
+		if len(logs) >= want || time.Now().After(deadline) {
+			return logs
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (r *fakeRepo) LockUserSessions(_ *gorm.DB, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, "lock "+userID)
+	return nil
+}
+
+func (r *fakeRepo) CountActiveSessionsByUser(_ *gorm.DB, userID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, "count "+userID)
+	sessionIDs := map[string]bool{}
+	for _, s := range r.sessions {
+		if s.UserID == userID && !s.DeletedAt.Valid {
+			sessionIDs[s.SessionID] = true
+		}
+	}
+	for _, a := range r.answers {
+		if a.UserID == userID && !a.DeletedAt.Valid {
+			sessionIDs[a.SessionID] = true
+		}
+	}
+	var active int64
+	for id := range sessionIDs {
+		if s, ok := r.sessions[id]; ok && s.ExpiredAt != nil {
+			continue
+		}
+		if c, ok := r.caches[id]; !ok || c.DeletedAt.Valid {
+			active++
+		}
+	}
+	return active, nil
+}
+
+// FindIdleSessions mirrors the SQL: unreported, unexpired sessions whose latest session update or answer
+// is before idleSince, oldest first
+func (r *fakeRepo) FindIdleSessions(_ *gorm.DB, idleSince time.Time, limit int) ([]repository.IdleSessionRow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.idleSweeps++
+	last := map[string]*repository.IdleSessionRow{}
+	touch := func(sessionID, userID string, at time.Time) {
+		row, ok := last[sessionID]
+		if !ok {
+			row = &repository.IdleSessionRow{SessionID: sessionID, UserID: userID, LastActivity: at}
+			last[sessionID] = row
+		}
+		if at.After(row.LastActivity) {
+			row.LastActivity = at
+		}
+	}
+	for _, s := range r.sessions {
+		if !s.DeletedAt.Valid {
+			touch(s.SessionID, s.UserID, s.UpdatedAt)
+		}
+	}
+	for _, a := range r.answers {
+		if !a.DeletedAt.Valid {
+			touch(a.SessionID, a.UserID, a.AnsweredAt)
+		}
+	}
+
+	var rows []repository.IdleSessionRow
+	for id, row := range last {
+		if s, ok := r.sessions[id]; ok && s.ExpiredAt != nil {
+			continue
+		}
+		if c, ok := r.caches[id]; ok && !c.DeletedAt.Valid {
+			continue
+		}
+		if row.LastActivity.Before(idleSince) {
+			rows = append(rows, *row)
+		}
+	}
+	slices.SortFunc(rows, func(a, b repository.IdleSessionRow) int { return a.LastActivity.Compare(b.LastActivity) })
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+func (r *fakeRepo) ExpireSession(_ *gorm.DB, sessionID, userID string, expiredAt time.Time, pruneAnswers bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[sessionID]
+	if !ok {
+		session = &internalEntity.Session{SessionID: sessionID, UserID: userID}
+		r.sessions[sessionID] = session
+	}
+	session.ExpiredAt = &expiredAt
+	if !pruneAnswers {
+		return nil
+	}
+	deleted := gorm.DeletedAt{Time: expiredAt, Valid: true}
+	for i := range r.answers {
+		if r.answers[i].SessionID == sessionID {
+			r.answers[i].DeletedAt = deleted
+		}
+	}
+	for i := range r.serveLogs {
+		if r.serveLogs[i].SessionID == sessionID {
+			r.serveLogs[i].DeletedAt = deleted
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepo) CreateSessionIfAbsent(_ *gorm.DB, session *internalEntity.Session) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, "create "+session.SessionID)
+	if _, ok := r.sessions[session.SessionID]; ok {
+		return false, nil
+	}
+	copied := *session
+	r.sessions[session.SessionID] = &copied
+	return true, nil
+}
@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// txCounter records how the transactions opened on a txTestDB ended
+type txCounter struct {
+	mu        sync.Mutex
+	commits   int
+	rollbacks int
+}
+
+func (c *txCounter) counts() (commits, rollbacks int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.commits, c.rollbacks
+}
+
+// txTestDB returns a DB whose transactions only record commit/rollback; statements fail, so the
+// repository must be faked. It lets tests drive repository.WithTransaction without Postgres.
+func txTestDB(t *testing.T) (*gorm.DB, *txCounter) {
+	t.Helper()
+	counter := &txCounter{}
+	conn := sql.OpenDB(txConnector{counter})
+	t.Cleanup(func() { conn.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open tx db: %v", err)
+	}
+	return db, counter
+}
+
+type txConnector struct{ counter *txCounter }
+
+func (c txConnector) Connect(context.Context) (driver.Conn, error) { return txConn(c), nil }
+func (c txConnector) Driver() driver.Driver                        { return nil }
+
+type txConn struct{ counter *txCounter }
+
+func (c txConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("txTestDB runs no statements")
+}
+func (c txConn) Close() error              { return nil }
+func (c txConn) Begin() (driver.Tx, error) { return txTx(c), nil }
+
+type txTx struct{ counter *txCounter }
+
+func (t txTx) Commit() error {
+	t.counter.mu.Lock()
+	defer t.counter.mu.Unlock()
+	t.counter.commits++
+	return nil
+}
+
+func (t txTx) Rollback() error {
+	t.counter.mu.Lock()
+	defer t.counter.mu.Unlock()
+	t.counter.rollbacks++
+	return nil
+}
@@ -33,10 +33,11 @@ type GeneratedQuestion struct {
 	QuestionText     string         `gorm:"type:text;not null" json:"question_text"` // "Pilih kata yang benar..."
 	TargetLetterPair string         `gorm:"size:10" json:"target_letter_pair"`
 	TargetLetter     string         `gorm:"size:5" json:"target_letter"`
-	Options          string         `gorm:"type:text;not null" json:"options"`          // JSON array: ["BATU","DATU","MATU","SATU"]
-	CorrectAnswer    string         `gorm:"size:100;not null" json:"correct_answer"`    // BATU
-	GeneratedBy      string         `gorm:"size:20;default:gemini" json:"generated_by"` // gemini, fallback
-	UsageCount       int            `gorm:"default:0" json:"usage_count"`               // berapa kali dipakai
+	Options          string         `gorm:"type:text;not null" json:"options"`           // JSON array: ["BATU","DATU","MATU","SATU"]
+	CorrectAnswer    string         `gorm:"size:100;not null" json:"correct_answer"`     // BATU
+	GeneratedBy      string         `gorm:"size:150;default:gemini" json:"generated_by"` // provider/model, template, manual
+	UsageCount       int            `gorm:"default:0" json:"usage_count"`                // berapa kali dipakai
+	Prompt           *string        `gorm:"type:text" json:"prompt,omitempty"`           // prompt LLM (llm.store_prompts)
 	CreatedAt        time.Time      `json:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at"`
 	DeletedAt        gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
@@ -46,6 +47,10 @@ func (GeneratedQuestion) TableName() string {
 	return "generated_questions"
 }
 
+// GeneratedByAdHoc menandai soal sintetis dari integrasi luar (answers.allow_adhoc): hanya untuk penilaian,
+// tidak pernah disajikan dari cache
+const GeneratedByAdHoc = "adhoc"
+
 // UserAnswer - Jawaban user untuk setiap soal
 type UserAnswer struct {
 	ID            uint           `gorm:"primarykey" json:"id"`
@@ -55,12 +60,19 @@ type UserAnswer struct {
 	UserAnswer    string         `gorm:"size:100;not null" json:"user_answer"`       // jawaban user
 	CorrectAnswer string         `gorm:"size:100;not null" json:"correct_answer"`    // jawaban yang benar
 	IsCorrect     bool           `gorm:"not null" json:"is_correct"`                 // benar/salah
+	Skipped       bool           `gorm:"not null;default:false" json:"skipped"`      // dilewati ("tidak tahu"), bukan salah huruf
+	PartialCredit float64        `gorm:"not null;default:0" json:"partial_credit"`   // skor 0-1 berdasarkan edit distance
+	AttemptNumber int            `gorm:"not null;default:1" json:"attempt_number"`   // percobaan ke-n (allow_reattempt)
 	QuestionText  string         `gorm:"type:text" json:"question_text"`             // soal yang dijawab
 	Difficulty    string         `gorm:"size:20;index" json:"difficulty"`            // difficulty soal
 	AnsweredAt    time.Time      `gorm:"autoCreateTime" json:"answered_at"`          // waktu jawab
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	// Koreksi salah klik dalam answers.correction_window_seconds setelah jawaban pertama
+	PreviousAnswer *string    `gorm:"size:100" json:"previous_answer,omitempty"` // jawaban sebelum dikoreksi
+	CorrectedAt    *time.Time `json:"corrected_at,omitempty"`
 }
 
 func (UserAnswer) TableName() string {
@@ -74,12 +86,14 @@ type SessionAnalysisCache struct {
 	TotalQuestions  int            `gorm:"not null" json:"total_questions"`
 	CorrectAnswers  int            `gorm:"not null" json:"correct_answers"`
 	WrongAnswers    int            `gorm:"not null" json:"wrong_answers"`
+	SessionScore    float64        `gorm:"not null;default:0" json:"session_score"`
 	AccuracyRate    string         `gorm:"size:20" json:"accuracy_rate"`
 	OverallValue    string         `gorm:"size:50" json:"overall_value"`
 	AIAnalysis      string         `gorm:"type:text" json:"ai_analysis"`
 	Recommendations string         `gorm:"type:text" json:"recommendations"`
 	ErrorPatterns   string         `gorm:"type:text" json:"error_patterns"`   // JSON array of error patterns
 	DifficultyStats string         `gorm:"type:text" json:"difficulty_stats"` // JSON object of difficulty stats
+	InvalidatedAt   *time.Time     `json:"invalidated_at,omitempty"`          // answers were regraded; the next report regenerates the analysis
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
@@ -94,6 +108,7 @@ type ChatMessage struct {
 	ID                     uint           `gorm:"primarykey" json:"id"`
 	SessionID              string         `gorm:"size:100;not null;index" json:"session_id"` // session test
 	Role                   string         `gorm:"size:20;not null" json:"role"`              // user, assistant, system
+	Kind                   string         `gorm:"size:20;not null;default:chat" json:"kind"` // chat, feedback (satu per session)
 	Message                string         `gorm:"type:text;not null" json:"message"`
 	TrainingRecommendation string         `gorm:"type:text" json:"training_recommendation"` // comma-separated letter pairs: "b-d,m-w"
 	CreatedAt              time.Time      `gorm:"autoCreateTime" json:"created_at"`
@@ -101,6 +116,76 @@ type ChatMessage struct {
 	DeletedAt              gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 }
 
+const (
+	ChatMessageKindChat     = "chat"
+	ChatMessageKindFeedback = "feedback" // hasil analisis report, dibuat sekali per session
+)
+
 func (ChatMessage) TableName() string {
 	return "chat_messages"
 }
+
+// Session - Metadata sesi latihan (target jumlah soal, difficulty)
+type Session struct {
+	ID          uint           `gorm:"primarykey" json:"id"`
+	SessionID   string         `gorm:"uniqueIndex;size:100;not null" json:"session_id"`
+	UserID      string         `gorm:"size:100;not null;index" json:"user_id"`
+	TargetCount int            `gorm:"not null" json:"target_count"`       // jumlah soal yang direncanakan
+	Difficulty  string         `gorm:"size:20;not null" json:"difficulty"` // easy, medium, hard
+	ExpiredAt   *time.Time     `gorm:"index" json:"expired_at,omitempty"`  // diisi cleaner jika session ditinggalkan
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// LLMUsage - Pemakaian LLM harian per user (untuk kuota)
+type LLMUsage struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	UserID    string    `gorm:"size:100;not null;uniqueIndex:idx_llm_usage_user_date_kind" json:"user_id"`
+	UsageDate string    `gorm:"size:10;not null;uniqueIndex:idx_llm_usage_user_date_kind" json:"usage_date"` // YYYY-MM-DD
+	Kind      string    `gorm:"size:20;not null;uniqueIndex:idx_llm_usage_user_date_kind" json:"kind"`       // generate, chat
+	Count     int       `gorm:"not null;default:0" json:"count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (LLMUsage) TableName() string {
+	return "llm_usages"
+}
+
+// UserPreferences - Pengaturan profil anak, dipakai sebagai default saat generate soal
+type UserPreferences struct {
+	ID                  uint      `gorm:"primarykey" json:"id"`
+	UserID              string    `gorm:"uniqueIndex;size:100;not null" json:"user_id"`
+	PreferredDifficulty string    `gorm:"size:20" json:"preferred_difficulty"` // kosong = dyslexia.default_difficulty
+	LetterPairs         string    `gorm:"type:text" json:"letter_pairs"`       // comma-separated: "b-d,p-q" (kosong = semua)
+	Language            string    `gorm:"size:10" json:"language"`             // id, en
+	Voice               string    `gorm:"size:50" json:"voice"`                // suara TTS pilihan client
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+func (UserPreferences) TableName() string {
+	return "user_preferences"
+}
+
+// QuestionServeLog - Catatan setiap soal yang dikirim ke user oleh Generate
+type QuestionServeLog struct {
+	ID         uint           `gorm:"primarykey" json:"id"`
+	QuestionID string         `gorm:"size:100;not null;index" json:"question_id"`
+	UserID     string         `gorm:"size:100;index" json:"user_id"` // kosong jika session belum dikenal
+	SessionID  string         `gorm:"size:100;index" json:"session_id"`
+	ServedAt   time.Time      `gorm:"not null" json:"served_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	// Opsi (JSON array) dalam urutan yang dikirim ke client; answer_index mengacu ke urutan ini
+	Options string `gorm:"type:text" json:"options"`
+}
+
+func (QuestionServeLog) TableName() string {
+	return "question_serve_logs"
+}
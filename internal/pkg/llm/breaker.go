@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+	BreakerDisabled BreakerState = "disabled"
+)
+
+// ErrCircuitOpen is returned without contacting the provider while the breaker is open
+var ErrCircuitOpen = errors.New("llm circuit breaker is open")
+
+// Breaker opens after threshold consecutive provider failures, rejects calls for cooldown,
+// then half-opens and lets a single probe through to decide whether to close again.
+// A nil Breaker lets every call through.
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	state     BreakerState
+	openedAt  time.Time
+	probing   bool
+	now       func() time.Time
+}
+
+// NewBreaker returns nil (disabled) when threshold <= 0
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a call may proceed; every allowed call must be followed by Record
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		// Only one probe at a time; everyone else keeps using the fallback
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Record feeds the outcome of an allowed call back into the breaker.
+// Calls cancelled by the caller say nothing about the provider and are ignored; only provider failures
+// (see isProviderFailure) count, other errors mean the provider answered and are recorded as a success.
+func (b *Breaker) Record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if errors.Is(err, context.Canceled) {
+		if b.state == BreakerHalfOpen {
+			b.probing = false
+		}
+		return
+	}
+
+	if !isProviderFailure(err) {
+		b.failures = 0
+		b.state = BreakerClosed
+		b.probing = false
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+		b.probing = false
+	}
+}
+
+// State returns the current state; an open breaker whose cooldown elapsed reports half_open
+func (b *Breaker) State() BreakerState {
+	if b == nil {
+		return BreakerDisabled
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// isProviderFailure reports whether err says the provider is unhealthy: 5xx responses, timeouts
+// (including 408) and transport errors. Other 4xx responses are the request's fault, not the provider's.
+func isProviderFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	status := 0
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	default:
+		return true // deadline exceeded, connection refused, reset, DNS, ...
+	}
+	return status >= http.StatusInternalServerError || status == http.StatusRequestTimeout || status == 0
+}
@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestIsProviderFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "success", err: nil, want: false},
+		{name: "500", err: &openai.APIError{HTTPStatusCode: http.StatusInternalServerError}, want: true},
+		{name: "503 wrapped", err: fmt.Errorf("openai generate error: %w", &openai.APIError{HTTPStatusCode: http.StatusServiceUnavailable}), want: true},
+		{name: "408", err: &openai.APIError{HTTPStatusCode: http.StatusRequestTimeout}, want: true},
+		{name: "400", err: &openai.APIError{HTTPStatusCode: http.StatusBadRequest}, want: false},
+		{name: "401", err: &openai.APIError{HTTPStatusCode: http.StatusUnauthorized}, want: false},
+		{name: "429", err: &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests}, want: false},
+		{name: "non-json 502", err: &openai.RequestError{HTTPStatusCode: http.StatusBadGateway}, want: true},
+		{name: "non-json 404", err: &openai.RequestError{HTTPStatusCode: http.StatusNotFound}, want: false},
+		{name: "deadline", err: context.DeadlineExceeded, want: true},
+		{name: "transport", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isProviderFailure(tt.err); got != tt.want {
+				t.Errorf("isProviderFailure(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakerStates(t *testing.T) {
+	serverErr := &openai.APIError{HTTPStatusCode: http.StatusInternalServerError}
+	clientErr := &openai.APIError{HTTPStatusCode: http.StatusBadRequest}
+	tests := []struct {
+		name    string
+		records []error
+		elapse  time.Duration
+		want    BreakerState
+	}{
+		{name: "below threshold", records: []error{serverErr}, want: BreakerClosed},
+		{name: "opens at threshold", records: []error{serverErr, serverErr}, want: BreakerOpen},
+		{name: "4xx never opens", records: []error{clientErr, clientErr, clientErr}, want: BreakerClosed},
+		{name: "4xx resets the streak", records: []error{serverErr, clientErr, serverErr}, want: BreakerClosed},
+		{name: "cancel is ignored", records: []error{serverErr, context.Canceled, serverErr}, want: BreakerOpen},
+		{name: "half-opens after cooldown", records: []error{serverErr, serverErr}, elapse: time.Minute, want: BreakerHalfOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(0, 0)
+			b := NewBreaker(2, time.Minute)
+			b.now = func() time.Time { return now }
+			for _, err := range tt.records {
+				if b.Allow() == nil {
+					b.Record(err)
+				}
+			}
+			now = now.Add(tt.elapse)
+			if got := b.State(); got != tt.want {
+				t.Errorf("State() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// After the cooldown a single probe decides: a 5xx reopens, a 4xx closes since the provider answered
+func TestBreakerProbe(t *testing.T) {
+	tests := []struct {
+		name  string
+		probe error
+		want  BreakerState
+	}{
+		{name: "success closes", probe: nil, want: BreakerClosed},
+		{name: "4xx closes", probe: &openai.APIError{HTTPStatusCode: http.StatusBadRequest}, want: BreakerClosed},
+		{name: "5xx reopens", probe: &openai.APIError{HTTPStatusCode: http.StatusBadGateway}, want: BreakerOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(0, 0)
+			b := NewBreaker(1, time.Minute)
+			b.now = func() time.Time { return now }
+			_ = b.Allow()
+			b.Record(context.DeadlineExceeded)
+			if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("Allow() while open = %v, want ErrCircuitOpen", err)
+			}
+
+			now = now.Add(time.Minute)
+			if err := b.Allow(); err != nil {
+				t.Fatalf("probe Allow() = %v", err)
+			}
+			if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("second Allow() during probe = %v, want ErrCircuitOpen", err)
+			}
+			b.Record(tt.probe)
+			if got := b.State(); got != tt.want {
+				t.Errorf("State() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNilBreaker(t *testing.T) {
+	var b *Breaker
+	if NewBreaker(0, time.Minute) != nil {
+		t.Fatal("NewBreaker(0) should be nil")
+	}
+	if err := b.Allow(); err != nil {
+		t.Errorf("nil Allow() = %v", err)
+	}
+	b.Record(errors.New("boom"))
+	if got := b.State(); got != BreakerDisabled {
+		t.Errorf("nil State() = %s, want disabled", got)
+	}
+}
@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"fmt"
+	"net/url"
 
 	openai "github.com/sashabaranov/go-openai"
 )
@@ -11,7 +12,11 @@ type GeminiClient struct {
 	APIKey  string
 	BaseURL string
 	Model   string
-	client  *openai.Client
+	// JSONMode sends response_format=json_object with GenerateText. Some OpenAI-compatible proxies reject
+	// the parameter; with it off callers rely on cleaning the JSON out of the plain text reply.
+	JSONMode bool
+	client   *openai.Client
+	breaker  *Breaker
 }
 
 func NewGeminiClient(apiKey string, model string, baseURL string) *GeminiClient {
@@ -26,36 +31,65 @@ func NewGeminiClient(apiKey string, model string, baseURL string) *GeminiClient
 	config.BaseURL = baseURL
 
 	return &GeminiClient{
-		APIKey:  apiKey,
-		Model:   model,
-		BaseURL: baseURL,
-		client:  openai.NewClientWithConfig(config),
+		APIKey:   apiKey,
+		Model:    model,
+		BaseURL:  baseURL,
+		JSONMode: true,
+		client:   openai.NewClientWithConfig(config),
 	}
 }
 
+type modelContextKey struct{}
+
+// WithModel overrides the client's configured model for calls made with the returned context
+func WithModel(ctx context.Context, model string) context.Context {
+	if model == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, modelContextKey{}, model)
+}
+
+// model returns the per-call override from ctx, falling back to the configured model
+func (c *GeminiClient) model(ctx context.Context) string {
+	if model, ok := ctx.Value(modelContextKey{}).(string); ok && model != "" {
+		return model
+	}
+	return c.Model
+}
+
+// ModelFor returns the model a call made with ctx is sent to
+func (c *GeminiClient) ModelFor(ctx context.Context) string {
+	return c.model(ctx)
+}
+
+// Provider identifies the OpenAI-compatible endpoint by the host of BaseURL
+func (c *GeminiClient) Provider() string {
+	if u, err := url.Parse(c.BaseURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return c.BaseURL
+}
+
+// SetBreaker guards every provider call with b; nil disables the breaker
+func (c *GeminiClient) SetBreaker(b *Breaker) {
+	c.breaker = b
+}
+
+func (c *GeminiClient) Breaker() *Breaker {
+	return c.breaker
+}
+
 func (c *GeminiClient) GenerateText(ctx context.Context, prompt string) (string, error) {
 	if c.client == nil {
 		return "", fmt.Errorf("client not initialized")
 	}
 
-	resp, err := c.client.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model: c.Model,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: prompt,
-				},
-			},
-			Temperature: 0.3,
-			TopP:        0.95,
-			MaxTokens:   2048 * 4,
-			ResponseFormat: &openai.ChatCompletionResponseFormat{
-				Type: openai.ChatCompletionResponseFormatTypeJSONObject,
-			},
-		},
-	)
+	if err := c.breaker.Allow(); err != nil {
+		return "", err
+	}
+
+	resp, err := c.client.CreateChatCompletion(ctx, c.textRequest(ctx, prompt))
+	c.breaker.Record(err)
 	if err != nil {
 		return "", fmt.Errorf("openai generate error: %w", err)
 	}
@@ -72,16 +106,42 @@ func (c *GeminiClient) GenerateText(ctx context.Context, prompt string) (string,
 	return text, nil
 }
 
+// textRequest builds the GenerateText request; response_format is only set in JSONMode
+func (c *GeminiClient) textRequest(ctx context.Context, prompt string) openai.ChatCompletionRequest {
+	req := openai.ChatCompletionRequest{
+		Model: c.model(ctx),
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt,
+			},
+		},
+		Temperature: 0.3,
+		TopP:        0.95,
+		MaxTokens:   2048 * 4,
+	}
+	if c.JSONMode {
+		req.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		}
+	}
+	return req
+}
+
 // GenerateChatResponse generates plain text response for chatbot (no JSON formatting)
 func (c *GeminiClient) GenerateChatResponse(ctx context.Context, messages []openai.ChatCompletionMessage) (string, error) {
 	if c.client == nil {
 		return "", fmt.Errorf("client not initialized")
 	}
 
+	if err := c.breaker.Allow(); err != nil {
+		return "", err
+	}
+
 	resp, err := c.client.CreateChatCompletion(
 		ctx,
 		openai.ChatCompletionRequest{
-			Model:       c.Model,
+			Model:       c.model(ctx),
 			Messages:    messages,
 			Temperature: 0.7,
 			TopP:        0.95,
@@ -89,6 +149,7 @@ func (c *GeminiClient) GenerateChatResponse(ctx context.Context, messages []open
 			// No ResponseFormat - allow plain text response
 		},
 	)
+	c.breaker.Record(err)
 	if err != nil {
 		return "", fmt.Errorf("openai chat error: %w", err)
 	}
@@ -104,3 +165,28 @@ func (c *GeminiClient) GenerateChatResponse(ctx context.Context, messages []open
 
 	return text, nil
 }
+
+// Moderate reports whether the moderation endpoint flags text as unsafe
+func (c *GeminiClient) Moderate(ctx context.Context, text string) (bool, error) {
+	if c.client == nil {
+		return false, fmt.Errorf("client not initialized")
+	}
+
+	if err := c.breaker.Allow(); err != nil {
+		return false, err
+	}
+
+	resp, err := c.client.Moderations(ctx, openai.ModerationRequest{Input: text})
+	c.breaker.Record(err)
+	if err != nil {
+		return false, fmt.Errorf("openai moderation error: %w", err)
+	}
+
+	for _, result := range resp.Results {
+		if result.Flagged {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
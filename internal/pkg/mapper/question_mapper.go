@@ -2,6 +2,7 @@ package mapper
 
 import (
 	"encoding/json"
+	"strings"
 
 	oldEntity "github.com/evandrarf/dinacom-be/internal/delivery/http/entity"
 	dbEntity "github.com/evandrarf/dinacom-be/internal/entity"
@@ -16,10 +17,25 @@ func ConvertToQuestionTemplate(dbTemplate *dbEntity.QuestionBankTemplate) (oldEn
 
 	return oldEntity.QuestionTemplate{
 		ID:               dbTemplate.TemplateID,
-		Difficulty:       oldEntity.Difficulty(dbTemplate.Difficulty),
+		Difficulty:       oldEntity.NormalizeDifficulty(dbTemplate.Difficulty),
 		TargetLetterPair: dbTemplate.TargetLetterPair,
 		TargetLetter:     dbTemplate.TargetLetter,
 		CorrectWord:      dbTemplate.CorrectWord,
-		Distractors:      distractors,
+		Distractors:      FilterDistractors(dbTemplate.CorrectWord, distractors),
 	}, nil
 }
+
+// FilterDistractors - Drop empty, duplicate, and correct-word distractors (case-insensitive)
+func FilterDistractors(correctWord string, distractors []string) []string {
+	seen := map[string]bool{strings.ToUpper(strings.TrimSpace(correctWord)): true}
+	filtered := make([]string, 0, len(distractors))
+	for _, d := range distractors {
+		key := strings.ToUpper(strings.TrimSpace(d))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		filtered = append(filtered, d)
+	}
+	return filtered
+}
@@ -0,0 +1,43 @@
+package mapper
+
+import (
+	"slices"
+	"testing"
+
+	dbEntity "github.com/evandrarf/dinacom-be/internal/entity"
+)
+
+func TestFilterDistractors(t *testing.T) {
+	tests := []struct {
+		name        string
+		correctWord string
+		distractors []string
+		want        []string
+	}{
+		{name: "no collision", correctWord: "BOLA", distractors: []string{"DOLA", "POLA"}, want: []string{"DOLA", "POLA"}},
+		{name: "correct word dropped", correctWord: "BOLA", distractors: []string{"DOLA", "BOLA", "POLA"}, want: []string{"DOLA", "POLA"}},
+		{name: "case-insensitive", correctWord: "bola", distractors: []string{" Bola ", "dola"}, want: []string{"dola"}},
+		{name: "duplicates and blanks dropped", correctWord: "BOLA", distractors: []string{"DOLA", "dola", "", "  "}, want: []string{"DOLA"}},
+		{name: "only collisions", correctWord: "BOLA", distractors: []string{"BOLA", "bola"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		if got := FilterDistractors(tt.correctWord, tt.distractors); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: FilterDistractors = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// A stored template whose distractor duplicates the correct word is repaired on load
+func TestConvertToQuestionTemplateDropsCorrectWord(t *testing.T) {
+	tpl, err := ConvertToQuestionTemplate(&dbEntity.QuestionBankTemplate{
+		TemplateID:  "tpl-1",
+		CorrectWord: "BOLA",
+		Distractors: `["DOLA","bola","POLA"]`,
+	})
+	if err != nil {
+		t.Fatalf("ConvertToQuestionTemplate: %v", err)
+	}
+	if want := []string{"DOLA", "POLA"}; !slices.Equal(tpl.Distractors, want) {
+		t.Errorf("distractors = %q, want %q", tpl.Distractors, want)
+	}
+}
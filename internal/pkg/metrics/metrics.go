@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Registry is a minimal goroutine-safe set of named counters
+type Registry struct {
+	mu       sync.Mutex
+	counters map[string]int64
+}
+
+func NewRegistry() *Registry {
+	return &Registry{counters: make(map[string]int64)}
+}
+
+// Inc increments counter name and returns the new value
+func (r *Registry) Inc(name string) int64 {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counters[name]++
+	return r.counters[name]
+}
+
+func (r *Registry) Get(name string) int64 {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counters[name]
+}
+
+// Snapshot returns a copy of all counters
+func (r *Registry) Snapshot() map[string]int64 {
+	snapshot := make(map[string]int64)
+	if r == nil {
+		return snapshot
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, v := range r.counters {
+		snapshot[k] = v
+	}
+	return snapshot
+}
+
+// WriteText writes every counter in the Prometheus text exposition format, sorted by name
+func (r *Registry) WriteText(w io.Writer) error {
+	snapshot := r.Snapshot()
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, err := fmt.Fprintf(w, "# TYPE %s counter\n%s %d\n", name, name, snapshot[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
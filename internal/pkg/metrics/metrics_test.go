@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	tests := []struct {
+		name string
+		incs []string
+		want string
+	}{
+		{name: "empty", want: ""},
+		{
+			name: "sorted counters",
+			incs: []string{"llm_parse_failures_generate", "llm_parse_failures_analysis", "llm_parse_failures_generate"},
+			want: "# TYPE llm_parse_failures_analysis counter\nllm_parse_failures_analysis 1\n" +
+				"# TYPE llm_parse_failures_generate counter\nllm_parse_failures_generate 2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			for _, name := range tt.incs {
+				r.Inc(name)
+			}
+			var out strings.Builder
+			if err := r.WriteText(&out); err != nil {
+				t.Fatalf("WriteText: %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("WriteText =\n%s\nwant\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNilRegistry(t *testing.T) {
+	var r *Registry
+	if r.Inc("x") != 0 || r.Get("x") != 0 || len(r.Snapshot()) != 0 {
+		t.Error("nil registry should count nothing")
+	}
+	var out strings.Builder
+	if err := r.WriteText(&out); err != nil || out.Len() != 0 {
+		t.Errorf("nil WriteText = %q, %v", out.String(), err)
+	}
+}
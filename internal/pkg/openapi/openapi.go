@@ -0,0 +1,240 @@
+package openapi
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Spec is a minimal OpenAPI 3 document builder. Schemas are derived from Go types via their json tags,
+// so the published contract follows the entity structs instead of drifting from them.
+type Spec struct {
+	doc     map[string]any
+	paths   map[string]map[string]any
+	schemas map[string]any
+}
+
+type Param struct {
+	Name        string
+	In          string // path, query, header
+	Type        string // string, integer, boolean
+	Required    bool
+	Description string
+}
+
+type Operation struct {
+	Summary     string
+	Tag         string
+	Params      []Param
+	Body        any // zero value of the request struct, nil = no body
+	Response    any // zero value of the response data, nil = no data
+	Paginated   bool
+	AdminOnly   bool
+	Description string
+}
+
+var fiberParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+func NewSpec(title string, version string) *Spec {
+	s := &Spec{
+		paths:   make(map[string]map[string]any),
+		schemas: make(map[string]any),
+	}
+	s.doc = map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   title,
+			"version": version,
+		},
+		"paths": s.paths,
+		"components": map[string]any{
+			"schemas": s.schemas,
+			"securitySchemes": map[string]any{
+				"AdminToken": map[string]any{"type": "apiKey", "in": "header", "name": "X-Admin-Token"},
+			},
+		},
+	}
+	return s
+}
+
+// Add registers an operation; path uses fiber syntax (/sessions/:session_id)
+func (s *Spec) Add(method string, path string, op Operation) {
+	path = fiberParam.ReplaceAllString(path, "{$1}")
+	if s.paths[path] == nil {
+		s.paths[path] = make(map[string]any)
+	}
+
+	operation := map[string]any{
+		"summary": op.Summary,
+		"responses": map[string]any{
+			"200": s.response(op.Response, op.Paginated),
+			"400": map[string]any{
+				"description": "Invalid request",
+				"content":     jsonContent(s.envelope(nil, false)),
+			},
+		},
+	}
+	if op.Tag != "" {
+		operation["tags"] = []string{op.Tag}
+	}
+	if op.Description != "" {
+		operation["description"] = op.Description
+	}
+	if op.AdminOnly {
+		operation["security"] = []map[string]any{{"AdminToken": []string{}}}
+	}
+
+	if len(op.Params) > 0 {
+		params := make([]map[string]any, 0, len(op.Params))
+		for _, p := range op.Params {
+			param := map[string]any{
+				"name":     p.Name,
+				"in":       p.In,
+				"required": p.Required || p.In == "path",
+				"schema":   map[string]any{"type": p.Type},
+			}
+			if p.Description != "" {
+				param["description"] = p.Description
+			}
+			params = append(params, param)
+		}
+		operation["parameters"] = params
+	}
+
+	if op.Body != nil {
+		operation["requestBody"] = map[string]any{
+			"required": true,
+			"content":  jsonContent(s.Schema(reflect.TypeOf(op.Body))),
+		}
+	}
+
+	s.paths[path][strings.ToLower(method)] = operation
+}
+
+// Document returns the spec ready to be encoded as JSON
+func (s *Spec) Document() map[string]any {
+	return s.doc
+}
+
+func (s *Spec) response(data any, paginated bool) map[string]any {
+	return map[string]any{
+		"description": "Success",
+		"content":     jsonContent(s.envelope(data, paginated)),
+	}
+}
+
+// envelope mirrors response.Response: {success, message, error, data, meta}
+func (s *Spec) envelope(data any, paginated bool) map[string]any {
+	properties := map[string]any{
+		"success": map[string]any{"type": "boolean"},
+		"message": map[string]any{"type": "string"},
+		"error":   map[string]any{"description": "error message or map of field errors"},
+	}
+	if data != nil {
+		properties["data"] = s.Schema(reflect.TypeOf(data))
+	}
+	if paginated {
+		properties["meta"] = map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"total":    map[string]any{"type": "integer"},
+				"page":     map[string]any{"type": "integer"},
+				"per_page": map[string]any{"type": "integer"},
+				"has_more": map[string]any{"type": "boolean"},
+			},
+		}
+	}
+	return map[string]any{"type": "object", "properties": properties}
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Schema converts a Go type into an OpenAPI schema; named structs become component references
+func (s *Spec) Schema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := s.Schema(t.Elem())
+		if _, isRef := schema["$ref"]; isRef {
+			return map[string]any{"allOf": []any{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": s.Schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.Schema(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return map[string]any{"type": "string", "format": "date-time"}
+		}
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		if _, ok := s.schemas[t.Name()]; !ok {
+			s.schemas[t.Name()] = map[string]any{} // placeholder guards against recursive types
+			s.schemas[t.Name()] = s.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]any{}
+	}
+}
+
+func (s *Spec) structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		name := strings.SplitN(tag, ",", 2)[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = s.Schema(field.Type)
+		if isRequired(field.Tag.Get("validate")) {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// isRequired matches the plain "required" rule only; conditional ones (required_unless, ...) stay optional
+func isRequired(validate string) bool {
+	rules := strings.Split(validate, ",")
+	if len(rules) > 0 && rules[0] == "omitempty" {
+		return false
+	}
+	for _, rule := range rules {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package openapi
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+type sample struct {
+	ID       string     `json:"id" validate:"required"`
+	Count    int        `json:"count,omitempty" validate:"omitempty,min=1"`
+	Tags     []string   `json:"tags"`
+	Seen     *time.Time `json:"seen"`
+	Internal string     `json:"-"`
+	Child    *sample    `json:"child"`
+}
+
+func TestSchemaFollowsJSONTags(t *testing.T) {
+	s := NewSpec("test", "1.0.0")
+	ref := s.Schema(reflect.TypeOf(sample{}))
+	if ref["$ref"] != "#/components/schemas/sample" {
+		t.Fatalf("named struct schema = %v, want a component reference", ref)
+	}
+
+	schema := s.schemas["sample"].(map[string]any)
+	properties := schema["properties"].(map[string]any)
+	want := []string{"child", "count", "id", "seen", "tags"}
+	var got []string
+	for name := range properties {
+		got = append(got, name)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Errorf("properties = %v, want %v", got, want)
+	}
+	if required := schema["required"].([]string); !slices.Equal(required, []string{"id"}) {
+		t.Errorf("required = %v, want [id]", required)
+	}
+	if seen := properties["seen"].(map[string]any); seen["format"] != "date-time" || seen["nullable"] != true {
+		t.Errorf("seen = %v, want a nullable date-time", seen)
+	}
+	if tags := properties["tags"].(map[string]any); tags["type"] != "array" {
+		t.Errorf("tags = %v, want an array", tags)
+	}
+}
+
+func TestAddConvertsFiberPaths(t *testing.T) {
+	s := NewSpec("test", "1.0.0")
+	s.Add("GET", "/sessions/:session_id/resume", Operation{
+		Summary: "Resume", AdminOnly: true,
+		Params: []Param{{Name: "session_id", In: "path", Type: "string"}},
+	})
+
+	op, ok := s.paths["/sessions/{session_id}/resume"]["get"].(map[string]any)
+	if !ok {
+		t.Fatalf("paths = %v, want /sessions/{session_id}/resume", s.paths)
+	}
+	params := op["parameters"].([]map[string]any)
+	if len(params) != 1 || params[0]["required"] != true {
+		t.Errorf("parameters = %v, want the path param required", params)
+	}
+	if _, ok := op["security"]; !ok {
+		t.Error("admin operation has no security requirement")
+	}
+}
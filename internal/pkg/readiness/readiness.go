@@ -0,0 +1,22 @@
+package readiness
+
+import "sync/atomic"
+
+// Flag reports whether startup work (migrations, seeding) has finished. The zero value is not ready.
+type Flag struct {
+	ready atomic.Bool
+}
+
+func New() *Flag {
+	return &Flag{}
+}
+
+func (f *Flag) SetReady() {
+	if f != nil {
+		f.ready.Store(true)
+	}
+}
+
+func (f *Flag) IsReady() bool {
+	return f != nil && f.ready.Load()
+}
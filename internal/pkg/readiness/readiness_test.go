@@ -0,0 +1,35 @@
+package readiness
+
+import (
+	"sync"
+	"testing"
+)
+
+// The flag starts not ready, flips once startup finishes and is read safely from request goroutines
+func TestFlag(t *testing.T) {
+	f := New()
+	if f.IsReady() {
+		t.Fatal("new flag is ready before seeding finished")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = f.IsReady()
+		}()
+	}
+	f.SetReady()
+	wg.Wait()
+	if !f.IsReady() {
+		t.Error("flag not ready after SetReady")
+	}
+
+	// Handlers built without a flag (e.g. in tests) report not ready instead of panicking
+	var none *Flag
+	none.SetReady()
+	if none.IsReady() {
+		t.Error("nil flag reports ready")
+	}
+}
@@ -0,0 +1,22 @@
+package response
+
+// PaginationMeta is the meta shape shared by every list endpoint
+type PaginationMeta struct {
+	Total   int64 `json:"total"`
+	Page    int   `json:"page"`
+	PerPage int   `json:"per_page"`
+	HasMore bool  `json:"has_more"`
+}
+
+func NewPaginationMeta(total int64, page, perPage int) PaginationMeta {
+	return PaginationMeta{
+		Total:   total,
+		Page:    page,
+		PerPage: perPage,
+		HasMore: int64(page)*int64(perPage) < total,
+	}
+}
+
+func NewPaginatedSuccess(msg string, data any, meta PaginationMeta) *Response {
+	return NewSuccess(msg, data, meta)
+}
@@ -0,0 +1,82 @@
+package response
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPaginationMetaJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		page    int
+		perPage int
+		want    string
+	}{
+		{name: "more pages", total: 120, page: 1, perPage: 50, want: `{"total":120,"page":1,"per_page":50,"has_more":true}`},
+		{name: "exact last page", total: 100, page: 2, perPage: 50, want: `{"total":100,"page":2,"per_page":50,"has_more":false}`},
+		{name: "past the end", total: 3, page: 5, perPage: 10, want: `{"total":3,"page":5,"per_page":10,"has_more":false}`},
+		{name: "empty", page: 1, perPage: 10, want: `{"total":0,"page":1,"per_page":10,"has_more":false}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(NewPaginationMeta(tt.total, tt.page, tt.perPage))
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("meta = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// The paginated envelope carries the typed meta next to the data
+func TestNewPaginatedSuccess(t *testing.T) {
+	res := NewPaginatedSuccess("ok", []string{"a"}, NewPaginationMeta(1, 1, 10))
+	got, err := json.Marshal(res)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `{"success":true,"message":"ok","data":["a"],"meta":{"total":1,"page":1,"per_page":10,"has_more":false}}`
+	if string(got) != want || res.StatusCode != 200 {
+		t.Errorf("response = %d %s, want 200 %s", res.StatusCode, got, want)
+	}
+}
+
+// A nil slice encodes as [] so clients never have to handle null lists
+func TestNewSuccessEmptySlice(t *testing.T) {
+	var items []string
+	got, err := json.Marshal(NewPaginatedSuccess("ok", items, NewPaginationMeta(0, 1, 10)))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `{"success":true,"message":"ok","data":[],"meta":{"total":0,"page":1,"per_page":10,"has_more":false}}`
+	if string(got) != want {
+		t.Errorf("response = %s, want %s", got, want)
+	}
+}
+
+// A typed nil meta (an optional *Meta) is omitted rather than encoded as null
+func TestNewSuccessNilMeta(t *testing.T) {
+	type meta struct {
+		Reason string `json:"reason"`
+	}
+	var none *meta
+	tests := []struct {
+		meta any
+		want string
+	}{
+		{meta: none, want: `{"success":true,"message":"ok","data":["a"]}`},
+		{meta: &meta{Reason: "short"}, want: `{"success":true,"message":"ok","data":["a"],"meta":{"reason":"short"}}`},
+	}
+	for _, tt := range tests {
+		got, err := json.Marshal(NewSuccess("ok", []string{"a"}, tt.meta))
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("response = %s, want %s", got, tt.want)
+		}
+	}
+}
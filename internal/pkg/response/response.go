@@ -1,6 +1,8 @@
 package response
 
 import (
+	"reflect"
+
 	"github.com/evandrarf/dinacom-be/internal/pkg/validate"
 	"github.com/gofiber/fiber/v2"
 
@@ -54,8 +56,8 @@ func NewSuccess(msg string, data any, meta any) *Response {
 		Success:    true,
 		Message:    msg,
 		StatusCode: fiber.StatusOK,
-		Data:       data,
-		Meta:       meta,
+		Data:       emptySliceIfNil(data),
+		Meta:       omitNilPointer(meta),
 	}
 
 	return res
@@ -64,3 +66,20 @@ func NewSuccess(msg string, data any, meta any) *Response {
 func (r *Response) Send(ctx *fiber.Ctx) error {
 	return ctx.Status(r.StatusCode).JSON(r)
 }
+
+// omitNilPointer drops a typed nil pointer (e.g. an optional *Meta) so "meta" is omitted instead of encoded as null
+func omitNilPointer(v any) any {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+	return v
+}
+
+// emptySliceIfNil turns a nil slice into an empty one so list endpoints always encode [] instead of null
+func emptySliceIfNil(data any) any {
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		return reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+	return data
+}
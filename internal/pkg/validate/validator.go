@@ -2,6 +2,7 @@ package validate
 
 import (
 	"reflect"
+	"regexp"
 	"strings"
 
 	"github.com/go-playground/locales/en"
@@ -11,6 +12,12 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// sessionIDPattern accepts client-generated ids such as UUIDs or slug-like tokens
+var sessionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// userIDPattern fits the user_id columns (size:100) and keeps ids usable as a single path segment
+var userIDPattern = regexp.MustCompile(`^[^\s/]{1,100}$`)
+
 type Validator struct {
 	validate *validator.Validate
 	trans    ut.Translator
@@ -29,22 +36,102 @@ func NewValidator() *Validator {
 	validator.RegisterTagNameFunc(func(fld reflect.StructField) string {
 		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
 		if name == "-" {
-			return ""
+			// Fields bound from the route instead of the body
+			return fld.Tag.Get("params")
 		}
 		return name
 	})
 
+	registerCustomRules(validator, trans)
+
 	return &Validator{
 		validate: validator,
 		trans:    trans,
 	}
 }
 
+// registerCustomRules adds project-specific tags together with their english messages
+func registerCustomRules(v *validator.Validate, trans ut.Translator) {
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+	v.RegisterValidation("session_id", func(fl validator.FieldLevel) bool {
+		return sessionIDPattern.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("user_id", func(fl validator.FieldLevel) bool {
+		return userIDPattern.MatchString(fl.Field().String())
+	})
+
+	registerMessage(v, trans, "notblank", "{0} cannot be blank")
+	registerMessage(v, trans, "session_id", "{0} must be 1-64 characters of letters, digits, '-' or '_'")
+	registerMessage(v, trans, "user_id", "{0} must be 1-100 characters without spaces or '/'")
+}
+
+// RegisterRule adds a string rule whose valid values are only known at runtime (e.g. configured difficulties)
+func (v *Validator) RegisterRule(tag string, valid func(value string) bool, message string) {
+	v.validate.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+		return valid(fl.Field().String())
+	})
+	registerMessage(v.validate, v.trans, tag, message)
+}
+
+func registerMessage(v *validator.Validate, trans ut.Translator, tag string, message string) {
+	v.RegisterTranslation(tag, trans, func(ut ut.Translator) error {
+		return ut.Add(tag, message, true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T(tag, fe.Field())
+		return t
+	})
+}
+
 func (v *Validator) ParseAndValidate(ctx *fiber.Ctx, req interface{}) error {
 	if err := ctx.BodyParser(req); err != nil {
 		return err
 	}
 
+	return v.Validate(req)
+}
+
+// ParseParamsAndValidate parses the body and route params (`params` tag) before validating
+func (v *Validator) ParseParamsAndValidate(ctx *fiber.Ctx, req interface{}) error {
+	if err := ctx.BodyParser(req); err != nil {
+		return err
+	}
+	if err := ctx.ParamsParser(req); err != nil {
+		return err
+	}
+
+	return v.Validate(req)
+}
+
+// ParseRouteAndValidate parses route params only (`params` tag), for requests without a body
+func (v *Validator) ParseRouteAndValidate(ctx *fiber.Ctx, req interface{}) error {
+	if err := ctx.ParamsParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return v.Validate(req)
+}
+
+// Normalizer is implemented by requests that clean up bound values (trim, lowercase, split) before validation
+type Normalizer interface {
+	Normalize()
+}
+
+// ParseQueryAndValidate binds query params (`query` tag) into req, normalizes and validates it
+func (v *Validator) ParseQueryAndValidate(ctx *fiber.Ctx, req interface{}) error {
+	if err := ctx.QueryParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return v.Validate(req)
+}
+
+func (v *Validator) Validate(req interface{}) error {
+	if n, ok := req.(Normalizer); ok {
+		n.Normalize()
+	}
+
 	err := v.validate.Struct(req)
 	if err == nil {
 		return nil
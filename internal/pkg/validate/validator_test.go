@@ -0,0 +1,120 @@
+package validate
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type preferencesRequest struct {
+	UserID   string `json:"-" params:"user_id" validate:"required,user_id"`
+	Language string `json:"language" validate:"omitempty,oneof=id en"`
+}
+
+// ParseParamsAndValidate binds the route's user_id next to the body and validates both
+func TestParseParamsAndValidateUserID(t *testing.T) {
+	v := NewValidator()
+	app := fiber.New()
+	app.Put("/users/:user_id/preferences", func(ctx *fiber.Ctx) error {
+		var req preferencesRequest
+		if err := v.ParseParamsAndValidate(ctx, &req); err != nil {
+			if fields, ok := err.(*FieldsError); ok {
+				return ctx.Status(fiber.StatusBadRequest).JSON(fields.Fields)
+			}
+			return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+		}
+		return ctx.SendString(req.UserID + "|" + req.Language)
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "valid", path: "/users/user-1/preferences", body: `{"language":"en"}`, wantStatus: 200, wantBody: "user-1|en"},
+		{name: "email-like id", path: "/users/ana@example.com/preferences", body: `{}`, wantStatus: 200, wantBody: "ana@example.com|"},
+		{name: "too long id", path: "/users/" + strings.Repeat("a", 101) + "/preferences", body: `{}`, wantStatus: 400, wantBody: "user_id must be 1-100 characters"},
+		{name: "invalid body field", path: "/users/user-1/preferences", body: `{"language":"fr"}`, wantStatus: 400, wantBody: "language"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus || !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("got %d %q, want %d containing %q", resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
+
+type listQuery struct {
+	Count   int      `query:"count" json:"count" validate:"omitempty,min=1,max=10"`
+	Verbose bool     `query:"verbose" json:"verbose"`
+	UseAI   *bool    `query:"use_ai" json:"use_ai"`
+	Tags    []string `query:"tag" json:"tag" validate:"omitempty,dive,required"`
+	Name    string   `query:"name" json:"name"`
+}
+
+func (q *listQuery) Normalize() {
+	q.Name = strings.ToLower(strings.TrimSpace(q.Name))
+}
+
+// ParseQueryAndValidate binds ints, bools and repeated values, normalizes, then validates
+func TestParseQueryAndValidate(t *testing.T) {
+	v := NewValidator()
+	app := fiber.New()
+	app.Get("/list", func(ctx *fiber.Ctx) error {
+		var q listQuery
+		if err := v.ParseQueryAndValidate(ctx, &q); err != nil {
+			if fields, ok := err.(*FieldsError); ok {
+				return ctx.Status(fiber.StatusBadRequest).JSON(fields.Fields)
+			}
+			return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+		}
+		useAI := "unset"
+		if q.UseAI != nil {
+			useAI = strconv.FormatBool(*q.UseAI)
+		}
+		return ctx.SendString(fmt.Sprintf("%d|%v|%s|%s|%s", q.Count, q.Verbose, useAI, strings.Join(q.Tags, "+"), q.Name))
+	})
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "defaults", query: "", wantStatus: 200, wantBody: "0|false|unset||"},
+		{name: "typed values", query: "?count=3&verbose=true&use_ai=false&name=%20Ana%20", wantStatus: 200, wantBody: "3|true|false||ana"},
+		{name: "repeated values", query: "?tag=b-d&tag=p-q", wantStatus: 200, wantBody: "0|false|unset|b-d+p-q|"},
+		{name: "comma kept for Normalize", query: "?tag=b-d,p-q", wantStatus: 200, wantBody: "0|false|unset|b-d,p-q|"},
+		{name: "bool as 1", query: "?verbose=1", wantStatus: 200, wantBody: "0|true|unset||"},
+		{name: "int out of range", query: "?count=11", wantStatus: 400, wantBody: "count"},
+		{name: "malformed int", query: "?count=abc", wantStatus: 400},
+		{name: "malformed bool", query: "?verbose=maybe", wantStatus: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", "/list"+tt.query, nil))
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus || (tt.wantStatus == 200 && string(body) != tt.wantBody) || !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("got %d %q, want %d with %q", resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	SignatureHeader      = "X-Webhook-Signature" // "sha256=<hex HMAC-SHA256 of the raw body>"
+	EventHeader          = "X-Webhook-Event"
+	DeliveryHeader       = "X-Webhook-Delivery" // unique per Send, identical across its retries
+	IdempotencyKeyHeader = "X-Idempotency-Key"  // caller's key, or the delivery id when none is given
+)
+
+type Client struct {
+	URL        string
+	Secret     string
+	MaxRetries int
+	Backoff    time.Duration
+	httpClient *http.Client
+}
+
+func NewClient(url string, secret string, maxRetries int, timeout time.Duration) *Client {
+	if maxRetries <= 0 {
+		maxRetries = 3
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &Client{
+		URL:        url,
+		Secret:     secret,
+		MaxRetries: maxRetries,
+		Backoff:    500 * time.Millisecond,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Enabled reports whether a target URL is configured
+func (c *Client) Enabled() bool {
+	return c != nil && c.URL != ""
+}
+
+// Sign returns the SignatureHeader value for body: "sha256=" followed by the hex encoded HMAC-SHA256
+// of body using the shared secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send POSTs payload as JSON, retrying with linear backoff on network errors and non-2xx responses.
+// Every attempt carries the same delivery id and idempotency key so the receiver can drop duplicates.
+func (c *Client) Send(ctx context.Context, event string, idempotencyKey string, payload any) error {
+	if !c.Enabled() {
+		return nil
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("webhook marshal error: %w", err)
+	}
+	deliveryID, err := newDeliveryID()
+	if err != nil {
+		return fmt.Errorf("webhook delivery id error: %w", err)
+	}
+	if idempotencyKey == "" {
+		idempotencyKey = deliveryID
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= c.MaxRetries; attempt++ {
+		lastErr = c.post(ctx, event, deliveryID, idempotencyKey, body)
+		if lastErr == nil {
+			return nil
+		}
+
+		if attempt < c.MaxRetries {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * c.Backoff):
+			}
+		}
+	}
+
+	return fmt.Errorf("webhook failed after %d attempts: %w", c.MaxRetries, lastErr)
+}
+
+// newDeliveryID returns a random 128-bit hex id
+func newDeliveryID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+func (c *Client) post(ctx context.Context, event string, deliveryID string, idempotencyKey string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, deliveryID)
+	req.Header.Set(IdempotencyKeyHeader, idempotencyKey)
+	if c.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(c.Secret, body))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook receiver returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
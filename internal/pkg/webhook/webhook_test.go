@@ -0,0 +1,123 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+type delivery struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver records every request and fails the first failures of them with a 500
+func receiver(t *testing.T, failures int) (*Client, func() []delivery) {
+	t.Helper()
+	var mu sync.Mutex
+	var got []delivery
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got = append(got, delivery{header: r.Header.Clone(), body: body})
+		n := len(got)
+		mu.Unlock()
+		if n <= failures {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(server.URL, "s3cret", 3, time.Second)
+	client.Backoff = time.Millisecond
+	return client, func() []delivery {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]delivery(nil), got...)
+	}
+}
+
+func TestSign(t *testing.T) {
+	got := Sign("key", []byte("The quick brown fox jumps over the lazy dog"))
+	want := "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+	if got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+}
+
+func TestSendHeaders(t *testing.T) {
+	tests := []struct {
+		name           string
+		idempotencyKey string
+		failures       int
+		wantAttempts   int
+	}{
+		{name: "caller key", idempotencyKey: "sess-1", wantAttempts: 1},
+		{name: "key defaults to delivery id", wantAttempts: 1},
+		{name: "retries reuse the delivery id", idempotencyKey: "sess-1", failures: 2, wantAttempts: 3},
+	}
+	hexID := regexp.MustCompile(`^[0-9a-f]{32}$`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, deliveries := receiver(t, tt.failures)
+			if err := client.Send(context.Background(), "session.complete", tt.idempotencyKey, map[string]string{"session_id": "sess-1"}); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+
+			got := deliveries()
+			if len(got) != tt.wantAttempts {
+				t.Fatalf("attempts = %d, want %d", len(got), tt.wantAttempts)
+			}
+			deliveryID := got[0].header.Get(DeliveryHeader)
+			if !hexID.MatchString(deliveryID) {
+				t.Fatalf("%s = %q, want 32 hex characters", DeliveryHeader, deliveryID)
+			}
+			wantKey := tt.idempotencyKey
+			if wantKey == "" {
+				wantKey = deliveryID
+			}
+			for i, d := range got {
+				if id := d.header.Get(DeliveryHeader); id != deliveryID {
+					t.Errorf("attempt %d %s = %q, want %q", i+1, DeliveryHeader, id, deliveryID)
+				}
+				if key := d.header.Get(IdempotencyKeyHeader); key != wantKey {
+					t.Errorf("attempt %d %s = %q, want %q", i+1, IdempotencyKeyHeader, key, wantKey)
+				}
+				if sig := d.header.Get(SignatureHeader); sig != Sign("s3cret", d.body) {
+					t.Errorf("attempt %d %s = %q, want the body HMAC", i+1, SignatureHeader, sig)
+				}
+				if event := d.header.Get(EventHeader); event != "session.complete" {
+					t.Errorf("attempt %d %s = %q", i+1, EventHeader, event)
+				}
+			}
+		})
+	}
+}
+
+func TestSendNewDeliveryPerCall(t *testing.T) {
+	client, deliveries := receiver(t, 0)
+	for i := 0; i < 2; i++ {
+		if err := client.Send(context.Background(), "session.complete", "sess-1", struct{}{}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	got := deliveries()
+	if got[0].header.Get(DeliveryHeader) == got[1].header.Get(DeliveryHeader) {
+		t.Error("two sends shared a delivery id")
+	}
+}
+
+func TestSendGivesUp(t *testing.T) {
+	client, deliveries := receiver(t, 10)
+	if err := client.Send(context.Background(), "session.complete", "sess-1", struct{}{}); err == nil {
+		t.Fatal("Send succeeded against a failing receiver")
+	}
+	if got := len(deliveries()); got != client.MaxRetries {
+		t.Errorf("attempts = %d, want %d", got, client.MaxRetries)
+	}
+}